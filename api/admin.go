@@ -0,0 +1,331 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper/queue"
+	"github.com/kevinburke/rest"
+)
+
+// The /admin/ routes, which need the admin scope, are for operating the
+// server from "clipper serve admin":
+//
+//	GET  /admin/jobs            scheduled jobs, with their state and history
+//	POST /admin/jobs/run        run the job named by the name parameter now
+//	GET  /admin/accounts        the last sync of each user's account
+//	GET  /admin/audit           the audit log; see audit.go
+//	POST /admin/retry           sync the accounts whose last sync failed
+//	GET  /admin/tokens          token names, scopes and cards, without the secrets
+//	POST /admin/tokens          make a token from the name, scopes and cards parameters
+//	POST /admin/tokens/revoke   remove the named token
+//	POST /admin/tokens/rotate   replace the named token with a new one
+
+// An AccountStatus describes the last sync of one user's account.
+type AccountStatus struct {
+	User        string    `json:"user"`
+	Cards       []int64   `json:"cards"`
+	LastStart   time.Time `json:"last_start,omitempty"`
+	LastFinish  time.Time `json:"last_finish,omitempty"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// Schedule syncs each of s.Users every interval, or everyone at once if
+// there are no Users, with a job queue (see the queue package) that saves
+// its jobs in the archive. Failed syncs are retried with backoff. The queue
+// runs until Shutdown.
+func (s *Server) Schedule(every time.Duration) error {
+	if !s.CanSync() {
+		return errors.New("api: can't schedule syncs without a SyncFunc")
+	}
+	q, err := queue.New(s.store, s.runJob)
+	if err != nil {
+		return err
+	}
+	names := s.userNames()
+	if len(names) == 0 {
+		names = []string{""}
+	}
+	for _, name := range names {
+		q.Add(name, every)
+	}
+	s.mu.Lock()
+	s.queue = q
+	s.mu.Unlock()
+	q.Start()
+	return nil
+}
+
+// runJob is the queue's Func. It syncs the user called name, or everyone if
+// name is empty, and returns queue.ErrBusy if another sync is running.
+func (s *Server) runJob(ctx context.Context, name string) error {
+	if !s.startSync(name) {
+		return queue.ErrBusy
+	}
+	var names []string
+	if name != "" {
+		names = []string{name}
+	}
+	return s.finishSync(ctx, names)
+}
+
+// Jobs returns the jobs scheduled with Schedule, with their recent history.
+func (s *Server) Jobs() []queue.Job {
+	s.mu.Lock()
+	q := s.queue
+	s.mu.Unlock()
+	if q == nil {
+		return []queue.Job{}
+	}
+	return q.Jobs()
+}
+
+// Accounts returns the last sync of each of s.Users, ordered by name.
+func (s *Server) Accounts() []AccountStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []AccountStatus{}
+	for _, name := range s.userNames() {
+		a := AccountStatus{User: name}
+		if last, ok := s.accounts[name]; ok {
+			a = *last
+		}
+		a.Cards = s.Users[name]
+		out = append(out, a)
+	}
+	return out
+}
+
+// RetryFailed syncs the accounts whose last sync failed, and returns their
+// names. If syncs are scheduled, it moves their jobs to the front of the
+// queue; otherwise it starts a sync, and returns false if one is already
+// running.
+func (s *Server) RetryFailed() ([]string, bool) {
+	failed := []string{}
+	for _, a := range s.Accounts() {
+		if a.LastError != "" {
+			failed = append(failed, a.User)
+		}
+	}
+	if len(failed) == 0 {
+		return failed, true
+	}
+	s.mu.Lock()
+	q := s.queue
+	s.mu.Unlock()
+	if q != nil {
+		for _, name := range failed {
+			q.RunNow(name)
+		}
+		return failed, true
+	}
+	user := ""
+	if len(failed) == 1 {
+		user = failed[0]
+	}
+	return failed, s.syncUsers(user, failed)
+}
+
+// RotateToken replaces the token named name with a new random one, keeping
+// its scopes, and returns it. If SaveTokens is set, it's called with the
+// tokens added with AddToken; otherwise the new token only lasts until the
+// server restarts. The token passed to New can't be rotated.
+func (s *Server) RotateToken(name string) (Token, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return Token{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.tokenIndexLocked(name)
+	if i < 0 {
+		return Token{}, errNoToken
+	}
+	tokens := append([]Token(nil), s.tokens...)
+	tokens[i].Token = secret
+	if err := s.setTokensLocked(tokens); err != nil {
+		return Token{}, err
+	}
+	return tokens[i], nil
+}
+
+// CreateToken adds a token with t's name, scopes and cards, and a new
+// random secret, and returns it. Like RotateToken, it calls SaveTokens.
+func (s *Server) CreateToken(t Token) (Token, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return Token{}, err
+	}
+	t.Token = secret
+	if err := t.validate(); err != nil {
+		return Token{}, fmt.Errorf("api: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, old := range s.tokens {
+		if old.Name == t.Name {
+			return Token{}, fmt.Errorf("api: there's already a token named %s", t.Name)
+		}
+	}
+	if err := s.setTokensLocked(append(s.tokens[:len(s.tokens):len(s.tokens)], t)); err != nil {
+		return Token{}, err
+	}
+	return t, nil
+}
+
+// RevokeToken removes the token named name. Like RotateToken, it calls
+// SaveTokens, and the token passed to New can't be revoked.
+func (s *Server) RevokeToken(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.tokenIndexLocked(name)
+	if i < 0 {
+		return errNoToken
+	}
+	tokens := make([]Token, 0, len(s.tokens)-1)
+	tokens = append(tokens, s.tokens[:i]...)
+	tokens = append(tokens, s.tokens[i+1:]...)
+	return s.setTokensLocked(tokens)
+}
+
+// tokenIndexLocked returns the index in s.tokens of the token named name,
+// or -1 if there isn't one, or it's the token passed to New. s.mu must be
+// held.
+func (s *Server) tokenIndexLocked(name string) int {
+	for i, t := range s.tokens {
+		if t.Name == name && !(i == 0 && s.hasDefaultToken) {
+			return i
+		}
+	}
+	return -1
+}
+
+// setTokensLocked saves tokens with SaveTokens, if it's set, and then
+// starts using them. s.mu must be held. Scopes reads s.tokens without the
+// lock, so tokens must be a new slice.
+func (s *Server) setTokensLocked(tokens []Token) error {
+	if s.SaveTokens != nil {
+		saved := tokens
+		if s.hasDefaultToken {
+			saved = saved[1:]
+		}
+		if err := s.SaveTokens(saved); err != nil {
+			return err
+		}
+	}
+	s.tokens = tokens
+	return nil
+}
+
+// newSecret returns a random token.
+func newSecret() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+var errNoToken = errors.New("api: there's no token with that name that can be changed")
+
+// A TokenInfo describes a token without giving it away.
+type TokenInfo struct {
+	Name   string  `json:"name"`
+	Scopes []Scope `json:"scopes"`
+	Cards  []int64 `json:"cards,omitempty"`
+}
+
+func (s *Server) admin(w http.ResponseWriter, r *http.Request) {
+	switch r.Method + " " + r.URL.Path {
+	case "GET /admin/jobs":
+		writeJSON(w, http.StatusOK, s.Jobs())
+	case "POST /admin/jobs/run":
+		s.mu.Lock()
+		q := s.queue
+		s.mu.Unlock()
+		if q == nil || !q.RunNow(r.FormValue("name")) {
+			rest.NotFound(w, r)
+			return
+		}
+		job, _ := q.Job(r.FormValue("name"))
+		writeJSON(w, http.StatusAccepted, job)
+	case "GET /admin/accounts":
+		writeJSON(w, http.StatusOK, s.Accounts())
+	case "POST /admin/retry":
+		if !s.CanSync() {
+			rest.Forbidden(w, r, &rest.Error{Title: "This server has no credentials to sync with", ID: "sync_unavailable"})
+			return
+		}
+		failed, ok := s.RetryFailed()
+		switch {
+		case !ok:
+			writeJSON(w, http.StatusConflict, s.SyncStatus())
+		case len(failed) == 0:
+			writeJSON(w, http.StatusOK, map[string][]string{"users": failed})
+		default:
+			writeJSON(w, http.StatusAccepted, map[string][]string{"users": failed})
+		}
+	case "GET /admin/tokens":
+		s.mu.Lock()
+		infos := make([]TokenInfo, len(s.tokens))
+		for i, t := range s.tokens {
+			infos[i] = TokenInfo{Name: t.Name, Scopes: t.Scopes, Cards: t.Cards}
+		}
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, infos)
+	case "POST /admin/tokens":
+		t := Token{Name: r.FormValue("name")}
+		for _, sc := range strings.Split(r.FormValue("scopes"), ",") {
+			if sc = strings.TrimSpace(sc); sc != "" {
+				t.Scopes = append(t.Scopes, Scope(sc))
+			}
+		}
+		for _, v := range strings.Split(r.FormValue("cards"), ",") {
+			if v = strings.TrimSpace(v); v == "" {
+				continue
+			}
+			card, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				badRequest(w, r, "Invalid card serial number %q", v)
+				return
+			}
+			t.Cards = append(t.Cards, card)
+		}
+		t, err := s.CreateToken(t)
+		if err != nil {
+			badRequest(w, r, "%s", strings.TrimPrefix(err.Error(), "api: "))
+			return
+		}
+		writeJSON(w, http.StatusCreated, t)
+	case "POST /admin/tokens/revoke":
+		if err := s.RevokeToken(r.FormValue("name")); err == errNoToken {
+			rest.NotFound(w, r)
+			return
+		} else if err != nil {
+			rest.ServerError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "POST /admin/tokens/rotate":
+		t, err := s.RotateToken(r.FormValue("name"))
+		if err == errNoToken {
+			rest.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			rest.ServerError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, t)
+	default:
+		rest.NotFound(w, r)
+	}
+}
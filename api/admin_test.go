@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/queue"
+	"github.com/kevinburke/clipper/store"
+)
+
+func adminRequest(srv *Server, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	return w
+}
+
+func waitForSync(srv *Server) {
+	for i := 0; i < 100 && srv.SyncStatus().Running; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAdminAccountsAndRetry(t *testing.T) {
+	var mu sync.Mutex
+	var synced []string
+	failBob := true
+	srv := testServer(t, func(ctx context.Context, user string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		synced = append(synced, user)
+		if user == "bob" && failBob {
+			return errors.New("login failed")
+		}
+		return nil
+	})
+	defer srv.Shutdown(context.Background())
+	srv.Users = map[string][]int64{"alice": {1202728442}, "bob": {2}}
+	if !srv.Sync() {
+		t.Fatal("Sync: returned false")
+	}
+	waitForSync(srv)
+
+	var accounts []AccountStatus
+	w := adminRequest(srv, "GET", "/admin/accounts", "secret")
+	if err := json.Unmarshal(w.Body.Bytes(), &accounts); err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 2 || accounts[0].LastError != "" || accounts[0].LastSuccess.IsZero() || accounts[1].LastError != "login failed" {
+		t.Fatalf("GET /admin/accounts: got %+v", accounts)
+	}
+
+	mu.Lock()
+	failBob, synced = false, nil
+	mu.Unlock()
+	if w := adminRequest(srv, "POST", "/admin/retry", "secret"); w.Code != 202 {
+		t.Fatalf("POST /admin/retry: got %d, want 202", w.Code)
+	}
+	waitForSync(srv)
+	mu.Lock()
+	if len(synced) != 1 || synced[0] != "bob" {
+		t.Errorf("retry synced %q, want [bob]", synced)
+	}
+	mu.Unlock()
+	if w := adminRequest(srv, "POST", "/admin/retry", "secret"); w.Code != 200 {
+		t.Errorf("POST /admin/retry with nothing failed: got %d, want 200", w.Code)
+	}
+}
+
+func TestAdminRotateToken(t *testing.T) {
+	srv := testServer(t, nil)
+	var saved []Token
+	srv.SaveTokens = func(tokens []Token) error {
+		saved = tokens
+		return nil
+	}
+	for _, tok := range []Token{
+		{Name: "ops", Token: "admin-token-0001", Scopes: []Scope{ScopeAdmin}},
+		{Name: "phone", Token: "read-only-token-1", Scopes: []Scope{ScopeRead}},
+	} {
+		if err := srv.AddToken(tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w := adminRequest(srv, "GET", "/admin/tokens", "read-only-token-1"); w.Code != 403 {
+		t.Errorf("GET /admin/tokens with a read token: got %d, want 403", w.Code)
+	}
+	if w := adminRequest(srv, "POST", "/admin/tokens/rotate?name=secret", "admin-token-0001"); w.Code != 404 {
+		t.Errorf("rotating the default token: got %d, want 404", w.Code)
+	}
+	w := adminRequest(srv, "POST", "/admin/tokens/rotate?name=phone", "admin-token-0001")
+	if w.Code != 200 {
+		t.Fatalf("POST /admin/tokens/rotate: got %d, want 200", w.Code)
+	}
+	var tok Token
+	if err := json.Unmarshal(w.Body.Bytes(), &tok); err != nil {
+		t.Fatal(err)
+	}
+	if tok.Name != "phone" || len(tok.Token) != 32 {
+		t.Errorf("rotated token: got %+v", tok)
+	}
+	if len(saved) != 2 || saved[1].Token != tok.Token {
+		t.Errorf("saved tokens: got %+v", saved)
+	}
+	if w := adminRequest(srv, "GET", "/cards", "read-only-token-1"); w.Code != 401 {
+		t.Errorf("GET /cards with the old token: got %d, want 401", w.Code)
+	}
+	if w := adminRequest(srv, "GET", "/cards", tok.Token); w.Code != 200 {
+		t.Errorf("GET /cards with the new token: got %d, want 200", w.Code)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	synced := make(chan string, 1)
+	srv := testServer(t, func(ctx context.Context, user string) error {
+		synced <- user
+		return nil
+	})
+	defer srv.Shutdown(context.Background())
+	srv.Users = map[string][]int64{"alice": {1202728442}}
+	if err := srv.Schedule(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if user := <-synced; user != "alice" {
+		t.Errorf("synced %q, want alice", user)
+	}
+	var jobs []queue.Job
+	for i := 0; i < 100; i++ {
+		w := adminRequest(srv, "GET", "/admin/jobs", "secret")
+		if err := json.Unmarshal(w.Body.Bytes(), &jobs); err != nil {
+			t.Fatal(err)
+		}
+		if len(jobs) == 1 && len(jobs[0].History) == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(jobs) != 1 || jobs[0].Name != "alice" || jobs[0].State != queue.StateScheduled || len(jobs[0].History) != 1 {
+		t.Fatalf("GET /admin/jobs: got %+v", jobs)
+	}
+	if _, err := os.Stat(filepath.Join(srv.store.Dir(), store.JobsFile)); err != nil {
+		t.Errorf("the job queue wasn't saved in the archive: %v", err)
+	}
+	if w := adminRequest(srv, "POST", "/admin/jobs/run?name=bob", "secret"); w.Code != 404 {
+		t.Errorf("POST /admin/jobs/run?name=bob: got %d, want 404", w.Code)
+	}
+	if w := adminRequest(srv, "POST", "/admin/jobs/run?name=alice", "secret"); w.Code != 202 {
+		t.Errorf("POST /admin/jobs/run?name=alice: got %d, want 202", w.Code)
+	}
+}
+
+func TestAdminCreateAndRevokeToken(t *testing.T) {
+	srv := testServer(t, nil)
+	var saved []Token
+	srv.SaveTokens = func(tokens []Token) error {
+		saved = tokens
+		return nil
+	}
+	w := adminRequest(srv, "POST", "/admin/tokens?name=sheet&scopes=read&cards=1202728442", "secret")
+	if w.Code != 201 {
+		t.Fatalf("POST /admin/tokens: got %d, want 201: %s", w.Code, w.Body.String())
+	}
+	var tok Token
+	if err := json.Unmarshal(w.Body.Bytes(), &tok); err != nil {
+		t.Fatal(err)
+	}
+	if len(tok.Token) != 32 || len(tok.Cards) != 1 || len(saved) != 1 {
+		t.Errorf("created token: got %+v, saved %+v", tok, saved)
+	}
+	for _, bad := range []string{
+		"name=sheet&scopes=read",
+		"name=ops&scopes=admin&cards=1202728442",
+		"name=other&scopes=read&cards=abc",
+	} {
+		if w := adminRequest(srv, "POST", "/admin/tokens?"+bad, "secret"); w.Code != 400 {
+			t.Errorf("POST /admin/tokens?%s: got %d, want 400", bad, w.Code)
+		}
+	}
+	if w := adminRequest(srv, "POST", "/admin/tokens/revoke?name=sheet", "secret"); w.Code != 204 {
+		t.Errorf("POST /admin/tokens/revoke: got %d, want 204", w.Code)
+	}
+	if len(saved) != 0 {
+		t.Errorf("saved tokens after revoking: got %+v", saved)
+	}
+	if w := adminRequest(srv, "GET", "/cards", tok.Token); w.Code != 401 {
+		t.Errorf("GET /cards with a revoked token: got %d, want 401", w.Code)
+	}
+}
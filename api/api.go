@@ -0,0 +1,688 @@
+// Package api serves the statement archive as a JSON REST API, so that other
+// tools can read Clipper data without running the clipper command.
+//
+// The routes are:
+//
+//	GET  /                                    a dashboard for browsers
+//	GET  /cards                               every card, with its latest balance
+//	GET  /cards/SERIAL.ics                    a card's rides as an iCalendar feed
+//	GET  /transactions?card=&from=&to=        transactions, oldest first; see below
+//	GET  /events                              new transactions and balances as they're synced; see events.go
+//	GET  /sync                                the status of the last sync
+//	POST /sync                                download new statements
+//	POST /trigger?user=|card=                 download new statements for one user
+//	POST /graphql                             GraphQL queries, if enabled
+//	GET  /metrics                             metrics for Prometheus
+//	GET  /healthz                             200 while the process is up
+//	GET  /readyz                              200 while the server can take requests
+//	POST /slack                               Slack slash commands, if enabled
+//	POST /plaid/transactions/get              Plaid-style transactions, if enabled
+//	POST /plaid/accounts/get                  Plaid-style accounts, if enabled
+//	GET  /admin/...                           operating the server; see admin.go
+//
+// from and to are dates in YYYY-MM-DD format, starting at midnight Pacific
+// time; to is exclusive. Times in responses are Pacific time, with their UTC
+// offset, like "2024-03-01T08:15:00-08:00". /transactions
+// also filters by agency (a comma-separated list), min_amount_cents and
+// max_amount_cents (debits are positive, credits negative), category (fare,
+// reload or other) and q, text to look for in the type, location, route,
+// product or agency. sort is time (the default), -time, amount or -amount.
+// With limit (at most 1000), transactions come a page at a time: the Link
+// header points at the next page, whose cursor stays valid as new statements
+// arrive. X-Total-Count is the number of matching transactions.
+//
+// Every request must carry one of the server's tokens, either as
+// "Authorization: Bearer TOKEN" or, for tools that can't set headers, a token
+// query parameter. A token may be limited to some scopes: reading, balances
+// only, syncing, metrics or admin, and to some cards. Browsers sign in to the dashboard at /login, which saves
+// the token in a cookie.
+// Slack signs its requests to /slack instead, and Plaid clients send the
+// token as their access_token. Calendar apps can subscribe to a card's feed
+// with the token in the URL, like /cards/1202728442.ics?token=TOKEN; use a
+// token with only the read scope. /healthz and /readyz don't need a token, so
+// container orchestrators can probe them.
+//
+// Every other request is recorded in the archive's audit log; see audit.go.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clipperstats"
+	"github.com/kevinburke/clipper/notify"
+	"github.com/kevinburke/clipper/queue"
+	"github.com/kevinburke/clipper/store"
+	"github.com/kevinburke/handlers"
+	"github.com/kevinburke/rest"
+)
+
+// A SyncFunc downloads new statements into the archive for user, one of the
+// names in Server.Users, or for everyone if user is empty.
+type SyncFunc func(ctx context.Context, user string) error
+
+// SyncTimeout is how long a sync may run.
+var SyncTimeout = 10 * time.Minute
+
+// A SyncStatus describes the most recent sync.
+type SyncStatus struct {
+	Running bool `json:"running"`
+	// User is who the running or most recent sync is for; it's empty if it
+	// was for everyone.
+	User        string    `json:"user,omitempty"`
+	LastStart   time.Time `json:"last_start,omitempty"`
+	LastFinish  time.Time `json:"last_finish,omitempty"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// A Server serves the API for a Store.
+type Server struct {
+	store *store.Store
+	sync  SyncFunc
+	mux   http.Handler
+	// graphql is set by EnableGraphQL.
+	graphql *graphql.Schema
+	// plaid is set by EnablePlaid.
+	plaid bool
+
+	// ClientErrors, if set, counts failed requests made while syncing; it's
+	// reported at /metrics. The SyncFunc should send requests through
+	// ClientErrors.Wrap.
+	ClientErrors *ErrorCounter
+
+	// Users maps the names SyncFunc accepts to the serial numbers of their
+	// cards, so that POST /trigger can sync one user or card.
+	Users map[string][]int64
+
+	// SlackSigningSecret, if set, lets a Slack app's "/clipper" slash command
+	// query balances and transactions at /slack.
+	SlackSigningSecret string
+
+	// Notifier, if set, is sent low balance and unusual activity alerts when
+	// a sync finds new transactions.
+	Notifier notify.Notifier
+
+	// SaveTokens, if set, is called with the tokens added with AddToken
+	// when one of them is rotated, so the new token outlasts a restart.
+	SaveTokens func([]Token) error
+
+	mu     sync.Mutex
+	tokens []Token
+	// hasDefaultToken is set if tokens[0] is the token passed to New.
+	hasDefaultToken bool
+	status          SyncStatus
+	// closing is set by Shutdown, and syncing tracks the running sync so
+	// Shutdown can wait for it.
+	closing bool
+	syncing sync.WaitGroup
+	// syncs counts finished syncs by result, and transactionsSynced the new
+	// transactions they found.
+	syncs              map[string]int
+	transactionsSynced int
+	// accounts records the last sync of each of Users.
+	accounts map[string]*AccountStatus
+	// queue is set by Schedule.
+	queue *queue.Queue
+	// subscribers are the clients reading GET /events; it's nil once the
+	// server shuts down.
+	eventsMu    sync.Mutex
+	subscribers map[*subscriber]struct{}
+
+	statements []string
+	txns       []clipper.Transaction
+}
+
+// New returns a Server for s. Requests must present token, which has every
+// scope, or one added with AddToken; if there are no tokens, every request is
+// allowed. If sync is nil, POST /sync isn't available.
+func New(s *store.Store, token string, sync SyncFunc) *Server {
+	srv := &Server{
+		store:    s,
+		sync:     sync,
+		syncs:    make(map[string]int),
+		accounts: make(map[string]*AccountStatus),
+
+		subscribers: make(map[*subscriber]struct{}),
+	}
+	if token != "" {
+		srv.tokens = []Token{{Name: "default", Token: token, Scopes: AllScopes}}
+		srv.hasDefaultToken = true
+	}
+	r := new(handlers.Regexp)
+	r.HandleFunc(regexp.MustCompile(`^/$`), []string{"GET"}, srv.dashboard)
+	r.HandleFunc(regexp.MustCompile(`^/cards$`), []string{"GET"}, srv.cards)
+	r.HandleFunc(cardFeedRoute, []string{"GET"}, srv.cardFeed)
+	r.HandleFunc(regexp.MustCompile(`^/transactions$`), []string{"GET"}, srv.transactions)
+	r.HandleFunc(regexp.MustCompile(`^/events$`), []string{"GET"}, srv.events)
+	r.HandleFunc(regexp.MustCompile(`^/sync$`), []string{"GET", "POST"}, srv.syncHandler)
+	r.HandleFunc(regexp.MustCompile(`^/trigger$`), []string{"POST"}, srv.trigger)
+	r.HandleFunc(regexp.MustCompile(`^/metrics$`), []string{"GET"}, srv.metrics)
+	r.HandleFunc(regexp.MustCompile(`^/admin/audit$`), []string{"GET"}, srv.auditLog)
+	r.HandleFunc(regexp.MustCompile(`^/admin/`), []string{"GET", "POST"}, srv.admin)
+	r.HandleFunc(regexp.MustCompile(`^/graphql$`), []string{"POST"}, func(w http.ResponseWriter, r *http.Request) {
+		if srv.graphql == nil {
+			rest.NotFound(w, r)
+			return
+		}
+		srv.graphQL(w, r)
+	})
+	srv.mux = r
+	return srv
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/healthz":
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	case r.URL.Path == "/readyz":
+		s.ready(w, r)
+	case strings.HasPrefix(r.URL.Path, "/static/"):
+		static.ServeHTTP(w, r)
+	default:
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		s.serveAudited(rec, r)
+		s.auditRequest(r, rec.code)
+	}
+}
+
+// serveAudited serves the requests that are recorded in the audit log.
+func (s *Server) serveAudited(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/login":
+		s.login(w, r)
+	case r.URL.Path == "/slack":
+		s.slack(w, r)
+	case strings.HasPrefix(r.URL.Path, "/plaid/"):
+		s.plaidHandler(w, r)
+	default:
+		s.serveAuthorized(w, r)
+	}
+}
+
+// serveAuthorized serves r if its token has the scope the request needs.
+func (s *Server) serveAuthorized(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.Token(tokenFrom(r))
+	scopes := t.Scopes
+	switch {
+	case !ok && wantsHTML(r):
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+	case !ok:
+		rest.Unauthorized(w, r, "clipper")
+	case !hasScope(scopes, requiredScope(r)):
+		rest.Forbidden(w, r, &rest.Error{
+			Title: fmt.Sprintf("This token doesn't have the %q scope", requiredScope(r)),
+			ID:    "insufficient_scope",
+		})
+	default:
+		s.mux.ServeHTTP(w, r.WithContext(NewContext(r.Context(), t)))
+	}
+}
+
+var static = staticHandler()
+
+// HideToken moves a token query parameter into the Authorization header
+// before calling h, so that request logs don't record it.
+func HideToken(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if tok := q.Get("token"); tok != "" {
+			q.Del("token")
+			r = r.Clone(r.Context())
+			r.URL.RawQuery = q.Encode()
+			r.RequestURI = r.URL.RequestURI()
+			if r.Header.Get("Authorization") == "" {
+				r.Header.Set("Authorization", "Bearer "+tok)
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Transactions returns every transaction in the archive. They're parsed
+// again only when the archive's statements change.
+func (s *Server) Transactions() ([]clipper.Transaction, error) {
+	paths, err := s.store.Statements()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.txns != nil && strings.Join(paths, "\x00") == strings.Join(s.statements, "\x00") {
+		return s.txns, nil
+	}
+	txns, err := s.store.Transactions()
+	e := store.AuditEvent{Actor: "server", Action: store.AuditParse, Detail: fmt.Sprintf("statements: %d", len(paths)), Outcome: store.AuditOK}
+	if err != nil {
+		e.Outcome, e.Error = store.AuditError, err.Error()
+	}
+	s.Audit(e)
+	if err != nil {
+		return nil, err
+	}
+	if txns == nil {
+		txns = []clipper.Transaction{}
+	}
+	s.statements, s.txns = paths, txns
+	return txns, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func badRequest(w http.ResponseWriter, r *http.Request, format string, args ...interface{}) {
+	rest.BadRequest(w, r, &rest.Error{Title: fmt.Sprintf(format, args...), ID: "invalid_parameter"})
+}
+
+// A Card is a card in the archive.
+type Card struct {
+	Serial       int64         `json:"serial"`
+	Transactions int           `json:"transactions"`
+	BalanceCents clipper.Money `json:"balance_cents"`
+	LastUsed     time.Time     `json:"last_used"`
+}
+
+// Cards returns the cards in txns, in the order they first appear.
+func Cards(txns []clipper.Transaction) []Card {
+	cards := []Card{}
+	index := make(map[int64]int)
+	for _, t := range txns {
+		i, ok := index[t.CardSerial]
+		if !ok {
+			i = len(cards)
+			index[t.CardSerial] = i
+			cards = append(cards, Card{Serial: t.CardSerial})
+		}
+		cards[i].Transactions++
+		cards[i].BalanceCents = t.BalanceCents
+		cards[i].LastUsed = t.Timestamp
+	}
+	return cards
+}
+
+func (s *Server) cards(w http.ResponseWriter, r *http.Request) {
+	txns, err := s.VisibleTransactions(r.Context())
+	if err != nil {
+		rest.ServerError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, Cards(txns))
+}
+
+var cardFeedRoute = regexp.MustCompile(`^/cards/(\d+)\.ics$`)
+
+// cardFeed serves a card's rides as an iCalendar feed.
+func (s *Server) cardFeed(w http.ResponseWriter, r *http.Request) {
+	serial, err := strconv.ParseInt(cardFeedRoute.FindStringSubmatch(r.URL.Path)[1], 10, 64)
+	if err != nil {
+		badRequest(w, r, "Invalid card serial number in %q", r.URL.Path)
+		return
+	}
+	txns, err := s.VisibleTransactions(r.Context())
+	if err != nil {
+		rest.ServerError(w, r, err)
+		return
+	}
+	var card []clipper.Transaction
+	for _, t := range txns {
+		if t.CardSerial == serial {
+			card = append(card, t)
+		}
+	}
+	if len(card) == 0 {
+		rest.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	name := fmt.Sprintf("Clipper card %d", serial)
+	if err := clipperstats.WriteICalendar(w, name, clipperstats.Legs(card)); err != nil {
+		handlers.Logger.Warn("error writing calendar", "err", err)
+	}
+}
+
+// A Transaction is a transaction as the API returns it.
+type Transaction struct {
+	// ID is the same for a transaction across downloads; see store.ID.
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Location  string    `json:"location,omitempty"`
+	Route     string    `json:"route,omitempty"`
+	Product   string    `json:"product,omitempty"`
+	Agency    string    `json:"agency,omitempty"`
+	// Category is fare, reload or other.
+	Category     string        `json:"category"`
+	DebitCents   clipper.Money `json:"debit_cents"`
+	CreditCents  clipper.Money `json:"credit_cents"`
+	BalanceCents clipper.Money `json:"balance_cents"`
+	CardSerial   int64         `json:"card_serial"`
+}
+
+func transactionOf(t clipper.Transaction) Transaction {
+	return Transaction{
+		ID:           store.ID(t),
+		Timestamp:    t.Timestamp,
+		Type:         t.Type,
+		Location:     t.Location,
+		Route:        t.Route,
+		Product:      t.Product,
+		Agency:       string(t.Agency()),
+		Category:     category(t),
+		DebitCents:   t.DebitCents,
+		CreditCents:  t.CreditCents,
+		BalanceCents: t.BalanceCents,
+		CardSerial:   t.CardSerial,
+	}
+}
+
+func (s *Server) transactions(w http.ResponseWriter, r *http.Request) {
+	tq, err := parseTxnQuery(r.URL.Query())
+	if err != nil {
+		badRequest(w, r, "%s", err.Error())
+		return
+	}
+	txns, err := s.VisibleTransactions(r.Context())
+	if err != nil {
+		rest.ServerError(w, r, err)
+		return
+	}
+	page, total, next := tq.run(txns)
+	out := make([]Transaction, len(page))
+	for i, t := range page {
+		out[i] = transactionOf(t)
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if next != nil {
+		setNextLink(w, r, *next)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// SyncStatus returns the status of the most recent sync.
+func (s *Server) SyncStatus() SyncStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// CanSync reports whether the server was given a way to download new
+// statements.
+func (s *Server) CanSync() bool { return s.sync != nil }
+
+// Sync starts downloading new statements in the background and returns
+// false if the server can't sync or a sync is already running.
+func (s *Server) Sync() bool { return s.SyncUser("") }
+
+// SyncUser is like Sync, but downloads statements only for user.
+func (s *Server) SyncUser(user string) bool {
+	if user != "" {
+		return s.syncUsers(user, []string{user})
+	}
+	return s.syncUsers("", s.userNames())
+}
+
+// userNames returns the names in s.Users, sorted.
+func (s *Server) userNames() []string {
+	names := make([]string, 0, len(s.Users))
+	for name := range s.Users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// syncUsers starts a sync that downloads statements for each of names in
+// turn, recording how each account did, and reports the sync as being for
+// user. If names is empty, it syncs everyone at once.
+func (s *Server) syncUsers(user string, names []string) bool {
+	if !s.startSync(user) {
+		return false
+	}
+	go s.finishSync(context.Background(), names)
+	return true
+}
+
+// startSync marks a sync for user as running. It returns false if the
+// server can't sync, a sync is already running or the server is shutting
+// down.
+func (s *Server) startSync(user string) bool {
+	if !s.CanSync() {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status.Running || s.closing {
+		return false
+	}
+	s.syncing.Add(1)
+	s.status.Running = true
+	s.status.User = user
+	s.status.LastStart = time.Now()
+	return true
+}
+
+// finishSync runs the sync started with startSync, downloading statements
+// for each of names in turn, or everyone if names is empty.
+func (s *Server) finishSync(ctx context.Context, names []string) error {
+	defer s.syncing.Done()
+	s.publishStatus()
+	if len(names) == 0 {
+		names = []string{""}
+	}
+	before, _ := s.Transactions()
+	var errs []error
+	for _, name := range names {
+		if err := s.syncAccount(ctx, name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	err := errors.Join(errs...)
+	var after []clipper.Transaction
+	if err == nil {
+		after, err = s.Transactions()
+	}
+	if err == nil && s.Notifier != nil && len(after) > len(before) {
+		s.sendAlerts(before, after)
+	}
+	if err == nil {
+		s.publishChanges(before, after)
+	}
+	defer s.publishStatus()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Running = false
+	s.status.LastFinish = time.Now()
+	if err != nil {
+		s.status.LastError = err.Error()
+		s.syncs["failure"]++
+	} else {
+		s.status.LastError = ""
+		s.status.LastSuccess = s.status.LastFinish
+		s.syncs["success"]++
+		if n := len(after) - len(before); n > 0 {
+			s.transactionsSynced += n
+		}
+	}
+	return err
+}
+
+// syncAccount downloads statements for the user called name, or everyone if
+// name is empty, and records the result in s.accounts.
+func (s *Server) syncAccount(ctx context.Context, name string) error {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, SyncTimeout)
+	defer cancel()
+	err := s.sync(ctx, name)
+	if name == "" {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.accounts[name]
+	if a == nil {
+		a = &AccountStatus{User: name}
+		s.accounts[name] = a
+	}
+	a.LastStart, a.LastFinish = start, time.Now()
+	if err != nil {
+		a.LastError = err.Error()
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	a.LastError = ""
+	a.LastSuccess = a.LastFinish
+	return nil
+}
+
+func (s *Server) syncHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		writeJSON(w, http.StatusOK, s.SyncStatus())
+		return
+	}
+	if !s.CanSync() {
+		rest.Forbidden(w, r, &rest.Error{Title: "This server has no credentials to sync with", ID: "sync_unavailable"})
+		return
+	}
+	if len(requestToken(r.Context()).Cards) > 0 {
+		rest.Forbidden(w, r, &rest.Error{Title: "This token is limited to some cards; sync them with POST /trigger?card=", ID: "card_restricted"})
+		return
+	}
+	if wantsHTML(r) {
+		s.Sync()
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	if !s.Sync() {
+		writeJSON(w, http.StatusConflict, s.SyncStatus())
+		return
+	}
+	writeJSON(w, http.StatusAccepted, s.SyncStatus())
+}
+
+// userFor returns the name in s.Users of the user who owns card.
+func (s *Server) userFor(card int64) (string, bool) {
+	for _, name := range s.userNames() {
+		for _, c := range s.Users[name] {
+			if c == card {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ownsOnly reports whether every card of user is one that t can see, so
+// that syncing user's account doesn't reach beyond t's cards. Everyone (an
+// empty user) never qualifies.
+func (s *Server) ownsOnly(t Token, user string) bool {
+	if user == "" {
+		return false
+	}
+	for _, c := range s.Users[user] {
+		if !t.allowsCard(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// trigger starts a sync for the user named by the user parameter, or the
+// owner of the card parameter, so a phone can refresh the archive right
+// after a trip. With neither, it syncs everyone, like POST /sync.
+func (s *Server) trigger(w http.ResponseWriter, r *http.Request) {
+	if !s.CanSync() {
+		rest.Forbidden(w, r, &rest.Error{Title: "This server has no credentials to sync with", ID: "sync_unavailable"})
+		return
+	}
+	user := r.FormValue("user")
+	if v := r.FormValue("card"); v != "" {
+		card, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			badRequest(w, r, "Invalid card serial number %q", v)
+			return
+		}
+		owner, ok := s.userFor(card)
+		if !ok {
+			rest.NotFound(w, r)
+			return
+		}
+		if user != "" && user != owner {
+			badRequest(w, r, "Card %d belongs to %s, not %s", card, owner, user)
+			return
+		}
+		user = owner
+	}
+	if _, ok := s.Users[user]; user != "" && !ok {
+		rest.NotFound(w, r)
+		return
+	}
+	if t := requestToken(r.Context()); len(t.Cards) > 0 && !s.ownsOnly(t, user) {
+		rest.Forbidden(w, r, &rest.Error{Title: "This token can't sync cards it isn't limited to", ID: "card_restricted"})
+		return
+	}
+	if !s.SyncUser(user) {
+		writeJSON(w, http.StatusConflict, s.SyncStatus())
+		return
+	}
+	writeJSON(w, http.StatusAccepted, s.SyncStatus())
+}
+
+// ready reports whether the server can take requests: it isn't shutting down
+// and the archive can be read.
+func (s *Server) ready(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	closing := s.closing
+	s.mu.Unlock()
+	if closing {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "shutting down"})
+		return
+	}
+	if _, err := s.store.Statements(); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "archive unavailable", "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Shutdown stops the server from starting new syncs, marks it not ready at
+// /readyz, and waits for a running sync to finish downloading, or for ctx to
+// be done. It doesn't stop serving requests; shut down the HTTP server for
+// that.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	q := s.queue
+	s.mu.Unlock()
+	s.closeEvents()
+	if q != nil {
+		if err := q.Stop(ctx); err != nil {
+			return err
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		s.syncing.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
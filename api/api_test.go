@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/store"
+)
+
+func testServer(t *testing.T, sync SyncFunc) *Server {
+	t.Helper()
+	dir := t.TempDir()
+	data, err := os.ReadFile("../testdata/transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.pdf"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(s, "secret", sync)
+}
+
+func get(t *testing.T, h http.Handler, path string, v interface{}) int {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code == 200 && v != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+	}
+	return w.Code
+}
+
+func TestAuth(t *testing.T) {
+	srv := testServer(t, nil)
+	for path, want := range map[string]int{
+		"/cards":              401,
+		"/cards?token=wrong":  401,
+		"/cards?token=secret": 200,
+	} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != want {
+			t.Errorf("GET %s: got %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestHideToken(t *testing.T) {
+	var got *http.Request
+	h := HideToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = r }))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/transactions?card=5&token=secret", nil))
+	if got.RequestURI != "/transactions?card=5" || got.Header.Get("Authorization") != "Bearer secret" {
+		t.Errorf("got URI %q, Authorization %q", got.RequestURI, got.Header.Get("Authorization"))
+	}
+}
+
+func TestCardsAndTransactions(t *testing.T) {
+	srv := testServer(t, nil)
+	var cards []Card
+	if code := get(t, srv, "/cards", &cards); code != 200 {
+		t.Fatalf("GET /cards: got %d", code)
+	}
+	if len(cards) != 1 || cards[0].Serial != 1202728442 || cards[0].Transactions == 0 {
+		t.Errorf("cards: got %+v", cards)
+	}
+
+	var all, jan []Transaction
+	get(t, srv, "/transactions?card=1202728442", &all)
+	get(t, srv, "/transactions?card=1202728442&from=2018-01-01&to=2018-02-01", &jan)
+	if len(jan) == 0 || len(jan) >= len(all) {
+		t.Errorf("expected January to be a subset: got %d of %d", len(jan), len(all))
+	}
+	for _, txn := range jan {
+		if txn.Timestamp.Month() != time.January {
+			t.Errorf("transaction outside January: %+v", txn)
+		}
+	}
+	var none []Transaction
+	get(t, srv, "/transactions?card=1", &none)
+	if none == nil || len(none) != 0 {
+		t.Errorf("expected an empty list for an unknown card, got %v", none)
+	}
+	if code := get(t, srv, "/transactions?from=yesterday", nil); code != 400 {
+		t.Errorf("bad date: got %d, want 400", code)
+	}
+}
+
+func TestCardFeed(t *testing.T) {
+	srv := testServer(t, nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", "/cards/1202728442.ics?token=secret", nil))
+	if w.Code != 200 || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar") {
+		t.Fatalf("GET /cards/1202728442.ics: got %d, %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if body := w.Body.String(); !strings.Contains(body, "BEGIN:VEVENT") || !strings.Contains(body, "UID:1202728442-") {
+		t.Errorf("expected events for the card, got %.300s", body)
+	}
+	for path, want := range map[string]int{
+		"/cards/1.ics?token=secret":          404,
+		"/cards/1202728442.ics":              401,
+		"/cards/1202728442.ics?token=wrong":  401,
+		"/cards/1202728442.ics?token=secret": 200,
+	} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != want {
+			t.Errorf("GET %s: got %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	if code := get(t, testServer(t, nil), "/sync", nil); code != 200 {
+		t.Errorf("GET /sync: got %d", code)
+	}
+	done := make(chan struct{})
+	srv := testServer(t, func(ctx context.Context, user string) error {
+		<-done
+		return nil
+	})
+	post := func() int {
+		req := httptest.NewRequest("POST", "/sync", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := post(); code != 202 {
+		t.Fatalf("POST /sync: got %d, want 202", code)
+	}
+	if code := post(); code != 409 {
+		t.Errorf("second POST /sync: got %d, want 409", code)
+	}
+	close(done)
+	for i := 0; i < 100 && srv.SyncStatus().Running; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if st := srv.SyncStatus(); st.Running || st.LastSuccess.IsZero() {
+		t.Errorf("sync status: got %+v", st)
+	}
+}
+
+func TestTrigger(t *testing.T) {
+	users := make(chan string, 1)
+	srv := testServer(t, func(ctx context.Context, user string) error {
+		users <- user
+		return nil
+	})
+	srv.Users = map[string][]int64{"alice": {1202728442}, "bob": {2}}
+	post := func(query string) int {
+		req := httptest.NewRequest("POST", "/trigger?"+query, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Code
+	}
+	for query, want := range map[string]int{
+		"user=carol":               404,
+		"card=3":                   404,
+		"card=abc":                 400,
+		"user=bob&card=1202728442": 400,
+	} {
+		if code := post(query); code != want {
+			t.Errorf("POST /trigger?%s: got %d, want %d", query, code, want)
+		}
+	}
+	if code := post("card=1202728442"); code != 202 {
+		t.Fatalf("POST /trigger?card=1202728442: got %d, want 202", code)
+	}
+	if user := <-users; user != "alice" {
+		t.Errorf("synced %q, want alice", user)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	release := make(chan struct{})
+	srv := testServer(t, func(ctx context.Context, user string) error {
+		<-release
+		return nil
+	})
+	probe := func(path string) int {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w.Code
+	}
+	if probe("/healthz") != 200 || probe("/readyz") != 200 {
+		t.Fatalf("probes failed before shutdown")
+	}
+	if !srv.Sync() {
+		t.Fatal("sync didn't start")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown with a sync running: got %v", err)
+	}
+	if code := probe("/readyz"); code != 503 {
+		t.Errorf("/readyz while shutting down: got %d", code)
+	}
+	if code := probe("/healthz"); code != 200 {
+		t.Errorf("/healthz while shutting down: got %d", code)
+	}
+	close(release)
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown: %v", err)
+	}
+	if st := srv.SyncStatus(); st.Running || st.LastSuccess.IsZero() {
+		t.Errorf("sync didn't finish: %+v", st)
+	}
+	if srv.Sync() {
+		t.Error("started a sync after Shutdown")
+	}
+}
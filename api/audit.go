@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/store"
+	"github.com/kevinburke/handlers"
+	"github.com/kevinburke/rest"
+)
+
+// The server records each API request, and each time it parses the
+// archive, in the archive's audit log (see store.AuditFile). GET
+// /admin/audit queries it, with the since and until (YYYY-MM-DD), actor,
+// action, account, card, outcome and limit parameters; limit defaults to
+// 100.
+
+// statusRecorder remembers the status code a handler wrote.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// Audit records e in the archive's audit log.
+func (s *Server) Audit(e store.AuditEvent) {
+	if err := s.store.Audit(e); err != nil {
+		handlers.Logger.Warn("error writing audit log", "err", err)
+	}
+}
+
+// auditRequest records r, which got a response with the given status code.
+func (s *Server) auditRequest(r *http.Request, code int) {
+	e := store.AuditEvent{Action: store.AuditAPI, Outcome: store.AuditOK}
+	switch {
+	case r.URL.Path == "/slack":
+		e.Actor = "slack"
+	case r.URL.Path == "/login" && r.Method == "POST":
+		if t, ok := s.Token(r.PostFormValue("token")); ok {
+			e.Actor = t.Name
+		}
+	default:
+		if t, ok := s.Token(tokenFrom(r)); ok {
+			e.Actor = t.Name
+		}
+	}
+	q := r.URL.Query()
+	q.Del("token")
+	e.Detail = r.Method + " " + (&url.URL{Path: r.URL.Path, RawQuery: q.Encode()}).String()
+	e.Account = q.Get("user")
+	if m := cardFeedRoute.FindStringSubmatch(r.URL.Path); m != nil {
+		e.Card, _ = strconv.ParseInt(m[1], 10, 64)
+	} else if card := q.Get("card"); card != "" {
+		e.Card, _ = strconv.ParseInt(card, 10, 64)
+	}
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		e.Outcome = store.AuditDenied
+	case code >= 400:
+		e.Outcome, e.Error = store.AuditError, strconv.Itoa(code)+" "+http.StatusText(code)
+	}
+	s.Audit(e)
+}
+
+// parseAuditQuery parses the parameters of GET /admin/audit.
+func parseAuditQuery(q url.Values) (store.AuditQuery, error) {
+	aq := store.AuditQuery{
+		Actor:   q.Get("actor"),
+		Action:  q.Get("action"),
+		Account: q.Get("account"),
+		Outcome: q.Get("outcome"),
+		Limit:   100,
+	}
+	var err error
+	if v := q.Get("since"); v != "" {
+		if aq.Since, err = clipper.ParseDate(v); err != nil {
+			return aq, fmt.Errorf("Invalid since date %q; use YYYY-MM-DD", v)
+		}
+	}
+	if v := q.Get("until"); v != "" {
+		if aq.Until, err = clipper.ParseDate(v); err != nil {
+			return aq, fmt.Errorf("Invalid until date %q; use YYYY-MM-DD", v)
+		}
+	}
+	if v := q.Get("card"); v != "" {
+		if aq.Card, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return aq, fmt.Errorf("Invalid card serial number %q", v)
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if aq.Limit, err = strconv.Atoi(v); err != nil || aq.Limit < 1 || aq.Limit > MaxLimit {
+			return aq, fmt.Errorf("Invalid limit %q; use a number from 1 to %d", v, MaxLimit)
+		}
+	}
+	return aq, nil
+}
+
+func (s *Server) auditLog(w http.ResponseWriter, r *http.Request) {
+	aq, err := parseAuditQuery(r.URL.Query())
+	if err != nil {
+		badRequest(w, r, "%s", err.Error())
+		return
+	}
+	events, err := s.store.AuditLog(aq)
+	if err != nil {
+		rest.ServerError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, events)
+}
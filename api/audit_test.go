@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kevinburke/clipper/store"
+)
+
+func TestAuditLog(t *testing.T) {
+	srv := testServer(t, func(context.Context, string) error { return nil })
+	if err := srv.AddToken(Token{Name: "phone", Token: "read-only-token-1", Scopes: []Scope{ScopeRead}}); err != nil {
+		t.Fatal(err)
+	}
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/cards/1202728442.ics?token=read-only-token-1", nil))
+	adminRequest(srv, "POST", "/sync", "read-only-token-1")
+	adminRequest(srv, "GET", "/cards", "wrong-token")
+
+	var events []store.AuditEvent
+	w := adminRequest(srv, "GET", "/admin/audit?action=api", "secret")
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("GET /admin/audit: got %+v", events)
+	}
+	if e := events[0]; e.Actor != "phone" || e.Card != 1202728442 || e.Outcome != store.AuditOK || e.Detail != "GET /cards/1202728442.ics" {
+		t.Errorf("calendar request: got %+v", e)
+	}
+	if e := events[1]; e.Actor != "phone" || e.Outcome != store.AuditDenied {
+		t.Errorf("sync without the sync scope: got %+v", e)
+	}
+	if e := events[2]; e.Actor != "" || e.Outcome != store.AuditDenied {
+		t.Errorf("request with a wrong token: got %+v", e)
+	}
+
+	w = adminRequest(srv, "GET", "/admin/audit?action=parse", "secret")
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Actor != "server" || events[0].Detail != "statements: 1" {
+		t.Errorf("parse events: got %+v", events)
+	}
+	if w := adminRequest(srv, "GET", "/admin/audit?limit=0", "secret"); w.Code != 400 {
+		t.Errorf("GET /admin/audit?limit=0: got %d, want 400", w.Code)
+	}
+}
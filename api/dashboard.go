@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/chart"
+	"github.com/kevinburke/clipper/clipperstats"
+	"github.com/kevinburke/clipper/store"
+	"github.com/kevinburke/rest"
+)
+
+//go:embed templates static
+var files embed.FS
+
+var dashboardTpl = template.Must(template.New("").Funcs(template.FuncMap{
+	"dollars": clipper.Money.String,
+}).ParseFS(files, "templates/*.html"))
+
+// tokenCookie holds the token for browsers that signed in to the dashboard.
+const tokenCookie = "clipper_token"
+
+// LowBalanceCents is the balance below which the dashboard highlights a card.
+var LowBalanceCents clipper.Money = 1000
+
+// RecentTransactions is how many transactions the dashboard lists.
+var RecentTransactions = 25
+
+type dashboardData struct {
+	Cards           []Card
+	LowBalanceCents clipper.Money
+	Charts          []template.HTML
+	Recent          []Transaction
+	Sync            SyncStatus
+	CanSync         bool
+}
+
+// wantsHTML reports whether r came from a browser rather than a tool that
+// wants JSON.
+func wantsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+func render(w http.ResponseWriter, r *http.Request, code int, name string, data interface{}) {
+	buf := new(bytes.Buffer)
+	if err := dashboardTpl.ExecuteTemplate(buf, name, data); err != nil {
+		rest.ServerError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write(buf.Bytes())
+}
+
+func (s *Server) dashboard(w http.ResponseWriter, r *http.Request) {
+	txns, err := s.VisibleTransactions(r.Context())
+	if err != nil {
+		rest.ServerError(w, r, err)
+		return
+	}
+	data := dashboardData{
+		Cards:           Cards(txns),
+		LowBalanceCents: LowBalanceCents,
+		Sync:            s.SyncStatus(),
+		CanSync:         s.CanSync() && hasScope(requestScopes(r.Context()), ScopeSync) && len(requestToken(r.Context()).Cards) == 0,
+	}
+	if months := clipperstats.Monthly(txns); len(months) > 0 {
+		if len(months) > 12 {
+			months = months[len(months)-12:]
+		}
+		data.Charts = append(data.Charts, template.HTML(chart.MonthlySpend(months).SVG()))
+		if agencies := clipperstats.AgencyTotals(store.Between(txns, months[0].Start, time.Time{})); len(agencies) > 0 {
+			data.Charts = append(data.Charts, template.HTML(chart.AgencySpend(agencies).SVG()))
+		}
+	}
+	for i := len(txns) - 1; i >= 0 && len(data.Recent) < RecentTransactions; i-- {
+		data.Recent = append(data.Recent, transactionOf(txns[i]))
+	}
+	render(w, r, http.StatusOK, "dashboard", data)
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		render(w, r, http.StatusOK, "login", struct{ Failed bool }{})
+		return
+	}
+	if _, ok := s.Scopes(r.PostFormValue("token")); !ok {
+		render(w, r, http.StatusUnauthorized, "login", struct{ Failed bool }{true})
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     tokenCookie,
+		Value:    r.PostFormValue("token"),
+		Path:     "/",
+		MaxAge:   int((365 * 24 * time.Hour).Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func staticHandler() http.Handler {
+	sub, err := fs.Sub(files, "static")
+	if err != nil {
+		panic(fmt.Sprintf("api: %v", err))
+	}
+	return http.StripPrefix("/static/", http.FileServer(http.FS(sub)))
+}
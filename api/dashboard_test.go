@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDashboardLogin(t *testing.T) {
+	srv := testServer(t, nil)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != 303 || w.Header().Get("Location") != "/login" {
+		t.Fatalf("GET / signed out: got %d to %q", w.Code, w.Header().Get("Location"))
+	}
+
+	login := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(url.Values{"token": {token}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	if w := login("wrong"); w.Code != 401 || len(w.Result().Cookies()) != 0 {
+		t.Errorf("bad login: got %d with cookies %v", w.Code, w.Result().Cookies())
+	}
+	w = login("secret")
+	cookies := w.Result().Cookies()
+	if w.Code != 303 || len(cookies) != 1 {
+		t.Fatalf("login: got %d with cookies %v", w.Code, cookies)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("GET /: got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"Card 1202728442", "$89.75", "<svg", "Recent activity", "haven't been downloaded"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("dashboard doesn't contain %q", want)
+		}
+	}
+	if strings.Contains(body, "Download now") {
+		t.Error("dashboard offers to download without credentials")
+	}
+}
+
+func TestDashboardStatic(t *testing.T) {
+	w := httptest.NewRecorder()
+	testServer(t, nil).ServeHTTP(w, httptest.NewRequest("GET", "/static/dashboard.css", nil))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), ".balance") {
+		t.Errorf("GET /static/dashboard.css: got %d", w.Code)
+	}
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/store"
+)
+
+// GET /events streams changes to the archive as Server-Sent Events, so that
+// the dashboard, or any other client, can update without polling. The events
+// are:
+//
+//	sync          a SyncStatus, when the stream opens and when a sync starts or finishes
+//	transactions  the Transactions a sync found, oldest first
+//	balances      the Cards whose balance a sync changed
+//
+// A token limited to some cards sees only their transactions and balances.
+// A client that falls too far behind is disconnected; EventSource clients
+// reconnect on their own.
+
+// EventsHeartbeat is how often the server writes a comment to an idle event
+// stream, so that proxies don't close it.
+var EventsHeartbeat = 30 * time.Second
+
+// eventBuffer is how many events a subscriber may fall behind by.
+const eventBuffer = 16
+
+// An event is a Server-Sent Event, with its data already encoded.
+type event struct {
+	name string
+	data []byte
+}
+
+// A subscriber is a client reading GET /events.
+type subscriber struct {
+	token Token
+	ch    chan event
+}
+
+// subscribe returns a subscriber that gets the events t may see. It returns
+// nil if the server is shutting down.
+func (s *Server) subscribe(t Token) *subscriber {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	if s.subscribers == nil {
+		return nil
+	}
+	sub := &subscriber{token: t, ch: make(chan event, eventBuffer)}
+	s.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (s *Server) unsubscribe(sub *subscriber) {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// closeEvents disconnects every subscriber, so that shutting down the HTTP
+// server doesn't wait for their streams, and stops new ones.
+func (s *Server) closeEvents() {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	for sub := range s.subscribers {
+		close(sub.ch)
+	}
+	s.subscribers = nil
+}
+
+// publish sends an event called name to each subscriber. data returns what to
+// send a subscriber with token t, or nil to send it nothing.
+func (s *Server) publish(name string, data func(t Token) interface{}) {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	for sub := range s.subscribers {
+		v := data(sub.token)
+		if v == nil {
+			continue
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			panic(fmt.Sprintf("api: encoding %s event: %v", name, err))
+		}
+		select {
+		case sub.ch <- event{name: name, data: b}:
+		default:
+			delete(s.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+func (s *Server) publishStatus() {
+	status := s.SyncStatus()
+	s.publish("sync", func(Token) interface{} { return status })
+}
+
+// publishChanges sends the transactions and balances that are in after but
+// not in before.
+func (s *Server) publishChanges(before, after []clipper.Transaction) {
+	seen := make(map[string]bool, len(before))
+	for _, t := range before {
+		seen[store.ID(t)] = true
+	}
+	var txns []clipper.Transaction
+	for _, t := range after {
+		if !seen[store.ID(t)] {
+			txns = append(txns, t)
+		}
+	}
+	if len(txns) == 0 {
+		return
+	}
+	was := make(map[int64]clipper.Money)
+	for _, c := range Cards(before) {
+		was[c.Serial] = c.BalanceCents
+	}
+	var cards []Card
+	for _, c := range Cards(after) {
+		if old, ok := was[c.Serial]; !ok || old != c.BalanceCents {
+			cards = append(cards, c)
+		}
+	}
+	s.publish("transactions", func(t Token) interface{} {
+		out := []Transaction{}
+		for _, txn := range t.filter(txns) {
+			out = append(out, transactionOf(txn))
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	})
+	s.publish("balances", func(t Token) interface{} {
+		out := []Card{}
+		for _, c := range cards {
+			if t.allowsCard(c.Serial) {
+				out = append(out, c)
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	})
+}
+
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	sub := s.subscribe(requestToken(r.Context()))
+	if sub == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "shutting down"})
+		return
+	}
+	defer s.unsubscribe(sub)
+	// The stream outlives any write timeout the HTTP server has.
+	rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	status, _ := json.Marshal(s.SyncStatus())
+	fmt.Fprintf(w, "event: sync\ndata: %s\n\n", status)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+	heartbeat := time.NewTicker(EventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.ch:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.name, e.data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevinburke/clipper/store"
+)
+
+// readEvent reads the next event from an event stream, skipping comments.
+func readEvent(t *testing.T, sc *bufio.Scanner) (name, data string) {
+	t.Helper()
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "" && name != "":
+			return name, data
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	t.Fatalf("event stream ended: %v", sc.Err())
+	return "", ""
+}
+
+func openEvents(t *testing.T, url, token string) *bufio.Scanner {
+	t.Helper()
+	req, _ := http.NewRequest("GET", url+"/events", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != 200 || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET /events: got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return bufio.NewScanner(resp.Body)
+}
+
+func TestEvents(t *testing.T) {
+	dir := t.TempDir()
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := New(s, "secret", func(ctx context.Context, user string) error {
+		data, err := os.ReadFile("../testdata/transactions.pdf")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, "a.pdf"), data, 0644)
+	})
+	if err := srv.AddToken(Token{Name: "bob", Token: "bob-phone-token-1", Scopes: []Scope{ScopeRead}, Cards: []int64{2}}); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	all := openEvents(t, ts.URL, "secret")
+	bob := openEvents(t, ts.URL, "bob-phone-token-1")
+	for _, sc := range []*bufio.Scanner{all, bob} {
+		if name, _ := readEvent(t, sc); name != "sync" {
+			t.Fatalf("first event: got %q, want sync", name)
+		}
+	}
+	if !srv.Sync() {
+		t.Fatal("couldn't start a sync")
+	}
+
+	var status SyncStatus
+	name, data := readEvent(t, all)
+	if name != "sync" || json.Unmarshal([]byte(data), &status) != nil || !status.Running {
+		t.Fatalf("sync start: got %s %s", name, data)
+	}
+	var txns []Transaction
+	name, data = readEvent(t, all)
+	if name != "transactions" || json.Unmarshal([]byte(data), &txns) != nil || len(txns) == 0 {
+		t.Fatalf("transactions: got %s %s", name, data)
+	}
+	var cards []Card
+	name, data = readEvent(t, all)
+	if name != "balances" || json.Unmarshal([]byte(data), &cards) != nil || len(cards) != 1 || cards[0].Serial != 1202728442 {
+		t.Fatalf("balances: got %s %s", name, data)
+	}
+	name, data = readEvent(t, all)
+	if name != "sync" || json.Unmarshal([]byte(data), &status) != nil || status.Running || status.LastSuccess.IsZero() {
+		t.Fatalf("sync finish: got %s %s", name, data)
+	}
+
+	// Bob's token can't see the card that changed.
+	for _, want := range []string{"sync", "sync"} {
+		if name, data := readEvent(t, bob); name != want {
+			t.Errorf("bob's events: got %s %s, want %s", name, data, want)
+		}
+	}
+
+	srv.Shutdown(context.Background())
+	if all.Scan() {
+		t.Errorf("stream still open after shutdown: %q", all.Text())
+	}
+}
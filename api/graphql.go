@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clipperstats"
+	"github.com/kevinburke/clipper/store"
+)
+
+// GraphQLSchema is the schema served at /graphql. Card serial numbers are
+// IDs, since they don't fit in a GraphQL Int. Dates are YYYY-MM-DD, and to is
+// exclusive, as in the REST API.
+const GraphQLSchema = `
+schema {
+	query: Query
+}
+
+scalar Time
+
+type Query {
+	cards: [Card!]!
+	transactions(card: ID, from: String, to: String, agency: String, type: String): [Transaction!]!
+	months(card: ID, from: String, to: String): [MonthTotal!]!
+	agencies(card: ID, from: String, to: String): [AgencyTotal!]!
+	sync: SyncStatus!
+}
+
+type Card {
+	serial: ID!
+	transactions: Int!
+	balanceCents: Int!
+	lastUsed: Time!
+}
+
+type Transaction {
+	timestamp: Time!
+	type: String!
+	location: String!
+	route: String!
+	product: String!
+	agency: String!
+	debitCents: Int!
+	creditCents: Int!
+	balanceCents: Int!
+	card: ID!
+}
+
+type MonthTotal {
+	start: Time!
+	trips: Int!
+	spendCents: Int!
+}
+
+type AgencyTotal {
+	agency: String!
+	trips: Int!
+	spendCents: Int!
+}
+
+type SyncStatus {
+	running: Boolean!
+	lastStart: Time
+	lastFinish: Time
+	lastSuccess: Time
+	lastError: String!
+}
+`
+
+// EnableGraphQL serves a GraphQL endpoint at /graphql, which takes a POST of
+// {"query": ..., "variables": ...} and returns the usual data and errors.
+func (s *Server) EnableGraphQL() error {
+	schema, err := graphql.ParseSchema(GraphQLSchema, &resolver{s}, graphql.UseFieldResolvers())
+	if err != nil {
+		return err
+	}
+	s.graphql = schema
+	return nil
+}
+
+func (s *Server) graphQL(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		badRequest(w, r, "Invalid GraphQL request: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.graphql.Exec(r.Context(), params.Query, params.OperationName, params.Variables))
+}
+
+type resolver struct {
+	s *Server
+}
+
+// filterArgs are the arguments that narrow down transactions.
+type filterArgs struct {
+	Card     *graphql.ID
+	From, To *string
+}
+
+func (a filterArgs) apply(txns []clipper.Transaction) ([]clipper.Transaction, error) {
+	var from, to time.Time
+	var err error
+	if a.From != nil {
+		if from, err = clipper.ParseDate(*a.From); err != nil {
+			return nil, err
+		}
+	}
+	if a.To != nil {
+		if to, err = clipper.ParseDate(*a.To); err != nil {
+			return nil, err
+		}
+	}
+	txns = store.Between(txns, from, to)
+	if a.Card == nil {
+		return txns, nil
+	}
+	card, err := strconv.ParseInt(string(*a.Card), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	out := txns[:0:0]
+	for _, t := range txns {
+		if t.CardSerial == card {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (r *resolver) filtered(ctx context.Context, args filterArgs) ([]clipper.Transaction, error) {
+	txns, err := r.s.VisibleTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return args.apply(txns)
+}
+
+type cardResolver struct{ c Card }
+
+func (c cardResolver) Serial() graphql.ID  { return graphql.ID(strconv.FormatInt(c.c.Serial, 10)) }
+func (c cardResolver) Transactions() int32 { return int32(c.c.Transactions) }
+func (c cardResolver) BalanceCents() int32 { return int32(c.c.BalanceCents) }
+func (c cardResolver) LastUsed() graphql.Time {
+	return graphql.Time{Time: c.c.LastUsed}
+}
+
+func (r *resolver) Cards(ctx context.Context) ([]cardResolver, error) {
+	txns, err := r.s.VisibleTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []cardResolver
+	for _, c := range Cards(txns) {
+		out = append(out, cardResolver{c})
+	}
+	return out, nil
+}
+
+type transactionResolver struct{ t clipper.Transaction }
+
+func (t transactionResolver) Timestamp() graphql.Time { return graphql.Time{Time: t.t.Timestamp} }
+func (t transactionResolver) Type() string            { return t.t.Type }
+func (t transactionResolver) Location() string        { return t.t.Location }
+func (t transactionResolver) Route() string           { return t.t.Route }
+func (t transactionResolver) Product() string         { return t.t.Product }
+func (t transactionResolver) Agency() string          { return string(t.t.Agency()) }
+func (t transactionResolver) DebitCents() int32       { return int32(t.t.DebitCents) }
+func (t transactionResolver) CreditCents() int32      { return int32(t.t.CreditCents) }
+func (t transactionResolver) BalanceCents() int32     { return int32(t.t.BalanceCents) }
+func (t transactionResolver) Card() graphql.ID {
+	return graphql.ID(strconv.FormatInt(t.t.CardSerial, 10))
+}
+
+func (r *resolver) Transactions(ctx context.Context, args struct {
+	filterArgs
+	Agency *string
+	Type   *string
+}) ([]transactionResolver, error) {
+	txns, err := r.filtered(ctx, args.filterArgs)
+	if err != nil {
+		return nil, err
+	}
+	var out []transactionResolver
+	for _, t := range txns {
+		if args.Agency != nil && !strings.EqualFold(string(t.Agency()), *args.Agency) {
+			continue
+		}
+		if args.Type != nil && !strings.Contains(strings.ToLower(t.Type), strings.ToLower(*args.Type)) {
+			continue
+		}
+		out = append(out, transactionResolver{t})
+	}
+	return out, nil
+}
+
+type monthResolver struct{ m clipperstats.Month }
+
+func (m monthResolver) Start() graphql.Time { return graphql.Time{Time: m.m.Start} }
+func (m monthResolver) Trips() int32        { return int32(m.m.Trips) }
+func (m monthResolver) SpendCents() int32   { return int32(m.m.SpendCents) }
+
+func (r *resolver) Months(ctx context.Context, args filterArgs) ([]monthResolver, error) {
+	txns, err := r.filtered(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	var out []monthResolver
+	for _, m := range clipperstats.Monthly(txns) {
+		out = append(out, monthResolver{m})
+	}
+	return out, nil
+}
+
+type agencyResolver struct{ a clipperstats.AgencyTotal }
+
+func (a agencyResolver) Agency() string    { return string(a.a.Agency) }
+func (a agencyResolver) Trips() int32      { return int32(a.a.Trips) }
+func (a agencyResolver) SpendCents() int32 { return int32(a.a.SpendCents) }
+
+func (r *resolver) Agencies(ctx context.Context, args filterArgs) ([]agencyResolver, error) {
+	txns, err := r.filtered(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	var out []agencyResolver
+	for _, a := range clipperstats.AgencyTotals(txns) {
+		out = append(out, agencyResolver{a})
+	}
+	return out, nil
+}
+
+type syncResolver struct{ st SyncStatus }
+
+func optionalTime(t time.Time) *graphql.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &graphql.Time{Time: t}
+}
+
+func (s syncResolver) Running() bool              { return s.st.Running }
+func (s syncResolver) LastStart() *graphql.Time   { return optionalTime(s.st.LastStart) }
+func (s syncResolver) LastFinish() *graphql.Time  { return optionalTime(s.st.LastFinish) }
+func (s syncResolver) LastSuccess() *graphql.Time { return optionalTime(s.st.LastSuccess) }
+func (s syncResolver) LastError() string          { return s.st.LastError }
+
+func (r *resolver) Sync(ctx context.Context) syncResolver {
+	return syncResolver{r.s.SyncStatus()}
+}
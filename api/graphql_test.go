@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func graphQL(t *testing.T, srv *Server, query string) (map[string]json.RawMessage, []interface{}) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("POST /graphql: got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data   map[string]json.RawMessage
+		Errors []interface{}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Data, resp.Errors
+}
+
+func TestGraphQL(t *testing.T) {
+	srv := testServer(t, nil)
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ sync { running } }"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != 404 {
+		t.Errorf("GraphQL before EnableGraphQL: got %d, want 404", w.Code)
+	}
+	if err := srv.EnableGraphQL(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, errs := graphQL(t, srv, `{
+		cards { serial balanceCents }
+		transactions(card: "1202728442", from: "2018-01-01", to: "2018-02-01", agency: "samtrans") { agency debitCents }
+		months { trips spendCents }
+		agencies(from: "2017-12-01", to: "2018-01-01") { agency trips }
+		sync { running lastSuccess }
+	}`)
+	if len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	var cards []struct {
+		Serial       string
+		BalanceCents int
+	}
+	json.Unmarshal(data["cards"], &cards)
+	if len(cards) != 1 || cards[0].Serial != "1202728442" || cards[0].BalanceCents != 8975 {
+		t.Errorf("cards: got %+v", cards)
+	}
+	var txns []struct {
+		Agency     string
+		DebitCents int
+	}
+	json.Unmarshal(data["transactions"], &txns)
+	if len(txns) != 4 {
+		t.Errorf("expected 4 SamTrans rides in January, got %+v", txns)
+	}
+	for _, txn := range txns {
+		if txn.Agency != "SamTrans" || txn.DebitCents != 205 {
+			t.Errorf("transaction: got %+v", txn)
+		}
+	}
+	var months []struct{ Trips int }
+	json.Unmarshal(data["months"], &months)
+	if len(months) != 3 {
+		t.Errorf("expected 3 months, got %+v", months)
+	}
+	var agencies []struct{ Agency string }
+	json.Unmarshal(data["agencies"], &agencies)
+	if len(agencies) == 0 {
+		t.Error("expected agencies in December")
+	}
+	var sync struct {
+		Running     bool
+		LastSuccess *string
+	}
+	json.Unmarshal(data["sync"], &sync)
+	if sync.Running || sync.LastSuccess != nil {
+		t.Errorf("sync: got %s", data["sync"])
+	}
+
+	if _, errs := graphQL(t, srv, `{ transactions(from: "yesterday") { type } }`); len(errs) == 0 {
+		t.Error("expected an error for a bad date")
+	}
+}
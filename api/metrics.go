@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kevinburke/rest"
+)
+
+// An ErrorCounter counts failed requests made through the transports it
+// wraps: requests that got no response, and responses with a 4xx or 5xx
+// status.
+type ErrorCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+type countingTransport struct {
+	rt http.RoundTripper
+	c  *ErrorCounter
+}
+
+func (t countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.rt.RoundTrip(req)
+	switch {
+	case err != nil:
+		t.c.add("network")
+	case resp.StatusCode >= 500:
+		t.c.add("5xx")
+	case resp.StatusCode >= 400:
+		t.c.add("4xx")
+	}
+	return resp, err
+}
+
+// Wrap returns a transport that sends requests with rt and counts the ones
+// that fail.
+func (c *ErrorCounter) Wrap(rt http.RoundTripper) http.RoundTripper {
+	return countingTransport{rt: rt, c: c}
+}
+
+func (c *ErrorCounter) add(kind string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[kind]++
+}
+
+// Counts returns the number of failures of each kind: "network", "4xx" or
+// "5xx".
+func (c *ErrorCounter) Counts() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]int, len(c.counts))
+	for k, v := range c.counts {
+		out[k] = v
+	}
+	return out
+}
+
+// metricsWriter writes metrics in the Prometheus text format.
+type metricsWriter struct {
+	bytes.Buffer
+	last string
+}
+
+func (m *metricsWriter) metric(name, typ, help string, labels string, value float64) {
+	if name != m.last {
+		fmt.Fprintf(m, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+		m.last = name
+	}
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(m, "%s%s %s\n", name, labels, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func seconds(t time.Time) float64 { return float64(t.UnixNano()) / 1e9 }
+
+func (s *Server) metrics(w http.ResponseWriter, r *http.Request) {
+	txns, err := s.VisibleTransactions(r.Context())
+	if err != nil {
+		rest.ServerError(w, r, err)
+		return
+	}
+	m := new(metricsWriter)
+	for _, c := range Cards(txns) {
+		label := fmt.Sprintf("card=%q", strconv.FormatInt(c.Serial, 10))
+		m.metric("clipper_card_balance_dollars", "gauge", "The card's balance after its latest transaction.", label, c.BalanceCents.Dollars())
+	}
+	for _, c := range Cards(txns) {
+		label := fmt.Sprintf("card=%q", strconv.FormatInt(c.Serial, 10))
+		m.metric("clipper_card_last_transaction_timestamp_seconds", "gauge", "When the card's latest transaction happened.", label, seconds(c.LastUsed))
+	}
+	m.metric("clipper_transactions", "gauge", "Transactions in the archive.", "", float64(len(txns)))
+
+	s.mu.Lock()
+	st, syncs, synced := s.status, s.syncs, s.transactionsSynced
+	s.mu.Unlock()
+	running := 0.0
+	if st.Running {
+		running = 1
+	}
+	m.metric("clipper_sync_running", "gauge", "Whether statements are being downloaded.", "", running)
+	for _, result := range []string{"failure", "success"} {
+		m.metric("clipper_syncs_total", "counter", "Finished syncs, by result.", fmt.Sprintf("result=%q", result), float64(syncs[result]))
+	}
+	m.metric("clipper_transactions_synced_total", "counter", "New transactions found by syncs.", "", float64(synced))
+	if !st.LastSuccess.IsZero() {
+		m.metric("clipper_last_successful_sync_timestamp_seconds", "gauge", "When the last successful sync finished.", "", seconds(st.LastSuccess))
+	}
+	if s.ClientErrors != nil {
+		counts := s.ClientErrors.Counts()
+		kinds := []string{"4xx", "5xx", "network"}
+		for k := range counts {
+			if k != "4xx" && k != "5xx" && k != "network" {
+				kinds = append(kinds, k)
+			}
+		}
+		sort.Strings(kinds)
+		for _, k := range kinds {
+			m.metric("clipper_http_client_errors_total", "counter", "Failed requests to the Clipper website while syncing.", fmt.Sprintf("kind=%q", k), float64(counts[k]))
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(m.Bytes())
+}
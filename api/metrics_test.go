@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestErrorCounter(t *testing.T) {
+	c := new(ErrorCounter)
+	codes := []int{200, 404, 503, 0}
+	rt := c.Wrap(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		code := codes[0]
+		codes = codes[1:]
+		if code == 0 {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: code}, nil
+	}))
+	for i := 0; i < 4; i++ {
+		rt.RoundTrip(httptest.NewRequest("GET", "/", nil))
+	}
+	got := c.Counts()
+	if got["4xx"] != 1 || got["5xx"] != 1 || got["network"] != 1 || len(got) != 3 {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	srv := testServer(t, func(ctx context.Context, user string) error { return nil })
+	srv.ClientErrors = new(ErrorCounter)
+	srv.ClientErrors.add("5xx")
+	if !srv.Sync() {
+		t.Fatal("sync didn't start")
+	}
+	for deadline := time.Now().Add(5 * time.Second); srv.SyncStatus().Running; {
+		if time.Now().After(deadline) {
+			t.Fatal("sync didn't finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("GET /metrics: got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE clipper_card_balance_dollars gauge\n",
+		`clipper_card_balance_dollars{card="1202728442"} 89.75` + "\n",
+		`clipper_syncs_total{result="success"} 1` + "\n",
+		"clipper_transactions_synced_total 0\n",
+		"clipper_last_successful_sync_timestamp_seconds ",
+		`clipper_http_client_errors_total{kind="5xx"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
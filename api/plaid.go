@@ -0,0 +1,270 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/store"
+	"github.com/kevinburke/clipper/transit"
+)
+
+// The Plaid endpoints mimic Plaid's /accounts/get and /transactions/get, so
+// that budgeting tools that already speak Plaid can treat the archive as one
+// more institution. Each card is an account. Requests carry the server's
+// token as the access_token (or secret) in the JSON body, as Plaid clients
+// do, or in the Authorization header.
+
+// PlaidAccount is an account as Plaid describes it.
+type PlaidAccount struct {
+	AccountID    string        `json:"account_id"`
+	Balances     PlaidBalances `json:"balances"`
+	Mask         string        `json:"mask"`
+	Name         string        `json:"name"`
+	OfficialName string        `json:"official_name"`
+	Type         string        `json:"type"`
+	Subtype      string        `json:"subtype"`
+}
+
+// PlaidBalances are an account's balances, in dollars.
+type PlaidBalances struct {
+	Available       float64  `json:"available"`
+	Current         float64  `json:"current"`
+	Limit           *float64 `json:"limit"`
+	ISOCurrencyCode string   `json:"iso_currency_code"`
+}
+
+// PlaidCategory is Plaid's personal finance category of a transaction.
+type PlaidCategory struct {
+	Primary  string `json:"primary"`
+	Detailed string `json:"detailed"`
+}
+
+// PlaidTransaction is a transaction as Plaid describes it. Amount is in
+// dollars, positive when money leaves the card (a fare) and negative when
+// it's added (a reload).
+type PlaidTransaction struct {
+	TransactionID           string        `json:"transaction_id"`
+	AccountID               string        `json:"account_id"`
+	Amount                  float64       `json:"amount"`
+	ISOCurrencyCode         string        `json:"iso_currency_code"`
+	Category                []string      `json:"category"`
+	CategoryID              string        `json:"category_id"`
+	PersonalFinanceCategory PlaidCategory `json:"personal_finance_category"`
+	Date                    string        `json:"date"`
+	Datetime                time.Time     `json:"datetime"`
+	AuthorizedDate          string        `json:"authorized_date"`
+	Name                    string        `json:"name"`
+	MerchantName            string        `json:"merchant_name,omitempty"`
+	PaymentChannel          string        `json:"payment_channel"`
+	Pending                 bool          `json:"pending"`
+}
+
+type plaidItem struct {
+	ItemID         string   `json:"item_id"`
+	InstitutionID  string   `json:"institution_id"`
+	BilledProducts []string `json:"billed_products"`
+}
+
+var clipperItem = plaidItem{ItemID: "clipper", InstitutionID: "clipper", BilledProducts: []string{"transactions"}}
+
+type plaidRequest struct {
+	AccessToken string `json:"access_token"`
+	Secret      string `json:"secret"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date"`
+	Options     struct {
+		AccountIDs []string `json:"account_ids"`
+		Count      *int     `json:"count"`
+		Offset     int      `json:"offset"`
+	} `json:"options"`
+}
+
+// EnablePlaid serves Plaid-compatible endpoints at /plaid/accounts/get and
+// /plaid/transactions/get.
+func (s *Server) EnablePlaid() { s.plaid = true }
+
+func plaidAccountID(serial int64) string { return "clipper-" + strconv.FormatInt(serial, 10) }
+
+func plaidAccount(c Card) PlaidAccount {
+	serial := strconv.FormatInt(c.Serial, 10)
+	mask := serial
+	if len(mask) > 4 {
+		mask = mask[len(mask)-4:]
+	}
+	balance := c.BalanceCents.Dollars()
+	return PlaidAccount{
+		AccountID:    plaidAccountID(c.Serial),
+		Balances:     PlaidBalances{Available: balance, Current: balance, ISOCurrencyCode: "USD"},
+		Mask:         mask,
+		Name:         "Clipper card " + serial,
+		OfficialName: "Clipper card " + serial,
+		Type:         "depository",
+		Subtype:      "prepaid",
+	}
+}
+
+func plaidTransaction(t clipper.Transaction) PlaidTransaction {
+	pt := PlaidTransaction{
+		TransactionID:   store.ID(t),
+		AccountID:       plaidAccountID(t.CardSerial),
+		Amount:          (t.DebitCents - t.CreditCents).Dollars(),
+		ISOCurrencyCode: "USD",
+		Date:            t.Timestamp.Format("2006-01-02"),
+		Datetime:        t.Timestamp,
+		AuthorizedDate:  t.Timestamp.Format("2006-01-02"),
+		Name:            t.Type,
+		PaymentChannel:  "in store",
+	}
+	if t.Location != "" {
+		pt.Name = t.Location
+	}
+	if a := t.Agency(); a != transit.AgencyUnknown {
+		pt.MerchantName = string(a)
+	}
+	if t.IsReload() {
+		pt.Category = []string{"Transfer", "Deposit"}
+		pt.CategoryID = "21007000"
+		pt.PersonalFinanceCategory = PlaidCategory{Primary: "TRANSFER_IN", Detailed: "TRANSFER_IN_ACCOUNT_TRANSFER"}
+	} else {
+		pt.Category = []string{"Travel", "Public Transportation Services"}
+		pt.CategoryID = "22014000"
+		pt.PersonalFinanceCategory = PlaidCategory{Primary: "TRANSPORTATION", Detailed: "TRANSPORTATION_PUBLIC_TRANSIT"}
+	}
+	return pt
+}
+
+func requestID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// plaidError writes an error in Plaid's format.
+func plaidError(w http.ResponseWriter, code int, errorType, errorCode, format string, args ...interface{}) {
+	writeJSON(w, code, map[string]interface{}{
+		"error_type":      errorType,
+		"error_code":      errorCode,
+		"error_message":   fmt.Sprintf(format, args...),
+		"display_message": nil,
+		"request_id":      requestID(),
+	})
+}
+
+func (s *Server) plaidHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.plaid {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != "POST" {
+		plaidError(w, http.StatusMethodNotAllowed, "INVALID_REQUEST", "INVALID_HTTP_METHOD", "use POST")
+		return
+	}
+	var req plaidRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		plaidError(w, http.StatusBadRequest, "INVALID_REQUEST", "INVALID_BODY", "request body is not valid JSON: %v", err)
+		return
+	}
+	t, ok := s.plaidToken(r, req)
+	if !ok {
+		plaidError(w, http.StatusBadRequest, "INVALID_INPUT", "INVALID_ACCESS_TOKEN", "provided access token is in an invalid format")
+		return
+	}
+	txns, err := s.VisibleTransactions(NewContext(r.Context(), t))
+	if err != nil {
+		plaidError(w, http.StatusInternalServerError, "API_ERROR", "INTERNAL_SERVER_ERROR", "%v", err)
+		return
+	}
+	accounts := []PlaidAccount{}
+	for _, c := range Cards(txns) {
+		accounts = append(accounts, plaidAccount(c))
+	}
+	switch r.URL.Path {
+	case "/plaid/accounts/get":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"accounts": accounts, "item": clipperItem, "request_id": requestID()})
+	case "/plaid/transactions/get":
+		s.plaidTransactions(w, req, accounts, txns)
+	default:
+		plaidError(w, http.StatusNotFound, "INVALID_REQUEST", "UNKNOWN_FIELDS", "%s is not supported", r.URL.Path)
+	}
+}
+
+// plaidToken returns the first of the token in the Authorization header, or
+// the access_token or secret in req, that has the read scope.
+func (s *Server) plaidToken(r *http.Request, req plaidRequest) (Token, bool) {
+	for _, tok := range []string{tokenFrom(r), req.AccessToken, req.Secret} {
+		if t, ok := s.Token(tok); ok && hasScope(t.Scopes, ScopeRead) {
+			return t, true
+		}
+	}
+	return Token{}, false
+}
+
+func (s *Server) plaidTransactions(w http.ResponseWriter, req plaidRequest, accounts []PlaidAccount, txns []clipper.Transaction) {
+	if req.StartDate == "" || req.EndDate == "" {
+		plaidError(w, http.StatusBadRequest, "INVALID_REQUEST", "MISSING_FIELDS", "the following required fields are missing: start_date, end_date")
+		return
+	}
+	start, err := clipper.ParseDate(req.StartDate)
+	if err != nil {
+		plaidError(w, http.StatusBadRequest, "INVALID_REQUEST", "INVALID_FIELD", "start_date must be a date in YYYY-MM-DD format")
+		return
+	}
+	end, err := clipper.ParseDate(req.EndDate)
+	if err != nil {
+		plaidError(w, http.StatusBadRequest, "INVALID_REQUEST", "INVALID_FIELD", "end_date must be a date in YYYY-MM-DD format")
+		return
+	}
+	count := 100
+	if req.Options.Count != nil {
+		count = *req.Options.Count
+	}
+	if count < 1 || count > 500 || req.Options.Offset < 0 {
+		plaidError(w, http.StatusBadRequest, "INVALID_REQUEST", "INVALID_FIELD", "count must be between 1 and 500, and offset at least 0")
+		return
+	}
+	want := make(map[string]bool)
+	for _, id := range req.Options.AccountIDs {
+		want[id] = true
+	}
+	if len(want) > 0 {
+		filtered := []PlaidAccount{}
+		for _, a := range accounts {
+			if want[a.AccountID] {
+				filtered = append(filtered, a)
+			}
+		}
+		accounts = filtered
+	}
+	out := []PlaidTransaction{}
+	for _, t := range store.Between(txns, start, end.AddDate(0, 0, 1)) {
+		if len(want) > 0 && !want[plaidAccountID(t.CardSerial)] {
+			continue
+		}
+		out = append(out, plaidTransaction(t))
+	}
+	// Plaid lists the most recent transactions first.
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Datetime.After(out[j].Datetime) })
+	total := len(out)
+	if req.Options.Offset < len(out) {
+		out = out[req.Options.Offset:]
+	} else {
+		out = out[:0]
+	}
+	if len(out) > count {
+		out = out[:count]
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"accounts":           accounts,
+		"transactions":       out,
+		"total_transactions": total,
+		"item":               clipperItem,
+		"request_id":         requestID(),
+	})
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func plaidPost(t *testing.T, srv *Server, path, body string, v interface{}) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader(body)))
+	if v != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("POST %s: %v: %s", path, err, w.Body.String())
+		}
+	}
+	return w.Code
+}
+
+func TestPlaid(t *testing.T) {
+	srv := testServer(t, nil)
+	const req = `{"access_token": "secret", "start_date": "2018-01-01", "end_date": "2018-02-01", "options": {"count": 2, "offset": 1}}`
+	if code := plaidPost(t, srv, "/plaid/transactions/get", req, nil); code != 404 {
+		t.Errorf("disabled: got %d, want 404", code)
+	}
+	srv.EnablePlaid()
+	var errResp struct {
+		ErrorCode string `json:"error_code"`
+	}
+	if code := plaidPost(t, srv, "/plaid/transactions/get", `{"access_token": "wrong", "start_date": "2018-01-01", "end_date": "2018-02-01"}`, &errResp); code != 400 || errResp.ErrorCode != "INVALID_ACCESS_TOKEN" {
+		t.Errorf("bad token: got %d %+v", code, errResp)
+	}
+	if code := plaidPost(t, srv, "/plaid/transactions/get", `{"access_token": "secret"}`, &errResp); code != 400 || errResp.ErrorCode != "MISSING_FIELDS" {
+		t.Errorf("no dates: got %d %+v", code, errResp)
+	}
+
+	var resp struct {
+		Accounts          []PlaidAccount
+		Transactions      []PlaidTransaction
+		TotalTransactions int `json:"total_transactions"`
+	}
+	if code := plaidPost(t, srv, "/plaid/transactions/get", req, &resp); code != 200 {
+		t.Fatalf("got %d", code)
+	}
+	if len(resp.Accounts) != 1 || resp.Accounts[0].AccountID != "clipper-1202728442" || resp.Accounts[0].Mask != "8442" || resp.Accounts[0].Balances.Current != 89.75 {
+		t.Errorf("accounts: got %+v", resp.Accounts)
+	}
+	if len(resp.Transactions) != 2 || resp.TotalTransactions < 4 {
+		t.Fatalf("got %d of %d transactions", len(resp.Transactions), resp.TotalTransactions)
+	}
+	first, second := resp.Transactions[0], resp.Transactions[1]
+	if first.Datetime.Before(second.Datetime) || first.Date > "2018-02-01" {
+		t.Errorf("expected newest first: %s then %s", first.Date, second.Date)
+	}
+	if first.Category[0] != "Travel" || first.Amount < 0 || first.TransactionID == "" {
+		t.Errorf("fare: got %+v", first)
+	}
+}
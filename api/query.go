@@ -0,0 +1,263 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/store"
+)
+
+// MaxLimit is the most transactions GET /transactions returns in one page.
+const MaxLimit = 1000
+
+// Transaction categories, for filtering with ?category=.
+const (
+	CategoryFare   = "fare"
+	CategoryReload = "reload"
+	CategoryOther  = "other"
+)
+
+// category returns t's category.
+func category(t clipper.Transaction) string {
+	switch {
+	case t.IsFare():
+		return CategoryFare
+	case t.IsReload():
+		return CategoryReload
+	}
+	return CategoryOther
+}
+
+// amountCents is what t cost: debits are positive and credits negative.
+func amountCents(t clipper.Transaction) clipper.Money { return t.DebitCents - t.CreditCents }
+
+// A txnSort orders transactions. Ties are broken by time and then by ID, so
+// every transaction has a distinct place and a cursor can resume after it.
+type txnSort struct {
+	name string
+	// primary returns the sort key of t before the tie breakers; it's zero
+	// for sorts by time.
+	primary func(t clipper.Transaction) int64
+	desc    bool
+}
+
+var txnSorts = map[string]txnSort{
+	"time":    {name: "time", primary: func(clipper.Transaction) int64 { return 0 }},
+	"-time":   {name: "-time", primary: func(clipper.Transaction) int64 { return 0 }, desc: true},
+	"amount":  {name: "amount", primary: func(t clipper.Transaction) int64 { return int64(amountCents(t)) }},
+	"-amount": {name: "-amount", primary: func(t clipper.Transaction) int64 { return int64(amountCents(t)) }, desc: true},
+}
+
+// A cursor is the position of the last transaction on a page.
+type cursor struct {
+	Sort    string `json:"s"`
+	Primary int64  `json:"p"`
+	Time    int64  `json:"t"`
+	ID      string `json:"i"`
+}
+
+func (s txnSort) cursorFor(t clipper.Transaction) cursor {
+	return cursor{Sort: s.name, Primary: s.primary(t), Time: t.Timestamp.UnixNano(), ID: store.ID(t)}
+}
+
+// less reports whether a comes before b.
+func (s txnSort) less(a, b cursor) bool {
+	if a.Primary != b.Primary {
+		return (a.Primary < b.Primary) != s.desc
+	}
+	if a.Time != b.Time {
+		return (a.Time < b.Time) != s.desc
+	}
+	if a.ID != b.ID {
+		return (a.ID < b.ID) != s.desc
+	}
+	return false
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.ID == "" {
+		return cursor{}, fmt.Errorf("Invalid cursor %q", s)
+	}
+	return c, nil
+}
+
+// A txnQuery is a parsed GET /transactions request.
+type txnQuery struct {
+	from, to   time.Time
+	card       int64
+	agencies   []string
+	minCents   *clipper.Money
+	maxCents   *clipper.Money
+	category   string
+	text       string
+	sort       txnSort
+	limit      int
+	after      *cursor
+	paginating bool
+}
+
+func parseTxnQuery(q url.Values) (txnQuery, error) {
+	var tq txnQuery
+	var err error
+	if v := q.Get("from"); v != "" {
+		if tq.from, err = clipper.ParseDate(v); err != nil {
+			return tq, fmt.Errorf("Invalid from date %q; use YYYY-MM-DD", v)
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if tq.to, err = clipper.ParseDate(v); err != nil {
+			return tq, fmt.Errorf("Invalid to date %q; use YYYY-MM-DD", v)
+		}
+	}
+	if v := q.Get("card"); v != "" {
+		if tq.card, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return tq, fmt.Errorf("Invalid card serial number %q", v)
+		}
+	}
+	if v := q.Get("agency"); v != "" {
+		for _, a := range strings.Split(v, ",") {
+			tq.agencies = append(tq.agencies, strings.TrimSpace(a))
+		}
+	}
+	for name, dst := range map[string]**clipper.Money{"min_amount_cents": &tq.minCents, "max_amount_cents": &tq.maxCents} {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return tq, fmt.Errorf("Invalid %s %q; use a whole number of cents", name, v)
+			}
+			m := clipper.Money(n)
+			*dst = &m
+		}
+	}
+	switch v := q.Get("category"); v {
+	case "", CategoryFare, CategoryReload, CategoryOther:
+		tq.category = v
+	default:
+		return tq, fmt.Errorf("Invalid category %q; use fare, reload or other", v)
+	}
+	tq.text = strings.ToLower(strings.TrimSpace(q.Get("q")))
+
+	sortName := q.Get("sort")
+	if sortName == "" {
+		sortName = "time"
+	}
+	var ok bool
+	if tq.sort, ok = txnSorts[sortName]; !ok {
+		return tq, fmt.Errorf("Invalid sort %q; use time, -time, amount or -amount", sortName)
+	}
+	if v := q.Get("limit"); v != "" {
+		if tq.limit, err = strconv.Atoi(v); err != nil || tq.limit < 1 || tq.limit > MaxLimit {
+			return tq, fmt.Errorf("Invalid limit %q; use a number from 1 to %d", v, MaxLimit)
+		}
+		tq.paginating = true
+	}
+	if v := q.Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil {
+			return tq, err
+		}
+		if c.Sort != tq.sort.name {
+			return tq, fmt.Errorf("This cursor is for sort=%s, not sort=%s", c.Sort, tq.sort.name)
+		}
+		tq.after = &c
+		tq.paginating = true
+	}
+	if tq.paginating && tq.limit == 0 {
+		tq.limit = 100
+	}
+	return tq, nil
+}
+
+func (tq txnQuery) match(t clipper.Transaction) bool {
+	if tq.card != 0 && t.CardSerial != tq.card {
+		return false
+	}
+	if len(tq.agencies) > 0 {
+		found := false
+		for _, a := range tq.agencies {
+			if strings.EqualFold(a, string(t.Agency())) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	amount := amountCents(t)
+	if tq.minCents != nil && amount < *tq.minCents {
+		return false
+	}
+	if tq.maxCents != nil && amount > *tq.maxCents {
+		return false
+	}
+	if tq.category != "" && category(t) != tq.category {
+		return false
+	}
+	if tq.text != "" {
+		haystack := strings.ToLower(strings.Join([]string{t.Type, t.Location, t.Route, t.Product, string(t.Agency())}, "\x00"))
+		if !strings.Contains(haystack, tq.text) {
+			return false
+		}
+	}
+	return true
+}
+
+// run returns the page of txns that tq asks for, the number of transactions
+// that match tq across every page, and the cursor for the next page, or nil
+// if this is the last one.
+func (tq txnQuery) run(txns []clipper.Transaction) (page []clipper.Transaction, total int, next *cursor) {
+	type entry struct {
+		t clipper.Transaction
+		c cursor
+	}
+	var matches []entry
+	for _, t := range store.Between(txns, tq.from, tq.to) {
+		if tq.match(t) {
+			matches = append(matches, entry{t, tq.sort.cursorFor(t)})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return tq.sort.less(matches[i].c, matches[j].c) })
+	total = len(matches)
+	start := 0
+	if tq.after != nil {
+		start = sort.Search(len(matches), func(i int) bool { return tq.sort.less(*tq.after, matches[i].c) })
+	}
+	end := len(matches)
+	if tq.paginating && start+tq.limit < end {
+		end = start + tq.limit
+		next = &matches[end-1].c
+	}
+	page = make([]clipper.Transaction, 0, end-start)
+	for _, e := range matches[start:end] {
+		page = append(page, e.t)
+	}
+	return page, total, next
+}
+
+// setNextLink points the Link header at the page after r's.
+func setNextLink(w http.ResponseWriter, r *http.Request, next cursor) {
+	q := r.URL.Query()
+	q.Del("token")
+	q.Set("cursor", next.encode())
+	u := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, u.String()))
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper"
+)
+
+func TestTransactionPages(t *testing.T) {
+	srv := testServer(t, nil)
+	var all []Transaction
+	get(t, srv, "/transactions", &all)
+
+	var paged []Transaction
+	path := "/transactions?limit=7"
+	for pages := 0; path != ""; pages++ {
+		if pages > len(all) {
+			t.Fatal("too many pages")
+		}
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != 200 {
+			t.Fatalf("GET %s: got %d: %s", path, w.Code, w.Body.String())
+		}
+		if total := w.Header().Get("X-Total-Count"); total != strconv.Itoa(len(all)) {
+			t.Errorf("X-Total-Count: got %s, want %d", total, len(all))
+		}
+		var page []Transaction
+		get(t, srv, path, &page)
+		paged = append(paged, page...)
+		path = ""
+		if link := w.Header().Get("Link"); link != "" {
+			path = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+		}
+	}
+	if len(paged) != len(all) {
+		t.Fatalf("got %d transactions in pages, want %d", len(paged), len(all))
+	}
+	for i := range all {
+		if paged[i].ID != all[i].ID {
+			t.Fatalf("transaction %d: got %s, want %s", i, paged[i].ID, all[i].ID)
+		}
+	}
+
+	var newest []Transaction
+	get(t, srv, "/transactions?sort=-time&limit=1", &newest)
+	if len(newest) != 1 || newest[0].ID != all[len(all)-1].ID {
+		t.Errorf("sort=-time: got %+v", newest)
+	}
+	for _, bad := range []string{"limit=0", "limit=1001", "sort=location", "cursor=nope", "category=bus", "min_amount_cents=1.50"} {
+		if code := get(t, srv, "/transactions?"+bad, nil); code != 400 {
+			t.Errorf("%s: got %d, want 400", bad, code)
+		}
+	}
+}
+
+func TestTransactionFilters(t *testing.T) {
+	srv := testServer(t, nil)
+	var all []Transaction
+	get(t, srv, "/transactions", &all)
+	for query, ok := range map[string]func(Transaction) bool{
+		"category=fare":        func(t Transaction) bool { return t.Category == CategoryFare },
+		"min_amount_cents=200": func(t Transaction) bool { return t.DebitCents-t.CreditCents >= 200 },
+		"max_amount_cents=-1":  func(t Transaction) bool { return t.CreditCents > t.DebitCents },
+		"q=bus":                func(t Transaction) bool { return strings.Contains(strings.ToLower(t.Location), "bus") },
+	} {
+		var got []Transaction
+		if code := get(t, srv, "/transactions?"+query, &got); code != 200 {
+			t.Fatalf("%s: got %d", query, code)
+		}
+		want := 0
+		for _, txn := range all {
+			if ok(txn) {
+				want++
+			}
+		}
+		if len(got) != want || want == 0 {
+			t.Errorf("%s: got %d transactions, want %d", query, len(got), want)
+		}
+	}
+}
+
+func TestCursorStable(t *testing.T) {
+	base := time.Date(2018, time.January, 1, 8, 0, 0, 0, time.UTC)
+	var txns []clipper.Transaction
+	for i := 0; i < 4; i++ {
+		txns = append(txns, clipper.Transaction{Timestamp: base.Add(time.Duration(i) * time.Hour), Type: "Single-tag fare payment", DebitCents: 250, CardSerial: 1})
+	}
+	tq, err := parseTxnQuery(url.Values{"limit": {"2"}, "sort": {"-time"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, total, next := tq.run(txns)
+	if len(first) != 2 || total != 4 || next == nil || !first[0].Timestamp.Equal(txns[3].Timestamp) {
+		t.Fatalf("first page: got %v, %d, %v", first, total, next)
+	}
+	// A newer transaction arrives before the client asks for page two.
+	txns = append(txns, clipper.Transaction{Timestamp: base.Add(10 * time.Hour), Type: "Single-tag fare payment", DebitCents: 250, CardSerial: 1})
+	tq, err = parseTxnQuery(url.Values{"limit": {"2"}, "sort": {"-time"}, "cursor": {next.encode()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, next := tq.run(txns)
+	if len(second) != 2 || !second[0].Timestamp.Equal(txns[1].Timestamp) || !second[1].Timestamp.Equal(txns[0].Timestamp) || next != nil {
+		t.Errorf("second page: got %v, next %v", second, next)
+	}
+	timeCursor := cursor{Sort: "-time", ID: "x"}.encode()
+	if _, err := parseTxnQuery(url.Values{"sort": {"amount"}, "cursor": {timeCursor}}); err == nil {
+		t.Error("expected an error using a -time cursor with sort=amount")
+	}
+}
+
+func TestDatesArePacific(t *testing.T) {
+	// 11pm on February 29 in California is already March 1 in UTC.
+	late := clipper.Transaction{Timestamp: time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC), Type: "Single-tag fare payment", DebitCents: 250, CardSerial: 1}
+	tq, err := parseTxnQuery(url.Values{"from": {"2024-03-01"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, clipper.Pacific); !tq.from.Equal(want) {
+		t.Errorf("from: got %v, want %v", tq.from, want)
+	}
+	if got, _, _ := tq.run([]clipper.Transaction{late}); len(got) != 0 {
+		t.Errorf("from=2024-03-01 included a ride on February 29: %v", got)
+	}
+}
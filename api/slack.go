@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clipperstats"
+	"github.com/kevinburke/clipper/notify"
+	"github.com/kevinburke/handlers"
+	"github.com/kevinburke/rest"
+)
+
+// slackMaxAge is how old a slash command's timestamp may be, to stop replays.
+const slackMaxAge = 5 * time.Minute
+
+// verifySlack reports whether r, with the given body, was signed by Slack
+// with secret.
+func verifySlack(r *http.Request, body []byte, secret string, now time.Time) bool {
+	ts := r.Header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(sec, 0)); age > slackMaxAge || age < -slackMaxAge {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(want), []byte(r.Header.Get("X-Slack-Signature")))
+}
+
+const slackUsage = "Usage: `/clipper balance` for card balances, or `/clipper last 5` for recent transactions."
+
+// slack answers Slack slash commands. Slack can't send the API token, so
+// requests are checked against SlackSigningSecret instead.
+func (s *Server) slack(w http.ResponseWriter, r *http.Request) {
+	if s.SlackSigningSecret == "" {
+		rest.NotFound(w, r)
+		return
+	}
+	if r.Method != "POST" {
+		rest.NotAllowed(w, r)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<16))
+	if err != nil {
+		rest.ServerError(w, r, err)
+		return
+	}
+	if !verifySlack(r, body, s.SlackSigningSecret, time.Now()) {
+		rest.Unauthorized(w, r, "clipper")
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	text, err := s.slackCommand(strings.Fields(r.FormValue("text")))
+	if err != nil {
+		rest.ServerError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"response_type": "ephemeral", "text": text})
+}
+
+// slackCommand returns the reply to a slash command with the given words.
+func (s *Server) slackCommand(words []string) (string, error) {
+	if len(words) == 0 {
+		return slackUsage, nil
+	}
+	txns, err := s.Transactions()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	switch strings.ToLower(words[0]) {
+	case "balance", "balances":
+		cards := Cards(txns)
+		if len(cards) == 0 {
+			return "No cards in the archive yet.", nil
+		}
+		for _, c := range cards {
+			fmt.Fprintf(&b, "Card %d: %s (last used %s)\n", c.Serial, c.BalanceCents, c.LastUsed.Format("Jan 2, 2006"))
+		}
+	case "last":
+		n := 5
+		if len(words) > 1 {
+			if n, err = strconv.Atoi(words[1]); err != nil || n <= 0 {
+				return slackUsage, nil
+			}
+		}
+		if n > 50 {
+			n = 50
+		}
+		if n > len(txns) {
+			n = len(txns)
+		}
+		if n == 0 {
+			return "No transactions in the archive yet.", nil
+		}
+		for i := len(txns) - 1; i >= len(txns)-n; i-- {
+			t := txns[i]
+			amount := (t.CreditCents - t.DebitCents).String()
+			fmt.Fprintf(&b, "%s  %s  %s  %s  card %d\n", t.Timestamp.Format("Jan 2 15:04"), amount, t.Type, t.Location, t.CardSerial)
+		}
+	default:
+		return slackUsage, nil
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// alerts returns the messages to send after a sync turns up transactions in
+// after that weren't in before: cards whose balance fell below
+// LowBalanceCents, and unusual activity in the new transactions.
+func alerts(before, after []clipper.Transaction) []notify.Message {
+	var out []notify.Message
+	was := make(map[int64]clipper.Money)
+	for _, c := range Cards(before) {
+		was[c.Serial] = c.BalanceCents
+	}
+	for _, c := range Cards(after) {
+		old, ok := was[c.Serial]
+		if c.BalanceCents < LowBalanceCents && (!ok || old >= LowBalanceCents) {
+			out = append(out, notify.Message{
+				Kind:    "low_balance",
+				Subject: fmt.Sprintf("Clipper card %d is running low", c.Serial),
+				Body:    fmt.Sprintf("The balance is %s.", c.BalanceCents),
+				Time:    c.LastUsed,
+			})
+		}
+	}
+	// Everything looks new on the first sync, so there's no history to
+	// compare with.
+	if len(before) == 0 {
+		return out
+	}
+	since := before[len(before)-1].Timestamp.Add(time.Nanosecond)
+	for _, a := range clipperstats.Anomalies(after, since, clipperstats.AnomalyOptions{}) {
+		out = append(out, notify.Message{
+			Kind:    string(a.Kind),
+			Subject: fmt.Sprintf("Unusual activity on Clipper card %d", a.CardSerial),
+			Body:    a.Description,
+			Time:    a.Time,
+		})
+	}
+	return out
+}
+
+func (s *Server) sendAlerts(before, after []clipper.Transaction) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, m := range alerts(before, after) {
+		if err := s.Notifier.Notify(ctx, m); err != nil {
+			handlers.Logger.Warn("error sending alert", "kind", m.Kind, "err", err)
+		}
+	}
+}
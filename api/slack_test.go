@@ -0,0 +1,81 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper"
+)
+
+func slackRequest(t *testing.T, srv *Server, secret, text string) (int, string) {
+	t.Helper()
+	body := url.Values{"command": {"/clipper"}, "text": {text}}.Encode()
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+	req := httptest.NewRequest("POST", "/slack", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	var resp struct{ Text string }
+	if w.Code == 200 {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code, resp.Text
+}
+
+func TestSlack(t *testing.T) {
+	srv := testServer(t, nil)
+	if code, _ := slackRequest(t, srv, "shh", "balance"); code != 404 {
+		t.Errorf("slack disabled: got %d, want 404", code)
+	}
+	srv.SlackSigningSecret = "shh"
+	if code, _ := slackRequest(t, srv, "wrong", "balance"); code != 401 {
+		t.Errorf("bad signature: got %d, want 401", code)
+	}
+	code, text := slackRequest(t, srv, "shh", "balance")
+	if code != 200 || !strings.Contains(text, "Card 1202728442: $89.75") {
+		t.Errorf("balance: got %d %q", code, text)
+	}
+	if _, text := slackRequest(t, srv, "shh", "last 2"); strings.Count(text, "\n") != 1 {
+		t.Errorf("last 2: got %q", text)
+	}
+	if _, text := slackRequest(t, srv, "shh", "bogus"); text != slackUsage {
+		t.Errorf("unknown command: got %q", text)
+	}
+}
+
+func TestAlerts(t *testing.T) {
+	day := func(d int, balance clipper.Money) clipper.Transaction {
+		return clipper.Transaction{
+			Timestamp:    time.Date(2018, 1, d, 8, 0, 0, 0, time.UTC),
+			Type:         "Single-tag fare payment",
+			Location:     "Muni bus",
+			DebitCents:   250,
+			BalanceCents: balance,
+			CardSerial:   7,
+		}
+	}
+	before := []clipper.Transaction{day(1, 1500), day(2, 1250)}
+	after := append(before, day(3, 1000), day(4, 750))
+	msgs := alerts(before, after)
+	if len(msgs) != 1 || msgs[0].Kind != "low_balance" || !strings.Contains(msgs[0].Body, "$7.50") {
+		t.Fatalf("got %+v", msgs)
+	}
+	// The card was already low, so there's nothing new to say.
+	if msgs := alerts(after, append(after, day(5, 500))); len(msgs) != 0 {
+		t.Errorf("got %+v", msgs)
+	}
+}
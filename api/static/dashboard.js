@@ -0,0 +1,21 @@
+// Reload the dashboard when a sync starts, finishes or finds new
+// transactions, so it stays current without refreshing by hand.
+(function() {
+  if (!window.EventSource) {
+    return;
+  }
+  var running = document.body.dataset.syncRunning === "true";
+  var events = new EventSource("/events");
+  events.addEventListener("sync", function(e) {
+    // The stream starts with the current status, which matches the page
+    // unless a sync started or finished while it loaded.
+    if (JSON.parse(e.data).running !== running) {
+      events.close();
+      location.reload();
+    }
+  });
+  events.addEventListener("transactions", function() {
+    events.close();
+    location.reload();
+  });
+})();
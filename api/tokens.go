@@ -0,0 +1,244 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/kevinburke/clipper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// A Scope is a kind of access that a token grants.
+type Scope string
+
+const (
+	// ScopeRead allows reading cards and transactions, through the
+	// dashboard, the REST, GraphQL, gRPC and Plaid APIs.
+	ScopeRead Scope = "read"
+	// ScopeBalances allows listing cards and their balances with GET
+	// /cards (or ListCards over gRPC), but not their transactions.
+	// ScopeRead includes it.
+	ScopeBalances Scope = "balances"
+	// ScopeSync allows starting downloads with POST /sync and /trigger.
+	ScopeSync Scope = "sync"
+	// ScopeMetrics allows reading /metrics.
+	ScopeMetrics Scope = "metrics"
+	// ScopeAdmin allows the /admin/ routes: listing jobs and accounts,
+	// retrying failed syncs and rotating tokens.
+	ScopeAdmin Scope = "admin"
+)
+
+// AllScopes are the scopes of the token passed to New.
+var AllScopes = []Scope{ScopeRead, ScopeBalances, ScopeSync, ScopeMetrics, ScopeAdmin}
+
+// A Token is a secret that grants some scopes.
+type Token struct {
+	// Name says who the token is for; it isn't secret.
+	Name   string  `json:"name" yaml:"name"`
+	Token  string  `json:"token" yaml:"token"`
+	Scopes []Scope `json:"scopes" yaml:"scopes"`
+	// Cards, if set, limits the token to these cards: it only sees their
+	// balances and transactions, and can only sync their owners' accounts.
+	Cards []int64 `json:"cards,omitempty" yaml:"cards,omitempty"`
+}
+
+// allowsCard reports whether t can see the card with the given serial
+// number.
+func (t Token) allowsCard(serial int64) bool {
+	if len(t.Cards) == 0 {
+		return true
+	}
+	for _, c := range t.Cards {
+		if c == serial {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the transactions in txns that t can see.
+func (t Token) filter(txns []clipper.Transaction) []clipper.Transaction {
+	if len(t.Cards) == 0 {
+		return txns
+	}
+	out := make([]clipper.Transaction, 0, len(txns))
+	for _, txn := range txns {
+		if t.allowsCard(txn.CardSerial) {
+			out = append(out, txn)
+		}
+	}
+	return out
+}
+
+// MinTokenLength is the shortest token AddToken accepts.
+const MinTokenLength = 16
+
+// LoadTokens reads a YAML list of tokens, for example:
+//
+//	[
+//	  {name: grafana, token: 0d6e4079e36703ebd37c00722f5891d2, scopes: [metrics]},
+//	  {name: phone, token: 9f2b1c3a4d5e6f708192a3b4c5d6e7f8, scopes: [read, sync]},
+//	  {name: sheet, token: 5b7c9d1e3f5a7b9c1d3e5f7a9b1c3d5e, scopes: [read], cards: [1202728442]},
+//	]
+func LoadTokens(r io.Reader) ([]Token, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var tokens []Token
+	if err := yaml.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("api: reading tokens: %v", err)
+	}
+	for i, t := range tokens {
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("api: token %d: %v", i+1, err)
+		}
+	}
+	return tokens, nil
+}
+
+// WriteTokens writes tokens as YAML, in the format LoadTokens reads.
+func WriteTokens(w io.Writer, tokens []Token) error {
+	data, err := yaml.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (t Token) validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("token has no name")
+	}
+	if len(t.Token) < MinTokenLength {
+		return fmt.Errorf("%s's token is shorter than %d characters", t.Name, MinTokenLength)
+	}
+	if len(t.Scopes) == 0 {
+		return fmt.Errorf("%s's token has no scopes", t.Name)
+	}
+	for _, sc := range t.Scopes {
+		if !hasScope(AllScopes, sc) {
+			return fmt.Errorf("%s's token has unknown scope %q", t.Name, sc)
+		}
+	}
+	if len(t.Cards) > 0 && hasScope(t.Scopes, ScopeAdmin) {
+		return fmt.Errorf("%s's token is limited to some cards, so it can't have the admin scope", t.Name)
+	}
+	return nil
+}
+
+// AddToken lets requests with t.Token through, with t.Scopes.
+func (s *Server) AddToken(t Token) error {
+	if err := t.validate(); err != nil {
+		return fmt.Errorf("api: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, old := range s.tokens {
+		if old.Name == t.Name {
+			return fmt.Errorf("api: there's already a token named %s", t.Name)
+		}
+	}
+	// Scopes reads s.tokens without the lock, so never modify it in place.
+	s.tokens = append(s.tokens[:len(s.tokens):len(s.tokens)], t)
+	return nil
+}
+
+// Scopes returns the scopes granted by token, or false if it isn't one of
+// the server's tokens. A server with no tokens grants every scope to
+// everyone.
+func (s *Server) Scopes(token string) ([]Scope, bool) {
+	t, ok := s.Token(token)
+	return t.Scopes, ok
+}
+
+// Token returns the server's token whose secret is token.
+func (s *Server) Token(token string) (Token, bool) {
+	s.mu.Lock()
+	tokens := s.tokens
+	s.mu.Unlock()
+	if len(tokens) == 0 {
+		return Token{Scopes: AllScopes}, true
+	}
+	var match Token
+	found := false
+	// Compare against every token, so the time taken doesn't say which
+	// one matched.
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) == 1 && !found {
+			match, found = t, true
+		}
+	}
+	return match, found
+}
+
+func hasScope(scopes []Scope, want Scope) bool {
+	for _, sc := range scopes {
+		if sc == want || (want == ScopeBalances && sc == ScopeRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenFrom returns the token sent with r, in order of preference: the
+// Authorization header, the login cookie or the token query parameter.
+func tokenFrom(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	if c, err := r.Cookie(tokenCookie); err == nil {
+		return c.Value
+	}
+	return r.URL.Query().Get("token")
+}
+
+// requiredScope returns the scope needed to make r.
+func requiredScope(r *http.Request) Scope {
+	switch {
+	case r.URL.Path == "/metrics":
+		return ScopeMetrics
+	case strings.HasPrefix(r.URL.Path, "/admin/"):
+		return ScopeAdmin
+	case r.URL.Path == "/cards":
+		return ScopeBalances
+	case r.Method == "POST" && (r.URL.Path == "/sync" || r.URL.Path == "/trigger"):
+		return ScopeSync
+	}
+	return ScopeRead
+}
+
+type tokenKey struct{}
+
+// NewContext returns a copy of ctx that carries t, the token a request was
+// made with, so that VisibleTransactions can limit what it returns.
+func NewContext(ctx context.Context, t Token) context.Context {
+	return context.WithValue(ctx, tokenKey{}, t)
+}
+
+// requestToken returns the token that authorized the request with ctx.
+func requestToken(ctx context.Context) Token {
+	t, _ := ctx.Value(tokenKey{}).(Token)
+	return t
+}
+
+// requestScopes returns the scopes of the token that authorized the request
+// with ctx.
+func requestScopes(ctx context.Context) []Scope {
+	return requestToken(ctx).Scopes
+}
+
+// VisibleTransactions returns the transactions in the archive that the
+// token in ctx (see NewContext) can see.
+func (s *Server) VisibleTransactions(ctx context.Context) ([]clipper.Transaction, error) {
+	txns, err := s.Transactions()
+	if err != nil {
+		return nil, err
+	}
+	return requestToken(ctx).filter(txns), nil
+}
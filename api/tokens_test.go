@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoadTokens(t *testing.T) {
+	tokens, err := LoadTokens(strings.NewReader(`
+- name: grafana
+  token: 0d6e4079e36703ebd37c00722f5891d2
+  scopes: [metrics]
+- name: phone
+  token: 9f2b1c3a4d5e6f708192a3b4c5d6e7f8
+  scopes: [read, sync]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[1].Name != "phone" || len(tokens[1].Scopes) != 2 {
+		t.Errorf("got %+v", tokens)
+	}
+	for _, bad := range []string{
+		"- {token: 0d6e4079e36703ebd37c00722f5891d2, scopes: [read]}",
+		"- {name: short, token: abc, scopes: [read]}",
+		"- {name: none, token: 0d6e4079e36703ebd37c00722f5891d2}",
+		"- {name: owner, token: 0d6e4079e36703ebd37c00722f5891d2, scopes: [owner]}",
+		"- {name: ops, token: 0d6e4079e36703ebd37c00722f5891d2, scopes: [admin], cards: [1]}",
+	} {
+		if _, err := LoadTokens(strings.NewReader(bad)); err == nil {
+			t.Errorf("LoadTokens(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestScopes(t *testing.T) {
+	srv := testServer(t, func(context.Context, string) error { return nil })
+	for _, tok := range []Token{
+		{Name: "grafana", Token: "metrics-only-token", Scopes: []Scope{ScopeMetrics}},
+		{Name: "phone", Token: "read-only-token-1", Scopes: []Scope{ScopeRead}},
+	} {
+		if err := srv.AddToken(tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range []struct {
+		method, path, token string
+		want                int
+	}{
+		{"GET", "/cards", "metrics-only-token", 403},
+		{"GET", "/metrics", "metrics-only-token", 200},
+		{"GET", "/cards", "read-only-token-1", 200},
+		{"GET", "/metrics", "read-only-token-1", 403},
+		{"POST", "/sync", "read-only-token-1", 403},
+		{"POST", "/sync", "secret", 202},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s %s with %s: got %d, want %d", tt.method, tt.path, tt.token, w.Code, tt.want)
+		}
+	}
+	srv.Shutdown(context.Background())
+}
+
+func TestCardTokens(t *testing.T) {
+	srv := testServer(t, func(context.Context, string) error { return nil })
+	srv.Users = map[string][]int64{"alice": {1202728442}, "bob": {2}}
+	for _, tok := range []Token{
+		{Name: "sheet", Token: "alice-sheet-token", Scopes: []Scope{ScopeRead, ScopeSync}, Cards: []int64{1202728442}},
+		{Name: "bob", Token: "bob-phone-token-1", Scopes: []Scope{ScopeRead, ScopeSync}, Cards: []int64{2}},
+		{Name: "widget", Token: "balances-only-tok", Scopes: []Scope{ScopeBalances}},
+	} {
+		if err := srv.AddToken(tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range []struct {
+		method, path, token string
+		want                int
+	}{
+		{"GET", "/cards", "balances-only-tok", 200},
+		{"GET", "/transactions", "balances-only-tok", 403},
+		{"GET", "/cards/1202728442.ics", "bob-phone-token-1", 404},
+		{"GET", "/cards/1202728442.ics", "alice-sheet-token", 200},
+		{"POST", "/sync", "alice-sheet-token", 403},
+		{"POST", "/trigger?user=bob", "alice-sheet-token", 403},
+		{"POST", "/trigger", "alice-sheet-token", 403},
+		{"POST", "/trigger?card=1202728442", "alice-sheet-token", 202},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s %s with %s: got %d, want %d", tt.method, tt.path, tt.token, w.Code, tt.want)
+		}
+	}
+	srv.Shutdown(context.Background())
+
+	var cards []Card
+	req := httptest.NewRequest("GET", "/cards", nil)
+	req.Header.Set("Authorization", "Bearer bob-phone-token-1")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &cards); err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 0 {
+		t.Errorf("bob's token sees alice's card: %+v", cards)
+	}
+}
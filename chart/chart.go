@@ -0,0 +1,190 @@
+// Package chart draws simple bar, line and pie charts as SVG or PNG, for
+// embedding in HTML reports and attaching to notifications.
+package chart
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// A Kind is a type of chart.
+type Kind int
+
+const (
+	// Bar draws a bar for each point, like spend per month.
+	Bar Kind = iota
+	// Line joins the points with a line, like a balance over time.
+	Line
+	// Pie draws each point's share of the total, like spend per agency.
+	// Points with values of zero or less are left out.
+	Pie
+)
+
+// A Point is one labeled value.
+type Point struct {
+	Label string
+	Value float64
+}
+
+// A Chart is a set of points and how to draw them.
+type Chart struct {
+	Kind   Kind
+	Title  string
+	Points []Point
+	// Width and Height are the size of the image in pixels. They default to
+	// 640 by 360.
+	Width, Height int
+	// Format formats values for axis labels and the pie chart legend. It
+	// defaults to rounding to a whole number.
+	Format func(float64) string
+}
+
+func (c Chart) size() (w, h float64) {
+	width, height := c.Width, c.Height
+	if width <= 0 {
+		width = 640
+	}
+	if height <= 0 {
+		height = 360
+	}
+	return float64(width), float64(height)
+}
+
+func (c Chart) format(v float64) string {
+	if c.Format != nil {
+		return c.Format(v)
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+var (
+	white   = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	black   = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	grey    = color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	palette = []color.RGBA{
+		{0x1f, 0x77, 0xb4, 0xff},
+		{0xff, 0x7f, 0x0e, 0xff},
+		{0x2c, 0xa0, 0x2c, 0xff},
+		{0xd6, 0x27, 0x28, 0xff},
+		{0x94, 0x67, 0xbd, 0xff},
+		{0x8c, 0x56, 0x4b, 0xff},
+		{0xe3, 0x77, 0xc2, 0xff},
+		{0x7f, 0x7f, 0x7f, 0xff},
+	}
+)
+
+// Text anchors.
+const (
+	start = iota
+	middle
+	end
+)
+
+// charWidth is the approximate width of a character of label text, in
+// pixels.
+const charWidth = 7
+
+// A canvas is something a chart can be drawn on. Angles are in radians,
+// clockwise from three o'clock.
+type canvas interface {
+	rect(x, y, w, h float64, fill color.RGBA)
+	line(x1, y1, x2, y2 float64, stroke color.RGBA)
+	wedge(cx, cy, r, a0, a1 float64, fill color.RGBA)
+	text(x, y float64, s string, anchor int)
+}
+
+// draw lays the chart out on cv.
+func (c Chart) draw(cv canvas) {
+	w, h := c.size()
+	cv.rect(0, 0, w, h, white)
+	if c.Title != "" {
+		cv.text(w/2, 24, c.Title, middle)
+	}
+	if c.Kind == Pie {
+		c.drawPie(cv, w, h)
+	} else {
+		c.drawAxes(cv, w, h)
+	}
+}
+
+func (c Chart) drawPie(cv canvas, w, h float64) {
+	total := 0.0
+	for _, p := range c.Points {
+		if p.Value > 0 {
+			total += p.Value
+		}
+	}
+	if total == 0 {
+		return
+	}
+	r := (h - 70) / 2
+	cx, cy := 30+r, 45+r
+	angle := -math.Pi / 2
+	i := 0
+	for _, p := range c.Points {
+		if p.Value <= 0 {
+			continue
+		}
+		col := palette[i%len(palette)]
+		sweep := 2 * math.Pi * p.Value / total
+		cv.wedge(cx, cy, r, angle, angle+sweep, col)
+		angle += sweep
+		lx, ly := cx+r+30, 50+float64(i)*20
+		cv.rect(lx, ly, 12, 12, col)
+		cv.text(lx+18, ly+11, fmt.Sprintf("%s %s (%.0f%%)", p.Label, c.format(p.Value), 100*p.Value/total), start)
+		i++
+	}
+}
+
+func (c Chart) drawAxes(cv canvas, w, h float64) {
+	left, right, top, bottom := 70.0, w-20, 40.0, h-50
+	if len(c.Points) == 0 {
+		return
+	}
+	lo, hi := 0.0, 0.0
+	for _, p := range c.Points {
+		lo, hi = math.Min(lo, p.Value), math.Max(hi, p.Value)
+	}
+	if hi == lo {
+		hi = lo + 1
+	}
+	y := func(v float64) float64 { return bottom - (v-lo)/(hi-lo)*(bottom-top) }
+
+	cv.line(left, top, right, top, grey)
+	cv.line(left, top, left, bottom, black)
+	cv.line(left, y(0), right, y(0), black)
+	cv.text(left-6, top+4, c.format(hi), end)
+	cv.text(left-6, bottom+4, c.format(lo), end)
+
+	n := len(c.Points)
+	slot := (right - left) / float64(n)
+	longest := 0
+	for _, p := range c.Points {
+		if len(p.Label) > longest {
+			longest = len(p.Label)
+		}
+	}
+	every := int(math.Ceil(float64((longest+1)*charWidth) / slot))
+	if every < 1 {
+		every = 1
+	}
+	for i, p := range c.Points {
+		x := left + slot*(float64(i)+0.5)
+		if i%every == 0 {
+			cv.text(x, bottom+18, p.Label, middle)
+		}
+		switch c.Kind {
+		case Bar:
+			top, base := y(p.Value), y(0)
+			if top > base {
+				top, base = base, top
+			}
+			cv.rect(x-slot*0.35, top, slot*0.7, base-top, palette[0])
+		case Line:
+			if i > 0 {
+				cv.line(x-slot, y(c.Points[i-1].Value), x, y(p.Value), palette[0])
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+var points = []Point{{"Jan", 1200}, {"Feb", 0}, {"Mar", 3450}}
+
+func TestSVG(t *testing.T) {
+	for _, kind := range []Kind{Bar, Line, Pie} {
+		svg := string(Chart{Kind: kind, Title: "Spend & more", Points: points, Format: Dollars}.SVG())
+		if !strings.HasPrefix(svg, "<svg ") || !strings.HasSuffix(svg, "</svg>\n") {
+			t.Errorf("kind %d: bad document:\n%s", kind, svg)
+		}
+		if !strings.Contains(svg, "Spend &amp; more") {
+			t.Errorf("kind %d: title not escaped", kind)
+		}
+		if !strings.Contains(svg, "$34.50") {
+			t.Errorf("kind %d: expected formatted maximum", kind)
+		}
+	}
+	pie := string(Chart{Kind: Pie, Points: points}.SVG())
+	if strings.Count(pie, "<path") != 2 {
+		t.Errorf("expected 2 pie slices, skipping the zero value:\n%s", pie)
+	}
+	whole := string(Chart{Kind: Pie, Points: points[:1]}.SVG())
+	if !strings.Contains(whole, "<circle") {
+		t.Errorf("expected a single slice to be drawn as a circle:\n%s", whole)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (Chart{Kind: Bar, Points: points, Width: 200, Height: 100}).WritePNG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 100 {
+		t.Errorf("bad size: %v", b)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := Sparkline([]float64{0, 1, 2, 3, 4, 5, 6, 7}); got != "▁▂▃▄▅▆▇█" {
+		t.Errorf("got %q", got)
+	}
+	if got := Sparkline([]float64{5, 5}); got != "▁▁" {
+		t.Errorf("flat line: got %q", got)
+	}
+	if got := Sparkline(nil); got != "" {
+		t.Errorf("empty: got %q", got)
+	}
+}
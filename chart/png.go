@@ -0,0 +1,82 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func (p *pngCanvas) rect(x, y, w, h float64, fill color.RGBA) {
+	r := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+w)), int(math.Round(y+h)))
+	draw.Draw(p.img, r, image.NewUniform(fill), image.Point{}, draw.Src)
+}
+
+func (p *pngCanvas) line(x1, y1, x2, y2 float64, stroke color.RGBA) {
+	steps := int(math.Max(math.Abs(x2-x1), math.Abs(y2-y1)))
+	if steps == 0 {
+		steps = 1
+	}
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x, y := int(math.Round(x1+t*(x2-x1))), int(math.Round(y1+t*(y2-y1)))
+		p.img.SetRGBA(x, y, stroke)
+		p.img.SetRGBA(x+1, y, stroke)
+		p.img.SetRGBA(x, y+1, stroke)
+	}
+}
+
+func (p *pngCanvas) wedge(cx, cy, r, a0, a1 float64, fill color.RGBA) {
+	for y := int(cy - r); y <= int(cy+r); y++ {
+		for x := int(cx - r); x <= int(cx+r); x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			if dx*dx+dy*dy > r*r {
+				continue
+			}
+			a := math.Atan2(dy, dx)
+			for a < a0 {
+				a += 2 * math.Pi
+			}
+			if a < a1 {
+				p.img.SetRGBA(x, y, fill)
+			}
+		}
+	}
+}
+
+func (p *pngCanvas) text(x, y float64, s string, anchor int) {
+	face := basicfont.Face7x13
+	d := font.Drawer{Dst: p.img, Src: image.NewUniform(black), Face: face}
+	width := d.MeasureString(s).Round()
+	switch anchor {
+	case middle:
+		x -= float64(width) / 2
+	case end:
+		x -= float64(width)
+	}
+	d.Dot = fixed.P(int(math.Round(x)), int(math.Round(y)))
+	d.DrawString(s)
+}
+
+// Image returns the chart drawn as an image.
+func (c Chart) Image() image.Image {
+	w, h := c.size()
+	p := &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, int(w), int(h)))}
+	c.draw(p)
+	return p.img
+}
+
+// WritePNG writes the chart to w as a PNG.
+func (c Chart) WritePNG(w io.Writer) error {
+	return png.Encode(w, c.Image())
+}
@@ -0,0 +1,44 @@
+package chart
+
+import (
+	"math"
+
+	"github.com/kevinburke/clipper/clipperstats"
+	"github.com/kevinburke/clipper/transit"
+)
+
+// Dollars formats a value in cents as dollars, rounded to the nearest cent.
+func Dollars(cents float64) string {
+	return transit.Money(math.Round(cents)).String()
+}
+
+// MonthlySpend is a bar chart of spend per month.
+func MonthlySpend(months []clipperstats.Month) Chart {
+	c := Chart{Kind: Bar, Title: "Spend per month", Format: Dollars}
+	for _, m := range months {
+		c.Points = append(c.Points, Point{Label: m.Start.Format("Jan 06"), Value: float64(m.SpendCents)})
+	}
+	return c
+}
+
+// AgencySpend is a pie chart of spend per agency.
+func AgencySpend(agencies []clipperstats.AgencyTotal) Chart {
+	c := Chart{Kind: Pie, Title: "Spend by agency", Format: Dollars}
+	for _, a := range agencies {
+		label := string(a.Agency)
+		if a.Agency == transit.AgencyUnknown {
+			label = "Other"
+		}
+		c.Points = append(c.Points, Point{Label: label, Value: float64(a.SpendCents)})
+	}
+	return c
+}
+
+// Balance is a line chart of a card's balance over time.
+func Balance(points []clipperstats.BalancePoint) Chart {
+	c := Chart{Kind: Line, Title: "Card balance", Format: Dollars}
+	for _, p := range points {
+		c.Points = append(c.Points, Point{Label: p.Time.Format("Jan 2"), Value: float64(p.BalanceCents)})
+	}
+	return c
+}
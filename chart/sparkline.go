@@ -0,0 +1,30 @@
+package chart
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline returns a line of block characters, one per value, whose heights
+// are scaled between the smallest and largest values, for showing a trend in
+// a terminal.
+func Sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	out := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(sparks)-1))
+		}
+		out[i] = sparks[level]
+	}
+	return string(out)
+}
@@ -0,0 +1,61 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"math"
+)
+
+type svgCanvas struct {
+	buf bytes.Buffer
+}
+
+func rgb(c color.RGBA) string { return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B) }
+
+func (s *svgCanvas) rect(x, y, w, h float64, fill color.RGBA) {
+	fmt.Fprintf(&s.buf, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n", x, y, w, h, rgb(fill))
+}
+
+func (s *svgCanvas) line(x1, y1, x2, y2 float64, stroke color.RGBA) {
+	fmt.Fprintf(&s.buf, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="2"/>`+"\n", x1, y1, x2, y2, rgb(stroke))
+}
+
+func (s *svgCanvas) wedge(cx, cy, r, a0, a1 float64, fill color.RGBA) {
+	if a1-a0 >= 2*math.Pi-1e-9 {
+		fmt.Fprintf(&s.buf, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"/>`+"\n", cx, cy, r, rgb(fill))
+		return
+	}
+	large := 0
+	if a1-a0 > math.Pi {
+		large = 1
+	}
+	fmt.Fprintf(&s.buf, `<path d="M%.1f,%.1f L%.1f,%.1f A%.1f,%.1f 0 %d 1 %.1f,%.1f Z" fill="%s"/>`+"\n",
+		cx, cy, cx+r*math.Cos(a0), cy+r*math.Sin(a0), r, r, large, cx+r*math.Cos(a1), cy+r*math.Sin(a1), rgb(fill))
+}
+
+var svgAnchors = [...]string{start: "start", middle: "middle", end: "end"}
+
+func (s *svgCanvas) text(x, y float64, str string, anchor int) {
+	fmt.Fprintf(&s.buf, `<text x="%.1f" y="%.1f" text-anchor="%s" font-family="sans-serif" font-size="12" fill="%s">%s</text>`+"\n",
+		x, y, svgAnchors[anchor], rgb(black), html.EscapeString(str))
+}
+
+// SVG returns the chart as an SVG document, suitable for writing to a file or
+// inlining in an HTML page.
+func (c Chart) SVG() []byte {
+	w, h := c.size()
+	s := new(svgCanvas)
+	fmt.Fprintf(&s.buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f">`+"\n", w, h, w, h)
+	c.draw(s)
+	s.buf.WriteString("</svg>\n")
+	return s.buf.Bytes()
+}
+
+// WriteSVG writes the chart to w as SVG.
+func (c Chart) WriteSVG(w io.Writer) error {
+	_, err := w.Write(c.SVG())
+	return err
+}
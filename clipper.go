@@ -1,9 +1,14 @@
 // Package clipper lets you interact with your Clipper Card data.
 //
 // Example usage:
-//	client := clipper.NewClient("email", "password")
+//
+//	client, err := clipper.NewClient("email", "password")
 //	// You can only access this page twice per day, per Clipper.
-//	transactions := client.Transactions(context.TODO())
+//	transactions, err := client.Transactions(context.TODO())
+//	if err != nil {
+//		// transactions still has the cards that worked.
+//		log.Println(err)
+//	}
 //	for card := range transactions {
 //		fmt.Println("nickname:", card.Nickname)
 //		fmt.Printf("txns: %#v\n", transactions[card].Transactions
@@ -16,6 +21,8 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -23,15 +30,18 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/kevinburke/clipper/clippercsv"
 	"github.com/kevinburke/rest"
-	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
-	"github.com/unidoc/unidoc/pdf/core"
 	pdf "github.com/unidoc/unidoc/pdf/model"
+	"golang.org/x/net/html/charset"
 	"golang.org/x/net/publicsuffix"
 	"golang.org/x/text/encoding/charmap"
 )
@@ -48,42 +58,59 @@ var positions = []float64{
 	722.22,
 }
 
-func findPositionIdx(pos float64) int {
-	if pos < 0 || pos > 1100 {
-		panic(fmt.Sprintf("invalid pos %v", pos))
+// pageWidth is past the right edge of any statement page; text placed
+// further out, or left of the page, isn't in the table.
+const pageWidth = 1100
+
+// findPositionIdx returns the column of the transaction table that text at x
+// position pos is in, or an error if pos is off the page.
+func findPositionIdx(pos float64) (int, error) {
+	if !(pos >= 0 && pos <= pageWidth) {
+		return 0, fmt.Errorf("text at x=%v is off the page", pos)
 	}
 	if pos <= positions[0] {
-		return 0
+		return 0, nil
 	}
 	if pos >= positions[len(positions)-1] {
-		return len(positions) - 1
+		return len(positions) - 1, nil
 	}
 	for i := 0; i < len(positions)-1; i++ {
 		halfway := positions[i] + (positions[i+1]-positions[i])/2
 		if pos < halfway {
-			return i
+			return i, nil
 		}
 	}
-	return len(positions) - 1
+	return len(positions) - 1, nil
 }
 
-func howManyTabs(prevPos, curPos float64) int {
-	if prevPos >= curPos {
-		panic("not expected")
+// howManyTabs returns how many columns text at curPos is to the right of
+// text at prevPos, or an error if either is off the page or curPos isn't to
+// the right.
+func howManyTabs(prevPos, curPos float64) (int, error) {
+	if !(prevPos < curPos) {
+		return 0, fmt.Errorf("text at x=%v comes after text at x=%v", curPos, prevPos)
 	}
-	idx := findPositionIdx(prevPos)
-	idx2 := findPositionIdx(curPos)
-	return idx2 - idx
-}
-
-func extractText(parser *pdfcontent.ContentStreamParser) (string, error) {
-	operations, err := parser.Parse()
+	idx, err := findPositionIdx(prevPos)
 	if err != nil {
-		return "", err
+		return 0, err
 	}
+	idx2, err := findPositionIdx(curPos)
+	if err != nil {
+		return 0, err
+	}
+	return idx2 - idx, nil
+}
+
+// extractText returns the text on a page with the given content stream, with
+// a tab between columns of the transaction table and a newline between rows.
+func extractText(content []byte) (string, error) {
+	sc := newContentScanner(content)
 	xPos, yPos := float64(-1), float64(-1)
 	inText := false
-	txt := ""
+	var txt strings.Builder
+	// Most of a page's content stream draws the table, so its text is a
+	// fraction of the size.
+	txt.Grow(len(content) / 8)
 	// columnStarts:
 	//  28.00 date
 	// 133.71 transaction type
@@ -93,139 +120,165 @@ func extractText(parser *pdfcontent.ContentStreamParser) (string, error) {
 	// 655.88 debit
 	// 685.78 credit
 	// 722.22 balance
-	for _, op := range *operations {
-		if op.Operand == "BT" {
+	for {
+		op, err := sc.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		args := sc.args
+		switch string(op) {
+		case "BT":
 			inText = true
-		} else if op.Operand == "ET" {
+		case "ET":
 			inText = false
-		}
-		if op.Operand == "Tm" {
+		case "Tm":
 			// Text matrix. See here for an explanation of how this relates to
 			// drawn software:
 			// https://stackoverflow.com/a/17202701/329700
-			if len(op.Params) != 6 {
-				continue
-			}
+			//
 			// 0-3 are scale/shear for x and y. Typical values are 1 0 0 1.
 			// 4 is X offset from the left side.
 			// 5 is Y offset from the bottom (origin in doc bottom left corner).
-			xfloat, ok := op.Params[4].(*core.PdfObjectFloat)
-			if !ok {
-				xint, ok := op.Params[4].(*core.PdfObjectInteger)
-				if !ok {
-					continue
-				}
-				xfloat = core.MakeFloat(float64(*xint))
-			}
-			yfloat, ok := op.Params[5].(*core.PdfObjectFloat)
-			if !ok {
-				yint, ok := op.Params[5].(*core.PdfObjectInteger)
-				if !ok {
-					continue
-				}
-				yfloat = core.MakeFloat(float64(*yint))
+			if len(args) != 6 || args[4].kind != operandNumber || args[5].kind != operandNumber {
+				continue
 			}
+			x, y := sc.number(args[4]), sc.number(args[5])
 			if yPos == -1 {
-				yPos = float64(*yfloat)
-			} else if yPos > float64(*yfloat) {
-				txt += "\n"
-				xPos = float64(*xfloat)
-				yPos = float64(*yfloat)
+				yPos = y
+			} else if yPos > y {
+				txt.WriteByte('\n')
+				xPos, yPos = x, y
 				continue
 			}
 			if xPos == -1 {
-				xPos = float64(*xfloat)
-			} else if xPos < float64(*xfloat) {
-				numTabs := howManyTabs(xPos, float64(*xfloat))
-				txt += strings.Repeat("\t", numTabs)
-				xPos = float64(*xfloat)
+				xPos = x
+			} else if xPos < x {
+				tabs, err := howManyTabs(xPos, x)
+				if err != nil {
+					return "", err
+				}
+				for ; tabs > 0; tabs-- {
+					txt.WriteByte('\t')
+				}
+				xPos = x
 			}
-		}
-
-		if op.Operand == "Td" || op.Operand == "TD" || op.Operand == "T*" {
+		case "Td", "TD", "T*":
 			// Move to next line...
-			txt += "\n"
-		}
-		if inText && op.Operand == "TJ" {
-			if len(op.Params) < 1 {
+			txt.WriteByte('\n')
+		case "TJ":
+			if !inText || len(args) < 1 {
 				continue
 			}
-			paramList, ok := op.Params[0].(*core.PdfObjectArray)
-			if !ok {
-				return "", fmt.Errorf("Invalid parameter type, no array (%T)", op.Params[0])
+			if args[0].kind != operandArray {
+				return "", fmt.Errorf("Invalid parameter type, no array (%s)", args[0].kind)
 			}
-			for _, obj := range *paramList {
-				switch v := obj.(type) {
-				case *core.PdfObjectString:
-					txt += string(*v)
-				case *core.PdfObjectFloat:
-					if *v < -100 {
-						txt += " "
-					}
-				case *core.PdfObjectInteger:
-					if *v < -100 {
-						txt += " "
+			for _, e := range sc.array(args[0]) {
+				switch e.kind {
+				case operandString:
+					txt.Write(sc.str(e))
+				case operandNumber:
+					if sc.number(e) < -100 {
+						txt.WriteByte(' ')
 					}
 				}
 			}
-		} else if inText && op.Operand == "Tj" {
-			if len(op.Params) < 1 {
+		case "Tj":
+			if !inText || len(args) < 1 {
 				continue
 			}
-			param, ok := op.Params[0].(*core.PdfObjectString)
-			if !ok {
-				return "", fmt.Errorf("Invalid parameter type, not string (%T)", op.Params[0])
+			if args[0].kind != operandString {
+				return "", fmt.Errorf("Invalid parameter type, not string (%s)", args[0].kind)
 			}
-			txt += string(*param)
+			txt.Write(sc.str(args[0]))
 		}
 	}
+	return txt.String(), nil
+}
 
-	return txt, nil
+// isASCII reports whether s is all ASCII, which every single-byte encoding
+// leaves alone.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
 }
 
-func extractPDFText(r io.ReadSeeker) ([]string, error) {
+// extractPDFText returns the text on each page of the PDF in r. It stops
+// with ctx.Err() if ctx is done before it gets to the next page.
+func extractPDFText(ctx context.Context, r io.ReadSeeker) ([]string, error) {
+	pages, _, err := extractPDFPages(ctx, r, 0, false)
+	return pages, err
+}
+
+// extractPDFPages is like extractPDFText, but stops after the first max
+// pages, if max is more than 0. A page that can't be read is a *ParseError;
+// if lenient, it's left empty and added to skipped instead.
+func extractPDFPages(ctx context.Context, r io.ReadSeeker, max int, lenient bool) (pages []string, skipped []error, err error) {
 	pdfReader, err := pdf.NewPdfReader(r)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	numPages, err := pdfReader.GetNumPages()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if max > 0 && numPages > max {
+		numPages = max
 	}
-	pages := make([]string, numPages)
+	pages = make([]string, numPages)
 	decoder := charmap.Windows1252.NewDecoder()
+	var content []byte
 	for i := 1; i <= numPages; i++ {
-		page, err := pdfReader.GetPage(i)
-		if err != nil {
-			return nil, err
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
 		}
-		contentStreams, err := page.GetContentStreams()
-		if err != nil {
-			return nil, err
+		content, err = pageContent(pdfReader, i, content[:0])
+		var txt string
+		if err == nil {
+			txt, err = extractText(content)
 		}
-		pageContentStr := ""
-
-		// If the value is an array, the effect shall be as if all of the
-		// streams in the array were concatenated, in order, to form a
-		// single stream.
-		for _, cstream := range contentStreams {
-			pageContentStr += cstream + "\n"
-		}
-
-		cstreamParser := pdfcontent.NewContentStreamParser(pageContentStr)
-		txt, err := extractText(cstreamParser)
-		if err != nil {
-			return nil, err
+		if err == nil && !isASCII(txt) {
+			txt, err = decoder.String(txt)
 		}
-		s, err := decoder.String(txt)
 		if err != nil {
-			fmt.Printf("Error decoding stream: %q\n", txt)
-			return nil, err
+			perr := &ParseError{Page: i, Err: err}
+			if !lenient {
+				return nil, nil, perr
+			}
+			rest.Logger.Warn("skipping a page that can't be read", "page", i, "err", err)
+			skipped = append(skipped, perr)
+			continue
 		}
-		pages[i-1] = strings.TrimSpace(s)
+		pages[i-1] = strings.TrimSpace(txt)
 	}
-	return pages, nil
+	return pages, skipped, nil
+}
+
+// pageContent appends the content stream of page number i to content.
+func pageContent(pdfReader *pdf.PdfReader, i int, content []byte) ([]byte, error) {
+	page, err := pdfReader.GetPage(i)
+	if err != nil {
+		return content, err
+	}
+	contentStreams, err := page.GetContentStreams()
+	if err != nil {
+		return content, err
+	}
+	// If the value is an array, the effect shall be as if all of the
+	// streams in the array were concatenated, in order, to form a
+	// single stream.
+	for _, cstream := range contentStreams {
+		content = append(content, cstream...)
+		content = append(content, '\n')
+	}
+	return content, nil
 }
 
 func parseLine(text string) ([]string, error) {
@@ -254,112 +307,217 @@ var recordHeader = []string{
 	"Balance",
 }
 
+// noActivity starts the only text on a statement for a card that had no
+// transactions in the requested period.
+const noActivity = "* No activity was recorded for this card"
+
+// headerLines is how many lines come before the transactions on the first
+// page of a statement (the title, the card number and the column headings),
+// and on the pages after it (the column headings).
+const (
+	firstPageHeaderLines = 3
+	pageHeaderLines      = 1
+)
+
 func getCSV(pages []string) (int64, [][]string, error) {
-	records := make([][]string, 1)
-	records[0] = make([]string, 8)
-	copy(records[0][:], recordHeader)
-	num := int64(-1)
-	for i := range pages {
+	num, records, _, err := readRecords(pages, false)
+	return num, records, err
+}
+
+// readRecords returns the card number and transaction records on pages, the
+// text of a statement. A line that isn't a row of the table is a *ParseError;
+// if lenient, it's left out and added to skipped instead.
+func readRecords(pages []string, lenient bool) (num int64, records [][]string, skipped []error, err error) {
+	num = -1
+	if len(pages) > 0 && strings.HasPrefix(pages[0], noActivity) {
+		return num, [][]string{append([]string(nil), recordHeader...)}, nil, nil
+	}
+	// Nearly every line is a transaction, so this is close to the number of
+	// records.
+	n := 1
+	for _, page := range pages {
+		n += strings.Count(page, "\n") + 1
+	}
+	records = make([][]string, 1, n)
+	records[0] = append(make([]string, 0, len(recordHeader)), recordHeader...)
+	for i, page := range pages {
+		header := pageHeaderLines
 		if i == 0 {
-			bs := bufio.NewScanner(strings.NewReader(pages[i]))
-			line := 0
-			for bs.Scan() {
-				text := bs.Text()
-				if line == 0 {
+			header = firstPageHeaderLines
+		}
+		for line := 0; page != ""; line++ {
+			var text string
+			text, page, _ = strings.Cut(page, "\n")
+			text = canonicalPDFLine(strings.TrimSuffix(text, "\r"))
+			if line < header {
+				switch {
+				case i == 0 && line == 0:
 					if text != "TRANSACTION HISTORY FOR" {
 						rest.Logger.Warn("Unexpected line text", "line", line, "text", text)
 					}
-					line++
-					continue
+				case i == 0 && line == 1:
+					num = readCardNumber(text, num)
+				case !strings.HasPrefix(text, "TRANSACTION TYPE\tLOCATION\tROUTE"):
+					rest.Logger.Warn("Unexpected line text", "line", line, "text", text)
 				}
-				if line == 1 {
-					if !strings.HasPrefix(text, "CARD ") {
-						rest.Logger.Warn("Unexpected line text", "line", line, "text", text)
-						line++
-						continue
-					}
-					parts := strings.Split(text, " ")
-					if len(parts) != 2 {
-						rest.Logger.Warn("Unexpected line text", "line", line, "text", text)
-						line++
-						continue
-					}
-					var err error
-					num, err = strconv.ParseInt(parts[1], 10, 64)
-					if err != nil {
-						rest.Logger.Warn("error reading account number", "line", line, "text", text)
-					}
-					line++
-					continue
-				}
-				if line == 2 {
-					if !strings.HasPrefix(text, "TRANSACTION TYPE\tLOCATION\tROUTE") {
-						rest.Logger.Warn("Unexpected line text", "line", line, "text", text)
-					}
-					line++
-					continue
-				}
-				parts, err := parseLine(text)
-				if err == io.EOF {
-					break
-				}
-				if err != nil {
-					return num, nil, err
-				}
-				records = append(records, parts)
-				line++
+				continue
 			}
-		} else {
-			bs := bufio.NewScanner(strings.NewReader(pages[i]))
-			line := 0
-			for bs.Scan() {
-				text := bs.Text()
-				if line == 0 {
-					if !strings.HasPrefix(text, "TRANSACTION TYPE\tLOCATION\tROUTE") {
-						rest.Logger.Warn("Unexpected line text", "line", line, "text", text)
-					}
-					line++
-					continue
-				}
-				parts, err := parseLine(text)
-				if err == io.EOF {
-					break
-				}
-				if err != nil {
-					return num, nil, err
+			parts, err := parseLine(text)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				perr := &ParseError{Page: i + 1, Line: line + 1, Err: err}
+				if !lenient {
+					return num, nil, nil, perr
 				}
-				records = append(records, parts)
-				line++
+				rest.Logger.Warn("skipping a line that isn't a transaction", "page", i+1, "line", line+1, "text", text)
+				skipped = append(skipped, perr)
+				continue
 			}
+			records = append(records, parts)
 		}
 	}
-	return num, records, nil
+	return num, records, skipped, nil
+}
+
+// readCardNumber reads the card serial number from the "CARD 1202728442"
+// line at the top of a statement, or returns num if the line doesn't look
+// like that.
+func readCardNumber(text string, num int64) int64 {
+	serial, ok := strings.CutPrefix(text, "CARD ")
+	if !ok || strings.Contains(serial, " ") {
+		rest.Logger.Warn("Unexpected line text", "line", 1, "text", text)
+		return num
+	}
+	n, err := strconv.ParseInt(serial, 10, 64)
+	if err != nil {
+		rest.Logger.Warn("error reading account number", "line", 1, "text", text)
+	}
+	return n
 }
 
+// TransactionData is a statement as rows of strings, the way it's laid out
+// on the page; ParsedTransactions reads them into typed Transactions. Version
+// 2 of this package converts it to and from its typed Statement.
 type TransactionData struct {
 	AccountNumber int64
 	Transactions  [][]string
 }
 
+// ParsedTransactions returns t's rows as Transactions, with their times and
+// amounts parsed and the header row left out; see ParseTransactions. An
+// AccountNumber below 0, for a statement that doesn't show one, leaves
+// CardSerial unset.
+func (t TransactionData) ParsedTransactions() ([]Transaction, error) {
+	return ParseTransactions(t.Transactions, max(t.AccountNumber, 0))
+}
+
+// WriteCSV writes the transaction records to w as CSV.
+func (t TransactionData) WriteCSV(w io.Writer, opts clippercsv.Options) error {
+	return clippercsv.NewWriter(w, opts).WriteAll(t.Transactions)
+}
+
+// A Record is a transaction as TransactionData.WriteJSON, and the JSON
+// encoders in the export package, write it: with its time in RFC 3339 format,
+// its amounts in cents and the agency it was with, if known.
+type Record struct {
+	// ID is the same for a transaction across downloads; see
+	// Transaction.ID.
+	ID           string    `json:"id"`
+	Timestamp    time.Time `json:"timestamp"`
+	CardSerial   int64     `json:"card_serial"`
+	Type         string    `json:"type"`
+	Location     string    `json:"location,omitempty"`
+	Route        string    `json:"route,omitempty"`
+	Product      string    `json:"product,omitempty"`
+	Agency       string    `json:"agency,omitempty"`
+	DebitCents   Money     `json:"debit_cents"`
+	CreditCents  Money     `json:"credit_cents"`
+	BalanceCents Money     `json:"balance_cents"`
+}
+
+// RecordOf returns t as a Record.
+func RecordOf(t Transaction) Record {
+	return Record{
+		ID:           t.ID(),
+		Timestamp:    t.Timestamp,
+		CardSerial:   t.CardSerial,
+		Type:         t.Type,
+		Location:     t.Location,
+		Route:        t.Route,
+		Product:      t.Product,
+		Agency:       string(t.Agency()),
+		DebitCents:   t.DebitCents,
+		CreditCents:  t.CreditCents,
+		BalanceCents: t.BalanceCents,
+	}
+}
+
+// WriteJSON writes the transactions to w as a JSON array of Records, the same
+// as the export package's JSONEncoder and "clipper-pdf-downloader parse
+// --format=json". It returns an error, before writing anything, if a row
+// doesn't parse; see ParsedTransactions.
+func (t TransactionData) WriteJSON(w io.Writer) error {
+	txns, err := t.ParsedTransactions()
+	if err != nil {
+		return err
+	}
+	records := make([]Record, len(txns))
+	for i, txn := range txns {
+		records[i] = RecordOf(txn)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
 // ParsePDF parses r (a stream of PDF encoded data) and returns a list of
-// transaction records suitable for encoding in a CSV file.
+// transaction records suitable for encoding in a CSV file. Call
+// ParsedTransactions on the result for typed Transactions instead.
 //
 // Each row in the output will have 8 columns. Note, the transaction data in the
 // PDF is not well validated; as long as it has 8 columns (or close to it), the
 // file will be returned as is.
+//
+// Version 2 of this package, github.com/kevinburke/clipper/v2, returns typed
+// Transactions instead.
 func ParsePDF(r io.ReadSeeker) (TransactionData, error) {
-	pages, err := extractPDFText(r)
+	return ParsePDFContext(context.Background(), r)
+}
+
+// ParsePDFContext is like ParsePDF, but gives up with ctx.Err() once ctx is
+// done, between one page and the next, so a server can stop parsing a long
+// statement when the request for it goes away.
+func ParsePDFContext(ctx context.Context, r io.ReadSeeker) (TransactionData, error) {
+	return ParsePDFOptions(ctx, r, ParseOptions{})
+}
+
+// ParseOptions change how ParsePDFOptions reads a statement.
+type ParseOptions struct {
+	// Lenient skips the pages and lines of a statement that can't be read,
+	// like a page with text placed off its edge, instead of failing.
+	Lenient bool
+}
+
+// ParsePDFOptions is like ParsePDFContext, with opts. In lenient mode, it
+// returns what it could read of the statement along with an error joining a
+// *ParseError for each page or line it skipped, or nil if it didn't skip
+// anything; list them with ParseErrors. It still fails outright if r isn't a
+// PDF it can read, or ctx is done.
+func ParsePDFOptions(ctx context.Context, r io.ReadSeeker, opts ParseOptions) (TransactionData, error) {
+	pages, skippedPages, err := extractPDFPages(ctx, r, 0, opts.Lenient)
 	if err != nil {
 		return TransactionData{}, err
 	}
-	accountNumber, records, err := getCSV(pages)
+	accountNumber, records, skippedLines, err := readRecords(pages, opts.Lenient)
 	if err != nil {
 		return TransactionData{}, err
 	}
 	return TransactionData{
 		AccountNumber: accountNumber,
 		Transactions:  records,
-	}, nil
+	}, errors.Join(append(skippedPages, skippedLines...)...)
 }
 
 type Card struct {
@@ -368,19 +526,96 @@ type Card struct {
 	Status              string
 	Reason              string
 	Type                string
-	CashValueCents      int
-	AutoloadAmountCents int
+	CashValueCents      Money
+	AutoloadAmountCents Money
+	// Expires is the date the card stops working. It's only set on cards
+	// returned by CardDetails, and is the zero time if Clipper doesn't show
+	// one.
+	Expires time.Time
+	// Discount is the reduced-fare program the card is enrolled in, if any.
+	// Like Expires, it's filled in by CardDetails.
+	Discount Discount
+}
+
+// Discount is a reduced-fare program a card can be enrolled in.
+type Discount string
+
+const (
+	DiscountNone   Discount = ""
+	DiscountYouth  Discount = "youth"
+	DiscountSenior Discount = "senior"
+	// DiscountRTC is the Regional Transit Connection card for people with
+	// disabilities.
+	DiscountRTC Discount = "rtc"
+	// DiscountStart is the Clipper START means-based fare program.
+	DiscountStart Discount = "start"
+)
+
+// parseDiscount returns the discount program named by s, which is a card type
+// ("YOUTH", "Senior") or program name ("Clipper START") from the site.
+func parseDiscount(s string) Discount {
+	lower := strings.ToLower(s)
+	switch {
+	case strings.Contains(lower, "youth") || strings.Contains(lower, "joven") || strings.Contains(lower, "juvenil") || strings.Contains(s, "青年"):
+		return DiscountYouth
+	case strings.Contains(lower, "senior") || strings.Contains(lower, "mayor") || strings.Contains(s, "長者"):
+		return DiscountSenior
+	case strings.Contains(lower, "rtc") || strings.Contains(lower, "regional transit connection") || strings.Contains(lower, "disab"):
+		return DiscountRTC
+	case strings.Contains(lower, "start"):
+		return DiscountStart
+	}
+	return DiscountNone
 }
 
 type Client struct {
-	username, password string
-	client             *http.Client
+	username string
+	password *Secret
+	client   *http.Client
+
+	// renderer, if set, loads pages that need JavaScript to show cards.
+	renderer Renderer
+	// events, if set, is told about logins and downloads.
+	events func(Event)
+	// browser is who the client says it is; see WithUserAgent. If
+	// userAgents is set, a new one is picked from it at each login.
+	browser    atomic.Pointer[browser]
+	userAgents []string
+	// transportOpts configured the transport, if it came from
+	// WithTransportOptions; pins are from WithPinnedCertificates.
+	transportOpts *TransportOptions
+	pins          []string
+	// sessions, if set, keeps the session between runs.
+	sessions SessionStore
+	// site is the card portal; see WithSiteAdapter. host is where
+	// ClipperWeb finds clippercard.com; see WithSiteURL.
+	site    SiteAdapter
+	host    string
+	hostURL *url.URL
+	// ssoHosts are the identity providers from WithSSOHosts.
+	ssoHosts []string
+	// pacer, if set, spaces out requests; see WithPacer. minInterval and
+	// burst are from WithRateLimit or WithMinInterval, and WithBurst;
+	// rateLimited is set by WithRateLimit, even to 0.
+	pacer       *Pacer
+	minInterval time.Duration
+	burst       int
+	rateLimited bool
+	// checkStatements is set by WithStatementCheck.
+	checkStatements bool
+	// quota, if set, counts requests and downloads; see WithQuota.
+	quota Quota
+	// concurrency is how many cards to work on at once; see
+	// WithConcurrency.
+	concurrency int
+	// eventMu keeps calls to events from overlapping.
+	eventMu sync.Mutex
 
 	loggedIn bool
 	mu       sync.Mutex
 }
 
-func NewClient(username, password string) (*Client, error) {
+func NewClient(username, password string, opts ...Option) (*Client, error) {
 	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
 	if err != nil {
 		return nil, err
@@ -388,33 +623,76 @@ func NewClient(username, password string) (*Client, error) {
 
 	client := &http.Client{
 		Jar:       jar,
-		Transport: rest.DefaultTransport,
+		Transport: defaultTransport,
 	}
-	return &Client{
+	c := &Client{
 		username: username,
-		password: password,
+		password: NewSecret(password),
 		client:   client,
-	}, nil
+		site:     new(ClipperWeb),
+		host:     host,
+	}
+	c.browser.Store(newBrowser(DefaultUserAgent))
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.pins != nil {
+		if err := c.pinTransport(); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.pace(); err != nil {
+		return nil, err
+	}
+	if err := c.checkConcurrency(); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(c.host)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+		return nil, fmt.Errorf("clipper: site URL %q isn't like %s", c.host, host)
+	}
+	c.host = strings.TrimSuffix(c.host, "/")
+	c.hostURL = u
+	return c, nil
 }
 
 const host = "https://www.clippercard.com"
-const userAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.13; rv:58.0) Gecko/20100101 Firefox/58.0"
 
+// Cards returns the cards on the account. If it can't load every page of a
+// long card list, it returns the cards it found along with the error.
 func (c *Client) Cards(ctx context.Context) ([]Card, error) {
-	cards, err := c.cards(ctx)
-	return cards, err
+	if err := c.ensureLogin(ctx); err != nil {
+		return nil, err
+	}
+	return c.site.Cards(ctx, c)
 }
 
-// caller should hold c.mu
+// login resumes the saved session, if there is one the site still takes, or
+// logs in with the password and saves the new session. It returns the page
+// the site shows after logging in. The caller should hold c.mu.
 func (c *Client) login(ctx context.Context) (*http.Response, error) {
+	if resp := c.resumeSession(ctx); resp != nil {
+		return resp, nil
+	}
+	resp, err := c.passwordLogin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.saveSession()
+	return resp, nil
+}
+
+// caller should hold c.mu
+func (c *Client) passwordLogin(ctx context.Context) (_ *http.Response, err error) {
+	defer func() { c.event(Event{Action: EventLogin, Err: err}) }()
+	c.rotateUserAgent()
 	// First, get the login page to obtain CSRF token
-	req, err := http.NewRequest("GET", host+"/ClipperWeb/login.html", nil)
+	req, err := http.NewRequest("GET", c.host+"/ClipperWeb/login.html", nil)
 	if err != nil {
 		return nil, err
 	}
 	req = req.WithContext(ctx)
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	c.setBrowserHeaders(req, "", false)
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -422,36 +700,44 @@ func (c *Client) login(ctx context.Context) (*http.Response, error) {
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf("could not get Clipper login page: want 200 response code, got %d", resp.StatusCode)
 	}
-	
-	// Extract CSRF token from the page
-	csrfToken, err := findCSRFToken(resp.Body)
+	loginPage, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
-	_, discardErr := io.Copy(ioutil.Discard, resp.Body)
-	if discardErr != nil {
-		return nil, discardErr
+	if err := resp.Body.Close(); err != nil {
+		return nil, err
+	}
+	if !c.isClipperURL(resp.Request.URL) {
+		// We were redirected to the hosted identity provider.
+		resp2, err := c.ssoLogin(ctx, resp, loginPage)
+		if err != nil {
+			return nil, err
+		}
+		return resp2, nil
 	}
-	closeErr := resp.Body.Close()
-	if closeErr != nil {
-		return nil, closeErr
+
+	// Extract CSRF token from the page
+	token, src, err := findCSRFToken(bytes.NewReader(loginPage))
+	if err != nil {
+		return nil, err
 	}
+	csrfToken := NewSecret(token)
+	defer csrfToken.Zero()
+	rest.Logger.Debug("found CSRF token", "page", "login", "source", src)
 
 	// Now submit the login form
 	data := url.Values{}
-	data.Set("_csrf", csrfToken)
+	data.Set("_csrf", csrfToken.Reveal())
 	data.Set("email", c.username)
-	data.Set("password", c.password)
+	data.Set("password", c.password.Reveal())
 
-	req, err = http.NewRequest("POST", host+"/ClipperWeb/account", strings.NewReader(data.Encode()))
+	req, err = http.NewRequest("POST", c.host+"/ClipperWeb/account", strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, err
 	}
 	req = req.WithContext(ctx)
-	req.Header.Set("User-Agent", userAgent)
+	c.setBrowserHeaders(req, c.host+"/ClipperWeb/login.html", false)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Referer", "https://www.clippercard.com/ClipperWeb/login.html")
 	resp2, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -459,18 +745,27 @@ func (c *Client) login(ctx context.Context) (*http.Response, error) {
 	if resp2.StatusCode != 200 && resp2.StatusCode != 302 {
 		return nil, fmt.Errorf("could not login: want 200 or 302 response code, got %d", resp2.StatusCode)
 	}
-	c.loggedIn = true
+	body, err := ioutil.ReadAll(resp2.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp2.Body.Close(); err != nil {
+		return nil, err
+	}
+	if err := checkLoginPage(resp2.Request.URL, body); err != nil {
+		return nil, err
+	}
+	resp2.Body = ioutil.NopCloser(bytes.NewReader(body))
 	return resp2, nil
 }
 
 func (c *Client) dashboard(ctx context.Context) (*http.Response, error) {
-	req, err := http.NewRequest("GET", host+"/ClipperWeb/account.html", nil)
+	req, err := http.NewRequest("GET", c.host+"/ClipperWeb/account.html", nil)
 	if err != nil {
 		return nil, err
 	}
 	req = req.WithContext(ctx)
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	c.setBrowserHeaders(req, "", false)
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -481,172 +776,415 @@ func (c *Client) dashboard(ctx context.Context) (*http.Response, error) {
 	return resp, nil
 }
 
-func (c *Client) cards(ctx context.Context) ([]Card, error) {
-	var resp *http.Response
-	var err error
-	c.mu.Lock()
-	if c.loggedIn {
-		c.mu.Unlock()
-		resp, err = c.dashboard(ctx)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		resp, err = c.login(ctx)
-		if err != nil {
-			c.mu.Unlock()
-			return nil, err
-		}
-		c.mu.Unlock()
+// dashboardPage returns the body of the account page, logging in first if
+// necessary.
+func (c *Client) dashboardPage(ctx context.Context) ([]byte, error) {
+	w, err := c.clipperWeb()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ensureLogin(ctx); err != nil {
+		return nil, err
+	}
+	if page := w.takePage(); page != nil {
+		// We just logged in, and the site showed the account page.
+		return page, nil
+	}
+	resp, err := c.dashboard(ctx)
+	if err != nil {
+		return nil, err
 	}
-	dashboardData, err := ioutil.ReadAll(resp.Body)
+	dashboardData, err := readUTF8(resp)
 	if err != nil {
 		return nil, err
 	}
 	if err := resp.Body.Close(); err != nil {
 		return nil, err
 	}
-	cards, err := getCards(bytes.NewReader(dashboardData))
-	return cards, err
+	return dashboardData, nil
 }
 
-func (c *Client) Transactions(ctx context.Context) (map[Card]TransactionData, error) {
-	// TODO: Update this method to work with new ClipperWeb API
-	return nil, fmt.Errorf("Transactions method not yet updated for new ClipperWeb API - use DownloadPDFs instead")
+// ensureLogin logs in with the site adapter if the client doesn't have a
+// session yet.
+func (c *Client) ensureLogin(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loggedIn {
+		return nil
+	}
+	if err := c.site.Login(ctx, c); err != nil {
+		return err
+	}
+	c.loggedIn = true
+	return nil
 }
 
-// DownloadPDFs downloads raw PDF transaction reports and saves them to the specified directory
-// startDate and endDate should be in YYYY-MM-DD format, or empty for default range
-// Set dryRun to true to test without actually downloading PDFs
-func (c *Client) DownloadPDFs(ctx context.Context, outputDir string, startDate, endDate string, dryRun bool) error {
-	convertDateFormat := func(dateStr string) string {
-		if dateStr == "" {
-			return ""
-		}
-		t, err := time.Parse("2006-01-02", dateStr)
-		if err != nil {
-			return dateStr
-		}
-		return t.Format("January 2, 2006")
+// ensureClipperWeb logs in if necessary, and returns an error if the client
+// isn't using the clippercard.com adapter, for methods that scrape pages
+// only it has.
+func (c *Client) ensureClipperWeb(ctx context.Context) error {
+	if _, err := c.clipperWeb(); err != nil {
+		return err
+	}
+	return c.ensureLogin(ctx)
+}
+
+func (c *Client) cardDetailsURL(serial int64) string {
+	return c.host + "/ClipperWeb/cardDetails.html?cardNumber=" + strconv.FormatInt(serial, 10)
+}
+
+// CardDetails fetches the detail page for card and returns a copy of card with
+// the fields that only appear there, like Expires, filled in.
+func (c *Client) CardDetails(ctx context.Context, card Card) (Card, error) {
+	if err := c.ensureClipperWeb(ctx); err != nil {
+		return card, err
 	}
-	
-	clipperStartDate := convertDateFormat(startDate)
-	clipperEndDate := convertDateFormat(endDate)
-	cards, err := c.cards(ctx)
+	body, err := c.getPage(ctx, c.cardDetailsURL(card.SerialNumber), c.host+"/ClipperWeb/account.html", false)
 	if err != nil {
-		return err
+		return card, fmt.Errorf("could not get details for card %d: %v", card.SerialNumber, err)
+	}
+	if err := setCardDetails(bytes.NewReader(body), &card); err != nil {
+		return card, err
 	}
-	
-	// Get CSRF token from account page
-	req, err := http.NewRequest("GET", host+"/ClipperWeb/account.html", nil)
+	return card, nil
+}
+
+// readUTF8 reads an HTML response body, converting it to UTF-8 if the page
+// declares a different charset.
+func readUTF8(resp *http.Response) ([]byte, error) {
+	r, err := charset.NewReader(resp.Body, resp.Header.Get("Content-Type"))
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+// getPage GETs the HTML page at u and returns its body. Set ajax to request a
+// fragment the way the site's own scripts do.
+func (c *Client) getPage(ctx context.Context, u, referer string, ajax bool) ([]byte, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
 	}
 	req = req.WithContext(ctx)
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	c.setBrowserHeaders(req, referer, ajax)
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("could not get account page: want 200 response code, got %d", resp.StatusCode)
+		resp.Body.Close()
+		return nil, fmt.Errorf("want 200 response code from %s, got %d", u, resp.StatusCode)
 	}
-	
-	csrfToken, err := findCSRFToken(resp.Body)
+	body, err := readUTF8(resp)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := resp.Body.Close(); err != nil {
+		return nil, err
 	}
-	_, discardErr := io.Copy(ioutil.Discard, resp.Body)
-	if discardErr != nil {
-		return discardErr
+	return body, nil
+}
+
+// maxCardPages bounds the number of extra card list pages we follow.
+const maxCardPages = 50
+
+func (c *Client) cards(ctx context.Context) ([]Card, error) {
+	dashboardData, err := c.dashboardPage(ctx)
+	if err != nil {
+		return nil, err
 	}
-	closeErr := resp.Body.Close()
-	if closeErr != nil {
-		return closeErr
+	return c.dashboardCards(ctx, dashboardData)
+}
+
+// dashboardCards returns the cards on the account page, whose body has already
+// been downloaded into dashboardData, and on the card list pages after it.
+func (c *Client) dashboardCards(ctx context.Context, dashboardData []byte) ([]Card, error) {
+	cards, err := getCards(bytes.NewReader(dashboardData))
+	if err != nil {
+		return nil, err
 	}
-	
-	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
-	defer cancel()
-	
-	for _, card := range cards {
-		// Create form data for PDF download
-		data := url.Values{}
-		data.Set("_csrf", csrfToken)
-		data.Set("cardNumber", strconv.FormatInt(card.SerialNumber, 10))
-		data.Set("cardNickName", card.Nickname)
-		data.Set("rhStartDate", "")
-		data.Set("rhEndDate", "")
-		// Set date range fields
-		if clipperStartDate != "" {
-			data.Set("rhStartDate", clipperStartDate)
-			data.Set("startDateValue", clipperStartDate)
-			data.Set("startDate", clipperStartDate)
-		} else {
-			data.Set("rhStartDate", "")
-			data.Set("startDateValue", "")
-			data.Set("startDate", "")
+	if len(cards) == 0 && c.renderer != nil {
+		// Some accounts get a dashboard that fills in the card list with
+		// JavaScript.
+		dashboardData, err = c.render(ctx, c.host+"/ClipperWeb/account.html")
+		if err != nil {
+			return nil, err
 		}
-		if clipperEndDate != "" {
-			data.Set("rhEndDate", clipperEndDate)
-			data.Set("endDateValue", clipperEndDate)
-			data.Set("endDate", clipperEndDate)
-		} else {
-			data.Set("rhEndDate", "")
-			data.Set("endDateValue", "")
-			data.Set("endDate", "")
+		cards, err = getCards(bytes.NewReader(dashboardData))
+		if err != nil {
+			return nil, err
 		}
-		
-		if dryRun {
-			fmt.Printf("[DRY RUN] Would download PDF for card %d (%s) with date range: %s to %s\n", 
-				card.SerialNumber, card.Nickname, 
-				map[bool]string{true: startDate, false: "default"}[startDate != ""], 
-				map[bool]string{true: endDate, false: "default"}[endDate != ""])
-			continue
+	}
+	// Accounts with many cards only render the first few on the dashboard.
+	page, _ := url.Parse(c.host + "/ClipperWeb/account.html")
+	return c.followCardPages(ctx, page, dashboardData, cards, getCards)
+}
+
+// followCardPages follows the "Next" and "show more" links on a card list page
+// (with the given URL and body), and returns cards plus the cards on the
+// following pages, as found by parse. If a page fails, it returns the cards
+// so far with the error.
+func (c *Client) followCardPages(ctx context.Context, page *url.URL, body []byte, cards []Card, parse func(io.Reader) ([]Card, error)) ([]Card, error) {
+	seen := make(map[int64]bool, len(cards))
+	for _, card := range cards {
+		seen[card.SerialNumber] = true
+	}
+	next, ajax := findMoreCardsURL(bytes.NewReader(body))
+	for i := 0; next != "" && i < maxCardPages; i++ {
+		u, err := page.Parse(next)
+		if err != nil {
+			return nil, err
 		}
-		
-		req, err := http.NewRequest("POST", host+"/ClipperWeb/view/transactionHistory.pdf", strings.NewReader(data.Encode()))
+		body, err = c.getPage(ctx, u.String(), page.String(), ajax)
 		if err != nil {
-			return err
+			return cards, fmt.Errorf("could not get more cards: %w", err)
 		}
-		req = req.WithContext(ctx)
-		req.Header.Set("User-Agent", userAgent)
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-		req.Header.Set("Accept", "application/pdf,*/*")
-		req.Header.Set("Referer", "https://www.clippercard.com/ClipperWeb/account.html")
-		
-		resp, err := c.client.Do(req)
+		more, err := parse(bytes.NewReader(body))
 		if err != nil {
-			return err
+			return cards, fmt.Errorf("could not get more cards: %w", err)
 		}
-		if resp.StatusCode != 200 {
-			return fmt.Errorf("bad status for card %d: want 200 got %d", card.SerialNumber, resp.StatusCode)
+		added := 0
+		for _, card := range more {
+			if seen[card.SerialNumber] {
+				continue
+			}
+			seen[card.SerialNumber] = true
+			cards = append(cards, card)
+			added++
 		}
-		
-		ctype := resp.Header.Get("Content-Type")
-		typ, _, err := mime.ParseMediaType(ctype)
-		if err != nil {
-			return err
+		if added == 0 {
+			// Some pagers link back to the first page; stop rather than
+			// looping.
+			break
 		}
-		if typ != "application/pdf" {
-			return fmt.Errorf("could not get transactions for card %d: Bad response content-type: want pdf got %s", card.SerialNumber, ctype)
+		if !ajax {
+			page = u
 		}
-		
-		pdfBody, err := ioutil.ReadAll(resp.Body)
+		next, ajax = findMoreCardsURL(bytes.NewReader(body))
+	}
+	return cards, nil
+}
+
+// Transactions fetches and parses the statement for each card on the account,
+// covering the range the site picks, without saving the PDFs. If some cards
+// fail, it returns the transactions for the rest, along with a CardError for
+// each card that failed.
+func (c *Client) Transactions(ctx context.Context) (map[Card]TransactionData, error) {
+	cards, err := c.Cards(ctx)
+	if err != nil && len(cards) == 0 {
+		return nil, err
+	}
+	result := make(map[Card]TransactionData, len(cards))
+	var mu sync.Mutex
+	cerr := c.eachCard(ctx, cards, func(card Card) error {
+		data, err := c.transactions(ctx, card)
 		if err != nil {
 			return err
 		}
-		if err := resp.Body.Close(); err != nil {
-			return err
+		mu.Lock()
+		result[card] = data
+		mu.Unlock()
+		return nil
+	})
+	return result, errors.Join(err, cerr)
+}
+
+// transactions fetches and parses card's statement.
+func (c *Client) transactions(ctx context.Context, card Card) (TransactionData, error) {
+	if err := c.takeDownload(); err != nil {
+		return TransactionData{}, err
+	}
+	rc, err := c.site.Statement(ctx, c, card, DateRange{})
+	if err != nil {
+		return TransactionData{}, err
+	}
+	defer rc.Close()
+	body, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return TransactionData{}, err
+	}
+	if sniffed := http.DetectContentType(body); sniffed != "application/pdf" {
+		return TransactionData{}, fmt.Errorf("statement is %s, not a PDF", sniffed)
+	}
+	if err := c.checkStatement(ctx, card, bytes.NewReader(body)); err != nil {
+		return TransactionData{}, err
+	}
+	return ParsePDFContext(ctx, bytes.NewReader(body))
+}
+
+// DownloadPDFs downloads raw PDF transaction reports and saves them to the specified directory
+// startDate and endDate should be in YYYY-MM-DD format, or empty for default range
+// Set dryRun to true to test without actually downloading PDFs
+//
+// A card that fails doesn't stop the others: DownloadPDFs saves what it can
+// and returns a CardError for each card it couldn't download, joined with any
+// error listing the cards. Canceling ctx stops the download in progress, and
+// the cards not yet started.
+//
+// It returns an error from ParseDateRange, without contacting the site, if
+// the dates don't make a valid DateRange.
+func (c *Client) DownloadPDFs(ctx context.Context, outputDir string, startDate, endDate string, dryRun bool) error {
+	r, err := ParseDateRange(startDate, endDate)
+	if err != nil {
+		return err
+	}
+	return c.DownloadRange(ctx, outputDir, r, dryRun)
+}
+
+// DownloadRange is like DownloadPDFs, but takes the dates as a DateRange,
+// which it validates before contacting the site.
+func (c *Client) DownloadRange(ctx context.Context, outputDir string, r DateRange, dryRun bool) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	cards, err := c.Cards(ctx)
+	if err != nil && len(cards) == 0 {
+		return err
+	}
+	return errors.Join(err, c.downloadPDFs(ctx, cards, outputDir, r, dryRun))
+}
+
+// statementTimeout is how long downloadPDFs gives each card's statement. It's
+// per card, not per run, since with pacing a run over many cards can take
+// much longer than any one of them, and it doesn't count the time the card's
+// requests wait for the Pacer behind other cards'.
+var statementTimeout = 45 * time.Second
+
+// downloadPDFs downloads a PDF for each card, covering r, and returns a
+// CardError for each one that failed.
+func (c *Client) downloadPDFs(ctx context.Context, cards []Card, outputDir string, r DateRange, dryRun bool) error {
+	return c.eachCard(ctx, cards, func(card Card) error {
+		if dryRun {
+			fmt.Printf("[DRY RUN] Would download PDF for card %d (%s) with date range: %s\n",
+				card.SerialNumber, card.Nickname, r)
+			return nil
+		}
+
+		ctx, cancel := withPacedTimeout(ctx, statementTimeout)
+		defer cancel()
+		filename, err := c.downloadPDF(ctx, card, r, filepath.Join(outputDir, fmt.Sprintf("clipper-transactions-%d.pdf", card.SerialNumber)))
+		if err != nil && context.Cause(ctx) == errPacedTimeout {
+			err = fmt.Errorf("clipper: gave up on the statement for card %d after %v: %w", card.SerialNumber, statementTimeout, context.DeadlineExceeded)
 		}
-		
-		// Save raw PDF to file
-		filename := fmt.Sprintf("%s/clipper-transactions-%d.pdf", outputDir, card.SerialNumber)
-		err = ioutil.WriteFile(filename, pdfBody, 0644)
+		c.event(Event{Action: EventDownload, Card: card.SerialNumber, Path: filename, Err: err})
 		if err != nil {
 			return err
 		}
 		fmt.Printf("Saved PDF: %s (Card: %s)\n", filename, card.Nickname)
+		return nil
+	})
+}
+
+// statementToken returns the CSRF token for the statement form on page.
+func (c *Client) statementToken(ctx context.Context, page string) (string, error) {
+	req, err := http.NewRequest("GET", page, nil)
+	if err != nil {
+		return "", err
 	}
-	return nil
+	req = req.WithContext(ctx)
+	c.setBrowserHeaders(req, "", false)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("could not get account page: want 200 response code, got %d", resp.StatusCode)
+	}
+	csrfToken, src, err := findCSRFToken(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	rest.Logger.Debug("found CSRF token", "page", page, "source", src)
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		return "", err
+	}
+	return csrfToken, nil
+}
+
+// statement submits the statement form on page, with data, and returns the
+// PDF the site sends back.
+func (c *Client) statement(ctx context.Context, card Card, data url.Values, page string) (io.ReadCloser, error) {
+	req, err := http.NewRequest("POST", c.host+"/ClipperWeb/view/transactionHistory.pdf", strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	c.setBrowserHeaders(req, page, false)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/pdf,*/*")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("bad status for card %d: want 200 got %d", card.SerialNumber, resp.StatusCode)
+	}
+	ctype := resp.Header.Get("Content-Type")
+	typ, _, err := mime.ParseMediaType(ctype)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if typ != "application/pdf" {
+		resp.Body.Close()
+		return nil, fmt.Errorf("could not get transactions for card %d: Bad response content-type: want pdf got %s", card.SerialNumber, ctype)
+	}
+	return resp.Body, nil
+}
+
+// downloadPDF downloads the statement for card, covering r, to the file
+// filename, and returns filename.
+func (c *Client) downloadPDF(ctx context.Context, card Card, r DateRange, filename string) (string, error) {
+	if err := c.takeDownload(); err != nil {
+		return "", err
+	}
+	rc, err := c.site.Statement(ctx, c, card, r)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	// Clipper sometimes labels an HTML error page as a PDF, so check the
+	// first bytes too.
+	body := bufio.NewReaderSize(rc, 512)
+	head, err := body.Peek(512)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	if sniffed := http.DetectContentType(head); sniffed != "application/pdf" {
+		return "", fmt.Errorf("could not get transactions for card %d: response is %s, not a PDF", card.SerialNumber, sniffed)
+	}
+
+	// Stream the PDF to a temporary file, so that a statement covering years
+	// of rides isn't held in memory, and a failed download doesn't leave a
+	// partial file behind.
+	f, err := os.CreateTemp(filepath.Dir(filename), fmt.Sprintf(".clipper-transactions-%d-*.pdf", card.SerialNumber))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return "", err
+	}
+	// Don't archive an error page, a statement cut off partway or another
+	// card's statement.
+	if err := c.checkStatement(ctx, card, f); err != nil {
+		f.Close()
+		return "", fmt.Errorf("could not get transactions for card %d: %w", card.SerialNumber, err)
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(f.Name(), filename); err != nil {
+		return "", err
+	}
+	return filename, nil
 }
@@ -2,7 +2,16 @@ package clipper
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io"
 	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -11,7 +20,7 @@ func TestParsePDF(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	s, err := extractPDFText(bytes.NewReader(data))
+	s, err := extractPDFText(context.Background(), bytes.NewReader(data))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -20,6 +29,97 @@ func TestParsePDF(t *testing.T) {
 	}
 }
 
+// pagesContext is a context that's done after Err has been called pages
+// times.
+type pagesContext struct {
+	context.Context
+	pages int
+}
+
+func (c *pagesContext) Err() error {
+	if c.pages <= 0 {
+		return context.Canceled
+	}
+	c.pages--
+	return nil
+}
+
+func TestParsePDFContext(t *testing.T) {
+	data := longStatement(t, 5)
+	ctx := &pagesContext{Context: context.Background(), pages: 2}
+	if _, err := ParsePDFContext(ctx, bytes.NewReader(data)); err != context.Canceled {
+		t.Errorf("canceled after 2 pages: got %v, want context.Canceled", err)
+	}
+	ctx = &pagesContext{Context: context.Background(), pages: 5}
+	got, err := ParsePDFContext(ctx, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ParsePDF(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Transactions) != len(want.Transactions) {
+		t.Errorf("got %d records, want %d", len(got.Transactions), len(want.Transactions))
+	}
+}
+
+func TestParsePDFNoActivity(t *testing.T) {
+	f, err := os.Open("testdata/no-transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, err := ParsePDF(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Transactions) != 1 {
+		t.Errorf("expected only the header row, got %d records", len(data.Transactions))
+	}
+}
+
+// testdata/odd-coordinates.pdf is testdata/transactions.pdf with the balance
+// of the first ride on page 1 moved into the credit column, and the first
+// ride on page 2 starting left of the page and the second ending far right of
+// it.
+func TestParsePDFLenient(t *testing.T) {
+	f, err := os.Open("testdata/odd-coordinates.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, err = ParsePDF(f)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Page != 2 || perr.Line != 0 {
+		t.Fatalf("ParsePDF: got %v, want an error for page 2", err)
+	}
+
+	f.Seek(0, io.SeekStart)
+	data, err := ParsePDFOptions(context.Background(), f, ParseOptions{Lenient: true})
+	perrs := ParseErrors(err)
+	if len(perrs) != 2 {
+		t.Fatalf("ParsePDFOptions: got %v, want 2 ParseErrors", err)
+	}
+	if perrs[0].Page != 2 || perrs[0].Line != 0 || !strings.Contains(perrs[0].Error(), "off the page") {
+		t.Errorf("first error: got %v, want page 2 off the page", perrs[0])
+	}
+	if perrs[1].Page != 1 || perrs[1].Line != 4 {
+		t.Errorf("second error: got %v, want page 1, line 4", perrs[1])
+	}
+	// Everything on page 1 but the bad ride, and the header.
+	if len(data.Transactions) != 29 {
+		t.Errorf("got %d records, want 29", len(data.Transactions))
+	}
+	if data.AccountNumber != 1202728442 {
+		t.Errorf("account number: got %d, want 1202728442", data.AccountNumber)
+	}
+
+	if _, err := ParsePDFOptions(context.Background(), strings.NewReader("not a PDF"), ParseOptions{Lenient: true}); err == nil || len(ParseErrors(err)) != 0 {
+		t.Errorf("lenient ParsePDFOptions of a non-PDF: got %v, want an error that isn't a ParseError", err)
+	}
+}
+
 func TestGetTransactions(t *testing.T) {
 	num, records, err := getCSV(samplePages)
 	if err != nil {
@@ -56,9 +156,201 @@ var tabsTests = []struct {
 
 func TestHowManyTabs(t *testing.T) {
 	for _, tt := range tabsTests {
-		got := howManyTabs(tt.prev, tt.cur)
+		got, err := howManyTabs(tt.prev, tt.cur)
+		if err != nil {
+			t.Errorf("howManyTabs(%v, %v): %v", tt.prev, tt.cur, err)
+			continue
+		}
 		if got != tt.tabs {
 			t.Errorf("howManyTabs(%v, %v): got %d, want %d", tt.prev, tt.cur, got, tt.tabs)
 		}
 	}
+	for _, tt := range []struct{ prev, cur float64 }{
+		{-40, 28},
+		{28, 5000},
+		{133, 28},
+		{28, 28},
+		{28, math.NaN()},
+	} {
+		if got, err := howManyTabs(tt.prev, tt.cur); err == nil {
+			t.Errorf("howManyTabs(%v, %v): got %d, want an error", tt.prev, tt.cur, got)
+		}
+	}
+}
+
+type fakeRenderer struct {
+	url     string
+	cookies []*http.Cookie
+}
+
+func (f *fakeRenderer) Render(ctx context.Context, url string, cookies []*http.Cookie) ([]byte, error) {
+	f.url = url
+	f.cookies = cookies
+	return []byte(`<span class="d-inline-block">1401491737 - Guest</span>`), nil
+}
+
+func TestRenderUsesSessionCookies(t *testing.T) {
+	r := new(fakeRenderer)
+	c, err := NewClient("email", "password", WithRenderer(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(host + "/ClipperWeb/account.html")
+	c.client.Jar.SetCookies(u, []*http.Cookie{{Name: "JSESSIONID", Value: "abc"}})
+	body, err := c.render(context.Background(), u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.url != u.String() || len(r.cookies) != 1 || r.cookies[0].Value != "abc" {
+		t.Errorf("renderer called with %q %v", r.url, r.cookies)
+	}
+	cards, err := getCards(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 1 {
+		t.Errorf("expected 1 card from rendered page, got %d", len(cards))
+	}
+}
+
+// lockedTransport shows a login form, which warns about locking the account,
+// and answers the login with an alert that the account is locked.
+type lockedTransport struct{}
+
+func (lockedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := `<form method="post" action="/ClipperWeb/account">
+<input type="hidden" name="_csrf" value="token">
+<p class="help-block">Too many failed login attempts will lock your account.</p>
+</form>`
+	if req.Method == "POST" {
+		body = `<div class="alert alert-danger">Your account has been temporarily locked.</div>`
+	}
+	return &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"text/html"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestLoginEvent(t *testing.T) {
+	var events []Event
+	c, err := NewClient("email", "password", WithTransport(lockedTransport{}), WithEvents(func(e Event) {
+		events = append(events, e)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ensureLogin(context.Background()); err != ErrAccountLocked {
+		t.Fatalf("ensureLogin: got %v, want ErrAccountLocked", err)
+	}
+	if len(events) != 1 || events[0].Action != EventLogin || events[0].Err != ErrAccountLocked {
+		t.Errorf("events: got %+v", events)
+	}
+}
+
+// bannerTransport shows a login form under a warning about locked accounts,
+// and lets the account in.
+type bannerTransport struct{}
+
+func (bannerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := `<div class="alert alert-warning">Accounts are locked for 30 minutes after too many failed login attempts.</div>
+<form method="post" action="/ClipperWeb/account">
+<input type="hidden" name="_csrf" value="token">
+</form>`
+	if req.Method == "POST" {
+		body = `<h1>My Account</h1>`
+	}
+	return &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"text/html"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestLoginFormWarning(t *testing.T) {
+	c, err := NewClient("email", "password", WithTransport(bannerTransport{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ensureLogin(context.Background()); err != nil {
+		t.Fatalf("ensureLogin: %v", err)
+	}
+}
+
+// pdfTransport answers every request with body, labeled as a PDF.
+type pdfTransport struct{ body string }
+
+func (p pdfTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"application/pdf"}},
+		Body:       io.NopCloser(strings.NewReader(p.body)),
+		Request:    req,
+	}, nil
+}
+
+func TestDownloadPDF(t *testing.T) {
+	card := Card{SerialNumber: 1202728442}
+	statement, err := os.ReadFile("testdata/transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty, err := os.ReadFile("testdata/no-transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A PDF that isn't a statement.
+	var blank bytes.Buffer
+	if err := writePDF(&blank, []pdfPage{{mediaBox: [4]float64{0, 0, 612, 792}, content: []byte("BT (Hello) Tj ET")}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		body    string
+		check   bool
+		wantErr bool
+	}{
+		{string(statement), true, false},
+		{string(empty), true, false},
+		{blank.String(), false, false},
+		{"<html><body>Something went wrong</body></html>", false, true},
+		// Labeled and sniffed as a PDF, but cut off, or not one at all.
+		{string(statement[:len(statement)/2]), false, true},
+		{"%PDF-1.4\n" + strings.Repeat("x", 4096), false, true},
+		// A PDF, but not a statement.
+		{blank.String(), true, true},
+	} {
+		dir := t.TempDir()
+		opts := []Option{WithTransport(pdfTransport{tt.body})}
+		if tt.check {
+			opts = append(opts, WithStatementCheck())
+		}
+		c, err := NewClient("email", "password", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.site.(*ClipperWeb).token = "token"
+		filename, err := c.downloadPDF(context.Background(), card, DateRange{}, filepath.Join(dir, "statement.pdf"))
+		if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalidPDF) && strings.HasPrefix(tt.body, "%PDF")) {
+			t.Fatalf("downloadPDF(%.10q, %d bytes): got error %v, want error %t", tt.body, len(tt.body), err, tt.wantErr)
+		}
+		entries, _ := os.ReadDir(dir)
+		if tt.wantErr {
+			if len(entries) != 0 {
+				t.Errorf("a failed download left %s behind", entries[0].Name())
+			}
+			continue
+		}
+		if len(entries) != 1 {
+			t.Fatalf("got %d files, want just the PDF", len(entries))
+		}
+		got, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.body {
+			t.Errorf("wrote %d bytes, want %d", len(got), len(tt.body))
+		}
+	}
 }
@@ -0,0 +1,93 @@
+// Package clippercsv writes the CSV that the clipper packages and commands
+// export. Every CSV goes through a Writer, so that fields with commas, quotes
+// or newlines in them, like "Embarcadero Station, Platform 2", are quoted the
+// same way everywhere, and so that spreadsheet users can ask for a byte order
+// mark or Windows line endings once for every export.
+package clippercsv
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// BOM is the UTF-8 byte order mark. Excel needs it to read non-ASCII text in
+// a CSV file as UTF-8.
+const BOM = "\ufeff"
+
+// Options control how a Writer encodes records. The zero Options write plain
+// comma-separated records ending in "\n".
+type Options struct {
+	// BOM starts the output with a UTF-8 byte order mark.
+	BOM bool
+	// CRLF ends each record with "\r\n", as RFC 4180 says to, instead of
+	// "\n".
+	CRLF bool
+	// Comma separates fields; it defaults to ','.
+	Comma rune
+}
+
+// A Writer writes records as CSV, quoting fields as needed. Like a
+// csv.Writer, it buffers its output; call Flush, and check Error, when done.
+type Writer struct {
+	w  io.Writer
+	cw *csv.Writer
+	// bom is set until the byte order mark, if requested, is written.
+	bom bool
+	err error
+}
+
+// NewWriter returns a Writer that writes to w.
+func NewWriter(w io.Writer, opts Options) *Writer {
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = opts.CRLF
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+	return &Writer{w: w, cw: cw, bom: opts.BOM}
+}
+
+// writeBOM writes the byte order mark before the first record, or the first
+// Flush if there are no records.
+func (w *Writer) writeBOM() error {
+	if !w.bom || w.err != nil {
+		return w.err
+	}
+	w.bom = false
+	_, w.err = io.WriteString(w.w, BOM)
+	return w.err
+}
+
+// Write writes a single record.
+func (w *Writer) Write(record []string) error {
+	if err := w.writeBOM(); err != nil {
+		return err
+	}
+	return w.cw.Write(record)
+}
+
+// WriteAll writes records and flushes the Writer.
+func (w *Writer) WriteAll(records [][]string) error {
+	for _, r := range records {
+		if err := w.Write(r); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (w *Writer) Flush() {
+	if w.writeBOM() != nil {
+		return
+	}
+	w.cw.Flush()
+}
+
+// Error reports any error that occurred during a previous Write or Flush.
+func (w *Writer) Error() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.cw.Error()
+}
@@ -0,0 +1,71 @@
+package clippercsv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	records := [][]string{
+		{"time", "location", "amount"},
+		{"2024-01-02T08:15:00-08:00", "Embarcadero Station, Platform 2", "2.50"},
+		{"2024-01-02T17:40:00-08:00", `The "Ferry Building"`, "-10.00"},
+	}
+	for _, tt := range []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, "time,location,amount\n" +
+			"2024-01-02T08:15:00-08:00,\"Embarcadero Station, Platform 2\",2.50\n" +
+			"2024-01-02T17:40:00-08:00,\"The \"\"Ferry Building\"\"\",-10.00\n"},
+		{Options{BOM: true, CRLF: true, Comma: ';'}, BOM + "time;location;amount\r\n" +
+			"2024-01-02T08:15:00-08:00;Embarcadero Station, Platform 2;2.50\r\n" +
+			"2024-01-02T17:40:00-08:00;\"The \"\"Ferry Building\"\"\";-10.00\r\n"},
+	} {
+		var buf bytes.Buffer
+		if err := NewWriter(&buf, tt.opts).WriteAll(records); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%+v: got\n%q\nwant\n%q", tt.opts, buf.String(), tt.want)
+		}
+		r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(buf.Bytes(), []byte(BOM))))
+		if tt.opts.Comma != 0 {
+			r.Comma = tt.opts.Comma
+		}
+		got, err := r.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, records) {
+			t.Errorf("%+v: read back %q", tt.opts, got)
+		}
+	}
+}
+
+func TestEmptyBOM(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, Options{BOM: true})
+	w.Flush()
+	w.Flush()
+	if buf.String() != BOM {
+		t.Errorf("got %q, want just the byte order mark", buf.String())
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestWriteError(t *testing.T) {
+	w := NewWriter(errWriter{}, Options{BOM: true})
+	if err := w.Write([]string{"a"}); err == nil || err.Error() != "disk full" {
+		t.Errorf("Write: got %v, want disk full", err)
+	}
+	if err := w.WriteAll([][]string{{"b"}}); err == nil {
+		t.Errorf("WriteAll after an error: got nil")
+	}
+}
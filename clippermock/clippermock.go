@@ -0,0 +1,188 @@
+// Package clippermock is a fake Clipper site, for trying out the clipper
+// tools and running them in CI without a Clipper account.
+//
+// A Server serves the login form, account page, card details and statement
+// PDFs from a directory of fixtures, by default a sanitized demo account with
+// two cards. Point a client at it with clipper.WithSiteURL and log in as
+// DemoEmail with DemoPassword:
+//
+//	srv := httptest.NewServer(clippermock.New())
+//	client, err := clipper.NewClient(clippermock.DemoEmail, clippermock.DemoPassword, clipper.WithSiteURL(srv.URL))
+//
+// The fixtures directory holds login.html, dashboard.html (the account page),
+// addValue.html, a card-<serial>.html details page for each card and a
+// statement-<serial>.pdf for each card with a statement. The pages
+// clipper-record-fixtures saves from a real account work too; pages a
+// directory doesn't have, like the login form, come from the demo account.
+package clippermock
+
+import (
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+)
+
+// The demo account's login.
+const (
+	DemoEmail    = "demo@example.com"
+	DemoPassword = "demo"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// Demo returns the bundled fixtures for the demo account.
+func Demo() fs.FS {
+	sub, err := fs.Sub(fixtures, "fixtures")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// A Server is a fake Clipper site. Its fields should not be changed once it
+// starts serving.
+type Server struct {
+	// Email and Password are the only login the site takes.
+	Email    string
+	Password string
+	// Fixtures holds the site's pages and statements.
+	Fixtures fs.FS
+
+	mu       sync.Mutex
+	sessions map[string]bool
+}
+
+// New returns a Server for the demo account.
+func New() *Server {
+	return &Server{
+		Email:    DemoEmail,
+		Password: DemoPassword,
+		Fixtures: Demo(),
+	}
+}
+
+const sessionCookie = "JSESSIONID"
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/" || r.URL.Path == "/ClipperWeb/login.html":
+		if r.Method != "GET" && r.Method != "HEAD" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.serveFile(w, r, "login.html", "text/html; charset=utf-8")
+	case r.URL.Path == "/ClipperWeb/account":
+		s.login(w, r)
+	case !s.loggedIn(r):
+		http.Redirect(w, r, "/ClipperWeb/login.html", http.StatusFound)
+	case r.URL.Path == "/ClipperWeb/account.html":
+		s.serveFile(w, r, "dashboard.html", "text/html; charset=utf-8")
+	case r.URL.Path == "/ClipperWeb/addValue.html":
+		s.serveFile(w, r, "addValue.html", "text/html; charset=utf-8")
+	case r.URL.Path == "/ClipperWeb/cardDetails.html":
+		serial, ok := cardNumber(r.FormValue("cardNumber"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.serveFile(w, r, "card-"+serial+".html", "text/html; charset=utf-8")
+	case r.URL.Path == "/ClipperWeb/view/transactionHistory.pdf":
+		s.statement(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// login checks the login form and starts a session.
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.PostFormValue("_csrf") == "" {
+		http.Error(w, "missing CSRF token", http.StatusForbidden)
+		return
+	}
+	if r.PostFormValue("email") != s.Email || r.PostFormValue("password") != s.Password {
+		http.Error(w, "wrong email or password", http.StatusUnauthorized)
+		return
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	id := hex.EncodeToString(b[:])
+	s.mu.Lock()
+	if s.sessions == nil {
+		s.sessions = make(map[string]bool)
+	}
+	s.sessions[id] = true
+	s.mu.Unlock()
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: id, Path: "/", HttpOnly: true})
+	http.Redirect(w, r, "/ClipperWeb/account.html", http.StatusFound)
+}
+
+func (s *Server) loggedIn(r *http.Request) bool {
+	ck, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[ck.Value]
+}
+
+// statement answers the transaction history form with the card's statement.
+func (s *Server) statement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.PostFormValue("_csrf") == "" {
+		http.Error(w, "missing CSRF token", http.StatusForbidden)
+		return
+	}
+	serial, ok := cardNumber(r.PostFormValue("cardNumber"))
+	if !ok {
+		http.Error(w, "bad card number", http.StatusBadRequest)
+		return
+	}
+	s.serveFile(w, r, "statement-"+serial+".pdf", "application/pdf")
+}
+
+// cardNumber returns the card serial number in v, if it is one.
+func cardNumber(v string) (string, bool) {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n <= 0 {
+		return "", false
+	}
+	return strconv.FormatInt(n, 10), true
+}
+
+// serveFile serves the fixture called name, or the demo account's if the
+// server's fixtures don't have it, or a 404 if neither does.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, name, contentType string) {
+	name = path.Clean(name)
+	data, err := fs.ReadFile(s.Fixtures, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = fs.ReadFile(Demo(), name)
+	}
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method == "HEAD" {
+		return
+	}
+	w.Write(data)
+}
@@ -0,0 +1,151 @@
+package clippermock
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper"
+)
+
+func TestDemoAccount(t *testing.T) {
+	srv := httptest.NewServer(New())
+	defer srv.Close()
+	c, err := clipper.NewClient(DemoEmail, DemoPassword, clipper.WithSiteURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	cards, err := c.Cards(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 2 || cards[0].SerialNumber != 1202728442 || cards[0].Nickname != "Commute" {
+		t.Fatalf("cards: %+v", cards)
+	}
+
+	dir := t.TempDir()
+	r, err := clipper.ParseDateRange("2024-01-01", "2024-06-30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DownloadRange(ctx, dir, r, false); err != nil {
+		t.Fatal(err)
+	}
+	data, err := clipper.ParseFile(filepath.Join(dir, "clipper-transactions-1202728442.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.AccountNumber != 1202728442 || len(data.Transactions) != 32 {
+		t.Errorf("parsed account %d with %d records, want 1202728442 with 32", data.AccountNumber, len(data.Transactions))
+	}
+
+	card, err := c.CardDetails(ctx, cards[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2027, 3, 31, 0, 0, 0, 0, clipper.Pacific); !card.Expires.Equal(want) {
+		t.Errorf("Expires: got %v, want %v", card.Expires, want)
+	}
+	notices, err := c.Notices(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notices) != 1 || notices[0].Kind != clipper.NoticeCardExpiring {
+		t.Errorf("notices: %+v", notices)
+	}
+	pricing, err := c.Pricing(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pricing.CardFeeCents != 300 {
+		t.Errorf("card fee: got %d", pricing.CardFeeCents)
+	}
+}
+
+func TestWrongPassword(t *testing.T) {
+	srv := httptest.NewServer(New())
+	defer srv.Close()
+	c, err := clipper.NewClient(DemoEmail, "wrong", clipper.WithSiteURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Cards(context.Background()); err == nil {
+		t.Error("logged in with the wrong password")
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	srv := httptest.NewServer(New())
+	defer srv.Close()
+	c, err := clipper.NewClient(DemoEmail, DemoPassword, clipper.WithSiteURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Transactions reads the statements in memory, without leaving
+	// anything in the temporary directory.
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	result, err := c.Transactions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("got statements for %d cards, want 2", len(result))
+	}
+	for card, data := range result {
+		txns, err := data.ParsedTransactions()
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch card.SerialNumber {
+		case 1202728442:
+			if data.AccountNumber != card.SerialNumber || len(txns) != 31 {
+				t.Errorf("card %d: got account %d with %d transactions, want 31", card.SerialNumber, data.AccountNumber, len(txns))
+			}
+		case 1401491737:
+			if len(txns) != 0 {
+				t.Errorf("card %d: got %d transactions, want none", card.SerialNumber, len(txns))
+			}
+		default:
+			t.Errorf("unexpected card %+v", card)
+		}
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+		t.Errorf("Transactions left %s in the temporary directory", entries[0].Name())
+	}
+}
+
+func TestRecordFixtures(t *testing.T) {
+	mock := New()
+	var mu sync.Mutex
+	dashboards := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ClipperWeb/account.html" {
+			mu.Lock()
+			dashboards++
+			mu.Unlock()
+		}
+		mock.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	c, err := clipper.NewClient(DemoEmail, DemoPassword, clipper.WithSiteURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, err := c.RecordFixtures(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 3 {
+		t.Errorf("wrote %q, want the dashboard and two cards", written)
+	}
+	if dashboards != 1 {
+		t.Errorf("downloaded the account page %d times, want 1", dashboards)
+	}
+}
@@ -0,0 +1,206 @@
+package clipperstats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// An AnomalyKind is a type of unusual activity.
+type AnomalyKind string
+
+const (
+	// AnomalyNewLocation is a fare paid somewhere the card has never been.
+	AnomalyNewLocation AnomalyKind = "new_location"
+	// AnomalySimultaneousUse is two cards tagging in different places at
+	// nearly the same time, which can mean one of them is lost or stolen.
+	AnomalySimultaneousUse AnomalyKind = "simultaneous_use"
+	// AnomalySpendSpike is a day with far more spending than usual.
+	AnomalySpendSpike AnomalyKind = "spend_spike"
+)
+
+// An Anomaly is unusual activity worth alerting about.
+type Anomaly struct {
+	Kind       AnomalyKind
+	Time       time.Time
+	CardSerial int64
+	// Txns are the transactions involved.
+	Txns        []transit.Transaction
+	Description string
+}
+
+// AnomalyOptions tune anomaly detection. Zero values use the defaults.
+type AnomalyOptions struct {
+	// SimultaneousWindow is how close together tags on two cards in
+	// different places must be to be reported. The default is 10 minutes.
+	SimultaneousWindow time.Duration
+	// SpikeFactor is how many times the median daily spend a day's spend must
+	// be to be reported. The default is 3.
+	SpikeFactor float64
+	// MinSpikeCents is the least a day's spend must be to be reported as a
+	// spike, so cheap days don't trigger alerts. The default is $20.
+	MinSpikeCents transit.Money
+}
+
+func (o AnomalyOptions) withDefaults() AnomalyOptions {
+	if o.SimultaneousWindow == 0 {
+		o.SimultaneousWindow = 10 * time.Minute
+	}
+	if o.SpikeFactor == 0 {
+		o.SpikeFactor = 3
+	}
+	if o.MinSpikeCents == 0 {
+		o.MinSpikeCents = 2000
+	}
+	return o
+}
+
+// Anomalies looks for unusual activity in transactions at or after since,
+// comparing them with the transactions in txns before since. Results are
+// ordered by time.
+func Anomalies(txns []transit.Transaction, since time.Time, opts AnomalyOptions) []Anomaly {
+	opts = opts.withDefaults()
+	sorted := append([]transit.Transaction(nil), txns...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+	var history, recent []transit.Transaction
+	for _, t := range sorted {
+		if t.Timestamp.Before(since) {
+			history = append(history, t)
+		} else {
+			recent = append(recent, t)
+		}
+	}
+	var out []Anomaly
+	out = append(out, newLocations(history, recent)...)
+	out = append(out, simultaneousUse(recent, opts.SimultaneousWindow)...)
+	out = append(out, spendSpikes(history, recent, opts)...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Time.Before(out[j].Time)
+	})
+	return out
+}
+
+// newLocations reports the first fare at each place a card hasn't been to in
+// history. Cards with no history aren't reported, since every place would be
+// new.
+func newLocations(history, recent []transit.Transaction) []Anomaly {
+	seen := make(map[int64]map[string]bool)
+	for _, t := range history {
+		if !t.IsFare() || t.Location == "" {
+			continue
+		}
+		if seen[t.CardSerial] == nil {
+			seen[t.CardSerial] = make(map[string]bool)
+		}
+		seen[t.CardSerial][t.Location] = true
+	}
+	var out []Anomaly
+	for _, t := range recent {
+		places, ok := seen[t.CardSerial]
+		if !ok || !t.IsFare() || t.Location == "" || places[t.Location] {
+			continue
+		}
+		places[t.Location] = true
+		out = append(out, Anomaly{
+			Kind:        AnomalyNewLocation,
+			Time:        t.Timestamp,
+			CardSerial:  t.CardSerial,
+			Txns:        []transit.Transaction{t},
+			Description: fmt.Sprintf("Card %d was used at %s for the first time", t.CardSerial, t.Location),
+		})
+	}
+	return out
+}
+
+// simultaneousUse reports tags on two different cards at different places
+// within window of each other. txns must be sorted by time.
+func simultaneousUse(txns []transit.Transaction, window time.Duration) []Anomaly {
+	var out []Anomaly
+	reported := make(map[[2]int64]bool)
+	for i, a := range txns {
+		if !a.IsFare() || a.Location == "" {
+			continue
+		}
+		for _, b := range txns[i+1:] {
+			if b.Timestamp.Sub(a.Timestamp) > window {
+				break
+			}
+			if !b.IsFare() || b.CardSerial == a.CardSerial || b.Location == "" || b.Location == a.Location {
+				continue
+			}
+			// Report each pair of cards once per burst of activity.
+			pair := [2]int64{a.CardSerial, b.CardSerial}
+			if reported[pair] {
+				continue
+			}
+			reported[pair] = true
+			out = append(out, Anomaly{
+				Kind:       AnomalySimultaneousUse,
+				Time:       b.Timestamp,
+				CardSerial: b.CardSerial,
+				Txns:       []transit.Transaction{a, b},
+				Description: fmt.Sprintf("Card %d was used at %s at %s, %s after card %d was used at %s",
+					b.CardSerial, b.Location, b.Timestamp.Format("3:04 PM"), b.Timestamp.Sub(a.Timestamp), a.CardSerial, a.Location),
+			})
+		}
+	}
+	return out
+}
+
+// spendSpikes reports days in recent whose spend on a card is well above the
+// card's median daily spend in history, counting only days with rides.
+func spendSpikes(history, recent []transit.Transaction, opts AnomalyOptions) []Anomaly {
+	type k struct {
+		card int64
+		day  time.Time
+	}
+	daily := func(txns []transit.Transaction) (map[k]transit.Money, map[k][]transit.Transaction, []k) {
+		spend := make(map[k]transit.Money)
+		byDay := make(map[k][]transit.Transaction)
+		var keys []k
+		for _, t := range txns {
+			if !t.IsFare() {
+				continue
+			}
+			ts := t.Timestamp
+			key := k{t.CardSerial, time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, ts.Location())}
+			if _, ok := byDay[key]; !ok {
+				keys = append(keys, key)
+			}
+			spend[key] += t.FareCents()
+			byDay[key] = append(byDay[key], t)
+		}
+		return spend, byDay, keys
+	}
+	histSpend, _, histKeys := daily(history)
+	perCard := make(map[int64][]transit.Money)
+	for _, key := range histKeys {
+		perCard[key.card] = append(perCard[key.card], histSpend[key])
+	}
+	spend, byDay, keys := daily(recent)
+	var out []Anomaly
+	for _, key := range keys {
+		days := perCard[key.card]
+		if len(days) == 0 || spend[key] < opts.MinSpikeCents {
+			continue
+		}
+		med := median(days)
+		if float64(spend[key]) < opts.SpikeFactor*float64(med) {
+			continue
+		}
+		txns := byDay[key]
+		out = append(out, Anomaly{
+			Kind:       AnomalySpendSpike,
+			Time:       txns[len(txns)-1].Timestamp,
+			CardSerial: key.card,
+			Txns:       txns,
+			Description: fmt.Sprintf("Card %d spent %s on %s, compared with %s on a typical day",
+				key.card, spend[key], key.day.Format("Jan 2"), med),
+		})
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package clipperstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestAnomalies(t *testing.T) {
+	fare := func(day, hour, min int, card int64, location string, cents transit.Money) transit.Transaction {
+		return transit.Transaction{
+			Timestamp:  time.Date(2018, time.January, day, hour, min, 0, 0, time.UTC),
+			Type:       "Single-tag fare payment",
+			Location:   location,
+			DebitCents: cents,
+			CardSerial: card,
+		}
+	}
+	var txns []transit.Transaction
+	for day := 1; day <= 10; day++ {
+		txns = append(txns, fare(day, 8, 0, 1, "SAM bus", 205), fare(day, 17, 0, 1, "SAM bus", 205))
+		txns = append(txns, fare(day, 9, 0, 2, "Powell (Muni)", 250))
+	}
+	since := time.Date(2018, time.January, 11, 0, 0, 0, 0, time.UTC)
+	txns = append(txns,
+		// Card 2 is used across town five minutes after card 1.
+		fare(11, 8, 0, 1, "SAM bus", 205),
+		fare(11, 8, 5, 2, "Larkspur Ferry Terminal", 1100),
+		// ... and then racks up charges.
+		fare(11, 9, 0, 2, "Larkspur Ferry Terminal", 1100),
+	)
+	anomalies := Anomalies(txns, since, AnomalyOptions{})
+	kinds := make(map[AnomalyKind]int)
+	for _, a := range anomalies {
+		kinds[a.Kind]++
+		if a.CardSerial != 2 {
+			t.Errorf("expected only card 2 to be flagged, got %+v", a)
+		}
+	}
+	if kinds[AnomalyNewLocation] != 1 || kinds[AnomalySimultaneousUse] != 1 || kinds[AnomalySpendSpike] != 1 {
+		t.Errorf("got %+v", anomalies)
+	}
+	for i := 1; i < len(anomalies); i++ {
+		if anomalies[i].Time.Before(anomalies[i-1].Time) {
+			t.Errorf("anomalies out of order")
+		}
+	}
+
+	// A normal day raises nothing.
+	normal := append(txns[:30:30], fare(11, 8, 0, 1, "SAM bus", 205), fare(11, 9, 0, 2, "Powell (Muni)", 250))
+	if got := Anomalies(normal, since, AnomalyOptions{}); len(got) != 0 {
+		t.Errorf("expected no anomalies, got %+v", got)
+	}
+}
@@ -0,0 +1,169 @@
+package clipperstats
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// AutoloadOptions tune autoload suggestions. Zero values use the defaults.
+type AutoloadOptions struct {
+	// LeadDays is how many days of riding the threshold should cover, since
+	// value added by autoload can take time to reach the card. The default is
+	// 2.
+	LeadDays int
+	// ReloadDays is how many days of riding each reload should cover. Larger
+	// reloads happen less often but leave more money idle on the card. The
+	// default is 14.
+	ReloadDays int
+}
+
+func (o AutoloadOptions) withDefaults() AutoloadOptions {
+	if o.LeadDays == 0 {
+		o.LeadDays = 2
+	}
+	if o.ReloadDays == 0 {
+		o.ReloadDays = 14
+	}
+	return o
+}
+
+// An AutoloadSuggestion compares how a card has been reloaded with how fast
+// it's spent, and suggests autoload settings.
+type AutoloadSuggestion struct {
+	CardSerial int64
+	// DailySpendCents is the average fare spending per day.
+	DailySpendCents float64
+	// Reloads counts times value was added, and Autoloads how many of those
+	// were automatic.
+	Reloads, Autoloads int
+	AverageReloadCents transit.Money
+	// DaysBetweenReloads is the average time between reloads, or 0 if there
+	// were fewer than two.
+	DaysBetweenReloads float64
+	// LowBalanceEvents counts rides that left less on the card than the most
+	// expensive fare it has paid, so the next ride might not be covered.
+	LowBalanceEvents int
+	// AverageBalanceCents is the balance over time, weighted by how long the
+	// card held it.
+	AverageBalanceCents transit.Money
+
+	// ThresholdCents and AmountCents are the suggested autoload settings:
+	// add AmountCents when the balance falls below ThresholdCents.
+	ThresholdCents, AmountCents transit.Money
+	// SuggestedDaysPerReload is how often the suggested settings would
+	// reload, and SuggestedAverageBalanceCents about what they'd keep on the
+	// card.
+	SuggestedDaysPerReload       float64
+	SuggestedAverageBalanceCents transit.Money
+}
+
+// roundUpCents rounds cents up to a multiple of $5.
+func roundUpCents(cents float64) transit.Money {
+	const step = 500
+	n := transit.Money(cents+step-1) / step * step
+	if n < step {
+		n = step
+	}
+	return n
+}
+
+// SuggestAutoload looks at how card in txns has been spent and reloaded and
+// suggests an autoload threshold that covers opts.LeadDays of the heaviest
+// riding seen, and an amount that covers opts.ReloadDays of average riding.
+// It returns false if the card has no fare spending to base a suggestion on.
+func SuggestAutoload(txns []transit.Transaction, card int64, opts AutoloadOptions) (AutoloadSuggestion, bool) {
+	opts = opts.withDefaults()
+	ctxns := cardTxns(txns, card)
+	s := AutoloadSuggestion{CardSerial: card}
+	if len(ctxns) == 0 {
+		return s, false
+	}
+
+	daily := make(map[time.Time]transit.Money)
+	var maxFare, spend, reloaded transit.Money
+	var firstReload, lastReload time.Time
+	for _, t := range ctxns {
+		if t.IsReload() {
+			if s.Reloads == 0 {
+				firstReload = t.Timestamp
+			}
+			lastReload = t.Timestamp
+			s.Reloads++
+			reloaded += t.CreditCents
+			if strings.Contains(strings.ToLower(t.Type), "autoload") {
+				s.Autoloads++
+			}
+			continue
+		}
+		if !t.IsFare() {
+			continue
+		}
+		// Rebates count against the day's spending, since dual-tag rides
+		// deduct the maximum fare on entry and refund the difference on
+		// exit.
+		fare := t.FareCents()
+		spend += fare
+		y, m, d := t.Timestamp.Date()
+		daily[time.Date(y, m, d, 0, 0, 0, 0, t.Timestamp.Location())] += fare
+		if t.DebitCents > maxFare {
+			maxFare = t.DebitCents
+		}
+	}
+	if spend <= 0 {
+		return s, false
+	}
+	for _, t := range ctxns {
+		if t.IsFare() && t.DebitCents > 0 && t.BalanceCents < maxFare {
+			s.LowBalanceEvents++
+		}
+	}
+
+	first, last := ctxns[0].Timestamp, ctxns[len(ctxns)-1].Timestamp
+	days := last.Sub(first).Hours() / 24
+	if days < 1 {
+		days = 1
+	}
+	s.DailySpendCents = float64(spend) / days
+	if s.Reloads > 0 {
+		s.AverageReloadCents = reloaded.Div(s.Reloads)
+	}
+	if s.Reloads > 1 {
+		s.DaysBetweenReloads = lastReload.Sub(firstReload).Hours() / 24 / float64(s.Reloads-1)
+	}
+
+	points := BalanceTimeline(ctxns, card)
+	var weighted, total float64
+	for i := 0; i+1 < len(points); i++ {
+		d := points[i+1].Time.Sub(points[i].Time).Hours()
+		weighted += float64(points[i].BalanceCents) * d
+		total += d
+	}
+	if total > 0 {
+		s.AverageBalanceCents = transit.Money(math.Round(weighted / total))
+	} else {
+		s.AverageBalanceCents = points[len(points)-1].BalanceCents
+	}
+
+	// The heaviest riding over any LeadDays in a row.
+	var peak transit.Money
+	for day := range daily {
+		var window transit.Money
+		for i := 0; i < opts.LeadDays; i++ {
+			window += daily[day.AddDate(0, 0, i)]
+		}
+		if window > peak {
+			peak = window
+		}
+	}
+	if maxFare > peak {
+		peak = maxFare
+	}
+	s.ThresholdCents = roundUpCents(float64(peak))
+	s.AmountCents = roundUpCents(s.DailySpendCents * float64(opts.ReloadDays))
+	s.SuggestedDaysPerReload = float64(s.AmountCents) / s.DailySpendCents
+	s.SuggestedAverageBalanceCents = s.ThresholdCents + s.AmountCents/2
+	return s, true
+}
@@ -0,0 +1,47 @@
+package clipperstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestSuggestAutoload(t *testing.T) {
+	start := time.Date(2018, time.January, 1, 8, 0, 0, 0, time.UTC)
+	balance := transit.Money(5000)
+	var txns []transit.Transaction
+	for day := 0; day < 20; day++ {
+		ts := start.AddDate(0, 0, day)
+		if day == 10 {
+			balance += 5000
+			txns = append(txns, transit.Transaction{Timestamp: ts, Type: "Autoload", CreditCents: 5000, BalanceCents: balance, CardSerial: 1})
+		}
+		balance -= 400
+		txns = append(txns, transit.Transaction{Timestamp: ts.Add(time.Hour), Type: "Single-tag fare payment", DebitCents: 400, BalanceCents: balance, CardSerial: 1})
+	}
+	s, ok := SuggestAutoload(txns, 1, AutoloadOptions{})
+	if !ok {
+		t.Fatal("expected a suggestion")
+	}
+	if s.Reloads != 1 || s.Autoloads != 1 || s.AverageReloadCents != 5000 {
+		t.Errorf("reloads: got %+v", s)
+	}
+	if s.DailySpendCents < 420 || s.DailySpendCents > 422 {
+		t.Errorf("DailySpendCents: got %v", s.DailySpendCents)
+	}
+	// Two days of $4 a day is $8, rounded up to $10.
+	if s.ThresholdCents != 1000 {
+		t.Errorf("ThresholdCents: got %d, want 1000", s.ThresholdCents)
+	}
+	// Fourteen days at about $4.21 a day is $58.95, rounded up to $60.
+	if s.AmountCents != 6000 {
+		t.Errorf("AmountCents: got %d, want 6000", s.AmountCents)
+	}
+	if s.AverageBalanceCents < 3000 || s.AverageBalanceCents > 4000 || s.SuggestedAverageBalanceCents != 4000 {
+		t.Errorf("balances: got %d now, %d suggested", s.AverageBalanceCents, s.SuggestedAverageBalanceCents)
+	}
+	if _, ok := SuggestAutoload(txns, 2, AutoloadOptions{}); ok {
+		t.Errorf("expected no suggestion for an unknown card")
+	}
+}
@@ -0,0 +1,149 @@
+package clipperstats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// DefaultSpendLookback is how far back ProjectRunOut looks to measure the
+// recent spend rate.
+const DefaultSpendLookback = 30 * 24 * time.Hour
+
+// Cards returns the serial numbers of the cards in txns, in ascending order.
+func Cards(txns []transit.Transaction) []int64 {
+	seen := make(map[int64]bool)
+	var cards []int64
+	for _, t := range txns {
+		if !seen[t.CardSerial] {
+			seen[t.CardSerial] = true
+			cards = append(cards, t.CardSerial)
+		}
+	}
+	sort.Slice(cards, func(i, j int) bool { return cards[i] < cards[j] })
+	return cards
+}
+
+// cardTxns returns the transactions in txns on card, oldest first.
+func cardTxns(txns []transit.Transaction, card int64) []transit.Transaction {
+	var out []transit.Transaction
+	for _, t := range txns {
+		if t.CardSerial == card {
+			out = append(out, t)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Timestamp.Before(out[j].Timestamp)
+	})
+	return out
+}
+
+// A BalancePoint is the cash value on a card after a transaction.
+type BalancePoint struct {
+	Time         time.Time
+	BalanceCents transit.Money
+}
+
+// BalanceTimeline returns the balance on card after each of its transactions
+// in txns, oldest first. Consecutive points with the same balance are
+// collapsed.
+func BalanceTimeline(txns []transit.Transaction, card int64) []BalancePoint {
+	var out []BalancePoint
+	for _, t := range cardTxns(txns, card) {
+		if len(out) > 0 && out[len(out)-1].BalanceCents == t.BalanceCents {
+			continue
+		}
+		out = append(out, BalancePoint{Time: t.Timestamp, BalanceCents: t.BalanceCents})
+	}
+	return out
+}
+
+// A BalanceJump is a change in balance that the debits and credits between
+// two transactions don't account for, for example because a statement is
+// missing, or Clipper adjusted the balance without a transaction.
+type BalanceJump struct {
+	Before, After transit.Transaction
+	// ExpectedCents is the balance After should have shown.
+	ExpectedCents transit.Money
+}
+
+// DiffCents returns how much more the balance was than expected; it's negative
+// if money went missing.
+func (j BalanceJump) DiffCents() transit.Money {
+	return j.After.BalanceCents - j.ExpectedCents
+}
+
+// BalanceJumps returns the unexplained balance changes in txns, oldest first.
+func BalanceJumps(txns []transit.Transaction) []BalanceJump {
+	var out []BalanceJump
+	for _, card := range Cards(txns) {
+		ctxns := cardTxns(txns, card)
+		for i := 1; i < len(ctxns); i++ {
+			prev, t := ctxns[i-1], ctxns[i]
+			expected := prev.BalanceCents - t.DebitCents + t.CreditCents
+			if t.BalanceCents != expected {
+				out = append(out, BalanceJump{Before: prev, After: t, ExpectedCents: expected})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].After.Timestamp.Before(out[j].After.Timestamp)
+	})
+	return out
+}
+
+// A Projection estimates when a card will run out of cash value.
+type Projection struct {
+	CardSerial int64
+	// AsOf is the time of the card's latest transaction, and BalanceCents the
+	// balance after it.
+	AsOf         time.Time
+	BalanceCents transit.Money
+	// DailySpendCents is the average fare spending per day over the lookback
+	// period.
+	DailySpendCents float64
+	// RunOut is when the balance will reach zero at DailySpendCents, assuming
+	// no value is added. It's the zero time if the card isn't being spent
+	// down.
+	RunOut time.Time
+}
+
+// DaysLeft returns the number of days from AsOf until RunOut, or -1 if there's
+// no projected run-out date.
+func (p Projection) DaysLeft() int {
+	if p.RunOut.IsZero() {
+		return -1
+	}
+	return int(p.RunOut.Sub(p.AsOf).Hours() / 24)
+}
+
+// ProjectRunOut projects when card will run out of cash value, measuring the
+// spend rate over the lookback period before its latest transaction. A lookback
+// of zero uses DefaultSpendLookback.
+func ProjectRunOut(txns []transit.Transaction, card int64, lookback time.Duration) Projection {
+	if lookback == 0 {
+		lookback = DefaultSpendLookback
+	}
+	ctxns := cardTxns(txns, card)
+	p := Projection{CardSerial: card}
+	if len(ctxns) == 0 {
+		return p
+	}
+	last := ctxns[len(ctxns)-1]
+	p.AsOf, p.BalanceCents = last.Timestamp, last.BalanceCents
+	since := last.Timestamp.Add(-lookback)
+	var spend transit.Money
+	for _, t := range ctxns {
+		if t.Timestamp.After(since) {
+			spend += t.FareCents()
+		}
+	}
+	p.DailySpendCents = float64(spend) / (lookback.Hours() / 24)
+	if p.DailySpendCents > 0 && p.BalanceCents > 0 {
+		days := float64(p.BalanceCents) / p.DailySpendCents
+		p.RunOut = p.AsOf.Add(time.Duration(math.Ceil(days * 24 * float64(time.Hour))))
+	}
+	return p
+}
@@ -0,0 +1,38 @@
+package clipperstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestBalance(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2018, time.January, d, 8, 0, 0, 0, time.UTC) }
+	txns := []transit.Transaction{
+		{Timestamp: day(1), Type: "Single-tag fare payment", DebitCents: 200, BalanceCents: 1000, CardSerial: 1},
+		{Timestamp: day(2), Type: "Dual-tag entry transaction, no fare deduction", BalanceCents: 1000, CardSerial: 1},
+		{Timestamp: day(2), Type: "Dual-tag exit transaction, fare payment", DebitCents: 200, BalanceCents: 800, CardSerial: 1},
+		// A statement is missing between these two.
+		{Timestamp: day(20), Type: "Single-tag fare payment", DebitCents: 200, BalanceCents: 400, CardSerial: 1},
+		{Timestamp: day(21), Type: "Single-tag fare payment", DebitCents: 200, BalanceCents: 5000, CardSerial: 2},
+	}
+	timeline := BalanceTimeline(txns, 1)
+	if len(timeline) != 3 || timeline[2].BalanceCents != 400 {
+		t.Errorf("BalanceTimeline: got %+v", timeline)
+	}
+	jumps := BalanceJumps(txns)
+	if len(jumps) != 1 || jumps[0].ExpectedCents != 600 || jumps[0].DiffCents() != -200 {
+		t.Fatalf("BalanceJumps: got %+v", jumps)
+	}
+	p := ProjectRunOut(txns, 1, 10*24*time.Hour)
+	if p.BalanceCents != 400 || p.DailySpendCents != 20 {
+		t.Fatalf("ProjectRunOut: got %+v", p)
+	}
+	if p.DaysLeft() != 20 || !p.RunOut.Equal(day(40)) {
+		t.Errorf("RunOut: got %v (%d days)", p.RunOut, p.DaysLeft())
+	}
+	if idle := ProjectRunOut(txns, 3, 0); !idle.RunOut.IsZero() || idle.DaysLeft() != -1 {
+		t.Errorf("expected no projection for an unknown card, got %+v", idle)
+	}
+}
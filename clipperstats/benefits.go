@@ -0,0 +1,102 @@
+package clipperstats
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// TransitBenefitCapCents are the IRS monthly limits on pre-tax transit
+// benefits, by year.
+var TransitBenefitCapCents = map[int]transit.Money{
+	2017: 25500,
+	2018: 26000,
+	2019: 26500,
+	2020: 27000,
+	2021: 27000,
+	2022: 28000,
+	2023: 30000,
+	2024: 31500,
+	2025: 32500,
+	2026: 34000,
+}
+
+// transitBenefitCap returns the cap for year, or the latest known cap for
+// years after the table ends.
+func transitBenefitCap(year int) transit.Money {
+	if c, ok := TransitBenefitCapCents[year]; ok {
+		return c
+	}
+	latest, cap := 0, transit.Money(0)
+	for y, c := range TransitBenefitCapCents {
+		if y < year && y > latest {
+			latest, cap = y, c
+		}
+	}
+	return cap
+}
+
+// DefaultBenefitPatterns match the transaction types and locations statements
+// use for value loaded by an employer's commuter benefit program.
+var DefaultBenefitPatterns = []string{"commuter", "benefit", "employer", "wageworks", "edenred", "pre-tax", "pretax"}
+
+// isBenefitLoad reports whether t adds commuter benefit value to the card.
+func isBenefitLoad(t transit.Transaction, patterns []string) bool {
+	if !t.IsReload() {
+		return false
+	}
+	text := strings.ToLower(t.Type + " " + t.Location + " " + t.Product)
+	for _, p := range patterns {
+		if p != "" && strings.Contains(text, strings.ToLower(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// A BenefitMonth reconciles commuter benefit loads against transit spending
+// for one calendar month.
+type BenefitMonth struct {
+	// Start is midnight on the first day of the month.
+	Start       time.Time
+	LoadedCents transit.Money
+	SpentCents  transit.Money
+	// UnusedCents is the benefit value loaded so far that hasn't been spent
+	// by the end of the month. Spending is counted against benefit value
+	// first.
+	UnusedCents transit.Money
+	// CapCents is the IRS monthly limit, and OverCapCents how much more than
+	// that was loaded.
+	CapCents     transit.Money
+	OverCapCents transit.Money
+}
+
+// ReconcileBenefits compares commuter benefit loads in txns with fare spending,
+// month by month, oldest first. Loads are reloads whose type, location or
+// product contain one of patterns; nil patterns use DefaultBenefitPatterns.
+func ReconcileBenefits(txns []transit.Transaction, patterns []string) []BenefitMonth {
+	if patterns == nil {
+		patterns = DefaultBenefitPatterns
+	}
+	var out []BenefitMonth
+	var unused transit.Money
+	for _, m := range Monthly(txns) {
+		bm := BenefitMonth{Start: m.Start, SpentCents: m.SpendCents, CapCents: transitBenefitCap(m.Start.Year())}
+		for _, t := range txns {
+			if monthStart(t.Timestamp).Equal(m.Start) && isBenefitLoad(t, patterns) {
+				bm.LoadedCents += t.CreditCents
+			}
+		}
+		unused += bm.LoadedCents - bm.SpentCents
+		if unused < 0 {
+			unused = 0
+		}
+		bm.UnusedCents = unused
+		if bm.CapCents > 0 && bm.LoadedCents > bm.CapCents {
+			bm.OverCapCents = bm.LoadedCents - bm.CapCents
+		}
+		out = append(out, bm)
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package clipperstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestReconcileBenefits(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2018, month, d, 8, 0, 0, 0, time.UTC)
+	}
+	txns := []transit.Transaction{
+		{Timestamp: day(time.January, 1), Type: "Add value", Product: "Commuter Benefits", CreditCents: 10000},
+		{Timestamp: day(time.January, 2), Type: "Single-tag fare payment", DebitCents: 6000},
+		// A personal reload isn't a benefit.
+		{Timestamp: day(time.January, 3), Type: "Autoload", CreditCents: 5000},
+		{Timestamp: day(time.February, 1), Type: "Add value", Product: "Commuter Benefits", CreditCents: 30000},
+		{Timestamp: day(time.February, 2), Type: "Single-tag fare payment", DebitCents: 5000},
+		{Timestamp: day(time.March, 2), Type: "Single-tag fare payment", DebitCents: 40000},
+	}
+	months := ReconcileBenefits(txns, nil)
+	if len(months) != 3 {
+		t.Fatalf("expected 3 months, got %+v", months)
+	}
+	if m := months[0]; m.LoadedCents != 10000 || m.SpentCents != 6000 || m.UnusedCents != 4000 || m.CapCents != 26000 || m.OverCapCents != 0 {
+		t.Errorf("January: got %+v", m)
+	}
+	if m := months[1]; m.UnusedCents != 4000+30000-5000 || m.OverCapCents != 4000 {
+		t.Errorf("February: got %+v", m)
+	}
+	if m := months[2]; m.UnusedCents != 0 {
+		t.Errorf("March: got %+v", m)
+	}
+	if got := transitBenefitCap(2040); got != TransitBenefitCapCents[2026] {
+		t.Errorf("expected the latest cap for future years, got %d", got)
+	}
+}
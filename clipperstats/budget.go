@@ -0,0 +1,90 @@
+package clipperstats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// DefaultBudgetThresholds are the percentages of a budget at which
+// BudgetCrossings reports spending.
+var DefaultBudgetThresholds = []int{50, 80, 100}
+
+// A Budget is a monthly spending limit on a set of cards, for a person or a
+// single card.
+type Budget struct {
+	Name       string
+	Cards      []int64
+	LimitCents transit.Money
+}
+
+// covers reports whether b applies to card.
+func (b Budget) covers(card int64) bool {
+	for _, c := range b.Cards {
+		if c == card {
+			return true
+		}
+	}
+	return false
+}
+
+// A BudgetCrossing is the point where a budget's month-to-date spending first
+// reached one of its thresholds.
+type BudgetCrossing struct {
+	Budget Budget
+	// Month is midnight on the first of the month the spending was in.
+	Month time.Time
+	// Percent is the threshold that was crossed.
+	Percent int
+	// Time is when the transaction that crossed it happened, and SpentCents
+	// the month-to-date spending after it.
+	Time       time.Time
+	SpentCents transit.Money
+}
+
+// BudgetCrossings walks through each month's spending on each budget's cards
+// and returns every time the running total reached one of thresholds, as
+// percentages of the budget's limit. A threshold of 100 is crossed when
+// spending reaches the limit. Budgets without a limit are skipped. Results are
+// ordered by time.
+func BudgetCrossings(txns []transit.Transaction, budgets []Budget, thresholds []int) []BudgetCrossing {
+	sorted := append([]transit.Transaction(nil), txns...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+	levels := append([]int(nil), thresholds...)
+	sort.Ints(levels)
+	var out []BudgetCrossing
+	for _, b := range budgets {
+		if b.LimitCents <= 0 {
+			continue
+		}
+		var month time.Time
+		var spent transit.Money
+		next := 0
+		for _, t := range sorted {
+			if !b.covers(t.CardSerial) {
+				continue
+			}
+			if m := monthStart(t.Timestamp); !m.Equal(month) {
+				month, spent, next = m, 0, 0
+			}
+			spent += t.FareCents()
+			for next < len(levels) && spent*100 >= transit.Money(levels[next])*b.LimitCents {
+				out = append(out, BudgetCrossing{
+					Budget:     b,
+					Month:      month,
+					Percent:    levels[next],
+					Time:       t.Timestamp,
+					SpentCents: spent,
+				})
+				next++
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Time.Before(out[j].Time)
+	})
+	return out
+}
@@ -0,0 +1,41 @@
+package clipperstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestBudgetCrossings(t *testing.T) {
+	fare := func(month time.Month, day int, card int64, cents transit.Money) transit.Transaction {
+		return transit.Transaction{
+			Timestamp:  time.Date(2018, month, day, 8, 0, 0, 0, time.UTC),
+			Type:       "Single-tag fare payment",
+			DebitCents: cents,
+			CardSerial: card,
+		}
+	}
+	txns := []transit.Transaction{
+		fare(time.January, 2, 1, 400),
+		fare(time.January, 3, 1, 200),
+		fare(time.January, 4, 2, 500),
+		fare(time.January, 5, 1, 500),
+		fare(time.February, 1, 1, 600),
+		{Timestamp: time.Date(2018, time.January, 6, 8, 0, 0, 0, time.UTC), Type: "Autoload", CreditCents: 5000, CardSerial: 1},
+	}
+	budgets := []Budget{{Name: "alice", Cards: []int64{1}, LimitCents: 1000}, {Name: "none", Cards: []int64{2}}}
+	got := BudgetCrossings(txns, budgets, []int{100, 50})
+	if len(got) != 3 {
+		t.Fatalf("expected 3 crossings, got %+v", got)
+	}
+	if got[0].Percent != 50 || got[0].Time.Day() != 3 || got[0].SpentCents != 600 {
+		t.Errorf("first crossing: got %+v", got[0])
+	}
+	if got[1].Percent != 100 || got[1].Time.Day() != 5 || got[1].SpentCents != 1100 {
+		t.Errorf("second crossing: got %+v", got[1])
+	}
+	if got[2].Percent != 50 || got[2].Month.Month() != time.February {
+		t.Errorf("expected the count to reset in February, got %+v", got[2])
+	}
+}
@@ -0,0 +1,184 @@
+package clipperstats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// DefaultGoPassAnnualCents is roughly what Caltrain charges employers per
+// employee per year for a GoPass, an unlimited all-zone pass, as of 2024.
+// Check with your employer for the price they pay.
+const DefaultGoPassAnnualCents transit.Money = 43500
+
+// A ZonePair is the range of Caltrain zones a ride travels through, with From
+// no greater than To.
+type ZonePair struct {
+	From, To int
+}
+
+// Zones returns the number of zones in the pair, which is what Caltrain fares
+// and monthly passes are priced by.
+func (p ZonePair) Zones() int { return p.To - p.From + 1 }
+
+// Covers reports whether a ride through o stays within p.
+func (p ZonePair) Covers(o ZonePair) bool { return p.From <= o.From && o.To <= p.To }
+
+func (p ZonePair) String() string {
+	if p.From == p.To {
+		return fmt.Sprintf("zone %d", p.From)
+	}
+	return fmt.Sprintf("zones %d-%d", p.From, p.To)
+}
+
+// legZones returns the zones a Caltrain ride traveled through, or false if
+// its stations or zones aren't known, for example if it's missing a tag-off.
+func legZones(l Leg) (ZonePair, bool) {
+	if l.Agency != transit.AgencyCaltrain || l.Destination() == "" {
+		return ZonePair{}, false
+	}
+	from, to, ok := legStations(l)
+	if !ok || from.Zone == 0 || to.Zone == 0 {
+		return ZonePair{}, false
+	}
+	if to.Zone < from.Zone {
+		from, to = to, from
+	}
+	return ZonePair{From: from.Zone, To: to.Zone}, true
+}
+
+// A ZonePairSpend is the riding between one pair of Caltrain zones.
+type ZonePairSpend struct {
+	Pair       ZonePair
+	Trips      int
+	SpendCents transit.Money
+}
+
+// CaltrainZonePairs returns Caltrain rides in txns grouped by the zones they
+// traveled through, most ridden first. Rides whose zones aren't known are
+// left out.
+func CaltrainZonePairs(txns []transit.Transaction) []ZonePairSpend {
+	byPair := make(map[ZonePair]*ZonePairSpend)
+	for _, l := range Legs(txns) {
+		pair, ok := legZones(l)
+		if !ok {
+			continue
+		}
+		s, ok := byPair[pair]
+		if !ok {
+			s = &ZonePairSpend{Pair: pair}
+			byPair[pair] = s
+		}
+		s.Trips++
+		s.SpendCents += l.FareCents()
+	}
+	out := make([]ZonePairSpend, 0, len(byPair))
+	for _, s := range byPair {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Trips != out[j].Trips {
+			return out[i].Trips > out[j].Trips
+		}
+		if out[i].Pair.From != out[j].Pair.From {
+			return out[i].Pair.From < out[j].Pair.From
+		}
+		return out[i].Pair.To < out[j].Pair.To
+	})
+	return out
+}
+
+// A CaltrainMonth compares a month of Caltrain rides paid as you go with a
+// zone-specific monthly pass and with a GoPass.
+type CaltrainMonth struct {
+	// Start is midnight on the first day of the month.
+	Start      time.Time
+	Trips      int
+	SpendCents transit.Money
+	// Pair is the zone range a monthly pass would need to cover the month's
+	// most common ride, and Pass the cheapest pass covering that many zones.
+	// Pass is empty if no pass covers it.
+	Pair ZonePair
+	Pass string
+	// PassCents is what the month would have cost with the pass: its price
+	// plus fares for rides outside its zones.
+	PassCents transit.Money
+	// GoPassCents is a month's share of the annual GoPass price.
+	GoPassCents transit.Money
+}
+
+// SavingsCents returns how much the pass would have saved over paying as you
+// go. It's negative if the pass would have cost more, and zero if there's no
+// pass to compare.
+func (m CaltrainMonth) SavingsCents() transit.Money {
+	if m.Pass == "" {
+		return 0
+	}
+	return m.SpendCents - m.PassCents
+}
+
+// CaltrainPasses compares each month of Caltrain rides in txns with the
+// Caltrain zone passes in products, and with a GoPass costing
+// goPassAnnualCents a year. Zero uses DefaultGoPassAnnualCents.
+func CaltrainPasses(txns []transit.Transaction, products []FareProduct, goPassAnnualCents transit.Money) []CaltrainMonth {
+	if goPassAnnualCents == 0 {
+		goPassAnnualCents = DefaultGoPassAnnualCents
+	}
+	var passes []FareProduct
+	for _, p := range products {
+		if p.Zones > 0 && len(p.Agencies) == 1 && p.Agencies[0] == transit.AgencyCaltrain {
+			passes = append(passes, p)
+		}
+	}
+	sort.SliceStable(passes, func(i, j int) bool { return passes[i].Zones < passes[j].Zones })
+
+	byMonth := make(map[time.Time][]Leg)
+	var starts []time.Time
+	for _, l := range Legs(txns) {
+		if l.Agency != transit.AgencyCaltrain {
+			continue
+		}
+		start := monthStart(l.Start())
+		if _, ok := byMonth[start]; !ok {
+			starts = append(starts, start)
+		}
+		byMonth[start] = append(byMonth[start], l)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	out := make([]CaltrainMonth, 0, len(starts))
+	for _, start := range starts {
+		legs := byMonth[start]
+		m := CaltrainMonth{Start: start, Trips: len(legs), GoPassCents: (goPassAnnualCents + 6) / 12}
+		counts := make(map[ZonePair]int)
+		for _, l := range legs {
+			m.SpendCents += l.FareCents()
+			if pair, ok := legZones(l); ok {
+				counts[pair]++
+			}
+		}
+		for pair, n := range counts {
+			if n > counts[m.Pair] || (n == counts[m.Pair] && pair.Zones() > m.Pair.Zones()) {
+				m.Pair = pair
+			}
+		}
+		for _, p := range passes {
+			if m.Pair.Zones() > 0 && p.Zones >= m.Pair.Zones() {
+				m.Pass = p.Name
+				m.PassCents = p.PriceCents
+				break
+			}
+		}
+		if m.Pass != "" {
+			for _, l := range legs {
+				if pair, ok := legZones(l); !ok || !m.Pair.Covers(pair) {
+					m.PassCents += l.FareCents()
+				}
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
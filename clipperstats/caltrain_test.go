@@ -0,0 +1,51 @@
+package clipperstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestCaltrainPasses(t *testing.T) {
+	ride := func(day, hour int, from, to string, fare transit.Money) []transit.Transaction {
+		start := time.Date(2024, time.March, day, hour, 0, 0, 0, time.UTC)
+		return []transit.Transaction{
+			{Timestamp: start, Type: "Dual-tag entry transaction, maximum fare deducted (purse debit)", Location: from, DebitCents: 1400},
+			{Timestamp: start.Add(40 * time.Minute), Type: "Dual-tag exit transaction, fare adjustment (purse rebate)", Location: to, CreditCents: 1400 - fare},
+		}
+	}
+	var txns []transit.Transaction
+	for day := 1; day <= 20; day++ {
+		// Belmont is in zone 3 and San Francisco in zone 1.
+		txns = append(txns, ride(day, 7, "Belmont (Caltrain)", "San Francisco (Caltrain)", 570)...)
+		txns = append(txns, ride(day, 17, "San Francisco (Caltrain)", "Belmont (Caltrain)", 570)...)
+	}
+	// One longer ride to San Jose, in zone 4.
+	txns = append(txns, ride(23, 10, "Belmont (Caltrain)", "San Jose Diridon (Caltrain)", 795)...)
+
+	pairs := CaltrainZonePairs(txns)
+	if len(pairs) != 2 || pairs[0].Pair != (ZonePair{1, 3}) || pairs[0].Trips != 40 || pairs[0].SpendCents != 40*570 {
+		t.Fatalf("pairs: got %+v", pairs)
+	}
+	if pairs[1].Pair != (ZonePair{3, 4}) || pairs[1].Pair.Zones() != 2 {
+		t.Errorf("second pair: got %+v", pairs[1])
+	}
+
+	months := CaltrainPasses(txns, DefaultFareProducts, 0)
+	if len(months) != 1 {
+		t.Fatalf("expected 1 month, got %+v", months)
+	}
+	m := months[0]
+	if m.Trips != 41 || m.SpendCents != 40*570+795 || m.Pass != "Caltrain 3-zone" {
+		t.Errorf("month: got %+v", m)
+	}
+	// The pass covers the commute but not the San Jose ride, and costs a
+	// little more than paying as you go.
+	if m.PassCents != 23100+795 || m.SavingsCents() != 40*570-23100 {
+		t.Errorf("PassCents: got %d, savings %d", m.PassCents, m.SavingsCents())
+	}
+	if m.GoPassCents != 3625 {
+		t.Errorf("GoPassCents: got %d", m.GoPassCents)
+	}
+}
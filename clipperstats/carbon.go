@@ -0,0 +1,136 @@
+package clipperstats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// legStations returns the stations where l started and ended.
+func legStations(l Leg) (from, to transit.Station, ok bool) {
+	found := false
+	for _, t := range l.Txns {
+		st, ok := l.station(t.Location)
+		if !ok {
+			continue
+		}
+		if !found {
+			from, found = st, true
+		}
+		to = st
+	}
+	return from, to, found
+}
+
+// A Mode describes the emissions of riding one agency.
+type Mode struct {
+	// GramsPerMile is the CO2 emitted per passenger mile.
+	GramsPerMile float64
+	// TypicalMiles is the length of a ride whose distance can't be worked
+	// out from its stations, for example a bus ride.
+	TypicalMiles float64
+}
+
+// EmissionFactors are the CO2 estimates used by Carbon.
+type EmissionFactors struct {
+	Modes map[transit.Agency]Mode
+	// Unknown is used for rides on agencies not in Modes.
+	Unknown Mode
+	// DrivingGramsPerMile is the CO2 emitted by driving the same distance
+	// alone.
+	DrivingGramsPerMile float64
+	// Circuity is how much longer a rail route is than the straight line
+	// between its stations.
+	Circuity float64
+}
+
+// DefaultEmissionFactors are rough per-passenger averages for Bay Area
+// operators, and the EPA's figure for a typical passenger car.
+var DefaultEmissionFactors = EmissionFactors{
+	Modes: map[transit.Agency]Mode{
+		transit.AgencyBART:            {GramsPerMile: 30, TypicalMiles: 12},
+		transit.AgencyCaltrain:        {GramsPerMile: 60, TypicalMiles: 20},
+		transit.AgencySFMTA:           {GramsPerMile: 120, TypicalMiles: 2.5},
+		transit.AgencyACTransit:       {GramsPerMile: 290, TypicalMiles: 4},
+		transit.AgencySamTrans:        {GramsPerMile: 290, TypicalMiles: 4},
+		transit.AgencyVTA:             {GramsPerMile: 290, TypicalMiles: 4},
+		transit.AgencyGoldenGate:      {GramsPerMile: 290, TypicalMiles: 15},
+		transit.AgencyGoldenGateFerry: {GramsPerMile: 500, TypicalMiles: 10},
+		transit.AgencySFBayFerry:      {GramsPerMile: 500, TypicalMiles: 10},
+	},
+	Unknown:             Mode{GramsPerMile: 200, TypicalMiles: 4},
+	DrivingGramsPerMile: 400,
+	Circuity:            1.2,
+}
+
+// A TripEmissions estimates the CO2 from one ride, and from driving instead.
+type TripEmissions struct {
+	Leg   Leg
+	Miles float64
+	// Measured is true if Miles comes from the ride's stations, and false if
+	// it's the mode's typical ride length.
+	Measured     bool
+	TransitGrams float64
+	DrivingGrams float64
+}
+
+// Emissions estimates the CO2 emitted by each ride in txns, oldest first.
+func Emissions(txns []transit.Transaction, f EmissionFactors) []TripEmissions {
+	var out []TripEmissions
+	for _, ls := range cardLegs(txns) {
+		for _, l := range ls {
+			mode, ok := f.Modes[l.Agency]
+			if !ok {
+				mode = f.Unknown
+			}
+			e := TripEmissions{Leg: l, Miles: mode.TypicalMiles}
+			if from, to, ok := legStations(l); ok && from != to {
+				circuity := f.Circuity
+				if circuity == 0 {
+					circuity = 1
+				}
+				e.Miles, e.Measured = from.MilesTo(to)*circuity, true
+			}
+			e.TransitGrams = e.Miles * mode.GramsPerMile
+			e.DrivingGrams = e.Miles * f.DrivingGramsPerMile
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Leg.Start().Before(out[j].Leg.Start())
+	})
+	return out
+}
+
+// A CarbonMonth totals ride emissions in one calendar month.
+type CarbonMonth struct {
+	// Start is midnight on the first day of the month.
+	Start     time.Time
+	Trips     int
+	Miles     float64
+	TransitKg float64
+	DrivingKg float64
+}
+
+// SavedKg returns the CO2 saved by riding transit instead of driving.
+func (c CarbonMonth) SavedKg() float64 {
+	return c.DrivingKg - c.TransitKg
+}
+
+// CarbonByMonth totals emissions by calendar month, oldest first.
+func CarbonByMonth(emissions []TripEmissions) []CarbonMonth {
+	var out []CarbonMonth
+	for _, e := range emissions {
+		start := monthStart(e.Leg.Start())
+		if len(out) == 0 || !out[len(out)-1].Start.Equal(start) {
+			out = append(out, CarbonMonth{Start: start})
+		}
+		c := &out[len(out)-1]
+		c.Trips++
+		c.Miles += e.Miles
+		c.TransitKg += e.TransitGrams / 1000
+		c.DrivingKg += e.DrivingGrams / 1000
+	}
+	return out
+}
@@ -0,0 +1,38 @@
+package clipperstats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestEmissions(t *testing.T) {
+	txns := []transit.Transaction{
+		{Timestamp: at(8, 0), Type: "Single-tag fare payment", Location: "SAM bus", DebitCents: 205},
+		{Timestamp: at(9, 0), Type: "Dual-tag entry transaction, no fare deduction", Location: "Powell St (BART)"},
+		// The exit doesn't say which Millbrae station it is.
+		{Timestamp: at(9, 30), Type: "Dual-tag exit transaction, fare payment", Location: "Millbrae", DebitCents: 630},
+	}
+	f := DefaultEmissionFactors
+	emissions := Emissions(txns, f)
+	if len(emissions) != 2 {
+		t.Fatalf("expected 2 rides, got %d", len(emissions))
+	}
+	bus := emissions[0]
+	if bus.Measured || bus.Miles != 4 || bus.TransitGrams != 4*290 || bus.DrivingGrams != 4*400 {
+		t.Errorf("bus: got %+v", bus)
+	}
+	bart := emissions[1]
+	if !bart.Measured || bart.Miles < 15 || bart.Miles > 18 {
+		t.Errorf("BART: got %+v", bart)
+	}
+	months := CarbonByMonth(emissions)
+	if len(months) != 1 || months[0].Trips != 2 {
+		t.Fatalf("CarbonByMonth: got %+v", months)
+	}
+	want := (bus.DrivingGrams + bart.DrivingGrams - bus.TransitGrams - bart.TransitGrams) / 1000
+	if math.Abs(months[0].SavedKg()-want) > 1e-9 {
+		t.Errorf("SavedKg: got %v, want %v", months[0].SavedKg(), want)
+	}
+}
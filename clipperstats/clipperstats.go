@@ -0,0 +1,261 @@
+// Package clipperstats computes commute statistics from Clipper transactions:
+// how often you ride, what rides cost, and when you travel.
+//
+// It works on []transit.Transaction and has no storage or network
+// dependencies, so programs can use it without the statement downloader in
+// package clipper. Transactions can come from clipper.ParseTransactions, the
+// store package, or anywhere else.
+//
+// A trip is a tag that starts a ride (see transit.Transaction.IsTripStart).
+// Spending is the net of fare debits and fare rebates; adding value to a card
+// is not spending.
+package clipperstats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// Trips returns the number of trips in txns.
+func Trips(txns []transit.Transaction) int {
+	n := 0
+	for _, t := range txns {
+		if t.IsTripStart() {
+			n++
+		}
+	}
+	return n
+}
+
+// SpendCents returns the total fare spending in txns.
+func SpendCents(txns []transit.Transaction) transit.Money {
+	var total transit.Money
+	for _, t := range txns {
+		total += t.FareCents()
+	}
+	return total
+}
+
+// span returns the time between the first and last transactions in txns.
+func span(txns []transit.Transaction) (first, last time.Time) {
+	for i, t := range txns {
+		if i == 0 || t.Timestamp.Before(first) {
+			first = t.Timestamp
+		}
+		if i == 0 || t.Timestamp.After(last) {
+			last = t.Timestamp
+		}
+	}
+	return first, last
+}
+
+// Weeks returns the number of weeks covered by txns, counting from the start
+// of the day of the first transaction to the end of the day of the last. It's
+// never less than one, so rates over short histories aren't inflated.
+func Weeks(txns []transit.Transaction) float64 {
+	if len(txns) == 0 {
+		return 0
+	}
+	first, last := span(txns)
+	start := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, first.Location())
+	end := time.Date(last.Year(), last.Month(), last.Day()+1, 0, 0, 0, 0, last.Location())
+	weeks := end.Sub(start).Hours() / (24 * 7)
+	if weeks < 1 {
+		return 1
+	}
+	return weeks
+}
+
+// TripsPerWeek returns the average number of trips per week.
+func TripsPerWeek(txns []transit.Transaction) float64 {
+	weeks := Weeks(txns)
+	if weeks == 0 {
+		return 0
+	}
+	return float64(Trips(txns)) / weeks
+}
+
+// AverageCostPerTripCents returns the average fare spending per trip, rounded
+// to the nearest cent, or 0 if there are no trips.
+func AverageCostPerTripCents(txns []transit.Transaction) transit.Money {
+	trips := Trips(txns)
+	if trips == 0 {
+		return 0
+	}
+	return SpendCents(txns).Div(trips)
+}
+
+// A Month is the activity in one calendar month.
+type Month struct {
+	// Start is midnight on the first day of the month.
+	Start      time.Time
+	Trips      int
+	SpendCents transit.Money
+}
+
+// monthStart returns midnight on the first of t's month.
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// Monthly returns activity per calendar month, oldest first. Months without
+// any transactions between the first and last are included with zero values.
+func Monthly(txns []transit.Transaction) []Month {
+	if len(txns) == 0 {
+		return nil
+	}
+	byMonth := make(map[time.Time]*Month)
+	for _, t := range txns {
+		start := monthStart(t.Timestamp)
+		m, ok := byMonth[start]
+		if !ok {
+			m = &Month{Start: start}
+			byMonth[start] = m
+		}
+		if t.IsTripStart() {
+			m.Trips++
+		}
+		m.SpendCents += t.FareCents()
+	}
+	first, last := span(txns)
+	var months []Month
+	for start := monthStart(first); !start.After(last); start = start.AddDate(0, 1, 0) {
+		if m, ok := byMonth[start]; ok {
+			months = append(months, *m)
+		} else {
+			months = append(months, Month{Start: start})
+		}
+	}
+	return months
+}
+
+// An HourCount is the number of trips started in an hour of the day.
+type HourCount struct {
+	// Hour is 0 (midnight to 1am) through 23.
+	Hour  int
+	Trips int
+}
+
+// BusiestHours returns the n hours of the day with the most trips, busiest
+// first. Hours without trips are not included.
+func BusiestHours(txns []transit.Transaction, n int) []HourCount {
+	var counts [24]int
+	for _, t := range txns {
+		if t.IsTripStart() {
+			counts[t.Timestamp.Hour()]++
+		}
+	}
+	hours := make([]HourCount, 0, 24)
+	for h, c := range counts {
+		if c > 0 {
+			hours = append(hours, HourCount{Hour: h, Trips: c})
+		}
+	}
+	sort.SliceStable(hours, func(i, j int) bool {
+		return hours[i].Trips > hours[j].Trips
+	})
+	if n >= 0 && len(hours) > n {
+		hours = hours[:n]
+	}
+	return hours
+}
+
+// A Summary collects the commute statistics for a set of transactions.
+type Summary struct {
+	First, Last             time.Time
+	Trips                   int
+	TripsPerWeek            float64
+	SpendCents              transit.Money
+	AverageCostPerTripCents transit.Money
+	// Journeys counts trips with transfers joined, using
+	// DefaultTransferWindow.
+	Journeys                int
+	IntraAgencyTransfers    int
+	InterAgencyTransfers    int
+	TransferCreditCents     transit.Money
+	AverageJourneyFareCents transit.Money
+	Months                  []Month
+	Agencies                []AgencyMonth
+	MissedTagOffs           []Quarter
+	BusiestHours            []HourCount
+}
+
+// Summarize computes a Summary for txns. The three busiest hours are included.
+func Summarize(txns []transit.Transaction) Summary {
+	first, last := span(txns)
+	journeys := Journeys(txns, DefaultTransferWindow)
+	s := Summary{
+		First:                   first,
+		Last:                    last,
+		Trips:                   Trips(txns),
+		TripsPerWeek:            TripsPerWeek(txns),
+		SpendCents:              SpendCents(txns),
+		AverageCostPerTripCents: AverageCostPerTripCents(txns),
+		Journeys:                len(journeys),
+		AverageJourneyFareCents: AverageJourneyFareCents(journeys),
+		Months:                  Monthly(txns),
+		Agencies:                ByAgency(txns),
+		MissedTagOffs:           MissedTagOffsByQuarter(MissedTagOffs(txns)),
+		BusiestHours:            BusiestHours(txns, 3),
+	}
+	for _, j := range journeys {
+		intra, inter := j.Transfers()
+		s.IntraAgencyTransfers += intra
+		s.InterAgencyTransfers += inter
+		s.TransferCreditCents += j.TransferCreditCents
+	}
+	return s
+}
+
+// An AgencyMonth is the activity with one agency in one calendar month.
+type AgencyMonth struct {
+	// Start is midnight on the first day of the month.
+	Start      time.Time
+	Agency     transit.Agency
+	Trips      int
+	SpendCents transit.Money
+}
+
+// ByAgency returns activity per agency per calendar month, ordered by month
+// and then by spend, highest first. Fares that can't be attributed to an
+// agency are reported under transit.AgencyUnknown.
+func ByAgency(txns []transit.Transaction) []AgencyMonth {
+	type k struct {
+		start  time.Time
+		agency transit.Agency
+	}
+	byKey := make(map[k]*AgencyMonth)
+	var out []*AgencyMonth
+	for _, t := range txns {
+		if !t.IsFare() {
+			continue
+		}
+		key := k{monthStart(t.Timestamp), t.Agency()}
+		am, ok := byKey[key]
+		if !ok {
+			am = &AgencyMonth{Start: key.start, Agency: key.agency}
+			byKey[key] = am
+			out = append(out, am)
+		}
+		if t.IsTripStart() {
+			am.Trips++
+		}
+		am.SpendCents += t.FareCents()
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Start.Equal(out[j].Start) {
+			return out[i].Start.Before(out[j].Start)
+		}
+		if out[i].SpendCents != out[j].SpendCents {
+			return out[i].SpendCents > out[j].SpendCents
+		}
+		return out[i].Agency < out[j].Agency
+	})
+	months := make([]AgencyMonth, len(out))
+	for i := range out {
+		months[i] = *out[i]
+	}
+	return months
+}
@@ -0,0 +1,89 @@
+package clipperstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func txn(day, hour int, typ string, debit, credit transit.Money) transit.Transaction {
+	return transit.Transaction{
+		Timestamp:   time.Date(2018, time.January, day, hour, 0, 0, 0, time.UTC),
+		Type:        typ,
+		DebitCents:  debit,
+		CreditCents: credit,
+	}
+}
+
+var sample = []transit.Transaction{
+	txn(1, 8, "Single-tag fare payment", 205, 0),
+	txn(1, 17, "Single-tag fare payment", 205, 0),
+	txn(2, 8, "Dual-tag entry transaction, maximum fare deducted (purse debit)", 1220, 0),
+	txn(2, 9, "Dual-tag exit transaction, fare adjustment (purse rebate)", 0, 900),
+	txn(3, 12, "Autoload", 0, 5000),
+	txn(14, 8, "Single-tag fare payment", 205, 0),
+}
+
+func TestSummarize(t *testing.T) {
+	s := Summarize(sample)
+	if s.Trips != 4 {
+		t.Errorf("Trips: got %d, want 4", s.Trips)
+	}
+	if s.SpendCents != 205*3+320 {
+		t.Errorf("SpendCents: got %d, want %d", s.SpendCents, 205*3+320)
+	}
+	if s.AverageCostPerTripCents != 234 {
+		t.Errorf("AverageCostPerTripCents: got %d, want 234", s.AverageCostPerTripCents)
+	}
+	if s.TripsPerWeek != 2 {
+		t.Errorf("TripsPerWeek: got %v, want 2", s.TripsPerWeek)
+	}
+	if len(s.BusiestHours) != 2 || s.BusiestHours[0] != (HourCount{Hour: 8, Trips: 3}) {
+		t.Errorf("BusiestHours: got %v", s.BusiestHours)
+	}
+	if len(s.Months) != 1 || s.Months[0].Trips != 4 {
+		t.Errorf("Months: got %v", s.Months)
+	}
+}
+
+func TestMonthlyFillsGaps(t *testing.T) {
+	txns := []transit.Transaction{
+		txn(1, 8, "Single-tag fare payment", 205, 0),
+		{Timestamp: time.Date(2018, time.March, 5, 8, 0, 0, 0, time.UTC), Type: "Single-tag fare payment", DebitCents: 250},
+	}
+	months := Monthly(txns)
+	if len(months) != 3 {
+		t.Fatalf("expected 3 months, got %d", len(months))
+	}
+	if months[1].Start.Month() != time.February || months[1].Trips != 0 {
+		t.Errorf("bad gap month: %+v", months[1])
+	}
+	if months[2].SpendCents != 250 {
+		t.Errorf("bad March spend: %+v", months[2])
+	}
+}
+
+func TestByAgency(t *testing.T) {
+	txns := []transit.Transaction{
+		{Timestamp: time.Date(2018, time.January, 2, 8, 0, 0, 0, time.UTC), Type: "Single-tag fare payment", Location: "Powell (Muni)", DebitCents: 250},
+		{Timestamp: time.Date(2018, time.January, 2, 9, 0, 0, 0, time.UTC), Type: "Dual-tag entry transaction, no fare deduction", Location: "Powell St (BART)"},
+		{Timestamp: time.Date(2018, time.January, 2, 9, 20, 0, 0, time.UTC), Type: "Dual-tag exit transaction, fare payment", Location: "Millbrae (BART)", DebitCents: 630},
+		{Timestamp: time.Date(2018, time.January, 3, 8, 0, 0, 0, time.UTC), Type: "Autoload", CreditCents: 5000},
+		{Timestamp: time.Date(2018, time.February, 1, 8, 0, 0, 0, time.UTC), Type: "Single-tag fare payment", Location: "Powell (Muni)", DebitCents: 250},
+	}
+	got := ByAgency(txns)
+	want := []AgencyMonth{
+		{Start: time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC), Agency: transit.AgencyBART, Trips: 1, SpendCents: 630},
+		{Start: time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC), Agency: transit.AgencySFMTA, Trips: 1, SpendCents: 250},
+		{Start: time.Date(2018, time.February, 1, 0, 0, 0, 0, time.UTC), Agency: transit.AgencySFMTA, Trips: 1, SpendCents: 250},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
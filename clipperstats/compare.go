@@ -0,0 +1,118 @@
+package clipperstats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// A Period is the time from From up to, but not including, To.
+type Period struct {
+	From, To time.Time
+}
+
+// Contains reports whether t is within p.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.From) && t.Before(p.To)
+}
+
+// Months returns the length of p in average-length months.
+func (p Period) Months() float64 {
+	return p.To.Sub(p.From).Hours() / 24 / (365.25 / 12)
+}
+
+// A FareChange compares the fares paid with one agency in two periods.
+type FareChange struct {
+	Agency                                transit.Agency
+	BeforeTrips, AfterTrips               int
+	BeforeSpendCents, AfterSpendCents     transit.Money
+	BeforeAverageCents, AfterAverageCents transit.Money
+	// MonthlyImpactCents is what the change in the average fare costs per
+	// month, at the number of rides a month taken in the later period. It
+	// separates fare changes from changes in how much you ride.
+	MonthlyImpactCents transit.Money
+}
+
+// AverageChangeCents returns the change in the average fare per ride, or 0
+// if there were no rides in one of the periods.
+func (c FareChange) AverageChangeCents() transit.Money {
+	if c.BeforeTrips == 0 || c.AfterTrips == 0 {
+		return 0
+	}
+	return c.AfterAverageCents - c.BeforeAverageCents
+}
+
+func (c *FareChange) finish(before, after Period) {
+	c.BeforeAverageCents = averageCents(c.BeforeSpendCents, c.BeforeTrips)
+	c.AfterAverageCents = averageCents(c.AfterSpendCents, c.AfterTrips)
+	if c.BeforeTrips == 0 || c.AfterTrips == 0 || after.Months() <= 0 {
+		return
+	}
+	perMonth := float64(c.AfterTrips) / after.Months()
+	beforeAvg := float64(c.BeforeSpendCents) / float64(c.BeforeTrips)
+	afterAvg := float64(c.AfterSpendCents) / float64(c.AfterTrips)
+	c.MonthlyImpactCents = transit.Money(math.Round((afterAvg - beforeAvg) * perMonth))
+}
+
+func averageCents(total transit.Money, n int) transit.Money {
+	return total.Div(n)
+}
+
+// A FareComparison compares fares between two periods, per agency and in
+// total.
+type FareComparison struct {
+	Before, After Period
+	// Agencies are ordered by monthly impact, largest increase first.
+	Agencies []FareChange
+	// Total sums all agencies. Its MonthlyImpactCents is the sum of theirs,
+	// so a shift between cheap and expensive agencies doesn't count as a
+	// fare change.
+	Total FareChange
+}
+
+// CompareFares compares the rides in txns during before with those during
+// after. Each ride's fare is what the leg cost after adjustments and
+// credits, so the comparison reflects what was actually paid.
+func CompareFares(txns []transit.Transaction, before, after Period) FareComparison {
+	cmp := FareComparison{Before: before, After: after}
+	byAgency := make(map[transit.Agency]*FareChange)
+	for _, l := range Legs(txns) {
+		inBefore, inAfter := before.Contains(l.Start()), after.Contains(l.Start())
+		if !inBefore && !inAfter {
+			continue
+		}
+		c, ok := byAgency[l.Agency]
+		if !ok {
+			c = &FareChange{Agency: l.Agency}
+			byAgency[l.Agency] = c
+		}
+		for _, fc := range []*FareChange{c, &cmp.Total} {
+			if inBefore {
+				fc.BeforeTrips++
+				fc.BeforeSpendCents += l.FareCents()
+			}
+			if inAfter {
+				fc.AfterTrips++
+				fc.AfterSpendCents += l.FareCents()
+			}
+		}
+	}
+	for _, c := range byAgency {
+		c.finish(before, after)
+		cmp.Agencies = append(cmp.Agencies, *c)
+	}
+	cmp.Total.finish(before, after)
+	cmp.Total.MonthlyImpactCents = 0
+	for _, c := range cmp.Agencies {
+		cmp.Total.MonthlyImpactCents += c.MonthlyImpactCents
+	}
+	sort.Slice(cmp.Agencies, func(i, j int) bool {
+		if cmp.Agencies[i].MonthlyImpactCents != cmp.Agencies[j].MonthlyImpactCents {
+			return cmp.Agencies[i].MonthlyImpactCents > cmp.Agencies[j].MonthlyImpactCents
+		}
+		return cmp.Agencies[i].Agency < cmp.Agencies[j].Agency
+	})
+	return cmp
+}
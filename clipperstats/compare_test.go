@@ -0,0 +1,47 @@
+package clipperstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestCompareFares(t *testing.T) {
+	ride := func(month time.Month, day int, location string, cents transit.Money) transit.Transaction {
+		return transit.Transaction{
+			Timestamp:  time.Date(2018, month, day, 8, 0, 0, 0, time.UTC),
+			Type:       "Single-tag fare payment",
+			Location:   location,
+			DebitCents: cents,
+		}
+	}
+	var txns []transit.Transaction
+	for day := 1; day <= 20; day++ {
+		txns = append(txns, ride(time.January, day, "Muni bus", 250))
+	}
+	for day := 1; day <= 10; day++ {
+		txns = append(txns, ride(time.July, day, "Muni bus", 275))
+		txns = append(txns, ride(time.July, day+10, "SAM bus", 225))
+	}
+	before := Period{From: time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2018, time.February, 1, 0, 0, 0, 0, time.UTC)}
+	after := Period{From: time.Date(2018, time.July, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2018, time.August, 1, 0, 0, 0, 0, time.UTC)}
+	cmp := CompareFares(txns, before, after)
+	if len(cmp.Agencies) != 2 {
+		t.Fatalf("expected 2 agencies, got %+v", cmp.Agencies)
+	}
+	muni := cmp.Agencies[0]
+	if muni.Agency != transit.AgencySFMTA || muni.BeforeTrips != 20 || muni.AfterTrips != 10 || muni.AverageChangeCents() != 25 {
+		t.Errorf("muni: got %+v", muni)
+	}
+	// 10 rides in a 31-day month is about 9.8 rides per average month.
+	if muni.MonthlyImpactCents != 245 {
+		t.Errorf("muni impact: got %d, want 245", muni.MonthlyImpactCents)
+	}
+	if cmp.Agencies[1].MonthlyImpactCents != 0 {
+		t.Errorf("samtrans has no earlier rides, so no impact: got %+v", cmp.Agencies[1])
+	}
+	if cmp.Total.MonthlyImpactCents != 245 || cmp.Total.BeforeTrips != 20 || cmp.Total.AfterTrips != 20 {
+		t.Errorf("total: got %+v", cmp.Total)
+	}
+}
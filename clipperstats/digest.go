@@ -0,0 +1,167 @@
+package clipperstats
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/kevinburke/clipper/clippercsv"
+	"github.com/kevinburke/clipper/transit"
+)
+
+// A CardBalance is a card's balance after its latest transaction.
+type CardBalance struct {
+	CardSerial   int64
+	BalanceCents transit.Money
+	AsOf         time.Time
+}
+
+// A Digest sums up a calendar month: what happened on each card, where the
+// balances ended up and anything unusual.
+type Digest struct {
+	// Start is midnight on the first day of the month.
+	Start        time.Time
+	Transactions []transit.Transaction
+	Trips        int
+	SpendCents   transit.Money
+	// Balances are as of the end of the month, for every card used by then,
+	// ordered by serial number.
+	Balances  []CardBalance
+	Anomalies []Anomaly
+}
+
+// MonthlyDigest builds a Digest of the month starting at start. Anomalies are
+// found by comparing the month with the transactions before it.
+func MonthlyDigest(txns []transit.Transaction, start time.Time) Digest {
+	start = monthStart(start)
+	end := start.AddDate(0, 1, 0)
+	d := Digest{Start: start}
+	var upToEnd []transit.Transaction
+	latest := make(map[int64]transit.Transaction)
+	for _, t := range txns {
+		if !t.Timestamp.Before(end) {
+			continue
+		}
+		upToEnd = append(upToEnd, t)
+		if l, ok := latest[t.CardSerial]; !ok || !t.Timestamp.Before(l.Timestamp) {
+			latest[t.CardSerial] = t
+		}
+		if !t.Timestamp.Before(start) {
+			d.Transactions = append(d.Transactions, t)
+		}
+	}
+	sort.SliceStable(d.Transactions, func(i, j int) bool {
+		return d.Transactions[i].Timestamp.Before(d.Transactions[j].Timestamp)
+	})
+	d.Trips = Trips(d.Transactions)
+	d.SpendCents = SpendCents(d.Transactions)
+	for card, t := range latest {
+		d.Balances = append(d.Balances, CardBalance{CardSerial: card, BalanceCents: t.BalanceCents, AsOf: t.Timestamp})
+	}
+	sort.Slice(d.Balances, func(i, j int) bool { return d.Balances[i].CardSerial < d.Balances[j].CardSerial })
+	d.Anomalies = Anomalies(upToEnd, start, AnomalyOptions{})
+	return d
+}
+
+// DigestCSVHeader names the columns written by Digest.WriteCSV.
+var DigestCSVHeader = []string{"time", "card", "type", "location", "route", "product", "debit", "credit", "balance"}
+
+// WriteCSV writes the month's transactions to w as CSV, with amounts in
+// dollars.
+func (d Digest) WriteCSV(w io.Writer, opts clippercsv.Options) error {
+	cw := clippercsv.NewWriter(w, opts)
+	cw.Write(DigestCSVHeader)
+	for _, t := range d.Transactions {
+		cw.Write([]string{
+			t.Timestamp.Format(time.RFC3339),
+			strconv.FormatInt(t.CardSerial, 10),
+			t.Type,
+			t.Location,
+			t.Route,
+			t.Product,
+			t.DebitCents.Decimal(),
+			t.CreditCents.Decimal(),
+			t.BalanceCents.Decimal(),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+const digestText = `{{.Trips}} rides, {{dollars .SpendCents}} spent, {{len .Transactions}} transactions.
+
+Balances:
+{{- range .Balances}}
+  card {{.CardSerial}}  {{dollars .BalanceCents}}  (as of {{.AsOf.Format "Jan 2"}})
+{{- end}}
+{{- if .Anomalies}}
+
+Unusual activity:
+{{- range .Anomalies}}
+  {{.Time.Format "Jan 2 3:04 PM"}}  card {{.CardSerial}}: {{.Description}}
+{{- end}}
+{{- end}}
+
+Transactions:
+{{- range .Transactions}}
+  {{.Timestamp.Format "Jan 2 3:04 PM"}}  {{printf "%-40s" .Type}}  {{printf "%-24s" .Location}}  {{dollars (amount .)}}
+{{- end}}
+`
+
+const digestHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Clipper digest for {{.Start.Format "January 2006"}}</title>
+</head>
+<body>
+<h1>Clipper digest for {{.Start.Format "January 2006"}}</h1>
+<p>{{.Trips}} rides, {{dollars .SpendCents}} spent, {{len .Transactions}} transactions.</p>
+<h2>Balances</h2>
+<table>
+<tr><th>Card</th><th>Balance</th><th>As of</th></tr>
+{{- range .Balances}}
+<tr><td>{{.CardSerial}}</td><td>{{dollars .BalanceCents}}</td><td>{{.AsOf.Format "Jan 2"}}</td></tr>
+{{- end}}
+</table>
+{{- if .Anomalies}}
+<h2>Unusual activity</h2>
+<ul>
+{{- range .Anomalies}}
+<li>{{.Time.Format "Jan 2 3:04 PM"}}, card {{.CardSerial}}: {{.Description}}</li>
+{{- end}}
+</ul>
+{{- end}}
+<h2>Transactions</h2>
+<table>
+<tr><th>Time</th><th>Card</th><th>Type</th><th>Location</th><th>Amount</th><th>Balance</th></tr>
+{{- range .Transactions}}
+<tr><td>{{.Timestamp.Format "Jan 2 3:04 PM"}}</td><td>{{.CardSerial}}</td><td>{{.Type}}</td><td>{{.Location}}</td><td>{{dollars (amount .)}}</td><td>{{dollars .BalanceCents}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`
+
+var digestFuncs = map[string]interface{}{
+	"dollars": reviewFuncs["dollars"],
+	"amount":  func(t transit.Transaction) transit.Money { return t.CreditCents - t.DebitCents },
+}
+
+var (
+	digestTextTemplate = template.Must(template.New("digest").Funcs(digestFuncs).Parse(digestText))
+	digestHTMLTemplate = htmltemplate.Must(htmltemplate.New("digest").Funcs(digestFuncs).Parse(digestHTML))
+)
+
+// WriteText writes the digest to w as plain text, suitable for an email body.
+func (d Digest) WriteText(w io.Writer) error {
+	return digestTextTemplate.Execute(w, d)
+}
+
+// WriteHTML writes the digest to w as an HTML page.
+func (d Digest) WriteHTML(w io.Writer) error {
+	return digestHTMLTemplate.Execute(w, d)
+}
@@ -0,0 +1,63 @@
+package clipperstats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/clippercsv"
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestMonthlyDigest(t *testing.T) {
+	txn := func(month time.Month, day int, card int64, balance transit.Money) transit.Transaction {
+		return transit.Transaction{
+			Timestamp:    time.Date(2025, month, day, 8, 0, 0, 0, time.UTC),
+			Type:         "Single-tag fare payment",
+			Location:     "Muni bus",
+			DebitCents:   250,
+			BalanceCents: balance,
+			CardSerial:   card,
+		}
+	}
+	txns := []transit.Transaction{
+		txn(time.January, 5, 1, 2000),
+		txn(time.February, 3, 1, 1750),
+		txn(time.February, 10, 1, 1500),
+		txn(time.March, 1, 1, 1250),
+		txn(time.January, 7, 2, 900),
+	}
+	d := MonthlyDigest(txns, time.Date(2025, time.February, 14, 0, 0, 0, 0, time.UTC))
+	if !d.Start.Equal(time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Start: got %v", d.Start)
+	}
+	if len(d.Transactions) != 2 || d.Trips != 2 || d.SpendCents != 500 {
+		t.Errorf("got %d transactions, %d trips, %d cents", len(d.Transactions), d.Trips, d.SpendCents)
+	}
+	if len(d.Balances) != 2 || d.Balances[0].BalanceCents != 1500 || d.Balances[1].BalanceCents != 900 {
+		t.Errorf("Balances: got %+v", d.Balances)
+	}
+
+	var buf bytes.Buffer
+	if err := d.WriteCSV(&buf, clippercsv.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 || !strings.HasSuffix(lines[1], ",2.50,0.00,17.50") {
+		t.Errorf("CSV: got %q", buf.String())
+	}
+	buf.Reset()
+	if err := d.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "card 2  $9.00") {
+		t.Errorf("text: got %q", buf.String())
+	}
+	buf.Reset()
+	if err := d.WriteHTML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<td>-$2.50</td>") {
+		t.Errorf("HTML: got %q", buf.String())
+	}
+}
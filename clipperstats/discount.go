@@ -0,0 +1,134 @@
+package clipperstats
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kevinburke/clipper/transit"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// A FareCategory is the kind of fare a card pays.
+type FareCategory string
+
+const (
+	FareAdult  FareCategory = "adult"
+	FareYouth  FareCategory = "youth"
+	FareSenior FareCategory = "senior"
+	// FareRTC is the Regional Transit Connection discount for riders with
+	// disabilities.
+	FareRTC FareCategory = "rtc"
+	// FareStart is Clipper START, the means-based discount.
+	FareStart FareCategory = "start"
+)
+
+// ParseFareCategory returns the category named s, ignoring case.
+func ParseFareCategory(s string) (FareCategory, error) {
+	c := FareCategory(strings.ToLower(strings.TrimSpace(s)))
+	switch c {
+	case FareAdult, FareYouth, FareSenior, FareRTC, FareStart:
+		return c, nil
+	}
+	return "", fmt.Errorf("clipperstats: unknown fare category %q", s)
+}
+
+// A DiscountFare is an agency's flat single-ride fare for adults and for each
+// discount category.
+type DiscountFare struct {
+	Agency     transit.Agency `yaml:"agency"`
+	AdultCents transit.Money  `yaml:"adult_cents"`
+	// Fares maps discount categories to what they should be charged.
+	Fares map[FareCategory]transit.Money `yaml:"fares"`
+}
+
+// DefaultDiscountFares are Clipper fares on flat-fare bus and rail operators as
+// of 2025. Distance-based operators like BART and Caltrain aren't included,
+// since their fares depend on where you ride.
+var DefaultDiscountFares = []DiscountFare{
+	{Agency: transit.AgencySFMTA, AdultCents: 285, Fares: map[FareCategory]transit.Money{FareYouth: 0, FareSenior: 140, FareRTC: 140, FareStart: 140}},
+	{Agency: transit.AgencyACTransit, AdultCents: 250, Fares: map[FareCategory]transit.Money{FareYouth: 125, FareSenior: 125, FareRTC: 125, FareStart: 125}},
+	{Agency: transit.AgencySamTrans, AdultCents: 250, Fares: map[FareCategory]transit.Money{FareYouth: 125, FareSenior: 125, FareRTC: 125, FareStart: 125}},
+	{Agency: transit.AgencyVTA, AdultCents: 250, Fares: map[FareCategory]transit.Money{FareYouth: 100, FareSenior: 100, FareRTC: 100, FareStart: 125}},
+}
+
+// LoadDiscountFares reads a table of discount fares in YAML, for example:
+//
+//   - agency: SFMTA
+//     adult_cents: 285
+//     fares: {youth: 0, senior: 140, rtc: 140, start: 140}
+func LoadDiscountFares(r io.Reader) ([]DiscountFare, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var fares []DiscountFare
+	if err := yaml.Unmarshal(data, &fares); err != nil {
+		return nil, fmt.Errorf("clipperstats: reading discount fares: %v", err)
+	}
+	for i, f := range fares {
+		if f.Agency == transit.AgencyUnknown {
+			return nil, fmt.Errorf("clipperstats: discount fare %d has no agency", i+1)
+		}
+		for c := range f.Fares {
+			if _, err := ParseFareCategory(string(c)); err != nil {
+				return nil, fmt.Errorf("clipperstats: discount fare for %s: %v", f.Agency, err)
+			}
+		}
+	}
+	return fares, nil
+}
+
+// A DiscountIssue is a ride on a discount card that cost more than the
+// discounted fare.
+type DiscountIssue struct {
+	Leg           Leg
+	Category      FareCategory
+	ExpectedCents transit.Money
+	ChargedCents  transit.Money
+	// FullFare is true if the ride was charged the adult fare or more.
+	FullFare bool
+}
+
+// OverchargeCents returns how much more than the discounted fare was charged.
+func (d DiscountIssue) OverchargeCents() transit.Money { return d.ChargedCents - d.ExpectedCents }
+
+// AuditDiscounts checks single-tag rides on the cards in categories against
+// the discounted fares in table and returns the rides that cost more than
+// they should have, in order. Rides on adult cards, on agencies without a
+// fare for the card's category, and dual-tag rides, whose fares depend on
+// distance, aren't checked. Rides that cost less, like free transfers, are
+// fine.
+func AuditDiscounts(txns []transit.Transaction, categories map[int64]FareCategory, table []DiscountFare) []DiscountIssue {
+	byAgency := make(map[transit.Agency]DiscountFare, len(table))
+	for _, f := range table {
+		byAgency[f.Agency] = f
+	}
+	var out []DiscountIssue
+	for _, l := range Legs(txns) {
+		c, ok := categories[l.Txns[0].CardSerial]
+		if !ok || c == FareAdult || l.isDualTag() {
+			continue
+		}
+		f, ok := byAgency[l.Agency]
+		if !ok {
+			continue
+		}
+		expected, ok := f.Fares[c]
+		if !ok {
+			continue
+		}
+		charged := l.FareCents()
+		if charged <= expected {
+			continue
+		}
+		out = append(out, DiscountIssue{
+			Leg:           l,
+			Category:      c,
+			ExpectedCents: expected,
+			ChargedCents:  charged,
+			FullFare:      f.AdultCents > 0 && charged >= f.AdultCents,
+		})
+	}
+	return out
+}
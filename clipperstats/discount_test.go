@@ -0,0 +1,53 @@
+package clipperstats
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestAuditDiscounts(t *testing.T) {
+	ride := func(day int, card int64, location string, cents transit.Money) transit.Transaction {
+		return transit.Transaction{
+			Timestamp:  time.Date(2025, time.March, day, 8, 0, 0, 0, time.UTC),
+			Type:       "Single-tag fare payment",
+			Location:   location,
+			DebitCents: cents,
+			CardSerial: card,
+		}
+	}
+	txns := []transit.Transaction{
+		ride(1, 1, "Muni bus", 0),
+		ride(2, 1, "Muni bus", 285),
+		ride(3, 2, "AC Transit bus", 125),
+		ride(4, 2, "AC Transit bus", 200),
+		ride(5, 3, "Muni bus", 285),
+		ride(6, 2, "BART bus", 500),
+	}
+	categories := map[int64]FareCategory{1: FareYouth, 2: FareSenior, 3: FareAdult}
+	issues := AuditDiscounts(txns, categories, DefaultDiscountFares)
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", issues)
+	}
+	if issues[0].Leg.Start().Day() != 2 || !issues[0].FullFare || issues[0].OverchargeCents() != 285 {
+		t.Errorf("first issue: got %+v", issues[0])
+	}
+	if issues[1].Leg.Start().Day() != 4 || issues[1].FullFare || issues[1].OverchargeCents() != 75 {
+		t.Errorf("second issue: got %+v", issues[1])
+	}
+}
+
+func TestLoadDiscountFares(t *testing.T) {
+	fares, err := LoadDiscountFares(strings.NewReader("- agency: SFMTA\n  adult_cents: 300\n  fares: {youth: 0, senior: 150}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fares) != 1 || fares[0].Fares[FareSenior] != 150 {
+		t.Errorf("got %+v", fares)
+	}
+	if _, err := LoadDiscountFares(strings.NewReader("- agency: SFMTA\n  fares: {child: 0}\n")); err == nil {
+		t.Errorf("expected error for unknown category")
+	}
+}
@@ -0,0 +1,74 @@
+package clipperstats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// DefaultDuplicateWindow is how close together two identical charges must be
+// to be reported as a likely double-charge.
+const DefaultDuplicateWindow = 5 * time.Minute
+
+// A DuplicateCharge is a pair of identical charges on the same card, at the
+// same location, close enough together that the second is likely a reader
+// error rather than a second ride.
+type DuplicateCharge struct {
+	First, Second transit.Transaction
+}
+
+// Gap returns the time between the two charges.
+func (d DuplicateCharge) Gap() time.Duration {
+	return d.Second.Timestamp.Sub(d.First.Timestamp)
+}
+
+func isDuplicateOf(a, b transit.Transaction) bool {
+	return a.DebitCents > 0 &&
+		a.CardSerial == b.CardSerial &&
+		a.Type == b.Type &&
+		a.Location == b.Location &&
+		a.Route == b.Route &&
+		a.DebitCents == b.DebitCents &&
+		a.CreditCents == b.CreditCents
+}
+
+// DuplicateCharges finds likely double-charges in txns: fare debits of the
+// same amount, type and location on the same card within window of each
+// other. A window of zero uses DefaultDuplicateWindow. txns should already be
+// merged (see store.Merge), so the same row from overlapping statements isn't
+// reported.
+func DuplicateCharges(txns []transit.Transaction, window time.Duration) []DuplicateCharge {
+	if window == 0 {
+		window = DefaultDuplicateWindow
+	}
+	var out []DuplicateCharge
+	for _, ls := range cardLegs(txns) {
+		var prev transit.Transaction
+		havePrev := false
+		for _, l := range ls {
+			for _, t := range l.Txns {
+				if havePrev && isDuplicateOf(prev, t) && t.Timestamp.Sub(prev.Timestamp) <= window {
+					// Keep comparing with the original charge, so a
+					// triple charge is reported as two extra charges.
+					out = append(out, DuplicateCharge{First: prev, Second: t})
+					continue
+				}
+				prev, havePrev = t, true
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Second.Timestamp.Before(out[j].Second.Timestamp)
+	})
+	return out
+}
+
+// DuplicateChargeCents returns the total of the extra charges in dups.
+func DuplicateChargeCents(dups []DuplicateCharge) transit.Money {
+	var total transit.Money
+	for _, d := range dups {
+		total += d.Second.DebitCents
+	}
+	return total
+}
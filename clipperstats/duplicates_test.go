@@ -0,0 +1,37 @@
+package clipperstats
+
+import (
+	"testing"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestDuplicateCharges(t *testing.T) {
+	fare := func(hour, min int, balance transit.Money) transit.Transaction {
+		return transit.Transaction{Timestamp: at(hour, min), Type: "Single-tag fare payment", Location: "SAM bus", Route: "LOC", DebitCents: 205, BalanceCents: balance, CardSerial: 7, Source: "a.pdf"}
+	}
+	txns := []transit.Transaction{
+		fare(8, 0, 1000),
+		fare(8, 1, 795),
+		fare(8, 2, 590),
+		// The ride home isn't a duplicate.
+		fare(17, 0, 385),
+		// Neither is a ride on another card.
+		{Timestamp: at(17, 1), Type: "Single-tag fare payment", Location: "SAM bus", Route: "LOC", DebitCents: 205, CardSerial: 8},
+	}
+	dups := DuplicateCharges(txns, 0)
+	if len(dups) != 2 {
+		t.Fatalf("expected 2 duplicates, got %d: %+v", len(dups), dups)
+	}
+	for _, d := range dups {
+		if d.First.BalanceCents != 1000 {
+			t.Errorf("expected comparison with the first charge, got %+v", d.First)
+		}
+	}
+	if dups[0].Gap().Minutes() != 1 {
+		t.Errorf("Gap: got %v", dups[0].Gap())
+	}
+	if got := DuplicateChargeCents(dups); got != 410 {
+		t.Errorf("DuplicateChargeCents: got %d, want 410", got)
+	}
+}
@@ -0,0 +1,105 @@
+package clipperstats
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// A JourneyRecord is a Journey flattened for export.
+type JourneyRecord struct {
+	// ID is the ID of the journey's first leg; see Leg.ID.
+	ID                  string        `json:"id"`
+	CardSerial          int64         `json:"card_serial"`
+	Start               time.Time     `json:"start"`
+	End                 time.Time     `json:"end"`
+	DurationMinutes     int           `json:"duration_minutes"`
+	Origin              string        `json:"origin"`
+	Destination         string        `json:"destination"`
+	Legs                int           `json:"legs"`
+	Agencies            []string      `json:"agencies"`
+	FareCents           transit.Money `json:"fare_cents"`
+	TransferCreditCents transit.Money `json:"transfer_credit_cents"`
+	// The coordinates of the origin and destination, if they're known
+	// stations.
+	OriginLat      float64 `json:"origin_lat,omitempty"`
+	OriginLon      float64 `json:"origin_lon,omitempty"`
+	DestinationLat float64 `json:"destination_lat,omitempty"`
+	DestinationLon float64 `json:"destination_lon,omitempty"`
+	// Purpose is set by the caller, usually from a Tagger.
+	Purpose Purpose `json:"purpose,omitempty"`
+}
+
+// Record returns j flattened for export.
+func (j Journey) Record() JourneyRecord {
+	agencies := j.Agencies()
+	names := make([]string, len(agencies))
+	for i, a := range agencies {
+		names[i] = string(a)
+	}
+	r := JourneyRecord{
+		ID:                  j.Legs[0].ID(),
+		CardSerial:          j.CardSerial,
+		Start:               j.Start(),
+		End:                 j.End(),
+		DurationMinutes:     int(j.Duration().Minutes()),
+		Origin:              j.Origin(),
+		Destination:         j.Destination(),
+		Legs:                len(j.Legs),
+		Agencies:            names,
+		FareCents:           j.FareCents(),
+		TransferCreditCents: j.TransferCreditCents,
+	}
+	first, last := j.Legs[0], j.Legs[len(j.Legs)-1]
+	if st, ok := first.station(first.Txns[0].Location); ok {
+		r.OriginLat, r.OriginLon = st.Lat, st.Lon
+	}
+	if d := last.Destination(); d != "" {
+		if st, ok := last.station(d); ok {
+			r.DestinationLat, r.DestinationLon = st.Lat, st.Lon
+		}
+	}
+	return r
+}
+
+// JourneyCSVHeader names the columns of JourneyRecord.CSV.
+var JourneyCSVHeader = []string{
+	"card_serial", "start", "end", "duration_minutes", "origin", "destination",
+	"legs", "agencies", "fare_cents", "transfer_credit_cents",
+	"origin_lat", "origin_lon", "destination_lat", "destination_lon",
+	"id", "purpose",
+}
+
+// CSV returns r as a CSV row, in the order of JourneyCSVHeader. Times are in
+// RFC 3339 format and agencies are separated by "+".
+func (r JourneyRecord) CSV() []string {
+	return []string{
+		strconv.FormatInt(r.CardSerial, 10),
+		r.Start.Format(time.RFC3339),
+		r.End.Format(time.RFC3339),
+		strconv.Itoa(r.DurationMinutes),
+		r.Origin,
+		r.Destination,
+		strconv.Itoa(r.Legs),
+		strings.Join(r.Agencies, "+"),
+		strconv.FormatInt(int64(r.FareCents), 10),
+		strconv.FormatInt(int64(r.TransferCreditCents), 10),
+		formatCoord(r.OriginLat),
+		formatCoord(r.OriginLon),
+		formatCoord(r.DestinationLat),
+		formatCoord(r.DestinationLon),
+		r.ID,
+		string(r.Purpose),
+	}
+}
+
+// formatCoord formats a latitude or longitude, or returns "" for an unknown
+// coordinate.
+func formatCoord(deg float64) string {
+	if deg == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(deg, 'f', -1, 64)
+}
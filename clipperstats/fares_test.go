@@ -0,0 +1,91 @@
+package clipperstats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestCheckFares(t *testing.T) {
+	ts := func(year int, day, hour, min int) time.Time {
+		return time.Date(year, time.March, day, hour, min, 0, 0, time.UTC)
+	}
+	txns := []transit.Transaction{
+		// Right fare, and an overcharge, on Muni in 2018.
+		{Timestamp: ts(2018, 1, 8, 0), Type: "Single-tag fare payment", Location: "Muni bus", DebitCents: 250, CardSerial: 1},
+		{Timestamp: ts(2018, 2, 8, 0), Type: "Single-tag fare payment", Location: "Muni bus", DebitCents: 300, CardSerial: 1},
+		// One-zone Caltrain ride that kept the maximum fare.
+		{Timestamp: ts(2018, 3, 8, 0), Type: "Dual-tag entry transaction, maximum fare deducted (purse debit)", Location: "Millbrae (Caltrain)", DebitCents: 1220, CardSerial: 1},
+		{Timestamp: ts(2018, 3, 8, 20), Type: "Dual-tag exit transaction, fare adjustment (purse rebate)", Location: "Belmont (Caltrain)", CreditCents: 100, CardSerial: 1},
+		// No 2018 fare for AC Transit.
+		{Timestamp: ts(2018, 4, 8, 0), Type: "Single-tag fare payment", Location: "AC Transit bus", DebitCents: 225, CardSerial: 1},
+		// A youth card charged the adult fare in 2026.
+		{Timestamp: ts(2026, 20, 8, 0), Type: "Single-tag fare payment", Location: "AC Transit bus", DebitCents: 250, CardSerial: 2},
+		// An express route with its own fare.
+		{Timestamp: ts(2026, 21, 8, 0), Type: "Single-tag fare payment", Location: "AC Transit bus", Route: "NX", DebitCents: 500, CardSerial: 1},
+	}
+	table := append([]PostedFare{
+		{Agency: transit.AgencyACTransit, Route: "nx", Cents: 600, Effective: "2025-01-01"},
+	}, DefaultPostedFares...)
+	check := CheckFares(txns, map[int64]FareCategory{2: FareYouth}, table)
+	if check.Checked != 5 || check.Unchecked != 1 {
+		t.Errorf("checked %d, unchecked %d", check.Checked, check.Unchecked)
+	}
+	if len(check.Issues) != 3 {
+		t.Fatalf("expected 3 issues, got %+v", check.Issues)
+	}
+	if got := check.Issues[0]; got.Leg.Start().Day() != 2 || got.OverchargeCents() != 50 {
+		t.Errorf("Muni issue: got %+v", got)
+	}
+	if got := check.Issues[1]; got.Fare.Zones != 2 || got.OverchargeCents() != 1120-545 {
+		t.Errorf("Caltrain issue: got %+v", got)
+	}
+	if got := check.Issues[2]; got.Category != FareYouth || got.OverchargeCents() != 125 {
+		t.Errorf("youth issue: got %+v", got)
+	}
+	if got := check.TotalOverchargeCents(); got != 50+575+125 {
+		t.Errorf("TotalOverchargeCents: got %d", got)
+	}
+}
+
+func TestExpectedFareStations(t *testing.T) {
+	l := Leg{Agency: transit.AgencyBART, Txns: []transit.Transaction{
+		{Timestamp: time.Date(2018, time.January, 2, 8, 0, 0, 0, time.UTC), Type: "Dual-tag entry transaction, no fare deduction", Location: "Millbrae (BART)"},
+		{Timestamp: time.Date(2018, time.January, 2, 8, 40, 0, 0, time.UTC), Type: "Dual-tag exit transaction, fare payment", Location: "Powell St (BART)", DebitCents: 465},
+	}}
+	table := []PostedFare{
+		{Agency: transit.AgencyBART, From: "Powell", To: "Millbrae", Cents: 465, Effective: "2017-07-01"},
+		{Agency: transit.AgencyBART, From: "Powell", To: "Millbrae", Cents: 480, Effective: "2018-01-01"},
+		{Agency: transit.AgencyBART, From: "Powell", To: "Millbrae", Cents: 500, Effective: "2019-01-01"},
+	}
+	f, ok := ExpectedFare(l, FareAdult, table)
+	if !ok || f.Cents != 480 {
+		t.Errorf("ExpectedFare: got %+v, %t", f, ok)
+	}
+}
+
+func TestLoadPostedFares(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePostedFares(&buf, DefaultPostedFares); err != nil {
+		t.Fatal(err)
+	}
+	fares, err := LoadPostedFares(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fares) != len(DefaultPostedFares) {
+		t.Errorf("round trip: got %d fares, want %d", len(fares), len(DefaultPostedFares))
+	}
+	for _, bad := range []string{
+		"- {cents: 250, effective: 2025-07-01}",
+		"- {agency: SFMTA, cents: 250}",
+		"- {agency: SFMTA, category: kid, cents: 250, effective: 2025-07-01}",
+	} {
+		if _, err := LoadPostedFares(strings.NewReader(bad)); err == nil {
+			t.Errorf("LoadPostedFares(%q): expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package clipperstats
+
+import (
+	"sort"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// A CardSummary totals one card's rides.
+type CardSummary struct {
+	CardSerial int64
+	Trips      int
+	SpendCents transit.Money
+}
+
+// A PersonSummary totals the rides on one person's cards.
+type PersonSummary struct {
+	Name       string
+	Cards      []CardSummary
+	Trips      int
+	SpendCents transit.Money
+}
+
+// A HouseholdSummary totals rides across everyone's cards.
+type HouseholdSummary struct {
+	People     []PersonSummary
+	Trips      int
+	SpendCents transit.Money
+}
+
+// Unassigned is the name Household gives cards that don't belong to anyone.
+const Unassigned = "Unassigned"
+
+// Household totals the rides in txns per card and per person. owners maps
+// card serial numbers to people's names; cards not in it are reported under
+// Unassigned. People are ordered by name, with Unassigned last, and each
+// person's cards by serial number.
+func Household(txns []transit.Transaction, owners map[int64]string) HouseholdSummary {
+	cards := make(map[int64]*CardSummary)
+	for _, t := range txns {
+		c, ok := cards[t.CardSerial]
+		if !ok {
+			c = &CardSummary{CardSerial: t.CardSerial}
+			cards[t.CardSerial] = c
+		}
+		if t.IsTripStart() {
+			c.Trips++
+		}
+		c.SpendCents += t.FareCents()
+	}
+	people := make(map[string]*PersonSummary)
+	for serial, c := range cards {
+		name, ok := owners[serial]
+		if !ok || name == "" {
+			name = Unassigned
+		}
+		p, ok := people[name]
+		if !ok {
+			p = &PersonSummary{Name: name}
+			people[name] = p
+		}
+		p.Cards = append(p.Cards, *c)
+		p.Trips += c.Trips
+		p.SpendCents += c.SpendCents
+	}
+	var h HouseholdSummary
+	for _, p := range people {
+		sort.Slice(p.Cards, func(i, j int) bool { return p.Cards[i].CardSerial < p.Cards[j].CardSerial })
+		h.People = append(h.People, *p)
+		h.Trips += p.Trips
+		h.SpendCents += p.SpendCents
+	}
+	sort.Slice(h.People, func(i, j int) bool {
+		a, b := h.People[i].Name, h.People[j].Name
+		if (a == Unassigned) != (b == Unassigned) {
+			return b == Unassigned
+		}
+		return a < b
+	})
+	return h
+}
@@ -0,0 +1,31 @@
+package clipperstats
+
+import (
+	"testing"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestHousehold(t *testing.T) {
+	fare := func(card int64, cents transit.Money) transit.Transaction {
+		return transit.Transaction{Timestamp: at(8, 0), Type: "Single-tag fare payment", DebitCents: cents, CardSerial: card}
+	}
+	txns := []transit.Transaction{
+		fare(1, 250), fare(1, 250), fare(2, 205), fare(3, 630), fare(9, 100),
+		{Timestamp: at(9, 0), Type: "Autoload", CreditCents: 5000, CardSerial: 1},
+	}
+	h := Household(txns, map[int64]string{1: "bob", 2: "bob", 3: "alice"})
+	if h.Trips != 5 || h.SpendCents != 1435 {
+		t.Errorf("totals: got %d trips, %d cents", h.Trips, h.SpendCents)
+	}
+	if len(h.People) != 3 {
+		t.Fatalf("expected 3 people, got %+v", h.People)
+	}
+	if h.People[0].Name != "alice" || h.People[1].Name != "bob" || h.People[2].Name != Unassigned {
+		t.Errorf("bad order: %+v", h.People)
+	}
+	bob := h.People[1]
+	if len(bob.Cards) != 2 || bob.Cards[0].CardSerial != 1 || bob.Trips != 3 || bob.SpendCents != 705 {
+		t.Errorf("bob: got %+v", bob)
+	}
+}
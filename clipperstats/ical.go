@@ -0,0 +1,88 @@
+package clipperstats
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// SingleTagDuration is how long a calendar event for a single-tag ride lasts.
+// Single-tag rides only record when the fare was paid, not when the ride
+// ended.
+const SingleTagDuration = 15 * time.Minute
+
+const icalTime = "20060102T150405Z"
+
+// icalEscape escapes s for use as an iCalendar TEXT value.
+func icalEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// writeICalLine writes a content line, folding it at 75 octets as RFC 5545
+// requires.
+func writeICalLine(w *bufio.Writer, line string) {
+	for len(line) > 75 {
+		n := 75
+		for n > 0 && !utf8.RuneStart(line[n]) {
+			n--
+		}
+		w.WriteString(line[:n])
+		w.WriteString("\r\n ")
+		line = line[n:]
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// LegSummary describes l in a few words, like "BART: Embarcadero to 16th St
+// Mission".
+func LegSummary(l Leg) string {
+	s := string(l.Agency)
+	if l.Agency == transit.AgencyUnknown {
+		s = "Ride"
+	}
+	s += ": " + l.Origin()
+	if d := l.Destination(); d != "" {
+		s += " to " + d
+	}
+	return s
+}
+
+// WriteICalendar writes legs to w as an iCalendar feed named name, with an
+// event for each ride. Event IDs are the legs' IDs, so calendars that
+// subscribe to the feed update rides instead of duplicating them.
+func WriteICalendar(w io.Writer, name string, legs []Leg) error {
+	bw := bufio.NewWriter(w)
+	writeICalLine(bw, "BEGIN:VCALENDAR")
+	writeICalLine(bw, "VERSION:2.0")
+	writeICalLine(bw, "PRODID:-//kevinburke//clipper//EN")
+	writeICalLine(bw, "CALSCALE:GREGORIAN")
+	writeICalLine(bw, "X-WR-CALNAME:"+icalEscape(name))
+	for _, l := range legs {
+		start, end := l.Start(), l.End()
+		if !l.isDualTag() || !end.After(start) {
+			end = start.Add(SingleTagDuration)
+		}
+		writeICalLine(bw, "BEGIN:VEVENT")
+		writeICalLine(bw, "UID:"+l.ID()+"@clipper")
+		// The last tag is when the ride was last changed.
+		writeICalLine(bw, "DTSTAMP:"+l.End().UTC().Format(icalTime))
+		writeICalLine(bw, "DTSTART:"+start.UTC().Format(icalTime))
+		writeICalLine(bw, "DTEND:"+end.UTC().Format(icalTime))
+		writeICalLine(bw, "SUMMARY:"+icalEscape(LegSummary(l)))
+		writeICalLine(bw, "LOCATION:"+icalEscape(l.Origin()))
+		desc := "Fare: " + l.FareCents().String()
+		if p := l.Txns[0].Product; p != "" {
+			desc += "\nProduct: " + p
+		}
+		writeICalLine(bw, "DESCRIPTION:"+icalEscape(desc))
+		writeICalLine(bw, "TRANSP:TRANSPARENT")
+		writeICalLine(bw, "END:VEVENT")
+	}
+	writeICalLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
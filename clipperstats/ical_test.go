@@ -0,0 +1,54 @@
+package clipperstats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestWriteICalendar(t *testing.T) {
+	start := time.Date(2018, time.January, 8, 8, 30, 0, 0, time.UTC)
+	txns := []transit.Transaction{
+		{Timestamp: start, Type: "Dual-tag entry transaction, maximum fare deducted (purse debit)", Location: "Embarcadero (BART)", DebitCents: 580, CardSerial: 1},
+		{Timestamp: start.Add(20 * time.Minute), Type: "Dual-tag exit transaction, fare adjustment (purse rebate)", Location: "Rockridge (BART)", CreditCents: 130, CardSerial: 1},
+		{Timestamp: start.Add(10 * time.Hour), Type: "Single-tag fare payment", Location: "SFM bus", Route: "38, Geary", Product: "Clipper Cash", DebitCents: 250, CardSerial: 1},
+	}
+	buf := new(bytes.Buffer)
+	if err := WriteICalendar(buf, "Clipper card 1", Legs(txns)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if n := strings.Count(out, "BEGIN:VEVENT\r\n"); n != 2 {
+		t.Fatalf("got %d events, want 2:\n%s", n, out)
+	}
+	for _, want := range []string{
+		"UID:1-20180108T0830@clipper\r\n",
+		"DTSTART:20180108T083000Z\r\nDTEND:20180108T085000Z\r\n",
+		"DTSTART:20180108T183000Z\r\nDTEND:20180108T184500Z\r\n",
+		"DESCRIPTION:Fare: $4.50\r\n",
+		`DESCRIPTION:Fare: $2.50\nProduct: Clipper Cash` + "\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+}
+
+func TestICalFolding(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := WriteICalendar(buf, strings.Repeat("é", 60)+", with a comma", nil); err != nil {
+		t.Fatal(err)
+	}
+	unfolded := strings.ReplaceAll(buf.String(), "\r\n ", "")
+	if !strings.Contains(unfolded, "X-WR-CALNAME:"+strings.Repeat("é", 60)+`\, with a comma`+"\r\n") {
+		t.Errorf("got %q", buf.String())
+	}
+}
@@ -0,0 +1,262 @@
+package clipperstats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// DefaultTransferWindow is how long after one ride ends the next can start and
+// still count as a transfer. Most Bay Area operators give transfer credit for
+// about two hours.
+const DefaultTransferWindow = 2 * time.Hour
+
+// A Leg is a single ride: a single tag, or a dual-tag entry together with the
+// exit and any fare adjustments that follow it.
+type Leg struct {
+	Agency transit.Agency
+	Txns   []transit.Transaction
+}
+
+// Start returns the time of the first tag in the leg.
+func (l Leg) Start() time.Time { return l.Txns[0].Timestamp }
+
+// End returns the time of the last tag in the leg.
+func (l Leg) End() time.Time { return l.Txns[len(l.Txns)-1].Timestamp }
+
+// ID identifies the leg across downloads: the card's serial number and the
+// time of its first tag, like "1202728442-20171212T0917".
+func (l Leg) ID() string {
+	return fmt.Sprintf("%d-%s", l.Txns[0].CardSerial, l.Start().Format("20060102T1504"))
+}
+
+// isDualTag reports whether l is a ride that's tagged on entry and exit, like
+// BART or Caltrain.
+func (l Leg) isDualTag() bool {
+	return strings.Contains(strings.ToLower(l.Txns[0].Type), "dual-tag")
+}
+
+// Origin returns where the leg started: the entry station, or the vehicle or
+// stop where a single-tag ride was paid for.
+func (l Leg) Origin() string {
+	return placeName(l, l.Txns[0].Location)
+}
+
+// Destination returns the exit station of a dual-tag ride. It's empty for
+// single-tag rides, which don't record where you got off, and for rides with
+// a missed tag-off.
+func (l Leg) Destination() string {
+	if !l.isDualTag() {
+		return ""
+	}
+	for i := len(l.Txns) - 1; i >= 1; i-- {
+		lower := strings.ToLower(l.Txns[i].Type)
+		if strings.Contains(lower, "exit") {
+			return placeName(l, l.Txns[i].Location)
+		}
+	}
+	return ""
+}
+
+// station returns the station at location, a Location on one of l's
+// transactions. Station names shared between agencies are resolved using the
+// leg's agency.
+func (l Leg) station(location string) (transit.Station, bool) {
+	st, ok := transit.LookupStation(location)
+	if !ok && l.Agency != transit.AgencyUnknown {
+		st, ok = transit.LookupStation(location + " (" + string(l.Agency) + ")")
+	}
+	return st, ok
+}
+
+// placeName returns the station name for location, or location itself if it
+// isn't a known station.
+func placeName(l Leg, location string) string {
+	if st, ok := l.station(location); ok {
+		return st.Name
+	}
+	return location
+}
+
+// FareCents returns what the leg cost after adjustments and credits.
+func (l Leg) FareCents() transit.Money {
+	var total transit.Money
+	for _, t := range l.Txns {
+		total += t.FareCents()
+	}
+	return total
+}
+
+// A Journey is one or more legs on the same card, each starting within the
+// transfer window of the end of the previous one.
+type Journey struct {
+	CardSerial int64
+	Legs       []Leg
+	// TransferCreditCents is the total of credits given on legs after the
+	// first, which is what the transfers saved.
+	TransferCreditCents transit.Money
+}
+
+// Start returns the time of the first tag in the journey.
+func (j Journey) Start() time.Time { return j.Legs[0].Start() }
+
+// End returns the time of the last tag in the journey.
+func (j Journey) End() time.Time { return j.Legs[len(j.Legs)-1].End() }
+
+// Duration returns the time from the first tag to the last. For a journey that
+// ends with a single-tag ride, that's when the last ride was paid for, not
+// when it ended.
+func (j Journey) Duration() time.Duration { return j.End().Sub(j.Start()) }
+
+// Origin returns where the journey started.
+func (j Journey) Origin() string { return j.Legs[0].Origin() }
+
+// Destination returns where the journey ended, the exit station of the last
+// ride. It's empty if the last ride didn't record an exit, like a bus ride.
+func (j Journey) Destination() string { return j.Legs[len(j.Legs)-1].Destination() }
+
+// Agencies returns the agencies the journey used, in order, without repeats.
+func (j Journey) Agencies() []transit.Agency {
+	var out []transit.Agency
+	for _, l := range j.Legs {
+		if len(out) == 0 || out[len(out)-1] != l.Agency {
+			out = append(out, l.Agency)
+		}
+	}
+	return out
+}
+
+// FareCents returns the effective end-to-end fare of the journey: all fares
+// charged on every leg, less adjustments and transfer credits.
+func (j Journey) FareCents() transit.Money {
+	var total transit.Money
+	for _, l := range j.Legs {
+		total += l.FareCents()
+	}
+	return total
+}
+
+// Transfers returns the number of transfers in the journey, split into those
+// between legs on the same agency and those between different agencies.
+func (j Journey) Transfers() (intra, inter int) {
+	for i := 1; i < len(j.Legs); i++ {
+		if j.Legs[i].Agency == j.Legs[i-1].Agency {
+			intra++
+		} else {
+			inter++
+		}
+	}
+	return intra, inter
+}
+
+// isTransferCredit reports whether t credits a fare because of a transfer.
+func isTransferCredit(t transit.Transaction) bool {
+	return t.CreditCents > 0 && strings.Contains(strings.ToLower(t.Type), "transfer")
+}
+
+// legs groups the fare transactions in txns, which must be from a single card
+// and sorted by time, into rides.
+func legs(txns []transit.Transaction) []Leg {
+	var out []Leg
+	for _, t := range txns {
+		if !t.IsFare() {
+			continue
+		}
+		if t.IsTripStart() || len(out) == 0 {
+			out = append(out, Leg{Agency: t.Agency(), Txns: []transit.Transaction{t}})
+			continue
+		}
+		l := &out[len(out)-1]
+		l.Txns = append(l.Txns, t)
+		if l.Agency == transit.AgencyUnknown {
+			l.Agency = t.Agency()
+		}
+	}
+	return out
+}
+
+// Legs groups the fare transactions in txns into rides, ordered by start
+// time.
+func Legs(txns []transit.Transaction) []Leg {
+	var out []Leg
+	for _, ls := range cardLegs(txns) {
+		out = append(out, ls...)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Start().Before(out[j].Start())
+	})
+	return out
+}
+
+// cardLegs groups txns by card, in the order each card first appears, and
+// then into rides.
+func cardLegs(txns []transit.Transaction) [][]Leg {
+	byCard := make(map[int64][]transit.Transaction)
+	var cards []int64
+	for _, t := range txns {
+		if _, ok := byCard[t.CardSerial]; !ok {
+			cards = append(cards, t.CardSerial)
+		}
+		byCard[t.CardSerial] = append(byCard[t.CardSerial], t)
+	}
+	out := make([][]Leg, 0, len(cards))
+	for _, card := range cards {
+		ctxns := byCard[card]
+		sort.SliceStable(ctxns, func(i, j int) bool {
+			return ctxns[i].Timestamp.Before(ctxns[j].Timestamp)
+		})
+		out = append(out, legs(ctxns))
+	}
+	return out
+}
+
+// Journeys groups txns into journeys, joining legs on the same card that start
+// within window of the previous leg's end. A window of zero uses
+// DefaultTransferWindow. Journeys are returned in order of start time.
+func Journeys(txns []transit.Transaction, window time.Duration) []Journey {
+	if window == 0 {
+		window = DefaultTransferWindow
+	}
+	var out []Journey
+	for _, ls := range cardLegs(txns) {
+		var cur *Journey
+		for _, l := range ls {
+			if cur != nil && l.Start().Sub(cur.End()) <= window {
+				cur.Legs = append(cur.Legs, l)
+				for _, t := range l.Txns {
+					if isTransferCredit(t) {
+						cur.TransferCreditCents += t.CreditCents
+					}
+				}
+				continue
+			}
+			if cur != nil {
+				out = append(out, *cur)
+			}
+			cur = &Journey{CardSerial: l.Txns[0].CardSerial, Legs: []Leg{l}}
+		}
+		if cur != nil {
+			out = append(out, *cur)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Start().Before(out[j].Start())
+	})
+	return out
+}
+
+// AverageJourneyFareCents returns the average effective fare of journeys,
+// rounded to the nearest cent, or 0 if there are none.
+func AverageJourneyFareCents(journeys []Journey) transit.Money {
+	if len(journeys) == 0 {
+		return 0
+	}
+	var total transit.Money
+	for _, j := range journeys {
+		total += j.FareCents()
+	}
+	return total.Div(len(journeys))
+}
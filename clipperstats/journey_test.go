@@ -0,0 +1,99 @@
+package clipperstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func at(hour, min int) time.Time {
+	return time.Date(2018, time.January, 2, hour, min, 0, 0, time.UTC)
+}
+
+func TestJourneys(t *testing.T) {
+	txns := []transit.Transaction{
+		// Muni, then BART with a transfer credit, then home much later.
+		{Timestamp: at(8, 0), Type: "Single-tag fare payment", Location: "Powell (Muni)", DebitCents: 250},
+		{Timestamp: at(8, 20), Type: "Dual-tag entry transaction, no fare deduction", Location: "Powell St (BART)"},
+		{Timestamp: at(8, 45), Type: "Dual-tag exit transaction, fare payment", Location: "Millbrae (BART)", DebitCents: 630},
+		{Timestamp: at(8, 45), Type: "Inter-operator transfer credit", Location: "Millbrae (BART)", CreditCents: 50},
+		{Timestamp: at(12, 0), Type: "Autoload", CreditCents: 5000},
+		{Timestamp: at(17, 0), Type: "Dual-tag entry transaction, maximum fare deducted (purse debit)", Location: "Belmont", DebitCents: 1220},
+		{Timestamp: at(17, 30), Type: "Dual-tag exit transaction, fare adjustment (purse rebate)", Location: "4th and King (Caltrain)", CreditCents: 900},
+	}
+	journeys := Journeys(txns, 0)
+	if len(journeys) != 2 {
+		t.Fatalf("expected 2 journeys, got %d: %+v", len(journeys), journeys)
+	}
+	j := journeys[0]
+	if len(j.Legs) != 2 {
+		t.Fatalf("expected 2 legs, got %d", len(j.Legs))
+	}
+	if intra, inter := j.Transfers(); intra != 0 || inter != 1 {
+		t.Errorf("Transfers: got %d intra, %d inter", intra, inter)
+	}
+	if j.TransferCreditCents != 50 {
+		t.Errorf("TransferCreditCents: got %d, want 50", j.TransferCreditCents)
+	}
+	if got := j.FareCents(); got != 250+630-50 {
+		t.Errorf("FareCents: got %d, want %d", got, 250+630-50)
+	}
+	if got := journeys[1].FareCents(); got != 320 {
+		t.Errorf("Caltrain FareCents: got %d, want 320", got)
+	}
+	if journeys[1].Legs[0].Agency != transit.AgencyCaltrain {
+		t.Errorf("Caltrain agency: got %q", journeys[1].Legs[0].Agency)
+	}
+	if got := AverageJourneyFareCents(journeys); got != 575 {
+		t.Errorf("AverageJourneyFareCents: got %d, want 575", got)
+	}
+}
+
+func TestJourneysSplitByCard(t *testing.T) {
+	txns := []transit.Transaction{
+		{Timestamp: at(8, 0), Type: "Single-tag fare payment", Location: "Powell (Muni)", DebitCents: 250, CardSerial: 1},
+		{Timestamp: at(8, 10), Type: "Single-tag fare payment", Location: "Powell (Muni)", DebitCents: 250, CardSerial: 2},
+	}
+	if got := len(Journeys(txns, 0)); got != 2 {
+		t.Errorf("expected 2 journeys, got %d", got)
+	}
+}
+
+func TestJourneyOriginDestination(t *testing.T) {
+	txns := []transit.Transaction{
+		{Timestamp: at(7, 0), Type: "Dual-tag entry transaction, maximum fare deducted (purse debit)", Location: "Belmont", DebitCents: 1220},
+		{Timestamp: at(7, 40), Type: "Dual-tag exit transaction, fare adjustment (purse rebate)", Location: "4th and King (Caltrain)", CreditCents: 900},
+		{Timestamp: at(7, 50), Type: "Dual-tag entry transaction, no fare deduction", Location: "Montgomery (BART)"},
+		{Timestamp: at(8, 10), Type: "Dual-tag exit transaction, fare payment", Location: "MacArthur", DebitCents: 390},
+		{Timestamp: at(8, 20), Type: "Single-tag fare payment", Location: "AC Transit bus", Route: "57", DebitCents: 225},
+	}
+	journeys := Journeys(txns, 0)
+	if len(journeys) != 1 {
+		t.Fatalf("expected 1 journey, got %d", len(journeys))
+	}
+	j := journeys[0]
+	if j.Origin() != "Belmont" {
+		t.Errorf("Origin: got %q", j.Origin())
+	}
+	if j.Legs[1].Destination() != "MacArthur" || j.Legs[2].Destination() != "" {
+		t.Errorf("leg destinations: got %q, %q", j.Legs[1].Destination(), j.Legs[2].Destination())
+	}
+	if j.Destination() != "" {
+		t.Errorf("Destination: got %q", j.Destination())
+	}
+	if j.Duration() != 80*time.Minute {
+		t.Errorf("Duration: got %v", j.Duration())
+	}
+	rec := j.Record()
+	want := []string{"0", "2018-01-02T07:00:00Z", "2018-01-02T08:20:00Z", "80", "Belmont", "", "3", "Caltrain+BART+AC Transit", "935", "0", "37.5206", "-122.276", "", "", "0-20180102T0700", ""}
+	got := rec.CSV()
+	if len(got) != len(JourneyCSVHeader) {
+		t.Fatalf("CSV has %d columns, header has %d", len(got), len(JourneyCSVHeader))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("column %s: got %q, want %q", JourneyCSVHeader[i], got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package clipperstats
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// An HourRange is a range of hours of the day, from From up to but not
+// including To. It wraps past midnight if From is greater than To, so 22-5
+// is 10pm to 4:59am.
+type HourRange struct {
+	From, To int
+}
+
+// DefaultLateNight is midnight to 4:59am.
+var DefaultLateNight = HourRange{0, 5}
+
+// ParseHourRange parses a range like "0-5" or "22-5".
+func ParseHourRange(s string) (HourRange, error) {
+	a, b, ok := strings.Cut(s, "-")
+	if ok {
+		from, err1 := strconv.Atoi(strings.TrimSpace(a))
+		to, err2 := strconv.Atoi(strings.TrimSpace(b))
+		if err1 == nil && err2 == nil && from >= 0 && from < 24 && to >= 0 && to <= 24 && from != to {
+			return HourRange{from, to}, nil
+		}
+	}
+	return HourRange{}, fmt.Errorf("clipperstats: bad hour range %q, want a range like 0-5 or 22-5", s)
+}
+
+// Contains reports whether hour, 0 through 23, is in r.
+func (r HourRange) Contains(hour int) bool {
+	if r.From < r.To {
+		return hour >= r.From && hour < r.To
+	}
+	return hour >= r.From || hour < r.To
+}
+
+func (r HourRange) String() string { return fmt.Sprintf("%02d:00-%02d:00", r.From, r.To) }
+
+// A PlaceCount is how many rides started or ended at a place.
+type PlaceCount struct {
+	Place string
+	Rides int
+}
+
+// A LateNightReport collects the rides that started within a range of hours.
+type LateNightReport struct {
+	Hours HourRange
+	// Rides are ordered by start time.
+	Rides []Leg
+	// Months counts the rides per calendar month.
+	Months []Month
+	// Places counts where the rides started or ended, most first.
+	Places []PlaceCount
+}
+
+// LateNight returns the rides in txns that started within hours. If cards
+// isn't empty, only rides on those cards are included.
+func LateNight(txns []transit.Transaction, hours HourRange, cards ...int64) LateNightReport {
+	r := LateNightReport{Hours: hours}
+	want := make(map[int64]bool, len(cards))
+	for _, c := range cards {
+		want[c] = true
+	}
+	var rideTxns []transit.Transaction
+	places := make(map[string]int)
+	for _, l := range Legs(txns) {
+		if len(want) > 0 && !want[l.Txns[0].CardSerial] {
+			continue
+		}
+		if !hours.Contains(l.Start().Hour()) {
+			continue
+		}
+		r.Rides = append(r.Rides, l)
+		rideTxns = append(rideTxns, l.Txns...)
+		for _, p := range []string{l.Origin(), l.Destination()} {
+			if p != "" {
+				places[p]++
+			}
+		}
+	}
+	for _, m := range Monthly(rideTxns) {
+		if m.Trips > 0 {
+			r.Months = append(r.Months, m)
+		}
+	}
+	for p, n := range places {
+		r.Places = append(r.Places, PlaceCount{Place: p, Rides: n})
+	}
+	sort.Slice(r.Places, func(i, j int) bool {
+		if r.Places[i].Rides != r.Places[j].Rides {
+			return r.Places[i].Rides > r.Places[j].Rides
+		}
+		return r.Places[i].Place < r.Places[j].Place
+	})
+	return r
+}
@@ -0,0 +1,57 @@
+package clipperstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestParseHourRange(t *testing.T) {
+	r, err := ParseHourRange("22-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for hour, want := range map[int]bool{21: false, 22: true, 23: true, 0: true, 4: true, 5: false, 12: false} {
+		if got := r.Contains(hour); got != want {
+			t.Errorf("22-5 contains %d: got %t", hour, got)
+		}
+	}
+	for _, bad := range []string{"5", "5-5", "0-25", "x-3"} {
+		if _, err := ParseHourRange(bad); err == nil {
+			t.Errorf("ParseHourRange(%q): expected error", bad)
+		}
+	}
+}
+
+func TestLateNight(t *testing.T) {
+	ride := func(month time.Month, day, hour int, card int64, location string) transit.Transaction {
+		return transit.Transaction{
+			Timestamp:  time.Date(2018, month, day, hour, 30, 0, 0, time.UTC),
+			Type:       "Single-tag fare payment",
+			Location:   location,
+			DebitCents: 0,
+			CardSerial: card,
+		}
+	}
+	txns := []transit.Transaction{
+		ride(time.January, 6, 1, 5, "Muni owl bus"),
+		ride(time.January, 6, 8, 5, "Muni bus"),
+		ride(time.January, 7, 23, 5, "Muni bus"),
+		ride(time.March, 2, 2, 5, "Muni owl bus"),
+		ride(time.March, 2, 2, 6, "AC Transit bus"),
+	}
+	r := LateNight(txns, DefaultLateNight, 5)
+	if len(r.Rides) != 2 || r.Rides[1].Start().Month() != time.March {
+		t.Fatalf("rides: got %d", len(r.Rides))
+	}
+	if len(r.Months) != 2 || r.Months[0].Trips != 1 {
+		t.Errorf("months: got %+v", r.Months)
+	}
+	if len(r.Places) != 1 || r.Places[0] != (PlaceCount{"Muni owl bus", 2}) {
+		t.Errorf("places: got %+v", r.Places)
+	}
+	if all := LateNight(txns, HourRange{22, 5}); len(all.Rides) != 4 {
+		t.Errorf("expected 4 rides from 10pm on any card, got %d", len(all.Rides))
+	}
+}
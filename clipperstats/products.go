@@ -0,0 +1,219 @@
+package clipperstats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// A FareProduct is something you can buy instead of paying full fare from
+// cash value: a monthly pass, a stored value discount, or an all-agency
+// unlimited pass.
+type FareProduct struct {
+	Name string `yaml:"name"`
+	// Agencies are the operators whose rides the product covers. If it's
+	// empty, the product covers rides on every agency.
+	Agencies []transit.Agency `yaml:"agencies"`
+	// Zones is the number of Caltrain zones the product covers. Caltrain
+	// rides through more zones aren't covered. Zero means any number.
+	Zones int `yaml:"zones"`
+	// PriceCents is what the product costs per month.
+	PriceCents transit.Money `yaml:"price_cents"`
+	// DiscountPercent is the discount on fares for covered rides. Passes are
+	// 100; stored value bonuses like BART's high-value discount tickets are
+	// less.
+	DiscountPercent float64 `yaml:"discount_percent"`
+}
+
+// caltrainZones returns the number of Caltrain zones l travels through, or 0
+// if its stations aren't known.
+func caltrainZones(l Leg) int {
+	from, to, ok := legStations(l)
+	if !ok || from.Zone == 0 || to.Zone == 0 {
+		return 0
+	}
+	if to.Zone < from.Zone {
+		from, to = to, from
+	}
+	return to.Zone - from.Zone + 1
+}
+
+// Covers reports whether l is a ride the product applies to.
+func (p FareProduct) Covers(l Leg) bool {
+	if len(p.Agencies) > 0 {
+		found := false
+		for _, a := range p.Agencies {
+			if a == l.Agency {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if p.Zones > 0 && l.Agency == transit.AgencyCaltrain {
+		zones := caltrainZones(l)
+		return zones > 0 && zones <= p.Zones
+	}
+	return l.Agency != transit.AgencyUnknown
+}
+
+// CostCents returns what a month with fareCents of covered rides would cost
+// with the product.
+func (p FareProduct) CostCents(fareCents transit.Money) transit.Money {
+	return p.PriceCents + fareCents.Mul((100-p.DiscountPercent)/100)
+}
+
+// DefaultFareProducts are adult fare products as of 2024. The BART high-value
+// discount was discontinued, but is useful for comparing old statements; the
+// all-agency pass is a hypothetical price for BayPass-style scenarios.
+var DefaultFareProducts = []FareProduct{
+	{Name: "Muni M", Agencies: []transit.Agency{transit.AgencySFMTA}, PriceCents: 8600, DiscountPercent: 100},
+	{Name: "AC Transit 31-day", Agencies: []transit.Agency{transit.AgencyACTransit}, PriceCents: 8400, DiscountPercent: 100},
+	{Name: "Caltrain 1-zone", Agencies: []transit.Agency{transit.AgencyCaltrain}, Zones: 1, PriceCents: 10500, DiscountPercent: 100},
+	{Name: "Caltrain 2-zone", Agencies: []transit.Agency{transit.AgencyCaltrain}, Zones: 2, PriceCents: 16800, DiscountPercent: 100},
+	{Name: "Caltrain 3-zone", Agencies: []transit.Agency{transit.AgencyCaltrain}, Zones: 3, PriceCents: 23100, DiscountPercent: 100},
+	{Name: "Caltrain 4-zone", Agencies: []transit.Agency{transit.AgencyCaltrain}, Zones: 4, PriceCents: 29400, DiscountPercent: 100},
+	{Name: "Caltrain 5-zone", Agencies: []transit.Agency{transit.AgencyCaltrain}, Zones: 5, PriceCents: 35700, DiscountPercent: 100},
+	{Name: "Caltrain 6-zone", Agencies: []transit.Agency{transit.AgencyCaltrain}, Zones: 6, PriceCents: 42000, DiscountPercent: 100},
+	{Name: "BART high-value discount", Agencies: []transit.Agency{transit.AgencyBART}, DiscountPercent: 6.25},
+	{Name: "All-agency unlimited (BayPass-style)", PriceCents: 25000, DiscountPercent: 100},
+}
+
+// LoadFareProducts reads a table of fare products in YAML, for example:
+//
+//   - name: Muni M
+//     agencies: [SFMTA]
+//     price_cents: 8600
+//     discount_percent: 100
+func LoadFareProducts(r io.Reader) ([]FareProduct, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var products []FareProduct
+	if err := yaml.Unmarshal(data, &products); err != nil {
+		return nil, fmt.Errorf("clipperstats: reading fare products: %v", err)
+	}
+	for i, p := range products {
+		if p.Name == "" {
+			return nil, fmt.Errorf("clipperstats: fare product %d has no name", i+1)
+		}
+		if p.DiscountPercent < 0 || p.DiscountPercent > 100 {
+			return nil, fmt.Errorf("clipperstats: fare product %q: discount_percent must be between 0 and 100", p.Name)
+		}
+	}
+	return products, nil
+}
+
+// A ProductMonth compares a fare product with paying full fare for the rides
+// it would have covered in one calendar month.
+type ProductMonth struct {
+	// Start is midnight on the first day of the month.
+	Start           time.Time
+	Product         string
+	Rides           int
+	PayAsYouGoCents transit.Money
+	// ProductCents is what the month would have cost with the product.
+	ProductCents transit.Money
+}
+
+// SavingsCents returns how much the product would have saved; it's negative
+// if paying as you go was cheaper.
+func (p ProductMonth) SavingsCents() transit.Money {
+	return p.PayAsYouGoCents - p.ProductCents
+}
+
+// BreakEven compares each of products with paying as you go, for every month
+// with at least one ride. Months where a product covers no rides are included,
+// costing its full price. Results are ordered by month, then in the order of
+// products.
+func BreakEven(txns []transit.Transaction, products []FareProduct) []ProductMonth {
+	fares := make(map[time.Time][]transit.Money)
+	rides := make(map[time.Time][]int)
+	var starts []time.Time
+	for _, ls := range cardLegs(txns) {
+		for _, l := range ls {
+			start := monthStart(l.Start())
+			if _, ok := fares[start]; !ok {
+				fares[start] = make([]transit.Money, len(products))
+				rides[start] = make([]int, len(products))
+				starts = append(starts, start)
+			}
+			for i, p := range products {
+				if p.Covers(l) {
+					fares[start][i] += l.FareCents()
+					rides[start][i]++
+				}
+			}
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	out := make([]ProductMonth, 0, len(starts)*len(products))
+	for _, start := range starts {
+		for i, p := range products {
+			out = append(out, ProductMonth{
+				Start:           start,
+				Product:         p.Name,
+				Rides:           rides[start][i],
+				PayAsYouGoCents: fares[start][i],
+				ProductCents:    p.CostCents(fares[start][i]),
+			})
+		}
+	}
+	return out
+}
+
+// A Recommendation says whether to buy a fare product going forward.
+type Recommendation struct {
+	Product string
+	// Buy is true if the product would have saved money on average over the
+	// months considered.
+	Buy bool
+	// AverageSavingsCents is the average monthly saving from the product;
+	// it's negative if paying as you go was cheaper.
+	AverageSavingsCents transit.Money
+	Months              int
+}
+
+// Recommend ranks fare products by their average monthly savings over the
+// most recent months of BreakEven results, highest first.
+func Recommend(months []ProductMonth, recent int) []Recommendation {
+	if len(months) == 0 || recent <= 0 {
+		return nil
+	}
+	var starts []time.Time
+	for _, pm := range months {
+		if len(starts) == 0 || !starts[len(starts)-1].Equal(pm.Start) {
+			starts = append(starts, pm.Start)
+		}
+	}
+	if len(starts) > recent {
+		starts = starts[len(starts)-recent:]
+	}
+	savings := make(map[string]transit.Money)
+	var names []string
+	for _, pm := range months {
+		if pm.Start.Before(starts[0]) {
+			continue
+		}
+		if _, ok := savings[pm.Product]; !ok {
+			names = append(names, pm.Product)
+		}
+		savings[pm.Product] += pm.SavingsCents()
+	}
+	out := make([]Recommendation, 0, len(names))
+	for _, name := range names {
+		avg := savings[name] / transit.Money(len(starts))
+		out = append(out, Recommendation{Product: name, Buy: avg > 0, AverageSavingsCents: avg, Months: len(starts)})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].AverageSavingsCents > out[j].AverageSavingsCents
+	})
+	return out
+}
@@ -0,0 +1,87 @@
+package clipperstats
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestBreakEven(t *testing.T) {
+	var txns []transit.Transaction
+	// 40 Muni rides in January, 2 in February.
+	for i := 0; i < 42; i++ {
+		month, day := time.January, 1+i/2
+		if i >= 40 {
+			month, day = time.February, i-39
+		}
+		txns = append(txns, transit.Transaction{
+			Timestamp:  time.Date(2018, month, day, 8+10*(i%2), 0, 0, 0, time.UTC),
+			Type:       "Single-tag fare payment",
+			Location:   "Powell (Muni)",
+			DebitCents: 250,
+		})
+	}
+	// One 3-zone Caltrain ride in January.
+	txns = append(txns,
+		transit.Transaction{Timestamp: time.Date(2018, time.January, 3, 7, 0, 0, 0, time.UTC), Type: "Dual-tag entry transaction, maximum fare deducted (purse debit)", Location: "Belmont", DebitCents: 1220},
+		transit.Transaction{Timestamp: time.Date(2018, time.January, 3, 7, 40, 0, 0, time.UTC), Type: "Dual-tag exit transaction, fare adjustment (purse rebate)", Location: "4th and King (Caltrain)", CreditCents: 580},
+	)
+	products := []FareProduct{
+		{Name: "Muni M", Agencies: []transit.Agency{transit.AgencySFMTA}, PriceCents: 8600, DiscountPercent: 100},
+		{Name: "Caltrain 2-zone", Agencies: []transit.Agency{transit.AgencyCaltrain}, Zones: 2, PriceCents: 16800, DiscountPercent: 100},
+		{Name: "Caltrain 3-zone", Agencies: []transit.Agency{transit.AgencyCaltrain}, Zones: 3, PriceCents: 23100, DiscountPercent: 100},
+		{Name: "Half off", DiscountPercent: 50},
+	}
+	months := BreakEven(txns, products)
+	if len(months) != 8 {
+		t.Fatalf("expected 8 rows, got %+v", months)
+	}
+	if m := months[0]; m.Product != "Muni M" || m.Rides != 40 || m.SavingsCents() != 10000-8600 {
+		t.Errorf("Muni January: got %+v", m)
+	}
+	if m := months[1]; m.Rides != 0 || m.SavingsCents() != -16800 {
+		t.Errorf("a 2-zone pass shouldn't cover a 3-zone ride: got %+v", m)
+	}
+	if m := months[2]; m.Rides != 1 || m.PayAsYouGoCents != 640 || m.ProductCents != 23100 {
+		t.Errorf("Caltrain January: got %+v", m)
+	}
+	if m := months[3]; m.Rides != 41 || m.SavingsCents() != (10000+640)/2 {
+		t.Errorf("discount: got %+v", m)
+	}
+	if m := months[4]; m.Start.Month() != time.February || m.Rides != 2 || m.SavingsCents() != 500-8600 {
+		t.Errorf("Muni February: got %+v", m)
+	}
+
+	recs := Recommend(months, 1)
+	if len(recs) != 4 || recs[0].Product != "Half off" || !recs[0].Buy || recs[1].Buy {
+		t.Errorf("last month: got %+v", recs)
+	}
+	recs = Recommend(months, 2)
+	if recs[1].Product != "Muni M" || recs[1].AverageSavingsCents != (1400-8100)/2 {
+		t.Errorf("two months: got %+v", recs)
+	}
+}
+
+func TestLoadFareProducts(t *testing.T) {
+	products, err := LoadFareProducts(strings.NewReader(`
+- name: Caltrain 2-zone
+  agencies: [Caltrain]
+  zones: 2
+  price_cents: 16800
+  discount_percent: 100
+- name: Everything
+  price_cents: 20000
+  discount_percent: 100
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 2 || products[0].Agencies[0] != transit.AgencyCaltrain || products[0].Zones != 2 || len(products[1].Agencies) != 0 {
+		t.Errorf("got %+v", products)
+	}
+	if _, err := LoadFareProducts(strings.NewReader("- name: bad\n  discount_percent: 150\n")); err == nil {
+		t.Error("expected an error for a discount over 100%")
+	}
+}
@@ -0,0 +1,219 @@
+package clipperstats
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// An AgencyTotal is the activity with one agency over a period.
+type AgencyTotal struct {
+	Agency     transit.Agency
+	Trips      int
+	SpendCents transit.Money
+}
+
+// A Gap is a stretch of time between two trips.
+type Gap struct {
+	From, To time.Time
+}
+
+// Days returns the number of whole days in the gap.
+func (g Gap) Days() int { return int(g.To.Sub(g.From).Hours() / 24) }
+
+// A YearReview sums up a calendar year of riding.
+type YearReview struct {
+	Year       int
+	Trips      int
+	SpendCents transit.Money
+	// TopStation is the station or stop where the most rides started or
+	// ended, and TopStationVisits how many did.
+	TopStation       string
+	TopStationVisits int
+	// LongestGap is the longest time between two trips in the year.
+	LongestGap   Gap
+	BusiestMonth Month
+	Months       []Month
+	// Agencies are ranked by trips, most first.
+	Agencies []AgencyTotal
+}
+
+// ReviewYear builds a YearReview of the transactions in txns during year.
+func ReviewYear(txns []transit.Transaction, year int) YearReview {
+	r := YearReview{Year: year}
+	var inYear []transit.Transaction
+	for _, t := range txns {
+		if t.Timestamp.Year() == year {
+			inYear = append(inYear, t)
+		}
+	}
+	r.Trips = Trips(inYear)
+	r.SpendCents = SpendCents(inYear)
+
+	r.Months = Monthly(inYear)
+	for _, m := range r.Months {
+		if m.Trips > r.BusiestMonth.Trips {
+			r.BusiestMonth = m
+		}
+	}
+
+	var starts []time.Time
+	for _, t := range inYear {
+		if t.IsTripStart() {
+			starts = append(starts, t.Timestamp)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	for i := 1; i < len(starts); i++ {
+		if starts[i].Sub(starts[i-1]) > r.LongestGap.To.Sub(r.LongestGap.From) {
+			r.LongestGap = Gap{From: starts[i-1], To: starts[i]}
+		}
+	}
+
+	visits := make(map[string]int)
+	for _, l := range Legs(inYear) {
+		for _, place := range []string{l.Origin(), l.Destination()} {
+			if place != "" {
+				visits[place]++
+			}
+		}
+	}
+	for place, n := range visits {
+		if n > r.TopStationVisits || (n == r.TopStationVisits && place < r.TopStation) {
+			r.TopStation, r.TopStationVisits = place, n
+		}
+	}
+
+	r.Agencies = AgencyTotals(inYear)
+	return r
+}
+
+// AgencyTotals returns activity per agency in txns, ranked by trips, most
+// first. Fares that can't be attributed to an agency are reported under
+// transit.AgencyUnknown.
+func AgencyTotals(txns []transit.Transaction) []AgencyTotal {
+	byAgency := make(map[transit.Agency]*AgencyTotal)
+	for _, t := range txns {
+		if !t.IsFare() {
+			continue
+		}
+		a, ok := byAgency[t.Agency()]
+		if !ok {
+			a = &AgencyTotal{Agency: t.Agency()}
+			byAgency[a.Agency] = a
+		}
+		if t.IsTripStart() {
+			a.Trips++
+		}
+		a.SpendCents += t.FareCents()
+	}
+	var out []AgencyTotal
+	for _, a := range byAgency {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Trips != out[j].Trips {
+			return out[i].Trips > out[j].Trips
+		}
+		return out[i].Agency < out[j].Agency
+	})
+	return out
+}
+
+var reviewFuncs = map[string]interface{}{
+	"dollars": transit.Money.String,
+	"agency": func(a transit.Agency) string {
+		if a == transit.AgencyUnknown {
+			return "Other"
+		}
+		return string(a)
+	},
+	"inc": func(i int) int { return i + 1 },
+}
+
+const reviewMarkdown = `# {{.Year}} on Clipper
+
+- **Rides:** {{.Trips}}
+- **Spent:** {{dollars .SpendCents}}
+{{- if .TopStation}}
+- **Most-used station:** {{.TopStation}} ({{.TopStationVisits}} visits)
+{{- end}}
+{{- if .BusiestMonth.Trips}}
+- **Busiest month:** {{.BusiestMonth.Start.Format "January"}} ({{.BusiestMonth.Trips}} rides)
+{{- end}}
+{{- if not .LongestGap.From.IsZero}}
+- **Longest break:** {{.LongestGap.Days}} days, {{.LongestGap.From.Format "Jan 2"}} to {{.LongestGap.To.Format "Jan 2"}}
+{{- end}}
+{{- if .Agencies}}
+
+## Agencies
+
+| # | Agency | Rides | Spent |
+|---|--------|------:|------:|
+{{- range $i, $a := .Agencies}}
+| {{inc $i}} | {{agency $a.Agency}} | {{$a.Trips}} | {{dollars $a.SpendCents}} |
+{{- end}}
+{{- end}}
+`
+
+const reviewHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Year}} on Clipper</title>
+</head>
+<body>
+<h1>{{.Year}} on Clipper</h1>
+<ul>
+<li><strong>Rides:</strong> {{.Trips}}</li>
+<li><strong>Spent:</strong> {{dollars .SpendCents}}</li>
+{{- if .TopStation}}
+<li><strong>Most-used station:</strong> {{.TopStation}} ({{.TopStationVisits}} visits)</li>
+{{- end}}
+{{- if .BusiestMonth.Trips}}
+<li><strong>Busiest month:</strong> {{.BusiestMonth.Start.Format "January"}} ({{.BusiestMonth.Trips}} rides)</li>
+{{- end}}
+{{- if not .LongestGap.From.IsZero}}
+<li><strong>Longest break:</strong> {{.LongestGap.Days}} days, {{.LongestGap.From.Format "Jan 2"}} to {{.LongestGap.To.Format "Jan 2"}}</li>
+{{- end}}
+</ul>
+{{- if .Agencies}}
+<h2>Agencies</h2>
+<table>
+<tr><th>#</th><th>Agency</th><th>Rides</th><th>Spent</th></tr>
+{{- range $i, $a := .Agencies}}
+<tr><td>{{inc $i}}</td><td>{{agency $a.Agency}}</td><td>{{$a.Trips}}</td><td>{{dollars $a.SpendCents}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- range .Charts}}
+<figure>
+{{.}}
+</figure>
+{{- end}}
+</body>
+</html>
+`
+
+var (
+	reviewMarkdownTemplate = template.Must(template.New("review").Funcs(reviewFuncs).Parse(reviewMarkdown))
+	reviewHTMLTemplate     = htmltemplate.Must(htmltemplate.New("review").Funcs(reviewFuncs).Parse(reviewHTML))
+)
+
+// WriteMarkdown writes the review to w as Markdown.
+func (r YearReview) WriteMarkdown(w io.Writer) error {
+	return reviewMarkdownTemplate.Execute(w, r)
+}
+
+// WriteHTML writes the review to w as an HTML page. Charts, if any, are
+// trusted HTML or inline SVG added after the summary.
+func (r YearReview) WriteHTML(w io.Writer, charts ...htmltemplate.HTML) error {
+	return reviewHTMLTemplate.Execute(w, struct {
+		YearReview
+		Charts []htmltemplate.HTML
+	}{r, charts})
+}
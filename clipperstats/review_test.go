@@ -0,0 +1,61 @@
+package clipperstats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestReviewYear(t *testing.T) {
+	on := func(month time.Month, day int, typ, location string, debit transit.Money) transit.Transaction {
+		return transit.Transaction{
+			Timestamp:  time.Date(2018, month, day, 8, 0, 0, 0, time.UTC),
+			Type:       typ,
+			Location:   location,
+			DebitCents: debit,
+		}
+	}
+	txns := []transit.Transaction{
+		{Timestamp: time.Date(2017, time.December, 30, 8, 0, 0, 0, time.UTC), Type: "Single-tag fare payment", DebitCents: 250},
+		on(time.January, 2, "Dual-tag entry transaction, maximum fare deducted (purse debit)", "Millbrae (BART)", 1000),
+		on(time.January, 3, "Dual-tag entry transaction, maximum fare deducted (purse debit)", "Millbrae (BART)", 1000),
+		on(time.January, 4, "Single-tag fare payment", "Muni bus", 250),
+		on(time.March, 10, "Single-tag fare payment", "Muni bus", 250),
+	}
+	r := ReviewYear(txns, 2018)
+	if r.Trips != 4 || r.SpendCents != 2500 {
+		t.Errorf("totals: got %d trips, %d cents", r.Trips, r.SpendCents)
+	}
+	if r.TopStation != "Millbrae" || r.TopStationVisits != 2 {
+		t.Errorf("top station: got %q (%d)", r.TopStation, r.TopStationVisits)
+	}
+	if r.BusiestMonth.Start.Month() != time.January || r.BusiestMonth.Trips != 3 {
+		t.Errorf("busiest month: got %+v", r.BusiestMonth)
+	}
+	if r.LongestGap.Days() != 65 {
+		t.Errorf("longest gap: got %d days", r.LongestGap.Days())
+	}
+	if len(r.Agencies) != 2 || r.Agencies[0].Agency != transit.AgencyBART || r.Agencies[1].Agency != transit.AgencySFMTA {
+		t.Errorf("agencies: got %+v", r.Agencies)
+	}
+
+	var md, html bytes.Buffer
+	if err := r.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(md.String(), "| 1 | BART | 2 | $20.00 |") {
+		t.Errorf("markdown missing agency row:\n%s", md.String())
+	}
+	if err := r.WriteHTML(&html, "<svg></svg>"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html.String(), "Millbrae (2 visits)") {
+		t.Errorf("html missing top station:\n%s", html.String())
+	}
+	if !strings.Contains(html.String(), "<figure>\n<svg></svg>\n</figure>") {
+		t.Errorf("html missing chart:\n%s", html.String())
+	}
+}
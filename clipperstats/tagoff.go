@@ -0,0 +1,140 @@
+package clipperstats
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// A MissedTagOff is a dual-tag ride that was charged the maximum fare (or a
+// penalty) because the card was never tagged at the exit.
+type MissedTagOff struct {
+	Leg Leg
+	// ChargedCents is what the ride cost.
+	ChargedCents transit.Money
+	// UsualCents is the typical fare for completed rides from the same entry
+	// location, or from the same agency if there are none. It's zero if
+	// Estimated is false.
+	UsualCents transit.Money
+	// OverchargeCents is ChargedCents less UsualCents, or ChargedCents if
+	// there's nothing to compare with.
+	OverchargeCents transit.Money
+	// Estimated is true if UsualCents comes from ride history.
+	Estimated bool
+}
+
+// isMissedTagOff reports whether l is a dual-tag ride without an exit tag
+// that was charged a maximum fare or a penalty.
+func isMissedTagOff(l Leg) bool {
+	first := strings.ToLower(l.Txns[0].Type)
+	if !strings.Contains(first, "dual-tag") || !strings.Contains(first, "entry") {
+		return strings.Contains(first, "penalty")
+	}
+	for _, t := range l.Txns[1:] {
+		lower := strings.ToLower(t.Type)
+		if strings.Contains(lower, "dual-tag") && strings.Contains(lower, "exit") {
+			return false
+		}
+	}
+	return strings.Contains(first, "maximum fare") || strings.Contains(first, "penalty") || l.FareCents() > 0
+}
+
+// isCompleted reports whether l is a dual-tag ride with both tags.
+func isCompleted(l Leg) bool {
+	first := strings.ToLower(l.Txns[0].Type)
+	if !strings.Contains(first, "dual-tag") || !strings.Contains(first, "entry") {
+		return false
+	}
+	for _, t := range l.Txns[1:] {
+		lower := strings.ToLower(t.Type)
+		if strings.Contains(lower, "dual-tag") && strings.Contains(lower, "exit") {
+			return true
+		}
+	}
+	return false
+}
+
+// median returns the median of vals, which must not be empty.
+func median(vals []transit.Money) transit.Money {
+	sorted := append([]transit.Money(nil), vals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
+
+// MissedTagOffs finds rides in txns that were charged for a missed tag-off
+// and estimates how much each one cost compared with a usual ride.
+func MissedTagOffs(txns []transit.Transaction) []MissedTagOff {
+	byLocation := make(map[string][]transit.Money)
+	byAgency := make(map[transit.Agency][]transit.Money)
+	var missed []Leg
+	for _, ls := range cardLegs(txns) {
+		for _, l := range ls {
+			switch {
+			case isMissedTagOff(l):
+				missed = append(missed, l)
+			case isCompleted(l):
+				fare := l.FareCents()
+				byLocation[l.Txns[0].Location] = append(byLocation[l.Txns[0].Location], fare)
+				byAgency[l.Agency] = append(byAgency[l.Agency], fare)
+			}
+		}
+	}
+	out := make([]MissedTagOff, 0, len(missed))
+	for _, l := range missed {
+		m := MissedTagOff{Leg: l, ChargedCents: l.FareCents()}
+		if fares := byLocation[l.Txns[0].Location]; len(fares) > 0 {
+			m.UsualCents, m.Estimated = median(fares), true
+		} else if fares := byAgency[l.Agency]; len(fares) > 0 && l.Agency != transit.AgencyUnknown {
+			m.UsualCents, m.Estimated = median(fares), true
+		}
+		m.OverchargeCents = m.ChargedCents - m.UsualCents
+		if m.OverchargeCents < 0 {
+			m.OverchargeCents = 0
+		}
+		out = append(out, m)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Leg.Start().Before(out[j].Leg.Start())
+	})
+	return out
+}
+
+// A Quarter totals missed tag-offs in one calendar quarter.
+type Quarter struct {
+	// Start is midnight on the first day of the quarter.
+	Start           time.Time
+	Count           int
+	OverchargeCents transit.Money
+}
+
+// Name returns the quarter in the form "2018 Q1".
+func (q Quarter) Name() string {
+	return q.Start.Format("2006") + " Q" + string(rune('1'+(int(q.Start.Month())-1)/3))
+}
+
+func quarterStart(t time.Time) time.Time {
+	month := time.Month((int(t.Month())-1)/3*3 + 1)
+	return time.Date(t.Year(), month, 1, 0, 0, 0, 0, t.Location())
+}
+
+// MissedTagOffsByQuarter totals missed by calendar quarter, oldest first.
+// Quarters without any missed tag-offs are omitted.
+func MissedTagOffsByQuarter(missed []MissedTagOff) []Quarter {
+	var out []Quarter
+	for _, m := range missed {
+		start := quarterStart(m.Leg.Start())
+		if len(out) == 0 || !out[len(out)-1].Start.Equal(start) {
+			out = append(out, Quarter{Start: start})
+		}
+		q := &out[len(out)-1]
+		q.Count++
+		q.OverchargeCents += m.OverchargeCents
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package clipperstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestMissedTagOffs(t *testing.T) {
+	day := func(month time.Month, d, hour int) time.Time {
+		return time.Date(2018, month, d, hour, 0, 0, 0, time.UTC)
+	}
+	txns := []transit.Transaction{
+		// Two completed rides from Belmont, at $3.20 and $3.70.
+		{Timestamp: day(1, 2, 8), Type: "Dual-tag entry transaction, maximum fare deducted (purse debit)", Location: "Belmont", DebitCents: 1220},
+		{Timestamp: day(1, 2, 9), Type: "Dual-tag exit transaction, fare adjustment (purse rebate)", Location: "4th and King (Caltrain)", CreditCents: 900},
+		{Timestamp: day(1, 3, 8), Type: "Dual-tag entry transaction, maximum fare deducted (purse debit)", Location: "Belmont", DebitCents: 1220},
+		{Timestamp: day(1, 3, 9), Type: "Dual-tag exit transaction, fare adjustment (purse rebate)", Location: "22nd St", CreditCents: 850},
+		// Missed the tag-off.
+		{Timestamp: day(1, 4, 8), Type: "Dual-tag entry transaction, maximum fare deducted (purse debit)", Location: "Belmont", DebitCents: 1220},
+		// Missed the tag-off somewhere new, in the next quarter.
+		{Timestamp: day(4, 4, 8), Type: "Dual-tag entry transaction, maximum fare deducted (purse debit)", Location: "Hillsdale", DebitCents: 1220},
+		// BART entry without an exit isn't charged until the exit.
+		{Timestamp: day(4, 5, 8), Type: "Dual-tag entry transaction, no fare deduction", Location: "Powell St (BART)"},
+	}
+	missed := MissedTagOffs(txns)
+	if len(missed) != 2 {
+		t.Fatalf("expected 2 missed tag-offs, got %d: %+v", len(missed), missed)
+	}
+	if m := missed[0]; !m.Estimated || m.UsualCents != 345 || m.OverchargeCents != 1220-345 {
+		t.Errorf("first: got %+v", m)
+	}
+	if m := missed[1]; !m.Estimated || m.UsualCents != 345 {
+		t.Errorf("second should fall back to the Caltrain median: got %+v", m)
+	}
+	quarters := MissedTagOffsByQuarter(missed)
+	if len(quarters) != 2 {
+		t.Fatalf("expected 2 quarters, got %+v", quarters)
+	}
+	if quarters[0].Name() != "2018 Q1" || quarters[1].Name() != "2018 Q2" {
+		t.Errorf("bad quarter names: %q, %q", quarters[0].Name(), quarters[1].Name())
+	}
+	if quarters[0].Count != 1 || quarters[0].OverchargeCents != 875 {
+		t.Errorf("Q1: got %+v", quarters[0])
+	}
+}
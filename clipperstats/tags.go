@@ -0,0 +1,165 @@
+package clipperstats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// A Purpose is why a trip was taken.
+type Purpose string
+
+const (
+	PurposeWork     Purpose = "work"
+	PurposePersonal Purpose = "personal"
+	PurposeOther    Purpose = "other"
+)
+
+// ParsePurpose returns the purpose named s, ignoring case.
+func ParsePurpose(s string) (Purpose, error) {
+	p := Purpose(strings.ToLower(strings.TrimSpace(s)))
+	switch p {
+	case PurposeWork, PurposePersonal, PurposeOther:
+		return p, nil
+	}
+	return "", fmt.Errorf("clipperstats: unknown purpose %q", s)
+}
+
+// A TagRule gives rides that match it a purpose. A ride must match every
+// field that's set.
+type TagRule struct {
+	Purpose Purpose `yaml:"purpose"`
+	// Days are days of the week, such as "Mon" or "Tuesday".
+	Days     []string         `yaml:"days,omitempty"`
+	Agencies []transit.Agency `yaml:"agencies,omitempty"`
+	// Locations are places one of the ride's tags must be at. Matching is
+	// case-insensitive and on a substring.
+	Locations []string `yaml:"locations,omitempty"`
+	// Hours is the range of hours the ride must start in, like "6-10" for
+	// 6:00 to 9:59.
+	Hours string `yaml:"hours,omitempty"`
+}
+
+func (r TagRule) hours() (from, to int, err error) {
+	if r.Hours == "" {
+		return 0, 24, nil
+	}
+	a, b, ok := strings.Cut(r.Hours, "-")
+	if ok {
+		from, err = strconv.Atoi(strings.TrimSpace(a))
+		if err == nil {
+			to, err = strconv.Atoi(strings.TrimSpace(b))
+		}
+	}
+	if !ok || err != nil || from < 0 || to > 24 || from >= to {
+		return 0, 0, fmt.Errorf("clipperstats: bad hours %q, want a range like 6-10", r.Hours)
+	}
+	return from, to, nil
+}
+
+// Validate returns an error if the rule can't be used.
+func (r TagRule) Validate() error {
+	if _, err := ParsePurpose(string(r.Purpose)); err != nil {
+		return err
+	}
+	for _, name := range r.Days {
+		if _, ok := parseWeekday(name); !ok {
+			return fmt.Errorf("clipperstats: unknown day %q", name)
+		}
+	}
+	_, _, err := r.hours()
+	return err
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	n := strings.ToLower(strings.TrimSpace(name))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if n == full || n == full[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// Match reports whether the rule applies to l.
+func (r TagRule) Match(l Leg) bool {
+	start := l.Start()
+	if len(r.Days) > 0 {
+		found := false
+		for _, name := range r.Days {
+			if d, ok := parseWeekday(name); ok && d == start.Weekday() {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if from, to, err := r.hours(); err != nil || start.Hour() < from || start.Hour() >= to {
+		return false
+	}
+	if len(r.Agencies) > 0 {
+		found := false
+		for _, a := range r.Agencies {
+			if a == l.Agency {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.Locations) > 0 {
+		found := false
+		for _, t := range l.Txns {
+			loc := strings.ToLower(t.Location)
+			for _, want := range r.Locations {
+				if want != "" && strings.Contains(loc, strings.ToLower(want)) {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// A Tagger decides the purpose of rides.
+type Tagger struct {
+	// Rules are tried in order; the first that matches wins.
+	Rules []TagRule `yaml:"rules,omitempty"`
+	// Overrides set the purpose of single rides, by Leg.ID, and take
+	// precedence over the rules.
+	Overrides map[string]Purpose `yaml:"overrides,omitempty"`
+	// Default is the purpose of rides no rule matches. It defaults to
+	// PurposeOther.
+	Default Purpose `yaml:"default,omitempty"`
+}
+
+// Tag returns the purpose of l.
+func (t Tagger) Tag(l Leg) Purpose {
+	if p, ok := t.Overrides[l.ID()]; ok {
+		return p
+	}
+	for _, r := range t.Rules {
+		if r.Match(l) {
+			return r.Purpose
+		}
+	}
+	if t.Default != "" {
+		return t.Default
+	}
+	return PurposeOther
+}
+
+// TagJourney returns the purpose of j, which is the purpose of its first
+// ride.
+func (t Tagger) TagJourney(j Journey) Purpose {
+	return t.Tag(j.Legs[0])
+}
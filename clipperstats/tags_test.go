@@ -0,0 +1,50 @@
+package clipperstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestTagger(t *testing.T) {
+	leg := func(day, hour int, location string) Leg {
+		txn := transit.Transaction{
+			// January 1, 2018 was a Monday.
+			Timestamp:  time.Date(2018, time.January, day, hour, 0, 0, 0, time.UTC),
+			Type:       "Single-tag fare payment",
+			Location:   location,
+			DebitCents: 250,
+			CardSerial: 7,
+		}
+		return Leg{Agency: txn.Agency(), Txns: []transit.Transaction{txn}}
+	}
+	tagger := Tagger{
+		Rules: []TagRule{
+			{Purpose: PurposeWork, Days: []string{"Mon", "Tuesday"}, Hours: "6-10"},
+			{Purpose: PurposePersonal, Days: []string{"Sat", "Sun"}},
+		},
+		Overrides: map[string]Purpose{"7-20180102T0800": PurposePersonal},
+	}
+	tests := []struct {
+		leg  Leg
+		want Purpose
+	}{
+		{leg(1, 8, "Muni bus"), PurposeWork},
+		{leg(1, 10, "Muni bus"), PurposeOther},
+		{leg(2, 8, "Muni bus"), PurposePersonal},
+		{leg(6, 12, "Muni bus"), PurposePersonal},
+		{leg(3, 8, "Muni bus"), PurposeOther},
+	}
+	for _, tt := range tests {
+		if got := tagger.Tag(tt.leg); got != tt.want {
+			t.Errorf("Tag(%s): got %q, want %q", tt.leg.ID(), got, tt.want)
+		}
+	}
+	if err := (TagRule{Purpose: PurposeWork, Hours: "10-6"}).Validate(); err == nil {
+		t.Errorf("expected error for backwards hours")
+	}
+	if err := (TagRule{Purpose: "fun"}).Validate(); err == nil {
+		t.Errorf("expected error for unknown purpose")
+	}
+}
@@ -0,0 +1,163 @@
+package clipperstats
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// Confidence levels used in a Timeline, as in Google's Semantic Location
+// History.
+const (
+	HighConfidence = "HIGH_CONFIDENCE"
+	LowConfidence  = "LOW_CONFIDENCE"
+)
+
+// A TimelineLocation is a place in a Timeline. Coordinates are in degrees
+// times 10^7, and are left out for places that aren't known stations, like a
+// bus.
+type TimelineLocation struct {
+	LatitudeE7  int    `json:"latitudeE7,omitempty"`
+	LongitudeE7 int    `json:"longitudeE7,omitempty"`
+	Name        string `json:"name,omitempty"`
+	// LocationConfidence is how sure we are of the place, from 0 to 100.
+	LocationConfidence int `json:"locationConfidence"`
+}
+
+func (l TimelineLocation) known() bool { return l.LatitudeE7 != 0 || l.LongitudeE7 != 0 }
+
+// A TimelineDuration is when something in a Timeline started and ended.
+type TimelineDuration struct {
+	StartTimestamp time.Time `json:"startTimestamp"`
+	EndTimestamp   time.Time `json:"endTimestamp"`
+}
+
+// A PlaceVisit is time spent at a place: a tag at a station or on a bus, or
+// the time between tags when transferring at a station.
+type PlaceVisit struct {
+	Location        TimelineLocation `json:"location"`
+	Duration        TimelineDuration `json:"duration"`
+	PlaceConfidence string           `json:"placeConfidence"`
+	// VisitConfidence is how sure we are of the visit, from 0 to 100.
+	VisitConfidence int `json:"visitConfidence"`
+}
+
+// An ActivitySegment is a ride between two known stations.
+type ActivitySegment struct {
+	StartLocation TimelineLocation `json:"startLocation"`
+	EndLocation   TimelineLocation `json:"endLocation"`
+	Duration      TimelineDuration `json:"duration"`
+	// Distance is the straight-line distance between the stations, in
+	// meters.
+	Distance     int    `json:"distance"`
+	ActivityType string `json:"activityType"`
+	Confidence   string `json:"confidence"`
+}
+
+// A TimelineObject is either a PlaceVisit or an ActivitySegment.
+type TimelineObject struct {
+	PlaceVisit      *PlaceVisit      `json:"placeVisit,omitempty"`
+	ActivitySegment *ActivitySegment `json:"activitySegment,omitempty"`
+}
+
+// A Timeline is transit history in the shape of Google's Semantic Location
+// History export, so it can be merged with other location history.
+type Timeline struct {
+	TimelineObjects []TimelineObject `json:"timelineObjects"`
+}
+
+// activityTypes maps agencies to Google's activity types. Agencies not listed
+// are buses.
+var activityTypes = map[transit.Agency]string{
+	transit.AgencyBART:            "IN_SUBWAY",
+	transit.AgencyCaltrain:        "IN_TRAIN",
+	transit.AgencySFMTA:           "IN_TRAM",
+	transit.AgencyGoldenGateFerry: "IN_FERRY",
+	transit.AgencySFBayFerry:      "IN_FERRY",
+}
+
+func activityType(a transit.Agency) string {
+	if t, ok := activityTypes[a]; ok {
+		return t
+	}
+	return "IN_BUS"
+}
+
+// timelineLocation returns the place at location, one of l's tags.
+func timelineLocation(l Leg, location string) TimelineLocation {
+	st, ok := l.station(location)
+	if !ok || (st.Lat == 0 && st.Lon == 0) {
+		return TimelineLocation{Name: location, LocationConfidence: 20}
+	}
+	return TimelineLocation{
+		LatitudeE7:         int(math.Round(st.Lat * 1e7)),
+		LongitudeE7:        int(math.Round(st.Lon * 1e7)),
+		Name:               st.Name,
+		LocationConfidence: 90,
+	}
+}
+
+func placeVisit(loc TimelineLocation, at time.Time) *PlaceVisit {
+	v := &PlaceVisit{
+		Location:        loc,
+		Duration:        TimelineDuration{at, at},
+		PlaceConfidence: HighConfidence,
+		VisitConfidence: 90,
+	}
+	if !loc.known() {
+		v.PlaceConfidence, v.VisitConfidence = LowConfidence, 30
+	}
+	return v
+}
+
+// BuildTimeline returns the rides in txns as a Timeline: a visit at every
+// tag, and a segment for every ride between two known stations. Visits to
+// the same station within DefaultTransferWindow, like leaving Caltrain and
+// entering BART at Millbrae, are joined into one visit. Times keep the
+// transactions' time zone.
+func BuildTimeline(txns []transit.Transaction) Timeline {
+	t := Timeline{TimelineObjects: []TimelineObject{}}
+	var last *PlaceVisit
+	visit := func(loc TimelineLocation, at time.Time) {
+		if last != nil && loc.known() && last.Location == loc && at.Sub(last.Duration.EndTimestamp) <= DefaultTransferWindow {
+			last.Duration.EndTimestamp = at
+			return
+		}
+		last = placeVisit(loc, at)
+		t.TimelineObjects = append(t.TimelineObjects, TimelineObject{PlaceVisit: last})
+	}
+	for _, l := range Legs(txns) {
+		origin := timelineLocation(l, l.Txns[0].Location)
+		visit(origin, l.Start())
+		dest := l.Destination()
+		if dest == "" {
+			continue
+		}
+		end := timelineLocation(l, l.Txns[len(l.Txns)-1].Location)
+		if origin.known() && end.known() {
+			from, _ := l.station(l.Txns[0].Location)
+			to, _ := l.station(l.Txns[len(l.Txns)-1].Location)
+			t.TimelineObjects = append(t.TimelineObjects, TimelineObject{ActivitySegment: &ActivitySegment{
+				StartLocation: origin,
+				EndLocation:   end,
+				Duration:      TimelineDuration{l.Start(), l.End()},
+				Distance:      int(math.Round(from.MilesTo(to) * 1609.344)),
+				ActivityType:  activityType(l.Agency),
+				Confidence:    "HIGH",
+			}})
+		}
+		last = nil
+		visit(end, l.End())
+	}
+	return t
+}
+
+// WriteJSON writes t as indented JSON.
+func (t Timeline) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
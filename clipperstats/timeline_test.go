@@ -0,0 +1,69 @@
+package clipperstats
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	txns := []transit.Transaction{
+		{Timestamp: at(7, 0), Type: "Dual-tag entry transaction, maximum fare deducted (purse debit)", Location: "Belmont (Caltrain)", DebitCents: 1220},
+		{Timestamp: at(7, 30), Type: "Dual-tag exit transaction, fare adjustment (purse rebate)", Location: "Millbrae (Caltrain)", CreditCents: 900},
+		{Timestamp: at(7, 40), Type: "Dual-tag entry transaction, no fare deduction", Location: "Millbrae (BART)"},
+		{Timestamp: at(8, 10), Type: "Dual-tag exit transaction, fare payment", Location: "Powell St (BART)", DebitCents: 465},
+		{Timestamp: at(8, 20), Type: "Single-tag fare payment", Location: "Muni bus", DebitCents: 250},
+	}
+	objs := BuildTimeline(txns).TimelineObjects
+	// Belmont, ride, Millbrae (Caltrain), Millbrae (BART), ride, Powell St,
+	// bus.
+	if len(objs) != 7 {
+		t.Fatalf("expected 7 objects, got %d", len(objs))
+	}
+	belmont := objs[0].PlaceVisit
+	if belmont == nil || belmont.Location.Name != "Belmont" || belmont.PlaceConfidence != HighConfidence || belmont.Location.LatitudeE7 != 375206000 {
+		t.Errorf("first visit: got %+v", objs[0])
+	}
+	ride := objs[1].ActivitySegment
+	if ride == nil || ride.ActivityType != "IN_TRAIN" || ride.Distance < 12000 || ride.Distance > 14000 {
+		t.Errorf("Caltrain ride: got %+v", ride)
+	}
+	if objs[4].ActivitySegment == nil || objs[4].ActivitySegment.ActivityType != "IN_SUBWAY" {
+		t.Errorf("BART ride: got %+v", objs[4])
+	}
+	bus := objs[6].PlaceVisit
+	if bus == nil || bus.PlaceConfidence != LowConfidence || bus.Location.LatitudeE7 != 0 || bus.Location.Name != "Muni bus" {
+		t.Errorf("bus visit: got %+v", objs[6])
+	}
+
+	var buf bytes.Buffer
+	if err := BuildTimeline(txns).WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string][]map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded["timelineObjects"]) != 7 {
+		t.Errorf("JSON: got %d timelineObjects", len(decoded["timelineObjects"]))
+	}
+}
+
+func TestBuildTimelineTransfer(t *testing.T) {
+	txns := []transit.Transaction{
+		{Timestamp: at(7, 0), Type: "Dual-tag entry transaction, no fare deduction", Location: "Powell St (BART)"},
+		{Timestamp: at(7, 30), Type: "Dual-tag exit transaction, fare payment", Location: "Millbrae (BART)", DebitCents: 465},
+		{Timestamp: at(9, 0), Type: "Dual-tag entry transaction, no fare deduction", Location: "Millbrae (BART)"},
+		{Timestamp: at(9, 30), Type: "Dual-tag exit transaction, fare payment", Location: "Powell St (BART)", DebitCents: 465},
+	}
+	objs := BuildTimeline(txns).TimelineObjects
+	if len(objs) != 5 {
+		t.Fatalf("expected 5 objects, got %d", len(objs))
+	}
+	v := objs[2].PlaceVisit
+	if v == nil || !v.Duration.StartTimestamp.Equal(at(7, 30)) || !v.Duration.EndTimestamp.Equal(at(9, 0)) {
+		t.Errorf("Millbrae visit: got %+v", objs[2])
+	}
+}
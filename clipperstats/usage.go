@@ -0,0 +1,92 @@
+package clipperstats
+
+import (
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// A Usage breaks trips down by when they were taken, for charting.
+type Usage struct {
+	// Heatmap[d][h] counts trips started on weekday d (0 is Sunday) in hour
+	// h (0 is midnight to 1am).
+	Heatmap [7][24]int `json:"heatmap"`
+	// ByHour and ByDay are the row and column totals of Heatmap.
+	ByHour       [24]int `json:"by_hour"`
+	ByDay        [7]int  `json:"by_day"`
+	WeekdayTrips int     `json:"weekday_trips"`
+	WeekendTrips int     `json:"weekend_trips"`
+	Weeks        []Week  `json:"weeks"`
+}
+
+// A Week counts trips in one week, starting on Sunday.
+type Week struct {
+	Start        time.Time `json:"start"`
+	WeekdayTrips int       `json:"weekday_trips"`
+	WeekendTrips int       `json:"weekend_trips"`
+	// Days is the number of distinct days with at least one trip.
+	Days int `json:"days"`
+}
+
+func weekStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()-int(t.Weekday()), 0, 0, 0, 0, t.Location())
+}
+
+func isWeekend(d time.Weekday) bool {
+	return d == time.Saturday || d == time.Sunday
+}
+
+// UsageStats counts trips in txns by hour and day of the week, and per week,
+// so changes in travel patterns over time are visible. Weeks without trips
+// between the first and last are included with zero counts.
+func UsageStats(txns []transit.Transaction) Usage {
+	var u Usage
+	weeks := make(map[time.Time]*Week)
+	days := make(map[time.Time]bool)
+	var first, last time.Time
+	for _, t := range txns {
+		if !t.IsTripStart() {
+			continue
+		}
+		ts := t.Timestamp
+		d, h := ts.Weekday(), ts.Hour()
+		u.Heatmap[d][h]++
+		u.ByHour[h]++
+		u.ByDay[d]++
+		start := weekStart(ts)
+		w, ok := weeks[start]
+		if !ok {
+			w = &Week{Start: start}
+			weeks[start] = w
+		}
+		if isWeekend(d) {
+			u.WeekendTrips++
+			w.WeekendTrips++
+		} else {
+			u.WeekdayTrips++
+			w.WeekdayTrips++
+		}
+		day := time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, ts.Location())
+		if !days[day] {
+			days[day] = true
+			w.Days++
+		}
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+	}
+	if len(weeks) == 0 {
+		return u
+	}
+	for start := first; !start.After(last); start = start.AddDate(0, 0, 7) {
+		if w, ok := weeks[start]; ok {
+			u.Weeks = append(u.Weeks, *w)
+		} else {
+			u.Weeks = append(u.Weeks, Week{Start: start})
+		}
+	}
+	return u
+}
@@ -0,0 +1,44 @@
+package clipperstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestUsageStats(t *testing.T) {
+	ride := func(month time.Month, day, hour int) transit.Transaction {
+		return transit.Transaction{
+			Timestamp:  time.Date(2018, month, day, hour, 0, 0, 0, time.UTC),
+			Type:       "Single-tag fare payment",
+			DebitCents: 250,
+		}
+	}
+	txns := []transit.Transaction{
+		ride(time.January, 2, 8),  // Tuesday
+		ride(time.January, 2, 17), // Tuesday
+		ride(time.January, 6, 12), // Saturday
+		{Timestamp: time.Date(2018, time.January, 6, 13, 0, 0, 0, time.UTC), Type: "Autoload", CreditCents: 5000},
+		ride(time.January, 23, 8), // Tuesday, two weeks later
+	}
+	u := UsageStats(txns)
+	if u.Heatmap[time.Tuesday][8] != 2 || u.Heatmap[time.Saturday][12] != 1 {
+		t.Errorf("bad heatmap: %v", u.Heatmap)
+	}
+	if u.ByHour[8] != 2 || u.ByDay[time.Tuesday] != 3 {
+		t.Errorf("bad totals: %v %v", u.ByHour, u.ByDay)
+	}
+	if u.WeekdayTrips != 3 || u.WeekendTrips != 1 {
+		t.Errorf("got %d weekday, %d weekend trips", u.WeekdayTrips, u.WeekendTrips)
+	}
+	if len(u.Weeks) != 4 {
+		t.Fatalf("expected 4 weeks, got %+v", u.Weeks)
+	}
+	if w := u.Weeks[0]; !w.Start.Equal(time.Date(2017, time.December, 31, 0, 0, 0, 0, time.UTC)) || w.WeekdayTrips != 2 || w.WeekendTrips != 1 || w.Days != 2 {
+		t.Errorf("first week: got %+v", w)
+	}
+	if w := u.Weeks[1]; w.WeekdayTrips != 0 || w.Days != 0 {
+		t.Errorf("empty week: got %+v", w)
+	}
+}
@@ -2,13 +2,13 @@ package main
 
 import (
 	"context"
-	"encoding/csv"
 	"flag"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clippercsv"
 )
 
 func checkError(err error, msg string) {
@@ -23,10 +23,9 @@ var password = flag.String("password", "", "Password")
 
 func writeTransactions(result map[clipper.Card]clipper.TransactionData) {
 	for card := range result {
-		f, err := os.Open(fmt.Sprintf("clipper-transactions-%d.csv", card.SerialNumber))
-		checkError(err, "opening file")
-		w := csv.NewWriter(f)
-		writeErr := w.WriteAll(result[card].Transactions)
+		f, err := os.Create(fmt.Sprintf("clipper-transactions-%d.csv", card.SerialNumber))
+		checkError(err, "creating file")
+		writeErr := result[card].WriteCSV(f, clippercsv.Options{})
 		checkError(writeErr, "writing CSV to "+f.Name())
 		closeErr := f.Close()
 		checkError(closeErr, "closing file")
@@ -0,0 +1,89 @@
+// The clipper-csv-server binary serves the PDF-to-CSV converter that runs at
+// clipper-csv.appspot.com, so you can run it yourself instead of uploading
+// statements to someone else's server.
+//
+// Usage:
+//
+//	clipper-csv-server [--addr=127.0.0.1:7065] [--rate=10] [--burst=5] [--forwarded-for]
+//
+// Browsers can upload a statement with the form at /. Other clients can POST
+// the PDF (or a multipart form with a "pdf" file) to /transactions and get
+// back CSV, or JSON if they send "Accept: application/json" or ?format=json:
+//
+//	curl --data-binary @statement.pdf -H 'Accept: application/json' \
+//		http://127.0.0.1:7065/transactions
+//
+// Uploads are parsed in memory and never written to disk. Each client can make
+// --burst uploads at once and --rate uploads a minute after that.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kevinburke/clipper/server"
+	"github.com/kevinburke/handlers"
+	"github.com/kevinburke/nacl"
+)
+
+// The server's Version.
+const Version = "0.6"
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:7065", "Address to listen on")
+	perMinute := flag.Float64("rate", 10, "Uploads each client can make per minute")
+	burst := flag.Int("burst", 5, "Uploads each client can make at once")
+	forwardedFor := flag.Bool("forwarded-for", false, "Identify clients by X-Forwarded-For; set only behind a proxy that sets it")
+	flag.Parse()
+	logger := handlers.Logger
+
+	// The key encrypts flash messages; $CLIPPER_SECRET_KEY keeps them
+	// readable across restarts.
+	var key nacl.Key
+	if hex := os.Getenv("CLIPPER_SECRET_KEY"); hex != "" {
+		var err error
+		key, err = nacl.Load(hex)
+		if err != nil {
+			logger.Error("Invalid $CLIPPER_SECRET_KEY", "err", err)
+			os.Exit(2)
+		}
+	} else {
+		key = nacl.NewKey()
+	}
+
+	limiter := server.NewLimiter(*perMinute, *burst)
+	limiter.ForwardedFor = *forwardedFor
+	var mux http.Handler = server.NewServeMux(key)
+	mux = limiter.Handler(mux)
+	mux = handlers.UUID(mux)
+	mux = handlers.Server(mux, "clipper-csv-server/"+Version)
+	mux = handlers.Log(mux)
+	mux = handlers.Duration(mux)
+
+	hs := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Minute,
+		WriteTimeout:      time.Minute,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		hs.Shutdown(shutdownCtx)
+	}()
+	logger.Info("Starting server", "addr", *addr)
+	if err := hs.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("server shut down", "err", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,15 @@
+// The clipper-lambda binary is an AWS Lambda function that downloads Clipper
+// statements and uploads them, with last month's digest, to S3. See the
+// serverless/lambda package for its configuration.
+//
+// Build it for the provided.al2023 runtime:
+//
+//	GOOS=linux GOARCH=arm64 go build -tags lambda.norpc -o bootstrap ./cmd/clipper-lambda
+//	zip clipper-lambda.zip bootstrap
+package main
+
+import "github.com/kevinburke/clipper/serverless/lambda"
+
+func main() {
+	lambda.Start()
+}
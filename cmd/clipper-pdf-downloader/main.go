@@ -5,9 +5,14 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/keychain"
+	"github.com/kevinburke/clipper/quota"
+	"github.com/kevinburke/clipper/store"
+	"github.com/kevinburke/clipper/transit"
 	"gopkg.in/yaml.v2"
 )
 
@@ -38,15 +43,36 @@ func loadConfig(filename string) (*Config, error) {
 var email = flag.String("email", "", "Login email (optional if using --user or --all)")
 var password = flag.String("password", "", "Password (optional if using --user or --all)")
 var outputDir = flag.String("output", "pdfs", "Output directory for PDF files")
-var startDate = flag.String("start", "", "Start date for transaction range (YYYY-MM-DD format, optional)")
-var endDate = flag.String("end", "", "End date for transaction range (YYYY-MM-DD format, optional)")
-var lastMonth = flag.Bool("last-month", false, "Download last month's transactions (overrides start/end dates)")
+var startDate = flag.String("start", "", "Start date for transaction range (YYYY-MM-DD format, Pacific time, optional)")
+var endDate = flag.String("end", "", "Last date for transaction range, included (YYYY-MM-DD format, Pacific time, optional); the range can cover at most a year")
+var lastMonth = flag.Bool("last-month", false, "Download last month's transactions, by Pacific time (overrides start/end dates)")
 var dryRun = flag.Bool("dry-run", false, "Test run without downloading PDFs (avoids API limits)")
 var user = flag.String("user", "", "Username from config file (e.g., --user=kaveh)")
 var all = flag.Bool("all", false, "Download for all users in config file")
 var configFile = flag.String("config", "config.yml", "Path to config file")
+var userAgent = flag.String("user-agent", os.Getenv("CLIPPER_USER_AGENT"), "User-Agent to send (defaults to $CLIPPER_USER_AGENT, or a recent Chrome)")
+var rotateUserAgent = flag.Bool("rotate-user-agent", false, "Log in as a browser picked at random from recent ones")
+var http1 = flag.Bool("http1", false, "Use HTTP/1.1 only, for when the Clipper site's firewall mishandles HTTP/2")
+var pins = flag.String("pin", os.Getenv("CLIPPER_PINS"), "Comma-separated base64 SHA-256 hashes of public keys; connect only to servers whose certificate chain has one (defaults to $CLIPPER_PINS)")
+var useKeychain = flag.Bool("keychain", false, "Keep the login session in the system keychain, and reuse it instead of logging in while it lasts")
+var sessionFile = flag.String("session-file", "", "Keep the login session in this file, readable only by you, and reuse it instead of logging in while it lasts; for systems without a keychain")
+var site = flag.String("site", os.Getenv("CLIPPER_SITE_URL"), "URL of the Clipper site, like http://127.0.0.1:7080 for clippermock (defaults to $CLIPPER_SITE_URL, or clippercard.com)")
+var minInterval = flag.Duration("min-interval", clipper.DefaultRateLimit, "Wait at least this long between requests to the Clipper site, across all users, after a burst of --burst requests")
+var burst = flag.Int("burst", clipper.DefaultBurst, "Number of requests to send at once before pacing them by --min-interval")
+var concurrency = flag.Int("concurrency", 1, "Download this many of an account's cards at once; requests are still paced by --min-interval")
+var checkStatements = flag.Bool("check-statements", false, "Read each downloaded PDF, and refuse to save one that isn't a Clipper statement")
+var stateDir = flag.String("state-dir", "", "Directory to keep each account's daily request and download counts in, shared with other runs (defaults to $CLIPPER_STATE_DIR, or ~/.local/state/clipper)")
+var maxRequests = flag.Int("max-requests", quota.DefaultLimits.Requests, "Most requests to make for an account in a day, across runs; 0 for no limit")
+var maxDownloads = flag.Int("max-downloads", quota.DefaultLimits.Downloads, "Most statements to download for an account in a day, across runs; 0 for no limit")
+var institution = flag.Bool("institution", false, "Treat the account as an employer/institutional account and download every card it manages")
+var syncNew = flag.Bool("sync", false, "Download only what's new for each card since the last --sync, keeping track in sync.json in the output directory; safe to run from cron every day")
+var dbPath = flag.String("db", "", "SQLite database to add the downloaded transactions to, like clipper.db; it keeps them all, without duplicates, across downloads")
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "parse" {
+		parseStatements(os.Args[2:])
+		return
+	}
 	flag.Parse()
 	// Determine which users to process
 	var usersToProcess []struct {
@@ -54,18 +80,18 @@ func main() {
 		email    string
 		password string
 	}
-	
+
 	// Check for conflicting flags
 	if (*user != "" && *all) || (*user != "" && (*email != "" || *password != "")) || (*all && (*email != "" || *password != "")) {
 		fmt.Fprintf(os.Stderr, "Cannot use --user, --all, and manual credentials together\n")
 		os.Exit(2)
 	}
-	
+
 	// Default to --all if no specific user method is specified
 	if *user == "" && !*all && *email == "" && *password == "" {
 		*all = true
 	}
-	
+
 	if *user != "" || *all {
 		config, err := loadConfig(*configFile)
 		if err != nil {
@@ -73,7 +99,7 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Make sure to copy config.example.yml to config.yml and fill in your credentials\n")
 			os.Exit(2)
 		}
-		
+
 		if *user != "" {
 			userData, exists := config.Users[*user]
 			if !exists {
@@ -105,7 +131,8 @@ func main() {
 		if *email == "" || *password == "" {
 			fmt.Fprintf(os.Stderr, "Please provide credentials\n")
 			fmt.Fprintf(os.Stderr, "Usage: %s [--user=username] [--all] OR [--email=email --password=password] [options]\n", os.Args[0])
-			fmt.Fprintf(os.Stderr, "Options: [--output=./pdfs] [--start=2024-01-01] [--end=2024-01-31] [--last-month] [--dry-run]\n")
+			fmt.Fprintf(os.Stderr, "Options: [--output=./pdfs] [--start=2024-01-01] [--end=2024-01-31] [--last-month] [--sync] [--dry-run] [--db=clipper.db]\n")
+			fmt.Fprintf(os.Stderr, "To convert downloaded PDFs to CSV or JSON: %s parse [--dir=pdfs] [--output=csv] [--format=csv|json] [--merge] [--db=clipper.db]\n", os.Args[0])
 			os.Exit(2)
 		}
 		usersToProcess = append(usersToProcess, struct {
@@ -115,17 +142,21 @@ func main() {
 		}{"manual", *email, *password})
 	}
 
-	// Handle last month flag
-	finalStartDate := *startDate
-	finalEndDate := *endDate
-	if *lastMonth {
-		now := time.Now()
-		firstOfThisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		lastMonthEnd := firstOfThisMonth.AddDate(0, 0, -1)
-		lastMonthStart := time.Date(lastMonthEnd.Year(), lastMonthEnd.Month(), 1, 0, 0, 0, 0, lastMonthEnd.Location())
-		finalStartDate = lastMonthStart.Format("2006-01-02")
-		finalEndDate = lastMonthEnd.Format("2006-01-02")
-		fmt.Printf("Using last month date range: %s to %s\n", finalStartDate, finalEndDate)
+	// Handle last month flag, and check the dates before logging in
+	var dates clipper.DateRange
+	if *syncNew {
+		if *lastMonth || *startDate != "" || *endDate != "" {
+			fmt.Fprintf(os.Stderr, "Cannot use --sync with --start, --end or --last-month; it picks the dates itself\n")
+			os.Exit(2)
+		}
+	} else if *lastMonth {
+		// Last month in California, not wherever this is running.
+		dates.Start, dates.End = transit.LastMonth(time.Now())
+		fmt.Printf("Using last month date range: %s\n", dates)
+	} else {
+		var err error
+		dates, err = clipper.ParseDateRange(*startDate, *endDate)
+		checkError(err, "checking --start and --end")
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute) // Longer timeout for multiple users
@@ -135,34 +166,169 @@ func main() {
 		fmt.Println("[DRY RUN] Testing PDF download parameters...")
 	} else {
 		fmt.Println("Downloading PDF transaction reports...")
-		// Create output directory if it doesn't exist
+	}
+	// Create output directory if it doesn't exist; --sync keeps its state
+	// there even on a dry run.
+	if !*dryRun || *syncNew {
 		if err := os.MkdirAll(*outputDir, 0755); err != nil {
 			checkError(err, "creating output directory")
 		}
 	}
-	
+	var archive *store.Store
+	if *syncNew {
+		var err error
+		archive, err = store.Open(*outputDir)
+		checkError(err, "opening the output directory")
+	}
+
+	transport := clipper.WithTransportOptions(clipper.TransportOptions{DisableHTTP2: *http1})
+	ua := clipper.WithUserAgent(*userAgent)
+	if *rotateUserAgent {
+		if *userAgent != "" {
+			fmt.Fprintf(os.Stderr, "Cannot use --user-agent and --rotate-user-agent together\n")
+			os.Exit(2)
+		}
+		ua = clipper.WithUserAgentRotation()
+	}
+
+	// One pacer for every user, so a run over several accounts is as polite
+	// as one over a single account.
+	pacer := clipper.NewPacer(*minInterval, *burst)
+	clientOpts := []clipper.Option{ua, transport, clipper.WithPacer(pacer), clipper.WithConcurrency(*concurrency)}
+	if *pins != "" {
+		clientOpts = append(clientOpts, clipper.WithPinnedCertificates(strings.Split(*pins, ",")...))
+	}
+	if *useKeychain && *sessionFile != "" {
+		fmt.Fprintf(os.Stderr, "Cannot use --keychain and --session-file together\n")
+		os.Exit(2)
+	}
+	if *useKeychain {
+		clientOpts = append(clientOpts, clipper.WithSessionStore(keychain.New("clipper-session")))
+	}
+	if *sessionFile != "" {
+		clientOpts = append(clientOpts, clipper.WithSessionStore(clipper.NewFileSessionStore(*sessionFile)))
+	}
+	if *site != "" {
+		clientOpts = append(clientOpts, clipper.WithSiteURL(*site))
+	}
+	if *checkStatements {
+		clientOpts = append(clientOpts, clipper.WithStatementCheck())
+	}
+	if *stateDir == "" {
+		dir, err := quota.DefaultDir()
+		checkError(err, "finding the state directory")
+		*stateDir = dir
+	}
+	tracker, err := quota.Open(*stateDir)
+	checkError(err, "opening the quota tracker")
+	tracker.Limits = quota.Limits{Requests: *maxRequests, Downloads: *maxDownloads}
+	clientOpts = append(clientOpts, clipper.WithQuota(tracker))
+
 	// Process each user
-	for i, userInfo := range usersToProcess {
+	failed := false
+	for _, userInfo := range usersToProcess {
 		if len(usersToProcess) > 1 {
 			fmt.Printf("\n=== Processing user: %s (%s) ===\n", userInfo.name, userInfo.email)
 		}
-		
-		client, err := clipper.NewClient(userInfo.email, userInfo.password)
-		checkError(err, fmt.Sprintf("creating client for user %s", userInfo.name))
-		
+
+		left, err := tracker.Remaining(userInfo.email)
+		checkError(err, "reading the quota")
+		if left.Downloads == 0 || left.Requests == 0 {
+			fmt.Fprintf(os.Stderr, "Skipping user %s: no requests or downloads left today (see --max-requests and --max-downloads)\n", userInfo.name)
+			failed = true
+			continue
+		}
+
 		// Download raw PDFs (or dry run)
-		err = client.DownloadPDFs(ctx, *outputDir, finalStartDate, finalEndDate, *dryRun)
-		checkError(err, fmt.Sprintf("downloading PDFs for user %s", userInfo.name))
-		
-		if i < len(usersToProcess)-1 {
-			fmt.Println("Waiting 2 seconds before next user...")
-			time.Sleep(2 * time.Second)
+		var client downloader
+		if *institution {
+			client, err = clipper.NewInstitutionalClient(userInfo.email, userInfo.password, clientOpts...)
+		} else {
+			opts := clientOpts
+			if !*dryRun {
+				opts = append([]clipper.Option{auditEvents(*outputDir, userInfo.name)}, clientOpts...)
+			}
+			client, err = clipper.NewClient(userInfo.email, userInfo.password, opts...)
 		}
+		checkError(err, fmt.Sprintf("creating client for user %s", userInfo.name))
+		if *syncNew {
+			var stmts []clipper.SyncedStatement
+			stmts, err = client.Sync(ctx, *outputDir, archive, *dryRun)
+			for _, st := range stmts {
+				if *dryRun {
+					fmt.Printf("[DRY RUN] Would download PDF for card %d (%s) with date range: %s\n", st.Card.SerialNumber, st.Card.Nickname, st.Range)
+				} else {
+					fmt.Printf("Saved PDF: %s (Card: %s)\n", st.Path, st.Card.Nickname)
+				}
+			}
+		} else {
+			err = client.DownloadRange(ctx, *outputDir, dates, *dryRun)
+		}
+		failed = checkDownload(err, userInfo.name) || failed
 	}
 
 	if *dryRun {
 		fmt.Println("\n[DRY RUN] Test completed successfully.")
 	} else {
 		fmt.Printf("\nPDF downloads completed for %d user(s). Files saved to: %s\n", len(usersToProcess), *outputDir)
+		if *dbPath != "" {
+			s, err := store.Open(*outputDir)
+			checkError(err, "opening the output directory")
+			txns, err := s.TransactionsContext(ctx)
+			checkError(err, "parsing the downloaded statements")
+			addToDB(*dbPath, txns)
+		}
+	}
+	if failed {
+		fmt.Fprintf(os.Stderr, "Some cards or users failed to download; see the errors above\n")
+		os.Exit(2)
+	}
+}
+
+// downloader is what Client and InstitutionalClient have in common.
+type downloader interface {
+	DownloadRange(ctx context.Context, outputDir string, r clipper.DateRange, dryRun bool) error
+	Sync(ctx context.Context, outputDir string, state clipper.SyncState, dryRun bool) ([]clipper.SyncedStatement, error)
+}
+
+// addToDB adds txns to the SQLite database at path, creating it if need be.
+func addToDB(path string, txns []clipper.Transaction) {
+	db, err := store.OpenDB(path)
+	checkError(err, "opening the database")
+	n, err := db.Add(txns)
+	checkError(err, "adding transactions to "+path)
+	checkError(db.Close(), "closing the database")
+	fmt.Printf("Added %d new transactions to %s\n", n, path)
+}
+
+// checkDownload exits if the download for user failed outright. If only some
+// cards failed, it prints the errors and reports true, so the other cards'
+// PDFs, and the other users', are kept.
+func checkDownload(err error, user string) bool {
+	if err == nil {
+		return false
+	}
+	if len(clipper.CardErrors(err)) == 0 {
+		checkError(err, fmt.Sprintf("downloading PDFs for user %s", user))
 	}
-}
\ No newline at end of file
+	fmt.Fprintf(os.Stderr, "Error downloading PDFs for user %s:\n%v\n", user, err)
+	return true
+}
+
+// auditEvents records the logins and downloads made for account in the
+// audit log of the archive in dir.
+func auditEvents(dir, account string) clipper.Option {
+	return clipper.WithEvents(func(e clipper.Event) {
+		s, err := store.Open(dir)
+		if err != nil {
+			return
+		}
+		ae := store.AuditEvent{Actor: "cli", Action: e.Action, Account: account, Card: e.Card, Detail: e.Path, Outcome: store.AuditOK}
+		if e.Err != nil {
+			ae.Outcome, ae.Error = store.AuditError, e.Err.Error()
+		}
+		if err := s.Audit(ae); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: couldn't write the audit log: %v\n", err)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clippercsv"
+	"github.com/kevinburke/clipper/export"
+	"github.com/kevinburke/clipper/store"
+)
+
+// parseStatements is the parse command: it reads the PDFs in a directory of
+// downloads and writes their transactions as CSV, or another format from
+// --format, one file per card, or one for every card with --merge.
+func parseStatements(args []string) {
+	fs := flag.NewFlagSet("parse", flag.ExitOnError)
+	dir := fs.String("dir", "pdfs", "Directory of downloaded PDF statements")
+	output := fs.String("output", "csv", "Output directory for the files")
+	format := fs.String("format", "csv", "Output format: csv, json (with typed times and amounts), jsonl, ofx or beancount")
+	merge := fs.Bool("merge", false, "Write every card's transactions to one file, like clipper-transactions.csv, instead of one per card")
+	db := fs.String("db", "", "Also add the transactions to this SQLite database, like clipper.db")
+	var csvOpts clippercsv.Options
+	fs.BoolVar(&csvOpts.BOM, "bom", false, "Start each CSV file with a byte order mark, so Excel reads it as UTF-8")
+	fs.BoolVar(&csvOpts.CRLF, "crlf", false, "End CSV lines with \\r\\n instead of \\n")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s parse [--dir=pdfs] [--output=csv] [--format=csv|json|jsonl|ofx|beancount] [--merge] [--db=clipper.db] [--bom] [--crlf]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if !slices.Contains(export.Formats, *format) {
+		fmt.Fprintf(os.Stderr, "Unknown format %q; use one of %s\n", *format, strings.Join(export.Formats, ", "))
+		os.Exit(2)
+	}
+
+	s, err := store.Open(*dir)
+	checkError(err, "opening the statement directory")
+	paths, err := s.Statements()
+	checkError(err, "listing statements")
+	if len(paths) == 0 {
+		fmt.Fprintf(os.Stderr, "No PDF statements found in %s\n", *dir)
+		os.Exit(2)
+	}
+
+	// One bad statement doesn't stop the rest; its transactions are
+	// left out, and the command fails at the end.
+	failed := false
+	results, errs := clipper.ParseAll(context.Background(), paths, 0)
+	lists := make([][]clipper.Transaction, 0, len(paths))
+	for i, path := range paths {
+		if errs[i] == nil {
+			var txns []clipper.Transaction
+			txns, errs[i] = results[i].ParsedTransactions()
+			for j := range txns {
+				txns[j].Source = path
+			}
+			lists = append(lists, txns)
+		}
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", path, errs[i])
+			failed = true
+		}
+	}
+	// Statements downloaded at different times overlap.
+	txns := store.Merge(lists...)
+
+	checkError(os.MkdirAll(*output, 0755), "creating output directory")
+	var files []string
+	if *merge {
+		name := filepath.Join(*output, "clipper-transactions."+*format)
+		checkError(writeFile(name, *format, txns, csvOpts), "writing "+name)
+		files = append(files, name)
+	} else {
+		byCard := make(map[int64][]clipper.Transaction)
+		for _, t := range txns {
+			byCard[t.CardSerial] = append(byCard[t.CardSerial], t)
+		}
+		cards := make([]int64, 0, len(byCard))
+		for card := range byCard {
+			cards = append(cards, card)
+		}
+		sort.Slice(cards, func(i, j int) bool { return cards[i] < cards[j] })
+		for _, card := range cards {
+			name := filepath.Join(*output, fmt.Sprintf("clipper-transactions-%d.%s", card, *format))
+			checkError(writeFile(name, *format, byCard[card], csvOpts), "writing "+name)
+			files = append(files, name)
+		}
+	}
+	if err := s.Audit(store.AuditEvent{Actor: "cli", Action: store.AuditExport, Detail: fmt.Sprintf("%d transactions as %s to %s", len(txns), *format, *output), Outcome: store.AuditOK}); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: couldn't write the audit log: %v\n", err)
+	}
+	fmt.Printf("Wrote %d transactions from %d statements to %d file(s) in %s\n", len(txns), len(paths), len(files), *output)
+	if *db != "" {
+		addToDB(*db, txns)
+	}
+	if failed {
+		fmt.Fprintf(os.Stderr, "Some statements couldn't be parsed; see the errors above\n")
+		os.Exit(2)
+	}
+}
+
+// writeFile writes txns to the file name in format, one of export.Formats.
+func writeFile(name, format string, txns []clipper.Transaction, csvOpts clippercsv.Options) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	enc, err := export.New(format, f, export.Options{CSV: csvOpts})
+	if err != nil {
+		f.Close()
+		return err
+	}
+	for _, t := range txns {
+		if err := enc.Encode(t); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
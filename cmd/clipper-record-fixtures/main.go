@@ -0,0 +1,44 @@
+// The clipper-record-fixtures command downloads the account and card pages
+// for a Clipper account, scrubs personal data from them, and saves them as
+// test fixtures.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kevinburke/clipper"
+)
+
+func checkError(err error, msg string) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %s: %v\n", msg, err)
+		os.Exit(2)
+	}
+}
+
+var email = flag.String("email", "", "Login email")
+var password = flag.String("password", "", "Password")
+var outputDir = flag.String("output", "testdata/fixtures", "Directory to write fixtures to")
+
+func main() {
+	flag.Parse()
+	if *email == "" || *password == "" {
+		fmt.Fprintf(os.Stderr, "Please provide an email and a password\n")
+		os.Exit(2)
+	}
+	checkError(os.MkdirAll(*outputDir, 0755), "creating output directory")
+	client, err := clipper.NewClient(*email, *password)
+	checkError(err, "creating client")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	files, err := client.RecordFixtures(ctx, *outputDir)
+	checkError(err, "recording fixtures")
+	for _, f := range files {
+		fmt.Println("wrote", f)
+	}
+	fmt.Println("Check the files by hand for personal data before committing them.")
+}
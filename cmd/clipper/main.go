@@ -0,0 +1,1984 @@
+// The clipper command works with an archive of downloaded Clipper statements.
+//
+// Usage:
+//
+//	clipper report [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [--plain]
+//	clipper duplicates [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [--window=5m]
+//	clipper journeys [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [--format=text|csv|json|timeline] [--bom] [--crlf]
+//	clipper usage [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [--json]
+//	clipper alerts [--dir=pdfs] [--days=7] [--webhook=URL] [--exec=PROGRAM]
+//	clipper expense [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [--rules=rules.yml] [--format=csv|pdf] [--output=FILE] [--bom] [--crlf]
+//	clipper export [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [--format=csv|json|jsonl|ofx|beancount] [--account=ACCOUNT] [--beancount-rules=FILE] [--output=FILE] [--bom] [--crlf]
+//	clipper benefits [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [--match=commuter,benefit]
+//	clipper carbon [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [--csv] [--bom] [--crlf]
+//	clipper recommend [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [--recent=3] [--products=products.yml]
+//	clipper review [--dir=pdfs] [--year=YYYY] [--format=markdown|html] [--output=FILE]
+//	clipper household [--dir=pdfs] [--config=config.yml] [--month=YYYY-MM] [--webhook=URL] [--exec=PROGRAM] [--chart]
+//	clipper chart [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [--kind=spend|agencies|balance] [--card=SERIAL] [--format=svg|png] [--output=FILE]
+//	clipper compare --before=YYYY-MM-DD..YYYY-MM-DD --after=YYYY-MM-DD..YYYY-MM-DD [--dir=pdfs]
+//	clipper discounts [--dir=pdfs] [--config=config.yml] [--fares=fares.yml]
+//	clipper tag [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [ID=work|personal|other ...]
+//	clipper caltrain [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [--products=products.yml] [--gopass=435]
+//	clipper latenight [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [--hours=0-5] [--card=SERIAL]
+//	clipper fares [--dir=pdfs] [--start=YYYY-MM-DD] [--end=YYYY-MM-DD] [--config=config.yml] [--fares=fares.yml] [--min=0.01] [--dump]
+//	clipper serve [--dir=pdfs] [--config=config.yml] [--addr=127.0.0.1:7066] [--grpc=127.0.0.1:7067] [--graphql] [--plaid] [--token=TOKEN] [--tokens=tokens.yml] [--sync-every=24h] [--shutdown-timeout=2m] [--vault=vault.json] [--slack-secret=SECRET] [--slack-webhook=URL] [--user-agent=UA] [--rotate-user-agent] [--http1] [--pin=PIN,...]
+//	clipper serve admin [--server=http://127.0.0.1:7066] [--token=TOKEN] jobs | run-job NAME | accounts | retry | audit [FILTER=VALUE ...] | tokens | create-token NAME SCOPE,... [CARD ...] | revoke-token NAME | rotate-token NAME
+//	clipper budget [--dir=pdfs] [--config=config.yml] [--thresholds=50,80,100] [--days=1] [--webhook=URL] [--exec=PROGRAM]
+//	clipper homeassistant --broker=tcp://HOST:1883 [--dir=pdfs] [--username=USER] [--password=PASS] [--discovery-prefix=homeassistant] [--topic=clipper]
+//	clipper grafana [--dir=pdfs] [--db=postgres|sqlite] [--output=clipper-grafana]
+//	clipper scrub [--seed=N] [--shift-days=N] [--format=pdf|csv] [--output=FILE] [--bom] [--crlf] STATEMENT.pdf
+//	clipper vault [--vault=vault.json] keygen | list | add NAME EMAIL [CARD ...] | remove NAME
+//	clipper digest [--dir=pdfs] [--config=config.yml] [--month=YYYY-MM] [--webhook=URL] [--exec=PROGRAM] [--bom] [--crlf]
+//
+// Messages are in English, Spanish or Chinese: set --lang=es or --lang=zh
+// before the command, or $CLIPPER_LANG, or else the locale picks.
+//
+// Dates and months are Pacific time, wherever clipper runs: --start=2024-03-01
+// starts at midnight in California, and the latest month is the latest
+// Pacific calendar month.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/api"
+	"github.com/kevinburke/clipper/chart"
+	"github.com/kevinburke/clipper/clippercsv"
+	"github.com/kevinburke/clipper/clipperstats"
+	"github.com/kevinburke/clipper/expense"
+	"github.com/kevinburke/clipper/export"
+	"github.com/kevinburke/clipper/grafana"
+	"github.com/kevinburke/clipper/gtfs"
+	"github.com/kevinburke/clipper/homeassistant"
+	"github.com/kevinburke/clipper/keychain"
+	"github.com/kevinburke/clipper/notify"
+	"github.com/kevinburke/clipper/queue"
+	"github.com/kevinburke/clipper/rpc"
+	"github.com/kevinburke/clipper/store"
+	"github.com/kevinburke/clipper/transit"
+	"github.com/kevinburke/clipper/vault"
+	"github.com/kevinburke/handlers"
+	"github.com/kevinburke/nacl"
+	"golang.org/x/term"
+	"google.golang.org/grpc"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func checkError(err error, msg string) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("Error %s: %v\n"), tr(msg), err)
+		os.Exit(2)
+	}
+}
+
+// commands are the commands printUsage lists, with what they do.
+var commands = []struct{ name, description string }{
+	{"report", "Summarize trips and spending from downloaded statements"},
+	{"duplicates", "List likely double-charges, with the statements they appear in"},
+	{"journeys", "List journeys from origin to destination, with transfers joined"},
+	{"usage", "Show when you ride, by hour, day of the week and week"},
+	{"alerts", "Check recent activity for anomalies and send alerts"},
+	{"expense", "Build a reimbursement report of work rides as CSV or PDF"},
+	{"export", "Write every transaction as CSV, JSON, JSON Lines, OFX or Beancount, for spreadsheets, scripts and finance programs"},
+	{"benefits", "Reconcile commuter benefit loads with spending and IRS limits"},
+	{"carbon", "Estimate CO2 from rides, compared with driving"},
+	{"recommend", "Compare passes and other fare products with paying as you go"},
+	{"review", "Write a year-in-review summary as Markdown or HTML"},
+	{"household", "Total a month's spending across everyone's cards, per person"},
+	{"compare", "Compare fares between two periods, adjusting for how much you rode"},
+	{"discounts", "Check rides on youth, senior, RTC and START cards for full-fare charges"},
+	{"chart", "Draw spend per month, spend by agency or a card's balance as SVG or PNG"},
+	{"tag", "List rides with their purpose, or tag rides as work, personal or other"},
+	{"caltrain", "Show Caltrain spend per zone pair and compare zone passes and GoPass"},
+	{"latenight", "List rides taken late at night, with where they started and ended"},
+	{"fares", "Check every ride against posted fares and list overcharges worth disputing"},
+	{"serve", "Serve a dashboard, a JSON REST API and Prometheus metrics, with token auth"},
+	{"budget", "Alert when month-to-date spending crosses budget thresholds"},
+	{"digest", "Mail a month's transactions, balances and unusual activity, with CSV and HTML"},
+	{"homeassistant", "Publish each card's balance and latest transaction to Home Assistant over MQTT"},
+	{"grafana", "Write a database schema, data, Grafana dashboard and Docker Compose setup"},
+	{"scrub", "Mask the card, dates and locations on a statement, to attach to a bug report"},
+	{"vault", "Store Clipper credentials for several accounts, encrypted, for serve to sync"},
+}
+
+func printUsage() {
+	w := tabwriter.NewWriter(os.Stderr, 0, 8, 2, ' ', 0)
+	fmt.Fprint(w, tr("usage: clipper [--lang=en|es|zh] <command> [flags]\n\nCommands:\n"))
+	for _, c := range commands {
+		fmt.Fprintf(w, "\t%s\t%s\n", c.name, tr(c.description))
+	}
+	w.Flush()
+}
+
+func main() {
+	lang := flag.String("lang", os.Getenv("CLIPPER_LANG"), "Language to print messages in: en, es or zh (defaults to $CLIPPER_LANG, or the locale)")
+	flag.Usage = printUsage
+	flag.Parse()
+	if *lang != "" {
+		if err := setLanguage(*lang); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	} else {
+		// Other languages in the locale fall back to English.
+		setLanguage(defaultLanguage())
+	}
+	if flag.NArg() < 1 {
+		printUsage()
+		os.Exit(2)
+	}
+	switch flag.Arg(0) {
+	case "report":
+		report(flag.Args()[1:])
+	case "duplicates":
+		duplicates(flag.Args()[1:])
+	case "journeys":
+		journeys(flag.Args()[1:])
+	case "usage":
+		usage(flag.Args()[1:])
+	case "alerts":
+		alerts(flag.Args()[1:])
+	case "expense":
+		expenseReport(flag.Args()[1:])
+	case "export":
+		exportTransactions(flag.Args()[1:])
+	case "benefits":
+		benefits(flag.Args()[1:])
+	case "carbon":
+		carbon(flag.Args()[1:])
+	case "recommend":
+		recommend(flag.Args()[1:])
+	case "review":
+		review(flag.Args()[1:])
+	case "household":
+		household(flag.Args()[1:])
+	case "compare":
+		compare(flag.Args()[1:])
+	case "discounts":
+		discounts(flag.Args()[1:])
+	case "chart":
+		drawChart(flag.Args()[1:])
+	case "tag":
+		tag(flag.Args()[1:])
+	case "caltrain":
+		caltrain(flag.Args()[1:])
+	case "latenight":
+		lateNight(flag.Args()[1:])
+	case "fares":
+		fares(flag.Args()[1:])
+	case "serve":
+		serve(flag.Args()[1:])
+	case "budget":
+		budget(flag.Args()[1:])
+	case "digest":
+		digest(flag.Args()[1:])
+	case "homeassistant":
+		homeAssistant(flag.Args()[1:])
+	case "grafana":
+		grafanaBundle(flag.Args()[1:])
+	case "scrub":
+		scrub(flag.Args()[1:])
+	case "vault":
+		vaultCommand(flag.Args()[1:])
+	default:
+		fmt.Fprintf(os.Stderr, tr("unknown command %q\n"), flag.Arg(0))
+		printUsage()
+		os.Exit(2)
+	}
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return clipper.ParseDate(s)
+}
+
+// gtfsFlag collects --gtfs AGENCY=PATH flags.
+type gtfsFlag []string
+
+func (g *gtfsFlag) String() string { return strings.Join(*g, ",") }
+
+func (g *gtfsFlag) Set(v string) error {
+	if !strings.Contains(v, "=") {
+		return fmt.Errorf(tr("want AGENCY=PATH, got %q"), v)
+	}
+	*g = append(*g, v)
+	return nil
+}
+
+// archiveFlags are the flags shared by commands that read the statement
+// archive.
+type archiveFlags struct {
+	cmd             string
+	dir, start, end *string
+	gtfs            *gtfsFlag
+}
+
+// addCSVFlags adds the flags that control how a command writes CSV.
+func addCSVFlags(fs *flag.FlagSet) *clippercsv.Options {
+	o := new(clippercsv.Options)
+	fs.BoolVar(&o.BOM, "bom", false, "Start CSV output with a byte order mark, so Excel reads it as UTF-8")
+	fs.BoolVar(&o.CRLF, "crlf", false, "End CSV lines with \\r\\n instead of \\n")
+	return o
+}
+
+func addArchiveFlags(fs *flag.FlagSet) archiveFlags {
+	a := archiveFlags{
+		cmd:   fs.Name(),
+		dir:   fs.String("dir", "pdfs", "Directory of downloaded statement PDFs"),
+		start: fs.String("start", "", "Only include transactions on or after this date (YYYY-MM-DD, Pacific time)"),
+		end:   fs.String("end", "", "Only include transactions before this date (YYYY-MM-DD, Pacific time)"),
+		gtfs:  new(gtfsFlag),
+	}
+	fs.Var(a.gtfs, "gtfs", "Load station metadata from a GTFS feed, as AGENCY=PATH (for example BART=bart.zip); may be repeated")
+	return a
+}
+
+// load returns the transactions in the archive within the requested dates,
+// after registering stations from any GTFS feeds.
+func (a archiveFlags) load() []clipper.Transaction {
+	for _, v := range *a.gtfs {
+		agency, path, _ := strings.Cut(v, "=")
+		feed, err := gtfs.Load(path, clipper.Agency(agency))
+		checkError(err, fmt.Sprintf(tr("loading GTFS feed %s"), path))
+		feed.Register()
+	}
+	from, err := parseDate(*a.start)
+	checkError(err, "parsing start date")
+	to, err := parseDate(*a.end)
+	checkError(err, "parsing end date")
+
+	s, err := store.Open(*a.dir)
+	checkError(err, "opening statement directory")
+	txns, err := s.Transactions()
+	a.audit(store.AuditParse, "clipper "+a.cmd, err)
+	checkError(err, "loading transactions")
+	return store.Between(txns, from, to)
+}
+
+// audit records that the command did action in the archive's audit log. A
+// read-only archive can't keep one, so failing to write it isn't fatal.
+func (a archiveFlags) audit(action, detail string, err error) {
+	s, serr := store.Open(*a.dir)
+	if serr != nil {
+		return
+	}
+	e := store.AuditEvent{Actor: "cli", Action: action, Detail: detail, Outcome: store.AuditOK}
+	if err != nil {
+		e.Outcome, e.Error = store.AuditError, err.Error()
+	}
+	if err := s.Audit(e); err != nil {
+		fmt.Fprintf(os.Stderr, tr("warning: couldn't write the audit log: %v\n"), err)
+	}
+}
+
+// tagger returns the trip tagging rules and overrides saved in the archive.
+func (a archiveFlags) tagger() clipperstats.Tagger {
+	s, err := store.Open(*a.dir)
+	checkError(err, "opening statement directory")
+	t, err := s.Tagger()
+	checkError(err, "loading trip tags")
+	return t
+}
+
+func report(args []string) {
+	fs := newFlagSet("report")
+	archive := addArchiveFlags(fs)
+	plain := fs.Bool("plain", false, "Print plain text even when writing to a terminal")
+	fs.Parse(args)
+
+	txns := archive.load()
+	if len(txns) == 0 {
+		fmt.Println(tr("No transactions found."))
+		return
+	}
+
+	sum := clipperstats.Summarize(txns)
+	if !*plain && isTerminal(os.Stdout) {
+		ttyReport(txns, sum)
+		return
+	}
+	fmt.Printf(tr("Transactions from %s to %s\n\n"), sum.First.Format("2006-01-02"), sum.Last.Format("2006-01-02"))
+	fmt.Printf(tr("Trips:                 %d\n"), sum.Trips)
+	fmt.Printf(tr("Trips per week:        %.1f\n"), sum.TripsPerWeek)
+	fmt.Printf(tr("Total spend:           %s\n"), sum.SpendCents)
+	fmt.Printf(tr("Average cost per trip: %s\n"), sum.AverageCostPerTripCents)
+	fmt.Printf(tr("Journeys:              %d (%d transfers within an agency, %d between agencies)\n"), sum.Journeys, sum.IntraAgencyTransfers, sum.InterAgencyTransfers)
+	fmt.Printf(tr("Transfer credits:      %s\n"), sum.TransferCreditCents)
+	fmt.Printf(tr("Average journey fare:  %s\n"), sum.AverageJourneyFareCents)
+
+	fmt.Print(tr("\nSpend per month:\n"))
+	for _, m := range sum.Months {
+		fmt.Printf(tr("  %s  %9s  %3d trips\n"), m.Start.Format("Jan 2006"), m.SpendCents, m.Trips)
+	}
+	if len(sum.Agencies) > 0 {
+		fmt.Print(tr("\nSpend per agency:\n"))
+		for _, am := range sum.Agencies {
+			agency := string(am.Agency)
+			if am.Agency == clipper.AgencyUnknown {
+				agency = tr("Unknown")
+			}
+			fmt.Printf(tr("  %s  %-20s  %9s  %3d trips\n"), am.Start.Format("Jan 2006"), agency, am.SpendCents, am.Trips)
+		}
+	}
+	if len(sum.MissedTagOffs) > 0 {
+		fmt.Print(tr("\nMissed tag-offs:\n"))
+		for _, q := range sum.MissedTagOffs {
+			fmt.Printf(tr("  %s  %3d rides  %9s overcharged\n"), q.Name(), q.Count, q.OverchargeCents)
+		}
+	}
+	fmt.Print(tr("\nBalance:\n"))
+	for _, card := range clipperstats.Cards(txns) {
+		p := clipperstats.ProjectRunOut(txns, card, 0)
+		fmt.Printf(tr("  Card %d  %9s as of %s"), card, p.BalanceCents, p.AsOf.Format("2006-01-02"))
+		if p.RunOut.IsZero() {
+			fmt.Printf("\n")
+		} else {
+			fmt.Printf(tr(", runs out around %s at %s/day\n"), p.RunOut.Format("2006-01-02"), clipper.Money(math.Round(p.DailySpendCents)))
+		}
+	}
+	printAutoload(os.Stdout, txns)
+	if jumps := clipperstats.BalanceJumps(txns); len(jumps) > 0 {
+		fmt.Print(tr("\nUnexplained balance changes:\n"))
+		for _, j := range jumps {
+			fmt.Printf(tr("  Card %d  %s to %s  expected %s, got %s\n"), j.After.CardSerial, j.Before.Timestamp.Format("2006-01-02"), j.After.Timestamp.Format("2006-01-02"), j.ExpectedCents, j.After.BalanceCents)
+		}
+	}
+	if len(sum.BusiestHours) > 0 {
+		fmt.Print(tr("\nBusiest travel hours:\n"))
+		for _, h := range sum.BusiestHours {
+			fmt.Printf(tr("  %02d:00-%02d:59  %3d trips\n"), h.Hour, h.Hour, h.Trips)
+		}
+	}
+}
+
+func duplicates(args []string) {
+	fs := newFlagSet("duplicates")
+	archive := addArchiveFlags(fs)
+	window := fs.Duration("window", clipperstats.DefaultDuplicateWindow, "Report identical charges at most this far apart")
+	fs.Parse(args)
+
+	dups := clipperstats.DuplicateCharges(archive.load(), *window)
+	if len(dups) == 0 {
+		fmt.Println(tr("No likely double-charges found."))
+		return
+	}
+	for i, d := range dups {
+		fmt.Printf(tr("%d. Card %d charged %s twice at %s, %s apart\n"), i+1, d.Second.CardSerial, d.Second.DebitCents, d.Second.Location, d.Gap())
+		for _, t := range []clipper.Transaction{d.First, d.Second} {
+			fmt.Printf(tr("   %s  %s  %s  balance %s\n"), t.Timestamp.Format("01/02/2006 03:04 PM"), t.Type, t.DebitCents, t.BalanceCents)
+			if t.Source != "" {
+				fmt.Printf(tr("     from %s, row %d\n"), t.Source, t.Row)
+			}
+		}
+	}
+	fmt.Printf(tr("\nTotal disputed: %s in %d charges\n"), clipperstats.DuplicateChargeCents(dups), len(dups))
+}
+
+func recommend(args []string) {
+	fs := newFlagSet("recommend")
+	archive := addArchiveFlags(fs)
+	recent := fs.Int("recent", 3, "Base recommendations on this many recent months")
+	productsFile := fs.String("products", "", "YAML table of fare products to compare (defaults to the built-in table)")
+	fs.Parse(args)
+
+	products := clipperstats.DefaultFareProducts
+	if *productsFile != "" {
+		f, err := os.Open(*productsFile)
+		checkError(err, "opening fare products")
+		products, err = clipperstats.LoadFareProducts(f)
+		f.Close()
+		checkError(err, "loading fare products")
+	}
+	months := clipperstats.BreakEven(archive.load(), products)
+	recs := clipperstats.Recommend(months, *recent)
+	if len(recs) == 0 {
+		fmt.Println(tr("No rides to compare."))
+		return
+	}
+	for _, pm := range months {
+		if pm.Rides == 0 {
+			continue
+		}
+		verdict := tr("pay as you go")
+		if pm.SavingsCents() > 0 {
+			verdict = tr("buy")
+		}
+		fmt.Printf(tr("%s  %-36s  %3d rides  %9s vs %9s  -> %s\n"), pm.Start.Format("Jan 2006"), pm.Product, pm.Rides, pm.PayAsYouGoCents, pm.ProductCents, verdict)
+	}
+	fmt.Printf(tr("\nBased on the last %d months with rides:\n"), recs[0].Months)
+	for _, r := range recs {
+		if r.Buy {
+			fmt.Printf(tr("  Buy %s: saves about %s a month\n"), r.Product, r.AverageSavingsCents)
+		} else {
+			fmt.Printf(tr("  Skip %s: paying as you go saves about %s a month\n"), r.Product, -r.AverageSavingsCents)
+		}
+	}
+}
+
+func carbon(args []string) {
+	fs := newFlagSet("carbon")
+	archive := addArchiveFlags(fs)
+	asCSV := fs.Bool("csv", false, "Print the monthly summary as CSV")
+	csvOpts := addCSVFlags(fs)
+	fs.Parse(args)
+
+	months := clipperstats.CarbonByMonth(clipperstats.Emissions(archive.load(), clipperstats.DefaultEmissionFactors))
+	if *asCSV {
+		w := clippercsv.NewWriter(os.Stdout, *csvOpts)
+		w.Write([]string{"month", "trips", "miles", "transit_kg_co2", "driving_kg_co2", "saved_kg_co2"})
+		for _, m := range months {
+			w.Write([]string{
+				m.Start.Format("2006-01"),
+				strconv.Itoa(m.Trips),
+				strconv.FormatFloat(m.Miles, 'f', 1, 64),
+				strconv.FormatFloat(m.TransitKg, 'f', 2, 64),
+				strconv.FormatFloat(m.DrivingKg, 'f', 2, 64),
+				strconv.FormatFloat(m.SavedKg(), 'f', 2, 64),
+			})
+		}
+		w.Flush()
+		checkError(w.Error(), "writing CSV")
+		return
+	}
+	if len(months) == 0 {
+		fmt.Println(tr("No rides found."))
+		return
+	}
+	var saved float64
+	for _, m := range months {
+		fmt.Printf(tr("%s  %3d trips  %6.1f miles  %6.1f kg CO2 by transit vs %6.1f kg driving, saved %6.1f kg\n"), m.Start.Format("Jan 2006"), m.Trips, m.Miles, m.TransitKg, m.DrivingKg, m.SavedKg())
+		saved += m.SavedKg()
+	}
+	fmt.Printf(tr("\nTotal saved: %.1f kg CO2\n"), saved)
+}
+
+func journeys(args []string) {
+	fs := newFlagSet("journeys")
+	archive := addArchiveFlags(fs)
+	format := fs.String("format", "text", "Output format: text, csv, json or timeline (Google Timeline-style location history JSON)")
+	csvOpts := addCSVFlags(fs)
+	fs.Parse(args)
+
+	txns := archive.load()
+	if *format == "timeline" {
+		checkError(clipperstats.BuildTimeline(txns).WriteJSON(os.Stdout), "writing timeline")
+		return
+	}
+	js := clipperstats.Journeys(txns, clipperstats.DefaultTransferWindow)
+	tagger := archive.tagger()
+	record := func(j clipperstats.Journey) clipperstats.JourneyRecord {
+		r := j.Record()
+		r.Purpose = tagger.TagJourney(j)
+		return r
+	}
+	switch *format {
+	case "csv":
+		w := clippercsv.NewWriter(os.Stdout, *csvOpts)
+		w.Write(clipperstats.JourneyCSVHeader)
+		for _, j := range js {
+			w.Write(record(j).CSV())
+		}
+		w.Flush()
+		checkError(w.Error(), "writing CSV")
+	case "json":
+		records := make([]clipperstats.JourneyRecord, len(js))
+		for i, j := range js {
+			records[i] = record(j)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		checkError(enc.Encode(records), "writing JSON")
+	case "text":
+		for _, j := range js {
+			dest := j.Destination()
+			if dest == "" {
+				dest = "?"
+			}
+			fmt.Printf(tr("%s  %-24s -> %-24s  %4.0f min  %9s  %s\n"), j.Start().Format("2006-01-02 03:04 PM"), j.Origin(), dest, j.Duration().Minutes(), j.FareCents(), tagger.TagJourney(j))
+		}
+	default:
+		fmt.Fprintf(os.Stderr, tr("unknown format %q\n"), *format)
+		os.Exit(2)
+	}
+}
+
+func usage(args []string) {
+	fs := newFlagSet("usage")
+	archive := addArchiveFlags(fs)
+	asJSON := fs.Bool("json", false, "Print the statistics as JSON, for charting")
+	fs.Parse(args)
+
+	u := clipperstats.UsageStats(archive.load())
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		checkError(enc.Encode(u), "writing JSON")
+		return
+	}
+	fmt.Printf(tr("Weekday trips: %d\nWeekend trips: %d\n\n"), u.WeekdayTrips, u.WeekendTrips)
+	fmt.Printf("     ")
+	for h := 0; h < 24; h++ {
+		fmt.Printf("%3d", h)
+	}
+	fmt.Printf("\n")
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		fmt.Printf("%s  ", d.String()[:3])
+		for h := 0; h < 24; h++ {
+			if n := u.Heatmap[d][h]; n > 0 {
+				fmt.Printf("%3d", n)
+			} else {
+				fmt.Printf("  .")
+			}
+		}
+		fmt.Printf("\n")
+	}
+	fmt.Print(tr("\nTrips per week:\n"))
+	for _, w := range u.Weeks {
+		fmt.Printf(tr("  %s  %3d weekday  %3d weekend  %d days\n"), w.Start.Format("2006-01-02"), w.WeekdayTrips, w.WeekendTrips, w.Days)
+	}
+}
+
+func alerts(args []string) {
+	fs := newFlagSet("alerts")
+	archive := addArchiveFlags(fs)
+	days := fs.Int("days", 7, "Check activity in this many days before the latest transaction")
+	webhook := fs.String("webhook", "", "POST alerts as JSON to this URL")
+	program := fs.String("exec", "", "Run this program for each alert, with the alert as JSON on stdin")
+	fs.Parse(args)
+
+	txns := archive.load()
+	if len(txns) == 0 {
+		return
+	}
+	latest := txns[len(txns)-1].Timestamp
+	since := latest.AddDate(0, 0, -*days)
+	notifiers := notify.Multi{notify.Writer{W: os.Stdout}}
+	if *webhook != "" {
+		notifiers = append(notifiers, notify.Webhook{URL: *webhook})
+	}
+	if *program != "" {
+		notifiers = append(notifiers, notify.Command{Path: *program})
+	}
+	ctx := context.Background()
+	failed := false
+	for _, a := range clipperstats.Anomalies(txns, since, clipperstats.AnomalyOptions{}) {
+		err := notifiers.Notify(ctx, notify.Message{
+			Kind:    string(a.Kind),
+			Subject: fmt.Sprintf(tr("Unusual activity on Clipper card %d"), a.CardSerial),
+			Body:    a.Description,
+			Time:    a.Time,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, tr("Error sending alert: %v\n"), err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func expenseReport(args []string) {
+	fs := newFlagSet("expense")
+	archive := addArchiveFlags(fs)
+	rulesFile := fs.String("rules", "", "YAML file of rules for which rides to claim (defaults to every ride)")
+	format := fs.String("format", "csv", "Output format: csv or pdf")
+	output := fs.String("output", "", "Write the report to this file instead of stdout")
+	csvOpts := addCSVFlags(fs)
+	fs.Parse(args)
+
+	var rules expense.Rules
+	if *rulesFile != "" {
+		f, err := os.Open(*rulesFile)
+		checkError(err, "opening rules")
+		rules, err = expense.LoadRules(f)
+		f.Close()
+		checkError(err, "loading rules")
+	}
+	from, err := parseDate(*archive.start)
+	checkError(err, "parsing start date")
+	to, err := parseDate(*archive.end)
+	checkError(err, "parsing end date")
+	rules.Tagger = archive.tagger()
+	report := expense.Generate(archive.load(), from, to, rules)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		checkError(err, "creating output file")
+		defer f.Close()
+		w = f
+	}
+	switch *format {
+	case "csv":
+		err = report.WriteCSV(w, *csvOpts)
+	case "pdf":
+		err = report.WritePDF(w)
+	default:
+		fmt.Fprintf(os.Stderr, tr("unknown format %q\n"), *format)
+		os.Exit(2)
+	}
+	dest := *output
+	if dest == "" {
+		dest = "standard output"
+	}
+	archive.audit(store.AuditExport, fmt.Sprintf("%s expense report to %s", *format, dest), err)
+	checkError(err, "writing report")
+}
+
+func exportTransactions(args []string) {
+	fs := newFlagSet("export")
+	archive := addArchiveFlags(fs)
+	format := fs.String("format", "csv", "Output format: csv, json, jsonl, ofx or beancount")
+	output := fs.String("output", "", "Write the transactions to this file instead of stdout")
+	account := fs.String("account", "", "Account number for an OFX statement (defaults to the first transaction's card)")
+	rulesFile := fs.String("beancount-rules", "", "YAML file of rules for which Beancount accounts to post transactions to")
+	csvOpts := addCSVFlags(fs)
+	fs.Parse(args)
+	if !slices.Contains(export.Formats, *format) {
+		fmt.Fprintf(os.Stderr, tr("unknown format %q\n"), *format)
+		os.Exit(2)
+	}
+	var rules export.BeancountRules
+	if *rulesFile != "" {
+		f, err := os.Open(*rulesFile)
+		checkError(err, "opening rules")
+		rules, err = export.LoadBeancountRules(f)
+		f.Close()
+		checkError(err, "loading rules")
+	}
+
+	from, err := parseDate(*archive.start)
+	checkError(err, "parsing start date")
+	to, err := parseDate(*archive.end)
+	checkError(err, "parsing end date")
+	txns := archive.load()
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		checkError(err, "creating output file")
+		defer f.Close()
+		w = f
+	}
+	enc, err := export.New(*format, w, export.Options{
+		CSV:       *csvOpts,
+		OFX:       export.OFXOptions{Account: *account, Start: from, End: to},
+		Beancount: rules,
+	})
+	checkError(err, "writing transactions")
+	for _, t := range txns {
+		if err = enc.Encode(t); err != nil {
+			break
+		}
+	}
+	if cerr := enc.Close(); err == nil {
+		err = cerr
+	}
+	dest := *output
+	if dest == "" {
+		dest = "standard output"
+	}
+	archive.audit(store.AuditExport, fmt.Sprintf("%d transactions as %s to %s", len(txns), *format, dest), err)
+	checkError(err, "writing transactions")
+}
+
+func benefits(args []string) {
+	fs := newFlagSet("benefits")
+	archive := addArchiveFlags(fs)
+	match := fs.String("match", strings.Join(clipperstats.DefaultBenefitPatterns, ","), "Comma-separated text that identifies benefit loads in a reload's type, location or product")
+	fs.Parse(args)
+
+	months := clipperstats.ReconcileBenefits(archive.load(), strings.Split(*match, ","))
+	if len(months) == 0 {
+		fmt.Println(tr("No transactions found."))
+		return
+	}
+	fmt.Printf("%-8s  %10s  %10s  %10s  %10s\n", tr("Month"), tr("Loaded"), tr("Spent"), tr("Unused"), tr("IRS limit"))
+	over := false
+	for _, m := range months {
+		flagged := ""
+		if m.OverCapCents > 0 {
+			flagged = fmt.Sprintf(tr("  over the limit by %s"), m.OverCapCents)
+			over = true
+		}
+		fmt.Printf("%-8s  %10s  %10s  %10s  %10s%s\n", m.Start.Format("Jan 2006"), m.LoadedCents, m.SpentCents, m.UnusedCents, m.CapCents, flagged)
+	}
+	last := months[len(months)-1]
+	fmt.Printf(tr("\nUnused benefit balance: %s\n"), last.UnusedCents)
+	if over {
+		fmt.Print(tr("Some months exceed the IRS monthly limit; the excess may be taxable.\n"))
+	}
+}
+
+func review(args []string) {
+	fs := newFlagSet("review")
+	archive := addArchiveFlags(fs)
+	year := fs.Int("year", 0, "Year to review (defaults to the latest year in the archive)")
+	format := fs.String("format", "markdown", "Output format: markdown or html")
+	output := fs.String("output", "", "Write the review to this file instead of stdout")
+	fs.Parse(args)
+
+	txns := archive.load()
+	if len(txns) == 0 {
+		fmt.Println(tr("No transactions found."))
+		return
+	}
+	if *year == 0 {
+		*year = txns[len(txns)-1].Timestamp.Year()
+	}
+	r := clipperstats.ReviewYear(txns, *year)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		checkError(err, "creating output file")
+		defer f.Close()
+		w = f
+	}
+	var err error
+	switch *format {
+	case "markdown", "md":
+		err = r.WriteMarkdown(w)
+	case "html":
+		err = r.WriteHTML(w,
+			template.HTML(chart.MonthlySpend(r.Months).SVG()),
+			template.HTML(chart.AgencySpend(r.Agencies).SVG()))
+	default:
+		fmt.Fprintf(os.Stderr, tr("unknown format %q\n"), *format)
+		os.Exit(2)
+	}
+	checkError(err, "writing review")
+}
+
+// householdConfig is the part of the downloader's config.yml that says whose
+// cards are whose and what they can spend. Budgets are in dollars a month.
+type householdConfig struct {
+	Users map[string]struct {
+		Email       string            `yaml:"email"`
+		Password    string            `yaml:"password"`
+		Cards       []int64           `yaml:"cards"`
+		Budget      float64           `yaml:"budget"`
+		CardBudgets map[int64]float64 `yaml:"card_budgets"`
+		CardTypes   map[int64]string  `yaml:"card_types"`
+	} `yaml:"users"`
+	SMTP struct {
+		Host     string   `yaml:"host"`
+		Port     int      `yaml:"port"`
+		Username string   `yaml:"username"`
+		Password string   `yaml:"password"`
+		From     string   `yaml:"from"`
+		To       []string `yaml:"to"`
+	} `yaml:"smtp"`
+}
+
+func loadHouseholdConfig(path string) householdConfig {
+	data, err := os.ReadFile(path)
+	checkError(err, "reading config file")
+	var config householdConfig
+	checkError(yaml.Unmarshal(data, &config), "parsing config file")
+	return config
+}
+
+// fareCategories returns the fare category of every card with a card type in
+// c.
+func (c householdConfig) fareCategories() map[int64]clipperstats.FareCategory {
+	categories := make(map[int64]clipperstats.FareCategory)
+	for name, u := range c.Users {
+		for card, typ := range u.CardTypes {
+			fc, err := clipperstats.ParseFareCategory(typ)
+			checkError(err, fmt.Sprintf(tr("reading card types for %s"), name))
+			categories[card] = fc
+		}
+	}
+	return categories
+}
+
+// budgets returns the per-user and per-card budgets in c, ordered by user
+// name.
+func (c householdConfig) budgets() []clipperstats.Budget {
+	names := make([]string, 0, len(c.Users))
+	for name := range c.Users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var out []clipperstats.Budget
+	for _, name := range names {
+		u := c.Users[name]
+		if u.Budget > 0 {
+			out = append(out, clipperstats.Budget{Name: name, Cards: u.Cards, LimitCents: clipper.Money(math.Round(u.Budget * 100))})
+		}
+		cards := make([]int64, 0, len(u.CardBudgets))
+		for card := range u.CardBudgets {
+			cards = append(cards, card)
+		}
+		sort.Slice(cards, func(i, j int) bool { return cards[i] < cards[j] })
+		for _, card := range cards {
+			out = append(out, clipperstats.Budget{
+				Name:       fmt.Sprintf(tr("%s (card %d)"), name, card),
+				Cards:      []int64{card},
+				LimitCents: clipper.Money(math.Round(u.CardBudgets[card] * 100)),
+			})
+		}
+	}
+	return out
+}
+
+func household(args []string) {
+	fs := newFlagSet("household")
+	archive := addArchiveFlags(fs)
+	configFile := fs.String("config", "config.yml", "Config file listing each user's cards")
+	month := fs.String("month", "", "Month to report on, as YYYY-MM in Pacific time (defaults to the latest month in the archive)")
+	webhook := fs.String("webhook", "", "Also POST the report as JSON to this URL")
+	program := fs.String("exec", "", "Also run this program with the report as JSON on stdin")
+	attachChart := fs.Bool("chart", false, "Attach a PNG chart of spend per person to the report")
+	fs.Parse(args)
+
+	config := loadHouseholdConfig(*configFile)
+	owners := make(map[int64]string)
+	for name, u := range config.Users {
+		for _, card := range u.Cards {
+			owners[card] = name
+		}
+	}
+
+	txns := archive.load()
+	if len(txns) == 0 {
+		fmt.Println(tr("No transactions found."))
+		return
+	}
+	var start time.Time
+	var err error
+	if *month != "" {
+		start, err = transit.ParseMonth(*month)
+		checkError(err, "parsing month")
+	} else {
+		start = transit.MonthStart(txns[len(txns)-1].Timestamp)
+	}
+	h := clipperstats.Household(store.Between(txns, start, start.AddDate(0, 1, 0)), owners)
+
+	var b strings.Builder
+	for _, p := range h.People {
+		fmt.Fprintf(&b, tr("%-20s  %4d trips  %10s\n"), p.Name, p.Trips, p.SpendCents)
+		for _, c := range p.Cards {
+			fmt.Fprintf(&b, tr("  card %-13d  %4d trips  %10s\n"), c.CardSerial, c.Trips, c.SpendCents)
+		}
+	}
+	fmt.Fprintf(&b, tr("%-20s  %4d trips  %10s\n"), tr("Household total"), h.Trips, h.SpendCents)
+
+	notifiers := notify.Multi{notify.Writer{W: os.Stdout}}
+	if *webhook != "" {
+		notifiers = append(notifiers, notify.Webhook{URL: *webhook})
+	}
+	if *program != "" {
+		notifiers = append(notifiers, notify.Command{Path: *program})
+	}
+	m := notify.Message{
+		Kind:    "household_report",
+		Subject: "Clipper spending for " + start.Format("January 2006"),
+		Body:    strings.TrimSuffix(b.String(), "\n"),
+		Time:    start,
+	}
+	if *attachChart {
+		c := chart.Chart{Kind: chart.Bar, Title: m.Subject, Format: chart.Dollars}
+		for _, p := range h.People {
+			c.Points = append(c.Points, chart.Point{Label: p.Name, Value: float64(p.SpendCents)})
+		}
+		var buf bytes.Buffer
+		checkError(c.WritePNG(&buf), "drawing chart")
+		m.Attachments = append(m.Attachments, notify.Attachment{
+			Name:        "household-" + start.Format("2006-01") + ".png",
+			ContentType: "image/png",
+			Data:        buf.Bytes(),
+		})
+	}
+	checkError(notifiers.Notify(context.Background(), m), "sending report")
+}
+
+func digest(args []string) {
+	fs := newFlagSet("digest")
+	archive := addArchiveFlags(fs)
+	configFile := fs.String("config", "config.yml", "Config file with the SMTP server to mail the digest through")
+	month := fs.String("month", "", "Month to report on, as YYYY-MM in Pacific time (defaults to the latest month in the archive)")
+	webhook := fs.String("webhook", "", "Also POST the digest as JSON to this URL")
+	program := fs.String("exec", "", "Also run this program with the digest as JSON on stdin")
+	csvOpts := addCSVFlags(fs)
+	fs.Parse(args)
+
+	txns := archive.load()
+	if len(txns) == 0 {
+		fmt.Println(tr("No transactions found."))
+		return
+	}
+	var start time.Time
+	var err error
+	if *month != "" {
+		start, err = transit.ParseMonth(*month)
+		checkError(err, "parsing month")
+	} else {
+		start = txns[len(txns)-1].Timestamp
+	}
+	d := clipperstats.MonthlyDigest(txns, start)
+
+	var body, csvData, htmlData bytes.Buffer
+	checkError(d.WriteText(&body), "writing digest")
+	checkError(d.WriteCSV(&csvData, *csvOpts), "writing CSV")
+	checkError(d.WriteHTML(&htmlData), "writing HTML")
+	name := "clipper-" + d.Start.Format("2006-01")
+	m := notify.Message{
+		Kind:    "monthly_digest",
+		Subject: "Clipper digest for " + d.Start.Format("January 2006"),
+		Body:    strings.TrimSuffix(body.String(), "\n"),
+		Time:    d.Start,
+		Attachments: []notify.Attachment{
+			{Name: name + ".csv", ContentType: "text/csv", Data: csvData.Bytes()},
+			{Name: name + ".html", ContentType: "text/html; charset=utf-8", Data: htmlData.Bytes()},
+		},
+	}
+
+	notifiers := notify.Multi{notify.Writer{W: os.Stdout}}
+	if _, err := os.Stat(*configFile); err == nil {
+		if c := loadHouseholdConfig(*configFile).SMTP; c.Host != "" {
+			notifiers = append(notifiers, notify.Email{
+				Host:     c.Host,
+				Port:     c.Port,
+				Username: c.Username,
+				Password: c.Password,
+				From:     c.From,
+				To:       c.To,
+			})
+		}
+	}
+	if *webhook != "" {
+		notifiers = append(notifiers, notify.Webhook{URL: *webhook})
+	}
+	if *program != "" {
+		notifiers = append(notifiers, notify.Command{Path: *program})
+	}
+	if len(notifiers) == 1 {
+		fmt.Fprintf(os.Stderr, tr("no smtp server in %s; printing the digest only\n"), *configFile)
+	}
+	checkError(notifiers.Notify(context.Background(), m), "sending digest")
+}
+
+func homeAssistant(args []string) {
+	fs := newFlagSet("homeassistant")
+	archive := addArchiveFlags(fs)
+	broker := fs.String("broker", "", "MQTT broker URL, like tcp://homeassistant.local:1883")
+	username := fs.String("username", "", "MQTT username")
+	password := fs.String("password", os.Getenv("MQTT_PASSWORD"), "MQTT password (defaults to $MQTT_PASSWORD)")
+	discoveryPrefix := fs.String("discovery-prefix", homeassistant.DefaultDiscoveryPrefix, "Home Assistant's MQTT discovery prefix")
+	topic := fs.String("topic", homeassistant.DefaultTopic, "Prefix of the topics to publish card states to")
+	fs.Parse(args)
+
+	if *broker == "" {
+		fmt.Fprintln(os.Stderr, tr("homeassistant needs --broker"))
+		os.Exit(2)
+	}
+	msgs, err := homeassistant.Messages(*discoveryPrefix, *topic, homeassistant.States(archive.load()))
+	checkError(err, "building messages")
+	opts := mqtt.NewClientOptions().AddBroker(*broker).SetClientID("clipper").SetUsername(*username).SetPassword(*password)
+	c := mqtt.NewClient(opts)
+	tok := c.Connect()
+	if !tok.WaitTimeout(30 * time.Second) {
+		checkError(errors.New(tr("timed out")), fmt.Sprintf(tr("connecting to %s"), *broker))
+	}
+	checkError(tok.Error(), fmt.Sprintf(tr("connecting to %s"), *broker))
+	defer c.Disconnect(250)
+	checkError(homeassistant.Publish(c, msgs), "publishing")
+	fmt.Fprintf(os.Stderr, tr("Published %d messages to %s\n"), len(msgs), *broker)
+}
+
+func grafanaBundle(args []string) {
+	fs := newFlagSet("grafana")
+	archive := addArchiveFlags(fs)
+	db := fs.String("db", "postgres", "Database to load transactions into: postgres or sqlite")
+	output := fs.String("output", "clipper-grafana", "Directory to write the setup to")
+	fs.Parse(args)
+
+	d, err := grafana.ParseDialect(*db)
+	checkError(err, "parsing --db")
+	files, err := grafana.Bundle(d, archive.load())
+	checkError(err, "building Grafana setup")
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(*output, filepath.FromSlash(name))
+		checkError(os.MkdirAll(filepath.Dir(path), 0755), "creating directory")
+		checkError(os.WriteFile(path, files[name], 0644), fmt.Sprintf(tr("writing %s"), name))
+	}
+	archive.audit(store.AuditExport, "Grafana setup to "+*output, nil)
+	fmt.Fprintf(os.Stderr, tr("Wrote %d files to %s; run \"docker compose up\" there and open http://localhost:3000\n"), len(names), *output)
+}
+
+func scrub(args []string) {
+	fs := newFlagSet("scrub")
+	seed := fs.Int64("seed", 0, "Seed for how far to move dates (defaults to a random one)")
+	shiftDays := fs.Int("shift-days", 0, "Move every date by this many days (defaults to a whole number of weeks back, picked by --seed)")
+	format := fs.String("format", "pdf", "Output format: pdf, or csv for the parsed transactions")
+	output := fs.String("output", "", "Write the scrubbed statement to this file instead of stdout")
+	csvOpts := addCSVFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprint(os.Stderr, tr("usage: clipper scrub [flags] STATEMENT.pdf\n"))
+		os.Exit(2)
+	}
+	opts := clipper.ScrubOptions{Seed: *seed, ShiftDays: *shiftDays}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	checkError(err, "opening statement")
+	defer f.Close()
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		out, err := os.Create(*output)
+		checkError(err, "creating output file")
+		defer out.Close()
+		w = out
+	}
+	switch *format {
+	case "pdf":
+		err = clipper.ScrubPDF(w, f, opts)
+	case "csv":
+		var data clipper.TransactionData
+		data, err = clipper.ParsePDF(f)
+		checkError(err, "parsing statement")
+		err = clipper.ScrubTransactions(data, opts).WriteCSV(w, *csvOpts)
+	default:
+		fmt.Fprintf(os.Stderr, tr("unknown format %q\n"), *format)
+		os.Exit(2)
+	}
+	checkError(err, "scrubbing statement")
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, tr("Wrote %s; check it by hand before sharing it\n"), *output)
+	}
+}
+
+func budget(args []string) {
+	fs := newFlagSet("budget")
+	archive := addArchiveFlags(fs)
+	configFile := fs.String("config", "config.yml", "Config file listing each user's cards and budgets")
+	thresholdList := fs.String("thresholds", "50,80,100", "Comma-separated percentages of the budget to alert at")
+	days := fs.Int("days", 1, "Alert on thresholds crossed in this many days before the latest transaction")
+	webhook := fs.String("webhook", "", "POST alerts as JSON to this URL")
+	program := fs.String("exec", "", "Run this program for each alert, with the alert as JSON on stdin")
+	fs.Parse(args)
+
+	var thresholds []int
+	for _, v := range strings.Split(*thresholdList, ",") {
+		pct, err := strconv.Atoi(strings.TrimSpace(v))
+		checkError(err, "parsing thresholds")
+		thresholds = append(thresholds, pct)
+	}
+	budgets := loadHouseholdConfig(*configFile).budgets()
+	if len(budgets) == 0 {
+		fmt.Fprintf(os.Stderr, tr("no budgets in %s\n"), *configFile)
+		os.Exit(2)
+	}
+	txns := archive.load()
+	if len(txns) == 0 {
+		return
+	}
+	since := txns[len(txns)-1].Timestamp.AddDate(0, 0, -*days)
+
+	notifiers := notify.Multi{notify.Writer{W: os.Stdout}}
+	if *webhook != "" {
+		notifiers = append(notifiers, notify.Webhook{URL: *webhook})
+	}
+	if *program != "" {
+		notifiers = append(notifiers, notify.Command{Path: *program})
+	}
+	ctx := context.Background()
+	failed := false
+	for _, c := range clipperstats.BudgetCrossings(txns, budgets, thresholds) {
+		if c.Time.Before(since) {
+			continue
+		}
+		err := notifiers.Notify(ctx, notify.Message{
+			Kind:    "budget",
+			Subject: fmt.Sprintf(tr("%s has spent %d%% of the %s transit budget"), c.Budget.Name, c.Percent, c.Month.Format("January")),
+			Body:    fmt.Sprintf(tr("Spent %s of %s so far in %s."), c.SpentCents, c.Budget.LimitCents, c.Month.Format("January 2006")),
+			Time:    c.Time,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, tr("Error sending alert: %v\n"), err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// printAutoload prints how each card in txns has been reloaded and suggested
+// autoload settings.
+func printAutoload(w io.Writer, txns []clipper.Transaction) {
+	header := false
+	for _, card := range clipperstats.Cards(txns) {
+		s, ok := clipperstats.SuggestAutoload(txns, card, clipperstats.AutoloadOptions{})
+		if !ok {
+			continue
+		}
+		if !header {
+			fmt.Fprint(w, tr("\nAutoload:\n"))
+			header = true
+		}
+		fmt.Fprintf(w, tr("  Card %d  spends %s/day; "), card, clipper.Money(math.Round(s.DailySpendCents)))
+		if s.Reloads == 0 {
+			fmt.Fprint(w, tr("never reloaded"))
+		} else {
+			fmt.Fprintf(w, tr("reloaded %d times (%d by autoload)"), s.Reloads, s.Autoloads)
+			if s.DaysBetweenReloads > 0 {
+				fmt.Fprintf(w, tr(" every %.0f days"), s.DaysBetweenReloads)
+			}
+			fmt.Fprintf(w, tr(", averaging %s"), s.AverageReloadCents)
+		}
+		fmt.Fprintf(w, tr("\n    Average balance %s, %d rides left too little for the next fare\n"), s.AverageBalanceCents, s.LowBalanceEvents)
+		fmt.Fprintf(w, tr("    Suggest: autoload %s when below %s (about every %.0f days, keeping about %s on the card),\n"),
+			s.AmountCents, s.ThresholdCents, s.SuggestedDaysPerReload, s.SuggestedAverageBalanceCents)
+		fmt.Fprintf(w, tr("    or add %s by hand every %.0f days\n"), s.AmountCents, s.SuggestedDaysPerReload)
+	}
+}
+
+// parsePeriod parses a period written as START..END, where END is exclusive.
+func parsePeriod(s string) (clipperstats.Period, error) {
+	from, to, ok := strings.Cut(s, "..")
+	if !ok {
+		return clipperstats.Period{}, fmt.Errorf(tr("want START..END, got %q"), s)
+	}
+	var p clipperstats.Period
+	var err error
+	if p.From, err = clipper.ParseDate(from); err != nil {
+		return p, err
+	}
+	if p.To, err = clipper.ParseDate(to); err != nil {
+		return p, err
+	}
+	if !p.To.After(p.From) {
+		return p, fmt.Errorf(tr("period %q ends before it starts"), s)
+	}
+	return p, nil
+}
+
+func compare(args []string) {
+	fs := newFlagSet("compare")
+	archive := addArchiveFlags(fs)
+	beforeFlag := fs.String("before", "", "Earlier period, as YYYY-MM-DD..YYYY-MM-DD (end exclusive)")
+	afterFlag := fs.String("after", "", "Later period, as YYYY-MM-DD..YYYY-MM-DD (end exclusive)")
+	fs.Parse(args)
+
+	before, err := parsePeriod(*beforeFlag)
+	checkError(err, "parsing --before")
+	after, err := parsePeriod(*afterFlag)
+	checkError(err, "parsing --after")
+	cmp := clipperstats.CompareFares(archive.load(), before, after)
+
+	fmt.Printf("%-20s  %15s  %15s  %8s  %12s\n", tr("Agency"), tr("Before"), tr("After"), tr("Change"), tr("Per month"))
+	row := func(name string, c clipperstats.FareChange) {
+		fmt.Printf("%-20s  %4d x %8s  %4d x %8s  %8s  %12s\n", name,
+			c.BeforeTrips, c.BeforeAverageCents, c.AfterTrips, c.AfterAverageCents,
+			c.AverageChangeCents(), c.MonthlyImpactCents)
+	}
+	for _, c := range cmp.Agencies {
+		name := string(c.Agency)
+		if c.Agency == clipper.AgencyUnknown {
+			name = tr("Other")
+		}
+		row(name, c)
+	}
+	row(tr("Total"), cmp.Total)
+	fmt.Printf(tr("\nAt %.1f rides a month, fare changes cost you %s a month.\n"),
+		float64(cmp.Total.AfterTrips)/after.Months(), cmp.Total.MonthlyImpactCents)
+}
+
+func discounts(args []string) {
+	fs := newFlagSet("discounts")
+	archive := addArchiveFlags(fs)
+	configFile := fs.String("config", "config.yml", "Config file listing each user's card types")
+	faresFile := fs.String("fares", "", "YAML file of discount fares to check against (defaults to built-in 2025 fares)")
+	fs.Parse(args)
+
+	categories := loadHouseholdConfig(*configFile).fareCategories()
+	if len(categories) == 0 {
+		fmt.Fprintf(os.Stderr, tr("no card_types in %s\n"), *configFile)
+		os.Exit(2)
+	}
+	table := clipperstats.DefaultDiscountFares
+	if *faresFile != "" {
+		f, err := os.Open(*faresFile)
+		checkError(err, "opening fares")
+		table, err = clipperstats.LoadDiscountFares(f)
+		f.Close()
+		checkError(err, "loading fares")
+	}
+
+	issues := clipperstats.AuditDiscounts(archive.load(), categories, table)
+	if len(issues) == 0 {
+		fmt.Println(tr("All checked rides were charged the discounted fare."))
+		return
+	}
+	var total clipper.Money
+	for _, d := range issues {
+		note := ""
+		if d.FullFare {
+			note = "  FULL FARE"
+		}
+		fmt.Printf(tr("%s  card %d  %-6s  %-20s  charged %s, expected %s%s\n"),
+			d.Leg.Start().Format("2006-01-02 15:04"), d.Leg.Txns[0].CardSerial, d.Category, d.Leg.Agency,
+			d.ChargedCents, d.ExpectedCents, note)
+		total += d.OverchargeCents()
+	}
+	fmt.Printf(tr("\n%d rides overcharged by %s in total.\n"), len(issues), total)
+}
+
+func drawChart(args []string) {
+	fs := newFlagSet("chart")
+	archive := addArchiveFlags(fs)
+	kind := fs.String("kind", "spend", "Chart to draw: spend (per month), agencies or balance")
+	card := fs.Int64("card", 0, "Card to chart the balance of (defaults to the first card in the archive)")
+	format := fs.String("format", "svg", "Output format: svg or png")
+	output := fs.String("output", "", "Write the chart to this file instead of stdout")
+	fs.Parse(args)
+
+	txns := archive.load()
+	var c chart.Chart
+	switch *kind {
+	case "spend":
+		c = chart.MonthlySpend(clipperstats.Monthly(txns))
+	case "agencies":
+		c = chart.AgencySpend(clipperstats.AgencyTotals(txns))
+	case "balance":
+		if *card == 0 {
+			cards := clipperstats.Cards(txns)
+			if len(cards) == 0 {
+				fmt.Fprintln(os.Stderr, tr("no cards in the archive"))
+				os.Exit(1)
+			}
+			*card = cards[0]
+		}
+		c = chart.Balance(clipperstats.BalanceTimeline(txns, *card))
+		c.Title = fmt.Sprintf(tr("Balance on card %d"), *card)
+	default:
+		fmt.Fprintf(os.Stderr, tr("unknown chart kind %q\n"), *kind)
+		os.Exit(2)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		checkError(err, "creating output file")
+		defer f.Close()
+		w = f
+	}
+	var err error
+	switch *format {
+	case "svg":
+		err = c.WriteSVG(w)
+	case "png":
+		err = c.WritePNG(w)
+	default:
+		fmt.Fprintf(os.Stderr, tr("unknown format %q\n"), *format)
+		os.Exit(2)
+	}
+	checkError(err, "writing chart")
+}
+
+func tag(args []string) {
+	fs := newFlagSet("tag")
+	archive := addArchiveFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		s, err := store.Open(*archive.dir)
+		checkError(err, "opening statement directory")
+		for _, arg := range fs.Args() {
+			id, purpose, ok := strings.Cut(arg, "=")
+			if !ok {
+				fmt.Fprintf(os.Stderr, tr("want ID=PURPOSE, got %q\n"), arg)
+				os.Exit(2)
+			}
+			checkError(s.SetTag(id, clipperstats.Purpose(strings.ToLower(purpose))), fmt.Sprintf(tr("tagging %s"), id))
+		}
+		return
+	}
+	tagger := archive.tagger()
+	for _, l := range clipperstats.Legs(archive.load()) {
+		dest := l.Destination()
+		if dest == "" {
+			dest = "?"
+		}
+		fmt.Printf("%-24s  %-20s  %-24s -> %-24s  %s\n", l.ID(), l.Agency, l.Origin(), dest, tagger.Tag(l))
+	}
+}
+
+func caltrain(args []string) {
+	fs := newFlagSet("caltrain")
+	archive := addArchiveFlags(fs)
+	productsFile := fs.String("products", "", "YAML table of fare products with Caltrain zone passes (defaults to the built-in table)")
+	goPass := fs.Float64("gopass", clipperstats.DefaultGoPassAnnualCents.Dollars(), "Annual price of a GoPass, in dollars")
+	fs.Parse(args)
+
+	products := clipperstats.DefaultFareProducts
+	if *productsFile != "" {
+		f, err := os.Open(*productsFile)
+		checkError(err, "opening fare products")
+		products, err = clipperstats.LoadFareProducts(f)
+		f.Close()
+		checkError(err, "loading fare products")
+	}
+	txns := archive.load()
+	pairs := clipperstats.CaltrainZonePairs(txns)
+	if len(pairs) == 0 {
+		fmt.Println(tr("No Caltrain rides with known zones."))
+		return
+	}
+	fmt.Printf("%-12s  %5s  %9s  %9s\n", tr("Zones"), tr("Rides"), tr("Spend"), tr("Average"))
+	for _, p := range pairs {
+		fmt.Printf("%-12s  %5d  %9s  %9s\n", p.Pair, p.Trips, p.SpendCents, p.SpendCents.Div(p.Trips))
+	}
+
+	fmt.Printf("\n%-8s  %5s  %9s  %-16s  %9s  %9s  %s\n", tr("Month"), tr("Rides"), tr("Paid"), tr("Pass"), tr("With pass"), tr("GoPass"), tr("Cheapest"))
+	var total, withPass, goPassTotal clipper.Money
+	for _, m := range clipperstats.CaltrainPasses(txns, products, clipper.Money(math.Round(*goPass*100))) {
+		pass, passCents := "-", "-"
+		cheapest, best := tr("pay as you go"), m.SpendCents
+		if m.Pass != "" {
+			pass = fmt.Sprintf("%s (%d-%d)", strings.TrimPrefix(m.Pass, "Caltrain "), m.Pair.From, m.Pair.To)
+			passCents = m.PassCents.String()
+			if m.PassCents < best {
+				cheapest, best = tr("zone pass"), m.PassCents
+			}
+			withPass += m.PassCents
+		} else {
+			withPass += m.SpendCents
+		}
+		if m.GoPassCents < best {
+			cheapest = "GoPass"
+		}
+		total += m.SpendCents
+		goPassTotal += m.GoPassCents
+		fmt.Printf("%-8s  %5d  %9s  %-16s  %9s  %9s  %s\n", m.Start.Format("Jan 2006"), m.Trips, m.SpendCents, pass, passCents, m.GoPassCents, cheapest)
+	}
+	fmt.Printf(tr("\nPaid %s; monthly zone passes would have cost %s, and a GoPass %s.\n"), total, withPass, goPassTotal)
+	fmt.Print(tr("A GoPass is bought by employers; if yours provides one, Caltrain rides cost you nothing.\n"))
+}
+
+func lateNight(args []string) {
+	fs := newFlagSet("latenight")
+	archive := addArchiveFlags(fs)
+	hoursFlag := fs.String("hours", "0-5", "Hours to report on, like 0-5 for midnight to 4:59am or 22-5 to start at 10pm")
+	card := fs.Int64("card", 0, "Only report on rides on this card (defaults to every card)")
+	fs.Parse(args)
+
+	hours, err := clipperstats.ParseHourRange(*hoursFlag)
+	checkError(err, "parsing --hours")
+	var cards []int64
+	if *card != 0 {
+		cards = append(cards, *card)
+	}
+	r := clipperstats.LateNight(archive.load(), hours, cards...)
+	if len(r.Rides) == 0 {
+		fmt.Printf(tr("No rides between %s.\n"), hours)
+		return
+	}
+	fmt.Printf(tr("%d rides between %s:\n\n"), len(r.Rides), hours)
+	for _, l := range r.Rides {
+		dest := l.Destination()
+		if dest == "" {
+			dest = "?"
+		}
+		fmt.Printf("%-20s  %-10d  %-20s  %-24s -> %-24s  %7s\n", l.Start().Format("Mon Jan 2 2006 15:04"), l.Txns[0].CardSerial, l.Agency, l.Origin(), dest, l.FareCents())
+	}
+	fmt.Print(tr("\nPer month:\n"))
+	for _, m := range r.Months {
+		fmt.Printf("  %-8s  %3d\n", m.Start.Format("Jan 2006"), m.Trips)
+	}
+	fmt.Print(tr("\nPlaces:\n"))
+	for _, p := range r.Places {
+		fmt.Printf("  %-32s  %3d\n", p.Place, p.Rides)
+	}
+}
+
+func fares(args []string) {
+	fs := newFlagSet("fares")
+	archive := addArchiveFlags(fs)
+	configFile := fs.String("config", "", "Config file listing each user's card types (cards not listed pay adult fares)")
+	faresFile := fs.String("fares", "", "YAML table of posted fares to check against (defaults to the built-in table)")
+	minDollars := fs.Float64("min", 0.01, "Only list overcharges of at least this many dollars")
+	dump := fs.Bool("dump", false, "Print the fare table as YAML, to start your own, and exit")
+	fs.Parse(args)
+
+	table := clipperstats.DefaultPostedFares
+	if *faresFile != "" {
+		f, err := os.Open(*faresFile)
+		checkError(err, "opening fares")
+		table, err = clipperstats.LoadPostedFares(f)
+		f.Close()
+		checkError(err, "loading fares")
+	}
+	if *dump {
+		checkError(clipperstats.WritePostedFares(os.Stdout, table), "writing fares")
+		return
+	}
+	categories := make(map[int64]clipperstats.FareCategory)
+	if *configFile != "" {
+		categories = loadHouseholdConfig(*configFile).fareCategories()
+	}
+
+	check := clipperstats.CheckFares(archive.load(), categories, table)
+	minCents := clipper.Money(math.Round(*minDollars * 100))
+	n, total := 0, clipper.Money(0)
+	for _, d := range check.Issues {
+		if d.OverchargeCents() < minCents {
+			continue
+		}
+		dest := d.Leg.Destination()
+		if dest == "" {
+			dest = "?"
+		}
+		fmt.Printf(tr("%s  card %d  %-6s  %-20s  %-24s -> %-24s  charged %s, posted %s\n"),
+			d.Leg.Start().Format("2006-01-02 15:04"), d.Leg.Txns[0].CardSerial, d.Category, d.Leg.Agency,
+			d.Leg.Origin(), dest, d.ChargedCents, d.Fare.Cents)
+		n++
+		total += d.OverchargeCents()
+	}
+	if n == 0 {
+		fmt.Printf(tr("No overcharges in %d rides checked."), check.Checked)
+	} else {
+		fmt.Printf(tr("\n%d of %d rides checked were overcharged by %s in total."), n, check.Checked, total)
+	}
+	fmt.Printf(tr(" %d rides had no posted fare to check against.\n"), check.Unchecked)
+}
+
+// syncFunc returns a function that downloads statements into dir for the
+// named user in c, or every user with credentials, or nil if there are none.
+// opts are passed to each client.
+func (c householdConfig) syncFunc(s *store.Store, opts ...clipper.Option) api.SyncFunc {
+	names := make([]string, 0, len(c.Users))
+	for name, u := range c.Users {
+		if u.Email != "" && u.Password != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return func(ctx context.Context, user string) error {
+		var errs []error
+		for _, name := range names {
+			if user != "" && name != user {
+				continue
+			}
+			u := c.Users[name]
+			client, err := clipper.NewClient(u.Email, u.Password, append(opts, auditEvents(s, name))...)
+			if err != nil {
+				return fmt.Errorf(tr("creating client for %s: %v"), name, err)
+			}
+			if err := client.DownloadPDFs(ctx, s.Dir(), "", "", false); err != nil {
+				if len(clipper.CardErrors(err)) == 0 {
+					return fmt.Errorf(tr("downloading statements for %s: %v"), name, err)
+				}
+				// Only some of this user's cards failed; go on to the
+				// next user.
+				errs = append(errs, fmt.Errorf(tr("downloading statements for %s: %v"), name, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// vaultSyncFunc returns a function that downloads statements for the named
+// tenant in v, or every tenant, each into its own directory under dir. Each
+// tenant's download runs as a separate job, so one account's failure or
+// timeout doesn't hold up the rest.
+func vaultSyncFunc(v *vault.Vault, s *store.Store, opts ...clipper.Option) api.SyncFunc {
+	return func(ctx context.Context, user string) error {
+		names := v.Names()
+		if user != "" {
+			if _, ok := v.Tenant(user); !ok {
+				return nil
+			}
+			names = []string{user}
+		}
+		return vault.Run(ctx, names, 2, api.SyncTimeout, func(ctx context.Context, name string) error {
+			email, password, err := v.Credentials(name)
+			if err != nil {
+				return err
+			}
+			client, err := clipper.NewClient(email, password, append(opts, auditEvents(s, name))...)
+			if err != nil {
+				return err
+			}
+			tenantDir := filepath.Join(s.Dir(), name)
+			if err := os.MkdirAll(tenantDir, 0700); err != nil {
+				return err
+			}
+			return client.DownloadPDFs(ctx, tenantDir, "", "", false)
+		})
+	}
+}
+
+// auditEvents records the logins and downloads a client makes for account
+// in the archive's audit log.
+func auditEvents(s *store.Store, account string) clipper.Option {
+	return clipper.WithEvents(func(e clipper.Event) {
+		ae := store.AuditEvent{Actor: "sync", Action: e.Action, Account: account, Card: e.Card, Detail: e.Path, Outcome: store.AuditOK}
+		if e.Err != nil {
+			ae.Outcome, ae.Error = store.AuditError, e.Err.Error()
+		}
+		if err := s.Audit(ae); err != nil {
+			handlers.Logger.Warn("error writing audit log", "err", err)
+		}
+	})
+}
+
+// combineSync returns a SyncFunc that runs each of the non-nil fns in turn,
+// or nil if there are none.
+func combineSync(fns ...api.SyncFunc) api.SyncFunc {
+	var nonNil []api.SyncFunc
+	for _, fn := range fns {
+		if fn != nil {
+			nonNil = append(nonNil, fn)
+		}
+	}
+	if len(nonNil) == 0 {
+		return nil
+	}
+	return func(ctx context.Context, user string) error {
+		var errs []error
+		for _, fn := range nonNil {
+			if err := fn(ctx, user); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+func openVault(path string) *vault.Vault {
+	hexkey := os.Getenv("CLIPPER_VAULT_KEY")
+	if hexkey == "" {
+		fmt.Fprintln(os.Stderr, tr("set $CLIPPER_VAULT_KEY to the vault's master key; make one with \"clipper vault keygen\""))
+		os.Exit(2)
+	}
+	key, err := nacl.Load(hexkey)
+	checkError(err, "loading $CLIPPER_VAULT_KEY")
+	v, err := vault.Open(path, key)
+	checkError(err, "opening vault")
+	return v
+}
+
+// readPassword reads a password from $CLIPPER_PASSWORD, or else from the
+// terminal without echoing it, or else a line of standard input.
+func readPassword() string {
+	if p := os.Getenv("CLIPPER_PASSWORD"); p != "" {
+		return p
+	}
+	if isTerminal(os.Stdin) {
+		fmt.Fprint(os.Stderr, tr("Clipper password: "))
+		p, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		checkError(err, "reading password")
+		return string(p)
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		checkError(err, "reading password")
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+func vaultCommand(args []string) {
+	fs := newFlagSet("vault")
+	path := fs.String("vault", "vault.json", "Vault file")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("usage: clipper vault [--vault=vault.json] keygen | list | add NAME EMAIL [CARD ...] | remove NAME"))
+		fmt.Fprintln(os.Stderr, tr("\nThe master key is read from $CLIPPER_VAULT_KEY, and passwords from $CLIPPER_PASSWORD or the terminal."))
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	switch cmd, rest := fs.Arg(0), fs.Args()[1:]; {
+	case cmd == "keygen" && len(rest) == 0:
+		fmt.Printf("%x\n", nacl.NewKey()[:])
+	case cmd == "list" && len(rest) == 0:
+		v := openVault(*path)
+		for _, name := range v.Names() {
+			t, _ := v.Tenant(name)
+			fmt.Printf(tr("%-16s  %-32s  %d cards\n"), t.Name, t.Email, len(t.Cards))
+		}
+	case cmd == "add" && len(rest) >= 2:
+		v := openVault(*path)
+		var cards []int64
+		for _, c := range rest[2:] {
+			serial, err := strconv.ParseInt(c, 10, 64)
+			checkError(err, "parsing card serial number")
+			cards = append(cards, serial)
+		}
+		checkError(v.Put(rest[0], rest[1], readPassword(), cards), fmt.Sprintf(tr("adding %s"), rest[0]))
+	case cmd == "remove" && len(rest) == 1:
+		checkError(openVault(*path).Delete(rest[0]), fmt.Sprintf(tr("removing %s"), rest[0]))
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+// writeFileAtomic replaces the file at path with data, so that a crash
+// doesn't leave it half written. The file is only readable by its owner.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".clipper-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// serveAdmin runs "clipper serve admin", which operates a running server
+// through its /admin/ API.
+func serveAdmin(args []string) {
+	fs := newFlagSet("serve admin")
+	server := fs.String("server", "http://127.0.0.1:7066", "URL of the running server")
+	token := fs.String("token", os.Getenv("CLIPPER_API_TOKEN"), "Token with the admin scope (defaults to $CLIPPER_API_TOKEN)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("usage: clipper serve admin [--server=http://127.0.0.1:7066] [--token=TOKEN] jobs | run-job NAME | accounts | retry | audit [FILTER=VALUE ...] | tokens | create-token NAME SCOPE,... [CARD ...] | revoke-token NAME | rotate-token NAME"))
+	}
+	fs.Parse(args)
+	call := func(method, path string, form url.Values, v interface{}) int {
+		req, err := http.NewRequest(method, strings.TrimSuffix(*server, "/")+path, strings.NewReader(form.Encode()))
+		checkError(err, "building request")
+		req.Header.Set("Authorization", "Bearer "+*token)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := http.DefaultClient.Do(req)
+		checkError(err, "calling server")
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		checkError(err, "reading response")
+		if resp.StatusCode >= 400 {
+			fmt.Fprintf(os.Stderr, "%s %s: %s\n%s", method, path, resp.Status, body)
+			os.Exit(1)
+		}
+		if v != nil {
+			checkError(json.Unmarshal(body, v), "reading response")
+		}
+		return resp.StatusCode
+	}
+	when := func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return t.Local().Format("2006-01-02 15:04")
+	}
+	switch cmd, rest := fs.Arg(0), fs.Args()[1:]; {
+	case cmd == "jobs" && len(rest) == 0:
+		var jobs []queue.Job
+		call("GET", "/admin/jobs", nil, &jobs)
+		if len(jobs) == 0 {
+			fmt.Println(tr("No scheduled jobs; start the server with --sync-every to add one."))
+		}
+		for _, j := range jobs {
+			name := j.Name
+			if name == "" {
+				name = tr("everyone")
+			}
+			fmt.Printf(tr("%-16s  %-9s  every %-8s  last run %-16s  next run %s\n"), name, j.State, j.Every, when(j.LastRun), when(j.NextRun))
+			if j.LastError != "" {
+				fmt.Printf(tr("%-16s  %d failures, last: %s\n"), "", j.Failures, j.LastError)
+			}
+		}
+	case cmd == "run-job" && len(rest) == 1:
+		var j queue.Job
+		call("POST", "/admin/jobs/run", url.Values{"name": {rest[0]}}, &j)
+		fmt.Printf(tr("Running %s\n"), rest[0])
+	case cmd == "audit":
+		q := url.Values{}
+		for _, arg := range rest {
+			k, v, ok := strings.Cut(arg, "=")
+			if !ok {
+				fs.Usage()
+				os.Exit(2)
+			}
+			q.Set(k, v)
+		}
+		var events []store.AuditEvent
+		call("GET", "/admin/audit?"+q.Encode(), nil, &events)
+		for _, e := range events {
+			actor, outcome := e.Actor, e.Outcome
+			if actor == "" {
+				actor = "(unknown)"
+			}
+			if e.Error != "" {
+				outcome += ": " + e.Error
+			}
+			subject := e.Account
+			if e.Card != 0 {
+				subject = strings.TrimSpace(subject + " " + strconv.FormatInt(e.Card, 10))
+			}
+			fmt.Printf("%s  %-12s  %-8s  %-20s  %s  %s\n", e.Time.Local().Format("2006-01-02 15:04:05"), actor, e.Action, subject, e.Detail, outcome)
+		}
+	case cmd == "accounts" && len(rest) == 0:
+		var accounts []api.AccountStatus
+		call("GET", "/admin/accounts", nil, &accounts)
+		for _, a := range accounts {
+			result := tr("ok")
+			if a.LastError != "" {
+				result = tr("failed: ") + a.LastError
+			} else if a.LastFinish.IsZero() {
+				result = tr("not synced yet")
+			}
+			fmt.Printf(tr("%-16s  last run %-16s  last success %-16s  %s\n"), a.User, when(a.LastFinish), when(a.LastSuccess), result)
+		}
+	case cmd == "retry" && len(rest) == 0:
+		var resp struct {
+			Users []string `json:"users"`
+		}
+		if call("POST", "/admin/retry", nil, &resp) == http.StatusOK {
+			fmt.Println(tr("No failed accounts to retry."))
+			return
+		}
+		fmt.Printf(tr("Retrying %s\n"), strings.Join(resp.Users, ", "))
+	case cmd == "tokens" && len(rest) == 0:
+		var tokens []api.TokenInfo
+		call("GET", "/admin/tokens", nil, &tokens)
+		for _, t := range tokens {
+			scopes := make([]string, len(t.Scopes))
+			for i, sc := range t.Scopes {
+				scopes[i] = string(sc)
+			}
+			cards := tr("every card")
+			if len(t.Cards) > 0 {
+				serials := make([]string, len(t.Cards))
+				for i, c := range t.Cards {
+					serials[i] = strconv.FormatInt(c, 10)
+				}
+				cards = tr("cards ") + strings.Join(serials, ", ")
+			}
+			fmt.Printf("%-16s  %-28s  %s\n", t.Name, strings.Join(scopes, ", "), cards)
+		}
+	case cmd == "create-token" && len(rest) >= 2:
+		var t api.Token
+		call("POST", "/admin/tokens", url.Values{"name": {rest[0]}, "scopes": {rest[1]}, "cards": {strings.Join(rest[2:], ",")}}, &t)
+		fmt.Println(t.Token)
+	case cmd == "revoke-token" && len(rest) == 1:
+		call("POST", "/admin/tokens/revoke", url.Values{"name": {rest[0]}}, nil)
+	case cmd == "rotate-token" && len(rest) == 1:
+		var t api.Token
+		call("POST", "/admin/tokens/rotate", url.Values{"name": {rest[0]}}, &t)
+		fmt.Println(t.Token)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+// userAgentOption returns the option for the --user-agent and
+// --rotate-user-agent flags.
+func userAgentOption(ua string, rotate bool) clipper.Option {
+	if !rotate {
+		return clipper.WithUserAgent(ua)
+	}
+	if ua != "" {
+		fmt.Fprintln(os.Stderr, tr("use --user-agent or --rotate-user-agent, not both"))
+		os.Exit(2)
+	}
+	return clipper.WithUserAgentRotation()
+}
+
+func serve(args []string) {
+	if len(args) > 0 && args[0] == "admin" {
+		serveAdmin(args[1:])
+		return
+	}
+	fs := newFlagSet("serve")
+	dir := fs.String("dir", "pdfs", "Directory of downloaded statement PDFs")
+	configFile := fs.String("config", "config.yml", "Config file with the credentials to sync with (optional)")
+	addr := fs.String("addr", "127.0.0.1:7066", "Address to listen on")
+	enableGraphQL := fs.Bool("graphql", false, "Serve a GraphQL endpoint at /graphql")
+	enablePlaid := fs.Bool("plaid", false, "Serve Plaid-compatible /plaid/transactions/get and /plaid/accounts/get endpoints")
+	grpcAddr := fs.String("grpc", "", "Address to serve the gRPC API on, like 127.0.0.1:7067 (off by default)")
+	token := fs.String("token", os.Getenv("CLIPPER_API_TOKEN"), "Token with every scope that clients can send (defaults to $CLIPPER_API_TOKEN)")
+	tokensFile := fs.String("tokens", "", "YAML list of further tokens, each with a name, scopes (read, balances, sync, metrics, admin) and optionally cards; tokens made, revoked or rotated with \"clipper serve admin\" are saved back to it")
+	syncEvery := fs.Duration("sync-every", 0, "Sync every account this often, like 24h (off by default)")
+	vaultFile := fs.String("vault", "", "Vault of tenants' credentials to sync, made with \"clipper vault\" (needs $CLIPPER_VAULT_KEY)")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 2*time.Minute, "On SIGTERM, how long to wait for a running sync and open requests")
+	slackSecret := fs.String("slack-secret", os.Getenv("SLACK_SIGNING_SECRET"), "Signing secret of a Slack app whose /clipper command points at /slack (defaults to $SLACK_SIGNING_SECRET)")
+	slackWebhook := fs.String("slack-webhook", os.Getenv("SLACK_WEBHOOK_URL"), "Slack incoming webhook URL for low balance and unusual activity alerts after syncs (defaults to $SLACK_WEBHOOK_URL)")
+	userAgent := fs.String("user-agent", os.Getenv("CLIPPER_USER_AGENT"), "User-Agent to sync with (defaults to $CLIPPER_USER_AGENT, or a recent Chrome)")
+	rotateUserAgent := fs.Bool("rotate-user-agent", false, "Sync as a browser picked at random from recent ones at each login")
+	http1 := fs.Bool("http1", false, "Sync over HTTP/1.1 only, for when the Clipper site's firewall mishandles HTTP/2")
+	pins := fs.String("pin", os.Getenv("CLIPPER_PINS"), "Comma-separated base64 SHA-256 hashes of public keys; sync only with servers whose certificate chain has one (defaults to $CLIPPER_PINS)")
+	useKeychain := fs.Bool("keychain", false, "Keep each account's session in the system keychain, and reuse it instead of logging in while it lasts")
+	minInterval := fs.Duration("min-interval", clipper.DefaultRateLimit, "Wait at least this long between requests to the Clipper site, across all accounts, after a burst of --burst requests")
+	burst := fs.Int("burst", clipper.DefaultBurst, "Number of requests to send at once before pacing them by --min-interval")
+	site := fs.String("site", os.Getenv("CLIPPER_SITE_URL"), "URL of the Clipper site to sync with, like http://127.0.0.1:7080 for clippermock (defaults to $CLIPPER_SITE_URL, or clippercard.com)")
+	fs.Parse(args)
+
+	var tokens []api.Token
+	if *tokensFile != "" {
+		f, err := os.Open(*tokensFile)
+		checkError(err, "opening tokens file")
+		tokens, err = api.LoadTokens(f)
+		f.Close()
+		checkError(err, "loading tokens")
+	}
+	if *token == "" && len(tokens) == 0 {
+		fmt.Fprintln(os.Stderr, tr("serve needs a token: set --token, $CLIPPER_API_TOKEN or --tokens"))
+		os.Exit(2)
+	}
+	s, err := store.Open(*dir)
+	checkError(err, "opening statement directory")
+	errs := new(api.ErrorCounter)
+	ua := userAgentOption(*userAgent, *rotateUserAgent)
+	transportOpts := clipper.TransportOptions{DisableHTTP2: *http1}
+	if *pins != "" {
+		transportOpts.PinnedCertificates = strings.Split(*pins, ",")
+	}
+	transport := errs.Wrap(clipper.NewTransport(transportOpts))
+	clientOpts := []clipper.Option{clipper.WithTransport(transport), ua, clipper.WithPacer(clipper.NewPacer(*minInterval, *burst))}
+	if *useKeychain {
+		clientOpts = append(clientOpts, clipper.WithSessionStore(keychain.New("clipper-session")))
+	}
+	if *site != "" {
+		clientOpts = append(clientOpts, clipper.WithSiteURL(*site))
+	}
+	var sync api.SyncFunc
+	users := make(map[string][]int64)
+	if _, err := os.Stat(*configFile); err == nil {
+		config := loadHouseholdConfig(*configFile)
+		sync = config.syncFunc(s, clientOpts...)
+		for name, u := range config.Users {
+			if u.Email != "" && u.Password != "" {
+				users[name] = u.Cards
+			}
+		}
+	}
+	if *vaultFile != "" {
+		v := openVault(*vaultFile)
+		for _, name := range v.Names() {
+			if _, ok := users[name]; ok {
+				fmt.Fprintf(os.Stderr, tr("%s is in both %s and %s\n"), name, *configFile, *vaultFile)
+				os.Exit(2)
+			}
+			t, _ := v.Tenant(name)
+			users[name] = t.Cards
+		}
+		sync = combineSync(sync, vaultSyncFunc(v, s, clientOpts...))
+	}
+	if sync == nil {
+		fmt.Fprintf(os.Stderr, tr("no credentials in %s; POST /sync is disabled\n"), *configFile)
+	}
+	srv := api.New(s, *token, sync)
+	for _, t := range tokens {
+		checkError(srv.AddToken(t), "adding token")
+	}
+	if *tokensFile != "" {
+		path := *tokensFile
+		srv.SaveTokens = func(tokens []api.Token) error {
+			buf := new(bytes.Buffer)
+			if err := api.WriteTokens(buf, tokens); err != nil {
+				return err
+			}
+			return writeFileAtomic(path, buf.Bytes())
+		}
+	}
+	srv.ClientErrors = errs
+	srv.Users = users
+	if *syncEvery > 0 {
+		if sync == nil {
+			fmt.Fprintln(os.Stderr, tr("--sync-every needs credentials to sync with"))
+			os.Exit(2)
+		}
+		checkError(srv.Schedule(*syncEvery), "scheduling syncs")
+	}
+	srv.SlackSigningSecret = *slackSecret
+	if *slackWebhook != "" {
+		srv.Notifier = notify.Slack{URL: *slackWebhook}
+	}
+	if *enableGraphQL {
+		checkError(srv.EnableGraphQL(), "starting GraphQL")
+	}
+	if *enablePlaid {
+		srv.EnablePlaid()
+	}
+	var grpcServer *grpc.Server
+	if *grpcAddr != "" {
+		ln, err := net.Listen("tcp", *grpcAddr)
+		checkError(err, "listening for gRPC")
+		grpcServer = rpc.NewServer(srv)
+		fmt.Fprintf(os.Stderr, tr("Serving gRPC on %s\n"), *grpcAddr)
+		go func() { checkError(grpcServer.Serve(ln), "serving gRPC") }()
+	}
+	var h http.Handler = srv
+	h = handlers.Log(h)
+	h = handlers.Duration(h)
+	h = api.HideToken(h)
+	hs := &http.Server{Addr: *addr, Handler: h}
+
+	// On SIGTERM, as sent by "docker stop" or Kubernetes, fail /readyz, let
+	// a running sync finish, then stop taking requests.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() {
+		fmt.Fprintf(os.Stderr, tr("Serving %s on http://%s\n"), s.Dir(), *addr)
+		errc <- hs.ListenAndServe()
+	}()
+	select {
+	case err := <-errc:
+		checkError(err, "serving")
+	case <-ctx.Done():
+	}
+	stop()
+	fmt.Fprintf(os.Stderr, tr("Shutting down; waiting up to %s for a running sync\n"), *shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		fmt.Fprintf(os.Stderr, tr("Sync didn't finish before shutdown: %v\n"), err)
+	}
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+	checkError(hs.Shutdown(shutdownCtx), "shutting down")
+}
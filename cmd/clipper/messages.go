@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// The languages clipper speaks: English, and the two other languages the
+// Clipper site comes in. The messages in the code are the English ones, and
+// each of the other languages has a catalog translating them, in
+// messages_es.go and messages_zh.go. A message that isn't in the catalog is
+// printed in English.
+var (
+	languages = []language.Tag{language.English, language.Spanish, language.TraditionalChinese}
+	catalogs  = map[language.Tag]map[string]string{
+		language.Spanish:            spanish,
+		language.TraditionalChinese: chinese,
+	}
+	matcher = language.NewMatcher(languages)
+)
+
+// catalog is the translations for the language set by --lang, or nil for
+// English.
+var catalog map[string]string
+
+// tr returns msg in the language set by --lang. Format strings are
+// translated with their verbs in the same order, or with explicit argument
+// indexes like %[2]s, so tr("... %d ...") can be passed to fmt.Printf.
+func tr(msg string) string {
+	if t, ok := catalog[msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// defaultLanguage returns the language of the locale, like es_US.UTF-8, to
+// use without --lang.
+func defaultLanguage() string {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if s := os.Getenv(v); s != "" {
+			return s
+		}
+	}
+	return "en"
+}
+
+// setLanguage makes tr translate into lang, a language like "es", "zh-TW" or
+// "es_MX.UTF-8". It returns an error for a language clipper doesn't speak.
+func setLanguage(lang string) error {
+	// Locales look like es_MX.UTF-8 or zh_TW@euro; language tags don't have
+	// the encoding or modifier.
+	if i := strings.IndexAny(lang, ".@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if lang == "C" || lang == "POSIX" {
+		lang = "en"
+	}
+	tag, err := language.Parse(strings.Replace(lang, "_", "-", -1))
+	if err != nil {
+		return fmt.Errorf("unknown language %q", lang)
+	}
+	_, i, conf := matcher.Match(tag)
+	if conf == language.No {
+		return fmt.Errorf("clipper doesn't speak %s; use en, es or zh", tag)
+	}
+	catalog = catalogs[languages[i]]
+	return nil
+}
+
+// newFlagSet returns a flag set for the command name, which prints its flags'
+// descriptions in the language set by --lang.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), tr("Usage of %s:\n"), "clipper "+name)
+		fs.VisitAll(func(f *flag.Flag) { f.Usage = tr(f.Usage) })
+		fs.PrintDefaults()
+	}
+	return fs
+}
@@ -0,0 +1,346 @@
+package main
+
+// spanish translates clipper's messages into Spanish.
+var spanish = map[string]string{
+	"Error %s: %v\n": "Error %s: %v\n",
+	"Summarize trips and spending from downloaded statements":                                                            "Resume viajes y gastos de los estados de cuenta descargados",
+	"List likely double-charges, with the statements they appear in":                                                     "Lista posibles cobros duplicados, con los estados de cuenta en que aparecen",
+	"List journeys from origin to destination, with transfers joined":                                                    "Lista los trayectos de origen a destino, uniendo los transbordos",
+	"Show when you ride, by hour, day of the week and week":                                                              "Muestra cuándo viaja, por hora, día de la semana y semana",
+	"Check recent activity for anomalies and send alerts":                                                                "Revisa la actividad reciente en busca de anomalías y envía alertas",
+	"Build a reimbursement report of work rides as CSV or PDF":                                                           "Crea un informe de reembolso de los viajes de trabajo en CSV o PDF",
+	"Write every transaction as CSV, JSON, JSON Lines, OFX or Beancount, for spreadsheets, scripts and finance programs": "Escribe todas las transacciones en CSV, JSON, JSON Lines, OFX o Beancount, para hojas de cálculo, scripts y programas de finanzas",
+	"Reconcile commuter benefit loads with spending and IRS limits":                                                      "Concilia las recargas del beneficio de transporte con los gastos y los límites del IRS",
+	"Estimate CO2 from rides, compared with driving":                                                                     "Estima el CO2 de los viajes, comparado con ir en coche",
+	"Compare passes and other fare products with paying as you go":                                                       "Compara pases y otros productos de tarifa con pagar por viaje",
+	"Write a year-in-review summary as Markdown or HTML":                                                                 "Escribe un resumen del año en Markdown o HTML",
+	"Total a month's spending across everyone's cards, per person":                                                       "Suma el gasto de un mes en las tarjetas de todos, por persona",
+	"Compare fares between two periods, adjusting for how much you rode":                                                 "Compara tarifas entre dos periodos, ajustando según cuánto viajó",
+	"Check rides on youth, senior, RTC and START cards for full-fare charges":                                            "Revisa los viajes con tarjetas de jóvenes, mayores, RTC y START en busca de cobros de tarifa completa",
+	"Draw spend per month, spend by agency or a card's balance as SVG or PNG":                                            "Dibuja el gasto por mes, el gasto por agencia o el saldo de una tarjeta en SVG o PNG",
+	"List rides with their purpose, or tag rides as work, personal or other":                                             "Lista los viajes con su propósito, o etiqueta viajes como trabajo, personal u otro",
+	"Show Caltrain spend per zone pair and compare zone passes and GoPass":                                               "Muestra el gasto en Caltrain por par de zonas y compara los pases de zona con GoPass",
+	"List rides taken late at night, with where they started and ended":                                                  "Lista los viajes de madrugada, con dónde empezaron y terminaron",
+	"Check every ride against posted fares and list overcharges worth disputing":                                         "Compara cada viaje con las tarifas publicadas y lista los cobros excesivos que vale la pena disputar",
+	"Serve a dashboard, a JSON REST API and Prometheus metrics, with token auth":                                         "Sirve un panel, una API REST en JSON y métricas de Prometheus, con autenticación por token",
+	"Alert when month-to-date spending crosses budget thresholds":                                                        "Avisa cuando el gasto del mes supera umbrales del presupuesto",
+	"Mail a month's transactions, balances and unusual activity, with CSV and HTML":                                      "Envía por correo las transacciones, saldos y actividad inusual de un mes, con CSV y HTML",
+	"Publish each card's balance and latest transaction to Home Assistant over MQTT":                                     "Publica el saldo y la última transacción de cada tarjeta en Home Assistant por MQTT",
+	"Write a database schema, data, Grafana dashboard and Docker Compose setup":                                          "Escribe un esquema de base de datos, datos, un panel de Grafana y una configuración de Docker Compose",
+	"Mask the card, dates and locations on a statement, to attach to a bug report":                                       "Oculta la tarjeta, las fechas y los lugares de un estado de cuenta, para adjuntarlo a un informe de error",
+	"Store Clipper credentials for several accounts, encrypted, for serve to sync":                                       "Guarda cifradas las credenciales de Clipper de varias cuentas, para que serve las sincronice",
+	"usage: clipper [--lang=en|es|zh] <command> [flags]\n\nCommands:\n":                                                  "uso: clipper [--lang=en|es|zh] <comando> [opciones]\n\nComandos:\n",
+	"Language to print messages in: en, es or zh (defaults to $CLIPPER_LANG, or the locale)":                             "Idioma de los mensajes: en, es o zh (por omisión $CLIPPER_LANG, o el de la configuración regional)",
+	"unknown command %q\n":     "comando desconocido %q\n",
+	"want AGENCY=PATH, got %q": "se esperaba AGENCIA=RUTA, se recibió %q",
+	"Start CSV output with a byte order mark, so Excel reads it as UTF-8":                                 "Empieza la salida CSV con una marca de orden de bytes, para que Excel la lea como UTF-8",
+	"End CSV lines with \\r\\n instead of \\n":                                                            "Termina las líneas CSV con \\r\\n en vez de \\n",
+	"Directory of downloaded statement PDFs":                                                              "Directorio de los PDF de estados de cuenta descargados",
+	"Only include transactions on or after this date (YYYY-MM-DD, Pacific time)":                          "Incluir solo transacciones en esta fecha o después (AAAA-MM-DD, hora del Pacífico)",
+	"Only include transactions before this date (YYYY-MM-DD, Pacific time)":                               "Incluir solo transacciones antes de esta fecha (AAAA-MM-DD, hora del Pacífico)",
+	"Load station metadata from a GTFS feed, as AGENCY=PATH (for example BART=bart.zip); may be repeated": "Carga datos de estaciones de un feed GTFS, como AGENCIA=RUTA (por ejemplo BART=bart.zip); puede repetirse",
+	"loading GTFS feed %s":                             "al cargar el feed GTFS %s",
+	"parsing start date":                               "al leer la fecha de inicio",
+	"parsing end date":                                 "al leer la fecha de fin",
+	"opening statement directory":                      "al abrir el directorio de estados de cuenta",
+	"loading transactions":                             "al cargar las transacciones",
+	"warning: couldn't write the audit log: %v\n":      "aviso: no se pudo escribir el registro de auditoría: %v\n",
+	"loading trip tags":                                "al cargar las etiquetas de viajes",
+	"Print plain text even when writing to a terminal": "Imprimir texto simple aunque la salida sea una terminal",
+	"No transactions found.":                           "No se encontraron transacciones.",
+	"Transactions from %s to %s\n\n":                   "Transacciones del %s al %s\n\n",
+	"Trips:                 %d\n":                      "Viajes:                   %d\n",
+	"Trips per week:        %.1f\n":                    "Viajes por semana:        %.1f\n",
+	"Total spend:           %s\n":                      "Gasto total:              %s\n",
+	"Average cost per trip: %s\n":                      "Costo promedio por viaje: %s\n",
+	"Journeys:              %d (%d transfers within an agency, %d between agencies)\n": "Trayectos:                %d (%d transbordos dentro de una agencia, %d entre agencias)\n",
+	"Transfer credits:      %s\n":                     "Créditos de transbordo:   %s\n",
+	"Average journey fare:  %s\n":                     "Tarifa promedio:          %s\n",
+	"\nSpend per month:\n":                            "\nGasto por mes:\n",
+	"  %s  %9s  %3d trips\n":                          "  %s  %9s  %3d viajes\n",
+	"\nSpend per agency:\n":                           "\nGasto por agencia:\n",
+	"Unknown":                                         "Desconocida",
+	"  %s  %-20s  %9s  %3d trips\n":                   "  %s  %-20s  %9s  %3d viajes\n",
+	"\nMissed tag-offs:\n":                            "\nSalidas sin marcar:\n",
+	"  %s  %3d rides  %9s overcharged\n":              "  %s  %3d viajes  %9s de más\n",
+	"\nBalance:\n":                                    "\nSaldo:\n",
+	"  Card %d  %9s as of %s":                         "  Tarjeta %d  %9s al %s",
+	", runs out around %s at %s/day\n":                ", se acaba hacia el %s a %s/día\n",
+	"\nUnexplained balance changes:\n":                "\nCambios de saldo sin explicar:\n",
+	"  Card %d  %s to %s  expected %s, got %s\n":      "  Tarjeta %d  %s a %s  se esperaba %s, hay %s\n",
+	"\nBusiest travel hours:\n":                       "\nHoras de más viajes:\n",
+	"  %02d:00-%02d:59  %3d trips\n":                  "  %02d:00-%02d:59  %3d viajes\n",
+	"Report identical charges at most this far apart": "Informar cobros idénticos separados como mucho por este tiempo",
+	"No likely double-charges found.":                 "No se encontraron posibles cobros duplicados.",
+	"%d. Card %d charged %s twice at %s, %s apart\n":  "%d. La tarjeta %d cobró %s dos veces en %s, con %s de diferencia\n",
+	"   %s  %s  %s  balance %s\n":                     "   %s  %s  %s  saldo %s\n",
+	"     from %s, row %d\n":                          "     de %s, fila %d\n",
+	"\nTotal disputed: %s in %d charges\n":            "\nTotal en disputa: %s en %d cobros\n",
+	"Base recommendations on this many recent months": "Basar las recomendaciones en esta cantidad de meses recientes",
+	"YAML table of fare products to compare (defaults to the built-in table)": "Tabla YAML de productos de tarifa para comparar (por omisión, la tabla incluida)",
+	"opening fare products": "al abrir los productos de tarifa",
+	"loading fare products": "al cargar los productos de tarifa",
+	"No rides to compare.":  "No hay viajes para comparar.",
+	"pay as you go":         "pagar por viaje",
+	"buy":                   "comprar",
+	"%s  %-36s  %3d rides  %9s vs %9s  -> %s\n":            "%s  %-36s  %3d viajes  %9s vs %9s  -> %s\n",
+	"\nBased on the last %d months with rides:\n":          "\nSegún los últimos %d meses con viajes:\n",
+	"  Buy %s: saves about %s a month\n":                   "  Compre %s: ahorra unos %s al mes\n",
+	"  Skip %s: paying as you go saves about %s a month\n": "  No compre %s: pagar por viaje ahorra unos %s al mes\n",
+	"Print the monthly summary as CSV":                     "Imprimir el resumen mensual en CSV",
+	"writing CSV":                                          "al escribir el CSV",
+	"No rides found.":                                      "No se encontraron viajes.",
+	"%s  %3d trips  %6.1f miles  %6.1f kg CO2 by transit vs %6.1f kg driving, saved %6.1f kg\n": "%s  %3d viajes  %6.1f millas  %6.1f kg de CO2 en transporte público vs %6.1f kg en coche, ahorro de %6.1f kg\n",
+	"\nTotal saved: %.1f kg CO2\n": "\nAhorro total: %.1f kg de CO2\n",
+	"Output format: text, csv, json or timeline (Google Timeline-style location history JSON)": "Formato de salida: text, csv, json o timeline (historial de ubicaciones en JSON al estilo de Google Timeline)",
+	"writing timeline": "al escribir la línea de tiempo",
+	"writing JSON":     "al escribir el JSON",
+	"%s  %-24s -> %-24s  %4.0f min  %9s  %s\n":                             "%s  %-24s -> %-24s  %4.0f min  %9s  %s\n",
+	"unknown format %q\n":                                                  "formato desconocido %q\n",
+	"Print the statistics as JSON, for charting":                           "Imprimir las estadísticas en JSON, para graficarlas",
+	"Weekday trips: %d\nWeekend trips: %d\n\n":                             "Viajes entre semana: %d\nViajes en fin de semana: %d\n\n",
+	"\nTrips per week:\n":                                                  "\nViajes por semana:\n",
+	"  %s  %3d weekday  %3d weekend  %d days\n":                            "  %s  %3d entre semana  %3d fin de semana  %d días\n",
+	"Check activity in this many days before the latest transaction":       "Revisar la actividad de esta cantidad de días antes de la última transacción",
+	"POST alerts as JSON to this URL":                                      "Enviar las alertas en JSON por POST a esta URL",
+	"Run this program for each alert, with the alert as JSON on stdin":     "Ejecutar este programa por cada alerta, con la alerta en JSON por la entrada estándar",
+	"Unusual activity on Clipper card %d":                                  "Actividad inusual en la tarjeta Clipper %d",
+	"Error sending alert: %v\n":                                            "Error al enviar la alerta: %v\n",
+	"YAML file of rules for which rides to claim (defaults to every ride)": "Archivo YAML de reglas sobre qué viajes reclamar (por omisión, todos)",
+	"Output format: csv or pdf":                                            "Formato de salida: csv o pdf",
+	"Write the report to this file instead of stdout":                      "Escribir el informe en este archivo en vez de la salida estándar",
+	"opening rules":        "al abrir las reglas",
+	"loading rules":        "al cargar las reglas",
+	"creating output file": "al crear el archivo de salida",
+	"writing report":       "al escribir el informe",
+	"Output format: csv, json, jsonl, ofx or beancount":                              "Formato de salida: csv, json, jsonl, ofx o beancount",
+	"YAML file of rules for which Beancount accounts to post transactions to":        "Archivo YAML de reglas sobre a qué cuentas de Beancount asentar las transacciones",
+	"Write the transactions to this file instead of stdout":                          "Escribir las transacciones en este archivo en vez de la salida estándar",
+	"Account number for an OFX statement (defaults to the first transaction's card)": "Número de cuenta para un extracto OFX (por defecto, la tarjeta de la primera transacción)",
+	"writing transactions": "al escribir las transacciones",
+	"Comma-separated text that identifies benefit loads in a reload's type, location or product": "Texto separado por comas que identifica las recargas del beneficio en el tipo, el lugar o el producto de una recarga",
+	"Month":                          "Mes",
+	"Loaded":                         "Cargado",
+	"Spent":                          "Gastado",
+	"Unused":                         "Sin usar",
+	"IRS limit":                      "Límite IRS",
+	"  over the limit by %s":         "  %s por encima del límite",
+	"\nUnused benefit balance: %s\n": "\nSaldo del beneficio sin usar: %s\n",
+	"Some months exceed the IRS monthly limit; the excess may be taxable.\n": "Algunos meses superan el límite mensual del IRS; el excedente puede tributar.\n",
+	"Year to review (defaults to the latest year in the archive)":            "Año a resumir (por omisión, el último año del archivo)",
+	"Output format: markdown or html":                                        "Formato de salida: markdown o html",
+	"Write the review to this file instead of stdout":                        "Escribir el resumen en este archivo en vez de la salida estándar",
+	"writing review":                        "al escribir el resumen del año",
+	"reading config file":                   "al leer el archivo de configuración",
+	"parsing config file":                   "al interpretar el archivo de configuración",
+	"reading card types for %s":             "al leer los tipos de tarjeta de %s",
+	"%s (card %d)":                          "%s (tarjeta %d)",
+	"Config file listing each user's cards": "Archivo de configuración con las tarjetas de cada usuario",
+	"Month to report on, as YYYY-MM in Pacific time (defaults to the latest month in the archive)": "Mes del informe, como AAAA-MM en hora del Pacífico (por omisión, el último mes del archivo)",
+	"Also POST the report as JSON to this URL":                                                     "Enviar también el informe en JSON por POST a esta URL",
+	"Also run this program with the report as JSON on stdin":                                       "Ejecutar también este programa con el informe en JSON por la entrada estándar",
+	"Attach a PNG chart of spend per person to the report":                                         "Adjuntar al informe un gráfico PNG del gasto por persona",
+	"parsing month":                   "al leer el mes",
+	"%-20s  %4d trips  %10s\n":        "%-20s  %4d viajes  %10s\n",
+	"  card %-13d  %4d trips  %10s\n": "  tarjeta %-13d  %4d viajes  %10s\n",
+	"Household total":                 "Total del hogar",
+	"drawing chart":                   "al dibujar el gráfico",
+	"sending report":                  "al enviar el informe",
+	"Config file with the SMTP server to mail the digest through": "Archivo de configuración con el servidor SMTP por el que enviar el resumen",
+	"Also POST the digest as JSON to this URL":                    "Enviar también el resumen en JSON por POST a esta URL",
+	"Also run this program with the digest as JSON on stdin":      "Ejecutar también este programa con el resumen en JSON por la entrada estándar",
+	"writing digest": "al escribir el resumen",
+	"writing HTML":   "al escribir el HTML",
+	"no smtp server in %s; printing the digest only\n": "no hay servidor smtp en %s; solo se imprime el resumen\n",
+	"sending digest": "al enviar el resumen",
+	"MQTT broker URL, like tcp://homeassistant.local:1883": "URL del broker MQTT, como tcp://homeassistant.local:1883",
+	"MQTT username": "Usuario de MQTT",
+	"MQTT password (defaults to $MQTT_PASSWORD)":             "Contraseña de MQTT (por omisión $MQTT_PASSWORD)",
+	"Home Assistant's MQTT discovery prefix":                 "Prefijo de descubrimiento MQTT de Home Assistant",
+	"Prefix of the topics to publish card states to":         "Prefijo de los temas en que publicar el estado de las tarjetas",
+	"homeassistant needs --broker":                           "homeassistant necesita --broker",
+	"building messages":                                      "al crear los mensajes",
+	"timed out":                                              "se agotó el tiempo",
+	"connecting to %s":                                       "al conectar con %s",
+	"publishing":                                             "al publicar",
+	"Published %d messages to %s\n":                          "Se publicaron %d mensajes en %s\n",
+	"Database to load transactions into: postgres or sqlite": "Base de datos en que cargar las transacciones: postgres o sqlite",
+	"Directory to write the setup to":                        "Directorio en que escribir la configuración",
+	"parsing --db":                                           "al leer --db",
+	"building Grafana setup":                                 "al crear la configuración de Grafana",
+	"creating directory":                                     "al crear el directorio",
+	"writing %s":                                             "al escribir %s",
+	"Wrote %d files to %s; run \"docker compose up\" there and open http://localhost:3000\n":         "Se escribieron %d archivos en %s; ejecute \"docker compose up\" allí y abra http://localhost:3000\n",
+	"Seed for how far to move dates (defaults to a random one)":                                      "Semilla para cuánto mover las fechas (por omisión, una al azar)",
+	"Move every date by this many days (defaults to a whole number of weeks back, picked by --seed)": "Mover cada fecha esta cantidad de días (por omisión, un número entero de semanas hacia atrás, elegido con --seed)",
+	"Output format: pdf, or csv for the parsed transactions":                                         "Formato de salida: pdf, o csv para las transacciones leídas",
+	"Write the scrubbed statement to this file instead of stdout":                                    "Escribir el estado de cuenta ocultado en este archivo en vez de la salida estándar",
+	"usage: clipper scrub [flags] STATEMENT.pdf\n":                                                   "uso: clipper scrub [opciones] ESTADO.pdf\n",
+	"opening statement":                                     "al abrir el estado de cuenta",
+	"parsing statement":                                     "al leer el estado de cuenta",
+	"scrubbing statement":                                   "al ocultar datos del estado de cuenta",
+	"Wrote %s; check it by hand before sharing it\n":        "Se escribió %s; revíselo a mano antes de compartirlo\n",
+	"Config file listing each user's cards and budgets":     "Archivo de configuración con las tarjetas y presupuestos de cada usuario",
+	"Comma-separated percentages of the budget to alert at": "Porcentajes del presupuesto, separados por comas, en los que avisar",
+	"Alert on thresholds crossed in this many days before the latest transaction": "Avisar de los umbrales superados en esta cantidad de días antes de la última transacción",
+	"parsing thresholds":                         "al leer los umbrales",
+	"no budgets in %s\n":                         "no hay presupuestos en %s\n",
+	"%s has spent %d%% of the %s transit budget": "%s ha gastado el %d%% del presupuesto de transporte de %s",
+	"Spent %s of %s so far in %s.":               "Gastado %s de %s hasta ahora en %s.",
+	"\nAutoload:\n":                              "\nRecarga automática:\n",
+	"  Card %d  spends %s/day; ":                 "  Tarjeta %d  gasta %s/día; ",
+	"never reloaded":                             "nunca recargada",
+	"reloaded %d times (%d by autoload)":         "recargada %d veces (%d automáticamente)",
+	" every %.0f days":                           " cada %.0f días",
+	", averaging %s":                             ", con un promedio de %s",
+	"\n    Average balance %s, %d rides left too little for the next fare\n":                          "\n    Saldo promedio %s, %d viajes con saldo insuficiente para la siguiente tarifa\n",
+	"    Suggest: autoload %s when below %s (about every %.0f days, keeping about %s on the card),\n": "    Sugerencia: recarga automática de %s cuando baje de %s (cada %.0f días aprox., manteniendo unos %s en la tarjeta),\n",
+	"    or add %s by hand every %.0f days\n":                                                         "    o agregar %s a mano cada %.0f días\n",
+	"want START..END, got %q":                                   "se esperaba INICIO..FIN, se recibió %q",
+	"period %q ends before it starts":                           "el periodo %q termina antes de empezar",
+	"Earlier period, as YYYY-MM-DD..YYYY-MM-DD (end exclusive)": "Periodo anterior, como AAAA-MM-DD..AAAA-MM-DD (sin incluir el fin)",
+	"Later period, as YYYY-MM-DD..YYYY-MM-DD (end exclusive)":   "Periodo posterior, como AAAA-MM-DD..AAAA-MM-DD (sin incluir el fin)",
+	"parsing --before":                                          "al leer --before",
+	"parsing --after":                                           "al leer --after",
+	"Agency":                                                    "Agencia",
+	"Before":                                                    "Antes",
+	"After":                                                     "Después",
+	"Change":                                                    "Cambio",
+	"Per month":                                                 "Por mes",
+	"Other":                                                     "Otra",
+	"Total":                                                     "Total",
+	"\nAt %.1f rides a month, fare changes cost you %s a month.\n":                   "\nCon %.1f viajes al mes, los cambios de tarifa le cuestan %s al mes.\n",
+	"Config file listing each user's card types":                                     "Archivo de configuración con los tipos de tarjeta de cada usuario",
+	"YAML file of discount fares to check against (defaults to built-in 2025 fares)": "Archivo YAML de tarifas con descuento para comparar (por omisión, las tarifas incluidas de 2025)",
+	"no card_types in %s\n": "no hay card_types en %s\n",
+	"opening fares":         "al abrir las tarifas",
+	"loading fares":         "al cargar las tarifas",
+	"All checked rides were charged the discounted fare.":                      "A todos los viajes revisados se les cobró la tarifa con descuento.",
+	"%s  card %d  %-6s  %-20s  charged %s, expected %s%s\n":                    "%s  tarjeta %d  %-6s  %-20s  cobrado %s, esperado %s%s\n",
+	"\n%d rides overcharged by %s in total.\n":                                 "\n%d viajes con cobro excesivo de %s en total.\n",
+	"Chart to draw: spend (per month), agencies or balance":                    "Gráfico a dibujar: spend (por mes), agencies o balance",
+	"Card to chart the balance of (defaults to the first card in the archive)": "Tarjeta cuyo saldo graficar (por omisión, la primera tarjeta del archivo)",
+	"Output format: svg or png":                                                "Formato de salida: svg o png",
+	"Write the chart to this file instead of stdout":                           "Escribir el gráfico en este archivo en vez de la salida estándar",
+	"no cards in the archive":                                                  "no hay tarjetas en el archivo",
+	"Balance on card %d":                                                       "Saldo de la tarjeta %d",
+	"unknown chart kind %q\n":                                                  "tipo de gráfico desconocido %q\n",
+	"writing chart":                                                            "al escribir el gráfico",
+	"want ID=PURPOSE, got %q\n":                                                "se esperaba ID=PROPÓSITO, se recibió %q\n",
+	"tagging %s":                                                               "al etiquetar %s",
+	"YAML table of fare products with Caltrain zone passes (defaults to the built-in table)": "Tabla YAML de productos de tarifa con pases de zona de Caltrain (por omisión, la tabla incluida)",
+	"Annual price of a GoPass, in dollars":                                                   "Precio anual de un GoPass, en dólares",
+	"No Caltrain rides with known zones.":                                                    "No hay viajes en Caltrain con zonas conocidas.",
+	"Zones":                                                                                  "Zonas",
+	"Rides":                                                                                  "Viajes",
+	"Spend":                                                                                  "Gasto",
+	"Average":                                                                                "Promedio",
+	"Paid":                                                                                   "Pagado",
+	"Pass":                                                                                   "Pase",
+	"With pass":                                                                              "Con pase",
+	"GoPass":                                                                                 "GoPass",
+	"Cheapest":                                                                               "Más barato",
+	"zone pass":                                                                              "pase de zona",
+	"\nPaid %s; monthly zone passes would have cost %s, and a GoPass %s.\n":                      "\nPagó %s; los pases de zona mensuales habrían costado %s, y un GoPass %s.\n",
+	"A GoPass is bought by employers; if yours provides one, Caltrain rides cost you nothing.\n": "Los GoPass los compran los empleadores; si el suyo le da uno, los viajes en Caltrain no le cuestan nada.\n",
+	"Hours to report on, like 0-5 for midnight to 4:59am or 22-5 to start at 10pm":               "Horas a informar, como 0-5 para medianoche a 4:59am o 22-5 para empezar a las 10pm",
+	"Only report on rides on this card (defaults to every card)":                                 "Informar solo de viajes con esta tarjeta (por omisión, todas)",
+	"parsing --hours":          "al leer --hours",
+	"No rides between %s.\n":   "No hay viajes entre %s.\n",
+	"%d rides between %s:\n\n": "%d viajes entre %s:\n\n",
+	"\nPer month:\n":           "\nPor mes:\n",
+	"\nPlaces:\n":              "\nLugares:\n",
+	"Config file listing each user's card types (cards not listed pay adult fares)": "Archivo de configuración con los tipos de tarjeta de cada usuario (las tarjetas no listadas pagan tarifa de adulto)",
+	"YAML table of posted fares to check against (defaults to the built-in table)":  "Tabla YAML de tarifas publicadas para comparar (por omisión, la tabla incluida)",
+	"Only list overcharges of at least this many dollars":                           "Listar solo cobros excesivos de al menos esta cantidad de dólares",
+	"Print the fare table as YAML, to start your own, and exit":                     "Imprimir la tabla de tarifas en YAML, como punto de partida de la suya, y salir",
+	"writing fares": "al escribir las tarifas",
+	"%s  card %d  %-6s  %-20s  %-24s -> %-24s  charged %s, posted %s\n":                        "%s  tarjeta %d  %-6s  %-20s  %-24s -> %-24s  cobrado %s, publicado %s\n",
+	"No overcharges in %d rides checked.":                                                      "Ningún cobro excesivo en %d viajes revisados.",
+	"\n%d of %d rides checked were overcharged by %s in total.":                                "\n%d de %d viajes revisados tuvieron un cobro excesivo de %s en total.",
+	" %d rides had no posted fare to check against.\n":                                         " %d viajes no tenían tarifa publicada con la que comparar.\n",
+	"creating client for %s: %v":                                                               "no se pudo crear el cliente de %s: %v",
+	"downloading statements for %s: %v":                                                        "no se pudieron descargar los estados de cuenta de %s: %v",
+	"set $CLIPPER_VAULT_KEY to the vault's master key; make one with \"clipper vault keygen\"": "defina $CLIPPER_VAULT_KEY con la clave maestra de la bóveda; cree una con \"clipper vault keygen\"",
+	"loading $CLIPPER_VAULT_KEY":                                                               "al cargar $CLIPPER_VAULT_KEY",
+	"opening vault":                                                                            "al abrir la bóveda",
+	"Clipper password: ":                                                                       "Contraseña de Clipper: ",
+	"reading password":                                                                         "al leer la contraseña",
+	"Vault file":                                                                               "Archivo de la bóveda",
+	"usage: clipper vault [--vault=vault.json] keygen | list | add NAME EMAIL [CARD ...] | remove NAME":       "uso: clipper vault [--vault=vault.json] keygen | list | add NOMBRE CORREO [TARJETA ...] | remove NOMBRE",
+	"\nThe master key is read from $CLIPPER_VAULT_KEY, and passwords from $CLIPPER_PASSWORD or the terminal.": "\nLa clave maestra se lee de $CLIPPER_VAULT_KEY, y las contraseñas de $CLIPPER_PASSWORD o de la terminal.",
+	"%-16s  %-32s  %d cards\n":   "%-16s  %-32s  %d tarjetas\n",
+	"parsing card serial number": "al leer el número de serie de la tarjeta",
+	"adding %s":                  "al agregar %s",
+	"removing %s":                "al quitar %s",
+	"URL of the running server":  "URL del servidor en ejecución",
+	"Token with the admin scope (defaults to $CLIPPER_API_TOKEN)": "Token con el alcance admin (por omisión $CLIPPER_API_TOKEN)",
+	"usage: clipper serve admin [--server=http://127.0.0.1:7066] [--token=TOKEN] jobs | run-job NAME | accounts | retry | audit [FILTER=VALUE ...] | tokens | create-token NAME SCOPE,... [CARD ...] | revoke-token NAME | rotate-token NAME": "uso: clipper serve admin [--server=http://127.0.0.1:7066] [--token=TOKEN] jobs | run-job NOMBRE | accounts | retry | audit [FILTRO=VALOR ...] | tokens | create-token NOMBRE ALCANCE,... [TARJETA ...] | revoke-token NOMBRE | rotate-token NOMBRE",
+	"building request": "al crear la solicitud",
+	"calling server":   "al llamar al servidor",
+	"reading response": "al leer la respuesta",
+	"No scheduled jobs; start the server with --sync-every to add one.": "No hay tareas programadas; inicie el servidor con --sync-every para agregar una.",
+	"everyone": "todos",
+	"%-16s  %-9s  every %-8s  last run %-16s  next run %s\n": "%-16s  %-9s  cada %-8s  última %-16s  próxima %s\n",
+	"%-16s  %d failures, last: %s\n":                         "%-16s  %d fallos, último: %s\n",
+	"Running %s\n":                                           "Ejecutando %s\n",
+	"ok":                                                     "bien",
+	"failed: ":                                               "falló: ",
+	"not synced yet":                                         "sin sincronizar aún",
+	"%-16s  last run %-16s  last success %-16s  %s\n": "%-16s  última %-16s  último éxito %-16s  %s\n",
+	"No failed accounts to retry.":                    "No hay cuentas fallidas que reintentar.",
+	"Retrying %s\n":                                   "Reintentando %s\n",
+	"every card":                                      "todas las tarjetas",
+	"cards ":                                          "tarjetas ",
+	"use --user-agent or --rotate-user-agent, not both":                                "use --user-agent o --rotate-user-agent, no ambos",
+	"Config file with the credentials to sync with (optional)":                         "Archivo de configuración con las credenciales con que sincronizar (opcional)",
+	"Address to listen on":                                                             "Dirección en que escuchar",
+	"Serve a GraphQL endpoint at /graphql":                                             "Servir un endpoint GraphQL en /graphql",
+	"Serve Plaid-compatible /plaid/transactions/get and /plaid/accounts/get endpoints": "Servir los endpoints compatibles con Plaid /plaid/transactions/get y /plaid/accounts/get",
+	"Address to serve the gRPC API on, like 127.0.0.1:7067 (off by default)":           "Dirección en que servir la API gRPC, como 127.0.0.1:7067 (desactivada por omisión)",
+	"Token with every scope that clients can send (defaults to $CLIPPER_API_TOKEN)":    "Token con todos los alcances que pueden enviar los clientes (por omisión $CLIPPER_API_TOKEN)",
+	"YAML list of further tokens, each with a name, scopes (read, balances, sync, metrics, admin) and optionally cards; tokens made, revoked or rotated with \"clipper serve admin\" are saved back to it": "Lista YAML de más tokens, cada uno con un nombre, alcances (read, balances, sync, metrics, admin) y opcionalmente tarjetas; los tokens creados, revocados o rotados con \"clipper serve admin\" se guardan en ella",
+	"Sync every account this often, like 24h (off by default)":                                                                                 "Sincronizar todas las cuentas con esta frecuencia, como 24h (desactivado por omisión)",
+	"Vault of tenants' credentials to sync, made with \"clipper vault\" (needs $CLIPPER_VAULT_KEY)":                                            "Bóveda con las credenciales de los inquilinos a sincronizar, creada con \"clipper vault\" (necesita $CLIPPER_VAULT_KEY)",
+	"On SIGTERM, how long to wait for a running sync and open requests":                                                                        "Al recibir SIGTERM, cuánto esperar a una sincronización en curso y a las solicitudes abiertas",
+	"Signing secret of a Slack app whose /clipper command points at /slack (defaults to $SLACK_SIGNING_SECRET)":                                "Secreto de firma de una app de Slack cuyo comando /clipper apunta a /slack (por omisión $SLACK_SIGNING_SECRET)",
+	"Slack incoming webhook URL for low balance and unusual activity alerts after syncs (defaults to $SLACK_WEBHOOK_URL)":                      "URL del webhook entrante de Slack para alertas de saldo bajo y actividad inusual tras sincronizar (por omisión $SLACK_WEBHOOK_URL)",
+	"User-Agent to sync with (defaults to $CLIPPER_USER_AGENT, or a recent Chrome)":                                                            "User-Agent con que sincronizar (por omisión $CLIPPER_USER_AGENT, o un Chrome reciente)",
+	"Sync as a browser picked at random from recent ones at each login":                                                                        "Sincronizar como un navegador reciente elegido al azar en cada inicio de sesión",
+	"Sync over HTTP/1.1 only, for when the Clipper site's firewall mishandles HTTP/2":                                                          "Sincronizar solo por HTTP/1.1, para cuando el firewall del sitio de Clipper maneja mal HTTP/2",
+	"Comma-separated base64 SHA-256 hashes of public keys; sync only with servers whose certificate chain has one (defaults to $CLIPPER_PINS)": "Hashes SHA-256 en base64 de claves públicas, separados por comas; sincronizar solo con servidores cuya cadena de certificados tenga uno (por omisión $CLIPPER_PINS)",
+	"Keep each account's session in the system keychain, and reuse it instead of logging in while it lasts":                                    "Guardar la sesión de cada cuenta en el llavero del sistema, y reutilizarla en vez de iniciar sesión mientras dure",
+	"Wait at least this long between requests to the Clipper site, across all accounts, after a burst of --burst requests":                     "Esperar al menos este tiempo entre solicitudes al sitio de Clipper, entre todas las cuentas, tras una ráfaga de --burst solicitudes",
+	"Number of requests to send at once before pacing them by --min-interval":                                                                  "Número de solicitudes que enviar de una vez antes de espaciarlas según --min-interval",
+	"URL of the Clipper site to sync with, like http://127.0.0.1:7080 for clippermock (defaults to $CLIPPER_SITE_URL, or clippercard.com)":     "URL del sitio de Clipper con que sincronizar, como http://127.0.0.1:7080 para clippermock (por omisión $CLIPPER_SITE_URL, o clippercard.com)",
+	"opening tokens file": "al abrir el archivo de tokens",
+	"loading tokens":      "al cargar los tokens",
+	"serve needs a token: set --token, $CLIPPER_API_TOKEN or --tokens": "serve necesita un token: defina --token, $CLIPPER_API_TOKEN o --tokens",
+	"%s is in both %s and %s\n":                                        "%s está tanto en %s como en %s\n",
+	"no credentials in %s; POST /sync is disabled\n":                   "no hay credenciales en %s; POST /sync está desactivado\n",
+	"adding token": "al agregar el token",
+	"--sync-every needs credentials to sync with": "--sync-every necesita credenciales con que sincronizar",
+	"scheduling syncs":          "al programar las sincronizaciones",
+	"starting GraphQL":          "al iniciar GraphQL",
+	"listening for gRPC":        "al escuchar para gRPC",
+	"Serving gRPC on %s\n":      "Sirviendo gRPC en %s\n",
+	"serving gRPC":              "al servir gRPC",
+	"Serving %s on http://%s\n": "Sirviendo %s en http://%s\n",
+	"serving":                   "al servir",
+	"Shutting down; waiting up to %s for a running sync\n": "Cerrando; se esperará hasta %s a una sincronización en curso\n",
+	"Sync didn't finish before shutdown: %v\n":             "La sincronización no terminó antes del cierre: %v\n",
+	"shutting down":           "al cerrar",
+	"Trips\t%9d\t%s\n":        "Viajes\t%9d\t%s\n",
+	"Spend\t%9s\t%s\n":        "Gasto\t%9s\t%s\n",
+	"Trips per week\t%9.1f\n": "Viajes por semana\t%9.1f\n",
+	"Average trip\t%9s\n":     "Viaje promedio\t%9s\n",
+	"Journeys\t%9d\ttransfers: %d within an agency, %d between agencies\n": "Trayectos\t%9d\ttransbordos: %d dentro de una agencia, %d entre agencias\n",
+	"Average journey\t%9s\n":                  "Trayecto promedio\t%9s\n",
+	"Transfer credits\t%9s\n":                 "Créditos de transbordo\t%9s\n",
+	"\nMonth\tTrips\t    Spend\n":             "\nMes\tViajes\t    Gasto\n",
+	"\nAgency\tTrips\t    Spend\tBy month\n":  "\nAgencia\tViajes\t    Gasto\tPor mes\n",
+	"\nMissed tag-offs\tRides\tOvercharged\n": "\nSalidas sin marcar\tViajes\tDe más\n",
+	"\nCard\t  Balance\tRuns out\tHistory\n":  "\nTarjeta\t    Saldo\tSe acaba\tHistorial\n",
+	"\nTrips by hour\t%s\n":                   "\nViajes por hora\t%s\n",
+	"Usage of %s:\n":                          "Uso de %s:\n",
+}
@@ -0,0 +1,347 @@
+package main
+
+// chinese translates clipper's messages into Traditional Chinese, as on the
+// Clipper site.
+var chinese = map[string]string{
+	"Error %s: %v\n": "%s時出錯：%v\n",
+	"Summarize trips and spending from downloaded statements":                                                            "彙整已下載對帳單中的乘車與花費",
+	"List likely double-charges, with the statements they appear in":                                                     "列出可能的重複扣款，以及出現這些扣款的對帳單",
+	"List journeys from origin to destination, with transfers joined":                                                    "列出從起點到終點的旅程，並合併轉乘",
+	"Show when you ride, by hour, day of the week and week":                                                              "依小時、星期幾和週顯示您何時搭車",
+	"Check recent activity for anomalies and send alerts":                                                                "檢查近期活動是否異常並傳送警示",
+	"Build a reimbursement report of work rides as CSV or PDF":                                                           "將公務乘車製成 CSV 或 PDF 報銷報告",
+	"Write every transaction as CSV, JSON, JSON Lines, OFX or Beancount, for spreadsheets, scripts and finance programs": "將所有交易寫成 CSV、JSON、JSON Lines、OFX 或 Beancount，供試算表、指令碼和理財程式使用",
+	"Reconcile commuter benefit loads with spending and IRS limits":                                                      "核對通勤福利儲值與花費及 IRS 上限",
+	"Estimate CO2 from rides, compared with driving":                                                                     "估算乘車的二氧化碳排放，並與開車比較",
+	"Compare passes and other fare products with paying as you go":                                                       "比較月票等票種與按次付費",
+	"Write a year-in-review summary as Markdown or HTML":                                                                 "以 Markdown 或 HTML 撰寫年度回顧",
+	"Total a month's spending across everyone's cards, per person":                                                       "統計每個人所有卡片一個月的花費",
+	"Compare fares between two periods, adjusting for how much you rode":                                                 "比較兩段期間的車資，並依搭乘次數調整",
+	"Check rides on youth, senior, RTC and START cards for full-fare charges":                                            "檢查青年、長者、RTC 和 START 卡的乘車是否被收取全票",
+	"Draw spend per month, spend by agency or a card's balance as SVG or PNG":                                            "將每月花費、各業者花費或卡片餘額繪成 SVG 或 PNG",
+	"List rides with their purpose, or tag rides as work, personal or other":                                             "列出乘車及其用途，或將乘車標記為公務、私人或其他",
+	"Show Caltrain spend per zone pair and compare zone passes and GoPass":                                               "顯示各 Caltrain 區間的花費，並比較區間月票與 GoPass",
+	"List rides taken late at night, with where they started and ended":                                                  "列出深夜的乘車，以及起訖地點",
+	"Check every ride against posted fares and list overcharges worth disputing":                                         "將每次乘車與公告票價核對，並列出值得申訴的多收費",
+	"Serve a dashboard, a JSON REST API and Prometheus metrics, with token auth":                                         "提供儀表板、JSON REST API 和 Prometheus 指標，並以權杖驗證",
+	"Alert when month-to-date spending crosses budget thresholds":                                                        "當月累計花費超過預算門檻時發出警示",
+	"Mail a month's transactions, balances and unusual activity, with CSV and HTML":                                      "以郵件寄送一個月的交易、餘額和異常活動，附 CSV 和 HTML",
+	"Publish each card's balance and latest transaction to Home Assistant over MQTT":                                     "透過 MQTT 將每張卡片的餘額和最新交易發佈到 Home Assistant",
+	"Write a database schema, data, Grafana dashboard and Docker Compose setup":                                          "產生資料庫結構、資料、Grafana 儀表板和 Docker Compose 設定",
+	"Mask the card, dates and locations on a statement, to attach to a bug report":                                       "遮蔽對帳單上的卡號、日期和地點，以便附在錯誤回報中",
+	"Store Clipper credentials for several accounts, encrypted, for serve to sync":                                       "加密儲存多個帳戶的 Clipper 憑證，供 serve 同步",
+	"usage: clipper [--lang=en|es|zh] <command> [flags]\n\nCommands:\n":                                                  "用法：clipper [--lang=en|es|zh] <指令> [選項]\n\n指令：\n",
+	"Language to print messages in: en, es or zh (defaults to $CLIPPER_LANG, or the locale)":                             "訊息語言：en、es 或 zh（預設為 $CLIPPER_LANG，或系統語言環境）",
+	"unknown command %q\n":     "未知的指令 %q\n",
+	"want AGENCY=PATH, got %q": "應為 業者=路徑，卻是 %q",
+	"Start CSV output with a byte order mark, so Excel reads it as UTF-8":                                 "CSV 輸出開頭加上位元組順序標記，讓 Excel 以 UTF-8 讀取",
+	"End CSV lines with \\r\\n instead of \\n":                                                            "CSV 每行以 \\r\\n 而非 \\n 結尾",
+	"Directory of downloaded statement PDFs":                                                              "已下載對帳單 PDF 的目錄",
+	"Only include transactions on or after this date (YYYY-MM-DD, Pacific time)":                          "只包含此日期當天或之後的交易（YYYY-MM-DD，太平洋時間）",
+	"Only include transactions before this date (YYYY-MM-DD, Pacific time)":                               "只包含此日期之前的交易（YYYY-MM-DD，太平洋時間）",
+	"Load station metadata from a GTFS feed, as AGENCY=PATH (for example BART=bart.zip); may be repeated": "從 GTFS 資料載入車站資訊，格式為 業者=路徑（例如 BART=bart.zip）；可重複指定",
+	"loading GTFS feed %s":                             "載入 GTFS 資料 %s",
+	"parsing start date":                               "解析開始日期",
+	"parsing end date":                                 "解析結束日期",
+	"opening statement directory":                      "開啟對帳單目錄",
+	"loading transactions":                             "載入交易",
+	"warning: couldn't write the audit log: %v\n":      "警告：無法寫入稽核記錄：%v\n",
+	"loading trip tags":                                "載入乘車標記",
+	"Print plain text even when writing to a terminal": "即使輸出到終端機也列印純文字",
+	"No transactions found.":                           "找不到交易。",
+	"Transactions from %s to %s\n\n":                   "%s 至 %s 的交易\n\n",
+	"Trips:                 %d\n":                      "乘車次數：      %d\n",
+	"Trips per week:        %.1f\n":                    "每週乘車次數：  %.1f\n",
+	"Total spend:           %s\n":                      "總花費：        %s\n",
+	"Average cost per trip: %s\n":                      "每次平均花費：  %s\n",
+	"Journeys:              %d (%d transfers within an agency, %d between agencies)\n": "旅程數：        %d（業者內轉乘 %d 次，跨業者轉乘 %d 次）\n",
+	"Transfer credits:      %s\n":                     "轉乘優惠：      %s\n",
+	"Average journey fare:  %s\n":                     "旅程平均車資：  %s\n",
+	"\nSpend per month:\n":                            "\n每月花費：\n",
+	"  %s  %9s  %3d trips\n":                          "  %s  %9s  %3d 次乘車\n",
+	"\nSpend per agency:\n":                           "\n各業者花費：\n",
+	"Unknown":                                         "未知",
+	"  %s  %-20s  %9s  %3d trips\n":                   "  %s  %-20s  %9s  %3d 次乘車\n",
+	"\nMissed tag-offs:\n":                            "\n下車未刷卡：\n",
+	"  %s  %3d rides  %9s overcharged\n":              "  %s  %3d 次乘車  多收 %9s\n",
+	"\nBalance:\n":                                    "\n餘額：\n",
+	"  Card %d  %9s as of %s":                         "  卡片 %d  %9s（截至 %s）",
+	", runs out around %s at %s/day\n":                "，以每天 %[2]s 計，約於 %[1]s 用完\n",
+	"\nUnexplained balance changes:\n":                "\n無法解釋的餘額變動：\n",
+	"  Card %d  %s to %s  expected %s, got %s\n":      "  卡片 %d  %s 至 %s  應為 %s，實為 %s\n",
+	"\nBusiest travel hours:\n":                       "\n最常搭車的時段：\n",
+	"  %02d:00-%02d:59  %3d trips\n":                  "  %02d:00-%02d:59  %3d 次乘車\n",
+	"Report identical charges at most this far apart": "回報間隔不超過此時間的相同扣款",
+	"No likely double-charges found.":                 "找不到可能的重複扣款。",
+	"%d. Card %d charged %s twice at %s, %s apart\n":  "%d. 卡片 %d 在 %[4]s 兩次扣款 %[3]s，相隔 %[5]s\n",
+	"   %s  %s  %s  balance %s\n":                     "   %s  %s  %s  餘額 %s\n",
+	"     from %s, row %d\n":                          "     來自 %s，第 %d 列\n",
+	"\nTotal disputed: %s in %d charges\n":            "\n申訴總額：%s，共 %d 筆扣款\n",
+	"Base recommendations on this many recent months": "依據最近這幾個月提出建議",
+	"YAML table of fare products to compare (defaults to the built-in table)": "要比較的票種 YAML 表（預設為內建表）",
+	"opening fare products": "開啟票種",
+	"loading fare products": "載入票種",
+	"No rides to compare.":  "沒有可比較的乘車。",
+	"pay as you go":         "按次付費",
+	"buy":                   "購買",
+	"%s  %-36s  %3d rides  %9s vs %9s  -> %s\n":            "%s  %-36s  %3d 次乘車  %9s 對 %9s  -> %s\n",
+	"\nBased on the last %d months with rides:\n":          "\n依據最近 %d 個有乘車的月份：\n",
+	"  Buy %s: saves about %s a month\n":                   "  購買 %s：每月約省 %s\n",
+	"  Skip %s: paying as you go saves about %s a month\n": "  不買 %s：按次付費每月約省 %s\n",
+	"Print the monthly summary as CSV":                     "以 CSV 列印每月摘要",
+	"writing CSV":                                          "寫入 CSV",
+	"No rides found.":                                      "找不到乘車。",
+	"%s  %3d trips  %6.1f miles  %6.1f kg CO2 by transit vs %6.1f kg driving, saved %6.1f kg\n": "%s  %3d 次乘車  %6.1f 英里  大眾運輸 %6.1f 公斤 CO2，開車 %6.1f 公斤，省下 %6.1f 公斤\n",
+	"\nTotal saved: %.1f kg CO2\n": "\n共省下 %.1f 公斤 CO2\n",
+	"Output format: text, csv, json or timeline (Google Timeline-style location history JSON)": "輸出格式：text、csv、json 或 timeline（Google 時間軸格式的位置記錄 JSON）",
+	"writing timeline": "寫入時間軸",
+	"writing JSON":     "寫入 JSON",
+	"%s  %-24s -> %-24s  %4.0f min  %9s  %s\n":                             "%s  %-24s -> %-24s  %4.0f 分鐘  %9s  %s\n",
+	"unknown format %q\n":                                                  "未知的格式 %q\n",
+	"Print the statistics as JSON, for charting":                           "以 JSON 列印統計資料，供製圖使用",
+	"Weekday trips: %d\nWeekend trips: %d\n\n":                             "平日乘車：%d\n週末乘車：%d\n\n",
+	"\nTrips per week:\n":                                                  "\n每週乘車次數：\n",
+	"  %s  %3d weekday  %3d weekend  %d days\n":                            "  %s  平日 %3d  週末 %3d  %d 天\n",
+	"Check activity in this many days before the latest transaction":       "檢查最新交易前這幾天內的活動",
+	"POST alerts as JSON to this URL":                                      "將警示以 JSON POST 到此 URL",
+	"Run this program for each alert, with the alert as JSON on stdin":     "每個警示執行此程式一次，並以 stdin 傳入 JSON 格式的警示",
+	"Unusual activity on Clipper card %d":                                  "Clipper 卡片 %d 有異常活動",
+	"Error sending alert: %v\n":                                            "傳送警示時出錯：%v\n",
+	"YAML file of rules for which rides to claim (defaults to every ride)": "規定要申報哪些乘車的 YAML 規則檔（預設為全部乘車）",
+	"Output format: csv or pdf":                                            "輸出格式：csv 或 pdf",
+	"Write the report to this file instead of stdout":                      "將報告寫入此檔案，而非 stdout",
+	"opening rules":        "開啟規則",
+	"loading rules":        "載入規則",
+	"creating output file": "建立輸出檔案",
+	"writing report":       "寫入報告",
+	"Output format: csv, json, jsonl, ofx or beancount":                              "輸出格式：csv、json、jsonl、ofx 或 beancount",
+	"YAML file of rules for which Beancount accounts to post transactions to":        "指定交易要記入哪些 Beancount 帳戶的 YAML 規則檔",
+	"Write the transactions to this file instead of stdout":                          "將交易寫入此檔案，而非 stdout",
+	"Account number for an OFX statement (defaults to the first transaction's card)": "OFX 對帳單的帳號（預設為第一筆交易的卡片）",
+	"writing transactions": "寫入交易",
+	"Comma-separated text that identifies benefit loads in a reload's type, location or product": "以逗號分隔的文字，用來從儲值的類型、地點或產品辨識福利儲值",
+	"Month":                          "月份",
+	"Loaded":                         "儲值",
+	"Spent":                          "花費",
+	"Unused":                         "未使用",
+	"IRS limit":                      "IRS 上限",
+	"  over the limit by %s":         "  超過上限 %s",
+	"\nUnused benefit balance: %s\n": "\n未使用的福利餘額：%s\n",
+	"Some months exceed the IRS monthly limit; the excess may be taxable.\n": "部分月份超過 IRS 每月上限；超出的部分可能需要課稅。\n",
+	"Year to review (defaults to the latest year in the archive)":            "要回顧的年份（預設為封存資料中最新的年份）",
+	"Output format: markdown or html":                                        "輸出格式：markdown 或 html",
+	"Write the review to this file instead of stdout":                        "將回顧寫入此檔案，而非 stdout",
+	"writing review":                        "寫入年度回顧",
+	"reading config file":                   "讀取設定檔",
+	"parsing config file":                   "解析設定檔",
+	"reading card types for %s":             "讀取 %s 的卡片類型",
+	"%s (card %d)":                          "%s（卡片 %d）",
+	"Config file listing each user's cards": "列出每位使用者卡片的設定檔",
+	"Month to report on, as YYYY-MM in Pacific time (defaults to the latest month in the archive)": "報告的月份，格式為 YYYY-MM，太平洋時間（預設為封存資料中最新的月份）",
+	"Also POST the report as JSON to this URL":                                                     "同時將報告以 JSON POST 到此 URL",
+	"Also run this program with the report as JSON on stdin":                                       "同時執行此程式，並以 stdin 傳入 JSON 格式的報告",
+	"Attach a PNG chart of spend per person to the report":                                         "在報告中附上每人花費的 PNG 圖表",
+	"parsing month":                   "解析月份",
+	"%-20s  %4d trips  %10s\n":        "%-20s  %4d 次乘車  %10s\n",
+	"  card %-13d  %4d trips  %10s\n": "  卡片 %-13d  %4d 次乘車  %10s\n",
+	"Household total":                 "家庭總計",
+	"drawing chart":                   "繪製圖表",
+	"sending report":                  "傳送報告",
+	"Config file with the SMTP server to mail the digest through": "寄送摘要所用 SMTP 伺服器的設定檔",
+	"Also POST the digest as JSON to this URL":                    "同時將摘要以 JSON POST 到此 URL",
+	"Also run this program with the digest as JSON on stdin":      "同時執行此程式，並以 stdin 傳入 JSON 格式的摘要",
+	"writing digest": "寫入摘要",
+	"writing HTML":   "寫入 HTML",
+	"no smtp server in %s; printing the digest only\n": "%s 中沒有 smtp 伺服器；只列印摘要\n",
+	"sending digest": "寄送摘要",
+	"MQTT broker URL, like tcp://homeassistant.local:1883": "MQTT 代理伺服器 URL，例如 tcp://homeassistant.local:1883",
+	"MQTT username": "MQTT 使用者名稱",
+	"MQTT password (defaults to $MQTT_PASSWORD)":             "MQTT 密碼（預設為 $MQTT_PASSWORD）",
+	"Home Assistant's MQTT discovery prefix":                 "Home Assistant 的 MQTT 探索前綴",
+	"Prefix of the topics to publish card states to":         "發佈卡片狀態的主題前綴",
+	"homeassistant needs --broker":                           "homeassistant 需要 --broker",
+	"building messages":                                      "建立訊息",
+	"timed out":                                              "逾時",
+	"connecting to %s":                                       "連線到 %s",
+	"publishing":                                             "發佈",
+	"Published %d messages to %s\n":                          "已發佈 %d 則訊息到 %s\n",
+	"Database to load transactions into: postgres or sqlite": "要載入交易的資料庫：postgres 或 sqlite",
+	"Directory to write the setup to":                        "寫入設定的目錄",
+	"parsing --db":                                           "解析 --db",
+	"building Grafana setup":                                 "建立 Grafana 設定",
+	"creating directory":                                     "建立目錄",
+	"writing %s":                                             "寫入 %s",
+	"Wrote %d files to %s; run \"docker compose up\" there and open http://localhost:3000\n":         "已將 %d 個檔案寫入 %s；在該處執行 \"docker compose up\" 並開啟 http://localhost:3000\n",
+	"Seed for how far to move dates (defaults to a random one)":                                      "決定日期位移量的種子（預設為隨機）",
+	"Move every date by this many days (defaults to a whole number of weeks back, picked by --seed)": "將每個日期位移這麼多天（預設為依 --seed 選出的整數週，往前位移）",
+	"Output format: pdf, or csv for the parsed transactions":                                         "輸出格式：pdf，或 csv 以輸出解析出的交易",
+	"Write the scrubbed statement to this file instead of stdout":                                    "將遮蔽後的對帳單寫入此檔案，而非 stdout",
+	"usage: clipper scrub [flags] STATEMENT.pdf\n":                                                   "用法：clipper scrub [選項] 對帳單.pdf\n",
+	"opening statement":                                     "開啟對帳單",
+	"parsing statement":                                     "解析對帳單",
+	"scrubbing statement":                                   "遮蔽對帳單",
+	"Wrote %s; check it by hand before sharing it\n":        "已寫入 %s；分享前請親自檢查\n",
+	"Config file listing each user's cards and budgets":     "列出每位使用者卡片和預算的設定檔",
+	"Comma-separated percentages of the budget to alert at": "以逗號分隔的預算百分比，達到時發出警示",
+	"Alert on thresholds crossed in this many days before the latest transaction": "對最新交易前這幾天內超過的門檻發出警示",
+	"parsing thresholds":                         "解析門檻",
+	"no budgets in %s\n":                         "%s 中沒有預算\n",
+	"%s has spent %d%% of the %s transit budget": "%s 已用掉 %d%% 的%s交通預算",
+	"Spent %s of %s so far in %s.":               "%[3]s 至今已花費 %[1]s，預算為 %[2]s。",
+	"\nAutoload:\n":                              "\n自動儲值：\n",
+	"  Card %d  spends %s/day; ":                 "  卡片 %d  每天花費 %s；",
+	"never reloaded":                             "從未儲值",
+	"reloaded %d times (%d by autoload)":         "儲值 %d 次（自動儲值 %d 次）",
+	" every %.0f days":                           "，每 %.0f 天一次",
+	", averaging %s":                             "，平均 %s",
+	"\n    Average balance %s, %d rides left too little for the next fare\n":                          "\n    平均餘額 %s，有 %d 次乘車後餘額不足下一次車資\n",
+	"    Suggest: autoload %s when below %s (about every %.0f days, keeping about %s on the card),\n": "    建議：自動儲值 %s，於餘額低於 %s 時（約每 %.0f 天一次，卡上保持約 %s），\n",
+	"    or add %s by hand every %.0f days\n":                                                         "    或手動儲值 %s，每 %.0f 天一次\n",
+	"want START..END, got %q":                                   "應為 開始..結束，卻是 %q",
+	"period %q ends before it starts":                           "期間 %q 的結束早於開始",
+	"Earlier period, as YYYY-MM-DD..YYYY-MM-DD (end exclusive)": "較早的期間，格式為 YYYY-MM-DD..YYYY-MM-DD（不含結束日）",
+	"Later period, as YYYY-MM-DD..YYYY-MM-DD (end exclusive)":   "較晚的期間，格式為 YYYY-MM-DD..YYYY-MM-DD（不含結束日）",
+	"parsing --before":                                          "解析 --before",
+	"parsing --after":                                           "解析 --after",
+	"Agency":                                                    "業者",
+	"Before":                                                    "之前",
+	"After":                                                     "之後",
+	"Change":                                                    "變化",
+	"Per month":                                                 "每月",
+	"Other":                                                     "其他",
+	"Total":                                                     "總計",
+	"\nAt %.1f rides a month, fare changes cost you %s a month.\n":                   "\n以每月 %.1f 次乘車計，票價變動讓您每月多花 %s。\n",
+	"Config file listing each user's card types":                                     "列出每位使用者卡片類型的設定檔",
+	"YAML file of discount fares to check against (defaults to built-in 2025 fares)": "要核對的優惠票價 YAML 檔（預設為內建的 2025 年票價）",
+	"no card_types in %s\n": "%s 中沒有 card_types\n",
+	"opening fares":         "開啟票價",
+	"loading fares":         "載入票價",
+	"All checked rides were charged the discounted fare.":                      "所有檢查過的乘車都收取了優惠票價。",
+	"%s  card %d  %-6s  %-20s  charged %s, expected %s%s\n":                    "%s  卡片 %d  %-6s  %-20s  收取 %s，應為 %s%s\n",
+	"\n%d rides overcharged by %s in total.\n":                                 "\n共 %d 次乘車多收 %s。\n",
+	"Chart to draw: spend (per month), agencies or balance":                    "要繪製的圖表：spend（每月）、agencies 或 balance",
+	"Card to chart the balance of (defaults to the first card in the archive)": "要繪製餘額的卡片（預設為封存資料中的第一張卡片）",
+	"Output format: svg or png":                                                "輸出格式：svg 或 png",
+	"Write the chart to this file instead of stdout":                           "將圖表寫入此檔案，而非 stdout",
+	"no cards in the archive":                                                  "封存資料中沒有卡片",
+	"Balance on card %d":                                                       "卡片 %d 的餘額",
+	"unknown chart kind %q\n":                                                  "未知的圖表類型 %q\n",
+	"writing chart":                                                            "寫入圖表",
+	"want ID=PURPOSE, got %q\n":                                                "應為 ID=用途，卻是 %q\n",
+	"tagging %s":                                                               "標記 %s",
+	"YAML table of fare products with Caltrain zone passes (defaults to the built-in table)": "含 Caltrain 區間月票的票種 YAML 表（預設為內建表）",
+	"Annual price of a GoPass, in dollars":                                                   "GoPass 的年費，以美元計",
+	"No Caltrain rides with known zones.":                                                    "沒有已知區間的 Caltrain 乘車。",
+	"Zones":                                                                                  "區間",
+	"Rides":                                                                                  "乘車",
+	"Spend":                                                                                  "花費",
+	"Average":                                                                                "平均",
+	"Paid":                                                                                   "已付",
+	"Pass":                                                                                   "月票",
+	"With pass":                                                                              "用月票",
+	"GoPass":                                                                                 "GoPass",
+	"Cheapest":                                                                               "最便宜",
+	"zone pass":                                                                              "區間月票",
+	"\nPaid %s; monthly zone passes would have cost %s, and a GoPass %s.\n":                      "\n已付 %s；區間月票需 %s，GoPass 需 %s。\n",
+	"A GoPass is bought by employers; if yours provides one, Caltrain rides cost you nothing.\n": "GoPass 由雇主購買；如果您的雇主提供，搭乘 Caltrain 不用花錢。\n",
+	"Hours to report on, like 0-5 for midnight to 4:59am or 22-5 to start at 10pm":               "要回報的時段，例如 0-5 代表午夜到凌晨 4:59，22-5 代表從晚上 10 點開始",
+	"Only report on rides on this card (defaults to every card)":                                 "只回報此卡片的乘車（預設為所有卡片）",
+	"parsing --hours":          "解析 --hours",
+	"No rides between %s.\n":   "%s 之間沒有乘車。\n",
+	"%d rides between %s:\n\n": "%d 次乘車在 %s 之間：\n\n",
+	"\nPer month:\n":           "\n每月：\n",
+	"\nPlaces:\n":              "\n地點：\n",
+	"Config file listing each user's card types (cards not listed pay adult fares)": "列出每位使用者卡片類型的設定檔（未列出的卡片支付成人票價）",
+	"YAML table of posted fares to check against (defaults to the built-in table)":  "要核對的公告票價 YAML 表（預設為內建表）",
+	"Only list overcharges of at least this many dollars":                           "只列出至少這麼多美元的多收費",
+	"Print the fare table as YAML, to start your own, and exit":                     "以 YAML 列印票價表，作為您自己票價表的起點，然後結束",
+	"writing fares": "寫入票價",
+	"%s  card %d  %-6s  %-20s  %-24s -> %-24s  charged %s, posted %s\n":                        "%s  卡片 %d  %-6s  %-20s  %-24s -> %-24s  收取 %s，公告 %s\n",
+	"No overcharges in %d rides checked.":                                                      "檢查的 %d 次乘車中沒有多收費。",
+	"\n%d of %d rides checked were overcharged by %s in total.":                                "\n檢查的 %[2]d 次乘車中有 %[1]d 次多收費，共 %[3]s。",
+	" %d rides had no posted fare to check against.\n":                                         "有 %d 次乘車沒有可核對的公告票價。\n",
+	"creating client for %s: %v":                                                               "建立 %s 的用戶端時出錯：%v",
+	"downloading statements for %s: %v":                                                        "下載 %s 的對帳單時出錯：%v",
+	"set $CLIPPER_VAULT_KEY to the vault's master key; make one with \"clipper vault keygen\"": "請將 $CLIPPER_VAULT_KEY 設為保管庫的主金鑰；可用 \"clipper vault keygen\" 產生",
+	"loading $CLIPPER_VAULT_KEY":                                                               "載入 $CLIPPER_VAULT_KEY",
+	"opening vault":                                                                            "開啟保管庫",
+	"Clipper password: ":                                                                       "Clipper 密碼：",
+	"reading password":                                                                         "讀取密碼",
+	"Vault file":                                                                               "保管庫檔案",
+	"usage: clipper vault [--vault=vault.json] keygen | list | add NAME EMAIL [CARD ...] | remove NAME":       "用法：clipper vault [--vault=vault.json] keygen | list | add 名稱 電子郵件 [卡片 ...] | remove 名稱",
+	"\nThe master key is read from $CLIPPER_VAULT_KEY, and passwords from $CLIPPER_PASSWORD or the terminal.": "\n主金鑰從 $CLIPPER_VAULT_KEY 讀取，密碼從 $CLIPPER_PASSWORD 或終端機讀取。",
+	"%-16s  %-32s  %d cards\n":   "%-16s  %-32s  %d 張卡片\n",
+	"parsing card serial number": "解析卡片序號",
+	"adding %s":                  "新增 %s",
+	"removing %s":                "移除 %s",
+	"URL of the running server":  "執行中伺服器的 URL",
+	"Token with the admin scope (defaults to $CLIPPER_API_TOKEN)": "具有 admin 權限範圍的權杖（預設為 $CLIPPER_API_TOKEN）",
+	"usage: clipper serve admin [--server=http://127.0.0.1:7066] [--token=TOKEN] jobs | run-job NAME | accounts | retry | audit [FILTER=VALUE ...] | tokens | create-token NAME SCOPE,... [CARD ...] | revoke-token NAME | rotate-token NAME": "用法：clipper serve admin [--server=http://127.0.0.1:7066] [--token=權杖] jobs | run-job 名稱 | accounts | retry | audit [篩選=值 ...] | tokens | create-token 名稱 權限範圍,... [卡片 ...] | revoke-token 名稱 | rotate-token 名稱",
+	"building request": "建立請求",
+	"calling server":   "呼叫伺服器",
+	"reading response": "讀取回應",
+	"No scheduled jobs; start the server with --sync-every to add one.": "沒有排程工作；以 --sync-every 啟動伺服器即可新增。",
+	"everyone": "所有人",
+	"%-16s  %-9s  every %-8s  last run %-16s  next run %s\n": "%-16s  %-9s  每 %-8s  上次執行 %-16s  下次執行 %s\n",
+	"%-16s  %d failures, last: %s\n":                         "%-16s  失敗 %d 次，最近一次：%s\n",
+	"Running %s\n":                                           "正在執行 %s\n",
+	"ok":                                                     "正常",
+	"failed: ":                                               "失敗：",
+	"not synced yet":                                         "尚未同步",
+	"%-16s  last run %-16s  last success %-16s  %s\n": "%-16s  上次執行 %-16s  上次成功 %-16s  %s\n",
+	"No failed accounts to retry.":                    "沒有需要重試的失敗帳戶。",
+	"Retrying %s\n":                                   "正在重試 %s\n",
+	"every card":                                      "所有卡片",
+	"cards ":                                          "卡片 ",
+	"use --user-agent or --rotate-user-agent, not both":                                "請使用 --user-agent 或 --rotate-user-agent，不要同時使用",
+	"Config file with the credentials to sync with (optional)":                         "含同步所用憑證的設定檔（選用）",
+	"Address to listen on":                                                             "監聽的位址",
+	"Serve a GraphQL endpoint at /graphql":                                             "在 /graphql 提供 GraphQL 端點",
+	"Serve Plaid-compatible /plaid/transactions/get and /plaid/accounts/get endpoints": "提供與 Plaid 相容的 /plaid/transactions/get 和 /plaid/accounts/get 端點",
+	"Address to serve the gRPC API on, like 127.0.0.1:7067 (off by default)":           "提供 gRPC API 的位址，例如 127.0.0.1:7067（預設關閉）",
+	"Token with every scope that clients can send (defaults to $CLIPPER_API_TOKEN)":    "具有所有權限範圍、供用戶端傳送的權杖（預設為 $CLIPPER_API_TOKEN）",
+	"YAML list of further tokens, each with a name, scopes (read, balances, sync, metrics, admin) and optionally cards; tokens made, revoked or rotated with \"clipper serve admin\" are saved back to it": "其他權杖的 YAML 清單，每個權杖含名稱、權限範圍（read、balances、sync、metrics、admin）及選用的卡片；以 \"clipper serve admin\" 建立、撤銷或輪換的權杖會存回此檔",
+	"Sync every account this often, like 24h (off by default)":                                                                                 "每隔這段時間同步所有帳戶，例如 24h（預設關閉）",
+	"Vault of tenants' credentials to sync, made with \"clipper vault\" (needs $CLIPPER_VAULT_KEY)":                                            "要同步的租戶憑證保管庫，以 \"clipper vault\" 建立（需要 $CLIPPER_VAULT_KEY）",
+	"On SIGTERM, how long to wait for a running sync and open requests":                                                                        "收到 SIGTERM 時，等待進行中的同步和未完成請求的時間",
+	"Signing secret of a Slack app whose /clipper command points at /slack (defaults to $SLACK_SIGNING_SECRET)":                                "Slack 應用程式的簽署密鑰，其 /clipper 指令指向 /slack（預設為 $SLACK_SIGNING_SECRET）",
+	"Slack incoming webhook URL for low balance and unusual activity alerts after syncs (defaults to $SLACK_WEBHOOK_URL)":                      "同步後發送低餘額和異常活動警示的 Slack 傳入 Webhook URL（預設為 $SLACK_WEBHOOK_URL）",
+	"User-Agent to sync with (defaults to $CLIPPER_USER_AGENT, or a recent Chrome)":                                                            "同步時使用的 User-Agent（預設為 $CLIPPER_USER_AGENT，或近期的 Chrome）",
+	"Sync as a browser picked at random from recent ones at each login":                                                                        "每次登入時從近期瀏覽器中隨機挑選一個來同步",
+	"Sync over HTTP/1.1 only, for when the Clipper site's firewall mishandles HTTP/2":                                                          "只透過 HTTP/1.1 同步，用於 Clipper 網站的防火牆無法正確處理 HTTP/2 時",
+	"Comma-separated base64 SHA-256 hashes of public keys; sync only with servers whose certificate chain has one (defaults to $CLIPPER_PINS)": "以逗號分隔、base64 編碼的公開金鑰 SHA-256 雜湊；只與憑證鏈中含其中之一的伺服器同步（預設為 $CLIPPER_PINS）",
+	"Keep each account's session in the system keychain, and reuse it instead of logging in while it lasts":                                    "將每個帳戶的工作階段存在系統鑰匙圈中，並在有效期間重複使用，而不重新登入",
+	"Wait at least this long between requests to the Clipper site, across all accounts, after a burst of --burst requests":                     "在 --burst 個請求的突發之後，對 Clipper 網站的請求之間至少間隔這麼久（所有帳戶共用）",
+	"Number of requests to send at once before pacing them by --min-interval":                                                                  "在依 --min-interval 間隔請求之前，一次可送出的請求數",
+	"URL of the Clipper site to sync with, like http://127.0.0.1:7080 for clippermock (defaults to $CLIPPER_SITE_URL, or clippercard.com)":     "要同步的 Clipper 網站網址，例如 clippermock 的 http://127.0.0.1:7080（預設為 $CLIPPER_SITE_URL，或 clippercard.com）",
+	"opening tokens file": "開啟權杖檔案",
+	"loading tokens":      "載入權杖",
+	"serve needs a token: set --token, $CLIPPER_API_TOKEN or --tokens": "serve 需要權杖：請設定 --token、$CLIPPER_API_TOKEN 或 --tokens",
+	"%s is in both %s and %s\n":                                        "%s 同時出現在 %s 和 %s 中\n",
+	"no credentials in %s; POST /sync is disabled\n":                   "%s 中沒有憑證；POST /sync 已停用\n",
+	"adding token": "新增權杖",
+	"--sync-every needs credentials to sync with": "--sync-every 需要同步所用的憑證",
+	"scheduling syncs":          "排程同步",
+	"starting GraphQL":          "啟動 GraphQL",
+	"listening for gRPC":        "監聽 gRPC",
+	"Serving gRPC on %s\n":      "在 %s 提供 gRPC\n",
+	"serving gRPC":              "提供 gRPC",
+	"Serving %s on http://%s\n": "在 http://%[2]s 提供 %[1]s\n",
+	"serving":                   "提供服務",
+	"Shutting down; waiting up to %s for a running sync\n": "正在關閉；最多等待 %s 讓進行中的同步完成\n",
+	"Sync didn't finish before shutdown: %v\n":             "同步未在關閉前完成：%v\n",
+	"shutting down":           "關閉",
+	"Trips\t%9d\t%s\n":        "乘車次數\t%9d\t%s\n",
+	"Spend\t%9s\t%s\n":        "花費\t%9s\t%s\n",
+	"Trips per week\t%9.1f\n": "每週乘車次數\t%9.1f\n",
+	"Average trip\t%9s\n":     "每次平均\t%9s\n",
+	"Journeys\t%9d\ttransfers: %d within an agency, %d between agencies\n": "旅程數\t%9d\t轉乘：業者內 %d 次，跨業者 %d 次\n",
+	"Average journey\t%9s\n":                  "旅程平均\t%9s\n",
+	"Transfer credits\t%9s\n":                 "轉乘優惠\t%9s\n",
+	"\nMonth\tTrips\t    Spend\n":             "\n月份\t乘車\t     花費\n",
+	"\nAgency\tTrips\t    Spend\tBy month\n":  "\n業者\t乘車\t     花費\t每月\n",
+	"\nMissed tag-offs\tRides\tOvercharged\n": "\n下車未刷卡\t乘車\t多收\n",
+	"\nCard\t  Balance\tRuns out\tHistory\n":  "\n卡片\t     餘額\t用完日\t記錄\n",
+	"\nTrips by hour\t%s\n":                   "\n每小時乘車\t%s\n",
+	"Usage of %s:\n":                          "%s 的用法：\n",
+}
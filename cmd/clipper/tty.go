@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kevinburke/clipper/chart"
+	"github.com/kevinburke/clipper/clipperstats"
+	"github.com/kevinburke/clipper/transit"
+)
+
+// isTerminal reports whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func agencyName(a transit.Agency) string {
+	if a == transit.AgencyUnknown {
+		return tr("Unknown")
+	}
+	return string(a)
+}
+
+// ttyReport prints the report as aligned tables with sparklines, for reading
+// in a terminal.
+func ttyReport(txns []transit.Transaction, sum clipperstats.Summary) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintf(w, tr("Transactions from %s to %s\n\n"), sum.First.Format("2006-01-02"), sum.Last.Format("2006-01-02"))
+
+	spend := make([]float64, len(sum.Months))
+	trips := make([]float64, len(sum.Months))
+	for i, m := range sum.Months {
+		spend[i], trips[i] = float64(m.SpendCents), float64(m.Trips)
+	}
+	fmt.Fprintf(w, tr("Trips\t%9d\t%s\n"), sum.Trips, chart.Sparkline(trips))
+	fmt.Fprintf(w, tr("Spend\t%9s\t%s\n"), sum.SpendCents, chart.Sparkline(spend))
+	fmt.Fprintf(w, tr("Trips per week\t%9.1f\n"), sum.TripsPerWeek)
+	fmt.Fprintf(w, tr("Average trip\t%9s\n"), sum.AverageCostPerTripCents)
+	fmt.Fprintf(w, tr("Journeys\t%9d\ttransfers: %d within an agency, %d between agencies\n"), sum.Journeys, sum.IntraAgencyTransfers, sum.InterAgencyTransfers)
+	fmt.Fprintf(w, tr("Average journey\t%9s\n"), sum.AverageJourneyFareCents)
+	fmt.Fprintf(w, tr("Transfer credits\t%9s\n"), sum.TransferCreditCents)
+
+	fmt.Fprint(w, tr("\nMonth\tTrips\t    Spend\n"))
+	for _, m := range sum.Months {
+		fmt.Fprintf(w, "%s\t%5d\t%9s\n", m.Start.Format("Jan 2006"), m.Trips, m.SpendCents)
+	}
+
+	if len(sum.Agencies) > 0 {
+		index := make(map[time.Time]int, len(sum.Months))
+		for i, m := range sum.Months {
+			index[m.Start] = i
+		}
+		byAgency := make(map[transit.Agency][]float64)
+		for _, am := range sum.Agencies {
+			if byAgency[am.Agency] == nil {
+				byAgency[am.Agency] = make([]float64, len(sum.Months))
+			}
+			byAgency[am.Agency][index[am.Start]] += float64(am.SpendCents)
+		}
+		fmt.Fprint(w, tr("\nAgency\tTrips\t    Spend\tBy month\n"))
+		for _, a := range clipperstats.AgencyTotals(txns) {
+			fmt.Fprintf(w, "%s\t%5d\t%9s\t%s\n", agencyName(a.Agency), a.Trips, a.SpendCents, chart.Sparkline(byAgency[a.Agency]))
+		}
+	}
+
+	if len(sum.MissedTagOffs) > 0 {
+		fmt.Fprint(w, tr("\nMissed tag-offs\tRides\tOvercharged\n"))
+		for _, q := range sum.MissedTagOffs {
+			fmt.Fprintf(w, "%s\t%5d\t%11s\n", q.Name(), q.Count, q.OverchargeCents)
+		}
+	}
+
+	fmt.Fprint(w, tr("\nCard\t  Balance\tRuns out\tHistory\n"))
+	for _, card := range clipperstats.Cards(txns) {
+		p := clipperstats.ProjectRunOut(txns, card, 0)
+		var balances []float64
+		for _, bp := range clipperstats.BalanceTimeline(txns, card) {
+			balances = append(balances, float64(bp.BalanceCents))
+		}
+		runOut := "-"
+		if !p.RunOut.IsZero() {
+			runOut = p.RunOut.Format("2006-01-02")
+		}
+		fmt.Fprintf(w, "%d\t%9s\t%s\t%s\n", card, p.BalanceCents, runOut, chart.Sparkline(balances))
+	}
+
+	w.Flush()
+	printAutoload(os.Stdout, txns)
+
+	usage := clipperstats.UsageStats(txns)
+	hours := make([]float64, 24)
+	for h, n := range usage.ByHour {
+		hours[h] = float64(n)
+	}
+	fmt.Fprintf(w, tr("\nTrips by hour\t%s\n"), chart.Sparkline(hours))
+	fmt.Fprintf(w, "\t%s\n", "0     6     12    18")
+}
@@ -0,0 +1,48 @@
+// The clippermock command serves a fake Clipper site on localhost, with a demo
+// account, so you can try the clipper tools, or run them in CI, without a
+// Clipper account. Point them at it with --site or $CLIPPER_SITE_URL:
+//
+//	clippermock &
+//	CLIPPER_SITE_URL=http://127.0.0.1:7080 clipper-pdf-downloader --email demo@example.com --password demo
+//
+// By default it serves a sanitized demo account with two cards. Use --fixtures
+// to serve a directory of pages saved by clipper-record-fixtures instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/kevinburke/clipper/clippermock"
+)
+
+func checkError(err error, msg string) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %s: %v\n", msg, err)
+		os.Exit(2)
+	}
+}
+
+var addr = flag.String("addr", "127.0.0.1:7080", "Address to listen on")
+var fixtures = flag.String("fixtures", "", "Directory of fixtures to serve, like one written by clipper-record-fixtures (defaults to the demo account)")
+var email = flag.String("email", clippermock.DemoEmail, "Login email the site takes")
+var password = flag.String("password", clippermock.DemoPassword, "Password the site takes")
+
+func main() {
+	flag.Parse()
+	srv := clippermock.New()
+	srv.Email, srv.Password = *email, *password
+	if *fixtures != "" {
+		_, err := os.Stat(*fixtures)
+		checkError(err, "opening fixtures")
+		srv.Fixtures = os.DirFS(*fixtures)
+	}
+	ln, err := net.Listen("tcp", *addr)
+	checkError(err, "listening")
+	fmt.Printf("Serving a fake Clipper site at http://%s\n", ln.Addr())
+	fmt.Printf("Log in as %s with the password %q\n", *email, *password)
+	checkError(http.Serve(ln, srv), "serving")
+}
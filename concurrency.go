@@ -0,0 +1,60 @@
+package clipper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// WithConcurrency makes the client work on up to n cards at once when it
+// downloads or fetches the statements for every card on an account, with
+// DownloadPDFs, DownloadRange, Sync or Transactions, which saves a lot of
+// waiting on an account with many cards. The default, like an n of 1, is one
+// card at a time. NewClient returns an error if n is negative.
+//
+// Requests are still paced by WithPacer or WithMinInterval, however many
+// cards are in flight, so pair this with one of them to stay polite to the
+// site. The function passed to WithEvents is never called by two cards at
+// once.
+func WithConcurrency(n int) Option {
+	return func(c *Client) {
+		c.concurrency = n
+	}
+}
+
+// checkConcurrency returns an error if WithConcurrency was given a bad value.
+func (c *Client) checkConcurrency() error {
+	if c.concurrency < 0 {
+		return fmt.Errorf("clipper: concurrency %d is negative", c.concurrency)
+	}
+	return nil
+}
+
+// eachCard calls fn for each of cards, on up to the client's concurrency at
+// once, and returns a CardError for each card fn fails for, in the order of
+// cards. Once ctx is done, it doesn't start on any more cards, and adds
+// ctx.Err() to the errors.
+func (c *Client) eachCard(ctx context.Context, cards []Card, fn func(Card) error) error {
+	n := max(c.concurrency, 1)
+	errs := make([]error, len(cards)+1)
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for i, card := range cards {
+		sem <- struct{}{}
+		if err := ctx.Err(); err != nil {
+			errs[len(cards)] = err
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(card); err != nil {
+				errs[i] = &CardError{Card: card.SerialNumber, Err: err}
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
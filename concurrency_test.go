@@ -0,0 +1,92 @@
+package clipper
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConcurrency(t *testing.T) {
+	for _, tt := range []struct {
+		n, want int
+	}{
+		{0, 1},
+		{1, 1},
+		{2, 2},
+		{8, 2},
+	} {
+		portal := &fakePortal{pdfs: testStatements(t), delay: 50 * time.Millisecond}
+		events := 0
+		c, err := NewClient("me@example.com", "password", WithSiteAdapter(portal), WithTransport(lockedTransport{}), WithConcurrency(tt.n), WithEvents(func(e Event) {
+			events++
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir := t.TempDir()
+		if err := c.DownloadRange(context.Background(), dir, DateRange{}, false); err != nil {
+			t.Fatal(err)
+		}
+		if portal.maxInFlight != tt.want {
+			t.Errorf("WithConcurrency(%d): got %d statements in flight at once, want %d", tt.n, portal.maxInFlight, tt.want)
+		}
+		if events != 3 {
+			t.Errorf("WithConcurrency(%d): got %d events, want 3", tt.n, events)
+		}
+		for _, card := range []string{"1202728442", "1401491737"} {
+			if _, err := os.Stat(filepath.Join(dir, "clipper-transactions-"+card+".pdf")); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+
+	portal := &fakePortal{pdfs: testStatements(t)}
+	delete(portal.pdfs, 1202728442)
+	c, err := NewClient("me@example.com", "password", WithSiteAdapter(portal), WithTransport(lockedTransport{}), WithConcurrency(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns, err := c.Transactions(context.Background())
+	if cerrs := CardErrors(err); len(cerrs) != 1 || cerrs[0].Card != 1202728442 {
+		t.Errorf("Transactions: got %v, want an error for the first card", err)
+	}
+	if len(txns) != 1 {
+		t.Errorf("Transactions: got %d cards, want 1", len(txns))
+	}
+
+	if _, err := NewClient("me@example.com", "password", WithConcurrency(-1)); err == nil {
+		t.Error("NewClient with a negative concurrency: got nil error")
+	}
+}
+
+func TestConcurrencyCancel(t *testing.T) {
+	portal := &fakePortal{pdfs: testStatements(t)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Cancel the run once the first card is saved.
+	c, err := NewClient("me@example.com", "password", WithSiteAdapter(portal), WithTransport(lockedTransport{}), WithEvents(func(e Event) {
+		if e.Action == EventDownload {
+			cancel()
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	err = c.DownloadRange(ctx, dir, DateRange{}, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("DownloadRange: got %v, want context.Canceled", err)
+	}
+	if len(portal.ranges) != 1 {
+		t.Errorf("portal got %d statement requests, want 1", len(portal.ranges))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "clipper-transactions-1202728442.pdf")); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "clipper-transactions-1401491737.pdf")); err == nil {
+		t.Error("DownloadRange saved the second card's statement after ctx was canceled")
+	}
+}
@@ -0,0 +1,409 @@
+package clipper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// A contentScanner reads the operators in a PDF page's content stream (PDF
+// 32000-1:2008, section 7.8.2). It understands just enough of the syntax to
+// hand extractText the text-showing and text-positioning operators. Unlike
+// unidoc's ContentStreamParser, it doesn't allocate an object for every
+// number, name and operator on the page: statements draw a rectangle and set
+// a color for every cell of the transaction table, and parsing those into
+// objects was most of the time it took to read a long statement.
+type contentScanner struct {
+	data []byte
+	pos  int
+	// args holds the operands of the operator next last returned, and elems
+	// the elements of any arrays among them. Strings are decoded into buf.
+	args  []operand
+	elems []operand
+	buf   []byte
+	// argStart is where in data the operands of the operator next last
+	// returned begin, or the operator itself if it has none.
+	argStart int
+}
+
+type operandKind uint8
+
+const (
+	// operandOther is a name, dictionary, boolean, null or nested array,
+	// none of which extractText uses.
+	operandOther operandKind = iota
+	operandNumber
+	operandString
+	operandArray
+)
+
+// An operand is an operand in a content stream.
+type operand struct {
+	kind operandKind
+	// A number is data[lo:hi] in the stream, a string is buf[lo:hi] and an
+	// array is elems[lo:hi].
+	lo, hi int
+}
+
+func (k operandKind) String() string {
+	switch k {
+	case operandNumber:
+		return "number"
+	case operandString:
+		return "string"
+	case operandArray:
+		return "array"
+	}
+	return "other"
+}
+
+func newContentScanner(data []byte) *contentScanner {
+	return &contentScanner{
+		data:  data,
+		args:  make([]operand, 0, 8),
+		elems: make([]operand, 0, 32),
+		buf:   make([]byte, 0, 256),
+	}
+}
+
+// number returns the value of o, a number.
+func (s *contentScanner) number(o operand) float64 {
+	// Like unidoc, read a malformed number as 0.
+	f, _ := strconv.ParseFloat(string(s.data[o.lo:o.hi]), 64)
+	return f
+}
+
+// str returns the bytes of o, a string. They're only valid until the next
+// call to next.
+func (s *contentScanner) str(o operand) []byte { return s.buf[o.lo:o.hi] }
+
+// array returns the elements of o, an array.
+func (s *contentScanner) array(o operand) []operand { return s.elems[o.lo:o.hi] }
+
+var errInvalidOperator = errors.New("clipper: invalid content stream operator")
+
+func isWhiteSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func isNumberStart(c byte) bool { return c >= '0' && c <= '9' || c == '.' }
+
+// startsValue reports whether an operand, rather than an operator, starts at
+// s.pos.
+func (s *contentScanner) startsValue() bool {
+	c := s.data[s.pos]
+	if c == '-' || c == '+' {
+		return s.pos+1 < len(s.data) && isNumberStart(s.data[s.pos+1])
+	}
+	return isDelimiter(c) || isNumberStart(c)
+}
+
+// next returns the next operator in the stream, with its operands in s.args.
+// The operator is only valid until the next call to next. At the end of the
+// stream it returns io.EOF; an operator cut off by the end of the stream is
+// dropped, as unidoc does.
+func (s *contentScanner) next() ([]byte, error) {
+	s.args = s.args[:0]
+	s.elems = s.elems[:0]
+	s.buf = s.buf[:0]
+	for {
+		if !s.skipSpace() {
+			return nil, io.EOF
+		}
+		if len(s.args) == 0 {
+			s.argStart = s.pos
+		}
+		if s.startsValue() {
+			o, err := s.value(true)
+			if err != nil {
+				return nil, err
+			}
+			s.args = append(s.args, o)
+			continue
+		}
+		start := s.pos
+		for s.pos < len(s.data) && !isWhiteSpace(s.data[s.pos]) && !isDelimiter(s.data[s.pos]) {
+			s.pos++
+		}
+		op := s.data[start:s.pos]
+		switch string(op) {
+		case "true", "false", "null":
+			s.args = append(s.args, operand{kind: operandOther})
+			continue
+		case "BI":
+			if err := s.skipInlineImage(); err != nil {
+				return nil, err
+			}
+		}
+		return op, nil
+	}
+}
+
+// skipSpace skips white space and comments, and returns false at the end of
+// the stream.
+func (s *contentScanner) skipSpace() bool {
+	for s.pos < len(s.data) {
+		switch c := s.data[s.pos]; {
+		case isWhiteSpace(c):
+			s.pos++
+		case c == '%':
+			for s.pos < len(s.data) && s.data[s.pos] != '\r' && s.data[s.pos] != '\n' {
+				s.pos++
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// value reads the operand at s.pos. Only the elements of a top-level array
+// are kept; nested arrays and dictionaries are skipped.
+func (s *contentScanner) value(top bool) (operand, error) {
+	c := s.data[s.pos]
+	switch {
+	case c == '(':
+		return s.literalString()
+	case c == '<' && s.pos+1 < len(s.data) && s.data[s.pos+1] == '<':
+		return operand{kind: operandOther}, s.skipDict()
+	case c == '<':
+		return s.hexString()
+	case c == '[':
+		if !top {
+			return operand{kind: operandOther}, s.skipArray()
+		}
+		return s.topArray()
+	case c == '/':
+		s.pos++
+		for s.pos < len(s.data) && !isWhiteSpace(s.data[s.pos]) && !isDelimiter(s.data[s.pos]) {
+			s.pos++
+		}
+		return operand{kind: operandOther}, nil
+	case isNumberStart(c) || c == '-' || c == '+':
+		return s.scanNumber(), nil
+	}
+	return operand{}, fmt.Errorf("%w at byte %d: %q", errInvalidOperator, s.pos, c)
+}
+
+// scanNumber reads a number, which may be written with an exponent even
+// though the spec doesn't allow one.
+func (s *contentScanner) scanNumber() operand {
+	start := s.pos
+	signs := true
+	for ; s.pos < len(s.data); s.pos++ {
+		c := s.data[s.pos]
+		switch {
+		case signs && (c == '-' || c == '+'):
+			signs = false
+		case c >= '0' && c <= '9', c == '.':
+			signs = false
+		case c == 'e':
+			signs = true
+		default:
+			return operand{kind: operandNumber, lo: start, hi: s.pos}
+		}
+	}
+	return operand{kind: operandNumber, lo: start, hi: s.pos}
+}
+
+// literalString decodes a string in parentheses into s.buf.
+func (s *contentScanner) literalString() (operand, error) {
+	s.pos++ // (
+	lo := len(s.buf)
+	depth := 1
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		s.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return operand{kind: operandString, lo: lo, hi: len(s.buf)}, nil
+			}
+		case '\\':
+			if s.pos >= len(s.data) {
+				return operand{}, io.EOF
+			}
+			c = s.data[s.pos]
+			s.pos++
+			if c >= '0' && c <= '7' {
+				code := int(c - '0')
+				for i := 0; i < 2 && s.pos < len(s.data) && s.data[s.pos] >= '0' && s.data[s.pos] <= '7'; i++ {
+					code = code*8 + int(s.data[s.pos]-'0')
+					s.pos++
+				}
+				s.buf = append(s.buf, byte(code))
+				continue
+			}
+			switch c {
+			case 'n':
+				s.buf = append(s.buf, '\n')
+			case 'r':
+				s.buf = append(s.buf, '\r')
+			case 't':
+				s.buf = append(s.buf, '\t')
+			case 'b':
+				s.buf = append(s.buf, '\b')
+			case 'f':
+				s.buf = append(s.buf, '\f')
+			case '(', ')', '\\':
+				s.buf = append(s.buf, c)
+			}
+			// Anything else, including a backslash before a line break, is
+			// dropped.
+			continue
+		}
+		s.buf = append(s.buf, c)
+	}
+	return operand{}, io.EOF
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// hexString decodes a string in angle brackets into s.buf, ignoring anything
+// that isn't a hex digit.
+func (s *contentScanner) hexString() (operand, error) {
+	s.pos++ // <
+	lo := len(s.buf)
+	var b byte
+	half := false
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		s.pos++
+		if c == '>' {
+			if half {
+				s.buf = append(s.buf, b<<4)
+			}
+			return operand{kind: operandString, lo: lo, hi: len(s.buf)}, nil
+		}
+		d, ok := unhex(c)
+		if !ok {
+			continue
+		}
+		if half {
+			s.buf = append(s.buf, b<<4|d)
+		} else {
+			b = d
+		}
+		half = !half
+	}
+	return operand{}, io.EOF
+}
+
+// topArray reads an array operand, keeping its strings and numbers in
+// s.elems. The elements of two arrays given to one operator don't
+// interleave, because nested arrays are skipped.
+func (s *contentScanner) topArray() (operand, error) {
+	s.pos++ // [
+	lo := len(s.elems)
+	for {
+		if !s.skipSpace() {
+			return operand{}, io.EOF
+		}
+		if s.data[s.pos] == ']' {
+			s.pos++
+			return operand{kind: operandArray, lo: lo, hi: len(s.elems)}, nil
+		}
+		o, err := s.element()
+		if err != nil {
+			return operand{}, err
+		}
+		s.elems = append(s.elems, o)
+	}
+}
+
+// element reads a value inside an array or dictionary.
+func (s *contentScanner) element() (operand, error) {
+	if s.startsValue() {
+		return s.value(false)
+	}
+	// true, false, null or, in a malformed stream, an operator.
+	for s.pos < len(s.data) && !isWhiteSpace(s.data[s.pos]) && !isDelimiter(s.data[s.pos]) {
+		s.pos++
+	}
+	return operand{kind: operandOther}, nil
+}
+
+func (s *contentScanner) skipArray() error {
+	s.pos++ // [
+	for {
+		if !s.skipSpace() {
+			return io.EOF
+		}
+		if s.data[s.pos] == ']' {
+			s.pos++
+			return nil
+		}
+		if _, err := s.element(); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *contentScanner) skipDict() error {
+	s.pos += 2 // <<
+	for {
+		if !s.skipSpace() {
+			return io.EOF
+		}
+		if s.data[s.pos] == '>' {
+			if s.pos+1 < len(s.data) && s.data[s.pos+1] == '>' {
+				s.pos += 2
+				return nil
+			}
+			return fmt.Errorf("%w at byte %d: %q", errInvalidOperator, s.pos, '>')
+		}
+		if _, err := s.element(); err != nil {
+			return err
+		}
+	}
+}
+
+// skipInlineImage skips the rest of an inline image, which runs from the BI
+// operator to the EI operator, with binary data after ID.
+func (s *contentScanner) skipInlineImage() error {
+	for {
+		if !s.skipSpace() {
+			return io.EOF
+		}
+		c := s.data[s.pos]
+		if c == 'I' && s.pos+1 < len(s.data) && s.data[s.pos+1] == 'D' &&
+			(s.pos+2 == len(s.data) || isWhiteSpace(s.data[s.pos+2])) {
+			s.pos += 3
+			break
+		}
+		if _, err := s.element(); err != nil {
+			return err
+		}
+	}
+	for ; s.pos+2 <= len(s.data); s.pos++ {
+		if s.data[s.pos] == 'E' && s.data[s.pos+1] == 'I' &&
+			(s.pos == 0 || isWhiteSpace(s.data[s.pos-1])) &&
+			(s.pos+2 == len(s.data) || isWhiteSpace(s.data[s.pos+2])) {
+			s.pos += 2
+			return nil
+		}
+	}
+	return io.EOF
+}
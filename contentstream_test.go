@@ -0,0 +1,278 @@
+package clipper
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// unidocText is extractText as it was written against unidoc's content
+// stream parser. extractText should give the same answers, faster.
+func unidocText(content string) (string, error) {
+	operations, err := pdfcontent.NewContentStreamParser(content).Parse()
+	if err != nil {
+		return "", err
+	}
+	number := func(o core.PdfObject) (float64, bool) {
+		switch v := o.(type) {
+		case *core.PdfObjectFloat:
+			return float64(*v), true
+		case *core.PdfObjectInteger:
+			return float64(*v), true
+		}
+		return 0, false
+	}
+	xPos, yPos := float64(-1), float64(-1)
+	inText := false
+	txt := ""
+	for _, op := range *operations {
+		switch op.Operand {
+		case "BT":
+			inText = true
+		case "ET":
+			inText = false
+		case "Tm":
+			if len(op.Params) != 6 {
+				continue
+			}
+			x, ok := number(op.Params[4])
+			if !ok {
+				continue
+			}
+			y, ok := number(op.Params[5])
+			if !ok {
+				continue
+			}
+			if yPos == -1 {
+				yPos = y
+			} else if yPos > y {
+				txt += "\n"
+				xPos, yPos = x, y
+				continue
+			}
+			if xPos == -1 {
+				xPos = x
+			} else if xPos < x {
+				tabs, err := howManyTabs(xPos, x)
+				if err != nil {
+					return "", err
+				}
+				txt += strings.Repeat("\t", tabs)
+				xPos = x
+			}
+		case "Td", "TD", "T*":
+			txt += "\n"
+		case "TJ":
+			if !inText || len(op.Params) < 1 {
+				continue
+			}
+			for _, obj := range *op.Params[0].(*core.PdfObjectArray) {
+				switch v := obj.(type) {
+				case *core.PdfObjectString:
+					txt += string(*v)
+				case *core.PdfObjectFloat, *core.PdfObjectInteger:
+					if n, _ := number(v); n < -100 {
+						txt += " "
+					}
+				}
+			}
+		case "Tj":
+			if inText && len(op.Params) > 0 {
+				txt += string(*op.Params[0].(*core.PdfObjectString))
+			}
+		}
+	}
+	return txt, nil
+}
+
+// contentStreams returns the content of each page of the PDF at path.
+func contentStreams(t testing.TB, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := pdf.NewPdfReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := r.GetNumPages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := make([]string, n)
+	for i := range pages {
+		page, err := r.GetPage(i + 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		streams, err := page.GetContentStreams()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages[i] = strings.Join(streams, "\n") + "\n"
+	}
+	return pages
+}
+
+// longStatement returns a statement PDF with the given number of pages (at
+// least 2), made from testdata/transactions.pdf by repeating the first page,
+// without its title, in the middle.
+func longStatement(t testing.TB, pages int) []byte {
+	t.Helper()
+	streams := contentStreams(t, "testdata/transactions.pdf")
+	middle := regexp.MustCompile(`\((TRANSACTION HISTORY FOR|CARD \d+)\)Tj`).ReplaceAllString(streams[0], "()Tj")
+	statement := make([]pdfPage, pages)
+	for i := range statement {
+		content := middle
+		switch i {
+		case 0:
+			content = streams[0]
+		case pages - 1:
+			content = streams[len(streams)-1]
+		}
+		statement[i] = pdfPage{mediaBox: [4]float64{0, 0, 792, 612}, content: []byte(content)}
+	}
+	var buf bytes.Buffer
+	if err := writePDF(&buf, statement); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractTextMatchesUnidoc(t *testing.T) {
+	for _, path := range []string{"testdata/transactions.pdf", "testdata/no-transactions.pdf"} {
+		for i, content := range contentStreams(t, path) {
+			want, err := unidocText(content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := extractText([]byte(content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("%s page %d:\ngot  %q\nwant %q", path, i+1, got, want)
+			}
+		}
+	}
+}
+
+func TestContentScanner(t *testing.T) {
+	for _, tt := range []struct {
+		content string
+		want    string
+	}{
+		{"BT (a\\(b\\)c \\\\ \\101\\7) Tj ET", "a(b)c \\ A\a"},
+		{"BT (nested (parens) here) Tj ET", "nested (parens) here"},
+		{"BT <48 65 6c6C6f> Tj <4> Tj ET", "Hello@"},
+		{"BT [(Clipper) -200 (START) 50 (card)] TJ ET", "Clipper STARTcard"},
+		{"BT [(a) -100.5 (b)] TJ ET", "a b"},
+		{"% a comment (Tj)\nBT (x) Tj ET % another\n", "x"},
+		{"/P << /MCID 0 /Nested << /A [1 (]) 2] >> >> BDC BT (y) Tj ET EMC", "y"},
+		{"BI /W 2 /H 1 /BPC 8 /CS /G ID \x00EI) ( EI BT (z) Tj ET", "z"},
+		{"BT (t) true false null Tj ET", "t"},
+		{"BT (outside) Tj ET (ignored) Tj", "outside"},
+		{"BT (line one) Tj T* (line two) Tj ET", "line one\nline two"},
+		{"BT (cut off", ""},
+	} {
+		got, err := extractText([]byte(tt.content))
+		if err != nil {
+			t.Errorf("%q: %v", tt.content, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.content, got, tt.want)
+		}
+		if want, err := unidocText(tt.content); err == nil && want != got && !strings.Contains(tt.content, "BI") {
+			t.Errorf("%q: unidoc got %q, extractText %q", tt.content, want, got)
+		}
+	}
+	for _, content := range []string{"BT ) Tj ET", "BT (x) ] ET", "BT 5 Tj ET", "BT [(x)] Tj ET"} {
+		if _, err := extractText([]byte(content)); err == nil {
+			t.Errorf("%q: want an error", content)
+		}
+	}
+	sc := newContentScanner([]byte("1 0 0 1 -28.5 .5 Tm 6.02e2 5-3 w"))
+	op, err := sc.next()
+	if err != nil || string(op) != "Tm" || len(sc.args) != 6 || sc.number(sc.args[4]) != -28.5 || sc.number(sc.args[5]) != 0.5 {
+		t.Errorf("Tm: got %s %v, %v", op, sc.args, err)
+	}
+	op, err = sc.next()
+	if err != nil || string(op) != "w" || len(sc.args) != 3 || sc.number(sc.args[0]) != 602 || sc.number(sc.args[2]) != -3 {
+		t.Errorf("w: got %s %v, %v", op, sc.args, err)
+	}
+	if _, err := sc.next(); err != io.EOF {
+		t.Errorf("at the end: got %v, want io.EOF", err)
+	}
+}
+
+func TestLongStatement(t *testing.T) {
+	short, err := ParsePDF(bytes.NewReader(longStatement(t, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	three, err := ParsePDF(bytes.NewReader(longStatement(t, 3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	long, err := ParsePDF(bytes.NewReader(longStatement(t, 60)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	perPage := len(three.Transactions) - len(short.Transactions)
+	if perPage < 20 || long.AccountNumber != short.AccountNumber {
+		t.Fatalf("got %d records on a middle page, and card %d", perPage, long.AccountNumber)
+	}
+	if want := len(short.Transactions) + 58*perPage; len(long.Transactions) != want {
+		t.Errorf("60 pages: got %d records, want %d", len(long.Transactions), want)
+	}
+}
+
+func benchmarkParsePDF(b *testing.B, pages int) {
+	data := longStatement(b, pages)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParsePDF(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParsePDF2Pages(b *testing.B)  { benchmarkParsePDF(b, 2) }
+func BenchmarkParsePDF60Pages(b *testing.B) { benchmarkParsePDF(b, 60) }
+
+func BenchmarkExtractText(b *testing.B) {
+	content := []byte(contentStreams(b, "testdata/transactions.pdf")[1])
+	b.SetBytes(int64(len(content)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := extractText(content); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetCSV(b *testing.B) {
+	pages, err := extractPDFText(context.Background(), bytes.NewReader(longStatement(b, 60)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := getCSV(pages); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
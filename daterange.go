@@ -0,0 +1,130 @@
+package clipper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// MaxDateRangeDays is the most days, counting both ends, that one statement
+// can cover; the site's statement form turns down longer ranges.
+const MaxDateRangeDays = 365
+
+// A DateRange is the Pacific calendar days a statement covers, from Start to
+// End, both included. Both are midnight Pacific time. A zero Start or End
+// leaves that end of the range for the site to pick.
+type DateRange struct {
+	Start, End time.Time
+}
+
+// ParseDateRange parses start and end as dates like "2024-03-01", in Pacific
+// time, and validates the range they make. Either can be empty to leave it to
+// the site.
+func ParseDateRange(start, end string) (DateRange, error) {
+	var r DateRange
+	var err error
+	if r.Start, err = parseRangeDate("start", start); err != nil {
+		return DateRange{}, err
+	}
+	if r.End, err = parseRangeDate("end", end); err != nil {
+		return DateRange{}, err
+	}
+	if err := r.Validate(); err != nil {
+		return DateRange{}, err
+	}
+	return r, nil
+}
+
+// parseRangeDate parses s as the start or end date of a range, saying what
+// to type instead if it isn't a YYYY-MM-DD date.
+func parseRangeDate(which, s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := ParseDate(s)
+	if err == nil {
+		return t, nil
+	}
+	// Dates the way Clipper and Americans write them.
+	for _, layout := range []string{"01/02/2006", "1/2/2006", "January 2, 2006", "Jan 2, 2006", "20060102"} {
+		if t, err := time.ParseInLocation(layout, s, Pacific); err == nil {
+			return time.Time{}, fmt.Errorf("clipper: %s date %q isn't in YYYY-MM-DD format; did you mean %s?", which, s, t.Format(transit.DateLayout))
+		}
+	}
+	var perr *time.ParseError
+	if errors.As(err, &perr) && strings.Contains(perr.Message, "out of range") {
+		return time.Time{}, fmt.Errorf("clipper: %s date %q isn't a day on the calendar", which, s)
+	}
+	return time.Time{}, fmt.Errorf("clipper: %s date %q isn't in YYYY-MM-DD format, like 2024-03-01", which, s)
+}
+
+// Validate returns an error saying what's wrong if r starts after it ends,
+// covers more than MaxDateRangeDays, or starts or ends after today in
+// Pacific time.
+func (r DateRange) Validate() error {
+	return r.validate(time.Now())
+}
+
+func (r DateRange) validate(now time.Time) error {
+	today := civilDay(now)
+	if !r.Start.IsZero() && civilDay(r.Start).After(today) {
+		return fmt.Errorf("clipper: start date %s is in the future; today is %s in Pacific time", r.Start.In(Pacific).Format(transit.DateLayout), today.Format(transit.DateLayout))
+	}
+	if !r.End.IsZero() && civilDay(r.End).After(today) {
+		return fmt.Errorf("clipper: end date %s is in the future; end today, %s in Pacific time, or leave the end date out", r.End.In(Pacific).Format(transit.DateLayout), today.Format(transit.DateLayout))
+	}
+	if r.Start.IsZero() || r.End.IsZero() {
+		return nil
+	}
+	if civilDay(r.Start).After(civilDay(r.End)) {
+		return fmt.Errorf("clipper: start date %s is after end date %s; swap them?", r.Start.In(Pacific).Format(transit.DateLayout), r.End.In(Pacific).Format(transit.DateLayout))
+	}
+	if days := r.Days(); days > MaxDateRangeDays {
+		return fmt.Errorf("clipper: %s covers %d days, but a statement can cover at most %d; download it in shorter ranges", r, days, MaxDateRangeDays)
+	}
+	return nil
+}
+
+// civilDay returns the Pacific calendar day t falls on, as midnight UTC, so
+// that days are 24 hours apart whatever daylight saving time does.
+func civilDay(t time.Time) time.Time {
+	y, m, d := t.In(Pacific).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Days returns the number of days r covers, counting both ends, or 0 if it
+// leaves either end to the site.
+func (r DateRange) Days() int {
+	if r.Start.IsZero() || r.End.IsZero() {
+		return 0
+	}
+	return int(civilDay(r.End).Sub(civilDay(r.Start))/(24*time.Hour)) + 1
+}
+
+// String returns r like "2024-03-01 to 2024-03-31", with "default" for an end
+// left to the site.
+func (r DateRange) String() string {
+	day := func(t time.Time) string {
+		if t.IsZero() {
+			return "default"
+		}
+		return t.In(Pacific).Format(transit.DateLayout)
+	}
+	return day(r.Start) + " to " + day(r.End)
+}
+
+// formDates returns r's ends the way the statement form wants them, like
+// "March 1, 2024", or "" for an end left to the site.
+func (r DateRange) formDates() (start, end string) {
+	day := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.In(Pacific).Format("January 2, 2006")
+	}
+	return day(r.Start), day(r.End)
+}
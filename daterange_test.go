@@ -0,0 +1,79 @@
+package clipper
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var dateRangeTests = []struct {
+	start, end string
+	want       string // a substring of the error, or the range if it's valid
+}{
+	{"", "", "default to default"},
+	{"2026-03-01", "", "2026-03-01 to default"},
+	{" 2026-03-01 ", "2026-03-31", "2026-03-01 to 2026-03-31"},
+	{"2026-10-16", "2026-10-16", "2026-10-16 to 2026-10-16"},
+	{"03/01/2026", "", "did you mean 2026-03-01?"},
+	{"", "March 31, 2026", "did you mean 2026-03-31?"},
+	{"2026-13-01", "", `start date "2026-13-01" isn't a day on the calendar`},
+	{"2026-02-30", "", "isn't a day on the calendar"},
+	{"last week", "", "isn't in YYYY-MM-DD format, like 2024-03-01"},
+	{"2026-03-31", "2026-03-01", "is after end date 2026-03-01; swap them?"},
+	{"2025-10-16", "2026-10-15", "2025-10-16 to 2026-10-15"},
+	{"2025-10-15", "2026-10-15", "covers 366 days, but a statement can cover at most 365"},
+	{"2026-10-17", "", "start date 2026-10-17 is in the future; today is 2026-10-16"},
+	{"2026-10-01", "2026-10-31", "end date 2026-10-31 is in the future"},
+}
+
+func TestDateRange(t *testing.T) {
+	// 11pm on October 16 in San Francisco, which is already the 17th in UTC.
+	now := time.Date(2026, 10, 16, 23, 0, 0, 0, Pacific)
+	for _, tt := range dateRangeTests {
+		var r DateRange
+		var err error
+		if r.Start, err = parseRangeDate("start", tt.start); err == nil {
+			if r.End, err = parseRangeDate("end", tt.end); err == nil {
+				err = r.validate(now)
+			}
+		}
+		got := r.String()
+		if err != nil {
+			got = err.Error()
+		}
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("range %q to %q: got %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestDateRangeDays(t *testing.T) {
+	// The range over the start of daylight saving time has a 23-hour day.
+	r, err := ParseDateRange("2024-03-01", "2024-03-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Days() != 31 {
+		t.Errorf("Days: got %d, want 31", r.Days())
+	}
+	if start, end := r.formDates(); start != "March 1, 2024" || end != "March 31, 2024" {
+		t.Errorf("formDates: got %q, %q", start, end)
+	}
+	if _, err := ParseDateRange("2024-03-31", "2024-03-01"); err == nil {
+		t.Error("ParseDateRange: want an error for a backwards range")
+	}
+}
+
+func TestDownloadPDFsChecksDates(t *testing.T) {
+	rt := new(uaTransport)
+	c, err := NewClient("email", "password", WithTransport(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DownloadPDFs(t.Context(), t.TempDir(), "2024-03-31", "2024-03-01", true); err == nil {
+		t.Error("want an error for a backwards range")
+	}
+	if len(rt.uas) != 0 {
+		t.Errorf("made %d requests before checking the dates", len(rt.uas))
+	}
+}
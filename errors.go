@@ -0,0 +1,146 @@
+package clipper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrPasswordResetRequired is returned when Clipper won't let the account log
+// in until its password is changed.
+var ErrPasswordResetRequired = errors.New("clipper: Clipper requires a password reset for this account; log in at https://www.clippercard.com, choose a new password and update your configuration")
+
+// ErrAccountLocked is returned when Clipper has temporarily locked the account,
+// usually after too many failed logins.
+var ErrAccountLocked = errors.New("clipper: the account is temporarily locked after too many failed logins; wait before trying again, or contact Clipper Customer Service at 877-878-8883")
+
+// A CardError is what went wrong with one card, when the client got what it
+// could for the account's other cards. Methods that work card by card, like
+// DownloadPDFs and Transactions, return one for each card that failed,
+// joined with errors.Join, alongside the results for the rest; list them with
+// CardErrors:
+//
+//	for _, cerr := range clipper.CardErrors(err) {
+//		log.Printf("card %d failed: %v", cerr.Card, cerr.Err)
+//	}
+type CardError struct {
+	// Card is the serial number of the card.
+	Card int64
+	Err  error
+}
+
+func (e *CardError) Error() string {
+	return fmt.Sprintf("clipper: card %d: %v", e.Card, e.Err)
+}
+
+func (e *CardError) Unwrap() error {
+	return e.Err
+}
+
+// A WrongCardError is returned for a download that turns out to be another
+// card's statement, which Clipper has been known to send.
+type WrongCardError struct {
+	// Want is the card the statement was asked for, and Got the card the
+	// statement is for.
+	Want, Got int64
+}
+
+func (e *WrongCardError) Error() string {
+	return fmt.Sprintf("clipper: asked for the statement for card %d, but got the one for card %d", e.Want, e.Got)
+}
+
+// CardErrors returns the CardErrors in err, which may join several of them
+// with other errors.
+func CardErrors(err error) []*CardError {
+	return errorsOf[*CardError](err)
+}
+
+// A ParseError is a part of a statement that couldn't be read: a page whose
+// layout doesn't match the statement's table, or a line on it that isn't a
+// row of the table. ParsePDF fails with the first one; ParsePDFOptions in
+// lenient mode skips them and returns one for each, joined with errors.Join;
+// list them with ParseErrors.
+type ParseError struct {
+	// Page is the page number, starting from 1, and Line the line on the
+	// page, also starting from 1, or 0 if the whole page couldn't be read.
+	Page, Line int
+	Err        error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("clipper: page %d: %v", e.Page, e.Err)
+	}
+	return fmt.Sprintf("clipper: page %d, line %d: %v", e.Page, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseErrors returns the ParseErrors in err, which may join several of them
+// with other errors.
+func ParseErrors(err error) []*ParseError {
+	return errorsOf[*ParseError](err)
+}
+
+// errorsOf returns the errors of type E in err, following errors.Join and
+// wrapping.
+func errorsOf[E error](err error) []E {
+	var found []E
+	var walk func(error)
+	walk = func(err error) {
+		if e, ok := err.(E); ok {
+			found = append(found, e)
+			return
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				walk(err)
+			}
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		}
+	}
+	if err != nil {
+		walk(err)
+	}
+	return found
+}
+
+var (
+	passwordResetPathRx = regexp.MustCompile(`(?i)(reset|change|expired|update)-?_?password`)
+	passwordResetTextRx = regexp.MustCompile(`(?i)(password has expired|must (reset|change) your password|reset your password to continue|password reset is required|create a new password to continue)`)
+	accountLockedTextRx = regexp.MustCompile(`(?i)(account (has been|is) (temporarily )?locked|too many (failed|unsuccessful) (login|sign-in|log in) attempts|account is disabled)`)
+)
+
+// checkLoginPage returns ErrPasswordResetRequired or ErrAccountLocked if the
+// page at u, reached after submitting a login form, is one of those
+// interstitials instead of the page we expected.
+//
+// Only the page's visible alerts and form errors are checked for the text, not
+// its scripts or help text like "too many failed login attempts will lock your
+// account", which login forms show whether or not the account is locked.
+func checkLoginPage(u *url.URL, body []byte) error {
+	messages := findMessages(bytes.NewReader(body))
+	if matchAny(accountLockedTextRx, messages) || strings.Contains(strings.ToLower(u.Path), "locked") {
+		return ErrAccountLocked
+	}
+	if passwordResetPathRx.MatchString(u.Path) || matchAny(passwordResetTextRx, messages) {
+		return ErrPasswordResetRequired
+	}
+	return nil
+}
+
+func matchAny(rx *regexp.Regexp, texts []string) bool {
+	for _, text := range texts {
+		if rx.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package clipper
+
+import (
+	"net/url"
+	"testing"
+)
+
+var loginPageTests = []struct {
+	path string
+	body string
+	want error
+}{
+	{"/ClipperWeb/account.html", `<span class="d-inline-block">1401491737 - Guest</span>`, nil},
+	{"/ClipperWeb/login.html", `<div class="alert alert-danger">Your account has been temporarily locked due to too many failed login attempts.</div>`, ErrAccountLocked},
+	{"/ClipperWeb/resetPassword.html", `<h1>Choose a new password</h1>`, ErrPasswordResetRequired},
+	{"/u/login", `<div role="alert"><p>Your password has expired. Please create a new password to continue.</p></div>`, ErrPasswordResetRequired},
+	{"/u/login/password", `<span class="ulp-input-error-message">Your account is locked. Reset your password to unlock it.</span>`, ErrAccountLocked},
+	// The "forgot password" link on the normal login page shouldn't trigger
+	// a false positive.
+	{"/ClipperWeb/login.html", `<a href="/ClipperWeb/forgotPassword.html">Forgot password?</a>`, nil},
+	// Nor should help text, scripts or an alert that isn't shown.
+	{"/ClipperWeb/login.html", `<p class="help-block">Too many failed login attempts will lock your account.</p>
+<script>var messages = {locked: "Your account has been temporarily locked.", expired: "Your password has expired."};</script>
+<div class="alert alert-danger" style="display: none">Your account has been temporarily locked.</div>
+<div class="invalid-feedback" hidden>You must reset your password to continue.</div>`, nil},
+}
+
+func TestCheckLoginPage(t *testing.T) {
+	for _, tt := range loginPageTests {
+		u := &url.URL{Scheme: "https", Host: "www.clippercard.com", Path: tt.path}
+		if got := checkLoginPage(u, []byte(tt.body)); got != tt.want {
+			t.Errorf("checkLoginPage(%q, %q): got %v, want %v", tt.path, tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+// Package expense builds reimbursement reports from Clipper transactions:
+// the rides that match your employer's rules over a date range, with totals
+// and references to the statements they came from.
+package expense
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clippercsv"
+	"github.com/kevinburke/clipper/clipperstats"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Rules decide which rides are reimbursable. A ride must match every rule
+// that's set; empty rules match everything.
+type Rules struct {
+	// Days are the days of the week you commute, such as "Mon" or
+	// "Tuesday".
+	Days []string `yaml:"days"`
+	// Agencies are the operators whose rides count.
+	Agencies []clipper.Agency `yaml:"agencies"`
+	// Locations are places one of the ride's tags must be at, such as your
+	// home and office stations. Matching is case-insensitive and on a
+	// substring, so "Belmont" matches "Belmont (Caltrain)".
+	Locations []string `yaml:"locations"`
+	// ExcludeDates are days not to claim, like holidays and vacation, in
+	// YYYY-MM-DD format.
+	ExcludeDates []string `yaml:"exclude_dates"`
+	// Purposes are the trip purposes to claim, usually just "work". Rides
+	// are tagged by Tagger.
+	Purposes []clipperstats.Purpose `yaml:"purposes"`
+
+	// Tagger tags rides with their purpose. It's not read from the rules
+	// file; callers usually load it from the store.
+	Tagger clipperstats.Tagger `yaml:"-"`
+}
+
+// LoadRules reads Rules in YAML.
+func LoadRules(r io.Reader) (Rules, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Rules{}, err
+	}
+	var rules Rules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return Rules{}, fmt.Errorf("expense: reading rules: %v", err)
+	}
+	if _, err := rules.weekdays(); err != nil {
+		return Rules{}, err
+	}
+	for _, d := range rules.ExcludeDates {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return Rules{}, fmt.Errorf("expense: bad exclude date %q", d)
+		}
+	}
+	for _, p := range rules.Purposes {
+		if _, err := clipperstats.ParsePurpose(string(p)); err != nil {
+			return Rules{}, fmt.Errorf("expense: %v", err)
+		}
+	}
+	return rules, nil
+}
+
+func (r Rules) weekdays() (map[time.Weekday]bool, error) {
+	if len(r.Days) == 0 {
+		return nil, nil
+	}
+	days := make(map[time.Weekday]bool)
+	for _, name := range r.Days {
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			full := strings.ToLower(d.String())
+			if n := strings.ToLower(name); n == full || n == full[:3] {
+				days[d] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("expense: unknown day %q", name)
+		}
+	}
+	return days, nil
+}
+
+// Match reports whether the ride l is reimbursable.
+func (r Rules) Match(l clipperstats.Leg) bool {
+	start := l.Start()
+	if days, err := r.weekdays(); err == nil && days != nil && !days[start.Weekday()] {
+		return false
+	}
+	for _, d := range r.ExcludeDates {
+		if start.Format("2006-01-02") == d {
+			return false
+		}
+	}
+	if len(r.Agencies) > 0 {
+		found := false
+		for _, a := range r.Agencies {
+			if a == l.Agency {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.Purposes) > 0 {
+		found := false
+		for _, p := range r.Purposes {
+			if p == r.Tagger.Tag(l) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.Locations) > 0 {
+		found := false
+		for _, t := range l.Txns {
+			loc := strings.ToLower(t.Location)
+			for _, want := range r.Locations {
+				if want != "" && strings.Contains(loc, strings.ToLower(want)) {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// An Item is one reimbursable ride.
+type Item struct {
+	Date        time.Time
+	CardLast4   string
+	Agency      clipper.Agency
+	Origin      string
+	Destination string
+	AmountCents clipper.Money
+	Purpose     clipperstats.Purpose
+	// Statements are the statements and rows the ride's transactions were
+	// read from, like "card-123-2018-01.pdf row 4".
+	Statements []string
+}
+
+// A Report is a reimbursement claim.
+type Report struct {
+	From, To    time.Time
+	Items       []Item
+	TotalCents  clipper.Money
+	Cards       []string
+	Statements  []string
+	GeneratedAt time.Time
+}
+
+// CardLast4 returns the last four digits of a card serial number.
+func CardLast4(serial int64) string {
+	s := strconv.FormatInt(serial, 10)
+	if len(s) > 4 {
+		s = s[len(s)-4:]
+	}
+	return s
+}
+
+// Generate builds a report of the rides in txns between from (inclusive) and
+// to (exclusive) that match rules. Rides that cost nothing aren't included.
+func Generate(txns []clipper.Transaction, from, to time.Time, rules Rules) Report {
+	r := Report{From: from, To: to, GeneratedAt: time.Now()}
+	cards := make(map[string]bool)
+	statements := make(map[string]bool)
+	for _, l := range clipperstats.Legs(txns) {
+		start := l.Start()
+		if (!from.IsZero() && start.Before(from)) || (!to.IsZero() && !start.Before(to)) {
+			continue
+		}
+		if !rules.Match(l) || l.FareCents() <= 0 {
+			continue
+		}
+		item := Item{
+			Date:        start,
+			CardLast4:   CardLast4(l.Txns[0].CardSerial),
+			Agency:      l.Agency,
+			Origin:      l.Origin(),
+			Destination: l.Destination(),
+			AmountCents: l.FareCents(),
+			Purpose:     rules.Tagger.Tag(l),
+		}
+		for _, t := range l.Txns {
+			if t.Source == "" {
+				continue
+			}
+			base := filepath.Base(t.Source)
+			item.Statements = append(item.Statements, fmt.Sprintf("%s row %d", base, t.Row))
+			statements[base] = true
+		}
+		cards[item.CardLast4] = true
+		r.Items = append(r.Items, item)
+		r.TotalCents += item.AmountCents
+	}
+	for c := range cards {
+		r.Cards = append(r.Cards, c)
+	}
+	sort.Strings(r.Cards)
+	for s := range statements {
+		r.Statements = append(r.Statements, s)
+	}
+	sort.Strings(r.Statements)
+	return r
+}
+
+// WriteCSV writes the report as CSV, one row per ride and a final total row.
+func (r Report) WriteCSV(w io.Writer, opts clippercsv.Options) error {
+	cw := clippercsv.NewWriter(w, opts)
+	cw.Write([]string{"date", "card", "agency", "from", "to", "amount", "statements", "purpose"})
+	for _, it := range r.Items {
+		cw.Write([]string{
+			it.Date.Format("2006-01-02 15:04"),
+			it.CardLast4,
+			string(it.Agency),
+			it.Origin,
+			it.Destination,
+			it.AmountCents.Decimal(),
+			strings.Join(it.Statements, "; "),
+			string(it.Purpose),
+		})
+	}
+	cw.Write([]string{"Total", "", "", "", "", r.TotalCents.Decimal(), "", ""})
+	cw.Flush()
+	return cw.Error()
+}
+
+// lines returns the report as lines of text, for the PDF.
+func (r Report) lines() []string {
+	period := "all dates"
+	if !r.From.IsZero() || !r.To.IsZero() {
+		to := r.To
+		if !to.IsZero() {
+			to = to.AddDate(0, 0, -1)
+		}
+		period = fmt.Sprintf("%s to %s", dateOrOpen(r.From), dateOrOpen(to))
+	}
+	out := []string{
+		"Transit expense report",
+		"",
+		"Period: " + period,
+		"Cards: " + strings.Join(prefixAll("ending ", r.Cards), ", "),
+		fmt.Sprintf("Rides: %d", len(r.Items)),
+		"Total: " + r.TotalCents.String(),
+		"",
+		fmt.Sprintf("%-17s %-5s %-10s %-40s %8s", "Date", "Card", "Agency", "Trip", "Amount"),
+	}
+	for _, it := range r.Items {
+		trip := it.Origin
+		if it.Destination != "" {
+			trip += " to " + it.Destination
+		}
+		if len(trip) > 40 {
+			trip = trip[:40]
+		}
+		agency := string(it.Agency)
+		if len(agency) > 10 {
+			agency = agency[:10]
+		}
+		out = append(out, fmt.Sprintf("%-17s %-5s %-10s %-40s %8s", it.Date.Format("2006-01-02 15:04"), it.CardLast4, agency, trip, it.AmountCents))
+	}
+	out = append(out, "", fmt.Sprintf("%-74s %8s", "Total", r.TotalCents))
+	if len(r.Statements) > 0 {
+		out = append(out, "", "Statements:")
+		for _, s := range r.Statements {
+			out = append(out, "  "+s)
+		}
+	}
+	out = append(out, "", "Generated "+r.GeneratedAt.Format("2006-01-02 15:04"))
+	return out
+}
+
+func dateOrOpen(t time.Time) string {
+	if t.IsZero() {
+		return "..."
+	}
+	return t.Format("2006-01-02")
+}
+
+func prefixAll(prefix string, ss []string) []string {
+	out := make([]string, len(ss))
+	for i, s := range ss {
+		out[i] = prefix + s
+	}
+	return out
+}
+
+// WritePDF writes the report as a PDF.
+func (r Report) WritePDF(w io.Writer) error {
+	return writeTextPDF(w, r.lines())
+}
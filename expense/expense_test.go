@@ -0,0 +1,116 @@
+package expense
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clippercsv"
+	"github.com/kevinburke/clipper/clipperstats"
+)
+
+var sample = []clipper.Transaction{
+	// Tuesday commute on Caltrain.
+	{Timestamp: time.Date(2018, time.January, 2, 7, 0, 0, 0, time.UTC), Type: "Dual-tag entry transaction, maximum fare deducted (purse debit)", Location: "Belmont", DebitCents: 1220, CardSerial: 1202728442, Source: "pdfs/jan.pdf", Row: 1},
+	{Timestamp: time.Date(2018, time.January, 2, 7, 40, 0, 0, time.UTC), Type: "Dual-tag exit transaction, fare adjustment (purse rebate)", Location: "4th and King (Caltrain)", CreditCents: 900, CardSerial: 1202728442, Source: "pdfs/jan.pdf", Row: 2},
+	// Saturday Muni ride.
+	{Timestamp: time.Date(2018, time.January, 6, 12, 0, 0, 0, time.UTC), Type: "Single-tag fare payment", Location: "Powell (Muni)", DebitCents: 250, CardSerial: 1202728442, Source: "pdfs/jan.pdf", Row: 3},
+	// Monday holiday.
+	{Timestamp: time.Date(2018, time.January, 15, 8, 0, 0, 0, time.UTC), Type: "Single-tag fare payment", Location: "SAM bus", DebitCents: 205, CardSerial: 1202728442, Source: "pdfs/jan.pdf", Row: 4},
+	{Timestamp: time.Date(2018, time.January, 16, 8, 0, 0, 0, time.UTC), Type: "Single-tag fare payment", Location: "SAM bus", DebitCents: 205, CardSerial: 1202728442, Source: "pdfs/jan.pdf", Row: 5},
+}
+
+func TestGenerate(t *testing.T) {
+	rules, err := LoadRules(strings.NewReader(`
+days: [Mon, Tue, Wednesday, Thu, Fri]
+exclude_dates: ["2018-01-15"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := Generate(sample, time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, time.February, 1, 0, 0, 0, 0, time.UTC), rules)
+	if len(r.Items) != 2 || r.TotalCents != 320+205 {
+		t.Fatalf("got %d items totalling %d: %+v", len(r.Items), r.TotalCents, r.Items)
+	}
+	it := r.Items[0]
+	if it.CardLast4 != "8442" || it.Origin != "Belmont" || it.Destination != "4th and King" {
+		t.Errorf("first item: %+v", it)
+	}
+	if len(it.Statements) != 2 || it.Statements[1] != "jan.pdf row 2" {
+		t.Errorf("statements: %q", it.Statements)
+	}
+	if len(r.Cards) != 1 || len(r.Statements) != 1 {
+		t.Errorf("cards %q, statements %q", r.Cards, r.Statements)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteCSV(&buf, clippercsv.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Total,,,,,5.25,") {
+		t.Errorf("CSV missing total:\n%s", buf.String())
+	}
+}
+
+func TestRulesLocations(t *testing.T) {
+	rules := Rules{Locations: []string{"4th and king"}, Agencies: []clipper.Agency{clipper.AgencyCaltrain}}
+	r := Generate(sample, time.Time{}, time.Time{}, rules)
+	if len(r.Items) != 1 || r.Items[0].Agency != clipper.AgencyCaltrain {
+		t.Errorf("got %+v", r.Items)
+	}
+}
+
+func TestLoadRulesErrors(t *testing.T) {
+	if _, err := LoadRules(strings.NewReader("days: [Someday]")); err == nil {
+		t.Error("expected an error for an unknown day")
+	}
+	if _, err := LoadRules(strings.NewReader(`exclude_dates: ["Jan 1"]`)); err == nil {
+		t.Error("expected an error for a bad date")
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	r := Generate(sample, time.Time{}, time.Time{}, Rules{})
+	// Enough rides for more than one page.
+	for len(r.Items) < 100 {
+		r.Items = append(r.Items, r.Items[0])
+	}
+	r.Items[1].Origin = "Café (Muni) \\ test"
+	var buf bytes.Buffer
+	if err := r.WritePDF(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "%PDF-1.4") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Errorf("not a PDF: %q", out[:20])
+	}
+	if !strings.Contains(out, "/Count 2") {
+		t.Error("expected two pages")
+	}
+	if !strings.Contains(out, `Caf\351 \(Muni\) \\ test`) {
+		t.Error("expected escaped, WinAnsi-encoded text")
+	}
+}
+
+func TestRulesPurposes(t *testing.T) {
+	rules, err := LoadRules(strings.NewReader("purposes: [work]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules.Tagger = clipperstats.Tagger{
+		Rules:     []clipperstats.TagRule{{Purpose: clipperstats.PurposeWork, Days: []string{"Tue"}}},
+		Overrides: map[string]clipperstats.Purpose{"1202728442-20180116T0800": clipperstats.PurposePersonal},
+	}
+	r := Generate(sample, time.Time{}, time.Time{}, rules)
+	if len(r.Items) != 1 || r.Items[0].Agency != clipper.AgencyCaltrain {
+		t.Errorf("got %+v", r.Items)
+	}
+	if r.Items[0].Purpose != clipperstats.PurposeWork {
+		t.Errorf("Purpose: got %q", r.Items[0].Purpose)
+	}
+	if _, err := LoadRules(strings.NewReader("purposes: [fun]\n")); err == nil {
+		t.Errorf("expected error for unknown purpose")
+	}
+}
@@ -0,0 +1,94 @@
+package expense
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	linesPerPage = 60
+	fontSize     = 9
+	lineHeight   = 12
+	pageWidth    = 612 // US Letter, in points
+	pageHeight   = 792
+	margin       = 40
+)
+
+// pdfString escapes s for a PDF string literal in the WinAnsi encoding used by
+// the standard Courier font.
+func pdfString(s string) string {
+	enc := charmap.Windows1252.NewEncoder()
+	var b strings.Builder
+	for _, r := range s {
+		out, err := enc.String(string(r))
+		if err != nil {
+			out = "?"
+		}
+		for _, c := range []byte(out) {
+			switch {
+			case c == '(' || c == ')' || c == '\\':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case c < 0x20 || c >= 0x7f:
+				fmt.Fprintf(&b, "\\%03o", c)
+			default:
+				b.WriteByte(c)
+			}
+		}
+	}
+	return b.String()
+}
+
+// writeTextPDF writes lines as a plain monospaced PDF document, breaking
+// pages as needed. It's only meant for simple reports, so it avoids needing a
+// PDF library.
+func writeTextPDF(w io.Writer, lines []string) error {
+	var pages [][]string
+	for len(lines) > linesPerPage {
+		pages = append(pages, lines[:linesPerPage])
+		lines = lines[linesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// 1: catalog, 2: page tree, 3: font, then a page and its contents for
+	// each page.
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	for i, page := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, lineHeight, margin, pageHeight-margin)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", pdfString(line))
+		}
+		if len(pages) > 1 {
+			fmt.Fprintf(&content, "ET\nBT\n/F1 %d Tf\n%d %d Td\n(%s) Tj\n", fontSize, pageWidth-margin-80, margin/2, pdfString(fmt.Sprintf("Page %d of %d", i+1, len(pages))))
+		}
+		content.WriteString("ET")
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 5+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,249 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/store"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// BeancountRules decide which Beancount accounts a BeancountEncoder posts
+// transactions to. In account names, "{card}" is replaced with the card's
+// serial number and "{agency}" with the agency that charged the fare, like
+// "BART" or "AC-Transit".
+type BeancountRules struct {
+	// Card is the asset account holding a card's balance. It defaults to
+	// "Assets:Clipper:{card}".
+	Card string `yaml:"card"`
+	// Fares is the expense account fares go to. It defaults to
+	// "Expenses:Transit:{agency}"; a fare whose agency isn't known goes to
+	// "Other".
+	Fares string `yaml:"fares"`
+	// Reloads is the account value added to a card comes from. It defaults
+	// to "Assets:Unknown", for you to move to the bank account or benefit
+	// that paid.
+	Reloads string `yaml:"reloads"`
+	// Opening is the account a card's balance before its first transaction
+	// comes from. It defaults to "Equity:Opening-Balances".
+	Opening string `yaml:"opening"`
+	// Types send transactions whose type matches, like "Autoload" or
+	// "Purchase", to another account instead. The first match wins.
+	Types []BeancountRule `yaml:"types"`
+	// Currency defaults to "USD".
+	Currency string `yaml:"currency"`
+}
+
+// A BeancountRule sends transactions of a type to an account.
+type BeancountRule struct {
+	// Type matches a transaction whose type contains it, ignoring case.
+	Type    string `yaml:"type"`
+	Account string `yaml:"account"`
+}
+
+// LoadBeancountRules reads BeancountRules in YAML.
+func LoadBeancountRules(r io.Reader) (BeancountRules, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return BeancountRules{}, err
+	}
+	var rules BeancountRules
+	if err := yaml.UnmarshalStrict(data, &rules); err != nil {
+		return BeancountRules{}, fmt.Errorf("export: reading Beancount rules: %v", err)
+	}
+	for _, rule := range rules.Types {
+		if rule.Type == "" || rule.Account == "" {
+			return BeancountRules{}, fmt.Errorf("export: a Beancount rule needs a type and an account: %+v", rule)
+		}
+	}
+	return rules, nil
+}
+
+// counterpart returns the account t's change to the card's balance is
+// posted against: the account of the first rule matching its type, or else
+// the one for fares or reloads.
+func (r BeancountRules) counterpart(t clipper.Transaction) string {
+	lower := strings.ToLower(t.Type)
+	for _, rule := range r.Types {
+		if strings.Contains(lower, strings.ToLower(rule.Type)) {
+			return r.account(t, rule.Account)
+		}
+	}
+	if t.IsReload() {
+		return r.account(t, r.Reloads)
+	}
+	return r.account(t, r.Fares)
+}
+
+// account returns account with the placeholders for t filled in.
+func (r BeancountRules) account(t clipper.Transaction, account string) string {
+	agency := "Other"
+	if a := t.Agency(); a != clipper.AgencyUnknown {
+		agency = beancountName(string(a))
+	}
+	return strings.NewReplacer("{card}", strconv.FormatInt(t.CardSerial, 10), "{agency}", agency).Replace(account)
+}
+
+// beancountName makes s fit in an account name, which allows letters, digits
+// and dashes, and has to start with a capital letter or digit.
+func beancountName(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		default:
+			dash = true
+		}
+	}
+	name := b.String()
+	if name == "" {
+		return "Other"
+	}
+	if c := name[0]; c >= 'a' && c <= 'z' {
+		name = string(c-'a'+'A') + name[1:]
+	}
+	return name
+}
+
+// A BeancountEncoder writes transactions as a Beancount ledger. Each
+// transaction that changes a card's balance is posted between the card's
+// account and a fare, reload or other account from its BeancountRules, with
+// the location as the payee and the type and route as the narration; ones
+// that don't, like the entry tag of a BART ride, are left out. Accounts are
+// opened on the day they're first used.
+//
+// After the last transaction on each day, the encoder asserts the card's
+// balance on the statement, dated the next day, since Beancount checks a
+// balance at the start of the day. The transactions for each card have to
+// come oldest first, as store.Merge returns them.
+type BeancountEncoder struct {
+	bw     *bufio.Writer
+	rules  BeancountRules
+	opened map[string]bool
+	// last is each card's latest transaction whose balance hasn't been
+	// asserted yet.
+	last map[int64]clipper.Transaction
+}
+
+// NewBeancountEncoder returns a BeancountEncoder that writes to w, posting to
+// the accounts from rules.
+func NewBeancountEncoder(w io.Writer, rules BeancountRules) *BeancountEncoder {
+	if rules.Card == "" {
+		rules.Card = "Assets:Clipper:{card}"
+	}
+	if rules.Fares == "" {
+		rules.Fares = "Expenses:Transit:{agency}"
+	}
+	if rules.Reloads == "" {
+		rules.Reloads = "Assets:Unknown"
+	}
+	if rules.Opening == "" {
+		rules.Opening = "Equity:Opening-Balances"
+	}
+	if rules.Currency == "" {
+		rules.Currency = "USD"
+	}
+	return &BeancountEncoder{
+		bw:     bufio.NewWriter(w),
+		rules:  rules,
+		opened: make(map[string]bool),
+		last:   make(map[int64]clipper.Transaction),
+	}
+}
+
+const beancountDate = "2006-01-02"
+
+// open opens account on day, if it isn't open already.
+func (e *BeancountEncoder) open(day time.Time, account string) {
+	if e.opened[account] {
+		return
+	}
+	e.opened[account] = true
+	fmt.Fprintf(e.bw, "%s open %s %s\n\n", day.Format(beancountDate), account, e.rules.Currency)
+}
+
+// assertBalance asserts the balance after t, on the day after it.
+func (e *BeancountEncoder) assertBalance(t clipper.Transaction) {
+	day := t.Timestamp.In(clipper.Pacific)
+	e.open(day, e.rules.account(t, e.rules.Card))
+	day = day.AddDate(0, 0, 1)
+	fmt.Fprintf(e.bw, "%s balance %s %s %s\n\n", day.Format(beancountDate), e.rules.account(t, e.rules.Card), t.BalanceCents.Decimal(), e.rules.Currency)
+}
+
+// Encode writes t as a Beancount transaction, after the balance assertion
+// for the card's last day, if t is on a later one.
+func (e *BeancountEncoder) Encode(t clipper.Transaction) error {
+	ts := t.Timestamp.In(clipper.Pacific)
+	change := t.CreditCents - t.DebitCents
+	prev, ok := e.last[t.CardSerial]
+	if ok && prev.Timestamp.In(clipper.Pacific).Format(beancountDate) != ts.Format(beancountDate) {
+		e.assertBalance(prev)
+	}
+	if !ok {
+		e.openingBalance(t, t.BalanceCents-change)
+	}
+	e.last[t.CardSerial] = t
+	if change == 0 {
+		return nil
+	}
+	card, other := e.rules.account(t, e.rules.Card), e.rules.counterpart(t)
+	e.open(ts, card)
+	e.open(ts, other)
+
+	narration := t.Type
+	if t.Route != "" {
+		narration += ", route " + t.Route
+	}
+	fmt.Fprintf(e.bw, "%s * %s %s\n", ts.Format(beancountDate), beancountString(t.Location), beancountString(narration))
+	fmt.Fprintf(e.bw, "  id: %s\n  time: %s\n", beancountString(store.ID(t)), beancountString(ts.Format("15:04")))
+	fmt.Fprintf(e.bw, "  %s  %s %s\n", card, change.Decimal(), e.rules.Currency)
+	_, err := fmt.Fprintf(e.bw, "  %s  %s %s\n\n", other, (-change).Decimal(), e.rules.Currency)
+	return err
+}
+
+// openingBalance brings the card's account up to balance, what was on the
+// card before t, its first transaction.
+func (e *BeancountEncoder) openingBalance(t clipper.Transaction, balance clipper.Money) {
+	if balance == 0 {
+		return
+	}
+	ts := t.Timestamp.In(clipper.Pacific)
+	card := e.rules.account(t, e.rules.Card)
+	e.open(ts, card)
+	e.open(ts, e.rules.Opening)
+	fmt.Fprintf(e.bw, "%s * \"Clipper\" \"Opening balance\"\n", ts.Format(beancountDate))
+	fmt.Fprintf(e.bw, "  %s  %s %s\n", card, balance.Decimal(), e.rules.Currency)
+	fmt.Fprintf(e.bw, "  %s  %s %s\n\n", e.rules.Opening, (-balance).Decimal(), e.rules.Currency)
+}
+
+// Close asserts each card's balance after its last transaction, and
+// flushes.
+func (e *BeancountEncoder) Close() error {
+	cards := make([]int64, 0, len(e.last))
+	for card := range e.last {
+		cards = append(cards, card)
+	}
+	sort.Slice(cards, func(i, j int) bool { return cards[i] < cards[j] })
+	for _, card := range cards {
+		e.assertBalance(e.last[card])
+	}
+	e.last = make(map[int64]clipper.Transaction)
+	return e.bw.Flush()
+}
+
+// beancountString quotes s as a Beancount string.
+func beancountString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ").Replace(s) + `"`
+}
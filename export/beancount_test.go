@@ -0,0 +1,132 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/store"
+)
+
+// checkBalances checks the balance assertions in a Beancount ledger the way
+// Beancount does: against the sum of the postings dated before them.
+func checkBalances(t *testing.T, ledger string) int {
+	t.Helper()
+	type posting struct {
+		date, account string
+		amount        clipper.Money
+	}
+	var postings []posting
+	date := ""
+	checked := 0
+	for _, line := range strings.Split(ledger, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0 || strings.HasSuffix(fields[0], ":"):
+		case strings.HasPrefix(line, "  "):
+			amount, err := clipper.ParseMoney(fields[1])
+			if err != nil {
+				t.Fatalf("posting %q: %v", line, err)
+			}
+			postings = append(postings, posting{date, fields[0], amount})
+		case len(fields) > 1 && fields[1] == "balance":
+			want, err := clipper.ParseMoney(fields[3])
+			if err != nil {
+				t.Fatalf("balance %q: %v", line, err)
+			}
+			var got clipper.Money
+			for _, p := range postings {
+				if p.account == fields[2] && p.date < fields[0] {
+					got += p.amount
+				}
+			}
+			if got != want {
+				t.Errorf("%s: postings add up to %s", line, got.Decimal())
+			}
+			checked++
+		default:
+			date = fields[0]
+		}
+	}
+	return checked
+}
+
+func TestBeancountEncoder(t *testing.T) {
+	txns := statement(t)
+	var buf bytes.Buffer
+	encodeAll(t, NewBeancountEncoder(&buf, BeancountRules{}), txns)
+	out := buf.String()
+	for _, want := range []string{
+		"2017-12-12 open Assets:Clipper:1202728442 USD\n",
+		"2017-12-12 * \"Clipper\" \"Opening balance\"\n  Assets:Clipper:1202728442  148.90 USD\n  Equity:Opening-Balances  -148.90 USD\n",
+		"2017-12-12 * \"SAM bus\" \"Single-tag fare payment, route LOC\"\n  id: \"" + store.ID(txns[0]) + "\"\n  time: \"09:17\"\n  Assets:Clipper:1202728442  -2.05 USD\n  Expenses:Transit:SamTrans  2.05 USD\n",
+		"2017-12-13 balance Assets:Clipper:1202728442 144.80 USD\n",
+		"2017-12-17 open Expenses:Transit:BART USD\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, out)
+		}
+	}
+	if n := checkBalances(t, out); n == 0 {
+		t.Errorf("no balance assertions:\n%s", out)
+	}
+	last := txns[len(txns)-1]
+	if want := "balance Assets:Clipper:1202728442 " + last.BalanceCents.Decimal() + " USD\n\n"; !strings.HasSuffix(out, want) {
+		t.Errorf("output doesn't end with the last balance %q", want)
+	}
+
+	buf.Reset()
+	encodeAll(t, NewBeancountEncoder(&buf, BeancountRules{}), nil)
+	if buf.Len() != 0 {
+		t.Errorf("no transactions: got %q, want nothing", buf.String())
+	}
+}
+
+func TestBeancountRules(t *testing.T) {
+	rules, err := LoadBeancountRules(strings.NewReader(`
+card: Assets:Transit:Clipper
+fares: Expenses:Commute:{agency}
+types:
+  - type: purse rebate
+    account: Income:Rebates
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	encodeAll(t, NewBeancountEncoder(&buf, rules), statement(t))
+	out := buf.String()
+	for _, want := range []string{
+		"  Assets:Transit:Clipper  -2.05 USD\n  Expenses:Commute:SamTrans  2.05 USD\n",
+		"  Assets:Transit:Clipper  6.75 USD\n  Income:Rebates  -6.75 USD\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, out)
+		}
+	}
+	checkBalances(t, out)
+
+	for _, in := range []string{
+		"fares: [",
+		"cards: Assets:Clipper",
+		"types:\n  - type: Autoload\n",
+	} {
+		if _, err := LoadBeancountRules(strings.NewReader(in)); err == nil {
+			t.Errorf("LoadBeancountRules(%q): got nil error", in)
+		}
+	}
+}
+
+func TestBeancountName(t *testing.T) {
+	for in, want := range map[string]string{
+		"AC Transit":   "AC-Transit",
+		"BART":         "BART",
+		"muni (SFMTA)": "Muni-SFMTA",
+		"  ":           "Other",
+	} {
+		if got := beancountName(in); got != want {
+			t.Errorf("beancountName(%q): got %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Package export writes transactions out one at a time, as CSV, JSON, JSON
+// Lines, OFX or a Beancount ledger, to any io.Writer. An Encoder holds on to
+// the transaction it's writing and, at most, a little about each card, so a
+// whole merged archive can go straight to a file, a gzip writer or an upload
+// without being built up in memory first:
+//
+//	enc := export.NewJSONLEncoder(gz)
+//	for _, t := range txns {
+//		if err := enc.Encode(t); err != nil {
+//			return err
+//		}
+//	}
+//	return enc.Close()
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clippercsv"
+	"github.com/kevinburke/clipper/store"
+)
+
+// An Encoder writes transactions to an io.Writer in some format. Close writes
+// whatever the format needs after the last transaction, and flushes; it
+// doesn't close the io.Writer.
+type Encoder interface {
+	Encode(t clipper.Transaction) error
+	Close() error
+}
+
+// Formats are the formats New knows, by name.
+var Formats = []string{"beancount", "csv", "json", "jsonl", "ofx"}
+
+// Options are the options for each format, for New.
+type Options struct {
+	CSV       clippercsv.Options
+	OFX       OFXOptions
+	Beancount BeancountRules
+}
+
+// New returns an Encoder for the named format, one of Formats, that writes to
+// w with the options for that format.
+func New(format string, w io.Writer, opts Options) (Encoder, error) {
+	switch format {
+	case "beancount":
+		return NewBeancountEncoder(w, opts.Beancount), nil
+	case "csv":
+		return NewCSVEncoder(w, opts.CSV), nil
+	case "json":
+		return NewJSONEncoder(w), nil
+	case "jsonl":
+		return NewJSONLEncoder(w), nil
+	case "ofx":
+		return NewOFXEncoder(w, opts.OFX), nil
+	}
+	return nil, fmt.Errorf("export: unknown format %q", format)
+}
+
+// CSVHeader names the columns a CSVEncoder writes.
+var CSVHeader = []string{"time", "card", "type", "location", "route", "product", "debit", "credit", "balance", "id"}
+
+// A CSVEncoder writes transactions as CSV, after a header row, with times in
+// RFC 3339 format and amounts in dollars.
+type CSVEncoder struct {
+	w      *clippercsv.Writer
+	header bool
+}
+
+// NewCSVEncoder returns a CSVEncoder that writes to w with opts.
+func NewCSVEncoder(w io.Writer, opts clippercsv.Options) *CSVEncoder {
+	return &CSVEncoder{w: clippercsv.NewWriter(w, opts)}
+}
+
+func (e *CSVEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	return e.w.Write(CSVHeader)
+}
+
+// Encode writes t as a CSV row.
+func (e *CSVEncoder) Encode(t clipper.Transaction) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	return e.w.Write([]string{
+		t.Timestamp.Format(time.RFC3339),
+		strconv.FormatInt(t.CardSerial, 10),
+		t.Type,
+		t.Location,
+		t.Route,
+		t.Product,
+		t.DebitCents.Decimal(),
+		t.CreditCents.Decimal(),
+		t.BalanceCents.Decimal(),
+		store.ID(t),
+	})
+}
+
+// Close writes the header, if nothing else has been written, and flushes.
+func (e *CSVEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// A Record is a transaction as a JSONEncoder or JSONLEncoder writes it. It's
+// the same as clipper.TransactionData.WriteJSON writes, so scripts can read
+// either.
+type Record = clipper.Record
+
+// RecordOf returns t as a Record.
+func RecordOf(t clipper.Transaction) Record {
+	return clipper.RecordOf(t)
+}
+
+// A JSONEncoder writes transactions as a JSON array of Records, one on each
+// line, for programs that want a single JSON value.
+type JSONEncoder struct {
+	bw *bufio.Writer
+	n  int
+}
+
+// NewJSONEncoder returns a JSONEncoder that writes to w.
+func NewJSONEncoder(w io.Writer) *JSONEncoder {
+	return &JSONEncoder{bw: bufio.NewWriter(w)}
+}
+
+// Encode writes t as the next element of the array.
+func (e *JSONEncoder) Encode(t clipper.Transaction) error {
+	data, err := json.Marshal(RecordOf(t))
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if e.n == 0 {
+		sep = "[\n"
+	}
+	e.n++
+	e.bw.WriteString(sep)
+	_, err = e.bw.Write(data)
+	return err
+}
+
+// Close ends the array, and flushes.
+func (e *JSONEncoder) Close() error {
+	end := "\n]\n"
+	if e.n == 0 {
+		end = "[]\n"
+	}
+	e.bw.WriteString(end)
+	return e.bw.Flush()
+}
+
+// A JSONLEncoder writes transactions as JSON Lines: a Record on each line.
+type JSONLEncoder struct {
+	bw  *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewJSONLEncoder returns a JSONLEncoder that writes to w.
+func NewJSONLEncoder(w io.Writer) *JSONLEncoder {
+	bw := bufio.NewWriter(w)
+	return &JSONLEncoder{bw: bw, enc: json.NewEncoder(bw)}
+}
+
+// Encode writes t as a line of JSON.
+func (e *JSONLEncoder) Encode(t clipper.Transaction) error {
+	return e.enc.Encode(RecordOf(t))
+}
+
+// Close flushes e.
+func (e *JSONLEncoder) Close() error {
+	return e.bw.Flush()
+}
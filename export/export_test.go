@@ -0,0 +1,190 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clippercsv"
+	"github.com/kevinburke/clipper/store"
+)
+
+func statement(t *testing.T) []clipper.Transaction {
+	t.Helper()
+	txns, err := store.ParseStatement("../testdata/transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return txns
+}
+
+func encodeAll(t *testing.T, enc Encoder, txns []clipper.Transaction) {
+	t.Helper()
+	for _, txn := range txns {
+		if err := enc.Encode(txn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCSVEncoder(t *testing.T) {
+	txns := statement(t)
+	var buf bytes.Buffer
+	encodeAll(t, NewCSVEncoder(&buf, clippercsv.Options{}), txns)
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(txns)+1 {
+		t.Fatalf("got %d records, want a header and %d transactions", len(records), len(txns))
+	}
+	if got := strings.Join(records[0], ","); got != strings.Join(CSVHeader, ",") {
+		t.Errorf("header: got %q", got)
+	}
+	first := records[1]
+	if first[0] != "2017-12-12T09:17:00-08:00" || first[1] != "1202728442" || first[6] != "2.05" || first[9] != store.ID(txns[0]) {
+		t.Errorf("first row: got %q", first)
+	}
+
+	buf.Reset()
+	encodeAll(t, NewCSVEncoder(&buf, clippercsv.Options{}), nil)
+	if got := buf.String(); got != strings.Join(CSVHeader, ",")+"\n" {
+		t.Errorf("no transactions: got %q, want just the header", got)
+	}
+}
+
+func TestJSONLEncoder(t *testing.T) {
+	txns := statement(t)
+	var buf bytes.Buffer
+	encodeAll(t, NewJSONLEncoder(&buf), txns)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(txns) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(txns))
+	}
+	var r Record
+	if err := json.Unmarshal([]byte(lines[0]), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.ID != store.ID(txns[0]) || r.DebitCents != 205 || !r.Timestamp.Equal(txns[0].Timestamp) || r.CardSerial != 1202728442 {
+		t.Errorf("first line: got %+v", r)
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	txns := statement(t)
+	var buf bytes.Buffer
+	encodeAll(t, NewJSONEncoder(&buf), txns)
+	var records []Record
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(txns) || records[0].ID != store.ID(txns[0]) {
+		t.Errorf("got %d records, want %d", len(records), len(txns))
+	}
+
+	buf.Reset()
+	encodeAll(t, NewJSONEncoder(&buf), nil)
+	if got := buf.String(); got != "[]\n" {
+		t.Errorf("no transactions: got %q, want an empty array", got)
+	}
+}
+
+// TestJSONMatchesWriteJSON checks that a JSONEncoder and
+// clipper.TransactionData.WriteJSON write the same JSON for a statement.
+func TestJSONMatchesWriteJSON(t *testing.T) {
+	f, err := os.Open("../testdata/transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, err := clipper.ParsePDF(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromLibrary bytes.Buffer
+	if err := data.WriteJSON(&fromLibrary); err != nil {
+		t.Fatal(err)
+	}
+	txns, err := data.ParsedTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromEncoder bytes.Buffer
+	encodeAll(t, NewJSONEncoder(&fromEncoder), txns)
+
+	// Compare them as generic JSON, so a field named differently shows up.
+	var want, got []map[string]any
+	if err := json.Unmarshal(fromEncoder.Bytes(), &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(fromLibrary.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(want) == 0 {
+		t.Fatal("JSONEncoder wrote no transactions")
+	}
+	if len(got) != len(want) {
+		t.Fatalf("WriteJSON wrote %d transactions, JSONEncoder %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("transaction %d:\nWriteJSON:   %v\nJSONEncoder: %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestOFXEncoder(t *testing.T) {
+	txns := statement(t)
+	var buf bytes.Buffer
+	end := time.Date(2018, 2, 12, 0, 0, 0, 0, clipper.Pacific)
+	encodeAll(t, NewOFXEncoder(&buf, OFXOptions{End: end}), txns)
+	out := buf.String()
+	if !strings.HasPrefix(out, "OFXHEADER:100\r\n") || !strings.HasSuffix(out, "</OFX>\r\n") {
+		t.Errorf("not an OFX file:\n%s", out)
+	}
+	if n := strings.Count(out, "<STMTTRN>"); n != len(txns) {
+		t.Errorf("got %d transactions, want %d", n, len(txns))
+	}
+	for _, want := range []string{
+		"<ACCTID>1202728442<",
+		"<DTSTART>20171212091700.000[-8:PST]<DTEND>20180212000000.000[-8:PST]",
+		"<TRNTYPE>DEBIT<DTPOSTED>20171212091700.000[-8:PST]<TRNAMT>-2.05<FITID>" + store.ID(txns[0]) + "<NAME>Single-tag fare payment<MEMO>SAM bus - LOC - Clipper Cash</STMTTRN>",
+		"<LEDGERBAL><BALAMT>" + txns[len(txns)-1].BalanceCents.Decimal() + "<",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	encodeAll(t, NewOFXEncoder(&buf, OFXOptions{Account: "household", End: end}), nil)
+	if out := buf.String(); !strings.Contains(out, "<ACCTID>household<") || !strings.Contains(out, "<BANKTRANLIST><DTSTART>20180212000000.000[-8:PST]") {
+		t.Errorf("no transactions:\n%s", out)
+	}
+}
+
+func TestOFXText(t *testing.T) {
+	if got := ofxText("A&B <Station>\n"+strings.Repeat("x", 40), 20); got != "A&amp;B &lt;Station&gt; xxxxxx" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, format := range Formats {
+		if _, err := New(format, new(bytes.Buffer), Options{}); err != nil {
+			t.Errorf("New(%q): %v", format, err)
+		}
+	}
+	if _, err := New("xlsx", new(bytes.Buffer), Options{}); err == nil {
+		t.Error("New(xlsx): want an error")
+	}
+}
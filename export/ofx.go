@@ -0,0 +1,137 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/store"
+)
+
+// OFXOptions control what an OFXEncoder says about the statement around the
+// transactions.
+type OFXOptions struct {
+	// Account is the account number the statement is for. It defaults to
+	// the serial number of the card of the first transaction; set it when
+	// encoding the transactions of several cards together.
+	Account string
+	// Start and End are the period the statement covers. An OFX file gives
+	// them before the transactions, so an OFXEncoder can't work them out
+	// from the transactions it writes; Start defaults to the time of the
+	// first transaction, and End to when the encoder was made.
+	Start, End time.Time
+}
+
+// An OFXEncoder writes transactions as an OFX 1.0.2 bank statement, which
+// GnuCash, Quicken and most other finance programs can import. Each
+// transaction's FITID, which those programs use to skip transactions they've
+// already imported, is its store.ID. The statement's ledger balance is the
+// balance after the last transaction.
+type OFXEncoder struct {
+	bw      *bufio.Writer
+	opts    OFXOptions
+	started bool
+	last    clipper.Transaction
+	n       int
+}
+
+// NewOFXEncoder returns an OFXEncoder that writes to w with opts.
+func NewOFXEncoder(w io.Writer, opts OFXOptions) *OFXEncoder {
+	if opts.End.IsZero() {
+		opts.End = time.Now()
+	}
+	return &OFXEncoder{bw: bufio.NewWriter(w), opts: opts}
+}
+
+// ofxHeader starts every file. OFX 1.x files are SGML, with a plain text
+// header in front.
+const ofxHeader = "OFXHEADER:100\r\nDATA:OFXSGML\r\nVERSION:102\r\nSECURITY:NONE\r\nENCODING:UTF-8\r\nCHARSET:NONE\r\nCOMPRESSION:NONE\r\nOLDFILEUID:NONE\r\nNEWFILEUID:NONE\r\n\r\n"
+
+// start writes everything before the first transaction. first is the first
+// transaction, or the zero Transaction if there aren't any.
+func (e *OFXEncoder) start(first clipper.Transaction) {
+	e.started = true
+	if e.opts.Account == "" && first.CardSerial != 0 {
+		e.opts.Account = strconv.FormatInt(first.CardSerial, 10)
+	}
+	if e.opts.Start.IsZero() {
+		e.opts.Start = first.Timestamp
+		if first.Timestamp.IsZero() {
+			e.opts.Start = e.opts.End
+		}
+	}
+	e.bw.WriteString(ofxHeader)
+	e.bw.WriteString("<OFX>\r\n<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS>")
+	fmt.Fprintf(e.bw, "<DTSERVER>%s<LANGUAGE>ENG</SONRS></SIGNONMSGSRSV1>\r\n", ofxTime(e.opts.End))
+	e.bw.WriteString("<BANKMSGSRSV1><STMTTRNRS><TRNUID>0<STATUS><CODE>0<SEVERITY>INFO</STATUS>\r\n")
+	fmt.Fprintf(e.bw, "<STMTRS><CURDEF>USD<BANKACCTFROM><BANKID>CLIPPER<ACCTID>%s<ACCTTYPE>CHECKING</BANKACCTFROM>\r\n", ofxText(e.opts.Account, 22))
+	fmt.Fprintf(e.bw, "<BANKTRANLIST><DTSTART>%s<DTEND>%s\r\n", ofxTime(e.opts.Start), ofxTime(e.opts.End))
+}
+
+// Encode writes t as a statement transaction, after the statement's header if
+// t is the first.
+func (e *OFXEncoder) Encode(t clipper.Transaction) error {
+	if !e.started {
+		e.start(t)
+	}
+	amount := t.CreditCents - t.DebitCents
+	typ := "OTHER"
+	switch {
+	case amount < 0:
+		typ = "DEBIT"
+	case amount > 0:
+		typ = "CREDIT"
+	}
+	var memo []string
+	for _, s := range []string{t.Location, t.Route, t.Product} {
+		if s != "" {
+			memo = append(memo, s)
+		}
+	}
+	fmt.Fprintf(e.bw, "<STMTTRN><TRNTYPE>%s<DTPOSTED>%s<TRNAMT>%s<FITID>%s<NAME>%s", typ, ofxTime(t.Timestamp), amount.Decimal(), store.ID(t), ofxText(t.Type, 32))
+	if len(memo) > 0 {
+		fmt.Fprintf(e.bw, "<MEMO>%s", ofxText(strings.Join(memo, " - "), 255))
+	}
+	_, err := e.bw.WriteString("</STMTTRN>\r\n")
+	e.last = t
+	e.n++
+	return err
+}
+
+// Close writes the end of the statement, with the balance after the last
+// transaction, and flushes.
+func (e *OFXEncoder) Close() error {
+	if !e.started {
+		e.start(clipper.Transaction{})
+	}
+	asOf := e.opts.End
+	if e.n > 0 {
+		asOf = e.last.Timestamp
+	}
+	e.bw.WriteString("</BANKTRANLIST>\r\n")
+	fmt.Fprintf(e.bw, "<LEDGERBAL><BALAMT>%s<DTASOF>%s</LEDGERBAL>\r\n", e.last.BalanceCents.Decimal(), ofxTime(asOf))
+	e.bw.WriteString("</STMTRS></STMTTRNRS></BANKMSGSRSV1>\r\n</OFX>\r\n")
+	return e.bw.Flush()
+}
+
+// ofxTime formats t as an OFX date and time, with its offset from UTC and
+// the name of its time zone, like "20180212091700.000[-8:PST]".
+func ofxTime(t time.Time) string {
+	name, offset := t.Zone()
+	return fmt.Sprintf("%s[%s:%s]", t.Format("20060102150405.000"), strconv.FormatFloat(float64(offset)/3600, 'f', -1, 64), name)
+}
+
+var ofxEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", " ", "\n", " ")
+
+// ofxText returns s escaped for OFX and cut to at most max characters, the
+// most OFX allows in the element it's going in.
+func ofxText(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		s = string(r[:max])
+	}
+	return ofxEscaper.Replace(s)
+}
@@ -0,0 +1,232 @@
+package clipper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// A Scrubber removes personal data from pages downloaded from the Clipper
+// site, so they can be checked in as test fixtures.
+//
+// Card serial numbers are masked, keeping their length and last four digits so
+// they still parse the same way. Nicknames are replaced with "Card 1",
+// "Card 2" and so on, as whole words in the page's text and attribute values,
+// and as quoted strings in its scripts, so a short nickname like "Me" doesn't
+// rewrite tags or code. Email addresses, phone numbers and form tokens are
+// replaced wherever they appear, as are the values of live Secrets, like the
+// client's password.
+type Scrubber struct {
+	replacer [][2][]byte
+	// names are the nicknames and extra strings, replaced by scrubNames.
+	names [][2]string
+}
+
+// NewScrubber returns a Scrubber that hides the given cards and account email
+// address, along with any other strings in extra (like the account holder's
+// name).
+func NewScrubber(cards []Card, email string, extra ...string) *Scrubber {
+	s := new(Scrubber)
+	for i, card := range cards {
+		serial := strconv.FormatInt(card.SerialNumber, 10)
+		s.add(serial, MaskSerial(serial))
+		if card.Nickname != "" {
+			s.names = append(s.names, [2]string{card.Nickname, fmt.Sprintf("Card %d", i+1)})
+		}
+	}
+	if email != "" {
+		s.add(email, "user@example.com")
+	}
+	for i, e := range extra {
+		if e != "" {
+			s.names = append(s.names, [2]string{e, fmt.Sprintf("Redacted %d", i+1)})
+		}
+	}
+	return s
+}
+
+func (s *Scrubber) add(old, new string) {
+	s.replacer = append(s.replacer, [2][]byte{[]byte(old), []byte(new)})
+}
+
+// MaskSerial replaces all but the first and last four digits of a card serial
+// number with zeros.
+func MaskSerial(serial string) string {
+	if len(serial) <= 5 {
+		return serial
+	}
+	b := []byte(serial)
+	for i := 1; i < len(b)-4; i++ {
+		b[i] = '0'
+	}
+	return string(b)
+}
+
+var (
+	emailRx = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phoneRx = regexp.MustCompile(`\b\d{3}[-. ]\d{3}[-. ]\d{4}\b`)
+	// Values of CSRF and session inputs, with the value attribute before or
+	// after the name.
+	tokenAfterRx  = regexp.MustCompile(`((?:name|id)="(?:_csrf|javax\.faces\.ViewState)"[^>]*?(?:value|content)=")[^"]*`)
+	tokenBeforeRx = regexp.MustCompile(`((?:value|content)=")[^"]*("[^>]*?(?:name|id)="(?:_csrf|javax\.faces\.ViewState)")`)
+)
+
+// Scrub returns a copy of page with personal data replaced.
+func (s *Scrubber) Scrub(page []byte) []byte {
+	out := s.scrubNames(page)
+	for _, r := range s.replacer {
+		out = bytes.Replace(out, r[0], r[1], -1)
+	}
+	out = emailRx.ReplaceAll(out, []byte("user@example.com"))
+	out = phoneRx.ReplaceAll(out, []byte("555-555-0100"))
+	out = tokenAfterRx.ReplaceAll(out, []byte("${1}redacted"))
+	out = tokenBeforeRx.ReplaceAll(out, []byte("${1}redacted${2}"))
+	return redactSecrets(out)
+}
+
+// scrubNames returns a copy of page with the names replaced in its text and
+// attribute values, where they appear HTML-escaped, and in quoted strings in
+// its scripts. Style sheets, and tokens without a name, are copied as they are.
+func (s *Scrubber) scrubNames(page []byte) []byte {
+	var out bytes.Buffer
+	z := html.NewTokenizer(bytes.NewReader(page))
+	// The element whose contents are raw text, like a script, or 0.
+	var rawText atom.Atom
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return out.Bytes()
+		}
+		// Token unescapes the tokenizer's buffer in place, so copy this first.
+		raw := append([]byte(nil), z.Raw()...)
+		switch tt {
+		case html.TextToken:
+			if rawText == atom.Script {
+				out.Write(s.replaceQuotedNames(raw))
+				continue
+			}
+			if rawText == atom.Style {
+				break
+			}
+			tok := z.Token()
+			if text := s.replaceNames(tok.Data); text != tok.Data {
+				out.WriteString(html.EscapeString(text))
+				continue
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if tt == html.StartTagToken && (tok.DataAtom == atom.Script || tok.DataAtom == atom.Style) {
+				rawText = tok.DataAtom
+			}
+			changed := false
+			for i, a := range tok.Attr {
+				if val := s.replaceNames(a.Val); val != a.Val {
+					tok.Attr[i].Val = val
+					changed = true
+				}
+			}
+			if changed {
+				out.WriteString(tok.String())
+				continue
+			}
+		case html.EndTagToken:
+			rawText = 0
+		}
+		out.Write(raw)
+	}
+}
+
+// replaceNames replaces each name in text where it's a whole word.
+func (s *Scrubber) replaceNames(text string) string {
+	for _, n := range s.names {
+		text = replaceWord(text, n[0], n[1])
+	}
+	return text
+}
+
+// replaceQuotedNames replaces each name in script where it's a whole string
+// in double or single quotes.
+func (s *Scrubber) replaceQuotedNames(script []byte) []byte {
+	for _, n := range s.names {
+		for _, q := range []string{`"`, `'`} {
+			script = bytes.Replace(script, []byte(q+n[0]+q), []byte(q+n[1]+q), -1)
+		}
+	}
+	return script
+}
+
+// replaceWord replaces old in s with new where old isn't part of a longer
+// word, so that "Me" is replaced in "Signed in as Me" but not in "Menu".
+func replaceWord(s, old, new string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, old)
+		if i < 0 {
+			break
+		}
+		before, _ := utf8.DecodeLastRuneInString(s[:i])
+		after, _ := utf8.DecodeRuneInString(s[i+len(old):])
+		b.WriteString(s[:i])
+		if isWordRune(before) || isWordRune(after) {
+			b.WriteString(old)
+		} else {
+			b.WriteString(new)
+		}
+		s = s[i+len(old):]
+	}
+	if b.Len() == 0 {
+		return s
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// RecordFixtures downloads the account page and the detail page for each card,
+// scrubs personal data from them, and writes them to dir. It returns the names
+// of the files it wrote.
+//
+// Check the output by hand before committing it; the scrubber can only remove
+// data it knows about.
+func (c *Client) RecordFixtures(ctx context.Context, dir string) ([]string, error) {
+	dashboardData, err := c.dashboardPage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cards, err := c.dashboardCards(ctx, dashboardData)
+	if err != nil {
+		return nil, err
+	}
+	s := NewScrubber(cards, c.username)
+	pages := map[string][]byte{"dashboard.html": dashboardData}
+	for _, card := range cards {
+		body, err := c.getPage(ctx, c.cardDetailsURL(card.SerialNumber), c.host+"/ClipperWeb/account.html", false)
+		if err != nil {
+			return nil, fmt.Errorf("could not get details for card %d: %v", card.SerialNumber, err)
+		}
+		name := "card-" + MaskSerial(strconv.FormatInt(card.SerialNumber, 10)) + ".html"
+		pages[name] = body
+	}
+	written := make([]string, 0, len(pages))
+	for name, page := range pages {
+		filename := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filename, s.Scrub(page), 0644); err != nil {
+			return written, err
+		}
+		written = append(written, filename)
+	}
+	return written, nil
+}
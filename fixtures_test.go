@@ -0,0 +1,69 @@
+package clipper
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestScrub(t *testing.T) {
+	page := []byte(`<span class="d-inline-block">1401491737 - Guest</span>
+<p>Signed in as kev@example.org, phone 925-555-1234</p>
+<input type="hidden" name="_csrf" value="0f9a-secret-token"/>
+<input value="another-secret" type="hidden" name="_csrf">`)
+	s := NewScrubber([]Card{{SerialNumber: 1401491737, Nickname: "Guest"}}, "kev@example.org")
+	out := s.Scrub(page)
+	for _, secret := range []string{"1401491737", "Guest", "kev@example.org", "925-555-1234", "0f9a-secret-token", "another-secret"} {
+		if bytes.Contains(out, []byte(secret)) {
+			t.Errorf("scrubbed page still contains %q:\n%s", secret, out)
+		}
+	}
+	cards, err := getCards(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 1 || cards[0].SerialNumber != 1000001737 || cards[0].Nickname != "Card 1" {
+		t.Errorf("scrubbed page should still parse, got %#v", cards)
+	}
+}
+
+func TestScrubShortName(t *testing.T) {
+	page := `<html><head><style>.Me > a { color: red }</style></head>
+<body><div class="Menu" data-card="Me"><a href="/Memo/Messages">Messages for Me</a></div>
+<script>var Me = 1; var nickname = "Me";</script></body></html>`
+	s := NewScrubber([]Card{{SerialNumber: 1401491737, Nickname: "Me"}}, "")
+	out := string(s.Scrub([]byte(page)))
+	for _, want := range []string{
+		`<style>.Me > a { color: red }</style>`,
+		`class="Menu" data-card="Card 1"`,
+		`href="/Memo/Messages"`,
+		`>Messages for Card 1</a>`,
+		`var Me = 1; var nickname = "Card 1";`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("scrubbed page should contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestScrubEscapedName(t *testing.T) {
+	page := []byte(`<span class="d-inline-block" title="Tom &amp; Jerry&#39;s">1401491737 - Tom &amp; Jerry&#39;s</span>
+<p>Card holder: Tom &amp; Jerry's &lt;3</p>`)
+	s := NewScrubber([]Card{{SerialNumber: 1401491737, Nickname: "Tom & Jerry's"}}, "")
+	out := s.Scrub(page)
+	for _, secret := range []string{"Tom", "Jerry"} {
+		if bytes.Contains(out, []byte(secret)) {
+			t.Errorf("scrubbed page still contains %q:\n%s", secret, out)
+		}
+	}
+	if !bytes.Contains(out, []byte("Card holder: Card 1 &lt;3")) {
+		t.Errorf("scrubbed page should keep the text around the name escaped:\n%s", out)
+	}
+	cards, err := getCards(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 1 || cards[0].Nickname != "Card 1" {
+		t.Errorf("scrubbed page should still parse, got %#v", cards)
+	}
+}
@@ -0,0 +1,252 @@
+package grafana
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// DatasourceUID is the UID of the provisioned datasource that the dashboard's
+// panels query.
+const DatasourceUID = "clipper"
+
+// queries are the dashboard's queries in one dialect. Each returns a time
+// column named time where the panel is a time series.
+type queries struct {
+	balances, monthlySpend, agencySpend, balanceHistory, recent string
+}
+
+func queriesFor(d Dialect) queries {
+	if d == SQLite {
+		// The SQLite datasource doesn't have $__timeFilter; compare Unix
+		// times instead.
+		filter := "CAST(strftime('%s', time) AS INTEGER) BETWEEN $__unixEpochFrom() AND $__unixEpochTo()"
+		return queries{
+			balances:       "SELECT CAST(card AS TEXT) AS card, balance_cents / 100.0 AS balance FROM card_balances ORDER BY card",
+			monthlySpend:   "SELECT strftime('%Y-%m-01T00:00:00Z', time) AS time, sum(fare_cents) / 100.0 AS spend FROM transactions WHERE is_fare AND " + filter + " GROUP BY 1 ORDER BY 1",
+			agencySpend:    "SELECT CASE agency WHEN '' THEN 'Other' ELSE agency END AS agency, sum(fare_cents) / 100.0 AS spend FROM transactions WHERE is_fare AND " + filter + " GROUP BY 1 ORDER BY 2 DESC",
+			balanceHistory: "SELECT time, CAST(card AS TEXT) AS card, balance_cents / 100.0 AS balance FROM transactions WHERE " + filter + " ORDER BY time",
+			recent:         "SELECT time, card, type, location, (credit_cents - debit_cents) / 100.0 AS amount, balance_cents / 100.0 AS balance FROM transactions WHERE " + filter + " ORDER BY time DESC LIMIT 50",
+		}
+	}
+	return queries{
+		balances:       "SELECT card::text AS card, balance_cents / 100.0 AS balance FROM card_balances ORDER BY card",
+		monthlySpend:   "SELECT date_trunc('month', time) AS time, sum(fare_cents) / 100.0 AS spend FROM transactions WHERE is_fare AND $__timeFilter(time) GROUP BY 1 ORDER BY 1",
+		agencySpend:    "SELECT CASE agency WHEN '' THEN 'Other' ELSE agency END AS agency, sum(fare_cents) / 100.0 AS spend FROM transactions WHERE is_fare AND $__timeFilter(time) GROUP BY 1 ORDER BY 2 DESC",
+		balanceHistory: "SELECT time, card::text AS card, balance_cents / 100.0 AS balance FROM transactions WHERE $__timeFilter(time) ORDER BY time",
+		recent:         "SELECT time, card, type, location, (credit_cents - debit_cents) / 100.0 AS amount, balance_cents / 100.0 AS balance FROM transactions WHERE $__timeFilter(time) ORDER BY time DESC LIMIT 50",
+	}
+}
+
+func datasourceType(d Dialect) string {
+	if d == SQLite {
+		return "frser-sqlite-datasource"
+	}
+	return "grafana-postgresql-datasource"
+}
+
+type object = map[string]interface{}
+
+func panel(d Dialect, id int, typ, title, format, query string, x, y, w, h int) object {
+	target := object{
+		"refId":        "A",
+		"datasource":   object{"type": datasourceType(d), "uid": DatasourceUID},
+		"rawSql":       query,
+		"queryText":    query,
+		"rawQueryText": query,
+		"editorMode":   "code",
+		"rawQuery":     true,
+		"format":       format,
+		"queryType":    format,
+	}
+	if d == SQLite {
+		target["timeColumns"] = []string{"time"}
+	}
+	return object{
+		"id":         id,
+		"type":       typ,
+		"title":      title,
+		"datasource": object{"type": datasourceType(d), "uid": DatasourceUID},
+		"gridPos":    object{"x": x, "y": y, "w": w, "h": h},
+		"targets":    []object{target},
+		"fieldConfig": object{
+			"defaults":  object{"unit": "currencyUSD"},
+			"overrides": []object{},
+		},
+		"options": object{},
+	}
+}
+
+// Dashboard returns a Grafana dashboard of the transactions table in d:
+// card balances, spend per month and per agency, balances over time and
+// recent transactions.
+func Dashboard(d Dialect) ([]byte, error) {
+	q := queriesFor(d)
+	balances := panel(d, 1, "stat", "Card balances", "table", q.balances, 0, 0, 8, 6)
+	balances["options"] = object{"reduceOptions": object{"values": true, "calcs": []string{"lastNotNull"}}, "textMode": "value_and_name"}
+	balances["fieldConfig"] = object{
+		"defaults": object{
+			"unit": "currencyUSD",
+			"thresholds": object{"mode": "absolute", "steps": []object{
+				{"color": "red", "value": nil},
+				{"color": "green", "value": 10},
+			}},
+		},
+		"overrides": []object{},
+	}
+	monthly := panel(d, 2, "barchart", "Spend per month", "time_series", q.monthlySpend, 8, 0, 16, 8)
+	monthly["options"] = object{"xTickLabelRotation": -45, "legend": object{"showLegend": false}}
+	agencies := panel(d, 3, "bargauge", "Spend by agency", "table", q.agencySpend, 0, 6, 8, 10)
+	agencies["options"] = object{"orientation": "horizontal", "reduceOptions": object{"values": true, "calcs": []string{}}}
+	history := panel(d, 4, "timeseries", "Balance", "time_series", q.balanceHistory, 8, 8, 16, 8)
+	history["transformations"] = []object{{"id": "partitionByValues", "options": object{"fields": []string{"card"}}}}
+	history["fieldConfig"] = object{
+		"defaults":  object{"unit": "currencyUSD", "custom": object{"lineInterpolation": "stepAfter"}},
+		"overrides": []object{},
+	}
+	recent := panel(d, 5, "table", "Recent transactions", "table", q.recent, 0, 16, 24, 10)
+	recent["fieldConfig"] = object{"defaults": object{}, "overrides": []object{
+		{"matcher": object{"id": "byRegexp", "options": "amount|balance"}, "properties": []object{{"id": "unit", "value": "currencyUSD"}}},
+	}}
+
+	dashboard := object{
+		"uid":           "clipper",
+		"title":         "Clipper",
+		"tags":          []string{"clipper", "transit"},
+		"timezone":      "browser",
+		"schemaVersion": 39,
+		"version":       1,
+		"editable":      true,
+		"time":          object{"from": "now-1y", "to": "now"},
+		"panels":        []object{balances, monthly, agencies, history, recent},
+	}
+	return json.MarshalIndent(dashboard, "", "  ")
+}
+
+func datasource(d Dialect) string {
+	if d == SQLite {
+		return fmt.Sprintf(`apiVersion: 1
+datasources:
+  - name: Clipper
+    uid: %s
+    type: %s
+    jsonData:
+      path: /var/lib/clipper/clipper.db
+`, DatasourceUID, datasourceType(d))
+	}
+	return fmt.Sprintf(`apiVersion: 1
+datasources:
+  - name: Clipper
+    uid: %s
+    type: %s
+    url: postgres:5432
+    user: clipper
+    jsonData:
+      database: clipper
+      sslmode: disable
+    secureJsonData:
+      password: clipper
+`, DatasourceUID, datasourceType(d))
+}
+
+const dashboards = `apiVersion: 1
+providers:
+  - name: Clipper
+    type: file
+    options:
+      path: /var/lib/grafana/dashboards
+`
+
+func compose(d Dialect) string {
+	if d == SQLite {
+		return `services:
+  load:
+    image: keinos/sqlite3
+    user: root
+    working_dir: /data
+    volumes:
+      - .:/data
+    command: sh -c "cat schema.sql data.sql | sqlite3 clipper.db"
+  grafana:
+    image: grafana/grafana-oss
+    depends_on:
+      load:
+        condition: service_completed_successfully
+    environment:
+      GF_INSTALL_PLUGINS: frser-sqlite-datasource
+    ports:
+      - "3000:3000"
+    volumes:
+      - ./provisioning:/etc/grafana/provisioning
+      - ./dashboards:/var/lib/grafana/dashboards
+      - .:/var/lib/clipper:ro
+`
+	}
+	return `services:
+  postgres:
+    image: postgres:16
+    environment:
+      POSTGRES_USER: clipper
+      POSTGRES_PASSWORD: clipper
+      POSTGRES_DB: clipper
+    volumes:
+      - ./schema.sql:/docker-entrypoint-initdb.d/1-schema.sql
+      - ./data.sql:/docker-entrypoint-initdb.d/2-data.sql
+  grafana:
+    image: grafana/grafana-oss
+    depends_on:
+      - postgres
+    ports:
+      - "3000:3000"
+    volumes:
+      - ./provisioning:/etc/grafana/provisioning
+      - ./dashboards:/var/lib/grafana/dashboards
+`
+}
+
+func readme(d Dialect) string {
+	reload := "Postgres loads schema.sql and data.sql only when its volume is new; to\nadd newer statements, run data.sql again with psql, which skips rows it\nalready has:\n\n\tdocker compose exec -T postgres psql -U clipper clipper < data.sql\n"
+	if d == SQLite {
+		reload = "The load service adds data.sql to clipper.db each time it starts, skipping\nrows it already has.\n"
+	}
+	return fmt.Sprintf(`Clipper dashboard for Grafana (%s)
+
+Start it with:
+
+	docker compose up
+
+and open http://localhost:3000 (the first login is admin/admin). The
+"Clipper" dashboard is provisioned from dashboards/clipper.json.
+
+%s
+schema.sql creates the transactions table and the card_balances view; the
+columns are documented at
+https://pkg.go.dev/github.com/kevinburke/clipper/grafana.
+`, d, reload)
+}
+
+// Bundle returns the files for a Grafana setup of txns in d, keyed by their
+// path relative to the directory they should be written to: the schema, the
+// data, the dashboard, Grafana provisioning for both, and a Docker Compose
+// file that runs it all.
+func Bundle(d Dialect, txns []transit.Transaction) (map[string][]byte, error) {
+	var data bytes.Buffer
+	if err := WriteInserts(&data, d, txns); err != nil {
+		return nil, err
+	}
+	dash, err := Dashboard(d)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		"schema.sql":                           []byte(Schema(d)),
+		"data.sql":                             data.Bytes(),
+		"dashboards/clipper.json":              dash,
+		"provisioning/datasources/clipper.yml": []byte(datasource(d)),
+		"provisioning/dashboards/clipper.yml":  []byte(dashboards),
+		"docker-compose.yml":                   []byte(compose(d)),
+		"README":                               []byte(readme(d)),
+	}, nil
+}
@@ -0,0 +1,136 @@
+// Package grafana loads the statement archive into Postgres or SQLite and
+// generates a Grafana dashboard of it, with the provisioning files to stand
+// the whole thing up with Docker Compose.
+//
+// The database has one table, transactions, with a row per statement row:
+//
+//	time           when the card was tagged or reloaded (UTC in SQLite, as
+//	               RFC 3339 text)
+//	card           the card's serial number
+//	type           Clipper's description, like "Single-tag fare payment"
+//	location       where the tag happened, if known
+//	route          the route, if known
+//	product        the fare product, if known
+//	agency         the transit agency, like "BART", or "" if unknown
+//	is_fare        whether the row pays for a ride, rather than adding value
+//	trip_start     whether the row starts a ride
+//	fare_cents     what the row cost in fares; 0 unless is_fare
+//	debit_cents    the amount taken off the card
+//	credit_cents   the amount added to the card
+//	balance_cents  the card's balance afterwards
+//
+// and a view, card_balances, of each card's latest balance. Rows are keyed
+// the way the archive deduplicates transactions, so loading the same
+// statements twice doesn't double count.
+package grafana
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// A Dialect is a flavor of SQL.
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// ParseDialect returns the Dialect named s.
+func ParseDialect(s string) (Dialect, error) {
+	switch d := Dialect(strings.ToLower(s)); d {
+	case Postgres, SQLite:
+		return d, nil
+	}
+	return "", fmt.Errorf("grafana: unknown database %q; use postgres or sqlite", s)
+}
+
+// Schema returns the statements that create the transactions table and the
+// card_balances view. They can be run more than once.
+func Schema(d Dialect) string {
+	timeType, cardType, view := "TIMESTAMPTZ", "BIGINT", "CREATE OR REPLACE VIEW"
+	if d == SQLite {
+		timeType, cardType, view = "TEXT", "INTEGER", "CREATE VIEW IF NOT EXISTS"
+	}
+	return fmt.Sprintf(`-- Clipper transactions, one row per statement row. See
+-- https://pkg.go.dev/github.com/kevinburke/clipper/grafana for the columns.
+CREATE TABLE IF NOT EXISTS transactions (
+	time %s NOT NULL,
+	card %s NOT NULL,
+	type TEXT NOT NULL,
+	location TEXT NOT NULL DEFAULT '',
+	route TEXT NOT NULL DEFAULT '',
+	product TEXT NOT NULL DEFAULT '',
+	agency TEXT NOT NULL DEFAULT '',
+	is_fare BOOLEAN NOT NULL,
+	trip_start BOOLEAN NOT NULL,
+	fare_cents INTEGER NOT NULL,
+	debit_cents INTEGER NOT NULL,
+	credit_cents INTEGER NOT NULL,
+	balance_cents INTEGER NOT NULL,
+	PRIMARY KEY (card, time, type, location, debit_cents, credit_cents, balance_cents)
+);
+
+CREATE INDEX IF NOT EXISTS transactions_time ON transactions (time);
+
+-- The latest balance of every card.
+%s card_balances AS
+SELECT t.card, t.time, t.balance_cents
+FROM transactions t
+WHERE t.time = (SELECT max(u.time) FROM transactions u WHERE u.card = t.card);
+`, timeType, cardType, view)
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func boolean(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func timestamp(d Dialect, t time.Time) string {
+	if d == SQLite {
+		// Stored as UTC text, so that comparing strings compares times.
+		return quote(t.UTC().Format(time.RFC3339))
+	}
+	return quote(t.Format(time.RFC3339))
+}
+
+// WriteInserts writes a statement inserting each of txns to w. Rows that are
+// already in the table are skipped.
+func WriteInserts(w io.Writer, d Dialect, txns []transit.Transaction) error {
+	bw := bufio.NewWriter(w)
+	for _, t := range txns {
+		agency := ""
+		if a := t.Agency(); a != transit.AgencyUnknown {
+			agency = string(a)
+		}
+		fmt.Fprintf(bw, "INSERT INTO transactions VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %s, %d, %d, %d, %d) ON CONFLICT DO NOTHING;\n",
+			timestamp(d, t.Timestamp),
+			strconv.FormatInt(t.CardSerial, 10),
+			quote(t.Type),
+			quote(t.Location),
+			quote(t.Route),
+			quote(t.Product),
+			quote(agency),
+			boolean(t.IsFare()),
+			boolean(t.IsTripStart()),
+			t.FareCents(),
+			t.DebitCents,
+			t.CreditCents,
+			t.BalanceCents,
+		)
+	}
+	return bw.Flush()
+}
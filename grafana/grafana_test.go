@@ -0,0 +1,80 @@
+package grafana
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestWriteInserts(t *testing.T) {
+	pacific := time.FixedZone("PST", -8*60*60)
+	txns := []transit.Transaction{{
+		Timestamp:    time.Date(2018, 1, 2, 8, 30, 0, 0, pacific),
+		Type:         "Dual-tag exit transaction, fare payment",
+		Location:     "Powell St. (BART)",
+		DebitCents:   200,
+		BalanceCents: 8975,
+		CardSerial:   1202728442,
+	}, {
+		Timestamp:   time.Date(2018, 1, 3, 9, 0, 0, 0, pacific),
+		Type:        "Threshold auto-load at a TransLink Device",
+		Location:    "O'Farrell",
+		CreditCents: 2000,
+		CardSerial:  1202728442,
+	}}
+	var buf bytes.Buffer
+	if err := WriteInserts(&buf, SQLite, txns); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 statements, got %q", buf.String())
+	}
+	want := "INSERT INTO transactions VALUES ('2018-01-02T16:30:00Z', 1202728442, 'Dual-tag exit transaction, fare payment', 'Powell St. (BART)', '', '', 'BART', TRUE, FALSE, 200, 200, 0, 8975) ON CONFLICT DO NOTHING;"
+	if lines[0] != want {
+		t.Errorf("got  %s\nwant %s", lines[0], want)
+	}
+	if !strings.Contains(lines[1], "'O''Farrell'") || !strings.Contains(lines[1], "FALSE, FALSE, 0, 0, 2000") {
+		t.Errorf("reload: got %s", lines[1])
+	}
+	buf.Reset()
+	WriteInserts(&buf, Postgres, txns[:1])
+	if !strings.HasPrefix(buf.String(), "INSERT INTO transactions VALUES ('2018-01-02T08:30:00-08:00'") {
+		t.Errorf("Postgres: got %s", buf.String())
+	}
+}
+
+func TestBundle(t *testing.T) {
+	for _, d := range []Dialect{Postgres, SQLite} {
+		files, err := Bundle(d, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var dash struct {
+			UID    string
+			Panels []struct {
+				Title   string
+				Targets []struct{ RawSQL string }
+			}
+		}
+		if err := json.Unmarshal(files["dashboards/clipper.json"], &dash); err != nil {
+			t.Fatal(err)
+		}
+		if dash.UID != "clipper" || len(dash.Panels) != 5 || dash.Panels[1].Targets[0].RawSQL == "" {
+			t.Errorf("%s dashboard: got %+v", d, dash)
+		}
+		if !strings.Contains(string(files["provisioning/datasources/clipper.yml"]), datasourceType(d)) {
+			t.Errorf("%s datasource: got %s", d, files["provisioning/datasources/clipper.yml"])
+		}
+		if !strings.Contains(string(files["schema.sql"]), "CREATE TABLE IF NOT EXISTS transactions") {
+			t.Errorf("%s schema: got %s", d, files["schema.sql"])
+		}
+	}
+	if _, err := ParseDialect("mysql"); err == nil {
+		t.Error("expected an error for mysql")
+	}
+}
@@ -0,0 +1,259 @@
+// Package gtfs loads station and route metadata from GTFS feeds, such as the
+// per-operator feeds published by 511.org, and uses it to fill in details
+// that Clipper statements leave out: stop IDs, coordinates and full route
+// names.
+package gtfs
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kevinburke/clipper"
+)
+
+// A Stop is a row from stops.txt.
+type Stop struct {
+	ID       string
+	Name     string
+	Lat, Lon float64
+	// ParentID is the stop_id of the station containing this stop, if any.
+	ParentID string
+	// LocationType is 0 for a stop or platform and 1 for a station.
+	LocationType int
+}
+
+// A Route is a row from routes.txt.
+type Route struct {
+	ID        string
+	ShortName string
+	LongName  string
+}
+
+// A Feed is the metadata from one operator's GTFS feed.
+type Feed struct {
+	Agency clipper.Agency
+	Stops  []Stop
+	Routes []Route
+
+	stopsByName   map[string]Stop
+	routesByShort map[string]Route
+}
+
+// Load reads the GTFS feed for agency at path, which is either a zip file or
+// a directory containing the feed's text files. Only stops.txt is required.
+func Load(path string, agency clipper.Agency) (*Feed, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	var open func(name string) (io.ReadCloser, error)
+	if fi.IsDir() {
+		open = func(name string) (io.ReadCloser, error) {
+			return os.Open(filepath.Join(path, name))
+		}
+	} else {
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, fmt.Errorf("gtfs: %v", err)
+		}
+		defer zr.Close()
+		open = func(name string) (io.ReadCloser, error) {
+			return zr.Open(name)
+		}
+	}
+	f := &Feed{Agency: agency}
+	stops, err := readTable(open, "stops.txt")
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range stops {
+		lat, _ := strconv.ParseFloat(row["stop_lat"], 64)
+		lon, _ := strconv.ParseFloat(row["stop_lon"], 64)
+		lt, _ := strconv.Atoi(row["location_type"])
+		f.Stops = append(f.Stops, Stop{
+			ID:           row["stop_id"],
+			Name:         row["stop_name"],
+			Lat:          lat,
+			Lon:          lon,
+			ParentID:     row["parent_station"],
+			LocationType: lt,
+		})
+	}
+	routes, err := readTable(open, "routes.txt")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, row := range routes {
+		f.Routes = append(f.Routes, Route{
+			ID:        row["route_id"],
+			ShortName: row["route_short_name"],
+			LongName:  row["route_long_name"],
+		})
+	}
+	f.index()
+	return f, nil
+}
+
+// readTable reads the GTFS file name as a list of rows keyed by column name.
+func readTable(open func(string) (io.ReadCloser, error), name string) ([]map[string]string, error) {
+	rc, err := open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	r := csv.NewReader(rc)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("gtfs: reading %s: %v", name, err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+	var rows []map[string]string
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gtfs: reading %s: %v", name, err)
+		}
+		row := make(map[string]string, len(header))
+		for i, col := range header {
+			if i < len(rec) {
+				row[col] = strings.TrimSpace(rec[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// normalize reduces a stop name to a form that matches between GTFS feeds
+// and Clipper statements, which abbreviate differently: "Powell Street BART"
+// and "Powell St" both become "powell st".
+func normalize(name string) string {
+	name, _ = splitOperator(name)
+	name = strings.ToLower(name)
+	name = strings.NewReplacer("&", " and ", "/", " ", "-", " ", ".", "", "'", "").Replace(name)
+	var words []string
+	for _, w := range strings.Fields(name) {
+		switch w {
+		case "station", "bart", "caltrain", "muni", "ferry", "terminal":
+			continue
+		case "street":
+			w = "st"
+		case "avenue":
+			w = "ave"
+		case "boulevard":
+			w = "blvd"
+		}
+		words = append(words, w)
+	}
+	return strings.Join(words, " ")
+}
+
+// splitOperator removes an operator in parentheses from the end of a name.
+func splitOperator(name string) (string, string) {
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndexByte(name, '('); i > 0 && strings.HasSuffix(name, ")") {
+		return strings.TrimSpace(name[:i]), name[i+1 : len(name)-1]
+	}
+	return name, ""
+}
+
+func (f *Feed) index() {
+	f.stopsByName = make(map[string]Stop)
+	for _, s := range f.Stops {
+		key := normalize(s.Name)
+		// Prefer stations to the platforms inside them.
+		if old, ok := f.stopsByName[key]; ok && old.LocationType == 1 {
+			continue
+		}
+		f.stopsByName[key] = s
+	}
+	f.routesByShort = make(map[string]Route)
+	for _, r := range f.Routes {
+		if r.ShortName != "" {
+			f.routesByShort[strings.ToLower(r.ShortName)] = r
+		}
+		f.routesByShort[strings.ToLower(r.ID)] = r
+	}
+}
+
+// Stop returns the stop whose name matches location, a value from the
+// Location column of a statement.
+func (f *Feed) Stop(location string) (Stop, bool) {
+	s, ok := f.stopsByName[normalize(location)]
+	return s, ok
+}
+
+// Route returns the route whose short name or ID is route, a value from the
+// Route column of a statement.
+func (f *Feed) Route(route string) (Route, bool) {
+	r, ok := f.routesByShort[strings.ToLower(strings.TrimSpace(route))]
+	return r, ok
+}
+
+// Stations returns the feed's stations in the form clipper.RegisterStations
+// takes. Stations that are already known, like BART and Caltrain stations,
+// keep the name Clipper prints and their fare zone, and gain the feed's stop
+// ID and coordinates.
+func (f *Feed) Stations() []clipper.Station {
+	known := make(map[string]clipper.Station)
+	for _, st := range clipper.Stations() {
+		if st.Agency == f.Agency {
+			known[normalize(st.Name)] = st
+		}
+	}
+	var out []clipper.Station
+	for key, s := range f.stopsByName {
+		st := clipper.Station{Name: s.Name, Agency: f.Agency}
+		if k, ok := known[key]; ok {
+			st = k
+		}
+		st.Lat, st.Lon, st.StopID = s.Lat, s.Lon, s.ID
+		out = append(out, st)
+	}
+	return out
+}
+
+// Register adds the feed's stations to the ones clipper.LookupStation knows
+// about.
+func (f *Feed) Register() {
+	clipper.RegisterStations(f.Stations()...)
+}
+
+// Details are what a feed knows about a transaction.
+type Details struct {
+	StopID        string
+	StopName      string
+	Lat, Lon      float64
+	RouteLongName string
+}
+
+// Enrich returns what the feed knows about t's location and route. ok is
+// false if the feed doesn't recognize either, or t is on another agency.
+func (f *Feed) Enrich(t clipper.Transaction) (d Details, ok bool) {
+	if a := t.Agency(); a != clipper.AgencyUnknown && a != f.Agency {
+		return Details{}, false
+	}
+	if s, found := f.Stop(t.Location); found {
+		d.StopID, d.StopName, d.Lat, d.Lon = s.ID, s.Name, s.Lat, s.Lon
+		ok = true
+	}
+	if r, found := f.Route(t.Route); found {
+		d.RouteLongName = r.LongName
+		ok = true
+	}
+	return d, ok
+}
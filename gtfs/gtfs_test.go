@@ -0,0 +1,107 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kevinburke/clipper"
+)
+
+const stopsTxt = "\ufeffstop_id,stop_name,stop_lat,stop_lon,location_type,parent_station\n" +
+	"POWL,Powell Street,37.784471,-122.407974,1,\n" +
+	"POWL-1,Powell Street,37.7845,-122.4080,0,POWL\n" +
+	"MLBR,Millbrae,37.600271,-122.386702,1,\n" +
+	"NEW,Brand New Station,37.5,-122.0,1,\n"
+
+const routesTxt = "route_id,route_short_name,route_long_name\n" +
+	"1,YL-N,Antioch to SFO/Millbrae\n"
+
+func writeFeed(t *testing.T) string {
+	dir := t.TempDir()
+	for name, data := range map[string]string{"stops.txt": stopsTxt, "routes.txt": routesTxt} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadDir(t *testing.T) {
+	f, err := Load(writeFeed(t), clipper.AgencyBART)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Stops) != 4 || len(f.Routes) != 1 {
+		t.Fatalf("got %d stops, %d routes", len(f.Stops), len(f.Routes))
+	}
+	s, ok := f.Stop("Powell St (BART)")
+	if !ok || s.ID != "POWL" {
+		t.Errorf("Stop: got %+v, %t", s, ok)
+	}
+	d, ok := f.Enrich(clipper.Transaction{
+		Type:     "Dual-tag exit transaction, fare payment",
+		Location: "Powell St (BART)",
+		Route:    "YL-N",
+	})
+	if !ok || d.StopID != "POWL" || d.RouteLongName != "Antioch to SFO/Millbrae" {
+		t.Errorf("Enrich: got %+v, %t", d, ok)
+	}
+	if _, ok := f.Enrich(clipper.Transaction{Type: "Single-tag fare payment", Location: "Powell (Muni)"}); ok {
+		t.Error("expected no details for a Muni transaction")
+	}
+
+	f.Register()
+	st, ok := clipper.LookupStation("Powell St (BART)")
+	if !ok || st.StopID != "POWL" || st.Lat != 37.784471 {
+		t.Errorf("registered Powell St: got %+v", st)
+	}
+	if st, ok := clipper.LookupStation("Brand New Station"); !ok || st.Agency != clipper.AgencyBART {
+		t.Errorf("registered new station: got %+v, %t", st, ok)
+	}
+}
+
+func TestLoadZip(t *testing.T) {
+	dir := writeFeed(t)
+	path := filepath.Join(t.TempDir(), "feed.zip")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(out)
+	w, err := zw.Create("stops.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "stops.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+	f, err := Load(path, clipper.AgencyBART)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Stops) != 4 || len(f.Routes) != 0 {
+		t.Errorf("got %d stops, %d routes", len(f.Stops), len(f.Routes))
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Powell Street BART", "powell st"},
+		{"Powell St (BART)", "powell st"},
+		{"San Francisco Caltrain Station", "san francisco"},
+		{"Dublin/Pleasanton", "dublin pleasanton"},
+	}
+	for _, tt := range tests {
+		if got := normalize(tt.in); got != tt.want {
+			t.Errorf("normalize(%q): got %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+//go:build chromedp
+
+package headless
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// DefaultSettle is how long Render waits for scripts to finish after the page
+// loads, if Renderer.Settle is zero.
+const DefaultSettle = 2 * time.Second
+
+// Renderer renders pages in a new headless Chrome instance. The zero value is
+// ready to use.
+type Renderer struct {
+	// WaitSelector is a CSS selector to wait for before reading the page, for
+	// example "span.d-inline-block". If empty, Render waits for the body to
+	// load and then for Settle.
+	WaitSelector string
+	// Settle is how long to wait for scripts when WaitSelector is empty.
+	Settle time.Duration
+	// Options are passed to chromedp.NewExecAllocator, after the defaults.
+	Options []chromedp.ExecAllocatorOption
+}
+
+// Render loads url with the given cookies and returns the page's HTML after its
+// scripts run.
+func (r *Renderer) Render(ctx context.Context, url string, cookies []*http.Cookie) ([]byte, error) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], r.Options...)
+	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
+	defer cancel()
+	taskCtx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+
+	var html string
+	tasks := chromedp.Tasks{
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			for _, c := range cookies {
+				if err := network.SetCookie(c.Name, c.Value).WithURL(url).Do(ctx); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+		chromedp.Navigate(url),
+	}
+	if r.WaitSelector != "" {
+		tasks = append(tasks, chromedp.WaitReady(r.WaitSelector, chromedp.ByQuery))
+	} else {
+		settle := r.Settle
+		if settle == 0 {
+			settle = DefaultSettle
+		}
+		tasks = append(tasks, chromedp.WaitReady("body", chromedp.ByQuery), chromedp.Sleep(settle))
+	}
+	tasks = append(tasks, chromedp.OuterHTML("html", &html, chromedp.ByQuery))
+	if err := chromedp.Run(taskCtx, tasks); err != nil {
+		return nil, err
+	}
+	return []byte(html), nil
+}
@@ -0,0 +1,15 @@
+// Package headless renders Clipper pages in headless Chrome, for accounts
+// whose pages fill in card data with JavaScript.
+//
+// The renderer depends on github.com/chromedp/chromedp, which most users don't
+// need, so it's only built with the "chromedp" build tag:
+//
+//	go get github.com/chromedp/chromedp
+//	go build -tags chromedp
+//
+// Pass it to the client with clipper.WithRenderer:
+//
+//	client, err := clipper.NewClient(email, password, clipper.WithRenderer(&headless.Renderer{}))
+//
+// The client only uses it when the plain HTML dashboard has no cards.
+package headless
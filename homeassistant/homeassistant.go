@@ -0,0 +1,182 @@
+// Package homeassistant publishes Clipper card balances to Home Assistant
+// over MQTT. Each card becomes a device with two sensors, its balance and its
+// latest transaction, announced with MQTT discovery so they appear without
+// any configuration in Home Assistant. An automation can then, for example,
+// announce when sensor.clipper_1202728442_balance drops below 5.
+package homeassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/kevinburke/clipper/transit"
+)
+
+// DefaultDiscoveryPrefix is the topic prefix Home Assistant listens to for
+// discovery messages, unless it's been configured otherwise.
+const DefaultDiscoveryPrefix = "homeassistant"
+
+// DefaultTopic is the prefix of the topics that card states are published to.
+const DefaultTopic = "clipper"
+
+// A CardState is what's known about a card: its latest transaction, which
+// carries its balance.
+type CardState struct {
+	Serial int64
+	Latest transit.Transaction
+}
+
+// States returns the state of every card in txns, ordered by serial number.
+func States(txns []transit.Transaction) []CardState {
+	latest := make(map[int64]transit.Transaction)
+	for _, t := range txns {
+		if l, ok := latest[t.CardSerial]; !ok || !t.Timestamp.Before(l.Timestamp) {
+			latest[t.CardSerial] = t
+		}
+	}
+	out := make([]CardState, 0, len(latest))
+	for serial, t := range latest {
+		out = append(out, CardState{Serial: serial, Latest: t})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Serial < out[j].Serial })
+	return out
+}
+
+// A Message is an MQTT message to publish.
+type Message struct {
+	Topic   string
+	Payload []byte
+	// Retain is set on every message, so Home Assistant sees the sensors
+	// and their states when it restarts.
+	Retain bool
+}
+
+// Device identifies a card in a discovery message.
+type Device struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+}
+
+// Sensor is the discovery config of an MQTT sensor.
+type Sensor struct {
+	Name                string `json:"name"`
+	UniqueID            string `json:"unique_id"`
+	ObjectID            string `json:"object_id"`
+	StateTopic          string `json:"state_topic"`
+	ValueTemplate       string `json:"value_template"`
+	JSONAttributesTopic string `json:"json_attributes_topic,omitempty"`
+	DeviceClass         string `json:"device_class,omitempty"`
+	StateClass          string `json:"state_class,omitempty"`
+	UnitOfMeasurement   string `json:"unit_of_measurement,omitempty"`
+	Icon                string `json:"icon,omitempty"`
+	Device              Device `json:"device"`
+}
+
+// State is the payload published to a card's state topic.
+type State struct {
+	// Balance is in dollars.
+	Balance         float64   `json:"balance"`
+	LastTransaction time.Time `json:"last_transaction"`
+	Type            string    `json:"type"`
+	Location        string    `json:"location,omitempty"`
+	Route           string    `json:"route,omitempty"`
+	Agency          string    `json:"agency,omitempty"`
+	// Amount is in dollars; it's negative for fares.
+	Amount float64 `json:"amount"`
+}
+
+// Messages returns the discovery and state messages for cards.
+// discoveryPrefix and topic default to DefaultDiscoveryPrefix and
+// DefaultTopic.
+func Messages(discoveryPrefix, topic string, cards []CardState) ([]Message, error) {
+	if discoveryPrefix == "" {
+		discoveryPrefix = DefaultDiscoveryPrefix
+	}
+	if topic == "" {
+		topic = DefaultTopic
+	}
+	var out []Message
+	add := func(t string, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		out = append(out, Message{Topic: t, Payload: data, Retain: true})
+		return nil
+	}
+	for _, c := range cards {
+		serial := strconv.FormatInt(c.Serial, 10)
+		id := "clipper_" + serial
+		stateTopic := topic + "/" + serial + "/state"
+		device := Device{
+			Identifiers:  []string{id},
+			Name:         "Clipper card " + serial,
+			Manufacturer: "Clipper",
+			Model:        "Clipper card",
+		}
+		balance := Sensor{
+			Name:              "Balance",
+			UniqueID:          id + "_balance",
+			ObjectID:          id + "_balance",
+			StateTopic:        stateTopic,
+			ValueTemplate:     "{{ value_json.balance }}",
+			DeviceClass:       "monetary",
+			StateClass:        "total",
+			UnitOfMeasurement: "USD",
+			Device:            device,
+		}
+		last := Sensor{
+			Name:                "Last transaction",
+			UniqueID:            id + "_last_transaction",
+			ObjectID:            id + "_last_transaction",
+			StateTopic:          stateTopic,
+			ValueTemplate:       "{{ value_json.last_transaction }}",
+			JSONAttributesTopic: stateTopic,
+			DeviceClass:         "timestamp",
+			Icon:                "mdi:train-car",
+			Device:              device,
+		}
+		for _, s := range []Sensor{balance, last} {
+			if err := add(fmt.Sprintf("%s/sensor/%s/config", discoveryPrefix, s.UniqueID), s); err != nil {
+				return nil, err
+			}
+		}
+		t := c.Latest
+		state := State{
+			Balance:         t.BalanceCents.Dollars(),
+			LastTransaction: t.Timestamp,
+			Type:            t.Type,
+			Location:        t.Location,
+			Route:           t.Route,
+			Amount:          (t.CreditCents - t.DebitCents).Dollars(),
+		}
+		if a := t.Agency(); a != transit.AgencyUnknown {
+			state.Agency = string(a)
+		}
+		if err := add(stateTopic, state); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Publish sends msgs with c, which must be connected, and waits for the
+// broker to acknowledge each of them.
+func Publish(c mqtt.Client, msgs []Message) error {
+	for _, m := range msgs {
+		tok := c.Publish(m.Topic, 1, m.Retain, m.Payload)
+		if !tok.WaitTimeout(30 * time.Second) {
+			return fmt.Errorf("homeassistant: timed out publishing to %s", m.Topic)
+		}
+		if err := tok.Error(); err != nil {
+			return fmt.Errorf("homeassistant: publishing to %s: %v", m.Topic, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package homeassistant
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+func TestMessages(t *testing.T) {
+	day := func(d int, card int64, balance transit.Money) transit.Transaction {
+		return transit.Transaction{
+			Timestamp:    time.Date(2018, 1, d, 8, 0, 0, 0, time.UTC),
+			Type:         "Single-tag fare payment",
+			Location:     "Muni bus",
+			DebitCents:   250,
+			BalanceCents: balance,
+			CardSerial:   card,
+		}
+	}
+	states := States([]transit.Transaction{day(2, 7, 1000), day(1, 7, 1250), day(1, 3, 500)})
+	if len(states) != 2 || states[0].Serial != 3 || states[1].Latest.BalanceCents != 1000 {
+		t.Fatalf("got %+v", states)
+	}
+	msgs, err := Messages("", "", states[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(msgs))
+	}
+	if msgs[0].Topic != "homeassistant/sensor/clipper_7_balance/config" || !msgs[0].Retain {
+		t.Errorf("discovery message: got %+v", msgs[0])
+	}
+	var s Sensor
+	if err := json.Unmarshal(msgs[0].Payload, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.StateTopic != "clipper/7/state" || s.UnitOfMeasurement != "USD" || s.Device.Identifiers[0] != "clipper_7" {
+		t.Errorf("balance sensor: got %+v", s)
+	}
+	var st State
+	if err := json.Unmarshal(msgs[2].Payload, &st); err != nil {
+		t.Fatal(err)
+	}
+	if msgs[2].Topic != "clipper/7/state" || st.Balance != 10 || st.Amount != -2.5 || st.Agency != "SFMTA" {
+		t.Errorf("state: got %s %+v", msgs[2].Topic, st)
+	}
+}
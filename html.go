@@ -4,10 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/net/html"
+	"golang.org/x/text/unicode/norm"
 )
 
 func findViewState(r io.Reader) (string, error) {
@@ -41,32 +47,66 @@ func findViewState(r io.Reader) (string, error) {
 	}
 }
 
-func findCSRFToken(r io.Reader) (string, error) {
+// csrfSource records the kind of markup findCSRFToken found a token in, for
+// debugging when the site changes.
+type csrfSource string
+
+const (
+	csrfFromInput  csrfSource = "input"
+	csrfFromMeta   csrfSource = "meta"
+	csrfFromScript csrfSource = "script"
+)
+
+func isCSRFName(name string) bool {
+	switch name {
+	case "_csrf", "csrf-token", "csrf_token", "_csrf_token":
+		return true
+	}
+	return false
+}
+
+// Matches tokens assigned in inline scripts or JSON, like
+// {"_csrf": "abc"} or var csrfToken = 'abc'.
+var scriptCSRFRx = regexp.MustCompile(`["']?(?:_csrf|csrfToken|csrf_token|_csrf_token|csrf)["']?\s*[:=]\s*["']([^"']+)["']`)
+
+// findCSRFToken returns the CSRF token for the forms on a page, and where it
+// was found. Hidden form inputs and <meta> tags take precedence over tokens
+// in inline scripts.
+func findCSRFToken(r io.Reader) (string, csrfSource, error) {
 	z := html.NewTokenizer(r)
+	scriptToken := ""
+	inScript := false
 	for {
 		tt := z.Next()
 		switch tt {
 		case html.ErrorToken:
-			return "", errors.New("CSRF token not found")
-		case html.SelfClosingTagToken:
-			tok := z.Token()
-			if tok.Data != "input" {
-				continue
+			if scriptToken != "" {
+				return scriptToken, csrfFromScript, nil
 			}
-			foundCSRF := false
-			for i := range tok.Attr {
-				if tok.Attr[i].Key == "name" && tok.Attr[i].Val == "_csrf" {
-					foundCSRF = true
+			return "", "", errors.New("CSRF token not found")
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "input":
+				if isCSRFName(attr(tok, "name")) && attr(tok, "value") != "" {
+					return attr(tok, "value"), csrfFromInput, nil
+				}
+			case "meta":
+				if isCSRFName(attr(tok, "name")) && attr(tok, "content") != "" {
+					return attr(tok, "content"), csrfFromMeta, nil
 				}
+			case "script":
+				inScript = tt == html.StartTagToken
 			}
-			if !foundCSRF {
+		case html.TextToken:
+			if !inScript || scriptToken != "" {
 				continue
 			}
-			for i := range tok.Attr {
-				if tok.Attr[i].Key == "value" {
-					return tok.Attr[i].Val, nil
-				}
+			if m := scriptCSRFRx.FindSubmatch(z.Text()); m != nil {
+				scriptToken = string(m[1])
 			}
+		case html.EndTagToken:
+			inScript = false
 		}
 	}
 }
@@ -102,7 +142,7 @@ func setNickSerialNumber(z *html.Tokenizer, card *Card) error {
 					if tt != html.TextToken {
 						return errors.New("expected text token")
 					}
-					name := z.Token().Data
+					name := canonicalLabel(z.Token().Data)
 					switch name {
 					case "Serial Number:":
 						tt = z.Next()
@@ -170,7 +210,7 @@ func setNickSerialNumber(z *html.Tokenizer, card *Card) error {
 							return fmt.Errorf("expected text token, got %#v\n", z.Token().String())
 						}
 						tok = z.Token()
-						card.Nickname = tok.Data
+						card.Nickname = cleanNickname(tok.Data)
 					}
 				}
 			}
@@ -186,7 +226,7 @@ func setNickSerialNumber(z *html.Tokenizer, card *Card) error {
 func getCards(r io.Reader) ([]Card, error) {
 	z := html.NewTokenizer(r)
 	cards := make([]Card, 0)
-	
+
 	for {
 		tt := z.Next()
 		switch tt {
@@ -194,6 +234,23 @@ func getCards(r io.Reader) ([]Card, error) {
 			return cards, nil
 		case html.StartTagToken:
 			tok := z.Token()
+			// The old "My Clipper" dashboard puts the nickname and serial
+			// number in a header, followed by a box with the card type and
+			// status.
+			if tok.Data == "div" && hasClass(tok, "darkGreyCardHeader") {
+				var card Card
+				if err := setNickSerialNumber(z, &card); err != nil {
+					return nil, err
+				}
+				cards = append(cards, card)
+				continue
+			}
+			if tok.Data == "div" && hasClass(tok, "cardData") && len(cards) > 0 {
+				if err := setCardInfo(z, &cards[len(cards)-1]); err != nil {
+					return nil, err
+				}
+				continue
+			}
 			if tok.Data != "span" {
 				continue
 			}
@@ -208,36 +265,37 @@ func getCards(r io.Reader) ([]Card, error) {
 			if !hasClass {
 				continue
 			}
-			
-			// Get the text content of this span
-			tt = z.Next()
-			if tt == html.TextToken {
-				text := z.Token().Data
-				// Parse card number and name from "1234567890 - CardName" format
-				if card := parseCardText(text); card != nil {
-					cards = append(cards, *card)
-				}
+
+			// Get the text content of this span. Nicknames can contain
+			// entities and markup (for example an emoji image), so read
+			// the whole element rather than the first text token.
+			text := elementText(z)
+			// Parse card number and name from "1234567890 - CardName" format
+			if card := parseCardText(text); card != nil {
+				cards = append(cards, *card)
 			}
 		}
 	}
 }
 
 func parseCardText(text string) *Card {
-	// Parse format like "1401491737 - Guest"
-	parts := strings.Split(text, " - ")
+	// Parse format like "1401491737 - Guest". The nickname may contain " - "
+	// itself.
+	text = strings.Replace(text, "\u00a0", " ", -1)
+	parts := strings.SplitN(text, " - ", 2)
 	if len(parts) != 2 {
 		return nil
 	}
-	
+
 	cardNumberStr := strings.TrimSpace(parts[0])
-	cardName := strings.TrimSpace(parts[1])
-	
+	cardName := cleanNickname(parts[1])
+
 	// Validate card number is numeric and reasonable length
 	cardNumber, err := strconv.ParseInt(cardNumberStr, 10, 64)
 	if err != nil || len(cardNumberStr) < 10 {
 		return nil
 	}
-	
+
 	return &Card{
 		SerialNumber: cardNumber,
 		Nickname:     cardName,
@@ -246,6 +304,26 @@ func parseCardText(text string) *Card {
 	}
 }
 
+// cleanNickname normalizes a card nickname from the site. Nicknames are
+// arbitrary user input, so they can contain emoji, accented characters and
+// non-breaking spaces. Composed and decomposed forms of the same character
+// are normalized to NFC, so the same nickname always compares (and names
+// files) the same way.
+func cleanNickname(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\u00a0':
+			return ' '
+		case r == utf8.RuneError:
+			return -1
+		case unicode.IsControl(r):
+			return -1
+		}
+		return r
+	}, s)
+	return norm.NFC.String(strings.TrimSpace(s))
+}
+
 func setCardInfo(z *html.Tokenizer, card *Card) error {
 	depth := 1
 	hitSpacer := false
@@ -282,7 +360,7 @@ func setCardInfo(z *html.Tokenizer, card *Card) error {
 					if tt != html.TextToken {
 						return errors.New("expected text token")
 					}
-					name := z.Token().Data
+					name := canonicalLabel(z.Token().Data)
 					tt = z.Next()
 					if tt != html.EndTagToken {
 						return fmt.Errorf("expected end tag token, got %#v", z.Token().String())
@@ -304,6 +382,7 @@ func setCardInfo(z *html.Tokenizer, card *Card) error {
 					switch name {
 					case "Type:":
 						card.Type = data
+						card.Discount = parseDiscount(data)
 					case "Status:":
 						card.Status = data
 					case "Reason:":
@@ -322,3 +401,459 @@ func setCardInfo(z *html.Tokenizer, card *Card) error {
 		}
 	}
 }
+
+// htmlForm is a <form> found in a HTML page, along with the values of the
+// inputs inside it.
+type htmlForm struct {
+	Action string
+	Method string
+	Values url.Values
+
+	// Names of the inputs that hold the username and the password, if the
+	// form has them.
+	UsernameField string
+	PasswordField string
+}
+
+func attr(tok html.Token, key string) string {
+	for i := range tok.Attr {
+		if tok.Attr[i].Key == key {
+			return tok.Attr[i].Val
+		}
+	}
+	return ""
+}
+
+// findForms returns every form in the document. Inputs outside of a form are
+// ignored.
+func findForms(r io.Reader) []htmlForm {
+	z := html.NewTokenizer(r)
+	var forms []htmlForm
+	var cur *htmlForm
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return forms
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "form":
+				forms = append(forms, htmlForm{
+					Action: attr(tok, "action"),
+					Method: strings.ToUpper(attr(tok, "method")),
+					Values: make(url.Values),
+				})
+				cur = &forms[len(forms)-1]
+			case "input":
+				if cur == nil {
+					continue
+				}
+				name := attr(tok, "name")
+				if name == "" {
+					continue
+				}
+				switch strings.ToLower(attr(tok, "type")) {
+				case "password":
+					cur.PasswordField = name
+				case "email":
+					cur.UsernameField = name
+				case "submit", "button", "image", "reset":
+					continue
+				default:
+					if cur.UsernameField == "" && isUsernameField(name) {
+						cur.UsernameField = name
+					}
+				}
+				cur.Values.Set(name, attr(tok, "value"))
+			}
+		case html.EndTagToken:
+			if z.Token().Data == "form" {
+				cur = nil
+			}
+		}
+	}
+}
+
+func isUsernameField(name string) bool {
+	switch strings.ToLower(name) {
+	case "email", "username", "user", "login", "loginfmt", "identifier":
+		return true
+	}
+	return false
+}
+
+func hasClass(tok html.Token, class string) bool {
+	for _, c := range strings.Fields(attr(tok, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// alertSeverity returns the level from a Bootstrap "alert-<level>" class.
+func alertSeverity(tok html.Token) string {
+	for _, c := range strings.Fields(attr(tok, "class")) {
+		if strings.HasPrefix(c, "alert-") && c != "alert-dismissible" && c != "alert-link" && c != "alert-heading" {
+			return strings.TrimPrefix(c, "alert-")
+		}
+	}
+	return ""
+}
+
+// getNotices finds account messages, which are rendered as Bootstrap alerts.
+func getNotices(r io.Reader) ([]Notice, error) {
+	z := html.NewTokenizer(r)
+	notices := make([]Notice, 0)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			return notices, nil
+		case html.StartTagToken:
+			tok := z.Token()
+			if !hasClass(tok, "alert") && attr(tok, "role") != "alert" {
+				continue
+			}
+			if strings.Contains(strings.ReplaceAll(attr(tok, "style"), " ", ""), "display:none") {
+				continue
+			}
+			text := elementText(z)
+			if text == "" {
+				continue
+			}
+			notices = append(notices, Notice{
+				Kind:     classifyNotice(text),
+				Severity: alertSeverity(tok),
+				Text:     text,
+			})
+		}
+	}
+}
+
+// isMessage reports whether tok starts an alert or a form error, where a login
+// page explains why it didn't let the account in.
+func isMessage(tok html.Token) bool {
+	if hasClass(tok, "alert") || attr(tok, "role") == "alert" {
+		return true
+	}
+	for _, c := range strings.Fields(attr(tok, "class")) {
+		if c == "invalid-feedback" || strings.Contains(strings.ToLower(c), "error") {
+			return true
+		}
+	}
+	return false
+}
+
+// isHidden reports whether tok has the hidden attribute or is styled
+// display:none.
+func isHidden(tok html.Token) bool {
+	for _, a := range tok.Attr {
+		if a.Key == "hidden" {
+			return true
+		}
+	}
+	return strings.Contains(strings.ReplaceAll(attr(tok, "style"), " ", ""), "display:none")
+}
+
+// findMessages returns the text of the visible alerts and form errors in r.
+// Hidden ones, like the empty error box on a login form, are skipped.
+func findMessages(r io.Reader) []string {
+	z := html.NewTokenizer(r)
+	var messages []string
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return messages
+		case html.StartTagToken:
+			tok := z.Token()
+			if !isMessage(tok) {
+				continue
+			}
+			if isHidden(tok) {
+				continue
+			}
+			if text := elementText(z); text != "" {
+				messages = append(messages, text)
+			}
+		}
+	}
+}
+
+// voidElements never have an end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// elementText returns the text inside the element whose start tag was just
+// read from z, with whitespace collapsed. Buttons (like the close button on a
+// dismissible alert) are skipped.
+func elementText(z *html.Tokenizer) string {
+	depth := 1
+	skip := 0
+	var buf strings.Builder
+	for depth > 0 {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			depth = 0
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			if string(name) == "br" || string(name) == "p" || string(name) == "div" {
+				buf.WriteByte(' ')
+			}
+			if tt == html.SelfClosingTagToken || voidElements[string(name)] {
+				continue
+			}
+			depth++
+			if string(name) == "button" || skip > 0 {
+				skip++
+			}
+		case html.EndTagToken:
+			depth--
+			if skip > 0 {
+				skip--
+			}
+		case html.TextToken:
+			if skip == 0 {
+				buf.Write(z.Text())
+			}
+		}
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// labelValues returns the value that follows each "Label:" text on the page,
+// keyed by label. This matches both the old fieldName/fieldData layout and the
+// newer one, which puts labels and values in sibling columns.
+func labelValues(r io.Reader) (map[string]string, error) {
+	z := html.NewTokenizer(r)
+	values := make(map[string]string)
+	label := ""
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			return values, nil
+		case html.StartTagToken:
+			if name, _ := z.TagName(); string(name) == "script" || string(name) == "style" {
+				z.Next()
+			}
+		case html.TextToken:
+			text := strings.Join(strings.Fields(string(z.Text())), " ")
+			if text == "" {
+				continue
+			}
+			if isLabel(text) {
+				label = canonicalLabel(text)
+				continue
+			}
+			if label != "" {
+				if _, ok := values[label]; !ok {
+					values[label] = text
+				}
+				label = ""
+			}
+		}
+	}
+}
+
+var expirationLabels = []string{
+	"Expiration Date:",
+	"Card Expiration Date:",
+	"Card Expiration:",
+	"Expiry Date:",
+	"Expires:",
+}
+
+var expirationLayouts = []string{
+	"01/02/2006",
+	"1/2/2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2006-01-02",
+}
+
+// parseExpiration parses an expiration date in any of the formats Clipper
+// uses, as midnight Pacific time. Dates with only a month and year ("03/2027")
+// expire at the end of that month.
+func parseExpiration(s string) (time.Time, error) {
+	for _, layout := range expirationLayouts {
+		if t, err := time.ParseInLocation(layout, s, Pacific); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"01/2006", "1/2006", "January 2006"} {
+		if t, err := time.ParseInLocation(layout, s, Pacific); err == nil {
+			return t.AddDate(0, 1, -1), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("clipper: could not parse expiration date %q", s)
+}
+
+// setCardDetails fills in fields on card from the card detail page.
+func setCardDetails(r io.Reader, card *Card) error {
+	values, err := labelValues(r)
+	if err != nil {
+		return err
+	}
+	for _, label := range expirationLabels {
+		val, ok := values[label]
+		if !ok {
+			continue
+		}
+		expires, err := parseExpiration(val)
+		if err != nil {
+			return err
+		}
+		card.Expires = expires
+		break
+	}
+	// Clipper START is a program on top of an adult card, so check it before
+	// the card type.
+	for _, label := range discountLabels {
+		if val, ok := values[label]; ok {
+			if d := parseDiscount(val); d != DiscountNone {
+				card.Discount = d
+				break
+			}
+		}
+	}
+	return nil
+}
+
+var discountLabels = []string{
+	"Discount Program:",
+	"Discount:",
+	"Fare Program:",
+	"Card Type:",
+	"Type:",
+}
+
+// findMoreCardsURL returns the link to the rest of the card list on an account
+// page, or "" if the list is complete. Large accounts get either a pagination
+// bar with a "Next" link, or a "show more" button whose data-url attribute
+// points at an endpoint returning the next batch of cards as a HTML fragment;
+// ajax is true for the latter.
+func findMoreCardsURL(r io.Reader) (u string, ajax bool) {
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return "", false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if dataURL := attr(tok, "data-url"); dataURL != "" && isShowMore(tok) {
+				return dataURL, true
+			}
+			if tok.Data != "a" {
+				continue
+			}
+			href := attr(tok, "href")
+			if href == "" || href == "#" || strings.HasPrefix(href, "javascript:") {
+				continue
+			}
+			if attr(tok, "rel") == "next" {
+				return href, false
+			}
+			if !hasClass(tok, "page-link") && !isShowMore(tok) {
+				continue
+			}
+			if tt == html.SelfClosingTagToken {
+				continue
+			}
+			if isShowMore(tok) {
+				return href, false
+			}
+			switch elementText(z) {
+			case "Next", "next", "›", "»", "Next ›", "Next »":
+				return href, false
+			}
+		}
+	}
+}
+
+func isShowMore(tok html.Token) bool {
+	for _, c := range strings.Fields(attr(tok, "class")) {
+		if c == "show-more" || c == "load-more" || c == "showMore" || c == "loadMore" {
+			return true
+		}
+	}
+	return false
+}
+
+// getInstitutionCards parses the card table in the institutional portal. The
+// columns are identified by their headers, since institutions can choose which
+// columns to show.
+func getInstitutionCards(r io.Reader) ([]Card, error) {
+	z := html.NewTokenizer(r)
+	cards := make([]Card, 0)
+	var columns []string
+	var row []string
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			return cards, nil
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "tr":
+				row = row[:0]
+			case "th":
+				columns = append(columns, elementText(z))
+			case "td":
+				row = append(row, elementText(z))
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if string(name) != "tr" || len(row) == 0 {
+				continue
+			}
+			card, ok := institutionCard(columns, row)
+			if ok {
+				cards = append(cards, card)
+			}
+		}
+	}
+}
+
+func institutionCard(columns, row []string) (Card, bool) {
+	var card Card
+	for i, val := range row {
+		if i >= len(columns) {
+			break
+		}
+		switch strings.TrimSuffix(strings.ToLower(columns[i]), ":") {
+		case "card number", "serial number", "card serial number":
+			num, err := strconv.ParseInt(strings.Replace(val, " ", "", -1), 10, 64)
+			if err != nil {
+				return Card{}, false
+			}
+			card.SerialNumber = num
+		case "nickname", "card nickname", "card holder", "employee", "name":
+			if card.Nickname == "" {
+				card.Nickname = val
+			}
+		case "status", "card status":
+			card.Status = val
+		case "type", "card type":
+			card.Type = val
+			card.Discount = parseDiscount(val)
+		}
+	}
+	return card, card.SerialNumber != 0
+}
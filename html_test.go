@@ -2,7 +2,9 @@ package clipper
 
 import (
 	"bytes"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kevinburke/unidoc/common"
 )
@@ -34,3 +36,222 @@ func TestGetCards(t *testing.T) {
 		t.Errorf("bad card nickname: %q", cards[0].Nickname)
 	}
 }
+
+var noticesPage = []byte(`<html><body>
+<div class="alert alert-warning alert-dismissible" role="alert">
+	<button type="button" class="close" data-dismiss="alert"><span>&times;</span></button>
+	Your card 1401491737 will expire on <strong>03/31/2025</strong>.<br>Order a replacement card.
+</div>
+<div class="alert alert-danger">Your Autoload payment of $50.00 failed. Please update your payment method.</div>
+<div class="alert alert-info" style="display: none">Hidden placeholder</div>
+<p class="alert-link">not an alert</p>
+</body></html>`)
+
+func TestGetNotices(t *testing.T) {
+	notices, err := getNotices(bytes.NewReader(noticesPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notices) != 2 {
+		t.Fatalf("expected 2 notices, got %d: %#v", len(notices), notices)
+	}
+	want := "Your card 1401491737 will expire on 03/31/2025. Order a replacement card."
+	if notices[0].Text != want {
+		t.Errorf("notice text: got %q, want %q", notices[0].Text, want)
+	}
+	if notices[0].Kind != NoticeCardExpiring || notices[0].Severity != "warning" {
+		t.Errorf("bad notice 0: %#v", notices[0])
+	}
+	if notices[1].Kind != NoticeAutoloadFailed || notices[1].Severity != "danger" {
+		t.Errorf("bad notice 1: %#v", notices[1])
+	}
+	if !notices[1].Important() {
+		t.Errorf("failed autoload should be important")
+	}
+}
+
+var cardDetailsPage = []byte(`<html><body>
+<div class="card-details">
+	<span class="d-inline-block">1401491737 - Guest</span>
+	<div class="row">
+		<div class="col-6">Card Type:</div>
+		<div class="col-6">Adult</div>
+	</div>
+	<div class="row">
+		<div class="col-6">Expiration Date:</div>
+		<div class="col-6"> 03/31/2027 </div>
+	</div>
+</div>
+</body></html>`)
+
+func TestSetCardDetails(t *testing.T) {
+	card := Card{SerialNumber: 1401491737}
+	if err := setCardDetails(bytes.NewReader(cardDetailsPage), &card); err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2027, time.March, 31, 0, 0, 0, 0, Pacific)
+	if !card.Expires.Equal(want) {
+		t.Errorf("Expires: got %v, want %v", card.Expires, want)
+	}
+	if card.Discount != DiscountNone {
+		t.Errorf("Discount: got %q, want none", card.Discount)
+	}
+}
+
+var discountTests = []struct {
+	in   string
+	want Discount
+}{
+	{"ADULT", DiscountNone},
+	{"YOUTH", DiscountYouth},
+	{"Senior (65+)", DiscountSenior},
+	{"RTC", DiscountRTC},
+	{"Clipper START", DiscountStart},
+}
+
+func TestParseDiscount(t *testing.T) {
+	for _, tt := range discountTests {
+		if got := parseDiscount(tt.in); got != tt.want {
+			t.Errorf("parseDiscount(%q): got %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseExpirationMonthYear(t *testing.T) {
+	got, err := parseExpiration("02/2028")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2028, time.February, 29, 0, 0, 0, 0, Pacific); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseExpirationLocation(t *testing.T) {
+	for _, s := range []string{"03/31/2027", "03/2027", "March 2027"} {
+		got, err := parseExpiration(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Location() != Pacific {
+			t.Errorf("parseExpiration(%q): got location %v, want %v", s, got.Location(), Pacific)
+		}
+		if y, m, d := got.Date(); y != 2027 || m != time.March || d != 31 || got.Hour() != 0 {
+			t.Errorf("parseExpiration(%q): got %v, want midnight on March 31, 2027", s, got)
+		}
+	}
+}
+
+var pagedDashboard = []byte(`<html><body>
+<div class="card-list">
+	<span class="d-inline-block">1401491737 - Guest</span>
+	<span class="d-inline-block">1401491738 - Work</span>
+</div>
+<nav><ul class="pagination">
+	<li class="page-item active"><a class="page-link" href="#">1</a></li>
+	<li class="page-item"><a class="page-link" href="account.html?page=2">2</a></li>
+	<li class="page-item"><a class="page-link" href="account.html?page=2"><span>Next</span></a></li>
+</ul></nav>
+</body></html>`)
+
+var showMoreFragment = []byte(`<span class="d-inline-block">1401491739 - Spare</span>
+<button class="btn btn-link show-more" data-url="/ClipperWeb/cards.html?offset=20">Show more</button>`)
+
+func TestFindMoreCardsURL(t *testing.T) {
+	u, ajax := findMoreCardsURL(bytes.NewReader(pagedDashboard))
+	if u != "account.html?page=2" || ajax {
+		t.Errorf("pagination: got (%q, %t)", u, ajax)
+	}
+	u, ajax = findMoreCardsURL(bytes.NewReader(showMoreFragment))
+	if u != "/ClipperWeb/cards.html?offset=20" || !ajax {
+		t.Errorf("show more: got (%q, %t)", u, ajax)
+	}
+	u, _ = findMoreCardsURL(bytes.NewReader(cardDetailsPage))
+	if u != "" {
+		t.Errorf("expected no more cards, got %q", u)
+	}
+}
+
+var institutionCardsPage = []byte(`<html><body>
+<table class="table cards">
+<thead><tr><th>Card Number</th><th>Employee</th><th>Card Type</th><th>Status</th></tr></thead>
+<tbody>
+<tr><td>1401491737</td><td>Alice Example</td><td>Adult</td><td>Active</td></tr>
+<tr><td>1401491738</td><td>Bob Example</td><td>Senior</td><td>Blocked</td></tr>
+</tbody>
+</table>
+<a class="page-link" href="cards.html?page=2">Next</a>
+</body></html>`)
+
+func TestGetInstitutionCards(t *testing.T) {
+	cards, err := getInstitutionCards(bytes.NewReader(institutionCardsPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 2 {
+		t.Fatalf("expected 2 cards, got %d", len(cards))
+	}
+	if cards[1].SerialNumber != 1401491738 || cards[1].Nickname != "Bob Example" || cards[1].Status != "Blocked" {
+		t.Errorf("bad card: %#v", cards[1])
+	}
+	if cards[1].Discount != DiscountSenior {
+		t.Errorf("Discount: got %q, want senior", cards[1].Discount)
+	}
+}
+
+var csrfTests = []struct {
+	name string
+	page string
+	want string
+	src  csrfSource
+}{
+	{"self-closing input", `<form><input type="hidden" name="_csrf" value="tok1"/></form>`, "tok1", csrfFromInput},
+	{"input", `<form><input type="hidden" name="_csrf" value="tok2"></form>`, "tok2", csrfFromInput},
+	{"meta", `<head><meta name="_csrf" content="tok3"><meta name="_csrf_header" content="X-CSRF-TOKEN"></head>`, "tok3", csrfFromMeta},
+	{"json", `<script>window.__STATE__ = {"user":null,"_csrf":"tok4"};</script>`, "tok4", csrfFromScript},
+	{"input wins over script", `<script>var csrfToken = 'old';</script><input name="_csrf" value="tok5">`, "tok5", csrfFromInput},
+}
+
+func TestFindCSRFToken(t *testing.T) {
+	for _, tt := range csrfTests {
+		got, src, err := findCSRFToken(strings.NewReader(tt.page))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want || src != tt.src {
+			t.Errorf("%s: got (%q, %q), want (%q, %q)", tt.name, got, src, tt.want, tt.src)
+		}
+	}
+	if _, _, err := findCSRFToken(strings.NewReader(`<input name="email">`)); err == nil {
+		t.Errorf("expected an error for a page without a token")
+	}
+}
+
+var nicknameTests = []struct {
+	in   string
+	want string
+}{
+	{`<span class="d-inline-block">1401491737 - Guest</span>`, "Guest"},
+	{`<span class="d-inline-block">1401491737 - Jos&eacute;&#39;s card</span>`, "José's card"},
+	{`<span class="d-inline-block">1401491737&nbsp;-&nbsp;🚲 Bike &amp; BART</span>`, "🚲 Bike & BART"},
+	{`<span class="d-inline-block">1401491737 - Work - Caltrain</span>`, "Work - Caltrain"},
+	{`<span class="d-inline-block">1401491737 - <b>Zo</b>e` + "́" + `</span>`, "Zoé"},
+	{`<span class="d-inline-block">1401491737 - 👩‍👧 Family</span>`, "👩‍👧 Family"},
+}
+
+func TestNicknames(t *testing.T) {
+	for _, tt := range nicknameTests {
+		cards, err := getCards(strings.NewReader(tt.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cards) != 1 {
+			t.Errorf("%s: expected 1 card, got %d", tt.in, len(cards))
+			continue
+		}
+		if cards[0].Nickname != tt.want {
+			t.Errorf("%s: got nickname %q, want %q", tt.in, cards[0].Nickname, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package clipper
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+)
+
+// institutionPath is the card list in the portal for employer and other
+// institutional accounts.
+const institutionPath = "/ClipperWeb/institution/cards.html"
+
+// An InstitutionalClient manages an employer or other institutional Clipper
+// account. Institutional accounts log in the same way as personal ones, but
+// list their (often hundreds of) cards in a separate, paginated portal.
+type InstitutionalClient struct {
+	c *Client
+}
+
+// NewInstitutionalClient returns a client for the institutional account with
+// the given administrator credentials.
+func NewInstitutionalClient(username, password string, opts ...Option) (*InstitutionalClient, error) {
+	opts = append([]Option{WithSiteAdapter(&ClipperWeb{institution: true})}, opts...)
+	c, err := NewClient(username, password, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &InstitutionalClient{c: c}, nil
+}
+
+// Cards returns every card the institution manages, following the portal's
+// pagination.
+func (ic *InstitutionalClient) Cards(ctx context.Context) ([]Card, error) {
+	return ic.c.Cards(ctx)
+}
+
+// institutionCards returns the cards in the institutional portal.
+func (c *Client) institutionCards(ctx context.Context) ([]Card, error) {
+	page, _ := url.Parse(c.host + institutionPath)
+	body, err := c.getPage(ctx, page.String(), c.host+"/ClipperWeb/account.html", false)
+	if err != nil {
+		return nil, err
+	}
+	cards, err := getInstitutionCards(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return c.followCardPages(ctx, page, body, cards, getInstitutionCards)
+}
+
+// DownloadPDFs downloads activity reports for every card the institution
+// manages. The arguments are the same as for Client.DownloadPDFs.
+func (ic *InstitutionalClient) DownloadPDFs(ctx context.Context, outputDir string, startDate, endDate string, dryRun bool) error {
+	return ic.c.DownloadPDFs(ctx, outputDir, startDate, endDate, dryRun)
+}
+
+// DownloadRange is like DownloadPDFs, but takes the dates as a DateRange.
+func (ic *InstitutionalClient) DownloadRange(ctx context.Context, outputDir string, r DateRange, dryRun bool) error {
+	return ic.c.DownloadRange(ctx, outputDir, r, dryRun)
+}
+
+// Sync downloads what's new for every card the institution manages; see
+// Client.Sync.
+func (ic *InstitutionalClient) Sync(ctx context.Context, outputDir string, state SyncState, dryRun bool) ([]SyncedStatement, error) {
+	return ic.c.Sync(ctx, outputDir, state, dryRun)
+}
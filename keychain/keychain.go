@@ -0,0 +1,72 @@
+// Package keychain keeps small secrets, like a Clipper session, in the
+// operating system's credential store instead of a file: the login keychain
+// on macOS, the Secret Service (GNOME Keyring or KWallet, through libsecret's
+// secret-tool) on Linux and the BSDs, and the Credential Manager on Windows.
+//
+// Each secret is filed under a service name and an account, like
+// "clipper-session" and an email address.
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// ErrUnsupported is returned on systems without a credential store this
+// package knows how to use.
+var ErrUnsupported = errors.New("keychain: no credential store on this system")
+
+// A Keychain stores secrets for one service in the system's credential store.
+type Keychain struct {
+	service string
+}
+
+// New returns a Keychain that files secrets under service.
+func New(service string) *Keychain {
+	return &Keychain{service: service}
+}
+
+// Get returns the secret stored for account, or an error wrapping
+// fs.ErrNotExist if there isn't one.
+func (k *Keychain) Get(account string) ([]byte, error) {
+	if err := k.check(account); err != nil {
+		return nil, err
+	}
+	b, err := get(k.service, account)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("keychain: no %s secret for %s: %w", k.service, account, err)
+	}
+	return b, err
+}
+
+// Set stores secret for account, replacing any secret already there.
+func (k *Keychain) Set(account string, secret []byte) error {
+	if err := k.check(account); err != nil {
+		return err
+	}
+	return set(k.service, account, secret)
+}
+
+// Delete removes the secret stored for account. Deleting a secret that isn't
+// there is not an error.
+func (k *Keychain) Delete(account string) error {
+	if err := k.check(account); err != nil {
+		return err
+	}
+	if err := del(k.service, account); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// check rejects names the command line tools can't pass through unmangled.
+func (k *Keychain) check(account string) error {
+	for _, s := range []string{k.service, account} {
+		if s == "" || strings.ContainsAny(s, "\"'\\\n\r\x00") {
+			return fmt.Errorf("keychain: invalid service or account name %q", s)
+		}
+	}
+	return nil
+}
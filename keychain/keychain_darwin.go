@@ -0,0 +1,62 @@
+package keychain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strings"
+)
+
+// The login keychain, through /usr/bin/security. Secrets are base64 encoded,
+// since the keychain stores text.
+
+const securityPath = "/usr/bin/security"
+
+// errItemNotFound is the exit status of security for a missing item.
+const errItemNotFound = 44
+
+func security(stdin string, args ...string) ([]byte, error) {
+	cmd := exec.Command(securityPath, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == errItemNotFound {
+		return nil, fs.ErrNotExist
+	}
+	if err != nil {
+		return nil, fmt.Errorf("keychain: security %s: %v: %s", args[0], err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, nil
+}
+
+func get(service, account string) ([]byte, error) {
+	out, err := security("", "find-generic-password", "-s", service, "-a", account, "-w")
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+	if err != nil {
+		return nil, fmt.Errorf("keychain: %s secret for %s isn't one this package stored: %v", service, account, err)
+	}
+	return b, nil
+}
+
+func set(service, account string, secret []byte) error {
+	// In interactive mode security reads the command from stdin, which keeps
+	// the secret out of the process list.
+	cmd := fmt.Sprintf("add-generic-password -U -s \"%s\" -a \"%s\" -w \"%s\"\n", service, account, base64.StdEncoding.EncodeToString(secret))
+	_, err := security(cmd, "-i")
+	return err
+}
+
+func del(service, account string) error {
+	_, err := security("", "delete-generic-password", "-s", service, "-a", account)
+	return err
+}
@@ -0,0 +1,7 @@
+//go:build !unix && !windows
+
+package keychain
+
+func get(service, account string) ([]byte, error)      { return nil, ErrUnsupported }
+func set(service, account string, secret []byte) error { return ErrUnsupported }
+func del(service, account string) error                { return ErrUnsupported }
@@ -0,0 +1,72 @@
+//go:build unix && !darwin
+
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeSecretTool is a secret-tool that keeps each secret in a file named for
+// its attributes.
+const fakeSecretTool = `#!/bin/sh
+cmd=$1; shift
+case $cmd in store) shift ;; esac
+f="$FAKE_SECRETS/$(echo "$@" | tr ' @' '_-')"
+case $cmd in
+store) cat > "$f" ;;
+lookup) [ -f "$f" ] || exit 1; cat "$f" ;;
+clear) rm -f "$f" ;;
+esac
+`
+
+func TestKeychain(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "secret-tool"), []byte(fakeSecretTool), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_SECRETS", t.TempDir())
+
+	k := New("clipper-test")
+	if _, err := k.Get("me@example.com"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Get before Set: got %v, want fs.ErrNotExist", err)
+	}
+	secret := []byte("{\"cookies\":[]}\n\x00")
+	if err := k.Set("me@example.com", secret); err != nil {
+		t.Fatal(err)
+	}
+	got, err := k.Get("me@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Errorf("Get: got %q, want %q", got, secret)
+	}
+	if _, err := New("other").Get("me@example.com"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Get from another service: got %v", err)
+	}
+	if err := k.Delete("me@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Delete("me@example.com"); err != nil {
+		t.Errorf("second Delete: %v", err)
+	}
+	if _, err := k.Get("me@example.com"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Get after Delete: got %v", err)
+	}
+	if err := k.Set(`me"@example.com`, secret); err == nil {
+		t.Error("Set: want an error for a quote in the account")
+	}
+}
+
+func TestKeychainWithoutSecretTool(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if _, err := New("clipper-test").Get("me@example.com"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("got %v, want ErrUnsupported", err)
+	}
+}
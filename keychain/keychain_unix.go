@@ -0,0 +1,59 @@
+//go:build unix && !darwin
+
+package keychain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strings"
+)
+
+// The Secret Service, through libsecret's secret-tool. Secrets are base64
+// encoded, since secret-tool prints them as text.
+
+func secretTool(stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("secret-tool", args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("%w: install secret-tool, which comes with libsecret", ErrUnsupported)
+	}
+	// secret-tool exits 1, saying nothing, when there's no such secret.
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 1 && stderr.Len() == 0 {
+		return nil, fs.ErrNotExist
+	}
+	if err != nil {
+		return nil, fmt.Errorf("keychain: secret-tool %s: %v: %s", args[0], err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, nil
+}
+
+func get(service, account string) ([]byte, error) {
+	out, err := secretTool(nil, "lookup", "service", service, "account", account)
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+	if err != nil {
+		return nil, fmt.Errorf("keychain: %s secret for %s isn't one this package stored: %v", service, account, err)
+	}
+	return b, nil
+}
+
+func set(service, account string, secret []byte) error {
+	label := fmt.Sprintf("%s (%s)", service, account)
+	_, err := secretTool([]byte(base64.StdEncoding.EncodeToString(secret)), "store", "--label="+label, "service", service, "account", account)
+	return err
+}
+
+func del(service, account string) error {
+	_, err := secretTool(nil, "clear", "service", service, "account", account)
+	return err
+}
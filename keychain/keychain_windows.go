@@ -0,0 +1,113 @@
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"syscall"
+	"unsafe"
+)
+
+// The Credential Manager, through advapi32. Each secret is a generic
+// credential named "service:account".
+
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredRead   = advapi32.NewProc("CredReadW")
+	procCredWrite  = advapi32.NewProc("CredWriteW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	// maxBlobSize is CRED_MAX_CREDENTIAL_BLOB_SIZE.
+	maxBlobSize = 5 * 512
+
+	errorNotFound syscall.Errno = 1168
+)
+
+// credential is CREDENTIALW.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func target(service, account string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(service + ":" + account)
+}
+
+func credError(op string, err error) error {
+	if errors.Is(err, errorNotFound) {
+		return fs.ErrNotExist
+	}
+	return fmt.Errorf("keychain: %s: %v", op, err)
+}
+
+func get(service, account string) ([]byte, error) {
+	name, err := target(service, account)
+	if err != nil {
+		return nil, err
+	}
+	var cred *credential
+	r, _, err := procCredRead.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if r == 0 {
+		return nil, credError("CredRead", err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	b := make([]byte, cred.CredentialBlobSize)
+	copy(b, unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize))
+	return b, nil
+}
+
+func set(service, account string, secret []byte) error {
+	if len(secret) > maxBlobSize {
+		return fmt.Errorf("keychain: a %d-byte secret is too big for the Credential Manager, which holds at most %d", len(secret), maxBlobSize)
+	}
+	name, err := target(service, account)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return err
+	}
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         name,
+		CredentialBlobSize: uint32(len(secret)),
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	if len(secret) > 0 {
+		cred.CredentialBlob = &secret[0]
+	}
+	r, _, err := procCredWrite.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if r == 0 {
+		return credError("CredWrite", err)
+	}
+	return nil
+}
+
+func del(service, account string) error {
+	name, err := target(service, account)
+	if err != nil {
+		return err
+	}
+	r, _, err := procCredDelete.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0)
+	if r == 0 {
+		return credError("CredDelete", err)
+	}
+	return nil
+}
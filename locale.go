@@ -0,0 +1,82 @@
+package clipper
+
+// Accounts can choose to see the Clipper site (and their statements) in
+// Spanish or Chinese. We ask for English with Accept-Language, but an account
+// preference wins over the header, so the parsers also translate the labels
+// they look for back to English.
+
+import "strings"
+
+// localizedLabels maps field labels on the Spanish and Chinese sites to the
+// English labels the parsers expect.
+var localizedLabels = map[string]string{
+	// Spanish
+	"Número de serie:":            "Serial Number:",
+	"Número de tarjeta:":          "Serial Number:",
+	"Apodo de la tarjeta:":        "Card Nickname:",
+	"Nombre de la tarjeta:":       "Card Nickname:",
+	"Tipo:":                       "Type:",
+	"Tipo de tarjeta:":            "Card Type:",
+	"Estado:":                     "Status:",
+	"Motivo:":                     "Reason:",
+	"Razón:":                      "Reason:",
+	"Fecha de vencimiento:":       "Expiration Date:",
+	"Fecha de caducidad:":         "Expiration Date:",
+	"Vence:":                      "Expires:",
+	"Programa de descuento:":      "Discount Program:",
+	"Descuento:":                  "Discount:",
+	"Valor en efectivo:":          "Cash value:",
+	"Número de serie de tarjeta:": "Serial Number:",
+	// Chinese
+	"序號:":   "Serial Number:",
+	"序列號:":  "Serial Number:",
+	"卡號:":   "Serial Number:",
+	"卡片暱稱:": "Card Nickname:",
+	"類型:":   "Type:",
+	"卡片類型:": "Card Type:",
+	"狀態:":   "Status:",
+	"原因:":   "Reason:",
+	"到期日:":  "Expiration Date:",
+	"有效期限:": "Expiration Date:",
+	"優惠計劃:": "Discount Program:",
+	"現金價值:": "Cash value:",
+}
+
+// canonicalLabel returns the English form of a field label from any of the
+// site's languages. Chinese pages use a full-width colon.
+func canonicalLabel(label string) string {
+	label = strings.TrimSpace(strings.Replace(label, "：", ":", -1))
+	if en, ok := localizedLabels[label]; ok {
+		return en
+	}
+	return label
+}
+
+// isLabel reports whether text looks like a field label.
+func isLabel(text string) bool {
+	return strings.HasSuffix(text, ":") || strings.HasSuffix(text, "：")
+}
+
+// localizedPDFPrefixes maps the start of lines in Spanish statements to their
+// English form. Chinese statements use CID fonts that we can't decode, so only
+// Spanish is handled here.
+var localizedPDFPrefixes = []struct {
+	es, en string
+}{
+	{"HISTORIAL DE TRANSACCIONES DE", "TRANSACTION HISTORY FOR"},
+	{"HISTORIAL DE TRANSACCIONES PARA", "TRANSACTION HISTORY FOR"},
+	{"TARJETA ", "CARD "},
+	{"TIPO DE TRANSACCIÓN\tUBICACIÓN\tRUTA", "TRANSACTION TYPE\tLOCATION\tROUTE"},
+	{"* Si hay una discrepancia en el saldo de la tarjeta", "* If there is a discrepancy in the listing of the card balance"},
+}
+
+// canonicalPDFLine translates the fixed header and footer text on a Spanish
+// statement page to English. Transaction rows are returned as is.
+func canonicalPDFLine(line string) string {
+	for _, p := range localizedPDFPrefixes {
+		if strings.HasPrefix(line, p.es) {
+			return p.en + line[len(p.es):]
+		}
+	}
+	return strings.Replace(line, "Página ", "Page ", 1)
+}
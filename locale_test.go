@@ -0,0 +1,43 @@
+package clipper
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+var spanishPages = []string{"HISTORIAL DE TRANSACCIONES DE\nTARJETA 1202728442\nTIPO DE TRANSACCIÓN\tUBICACIÓN\tRUTA\tPRODUCTO\tDÉBITO\tCRÉDITO\tSALDO*\n12/12/2017 09:17 AM\tSingle-tag fare payment\tSAM bus\tLOC\tClipper Cash\t2.05\t\t146.85\n* Si hay una discrepancia en el saldo de la tarjeta, comuníquese con el Centro de Servicio al Cliente.\n02/10/2018\t\t\t\t\t\t\tPágina 1 de"}
+
+func TestGetCSVSpanish(t *testing.T) {
+	num, records, err := getCSV(spanishPages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if num != 1202728442 {
+		t.Errorf("bad account number: got %d", num)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected header and 1 record, got %d", len(records))
+	}
+	if records[1][2] != "SAM bus" {
+		t.Errorf("bad location: %q", records[1][2])
+	}
+}
+
+var chineseCardPage = []byte(`<html lang="zh-TW"><body>
+<div class="row"><div class="col-6">卡片類型：</div><div class="col-6">青年</div></div>
+<div class="row"><div class="col-6">到期日：</div><div class="col-6">03/31/2027</div></div>
+</body></html>`)
+
+func TestSetCardDetailsChinese(t *testing.T) {
+	var card Card
+	if err := setCardDetails(bytes.NewReader(chineseCardPage), &card); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2027, time.March, 31, 0, 0, 0, 0, Pacific); !card.Expires.Equal(want) {
+		t.Errorf("Expires: got %v, want %v", card.Expires, want)
+	}
+	if card.Discount != DiscountYouth {
+		t.Errorf("Discount: got %q, want youth", card.Discount)
+	}
+}
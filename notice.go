@@ -0,0 +1,67 @@
+package clipper
+
+import (
+	"bytes"
+	"context"
+	"strings"
+)
+
+// NoticeKind classifies a Notice.
+type NoticeKind string
+
+const (
+	// NoticeCardExpiring means a card, or the payment method funding its
+	// Autoload, is about to expire.
+	NoticeCardExpiring NoticeKind = "card_expiring"
+	// NoticeAutoloadFailed means Clipper could not charge the payment method
+	// for an Autoload.
+	NoticeAutoloadFailed NoticeKind = "autoload_failed"
+	// NoticeBalanceAdjustment means Clipper changed a card's balance, for
+	// example after a refund or a disputed fare.
+	NoticeBalanceAdjustment NoticeKind = "balance_adjustment"
+	// NoticeOther is any message we don't recognize.
+	NoticeOther NoticeKind = "other"
+)
+
+// A Notice is a message from the "messages/alerts" section of the account
+// page.
+type Notice struct {
+	Kind NoticeKind
+	// Severity is the alert level the site displays the message with: "danger",
+	// "warning", "info" or "success". It may be empty.
+	Severity string
+	Text     string
+}
+
+// Important reports whether the notice is likely to need action from the
+// account owner.
+func (n Notice) Important() bool {
+	switch n.Kind {
+	case NoticeCardExpiring, NoticeAutoloadFailed, NoticeBalanceAdjustment:
+		return true
+	}
+	return n.Severity == "danger" || n.Severity == "warning"
+}
+
+func classifyNotice(text string) NoticeKind {
+	lower := strings.ToLower(text)
+	switch {
+	case strings.Contains(lower, "autoload") && (strings.Contains(lower, "fail") || strings.Contains(lower, "declined") || strings.Contains(lower, "unable")):
+		return NoticeAutoloadFailed
+	case strings.Contains(lower, "expire") || strings.Contains(lower, "expiring") || strings.Contains(lower, "expiration"):
+		return NoticeCardExpiring
+	case strings.Contains(lower, "adjust") || strings.Contains(lower, "refund") || strings.Contains(lower, "credited"):
+		return NoticeBalanceAdjustment
+	}
+	return NoticeOther
+}
+
+// Notices returns the messages shown on the account page, for example
+// warnings about expiring cards or failed Autoload payments.
+func (c *Client) Notices(ctx context.Context) ([]Notice, error) {
+	dashboardData, err := c.dashboardPage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return getNotices(bytes.NewReader(dashboardData))
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email sends each message by SMTP, with its attachments.
+type Email struct {
+	Host string
+	// Port defaults to 587. The connection is upgraded with STARTTLS if the
+	// server offers it.
+	Port int
+	// Username and Password, if set, are used to sign in to the server.
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (e Email) Notify(ctx context.Context, m Message) error {
+	if e.Host == "" || e.From == "" || len(e.To) == 0 {
+		return errors.New("notify: email needs a host, a from address and at least one recipient")
+	}
+	msg, err := e.message(m)
+	if err != nil {
+		return err
+	}
+	port := e.Port
+	if port == 0 {
+		port = 587
+	}
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(port))
+	// smtp.SendMail doesn't take a Context, so give up waiting for it
+	// instead; the send may still finish in the background.
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(addr, auth, e.From, e.To, msg) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("notify: sending email: %v", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("notify: sending email: %v", ctx.Err())
+	}
+}
+
+// message returns m as a MIME message, with the body as text and the
+// attachments base64 encoded.
+func (e Email) message(m Message) ([]byte, error) {
+	var boundary [12]byte
+	if _, err := rand.Read(boundary[:]); err != nil {
+		return nil, err
+	}
+	b := fmt.Sprintf("clipper-%x", boundary)
+	date := m.Time
+	if date.IsZero() {
+		date = time.Now()
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", e.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", b)
+	fmt.Fprintf(&buf, "--%s\r\n", b)
+	fmt.Fprintf(&buf, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&buf, "Content-Transfer-Encoding: base64\r\n\r\n")
+	writeBase64(&buf, []byte(m.Body))
+	for _, a := range m.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		fmt.Fprintf(&buf, "--%s\r\n", b)
+		fmt.Fprintf(&buf, "Content-Type: %s\r\n", contentType)
+		fmt.Fprintf(&buf, "Content-Disposition: %s\r\n", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+		fmt.Fprintf(&buf, "Content-Transfer-Encoding: base64\r\n\r\n")
+		writeBase64(&buf, a.Data)
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", b)
+	return buf.Bytes(), nil
+}
+
+// writeBase64 writes data base64 encoded, in lines of 76 characters.
+func writeBase64(buf *bytes.Buffer, data []byte) {
+	s := base64.StdEncoding.EncodeToString(data)
+	for len(s) > 76 {
+		buf.WriteString(s[:76] + "\r\n")
+		s = s[76:]
+	}
+	buf.WriteString(s + "\r\n")
+}
@@ -0,0 +1,143 @@
+// Package notify sends alerts, such as a low balance or unusual card
+// activity, to wherever you want to hear about them.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"time"
+)
+
+// A Message is a single alert.
+type Message struct {
+	// Kind identifies what the alert is about, for example "new_location".
+	Kind    string    `json:"kind"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	Time    time.Time `json:"time"`
+	// Attachments are files to send with the message, like a chart. Not
+	// every notifier can deliver them.
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// An Attachment is a file sent with a message. In JSON, Data is base64
+// encoded.
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// A Notifier delivers messages.
+type Notifier interface {
+	Notify(ctx context.Context, m Message) error
+}
+
+// Func adapts a function to a Notifier.
+type Func func(ctx context.Context, m Message) error
+
+func (f Func) Notify(ctx context.Context, m Message) error { return f(ctx, m) }
+
+// Multi sends each message to every notifier in it, and returns the errors
+// from any that failed.
+type Multi []Notifier
+
+func (ms Multi) Notify(ctx context.Context, m Message) error {
+	var errs []error
+	for _, n := range ms {
+		if err := n.Notify(ctx, m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Writer writes messages as text to W, for example os.Stderr.
+type Writer struct {
+	W io.Writer
+}
+
+func (w Writer) Notify(ctx context.Context, m Message) error {
+	var attached string
+	for _, a := range m.Attachments {
+		attached += fmt.Sprintf("(attached: %s, %d bytes)\n", a.Name, len(a.Data))
+	}
+	_, err := fmt.Fprintf(w.W, "[%s] %s\n%s\n%s\n", m.Kind, m.Subject, m.Body, attached)
+	return err
+}
+
+// Webhook POSTs each message as JSON to URL.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+func (w Webhook) Notify(ctx context.Context, m Message) error {
+	return postJSON(ctx, w.Client, w.URL, "webhook", m)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url, name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify: %s: %v", name, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notify: %s returned %s", name, resp.Status)
+	}
+	return nil
+}
+
+// Slack posts each message to a Slack channel through an incoming webhook
+// URL. Attachments aren't sent.
+type Slack struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s Slack) Notify(ctx context.Context, m Message) error {
+	text := "*" + m.Subject + "*"
+	if m.Body != "" {
+		text += "\n" + m.Body
+	}
+	return postJSON(ctx, s.Client, s.URL, "slack", map[string]string{"text": text})
+}
+
+// Command runs a program for each message, with the message as JSON on
+// standard input.
+type Command struct {
+	Path string
+	Args []string
+}
+
+func (c Command) Notify(ctx context.Context, m Message) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, c.Path, c.Args...)
+	cmd.Stdin = bytes.NewReader(data)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notify: running %s: %v: %s", c.Path, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
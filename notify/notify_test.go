@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestWebhook(t *testing.T) {
+	var got Message
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+	m := Message{Kind: "test", Subject: "hello", Attachments: []Attachment{{Name: "chart.png", ContentType: "image/png", Data: []byte{0x89, 'P'}}}}
+	if err := (Webhook{URL: s.URL}).Notify(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	if got.Kind != "test" || got.Subject != "hello" || len(got.Attachments) != 1 || string(got.Attachments[0].Data) != "\x89P" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestMulti(t *testing.T) {
+	var buf bytes.Buffer
+	fail := Func(func(ctx context.Context, m Message) error { return errors.New("boom") })
+	err := Multi{Writer{W: &buf}, fail}.Notify(context.Background(), Message{Kind: "k", Subject: "s", Body: "b"})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected the failing notifier's error, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "[k] s") {
+		t.Errorf("Writer didn't write the message: %q", buf.String())
+	}
+}
+
+func TestSlack(t *testing.T) {
+	var got map[string]string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+	if err := (Slack{URL: s.URL}).Notify(context.Background(), Message{Subject: "Low balance", Body: "$4.50 left"}); err != nil {
+		t.Fatal(err)
+	}
+	if got["text"] != "*Low balance*\n$4.50 left" {
+		t.Errorf("got %q", got["text"])
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	e := Email{From: "clipper@example.com", To: []string{"alice@example.com", "bob@example.com"}}
+	body := strings.Repeat("All aboard. ", 20)
+	data, err := e.message(Message{Subject: "Clipper digest", Body: body, Attachments: []Attachment{{Name: "digest.csv", ContentType: "text/csv", Data: []byte("a,b\n")}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msg.Header.Get("To"); got != "alice@example.com, bob@example.com" {
+		t.Errorf("To: got %q", got)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	var parts []string
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, p.FileName()+":"+string(decoded))
+	}
+	if len(parts) != 2 || parts[0] != ":"+body || parts[1] != "digest.csv:a,b\n" {
+		t.Errorf("got parts %q", parts)
+	}
+}
@@ -0,0 +1,89 @@
+package clipper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// An Option configures a Client.
+type Option func(*Client)
+
+// A Renderer loads a page in a browser, runs its scripts, and returns the
+// resulting HTML. cookies are the client's session cookies for the page.
+//
+// The headless package has a Renderer that drives Chrome.
+type Renderer interface {
+	Render(ctx context.Context, url string, cookies []*http.Cookie) ([]byte, error)
+}
+
+// WithRenderer makes the client fall back to r when a page it scrapes comes
+// back without the data it expects, because the site filled it in with
+// JavaScript. Rendering is much slower than a plain request, so it's only used
+// when the static page turns up empty.
+func WithRenderer(r Renderer) Option {
+	return func(c *Client) {
+		c.renderer = r
+	}
+}
+
+// WithTransport makes the client send requests with rt, for example to
+// count or log them. rt must not be nil.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.client.Transport = rt
+		c.transportOpts = nil
+	}
+}
+
+// What a Client did, in an Event.
+const (
+	EventLogin    = "login"
+	EventResume   = "resume"
+	EventDownload = "download"
+)
+
+// An Event is something a Client did on the Clipper website.
+type Event struct {
+	// Action is EventLogin, EventResume (for picking up a saved session
+	// instead of logging in; see WithSessionStore) or EventDownload.
+	Action string
+	// Card and Path are the card whose statement was downloaded, and the
+	// file it was saved to.
+	Card int64
+	Path string
+	// Err is why the action failed, or nil if it succeeded.
+	Err error
+}
+
+// WithEvents makes the client call fn each time it logs in or downloads a
+// statement, for example to keep an audit log. fn must not use the client.
+func WithEvents(fn func(Event)) Option {
+	return func(c *Client) {
+		c.events = fn
+	}
+}
+
+func (c *Client) event(e Event) {
+	if c.events != nil {
+		c.eventMu.Lock()
+		defer c.eventMu.Unlock()
+		c.events(e)
+	}
+}
+
+func (c *Client) render(ctx context.Context, rawurl string) ([]byte, error) {
+	if c.renderer == nil {
+		return nil, errors.New("clipper: no renderer configured")
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	var cookies []*http.Cookie
+	if c.client.Jar != nil {
+		cookies = c.client.Jar.Cookies(u)
+	}
+	return c.renderer.Render(ctx, rawurl, cookies)
+}
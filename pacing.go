@@ -0,0 +1,230 @@
+package clipper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// A Pacer spaces out requests to each host, so that a run over many cards or
+// accounts doesn't send the Clipper site a flood of them. Give several
+// clients the same Pacer, with WithPacer, to pace them together, as the
+// commands do for the accounts in a run; WithMinInterval gives a client one
+// of its own. A Pacer is safe to use from several goroutines.
+type Pacer struct {
+	limit rate.Limit
+	burst int
+
+	mu    sync.Mutex
+	hosts map[string]*rate.Limiter
+}
+
+// NewPacer returns a Pacer that lets burst requests to a host go at once, and
+// after that one every interval. A burst below 1 counts as 1.
+func NewPacer(interval time.Duration, burst int) *Pacer {
+	if burst < 1 {
+		burst = 1
+	}
+	limit := rate.Inf
+	if interval > 0 {
+		limit = rate.Every(interval)
+	}
+	return &Pacer{limit: limit, burst: burst, hosts: make(map[string]*rate.Limiter)}
+}
+
+// Wait blocks until a request to host can go, or ctx is done.
+func (p *Pacer) Wait(ctx context.Context, host string) error {
+	p.mu.Lock()
+	l, ok := p.hosts[host]
+	if !ok {
+		l = rate.NewLimiter(p.limit, p.burst)
+		p.hosts[host] = l
+	}
+	p.mu.Unlock()
+	return l.Wait(ctx)
+}
+
+// WithPacer makes the client wait for p before each request it sends,
+// including the redirects it follows. Pages a Renderer loads aren't paced.
+func WithPacer(p *Pacer) Option {
+	return func(c *Client) {
+		c.pacer = p
+	}
+}
+
+// DefaultRateLimit and DefaultBurst are how a client paces its requests to
+// clippercard.com unless it's told otherwise, with WithRateLimit,
+// WithMinInterval or WithPacer: a burst of DefaultBurst requests at once, and
+// after that one every DefaultRateLimit. That's slow enough for a loop over
+// many cards or accounts to stay clear of the Clipper site's blocking, and
+// costs a run over a card or two nothing. A client pointed at another site
+// with WithSiteURL, like a clippermock server, isn't paced by default.
+const (
+	DefaultRateLimit = 2 * time.Second
+	DefaultBurst     = 5
+)
+
+// WithRateLimit makes the client send at most one request to each host every
+// d, after a burst of as many as WithBurst allows, DefaultBurst by default,
+// instead of pacing by DefaultRateLimit. A d of 0 turns pacing off. NewClient
+// returns an error if d is negative, or if the client also has a Pacer from
+// WithPacer; give the Pacer the rate instead.
+func WithRateLimit(d time.Duration) Option {
+	return func(c *Client) {
+		c.minInterval = d
+		c.rateLimited = true
+	}
+}
+
+// WithMinInterval makes the client wait at least d between requests to each
+// host, after a burst of as many as WithBurst allows, 1 by default. NewClient
+// returns an error if d is negative, or if the client also has a Pacer from
+// WithPacer.
+func WithMinInterval(d time.Duration) Option {
+	return func(c *Client) {
+		c.minInterval = d
+	}
+}
+
+// WithBurst lets a client paced by WithMinInterval send n requests to a host
+// at once before it starts waiting. NewClient returns an error if n is
+// negative.
+func WithBurst(n int) Option {
+	return func(c *Client) {
+		c.burst = n
+	}
+}
+
+// pace sets up the pacing from WithPacer, WithRateLimit, WithMinInterval
+// and WithBurst, or else the default pacing, and the quota from WithQuota.
+func (c *Client) pace() error {
+	if c.minInterval < 0 {
+		return fmt.Errorf("clipper: minimum interval %v is negative", c.minInterval)
+	}
+	if c.burst < 0 {
+		return fmt.Errorf("clipper: burst %d is negative", c.burst)
+	}
+	burst := c.burst
+	if burst == 0 && c.rateLimited {
+		burst = DefaultBurst
+	}
+	switch {
+	case c.minInterval > 0:
+		if c.pacer != nil {
+			return errors.New("clipper: can't use WithRateLimit or WithMinInterval with WithPacer; pass the interval to NewPacer instead")
+		}
+		c.pacer = NewPacer(c.minInterval, burst)
+	case c.pacer == nil && !c.rateLimited && c.host == host:
+		if burst == 0 {
+			burst = DefaultBurst
+		}
+		c.pacer = NewPacer(DefaultRateLimit, burst)
+	}
+	if c.pacer != nil || c.quota != nil {
+		c.client.Transport = &pacedTransport{RoundTripper: c.client.Transport, pacer: c.pacer, quota: c.quota, account: c.username}
+	}
+	return nil
+}
+
+// pacedTransport takes a request from a Quota and waits for a Pacer, if
+// set, before each request.
+type pacedTransport struct {
+	http.RoundTripper
+	pacer   *Pacer
+	quota   Quota
+	account string
+}
+
+func (t *pacedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var err error
+	if t.quota != nil {
+		err = t.quota.Take(t.account, QuotaRequest)
+	}
+	if err == nil && t.pacer != nil {
+		timeout, _ := req.Context().Value(pacedTimeoutKey{}).(*pacedTimeout)
+		if timeout != nil {
+			timeout.pause()
+		}
+		err = t.pacer.Wait(req.Context(), req.URL.Host)
+		if timeout != nil {
+			timeout.resume()
+		}
+	}
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.RoundTripper.RoundTrip(req)
+}
+
+// errPacedTimeout is the cause of a context from withPacedTimeout that ran out
+// of time.
+var errPacedTimeout = errors.New("clipper: timed out")
+
+type pacedTimeoutKey struct{}
+
+// A pacedTimeout cancels a context once it has run for a while, not counting
+// the time its requests spent waiting for a Pacer, so that a card queued
+// behind others in a paced run gets as long as one at the front.
+type pacedTimeout struct {
+	cancel context.CancelCauseFunc
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	left    time.Duration
+	started time.Time
+	// waiting is how many requests are waiting for a Pacer now.
+	waiting int
+}
+
+// withPacedTimeout returns a copy of ctx that's canceled, with the cause
+// errPacedTimeout, after d plus however long its requests wait for the
+// client's Pacer.
+func withPacedTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	t := &pacedTimeout{cancel: cancel, left: d, started: time.Now()}
+	t.timer = time.AfterFunc(d, func() { cancel(errPacedTimeout) })
+	return context.WithValue(ctx, pacedTimeoutKey{}, t), func() {
+		t.timer.Stop()
+		cancel(nil)
+	}
+}
+
+// pause stops the clock while a request waits for a Pacer.
+func (t *pacedTimeout) pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.waiting++
+	if t.waiting > 1 {
+		return
+	}
+	if t.timer.Stop() {
+		t.left -= time.Since(t.started)
+	} else {
+		// It already ran out.
+		t.left = 0
+	}
+}
+
+// resume starts the clock again once no requests are waiting.
+func (t *pacedTimeout) resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.waiting--
+	if t.waiting > 0 {
+		return
+	}
+	if t.left <= 0 {
+		t.cancel(errPacedTimeout)
+		return
+	}
+	t.started = time.Now()
+	t.timer.Reset(t.left)
+}
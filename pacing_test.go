@@ -0,0 +1,128 @@
+package clipper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestPacer(t *testing.T) {
+	p := NewPacer(20*time.Millisecond, 2)
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := p.Wait(ctx, "www.clippercard.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Two go at once, then one every 20ms.
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("4 requests took %v, want at least 40ms", elapsed)
+	}
+	start = time.Now()
+	if err := p.Wait(ctx, "login.clippercard.com"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("another host waited %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := p.Wait(ctx, "www.clippercard.com"); err == nil {
+		t.Error("Wait with a canceled context: want an error")
+	}
+}
+
+func TestPacingOptions(t *testing.T) {
+	c, err := NewClient("me@example.com", "password", WithTransport(lockedTransport{}), WithMinInterval(time.Second), WithBurst(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, ok := c.client.Transport.(*pacedTransport); !ok || pt.pacer.burst != 3 {
+		t.Errorf("transport: got %#v", c.client.Transport)
+	}
+	for _, tt := range []struct {
+		opts     []Option
+		interval time.Duration
+		burst    int
+	}{
+		{nil, DefaultRateLimit, DefaultBurst},
+		{[]Option{WithBurst(2)}, DefaultRateLimit, 2},
+		{[]Option{WithRateLimit(time.Second)}, time.Second, DefaultBurst},
+		{[]Option{WithRateLimit(0)}, 0, 0},
+		{[]Option{WithSiteURL("http://127.0.0.1:7080")}, 0, 0},
+	} {
+		c, err := NewClient("me@example.com", "password", tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pt, ok := c.client.Transport.(*pacedTransport)
+		if tt.interval == 0 {
+			if ok {
+				t.Errorf("NewClient with %d options: paced by %+v, want no pacing", len(tt.opts), pt.pacer)
+			}
+			continue
+		}
+		if !ok || pt.pacer.limit != rate.Every(tt.interval) || pt.pacer.burst != tt.burst {
+			t.Errorf("NewClient with %d options: got transport %#v, want a request every %v after %d", len(tt.opts), c.client.Transport, tt.interval, tt.burst)
+		}
+	}
+	for _, opts := range [][]Option{
+		{WithRateLimit(-time.Second)},
+		{WithRateLimit(time.Second), WithPacer(NewPacer(time.Second, 1))},
+		{WithMinInterval(-time.Second)},
+		{WithMinInterval(time.Second), WithBurst(-1)},
+		{WithMinInterval(time.Second), WithPacer(NewPacer(time.Second, 1))},
+	} {
+		if _, err := NewClient("me@example.com", "password", opts...); err == nil {
+			t.Errorf("NewClient with %d options: want an error", len(opts))
+		}
+	}
+}
+
+func TestStatementTimeout(t *testing.T) {
+	defer func(d time.Duration) { statementTimeout = d }(statementTimeout)
+	statementTimeout = 150 * time.Millisecond
+
+	// Each card fits in the timeout, though the run as a whole doesn't.
+	portal := &fakePortal{pdfs: testStatements(t), delay: 100 * time.Millisecond}
+	c, err := NewClient("me@example.com", "password", WithSiteAdapter(portal), WithTransport(lockedTransport{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DownloadRange(context.Background(), t.TempDir(), DateRange{}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	portal.delay = 200 * time.Millisecond
+	err = c.DownloadRange(context.Background(), t.TempDir(), DateRange{}, false)
+	if cerrs := CardErrors(err); len(cerrs) != 2 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("DownloadRange with slow statements: got %v, want a deadline error for each card", err)
+	}
+}
+
+func TestStatementTimeoutPaced(t *testing.T) {
+	defer func(d time.Duration) { statementTimeout = d }(statementTimeout)
+	statementTimeout = 100 * time.Millisecond
+
+	// Both cards go at once, but only one request at a time does, so the
+	// second card waits for the Pacer longer than its timeout. That wait
+	// doesn't count against it.
+	portal := &fakePortal{pdfs: testStatements(t), paced: true}
+	c, err := NewClient("me@example.com", "password", WithSiteAdapter(portal), WithTransport(lockedTransport{}),
+		WithPacer(NewPacer(250*time.Millisecond, 1)), WithConcurrency(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := c.DownloadRange(context.Background(), t.TempDir(), DateRange{}, false); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("DownloadRange took %v, want the second card to wait for the Pacer", elapsed)
+	}
+}
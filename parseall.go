@@ -0,0 +1,73 @@
+package clipper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// ParseFile parses the PDF statement at path.
+func ParseFile(path string) (TransactionData, error) {
+	return ParseFileContext(context.Background(), path)
+}
+
+// ParseFileContext is like ParseFile, but gives up once ctx is done; see
+// ParsePDFContext.
+func ParseFileContext(ctx context.Context, path string) (_ TransactionData, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return TransactionData{}, err
+	}
+	defer f.Close()
+	// unidoc panics on some malformed PDFs; one bad statement in an archive
+	// shouldn't take down a program reading the rest.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("clipper: parsing %s: panic: %v", path, r)
+		}
+	}()
+	data, err := ParsePDFContext(ctx, f)
+	if err != nil {
+		return TransactionData{}, fmt.Errorf("clipper: parsing %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// ParseAll parses the PDF statements at files, up to workers at a time, or
+// one per CPU if workers is 0 or less. results[i] and errs[i] are the result
+// of parsing files[i]; a file that can't be read or parsed doesn't stop the
+// others. Once ctx is done, the files that haven't been started fail with
+// ctx.Err(), and the ones being parsed stop at the next page.
+func ParseAll(ctx context.Context, files []string, workers int) (results []TransactionData, errs []error) {
+	results = make([]TransactionData, len(files))
+	errs = make([]error, len(files))
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				results[i], errs[i] = ParseFileContext(ctx, files[i])
+			}
+		}()
+	}
+	for i := range files {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results, errs
+}
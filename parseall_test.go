@@ -0,0 +1,89 @@
+package clipper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseAll(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.pdf")
+	if err := os.WriteFile(bad, []byte("%PDF-1.4\nnot really\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	long := filepath.Join(dir, "long.pdf")
+	if err := os.WriteFile(long, longStatement(t, 5), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := []string{
+		"testdata/transactions.pdf",
+		bad,
+		"testdata/no-transactions.pdf",
+		filepath.Join(dir, "missing.pdf"),
+		long,
+		"testdata/transactions.pdf",
+	}
+	results, errs := ParseAll(context.Background(), files, 3)
+	if len(results) != len(files) || len(errs) != len(files) {
+		t.Fatalf("got %d results and %d errors for %d files", len(results), len(errs), len(files))
+	}
+	for i, f := range files {
+		if wantErr := i == 1 || i == 3; (errs[i] != nil) != wantErr {
+			t.Errorf("%s: got error %v", f, errs[i])
+		}
+	}
+	if !errors.Is(errs[3], os.ErrNotExist) {
+		t.Errorf("missing file: got %v, want a not-exist error", errs[3])
+	}
+	want, err := ParseFile("testdata/transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].AccountNumber != want.AccountNumber || len(results[0].Transactions) != len(want.Transactions) ||
+		len(results[5].Transactions) != len(want.Transactions) {
+		t.Errorf("got %d and %d records, want %d", len(results[0].Transactions), len(results[5].Transactions), len(want.Transactions))
+	}
+	if len(results[4].Transactions) <= len(want.Transactions) {
+		t.Errorf("long statement: got %d records", len(results[4].Transactions))
+	}
+}
+
+func TestParseAllCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	files := []string{"testdata/transactions.pdf", "testdata/no-transactions.pdf"}
+	_, errs := ParseAll(ctx, files, 0)
+	for i, err := range errs {
+		if err != context.Canceled {
+			t.Errorf("%s: got %v, want context.Canceled", files[i], err)
+		}
+	}
+	if results, errs := ParseAll(ctx, nil, 4); len(results) != 0 || len(errs) != 0 {
+		t.Errorf("no files: got %v, %v", results, errs)
+	}
+}
+
+func BenchmarkParseAll(b *testing.B) {
+	dir := b.TempDir()
+	data := longStatement(b, 4)
+	files := make([]string, 60)
+	for i := range files {
+		files[i] = filepath.Join(dir, fmt.Sprintf("statement-%02d.pdf", i))
+		if err := os.WriteFile(files[i], data, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, errs := ParseAll(context.Background(), files, 0)
+		for _, err := range errs {
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
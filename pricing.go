@@ -0,0 +1,125 @@
+package clipper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// Pricing holds the fees and limits that apply when adding value to a card,
+// as shown on the add value pages. Fields the site doesn't show are zero.
+type Pricing struct {
+	// CardFeeCents is the fee for a new card.
+	CardFeeCents Money
+	// MinimumLoadCents is the smallest amount of cash value that can be added
+	// at once.
+	MinimumLoadCents Money
+	// MaximumBalanceCents is the most cash value a card can hold.
+	MaximumBalanceCents Money
+	// SurchargeCents is a flat fee added to each payment.
+	SurchargeCents Money
+	// SurchargePercent is a percentage of each payment added as a fee, for
+	// example 2.5 for 2.5%.
+	SurchargePercent float64
+}
+
+// Validate returns an error if adding amount to a card with the given balance
+// would be rejected.
+func (p Pricing) Validate(amount, balance Money) error {
+	if amount <= 0 {
+		return fmt.Errorf("clipper: amount to add must be positive, got %s", amount)
+	}
+	if p.MinimumLoadCents > 0 && amount < p.MinimumLoadCents {
+		return fmt.Errorf("clipper: amount %s is below the minimum load of %s", amount, p.MinimumLoadCents)
+	}
+	if p.MaximumBalanceCents > 0 && balance+amount > p.MaximumBalanceCents {
+		return fmt.Errorf("clipper: adding %s to a balance of %s would exceed the maximum balance of %s",
+			amount, balance, p.MaximumBalanceCents)
+	}
+	return nil
+}
+
+// TotalCents returns what a payment of amount costs including surcharges.
+func (p Pricing) TotalCents(amount Money) Money {
+	return amount + p.SurchargeCents + amount.Mul(p.SurchargePercent/100)
+}
+
+var (
+	dollarRx         = regexp.MustCompile(`\$\s?[\d,]+(?:\.\d{1,2})?`)
+	percentRx        = regexp.MustCompile(`([\d.]+)\s?%`)
+	minimumLoadRx    = regexp.MustCompile(`(?i)minimum (?:load|amount|value|purchase)[^$]{0,40}(\$\s?[\d,]+(?:\.\d{2})?)`)
+	maximumBalanceRx = regexp.MustCompile(`(?i)maximum (?:balance|card value|cash value|value)[^$]{0,40}(\$\s?[\d,]+(?:\.\d{2})?)`)
+)
+
+// getPricing parses the add value page. It reads labeled fields first and
+// falls back to sentences like "minimum load amount is $1.25".
+func getPricing(r io.Reader) (Pricing, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Pricing{}, err
+	}
+	values, err := labelValues(bytes.NewReader(data))
+	if err != nil {
+		return Pricing{}, err
+	}
+	var p Pricing
+	setCents := func(dst *Money, labels ...string) {
+		for _, label := range labels {
+			if m := dollarRx.FindString(values[label]); m != "" {
+				if cents, err := ParseMoney(m); err == nil {
+					*dst = cents
+					return
+				}
+			}
+		}
+	}
+	setCents(&p.CardFeeCents, "Card Fee:", "Card fee:", "New Card Fee:")
+	setCents(&p.MinimumLoadCents, "Minimum Load:", "Minimum Load Amount:", "Minimum Amount:")
+	setCents(&p.MaximumBalanceCents, "Maximum Balance:", "Maximum Card Value:", "Maximum Cash Value:")
+	for _, label := range []string{"Convenience Fee:", "Service Fee:", "Processing Fee:", "Surcharge:"} {
+		val, ok := values[label]
+		if !ok {
+			continue
+		}
+		if m := percentRx.FindStringSubmatch(val); m != nil {
+			if pct, err := strconv.ParseFloat(m[1], 64); err == nil {
+				p.SurchargePercent = pct
+			}
+		}
+		if m := dollarRx.FindString(val); m != "" {
+			if cents, err := ParseMoney(m); err == nil {
+				p.SurchargeCents = cents
+			}
+		}
+		break
+	}
+
+	text := string(data)
+	if p.MinimumLoadCents == 0 {
+		if m := minimumLoadRx.FindStringSubmatch(text); m != nil {
+			p.MinimumLoadCents, _ = ParseMoney(m[1])
+		}
+	}
+	if p.MaximumBalanceCents == 0 {
+		if m := maximumBalanceRx.FindStringSubmatch(text); m != nil {
+			p.MaximumBalanceCents, _ = ParseMoney(m[1])
+		}
+	}
+	return p, nil
+}
+
+// Pricing returns the fees and limits for adding value to cards on the
+// account.
+func (c *Client) Pricing(ctx context.Context) (Pricing, error) {
+	if err := c.ensureClipperWeb(ctx); err != nil {
+		return Pricing{}, err
+	}
+	body, err := c.getPage(ctx, c.host+"/ClipperWeb/addValue.html", c.host+"/ClipperWeb/account.html", false)
+	if err != nil {
+		return Pricing{}, fmt.Errorf("could not get add value page: %v", err)
+	}
+	return getPricing(bytes.NewReader(body))
+}
@@ -0,0 +1,35 @@
+package clipper
+
+import (
+	"strings"
+	"testing"
+)
+
+var addValuePage = `<html><body>
+<div class="row"><div class="col">Card Fee:</div><div class="col">$3.00</div></div>
+<div class="row"><div class="col">Convenience Fee:</div><div class="col">2.5% of the payment</div></div>
+<p>The minimum load amount is $1.25. Cards can hold a maximum balance of $300.00.</p>
+</body></html>`
+
+func TestGetPricing(t *testing.T) {
+	p, err := getPricing(strings.NewReader(addValuePage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Pricing{CardFeeCents: 300, MinimumLoadCents: 125, MaximumBalanceCents: 30000, SurchargePercent: 2.5}
+	if p != want {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+	if err := p.Validate(100, 0); err == nil {
+		t.Errorf("expected error for load below minimum")
+	}
+	if err := p.Validate(5000, 26000); err == nil {
+		t.Errorf("expected error for exceeding maximum balance")
+	}
+	if err := p.Validate(2000, 1000); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := p.TotalCents(2000); got != 2050 {
+		t.Errorf("TotalCents(2000): got %d, want 2050", got)
+	}
+}
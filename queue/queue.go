@@ -0,0 +1,403 @@
+// Package queue runs recurring background jobs, like downloading each
+// account's statements, one at a time.
+//
+// Each job runs on its own schedule. A job that fails is retried with
+// exponential backoff, up to MaxAttempts times, before it waits for its next
+// scheduled run. Jobs start at most once every Spacing, so that a server with
+// many accounts doesn't log in to Clipper many times in a row, and a job that
+// fails because Clipper locked the account isn't retried early, since more
+// logins would keep it locked. The queue saves each job's state and recent
+// history with a Saver after every change, so they survive a restart.
+package queue
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/handlers"
+	"golang.org/x/time/rate"
+)
+
+// HistoryLen is the number of runs each job remembers.
+const HistoryLen = 20
+
+// A State describes what a job is doing, or how its last run went.
+type State string
+
+const (
+	// StateScheduled jobs are waiting for their next run. The last run, if
+	// any, succeeded.
+	StateScheduled State = "scheduled"
+	StateRunning   State = "running"
+	// StateRetrying jobs failed and will be retried soon.
+	StateRetrying State = "retrying"
+	// StateFailed jobs failed too many times, or in a way that retrying
+	// won't fix, and are waiting for their next scheduled run.
+	StateFailed State = "failed"
+)
+
+// A Run is one run of a job.
+type Run struct {
+	Start  time.Time `json:"start"`
+	Finish time.Time `json:"finish"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// A Job is a recurring job.
+type Job struct {
+	// Name is passed to the queue's Func.
+	Name  string        `json:"name"`
+	Every time.Duration `json:"every"`
+	State State         `json:"state"`
+	// Failures is the number of runs that have failed since the last one
+	// that succeeded.
+	Failures    int       `json:"failures"`
+	LastRun     time.Time `json:"last_run,omitempty"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	NextRun     time.Time `json:"next_run"`
+	// History holds the last HistoryLen runs, oldest first.
+	History []Run `json:"history"`
+}
+
+// A Func runs the job called name.
+type Func func(ctx context.Context, name string) error
+
+// ErrBusy can be returned by a Func that can't run yet, for example because
+// other work is in progress. The job is tried again after Spacing, without
+// counting as a failure.
+var ErrBusy = errors.New("queue: busy")
+
+// A Saver saves the queue's jobs, and loads them when the queue is created.
+// *store.Store is a Saver.
+type Saver interface {
+	LoadJobs(v interface{}) error
+	SaveJobs(v interface{}) error
+}
+
+// A Queue runs jobs in the background. Set its fields before calling Start.
+type Queue struct {
+	// Spacing is the least time between the starts of two jobs; it defaults
+	// to a minute.
+	Spacing time.Duration
+	// MaxAttempts is how many times in a row a job may fail before it waits
+	// for its next scheduled run; it defaults to 5.
+	MaxAttempts int
+	// Backoff is how long to wait before retrying a failed job the first
+	// time. It doubles with each failure, up to the job's interval, and
+	// defaults to a minute.
+	Backoff time.Duration
+
+	saver Saver
+	fn    Func
+
+	mu sync.Mutex
+	// jobs are the jobs added with Add, and saved the jobs loaded from the
+	// Saver, which Add picks up where they left off.
+	jobs    map[string]*Job
+	saved   map[string]*Job
+	started bool
+	wake    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// New returns a Queue that runs jobs with fn, and loads and saves them with
+// s.
+func New(s Saver, fn Func) (*Queue, error) {
+	var saved []*Job
+	if err := s.LoadJobs(&saved); err != nil {
+		return nil, err
+	}
+	q := &Queue{
+		saver:   s,
+		fn:      fn,
+		jobs:    make(map[string]*Job),
+		saved:   make(map[string]*Job),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for _, j := range saved {
+		q.saved[j.Name] = j
+	}
+	return q, nil
+}
+
+func (q *Queue) spacing() time.Duration {
+	if q.Spacing > 0 {
+		return q.Spacing
+	}
+	return time.Minute
+}
+
+// Add schedules the job called name to run every interval. If the queue
+// saved the job's state before a restart, the job keeps its history and its
+// next run; otherwise it runs as soon as possible. Jobs that were saved but
+// aren't added again are forgotten the next time the queue saves.
+func (q *Queue) Add(name string, every time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[name]
+	if !ok {
+		j, ok = q.saved[name]
+	}
+	if !ok {
+		j = &Job{Name: name, State: StateScheduled, NextRun: time.Now(), History: []Run{}}
+	}
+	if j.State == StateRunning {
+		// The server stopped while the job was running.
+		j.State = StateRetrying
+		j.NextRun = time.Now()
+	}
+	j.Every = every
+	if next := time.Now().Add(every); j.NextRun.After(next) {
+		j.NextRun = next
+	}
+	q.jobs[name] = j
+	q.saveLocked()
+	q.notify()
+}
+
+// RunNow moves the job called name to the front of the queue. It returns
+// false if there's no such job.
+func (q *Queue) RunNow(name string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[name]
+	if !ok {
+		return false
+	}
+	if j.State != StateRunning {
+		j.NextRun = time.Now()
+		q.saveLocked()
+		q.notify()
+	}
+	return true
+}
+
+// Jobs returns the queue's jobs, ordered by name.
+func (q *Queue) Jobs() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		jobs = append(jobs, copyJob(j))
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].Name < jobs[k].Name })
+	return jobs
+}
+
+// Job returns the job called name.
+func (q *Queue) Job(name string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[name]
+	if !ok {
+		return Job{}, false
+	}
+	return copyJob(j), true
+}
+
+func copyJob(j *Job) Job {
+	c := *j
+	c.History = append([]Run{}, j.History...)
+	return c
+}
+
+// notify wakes the queue's goroutine to look at the schedule again.
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// saveLocked saves the jobs; q.mu must be held.
+func (q *Queue) saveLocked() {
+	jobs := make([]*Job, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].Name < jobs[k].Name })
+	if err := q.saver.SaveJobs(jobs); err != nil {
+		handlers.Logger.Warn("error saving job queue", "err", err)
+	}
+}
+
+// Start runs jobs in the background until Stop is called.
+func (q *Queue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.started {
+		return
+	}
+	q.started = true
+	go q.loop()
+}
+
+// Stop stops the queue from starting jobs, and waits for a running job to
+// finish or for ctx to be done.
+func (q *Queue) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	started := q.started
+	select {
+	case <-q.stop:
+	default:
+		close(q.stop)
+	}
+	q.mu.Unlock()
+	if !started {
+		return nil
+	}
+	select {
+	case <-q.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// next returns the job that should run next and how long until it should.
+// It returns nil if there are no jobs.
+func (q *Queue) next() (*Job, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var next *Job
+	for _, j := range q.jobs {
+		if next == nil || j.NextRun.Before(next.NextRun) || (j.NextRun.Equal(next.NextRun) && j.Name < next.Name) {
+			next = j
+		}
+	}
+	if next == nil {
+		return nil, 0
+	}
+	return next, time.Until(next.NextRun)
+}
+
+func (q *Queue) loop() {
+	defer close(q.stopped)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-q.stop
+		cancel()
+	}()
+	limiter := rate.NewLimiter(rate.Every(q.spacing()), 1)
+	for {
+		j, wait := q.next()
+		if j == nil || wait > 0 {
+			if !q.sleep(j != nil, wait) {
+				return
+			}
+			continue
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return
+		}
+		// The schedule may have changed while we waited.
+		if j2, wait := q.next(); j2 != j || wait > 0 {
+			continue
+		}
+		q.run(j)
+	}
+}
+
+// sleep waits for the schedule to change or, if timed is set, for d to
+// pass. It returns false if the queue was stopped.
+func (q *Queue) sleep(timed bool, d time.Duration) bool {
+	var timer <-chan time.Time
+	if timed {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		timer = t.C
+	}
+	select {
+	case <-q.stop:
+		return false
+	case <-q.wake:
+	case <-timer:
+	}
+	return true
+}
+
+// run runs j and records how it went.
+func (q *Queue) run(j *Job) {
+	q.mu.Lock()
+	state := j.State
+	start := time.Now()
+	j.State = StateRunning
+	q.saveLocked()
+	q.mu.Unlock()
+
+	err := q.fn(context.Background(), j.Name)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	finish := time.Now()
+	if errors.Is(err, ErrBusy) {
+		j.State = state
+		j.NextRun = finish.Add(q.spacing())
+		q.saveLocked()
+		return
+	}
+	j.LastRun = start
+	r := Run{Start: start, Finish: finish}
+	if err == nil {
+		j.State = StateScheduled
+		j.Failures = 0
+		j.LastSuccess = finish
+		j.LastError = ""
+		j.NextRun = start.Add(j.Every)
+	} else {
+		r.Error = err.Error()
+		j.Failures++
+		j.LastError = r.Error
+		if delay, ok := q.retryAfter(j, err); ok {
+			j.State = StateRetrying
+			j.NextRun = finish.Add(delay)
+		} else {
+			j.State = StateFailed
+			j.NextRun = start.Add(j.Every)
+		}
+	}
+	j.History = append(j.History, r)
+	if len(j.History) > HistoryLen {
+		j.History = j.History[len(j.History)-HistoryLen:]
+	}
+	q.saveLocked()
+}
+
+// retryAfter returns how long to wait before retrying j, which just failed
+// with err, or false if it shouldn't be retried before its next scheduled
+// run.
+func (q *Queue) retryAfter(j *Job, err error) (time.Duration, bool) {
+	if errors.Is(err, clipper.ErrAccountLocked) || errors.Is(err, clipper.ErrPasswordResetRequired) {
+		return 0, false
+	}
+	max := q.MaxAttempts
+	if max < 1 {
+		max = 5
+	}
+	// Failures counts up across scheduled runs; each scheduled run gets max
+	// attempts.
+	attempt := j.Failures % max
+	if attempt == 0 {
+		return 0, false
+	}
+	delay := q.Backoff
+	if delay <= 0 {
+		delay = time.Minute
+	}
+	for i := 1; i < attempt && delay < j.Every; i++ {
+		delay *= 2
+	}
+	if delay > j.Every {
+		delay = j.Every
+	}
+	return delay, true
+}
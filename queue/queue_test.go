@@ -0,0 +1,182 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper"
+)
+
+// memSaver saves jobs as JSON in memory.
+type memSaver struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (m *memSaver) LoadJobs(v interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.data == nil {
+		return nil
+	}
+	return json.Unmarshal(m.data, v)
+}
+
+func (m *memSaver) SaveJobs(v interface{}) error {
+	data, err := json.Marshal(v)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = data
+	return err
+}
+
+// waitFor waits for the job called name to satisfy ok.
+func waitFor(t *testing.T, q *Queue, name string, ok func(Job) bool) Job {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		if j, _ := q.Job(name); ok(j) {
+			return j
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	j, _ := q.Job(name)
+	t.Fatalf("job %q: gave up waiting, got %+v", name, j)
+	return j
+}
+
+func newQueue(t *testing.T, s Saver, fn Func) *Queue {
+	t.Helper()
+	q, err := New(s, fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Spacing = time.Millisecond
+	q.Backoff = time.Millisecond
+	t.Cleanup(func() { q.Stop(context.Background()) })
+	return q
+}
+
+func TestRetry(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	q := newQueue(t, new(memSaver), func(ctx context.Context, name string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls <= 2 {
+			return fmt.Errorf("attempt %d failed", calls)
+		}
+		return nil
+	})
+	q.Add("alice", time.Hour)
+	q.Start()
+	j := waitFor(t, q, "alice", func(j Job) bool { return !j.LastSuccess.IsZero() })
+	if j.State != StateScheduled || j.Failures != 0 || j.LastError != "" {
+		t.Errorf("after success: got %+v", j)
+	}
+	if len(j.History) != 3 || j.History[0].Error != "attempt 1 failed" || j.History[2].Error != "" {
+		t.Errorf("history: got %+v", j.History)
+	}
+	if d := time.Until(j.NextRun); d < 59*time.Minute {
+		t.Errorf("next run in %v, want about an hour", d)
+	}
+}
+
+func TestGiveUp(t *testing.T) {
+	q := newQueue(t, new(memSaver), func(ctx context.Context, name string) error {
+		if name == "locked" {
+			return fmt.Errorf("logging in: %w", clipper.ErrAccountLocked)
+		}
+		return errors.New("no statements")
+	})
+	q.MaxAttempts = 3
+	q.Add("bob", time.Hour)
+	q.Add("locked", time.Hour)
+	q.Start()
+	j := waitFor(t, q, "bob", func(j Job) bool { return j.State == StateFailed })
+	if j.Failures != 3 || len(j.History) != 3 {
+		t.Errorf("bob: got %d failures and %d runs, want 3", j.Failures, len(j.History))
+	}
+	j = waitFor(t, q, "locked", func(j Job) bool { return j.State == StateFailed })
+	if j.Failures != 1 {
+		t.Errorf("locked account retried: got %d failures, want 1", j.Failures)
+	}
+	if d := time.Until(j.NextRun); d < 59*time.Minute {
+		t.Errorf("locked account runs again in %v, want about an hour", d)
+	}
+}
+
+func TestBusy(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	q := newQueue(t, new(memSaver), func(ctx context.Context, name string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			return ErrBusy
+		}
+		return nil
+	})
+	q.Add("alice", time.Hour)
+	q.Start()
+	j := waitFor(t, q, "alice", func(j Job) bool { return !j.LastSuccess.IsZero() })
+	if len(j.History) != 1 || j.Failures != 0 {
+		t.Errorf("a busy run was recorded: got %+v", j)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	q := &Queue{MaxAttempts: 4, Backoff: time.Minute}
+	for _, tt := range []struct {
+		failures int
+		want     time.Duration
+		retry    bool
+	}{
+		{1, time.Minute, true},
+		{2, 2 * time.Minute, true},
+		{3, 4 * time.Minute, true},
+		{4, 0, false},
+		{5, time.Minute, true},
+	} {
+		j := &Job{Every: time.Hour, Failures: tt.failures}
+		got, retry := q.retryAfter(j, errors.New("oops"))
+		if got != tt.want || retry != tt.retry {
+			t.Errorf("%d failures: got %v, %t; want %v, %t", tt.failures, got, retry, tt.want, tt.retry)
+		}
+	}
+	if got, _ := q.retryAfter(&Job{Every: 3 * time.Minute, Failures: 3}, errors.New("oops")); got != 3*time.Minute {
+		t.Errorf("backoff should stop at the job's interval, got %v", got)
+	}
+}
+
+func TestPersist(t *testing.T) {
+	s := new(memSaver)
+	q := newQueue(t, s, func(ctx context.Context, name string) error { return nil })
+	q.Add("alice", time.Hour)
+	q.Add("bob", time.Hour)
+	q.Start()
+	before := waitFor(t, q, "alice", func(j Job) bool { return !j.LastSuccess.IsZero() })
+	waitFor(t, q, "bob", func(j Job) bool { return !j.LastSuccess.IsZero() })
+	if err := q.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	q2 := newQueue(t, s, func(ctx context.Context, name string) error { return nil })
+	q2.Add("alice", time.Hour)
+	after, ok := q2.Job("alice")
+	if !ok || len(after.History) != 1 || !after.NextRun.Equal(before.NextRun) {
+		t.Errorf("after a restart: got %+v, want %+v", after, before)
+	}
+	if _, ok := q2.Job("bob"); ok {
+		t.Errorf("bob wasn't added again, but is still a job")
+	}
+	if q2.RunNow("carol") {
+		t.Errorf("RunNow(carol): got true for a job that doesn't exist")
+	}
+}
@@ -0,0 +1,41 @@
+package clipper
+
+import "errors"
+
+// What a Quota counts.
+const (
+	QuotaRequest  = "request"
+	QuotaDownload = "download"
+)
+
+// ErrQuotaExceeded is wrapped by the error a Quota returns when an account
+// has used up its requests or downloads for the day.
+var ErrQuotaExceeded = errors.New("clipper: daily quota used up")
+
+// A Quota counts the requests a client makes and the statements it
+// downloads for each account, so that runs that share it, like a library
+// user's and the command line's, can stay under Clipper's limits between
+// them. The quota package's Tracker is one, kept in a file.
+type Quota interface {
+	// Take counts one of kind, QuotaRequest or QuotaDownload, for
+	// account. If the account has none of kind left today, Take returns an
+	// error wrapping ErrQuotaExceeded and doesn't count it.
+	Take(account, kind string) error
+}
+
+// WithQuota makes the client take from q for each request it sends,
+// including the redirects it follows, and for each statement it downloads,
+// and fail once q says the account's quota is used up.
+func WithQuota(q Quota) Option {
+	return func(c *Client) {
+		c.quota = q
+	}
+}
+
+// takeDownload takes a download from the client's Quota, if it has one.
+func (c *Client) takeDownload() error {
+	if c.quota == nil {
+		return nil
+	}
+	return c.quota.Take(c.username, QuotaDownload)
+}
@@ -0,0 +1,236 @@
+// Package quota keeps count of the requests and statement downloads made for
+// each Clipper account each day, in files in a state directory, so that
+// every program that uses the same directory, like a scheduled download and
+// the library in a script, stays under the account's daily limits together.
+//
+// A Tracker is a clipper.Quota; pass it to clipper.WithQuota. Before starting
+// a batch, check what's left with Remaining.
+//
+// Days are Pacific calendar days, like Clipper's. Each day's counts are kept
+// in their own file, which programs only append to, so several can share a
+// directory; two racing for the last of a quota can go over it by one.
+package quota
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinburke/clipper"
+)
+
+// Limits are the requests and downloads an account can make in a day. A
+// zero field means no limit.
+type Limits struct {
+	Requests  int
+	Downloads int
+}
+
+// DefaultLimits are the limits a Tracker starts with: enough for a few runs a
+// day over an account with several cards, with room to spare below the point
+// where Clipper starts turning requests down.
+var DefaultLimits = Limits{Requests: 300, Downloads: 20}
+
+// Usage is what an account has used, or has left, in a day. A negative field
+// in what's left means no limit.
+type Usage struct {
+	Requests  int `json:"requests"`
+	Downloads int `json:"downloads"`
+}
+
+// keepDays is how many days of counts a Tracker keeps.
+const keepDays = 7
+
+// A Tracker counts requests and downloads in a directory. It's safe to use
+// from several goroutines.
+type Tracker struct {
+	// Limits are the daily limits Take enforces.
+	Limits Limits
+
+	dir string
+	now func() time.Time
+	mu  sync.Mutex
+}
+
+// DefaultDir returns the directory for a Tracker to use: $CLIPPER_STATE_DIR
+// if it's set, or else "clipper" in $XDG_STATE_HOME (~/.local/state by
+// default) on Unix systems, or in the user's configuration directory on
+// macOS and Windows.
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("CLIPPER_STATE_DIR"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "clipper"), nil
+	}
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "clipper"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "clipper"), nil
+}
+
+// Open returns a Tracker with DefaultLimits that keeps its counts in dir,
+// making dir if it doesn't exist, and removes counts older than a week.
+func Open(dir string) (*Tracker, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	t := &Tracker{Limits: DefaultLimits, dir: dir, now: time.Now}
+	if err := t.prune(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// An entry is one line of a day's file.
+type entry struct {
+	Account string `json:"account"`
+	Kind    string `json:"kind"`
+}
+
+// accountKey is how the files name account: by a hash, so that they don't
+// hold anyone's email address.
+func accountKey(account string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(account)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// dayFile returns the file for the Pacific day now falls on.
+func (t *Tracker) dayFile(now time.Time) string {
+	return filepath.Join(t.dir, "quota-"+now.In(clipper.Pacific).Format("2006-01-02")+".jsonl")
+}
+
+// Used returns what account has used today.
+func (t *Tracker) Used(account string) (Usage, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.used(account, t.now())
+}
+
+func (t *Tracker) used(account string, now time.Time) (Usage, error) {
+	var u Usage
+	f, err := os.Open(t.dayFile(now))
+	if errors.Is(err, fs.ErrNotExist) {
+		return u, nil
+	}
+	if err != nil {
+		return u, err
+	}
+	defer f.Close()
+	key := accountKey(account)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var e entry
+		// Skip a line another program is still writing.
+		if json.Unmarshal(s.Bytes(), &e) != nil || e.Account != key {
+			continue
+		}
+		switch e.Kind {
+		case clipper.QuotaRequest:
+			u.Requests++
+		case clipper.QuotaDownload:
+			u.Downloads++
+		}
+	}
+	return u, s.Err()
+}
+
+// Remaining returns what account has left today under t.Limits. A negative
+// field means no limit.
+func (t *Tracker) Remaining(account string) (Usage, error) {
+	u, err := t.Used(account)
+	if err != nil {
+		return Usage{}, err
+	}
+	left := func(limit, used int) int {
+		if limit <= 0 {
+			return -1
+		}
+		return max(limit-used, 0)
+	}
+	return Usage{Requests: left(t.Limits.Requests, u.Requests), Downloads: left(t.Limits.Downloads, u.Downloads)}, nil
+}
+
+// Take counts one of kind, clipper.QuotaRequest or clipper.QuotaDownload, for
+// account today. If that would go over t.Limits, it returns an error wrapping
+// clipper.ErrQuotaExceeded instead.
+func (t *Tracker) Take(account, kind string) error {
+	var limit int
+	switch kind {
+	case clipper.QuotaRequest:
+		limit = t.Limits.Requests
+	case clipper.QuotaDownload:
+		limit = t.Limits.Downloads
+	default:
+		return fmt.Errorf("quota: unknown kind %q", kind)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	if limit > 0 {
+		u, err := t.used(account, now)
+		if err != nil {
+			return err
+		}
+		used := u.Requests
+		if kind == clipper.QuotaDownload {
+			used = u.Downloads
+		}
+		if used >= limit {
+			return fmt.Errorf("%w: %s has made %d of its %d %ss today", clipper.ErrQuotaExceeded, account, used, limit, kind)
+		}
+	}
+	line, err := json.Marshal(entry{Account: accountKey(account), Kind: kind})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(t.dayFile(now), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	// One write per line, so that lines from programs sharing the file
+	// don't interleave.
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// prune removes the files for days more than a week ago.
+func (t *Tracker) prune() error {
+	files, err := filepath.Glob(filepath.Join(t.dir, "quota-*.jsonl"))
+	if err != nil {
+		return err
+	}
+	oldest := t.dayFile(t.now().AddDate(0, 0, -keepDays))
+	for _, f := range files {
+		// The names sort by date.
+		if f < oldest {
+			if err := os.Remove(f); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var _ clipper.Quota = (*Tracker)(nil)
@@ -0,0 +1,95 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clippermock"
+)
+
+func TestTracker(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 3, 1, 23, 0, 0, 0, clipper.Pacific)
+	open := func() *Tracker {
+		tr, err := Open(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr.Limits = Limits{Requests: 3, Downloads: 1}
+		tr.now = func() time.Time { return now }
+		return tr
+	}
+	a, b := open(), open()
+	for i := 0; i < 2; i++ {
+		if err := a.Take("me@example.com", clipper.QuotaRequest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Another program sharing the directory sees a's requests.
+	if err := b.Take("me@example.com", clipper.QuotaRequest); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Take("me@example.com", clipper.QuotaRequest); !errors.Is(err, clipper.ErrQuotaExceeded) {
+		t.Errorf("fourth request: got %v, want ErrQuotaExceeded", err)
+	}
+	if err := a.Take("you@example.com", clipper.QuotaDownload); err != nil {
+		t.Errorf("another account: %v", err)
+	}
+	left, err := a.Remaining("me@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left != (Usage{Requests: 0, Downloads: 1}) {
+		t.Errorf("Remaining: got %+v", left)
+	}
+
+	// A new Pacific day starts over.
+	now = now.Add(2 * time.Hour)
+	if used, err := a.Used("me@example.com"); err != nil || used != (Usage{}) {
+		t.Errorf("Used the next day: got %+v, %v", used, err)
+	}
+	// A week later, the old days' files are gone.
+	now = now.AddDate(0, 0, keepDays+1)
+	if err := a.prune(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "quota-*")); len(files) != 0 {
+		t.Errorf("old files left: %q", files)
+	}
+}
+
+func TestClientQuota(t *testing.T) {
+	srv := httptest.NewServer(clippermock.New())
+	defer srv.Close()
+	tr, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.Limits.Downloads = 1
+	c, err := clipper.NewClient(clippermock.DemoEmail, clippermock.DemoPassword, clipper.WithSiteURL(srv.URL), clipper.WithQuota(tr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	err = c.DownloadRange(context.Background(), dir, clipper.DateRange{}, false)
+	cerrs := clipper.CardErrors(err)
+	if len(cerrs) != 1 || cerrs[0].Card != 1401491737 || !errors.Is(err, clipper.ErrQuotaExceeded) {
+		t.Fatalf("DownloadRange: got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "clipper-transactions-1202728442.pdf")); err != nil {
+		t.Error(err)
+	}
+	used, err := tr.Used(clippermock.DemoEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used.Downloads != 1 || used.Requests < 4 {
+		t.Errorf("Used: got %+v", used)
+	}
+}
@@ -0,0 +1,668 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: clipper.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Card struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Serial        int64                  `protobuf:"varint,1,opt,name=serial,proto3" json:"serial,omitempty"`
+	Transactions  int32                  `protobuf:"varint,2,opt,name=transactions,proto3" json:"transactions,omitempty"`
+	BalanceCents  int64                  `protobuf:"varint,3,opt,name=balance_cents,json=balanceCents,proto3" json:"balance_cents,omitempty"`
+	LastUsed      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_used,json=lastUsed,proto3" json:"last_used,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Card) Reset() {
+	*x = Card{}
+	mi := &file_clipper_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Card) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Card) ProtoMessage() {}
+
+func (x *Card) ProtoReflect() protoreflect.Message {
+	mi := &file_clipper_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Card.ProtoReflect.Descriptor instead.
+func (*Card) Descriptor() ([]byte, []int) {
+	return file_clipper_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Card) GetSerial() int64 {
+	if x != nil {
+		return x.Serial
+	}
+	return 0
+}
+
+func (x *Card) GetTransactions() int32 {
+	if x != nil {
+		return x.Transactions
+	}
+	return 0
+}
+
+func (x *Card) GetBalanceCents() int64 {
+	if x != nil {
+		return x.BalanceCents
+	}
+	return 0
+}
+
+func (x *Card) GetLastUsed() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastUsed
+	}
+	return nil
+}
+
+type ListCardsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCardsRequest) Reset() {
+	*x = ListCardsRequest{}
+	mi := &file_clipper_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCardsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCardsRequest) ProtoMessage() {}
+
+func (x *ListCardsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clipper_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCardsRequest.ProtoReflect.Descriptor instead.
+func (*ListCardsRequest) Descriptor() ([]byte, []int) {
+	return file_clipper_proto_rawDescGZIP(), []int{1}
+}
+
+type ListCardsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cards         []*Card                `protobuf:"bytes,1,rep,name=cards,proto3" json:"cards,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCardsResponse) Reset() {
+	*x = ListCardsResponse{}
+	mi := &file_clipper_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCardsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCardsResponse) ProtoMessage() {}
+
+func (x *ListCardsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clipper_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCardsResponse.ProtoReflect.Descriptor instead.
+func (*ListCardsResponse) Descriptor() ([]byte, []int) {
+	return file_clipper_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListCardsResponse) GetCards() []*Card {
+	if x != nil {
+		return x.Cards
+	}
+	return nil
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Location      string                 `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`
+	Route         string                 `protobuf:"bytes,4,opt,name=route,proto3" json:"route,omitempty"`
+	Product       string                 `protobuf:"bytes,5,opt,name=product,proto3" json:"product,omitempty"`
+	Agency        string                 `protobuf:"bytes,6,opt,name=agency,proto3" json:"agency,omitempty"`
+	DebitCents    int64                  `protobuf:"varint,7,opt,name=debit_cents,json=debitCents,proto3" json:"debit_cents,omitempty"`
+	CreditCents   int64                  `protobuf:"varint,8,opt,name=credit_cents,json=creditCents,proto3" json:"credit_cents,omitempty"`
+	BalanceCents  int64                  `protobuf:"varint,9,opt,name=balance_cents,json=balanceCents,proto3" json:"balance_cents,omitempty"`
+	CardSerial    int64                  `protobuf:"varint,10,opt,name=card_serial,json=cardSerial,proto3" json:"card_serial,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_clipper_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_clipper_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_clipper_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Transaction) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Transaction) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Transaction) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *Transaction) GetRoute() string {
+	if x != nil {
+		return x.Route
+	}
+	return ""
+}
+
+func (x *Transaction) GetProduct() string {
+	if x != nil {
+		return x.Product
+	}
+	return ""
+}
+
+func (x *Transaction) GetAgency() string {
+	if x != nil {
+		return x.Agency
+	}
+	return ""
+}
+
+func (x *Transaction) GetDebitCents() int64 {
+	if x != nil {
+		return x.DebitCents
+	}
+	return 0
+}
+
+func (x *Transaction) GetCreditCents() int64 {
+	if x != nil {
+		return x.CreditCents
+	}
+	return 0
+}
+
+func (x *Transaction) GetBalanceCents() int64 {
+	if x != nil {
+		return x.BalanceCents
+	}
+	return 0
+}
+
+func (x *Transaction) GetCardSerial() int64 {
+	if x != nil {
+		return x.CardSerial
+	}
+	return 0
+}
+
+type ListTransactionsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// card limits the results to one card; 0 means every card.
+	Card int64 `protobuf:"varint,1,opt,name=card,proto3" json:"card,omitempty"`
+	// from and to limit the results to transactions at or after from and
+	// before to. Either can be left unset.
+	From          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransactionsRequest) Reset() {
+	*x = ListTransactionsRequest{}
+	mi := &file_clipper_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransactionsRequest) ProtoMessage() {}
+
+func (x *ListTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clipper_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*ListTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_clipper_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListTransactionsRequest) GetCard() int64 {
+	if x != nil {
+		return x.Card
+	}
+	return 0
+}
+
+func (x *ListTransactionsRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *ListTransactionsRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+type ListTransactionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transactions  []*Transaction         `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransactionsResponse) Reset() {
+	*x = ListTransactionsResponse{}
+	mi := &file_clipper_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransactionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransactionsResponse) ProtoMessage() {}
+
+func (x *ListTransactionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clipper_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransactionsResponse.ProtoReflect.Descriptor instead.
+func (*ListTransactionsResponse) Descriptor() ([]byte, []int) {
+	return file_clipper_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListTransactionsResponse) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+type SyncRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncRequest) Reset() {
+	*x = SyncRequest{}
+	mi := &file_clipper_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncRequest) ProtoMessage() {}
+
+func (x *SyncRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clipper_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncRequest.ProtoReflect.Descriptor instead.
+func (*SyncRequest) Descriptor() ([]byte, []int) {
+	return file_clipper_proto_rawDescGZIP(), []int{6}
+}
+
+type GetSyncStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSyncStatusRequest) Reset() {
+	*x = GetSyncStatusRequest{}
+	mi := &file_clipper_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSyncStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSyncStatusRequest) ProtoMessage() {}
+
+func (x *GetSyncStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clipper_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSyncStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetSyncStatusRequest) Descriptor() ([]byte, []int) {
+	return file_clipper_proto_rawDescGZIP(), []int{7}
+}
+
+type SyncStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Running       bool                   `protobuf:"varint,1,opt,name=running,proto3" json:"running,omitempty"`
+	LastStart     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=last_start,json=lastStart,proto3" json:"last_start,omitempty"`
+	LastFinish    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=last_finish,json=lastFinish,proto3" json:"last_finish,omitempty"`
+	LastSuccess   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_success,json=lastSuccess,proto3" json:"last_success,omitempty"`
+	LastError     string                 `protobuf:"bytes,5,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncStatus) Reset() {
+	*x = SyncStatus{}
+	mi := &file_clipper_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncStatus) ProtoMessage() {}
+
+func (x *SyncStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_clipper_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncStatus.ProtoReflect.Descriptor instead.
+func (*SyncStatus) Descriptor() ([]byte, []int) {
+	return file_clipper_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SyncStatus) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *SyncStatus) GetLastStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastStart
+	}
+	return nil
+}
+
+func (x *SyncStatus) GetLastFinish() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastFinish
+	}
+	return nil
+}
+
+func (x *SyncStatus) GetLastSuccess() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSuccess
+	}
+	return nil
+}
+
+func (x *SyncStatus) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+var File_clipper_proto protoreflect.FileDescriptor
+
+const file_clipper_proto_rawDesc = "" +
+	"\n" +
+	"\rclipper.proto\x12\n" +
+	"clipper.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa0\x01\n" +
+	"\x04Card\x12\x16\n" +
+	"\x06serial\x18\x01 \x01(\x03R\x06serial\x12\"\n" +
+	"\ftransactions\x18\x02 \x01(\x05R\ftransactions\x12#\n" +
+	"\rbalance_cents\x18\x03 \x01(\x03R\fbalanceCents\x127\n" +
+	"\tlast_used\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\blastUsed\"\x12\n" +
+	"\x10ListCardsRequest\";\n" +
+	"\x11ListCardsResponse\x12&\n" +
+	"\x05cards\x18\x01 \x03(\v2\x10.clipper.v1.CardR\x05cards\"\xc9\x02\n" +
+	"\vTransaction\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1a\n" +
+	"\blocation\x18\x03 \x01(\tR\blocation\x12\x14\n" +
+	"\x05route\x18\x04 \x01(\tR\x05route\x12\x18\n" +
+	"\aproduct\x18\x05 \x01(\tR\aproduct\x12\x16\n" +
+	"\x06agency\x18\x06 \x01(\tR\x06agency\x12\x1f\n" +
+	"\vdebit_cents\x18\a \x01(\x03R\n" +
+	"debitCents\x12!\n" +
+	"\fcredit_cents\x18\b \x01(\x03R\vcreditCents\x12#\n" +
+	"\rbalance_cents\x18\t \x01(\x03R\fbalanceCents\x12\x1f\n" +
+	"\vcard_serial\x18\n" +
+	" \x01(\x03R\n" +
+	"cardSerial\"\x89\x01\n" +
+	"\x17ListTransactionsRequest\x12\x12\n" +
+	"\x04card\x18\x01 \x01(\x03R\x04card\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\"W\n" +
+	"\x18ListTransactionsResponse\x12;\n" +
+	"\ftransactions\x18\x01 \x03(\v2\x17.clipper.v1.TransactionR\ftransactions\"\r\n" +
+	"\vSyncRequest\"\x16\n" +
+	"\x14GetSyncStatusRequest\"\xfc\x01\n" +
+	"\n" +
+	"SyncStatus\x12\x18\n" +
+	"\arunning\x18\x01 \x01(\bR\arunning\x129\n" +
+	"\n" +
+	"last_start\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tlastStart\x12;\n" +
+	"\vlast_finish\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"lastFinish\x12=\n" +
+	"\flast_success\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vlastSuccess\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x05 \x01(\tR\tlastError2\xb6\x02\n" +
+	"\aClipper\x12H\n" +
+	"\tListCards\x12\x1c.clipper.v1.ListCardsRequest\x1a\x1d.clipper.v1.ListCardsResponse\x12]\n" +
+	"\x10ListTransactions\x12#.clipper.v1.ListTransactionsRequest\x1a$.clipper.v1.ListTransactionsResponse\x127\n" +
+	"\x04Sync\x12\x17.clipper.v1.SyncRequest\x1a\x16.clipper.v1.SyncStatus\x12I\n" +
+	"\rGetSyncStatus\x12 .clipper.v1.GetSyncStatusRequest\x1a\x16.clipper.v1.SyncStatusB#Z!github.com/kevinburke/clipper/rpcb\x06proto3"
+
+var (
+	file_clipper_proto_rawDescOnce sync.Once
+	file_clipper_proto_rawDescData []byte
+)
+
+func file_clipper_proto_rawDescGZIP() []byte {
+	file_clipper_proto_rawDescOnce.Do(func() {
+		file_clipper_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_clipper_proto_rawDesc), len(file_clipper_proto_rawDesc)))
+	})
+	return file_clipper_proto_rawDescData
+}
+
+var file_clipper_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_clipper_proto_goTypes = []any{
+	(*Card)(nil),                     // 0: clipper.v1.Card
+	(*ListCardsRequest)(nil),         // 1: clipper.v1.ListCardsRequest
+	(*ListCardsResponse)(nil),        // 2: clipper.v1.ListCardsResponse
+	(*Transaction)(nil),              // 3: clipper.v1.Transaction
+	(*ListTransactionsRequest)(nil),  // 4: clipper.v1.ListTransactionsRequest
+	(*ListTransactionsResponse)(nil), // 5: clipper.v1.ListTransactionsResponse
+	(*SyncRequest)(nil),              // 6: clipper.v1.SyncRequest
+	(*GetSyncStatusRequest)(nil),     // 7: clipper.v1.GetSyncStatusRequest
+	(*SyncStatus)(nil),               // 8: clipper.v1.SyncStatus
+	(*timestamppb.Timestamp)(nil),    // 9: google.protobuf.Timestamp
+}
+var file_clipper_proto_depIdxs = []int32{
+	9,  // 0: clipper.v1.Card.last_used:type_name -> google.protobuf.Timestamp
+	0,  // 1: clipper.v1.ListCardsResponse.cards:type_name -> clipper.v1.Card
+	9,  // 2: clipper.v1.Transaction.timestamp:type_name -> google.protobuf.Timestamp
+	9,  // 3: clipper.v1.ListTransactionsRequest.from:type_name -> google.protobuf.Timestamp
+	9,  // 4: clipper.v1.ListTransactionsRequest.to:type_name -> google.protobuf.Timestamp
+	3,  // 5: clipper.v1.ListTransactionsResponse.transactions:type_name -> clipper.v1.Transaction
+	9,  // 6: clipper.v1.SyncStatus.last_start:type_name -> google.protobuf.Timestamp
+	9,  // 7: clipper.v1.SyncStatus.last_finish:type_name -> google.protobuf.Timestamp
+	9,  // 8: clipper.v1.SyncStatus.last_success:type_name -> google.protobuf.Timestamp
+	1,  // 9: clipper.v1.Clipper.ListCards:input_type -> clipper.v1.ListCardsRequest
+	4,  // 10: clipper.v1.Clipper.ListTransactions:input_type -> clipper.v1.ListTransactionsRequest
+	6,  // 11: clipper.v1.Clipper.Sync:input_type -> clipper.v1.SyncRequest
+	7,  // 12: clipper.v1.Clipper.GetSyncStatus:input_type -> clipper.v1.GetSyncStatusRequest
+	2,  // 13: clipper.v1.Clipper.ListCards:output_type -> clipper.v1.ListCardsResponse
+	5,  // 14: clipper.v1.Clipper.ListTransactions:output_type -> clipper.v1.ListTransactionsResponse
+	8,  // 15: clipper.v1.Clipper.Sync:output_type -> clipper.v1.SyncStatus
+	8,  // 16: clipper.v1.Clipper.GetSyncStatus:output_type -> clipper.v1.SyncStatus
+	13, // [13:17] is the sub-list for method output_type
+	9,  // [9:13] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_clipper_proto_init() }
+func file_clipper_proto_init() {
+	if File_clipper_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_clipper_proto_rawDesc), len(file_clipper_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_clipper_proto_goTypes,
+		DependencyIndexes: file_clipper_proto_depIdxs,
+		MessageInfos:      file_clipper_proto_msgTypes,
+	}.Build()
+	File_clipper_proto = out.File
+	file_clipper_proto_goTypes = nil
+	file_clipper_proto_depIdxs = nil
+}
@@ -0,0 +1,249 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: clipper.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Clipper_ListCards_FullMethodName        = "/clipper.v1.Clipper/ListCards"
+	Clipper_ListTransactions_FullMethodName = "/clipper.v1.Clipper/ListTransactions"
+	Clipper_Sync_FullMethodName             = "/clipper.v1.Clipper/Sync"
+	Clipper_GetSyncStatus_FullMethodName    = "/clipper.v1.Clipper/GetSyncStatus"
+)
+
+// ClipperClient is the client API for Clipper service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The Clipper service serves the statement archive, with the same data as the
+// JSON API that "clipper serve" runs.
+type ClipperClient interface {
+	// ListCards returns every card in the archive, with its latest balance.
+	ListCards(ctx context.Context, in *ListCardsRequest, opts ...grpc.CallOption) (*ListCardsResponse, error)
+	// ListTransactions returns transactions, oldest first.
+	ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error)
+	// Sync starts downloading new statements in the background.
+	Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*SyncStatus, error)
+	// GetSyncStatus returns the status of the most recent sync.
+	GetSyncStatus(ctx context.Context, in *GetSyncStatusRequest, opts ...grpc.CallOption) (*SyncStatus, error)
+}
+
+type clipperClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClipperClient(cc grpc.ClientConnInterface) ClipperClient {
+	return &clipperClient{cc}
+}
+
+func (c *clipperClient) ListCards(ctx context.Context, in *ListCardsRequest, opts ...grpc.CallOption) (*ListCardsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCardsResponse)
+	err := c.cc.Invoke(ctx, Clipper_ListCards_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clipperClient) ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTransactionsResponse)
+	err := c.cc.Invoke(ctx, Clipper_ListTransactions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clipperClient) Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*SyncStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SyncStatus)
+	err := c.cc.Invoke(ctx, Clipper_Sync_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clipperClient) GetSyncStatus(ctx context.Context, in *GetSyncStatusRequest, opts ...grpc.CallOption) (*SyncStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SyncStatus)
+	err := c.cc.Invoke(ctx, Clipper_GetSyncStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ClipperServer is the server API for Clipper service.
+// All implementations must embed UnimplementedClipperServer
+// for forward compatibility.
+//
+// The Clipper service serves the statement archive, with the same data as the
+// JSON API that "clipper serve" runs.
+type ClipperServer interface {
+	// ListCards returns every card in the archive, with its latest balance.
+	ListCards(context.Context, *ListCardsRequest) (*ListCardsResponse, error)
+	// ListTransactions returns transactions, oldest first.
+	ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error)
+	// Sync starts downloading new statements in the background.
+	Sync(context.Context, *SyncRequest) (*SyncStatus, error)
+	// GetSyncStatus returns the status of the most recent sync.
+	GetSyncStatus(context.Context, *GetSyncStatusRequest) (*SyncStatus, error)
+	mustEmbedUnimplementedClipperServer()
+}
+
+// UnimplementedClipperServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedClipperServer struct{}
+
+func (UnimplementedClipperServer) ListCards(context.Context, *ListCardsRequest) (*ListCardsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCards not implemented")
+}
+func (UnimplementedClipperServer) ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (UnimplementedClipperServer) Sync(context.Context, *SyncRequest) (*SyncStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Sync not implemented")
+}
+func (UnimplementedClipperServer) GetSyncStatus(context.Context, *GetSyncStatusRequest) (*SyncStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSyncStatus not implemented")
+}
+func (UnimplementedClipperServer) mustEmbedUnimplementedClipperServer() {}
+func (UnimplementedClipperServer) testEmbeddedByValue()                 {}
+
+// UnsafeClipperServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClipperServer will
+// result in compilation errors.
+type UnsafeClipperServer interface {
+	mustEmbedUnimplementedClipperServer()
+}
+
+func RegisterClipperServer(s grpc.ServiceRegistrar, srv ClipperServer) {
+	// If the following call pancis, it indicates UnimplementedClipperServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Clipper_ServiceDesc, srv)
+}
+
+func _Clipper_ListCards_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCardsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClipperServer).ListCards(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Clipper_ListCards_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClipperServer).ListCards(ctx, req.(*ListCardsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Clipper_ListTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClipperServer).ListTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Clipper_ListTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClipperServer).ListTransactions(ctx, req.(*ListTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Clipper_Sync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClipperServer).Sync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Clipper_Sync_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClipperServer).Sync(ctx, req.(*SyncRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Clipper_GetSyncStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSyncStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClipperServer).GetSyncStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Clipper_GetSyncStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClipperServer).GetSyncStatus(ctx, req.(*GetSyncStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Clipper_ServiceDesc is the grpc.ServiceDesc for Clipper service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Clipper_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "clipper.v1.Clipper",
+	HandlerType: (*ClipperServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListCards",
+			Handler:    _Clipper_ListCards_Handler,
+		},
+		{
+			MethodName: "ListTransactions",
+			Handler:    _Clipper_ListTransactions_Handler,
+		},
+		{
+			MethodName: "Sync",
+			Handler:    _Clipper_Sync_Handler,
+		},
+		{
+			MethodName: "GetSyncStatus",
+			Handler:    _Clipper_GetSyncStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "clipper.proto",
+}
@@ -0,0 +1,180 @@
+// Package rpc serves the statement archive over gRPC, for tools that want
+// typed clients. The service is defined in clipper.proto; clipper.pb.go and
+// clipper_grpc.pb.go are generated from it with "make proto", which also
+// generates a TypeScript client for Node in rpc/ts/src.
+//
+// Clients authenticate by sending one of the API server's tokens as
+// "authorization: Bearer TOKEN" metadata; TokenCredentials does this for Go
+// clients. Sync needs a token with the sync scope, ListCards one with the
+// balances or read scope, and the other calls one with the read scope. A
+// token limited to some cards only sees those, and can't Sync. Each call is recorded in the archive's audit log.
+package rpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper/api"
+	"github.com/kevinburke/clipper/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// NewServer returns a gRPC server for the archive served by a, which accepts
+// a's tokens.
+func NewServer(a *api.Server) *grpc.Server {
+	s := grpc.NewServer(grpc.UnaryInterceptor(tokenInterceptor(a)))
+	RegisterClipperServer(s, &server{api: a})
+	return s
+}
+
+func tokenInterceptor(a *api.Server) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		got := ""
+		if auth := md.Get("authorization"); len(auth) > 0 {
+			got = strings.TrimPrefix(auth[0], "Bearer ")
+		}
+		defer func() { audit(a, got, info.FullMethod, err) }()
+		t, ok := a.Token(got)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "missing or incorrect token")
+		}
+		want := api.ScopeRead
+		switch info.FullMethod {
+		case Clipper_Sync_FullMethodName:
+			want = api.ScopeSync
+			if len(t.Cards) > 0 {
+				return nil, status.Error(codes.PermissionDenied, "token is limited to some cards, so it can't sync every account")
+			}
+		case Clipper_ListCards_FullMethodName:
+			want = api.ScopeBalances
+		}
+		for _, sc := range t.Scopes {
+			if sc == want || (want == api.ScopeBalances && sc == api.ScopeRead) {
+				return handler(api.NewContext(ctx, t), req)
+			}
+		}
+		return nil, status.Errorf(codes.PermissionDenied, "token doesn't have the %q scope", want)
+	}
+}
+
+// audit records a call to method, made with token, in the archive's audit
+// log.
+func audit(a *api.Server, token, method string, err error) {
+	t, _ := a.Token(token)
+	e := store.AuditEvent{Actor: t.Name, Action: store.AuditAPI, Detail: "gRPC " + method, Outcome: store.AuditOK}
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.Unauthenticated, codes.PermissionDenied:
+		e.Outcome = store.AuditDenied
+	default:
+		e.Outcome, e.Error = store.AuditError, err.Error()
+	}
+	a.Audit(e)
+}
+
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// The server listens on localhost without TLS, like the JSON API.
+func (t tokenCredentials) RequireTransportSecurity() bool { return false }
+
+// TokenCredentials returns a dial option that sends token with every call.
+func TokenCredentials(token string) grpc.DialOption {
+	return grpc.WithPerRPCCredentials(tokenCredentials(token))
+}
+
+type server struct {
+	UnimplementedClipperServer
+	api *api.Server
+}
+
+func (s *server) ListCards(ctx context.Context, req *ListCardsRequest) (*ListCardsResponse, error) {
+	txns, err := s.api.VisibleTransactions(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &ListCardsResponse{}
+	for _, c := range api.Cards(txns) {
+		resp.Cards = append(resp.Cards, &Card{
+			Serial:       c.Serial,
+			Transactions: int32(c.Transactions),
+			BalanceCents: int64(c.BalanceCents),
+			LastUsed:     timestamppb.New(c.LastUsed),
+		})
+	}
+	return resp, nil
+}
+
+func (s *server) ListTransactions(ctx context.Context, req *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	txns, err := s.api.VisibleTransactions(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	txns = store.Between(txns, timeOf(req.GetFrom()), timeOf(req.GetTo()))
+	resp := &ListTransactionsResponse{}
+	for _, t := range txns {
+		if req.GetCard() != 0 && t.CardSerial != req.GetCard() {
+			continue
+		}
+		resp.Transactions = append(resp.Transactions, &Transaction{
+			Timestamp:    timestamppb.New(t.Timestamp),
+			Type:         t.Type,
+			Location:     t.Location,
+			Route:        t.Route,
+			Product:      t.Product,
+			Agency:       string(t.Agency()),
+			DebitCents:   int64(t.DebitCents),
+			CreditCents:  int64(t.CreditCents),
+			BalanceCents: int64(t.BalanceCents),
+			CardSerial:   t.CardSerial,
+		})
+	}
+	return resp, nil
+}
+
+// timeOf returns the time ts, or the zero time if it isn't set.
+func timeOf(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func syncStatus(st api.SyncStatus) *SyncStatus {
+	ts := func(t time.Time) *timestamppb.Timestamp {
+		if t.IsZero() {
+			return nil
+		}
+		return timestamppb.New(t)
+	}
+	return &SyncStatus{
+		Running:     st.Running,
+		LastStart:   ts(st.LastStart),
+		LastFinish:  ts(st.LastFinish),
+		LastSuccess: ts(st.LastSuccess),
+		LastError:   st.LastError,
+	}
+}
+
+func (s *server) Sync(ctx context.Context, req *SyncRequest) (*SyncStatus, error) {
+	if !s.api.CanSync() {
+		return nil, status.Error(codes.FailedPrecondition, "this server has no credentials to sync with")
+	}
+	if !s.api.Sync() {
+		return nil, status.Error(codes.AlreadyExists, "a sync is already running")
+	}
+	return syncStatus(s.api.SyncStatus()), nil
+}
+
+func (s *server) GetSyncStatus(ctx context.Context, req *GetSyncStatusRequest) (*SyncStatus, error) {
+	return syncStatus(s.api.SyncStatus()), nil
+}
@@ -0,0 +1,121 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper/api"
+	"github.com/kevinburke/clipper/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func testClient(t *testing.T, token string) ClipperClient {
+	t.Helper()
+	dir := t.TempDir()
+	data, err := os.ReadFile("../testdata/transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.pdf"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := store.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln := bufconn.Listen(1 << 20)
+	a := api.New(s, "secret", nil)
+	for _, tok := range []api.Token{
+		{Name: "grafana", Token: "metrics-only-token", Scopes: []api.Scope{api.ScopeMetrics}},
+		{Name: "widget", Token: "other-card-balance", Scopes: []api.Scope{api.ScopeBalances}, Cards: []int64{2}},
+	} {
+		if err := a.AddToken(tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := NewServer(a)
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		TokenCredentials(token),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewClipperClient(conn)
+}
+
+func TestAuth(t *testing.T) {
+	_, err := testClient(t, "wrong").ListCards(context.Background(), &ListCardsRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("wrong token: got %v", err)
+	}
+	_, err = testClient(t, "metrics-only-token").ListCards(context.Background(), &ListCardsRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("token without the read scope: got %v", err)
+	}
+	widget := testClient(t, "other-card-balance")
+	cards, err := widget.ListCards(context.Background(), &ListCardsRequest{})
+	if err != nil || len(cards.Cards) != 0 {
+		t.Errorf("token limited to another card: got %v, %v", cards, err)
+	}
+	_, err = widget.ListTransactions(context.Background(), &ListTransactionsRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("balances-only token: got %v", err)
+	}
+}
+
+func TestListCardsAndTransactions(t *testing.T) {
+	c := testClient(t, "secret")
+	ctx := context.Background()
+	cards, err := c.ListCards(ctx, &ListCardsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards.Cards) != 1 || cards.Cards[0].Serial != 1202728442 || cards.Cards[0].BalanceCents != 8975 {
+		t.Errorf("cards: got %v", cards.Cards)
+	}
+	all, err := c.ListTransactions(ctx, &ListTransactionsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jan, err := c.ListTransactions(ctx, &ListTransactionsRequest{
+		Card: 1202728442,
+		From: timestamppb.New(time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		To:   timestamppb.New(time.Date(2018, time.February, 1, 0, 0, 0, 0, time.UTC)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jan.Transactions) == 0 || len(jan.Transactions) >= len(all.Transactions) {
+		t.Errorf("expected January to be a subset: got %d of %d", len(jan.Transactions), len(all.Transactions))
+	}
+	for _, txn := range jan.Transactions {
+		if txn.Timestamp.AsTime().Month() != time.January {
+			t.Errorf("transaction outside January: %v", txn)
+		}
+	}
+}
+
+func TestSyncUnavailable(t *testing.T) {
+	c := testClient(t, "secret")
+	if _, err := c.Sync(context.Background(), &SyncRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Sync: got %v", err)
+	}
+	st, err := c.GetSyncStatus(context.Background(), &GetSyncStatusRequest{})
+	if err != nil || st.Running || st.LastSuccess != nil {
+		t.Errorf("GetSyncStatus: got %v, %v", st, err)
+	}
+}
@@ -0,0 +1,338 @@
+package clipper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+	"github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ScrubOptions control how ScrubPDF and ScrubTransactions disguise a
+// statement.
+type ScrubOptions struct {
+	// Seed picks how far dates move, unless ShiftDays is set. Scrubbing a
+	// statement twice with the same options gives the same result.
+	Seed int64
+	// ShiftDays is how many days to move every date, and may be negative. If
+	// it's zero, dates move back by between 1 and 52 weeks, picked by Seed,
+	// so days of the week stay the same.
+	ShiftDays int
+}
+
+// ScrubPDF writes a copy of the statement in r to w with the card number
+// masked (see MaskSerial), every date shifted and every location replaced by a made-up name,
+// so it can be attached to a bug report without telling anyone where the
+// card has been. Everything else (the layout of the table, the transaction
+// types, routes, products and amounts) is left alone, so the copy parses
+// the way the original does.
+//
+// A fake location keeps the agency of the real one, as in "Location 3
+// (BART)", but not what's particular to the station, like its Caltrain fare
+// zone. The copy is drawn in Helvetica, without the statement's fonts or
+// images.
+func ScrubPDF(w io.Writer, r io.ReadSeeker, opts ScrubOptions) error {
+	reader, err := pdf.NewPdfReader(r)
+	if err != nil {
+		return err
+	}
+	numPages, err := reader.GetNumPages()
+	if err != nil {
+		return err
+	}
+	s := newStatementScrubber(opts)
+	pages := make([]pdfPage, numPages)
+	for i := range pages {
+		page, err := reader.GetPage(i + 1)
+		if err != nil {
+			return err
+		}
+		box, err := page.GetMediaBox()
+		if err != nil {
+			return err
+		}
+		streams, err := page.GetContentStreams()
+		if err != nil {
+			return err
+		}
+		content, err := s.content([]byte(strings.Join(streams, "\n")))
+		if err != nil {
+			return fmt.Errorf("clipper: page %d: %v", i+1, err)
+		}
+		p := pdfPage{
+			mediaBox: [4]float64{box.Llx, box.Lly, box.Urx, box.Ury},
+			content:  content,
+		}
+		if page.Rotate != nil {
+			p.rotate = *page.Rotate
+		}
+		if page.Resources != nil {
+			if fonts, ok := core.TraceToDirectObject(page.Resources.Font).(*core.PdfObjectDictionary); ok {
+				for _, name := range fonts.Keys() {
+					p.fonts = append(p.fonts, name.DefaultWriteString())
+				}
+			}
+		}
+		pages[i] = p
+	}
+	return writePDF(w, pages)
+}
+
+// ScrubTransactions returns a copy of data, as returned by ParsePDF, with the
+// card number masked, dates shifted and locations replaced the way ScrubPDF
+// does it. Scrubbing a statement with ScrubPDF and parsing it gives the same
+// records as parsing it and calling ScrubTransactions with the same options.
+func ScrubTransactions(data TransactionData, opts ScrubOptions) TransactionData {
+	s := newStatementScrubber(opts)
+	out := TransactionData{
+		AccountNumber: s.card(data.AccountNumber),
+		Transactions:  make([][]string, len(data.Transactions)),
+	}
+	for i, record := range data.Transactions {
+		record = append([]string(nil), record...)
+		header := i == 0 && len(record) > 0 && record[0] == recordHeader[0]
+		if !header && len(record) == len(recordHeader) {
+			if d, ok := s.date(record[0]); ok {
+				record[0] = d
+			}
+			record[2] = s.location(record[2])
+		}
+		out.Transactions[i] = record
+	}
+	return out
+}
+
+// statementScrubber disguises the values on a statement. It gives a value
+// the same disguise every time it sees it.
+type statementScrubber struct {
+	shiftDays int
+	locations map[string]string
+	decoder   *encoding.Decoder
+}
+
+func newStatementScrubber(opts ScrubOptions) *statementScrubber {
+	shift := opts.ShiftDays
+	if shift == 0 {
+		shift = -7 * (1 + rand.New(rand.NewSource(opts.Seed)).Intn(52))
+	}
+	return &statementScrubber{
+		shiftDays: shift,
+		locations: make(map[string]string),
+		decoder:   charmap.Windows1252.NewDecoder(),
+	}
+}
+
+// card returns serial masked with MaskSerial, or serial itself if it isn't
+// a serial number.
+func (s *statementScrubber) card(serial int64) int64 {
+	if serial <= 0 {
+		return serial
+	}
+	masked, err := strconv.ParseInt(MaskSerial(strconv.FormatInt(serial, 10)), 10, 64)
+	if err != nil {
+		return serial
+	}
+	return masked
+}
+
+// statementDateLayouts are the ways statements write dates: in the Date
+// column, and at the bottom of each page.
+var statementDateLayouts = []string{statementTimeLayout, "01/02/2006"}
+
+// date returns v moved by s.shiftDays, if v is a date in one of
+// statementDateLayouts. The time of day, on the wall clock, stays the same.
+func (s *statementScrubber) date(v string) (string, bool) {
+	v = strings.TrimSpace(v)
+	for _, layout := range statementDateLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.AddDate(0, 0, s.shiftDays).Format(layout), true
+		}
+	}
+	return "", false
+}
+
+// location returns a made-up name for location, numbered in the order
+// locations are first seen, with its agency in parentheses if it has one.
+func (s *statementScrubber) location(location string) string {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return ""
+	}
+	if fake, ok := s.locations[location]; ok {
+		return fake
+	}
+	fake := fmt.Sprintf("Location %d", len(s.locations)+1)
+	if a := transit.AgencyAt(location); a != AgencyUnknown {
+		fake += " (" + string(a) + ")"
+	}
+	s.locations[location] = fake
+	return fake
+}
+
+// text returns the disguise for text, a string shown in the given column of
+// the transaction table (see positions), or false if it should be left
+// alone. *inRow says whether the row text is on starts with a date; text
+// updates it.
+func (s *statementScrubber) text(column int, inRow *bool, text []byte) (string, bool) {
+	v := string(text)
+	if serial, ok := strings.CutPrefix(v, "CARD "); ok {
+		if n, err := strconv.ParseInt(serial, 10, 64); err == nil {
+			return "CARD " + strconv.FormatInt(s.card(n), 10), true
+		}
+	}
+	if d, ok := s.date(v); ok {
+		if column == 0 {
+			*inRow = true
+		}
+		return d, true
+	}
+	if column == 2 && *inRow {
+		if !isASCII(v) {
+			if decoded, err := s.decoder.String(v); err == nil {
+				v = decoded
+			}
+		}
+		return s.location(v), true
+	}
+	return "", false
+}
+
+// content returns a page's content stream with the text on it disguised. It
+// uses the text matrix the way extractText does to tell which column of the
+// transaction table a string is in. Images are dropped, since the rewritten
+// page doesn't carry them.
+func (s *statementScrubber) content(data []byte) ([]byte, error) {
+	sc := newContentScanner(data)
+	out := make([]byte, 0, len(data))
+	last := 0
+	replace := func(with []byte) {
+		out = append(out, data[last:sc.argStart]...)
+		out = append(out, with...)
+		last = sc.pos
+	}
+	column, y := -1, float64(-1)
+	inRow := false
+	var joined []byte
+	for {
+		op, err := sc.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		args := sc.args
+		switch string(op) {
+		case "Tm":
+			if len(args) != 6 || args[4].kind != operandNumber || args[5].kind != operandNumber {
+				continue
+			}
+			x := sc.number(args[4])
+			if ny := sc.number(args[5]); ny != y {
+				y, inRow = ny, false
+			}
+			column = -1
+			if col, err := findPositionIdx(x); err == nil {
+				column = col
+			}
+		case "Tj":
+			if len(args) != 1 || args[0].kind != operandString {
+				continue
+			}
+			if v, ok := s.text(column, &inRow, sc.str(args[0])); ok {
+				replace(showText(v))
+			}
+		case "TJ":
+			if len(args) != 1 || args[0].kind != operandArray {
+				continue
+			}
+			joined = joined[:0]
+			for _, e := range sc.array(args[0]) {
+				switch e.kind {
+				case operandString:
+					joined = append(joined, sc.str(e)...)
+				case operandNumber:
+					if sc.number(e) < -100 {
+						joined = append(joined, ' ')
+					}
+				}
+			}
+			if v, ok := s.text(column, &inRow, joined); ok {
+				replace(showText(v))
+			}
+		case "Do":
+			replace(nil)
+		}
+	}
+	return append(out, data[last:]...), nil
+}
+
+// showText returns a Tj operator that shows v.
+func showText(v string) []byte {
+	b := make([]byte, 0, len(v)+6)
+	b = append(b, '(')
+	for i := 0; i < len(v); i++ {
+		if c := v[i]; c == '(' || c == ')' || c == '\\' {
+			b = append(b, '\\')
+		}
+		b = append(b, v[i])
+	}
+	return append(b, ") Tj"...)
+}
+
+// A pdfPage is a page for writePDF.
+type pdfPage struct {
+	mediaBox [4]float64
+	rotate   int64
+	// fonts are the names, like "/F1", the content uses for fonts. They're
+	// all drawn in Helvetica.
+	fonts   []string
+	content []byte
+}
+
+// writePDF writes a PDF document with the given pages to w.
+func writePDF(w io.Writer, pages []pdfPage) error {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(format string, args ...interface{}) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&buf, format, args...)
+		buf.WriteString("\nendobj\n")
+	}
+	num := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	buf.WriteString("%PDF-1.4\n")
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range kids {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	obj("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	for i, p := range pages {
+		var fonts strings.Builder
+		for _, name := range p.fonts {
+			fmt.Fprintf(&fonts, "%s 3 0 R ", name)
+		}
+		box := p.mediaBox
+		obj("<< /Type /Page /Parent 2 0 R /MediaBox [%s %s %s %s] /Rotate %d /Resources << /Font << %s>> >> /Contents %d 0 R >>",
+			num(box[0]), num(box[1]), num(box[2]), num(box[3]), p.rotate, fonts.String(), 5+2*i)
+		obj("<< /Length %d >>\nstream\n%s\nendstream", len(p.content), p.content)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,135 @@
+package clipper
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScrubPDF(t *testing.T) {
+	data, err := os.ReadFile("testdata/transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig, err := ParsePDF(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := ScrubOptions{Seed: 7, ShiftDays: 100}
+	var buf bytes.Buffer
+	if err := ScrubPDF(&buf, bytes.NewReader(data), opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{"1202728442", "Millbrae", "Belmont", "Montgomery", "12/12/2017"} {
+		if bytes.Contains(buf.Bytes(), []byte(leak)) {
+			t.Errorf("scrubbed PDF contains %q", leak)
+		}
+	}
+	scrubbed, err := ParsePDF(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ScrubTransactions(orig, opts); !reflect.DeepEqual(scrubbed, want) {
+		t.Errorf("parsing the scrubbed PDF:\ngot  %v\nwant %v", scrubbed, want)
+	}
+	if scrubbed.AccountNumber != 1000008442 {
+		t.Errorf("card number: got %d, want 1000008442", scrubbed.AccountNumber)
+	}
+	before, err := ParseTransactions(orig.Transactions, orig.AccountNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := ParseTransactions(scrubbed.Transactions, scrubbed.AccountNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before) {
+		t.Fatalf("got %d transactions, want %d", len(after), len(before))
+	}
+	for i := range before {
+		b, a := before[i], after[i]
+		if a.DebitCents != b.DebitCents || a.CreditCents != b.CreditCents || a.BalanceCents != b.BalanceCents ||
+			a.Type != b.Type || a.Route != b.Route || a.Agency() != b.Agency() {
+			t.Errorf("row %d: got %+v, want the same fares as %+v", i+1, a, b)
+		}
+		if got := a.Timestamp.Sub(b.Timestamp).Hours(); got < 100*24-1 || got > 100*24+1 {
+			t.Errorf("row %d: moved %v hours, want 100 days", i+1, got)
+		}
+		if a.Timestamp.Format("15:04") != b.Timestamp.Format("15:04") {
+			t.Errorf("row %d: time changed from %v to %v", i+1, b.Timestamp, a.Timestamp)
+		}
+		if !strings.HasPrefix(a.Location, "Location ") {
+			t.Errorf("row %d: location %q", i+1, a.Location)
+		}
+	}
+	// Two visits to the same place are still two visits to the same place.
+	if before[4].Location != before[9].Location || after[4].Location != after[9].Location || after[4].Location == after[5].Location {
+		t.Errorf("locations: got %q, %q and %q", after[4].Location, after[9].Location, after[5].Location)
+	}
+
+	var again bytes.Buffer
+	if err := ScrubPDF(&again, bytes.NewReader(data), opts); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Bytes(), buf.Bytes()) {
+		t.Error("scrubbing twice with the same options gave different PDFs")
+	}
+}
+
+func TestScrubTransactions(t *testing.T) {
+	data := TransactionData{
+		AccountNumber: 1202728442,
+		Transactions: [][]string{
+			append([]string(nil), recordHeader...),
+			{"03/08/2024 11:45 PM", "Single-tag fare payment", "SAM bus", "LOC", "Clipper Cash", "2.05", "", "10.00"},
+			{"03/09/2024 08:00 AM", "Dual-tag exit transaction, fare payment", "Millbrae (BART)", "", "Clipper Cash", "4.65", "", "5.35"},
+			{"03/10/2024 01:30 AM", "Single-tag fare payment", "Somewhere", "", "Clipper Cash", "2.05", "", "3.30"},
+			{"03/11/2024 07:00 AM", "Dual-tag entry transaction, no fare deduction", "Millbrae (BART)", "", "Clipper Cash", "", "", "3.30"},
+		},
+	}
+	got := ScrubTransactions(data, ScrubOptions{Seed: 1})
+	if got.AccountNumber != 1000008442 {
+		t.Errorf("card number: got %d, want 1000008442", got.AccountNumber)
+	}
+	if again := ScrubTransactions(data, ScrubOptions{Seed: 1}); !reflect.DeepEqual(again, got) {
+		t.Errorf("same seed: got %v, then %v", got, again)
+	}
+	if other := ScrubTransactions(data, ScrubOptions{Seed: 2}); other.Transactions[1][0] == got.Transactions[1][0] {
+		t.Errorf("seeds 1 and 2 both moved %s to %s", data.Transactions[1][0], got.Transactions[1][0])
+	}
+	if data.Transactions[1][2] != "SAM bus" {
+		t.Errorf("ScrubTransactions changed its argument")
+	}
+	if !reflect.DeepEqual(got.Transactions[0], recordHeader) {
+		t.Errorf("header: got %q", got.Transactions[0])
+	}
+	wantLocations := []string{"Location 1 (SamTrans)", "Location 2 (BART)", "Location 3", "Location 2 (BART)"}
+	txns, err := ParseTransactions(got.Transactions, got.AccountNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shift int
+	for i, txn := range txns {
+		if txn.Location != wantLocations[i] {
+			t.Errorf("row %d: location %q, want %q", i+1, txn.Location, wantLocations[i])
+		}
+		orig, err := parseTransaction(data.Transactions[i+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The wall clock time stays the same across a daylight saving change,
+		// and the shift is a whole number of weeks.
+		if txn.Timestamp.Format("15:04") != orig.Timestamp.Format("15:04") || txn.Timestamp.Weekday() != orig.Timestamp.Weekday() {
+			t.Errorf("row %d: %v from %v", i+1, txn.Timestamp, orig.Timestamp)
+		}
+		days := int(orig.Timestamp.Sub(txn.Timestamp).Hours()+12) / 24
+		if i == 0 {
+			shift = days
+		}
+		if days != shift || days < 7 || days > 364 {
+			t.Errorf("row %d: moved back %d days", i+1, days)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package clipper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"runtime"
+	"sync"
+	"weak"
+)
+
+const redacted = "[redacted]"
+
+// A Secret holds a credential, like a password, a one-time code or a form's
+// CSRF token, and keeps it out of logs. It prints as "[redacted]" with fmt
+// and log/slog, and marshals that way as text or JSON, so a Secret that ends
+// up in an error message or a logged struct doesn't give itself away. Reveal
+// returns the value, to send it to the site.
+//
+// While a Secret is live, its value is also redacted, as is and URL-encoded,
+// from the client's dumps of HTTP traffic ($DEBUG_HTTP_TRAFFIC) and from
+// pages a Scrubber scrubs.
+//
+// Zero overwrites the value when you're done with it; a Secret that's garbage
+// collected is zeroed too. This is best effort: copies made with Reveal, and
+// the string a Secret was made from, are out of its reach.
+type Secret struct {
+	mu sync.Mutex
+	b  []byte
+}
+
+// NewSecret returns a Secret holding s.
+func NewSecret(s string) *Secret {
+	b := []byte(s)
+	sec := &Secret{b: b}
+	runtime.AddCleanup(sec, zero, b)
+	liveSecrets.add(sec)
+	return sec
+}
+
+// Reveal returns the secret's value, or "" if it's been zeroed.
+func (s *Secret) Reveal() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return string(s.b)
+}
+
+// Zero overwrites the secret's value and forgets it.
+func (s *Secret) Zero() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	zero(s.b)
+	s.b = nil
+	s.mu.Unlock()
+	liveSecrets.remove(s)
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// String returns "[redacted]".
+func (s *Secret) String() string { return redacted }
+
+// Format writes "[redacted]" for every verb, including %#v.
+func (s *Secret) Format(f fmt.State, verb rune) { io.WriteString(f, redacted) }
+
+// LogValue returns "[redacted]", for log/slog.
+func (s *Secret) LogValue() slog.Value { return slog.StringValue(redacted) }
+
+// MarshalText returns "[redacted]", which is also how encoding/json writes a
+// Secret.
+func (s *Secret) MarshalText() ([]byte, error) { return []byte(redacted), nil }
+
+// minRedactLen is the length below which a secret isn't redacted from traffic
+// dumps and scrubbed pages; a two-letter secret would mangle every page.
+const minRedactLen = 4
+
+// liveSecrets are the Secrets that haven't been zeroed or collected.
+var liveSecrets secretSet
+
+type secretSet struct {
+	mu      sync.Mutex
+	secrets map[weak.Pointer[Secret]]struct{}
+}
+
+func (ss *secretSet) add(s *Secret) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.secrets == nil {
+		ss.secrets = make(map[weak.Pointer[Secret]]struct{})
+	}
+	ss.secrets[weak.Make(s)] = struct{}{}
+}
+
+func (ss *secretSet) remove(s *Secret) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	delete(ss.secrets, weak.Make(s))
+}
+
+// values returns the values of the live secrets long enough to redact, and
+// forgets the ones that have been collected.
+func (ss *secretSet) values() [][]byte {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	var vals [][]byte
+	for p := range ss.secrets {
+		s := p.Value()
+		if s == nil {
+			delete(ss.secrets, p)
+			continue
+		}
+		if v := s.Reveal(); len(v) >= minRedactLen {
+			vals = append(vals, []byte(v))
+			if q := url.QueryEscape(v); q != v {
+				vals = append(vals, []byte(q))
+			}
+		}
+	}
+	return vals
+}
+
+// redactSecrets returns b with the value of every live Secret replaced by
+// "[redacted]".
+func redactSecrets(b []byte) []byte {
+	for _, v := range liveSecrets.values() {
+		b = bytes.ReplaceAll(b, v, []byte(redacted))
+	}
+	return b
+}
+
+// redactingWriter redacts live Secrets from each Write to w. A secret split
+// across two writes gets through, so write whole messages at once.
+type redactingWriter struct{ w io.Writer }
+
+func (r redactingWriter) Write(p []byte) (int, error) {
+	if _, err := r.w.Write(redactSecrets(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
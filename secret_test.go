@@ -0,0 +1,90 @@
+package clipper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/kevinburke/rest"
+)
+
+func TestSecretRedacts(t *testing.T) {
+	s := NewSecret("correct horse battery staple")
+	defer s.Zero()
+	login := struct {
+		Email    string
+		Password *Secret
+	}{"me@example.com", s}
+	for _, got := range []string{
+		fmt.Sprint(s),
+		fmt.Sprintf("%s %q %x %#v %+v", s, s, s, s, login),
+		fmt.Errorf("logging in with %v: %w", login, io.EOF).Error(),
+	} {
+		if strings.Contains(got, "horse") || !strings.Contains(got, redacted) {
+			t.Errorf("formatted as %q", got)
+		}
+	}
+	data, err := json.Marshal(login)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Email":"me@example.com","Password":"[redacted]"}`; string(data) != want {
+		t.Errorf("JSON: got %s, want %s", data, want)
+	}
+	buf := new(bytes.Buffer)
+	slog.New(slog.NewTextHandler(buf, nil)).Info("login", "password", s)
+	if strings.Contains(buf.String(), "horse") {
+		t.Errorf("slog: got %q", buf.String())
+	}
+
+	if s.Reveal() != "correct horse battery staple" {
+		t.Errorf("Reveal: got %q", s.Reveal())
+	}
+	page := "password=" + url.QueryEscape(s.Reveal()) + "&echo=correct horse battery staple"
+	if got := string(redactSecrets([]byte(page))); got != "password=[redacted]&echo=[redacted]" {
+		t.Errorf("redactSecrets: got %q", got)
+	}
+	if got := string(NewScrubber(nil, "").Scrub([]byte(page))); got != "password=[redacted]&echo=[redacted]" {
+		t.Errorf("Scrub: got %q", got)
+	}
+	s.Zero()
+	if s.Reveal() != "" {
+		t.Errorf("Reveal after Zero: got %q", s.Reveal())
+	}
+	if got := string(redactSecrets([]byte(page))); got != page {
+		t.Errorf("redactSecrets after Zero: got %q", got)
+	}
+}
+
+func TestDebugDumpRedactsSecrets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Write([]byte("welcome back, " + r.Form.Get("password")))
+	}))
+	defer srv.Close()
+	buf := new(bytes.Buffer)
+	c, err := NewClient("me@example.com", "hunter2!", WithTransport(&rest.Transport{
+		RoundTripper: http.DefaultTransport,
+		Debug:        true,
+		Output:       redactingWriter{buf},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := url.Values{"password": {c.password.Reveal()}}
+	resp, err := c.client.PostForm(srv.URL, form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if strings.Contains(buf.String(), "hunter2") || strings.Count(buf.String(), redacted) != 2 {
+		t.Errorf("dump:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clippercsv"
+	"github.com/kevinburke/handlers"
+	"github.com/kevinburke/rest/resterror"
+)
+
+// MaxUpload is the largest PDF the server will parse.
+const MaxUpload = 10 * 1024 * 1024
+
+// A Statement is the JSON form of a parsed PDF.
+type Statement struct {
+	AccountNumber int64 `json:"account_number"`
+	// Transactions are keyed by the column names of the CSV header.
+	Transactions []map[string]string `json:"transactions"`
+}
+
+// NewStatement converts data to a Statement.
+func NewStatement(data clipper.TransactionData) Statement {
+	st := Statement{AccountNumber: data.AccountNumber, Transactions: []map[string]string{}}
+	if len(data.Transactions) == 0 {
+		return st
+	}
+	header := data.Transactions[0]
+	for _, row := range data.Transactions[1:] {
+		txn := make(map[string]string, len(header))
+		for i, col := range header {
+			if i < len(row) {
+				txn[col] = row[i]
+			}
+		}
+		st.Transactions = append(st.Transactions, txn)
+	}
+	return st
+}
+
+func writeError(w http.ResponseWriter, err *resterror.Error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(err.Status)
+	if err := json.NewEncoder(w).Encode(err); err != nil {
+		handlers.Logger.Warn("error writing response", "err", err)
+	}
+}
+
+// wantsJSON reports whether the client asked for JSON, with ?format=json or
+// an Accept header, rather than CSV.
+func wantsJSON(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "json":
+		return true
+	case "csv":
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// readUpload returns the PDF in r's body, which is either the PDF itself or a
+// multipart form with a single file in the "pdf" (or, as the upload form
+// sends it, "csv") field.
+func readUpload(r *http.Request) ([]byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return io.ReadAll(r.Body)
+	}
+	if err := r.ParseMultipartForm(MaxUpload); err != nil {
+		return nil, err
+	}
+	files := append(r.MultipartForm.File["pdf"], r.MultipartForm.File["csv"]...)
+	if len(files) != 1 {
+		return nil, fmt.Errorf("Please provide exactly one file")
+	}
+	f, err := files[0].Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// parseAPI handles POST /transactions: it parses the uploaded PDF and returns
+// the transactions as CSV or JSON. Nothing uploaded is kept.
+func parseAPI(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxUpload)
+	data, err := readUpload(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if _, ok := err.(*http.MaxBytesError); ok {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, &resterror.Error{Title: err.Error(), ID: "bad_upload", Instance: r.URL.Path, Status: status})
+		return
+	}
+	if len(data) == 0 {
+		writeError(w, &resterror.Error{Title: "Please upload a PDF", ID: "missing_pdf", Instance: r.URL.Path, Status: http.StatusBadRequest})
+		return
+	}
+	txnData, err := clipper.ParsePDFContext(r.Context(), bytes.NewReader(data))
+	if err != nil {
+		writeError(w, &resterror.Error{Title: err.Error(), ID: "invalid_pdf", Instance: r.URL.Path, Status: http.StatusUnprocessableEntity})
+		return
+	}
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(NewStatement(txnData)); err != nil {
+			handlers.Logger.Warn("error writing response", "err", err)
+		}
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="clipper-transactions-%d.csv"`, txnData.AccountNumber))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	if err := txnData.WriteCSV(w, clippercsv.Options{}); err != nil {
+		handlers.Logger.Warn("error writing response", "err", err)
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseAPI(t *testing.T) {
+	pdf, err := os.ReadFile("../testdata/transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := NewServeMux(nil)
+
+	req := httptest.NewRequest("POST", "/transactions", bytes.NewReader(pdf))
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("POST /transactions: got %d: %s", w.Code, w.Body.String())
+	}
+	var st Statement
+	if err := json.Unmarshal(w.Body.Bytes(), &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.AccountNumber != 1202728442 || len(st.Transactions) == 0 || st.Transactions[0]["Location"] == "" {
+		t.Errorf("got %+v", st)
+	}
+
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	fw, err := mw.CreateFormFile("pdf", "statement.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(pdf)
+	mw.Close()
+	req = httptest.NewRequest("POST", "/transactions", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != 200 || !strings.HasPrefix(w.Body.String(), "Date,") {
+		t.Errorf("POST /transactions as a form: got %d: %.100s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/transactions", strings.NewReader("not a pdf")))
+	if w.Code != 422 {
+		t.Errorf("POST /transactions with a bad PDF: got %d, want 422", w.Code)
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinburke/rest/resterror"
+	"golang.org/x/time/rate"
+)
+
+// idleLimiter is how long a client's limiter is kept after its last upload.
+const idleLimiter = 10 * time.Minute
+
+// A Limiter limits how often each client can upload a PDF, so that one client
+// can't keep the server busy parsing. Pages and static files aren't limited.
+type Limiter struct {
+	// ForwardedFor identifies clients by the first address in the
+	// X-Forwarded-For header instead of the connection's address. Only set
+	// it behind a proxy that sets the header.
+	ForwardedFor bool
+
+	limit rate.Limit
+	burst int
+
+	mu      sync.Mutex
+	clients map[string]*client
+	pruned  time.Time
+}
+
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewLimiter returns a Limiter that lets each client upload burst PDFs at once
+// and then perMinute PDFs a minute.
+func NewLimiter(perMinute float64, burst int) *Limiter {
+	return &Limiter{
+		limit:   rate.Limit(perMinute / 60),
+		burst:   burst,
+		clients: make(map[string]*client),
+	}
+}
+
+func (l *Limiter) clientAddr(r *http.Request) string {
+	if l.ForwardedFor {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			addr, _, _ := strings.Cut(fwd, ",")
+			return strings.TrimSpace(addr)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// reserve returns how long the client at addr has to wait before it can
+// upload, or 0 if it can upload now.
+func (l *Limiter) reserve(addr string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.pruned) > time.Minute {
+		for a, c := range l.clients {
+			if now.Sub(c.lastSeen) > idleLimiter {
+				delete(l.clients, a)
+			}
+		}
+		l.pruned = now
+	}
+	c, ok := l.clients[addr]
+	if !ok {
+		c = &client{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[addr] = c
+	}
+	c.lastSeen = now
+	res := c.limiter.ReserveN(now, 1)
+	if !res.OK() {
+		return idleLimiter
+	}
+	if delay := res.DelayFrom(now); delay > 0 {
+		// Don't hold the client's place; it will have to try again.
+		res.CancelAt(now)
+		return delay
+	}
+	return 0
+}
+
+// Handler returns a handler that answers uploads over the limit with
+// 429 Too Many Requests, and passes everything else to h.
+func (l *Limiter) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if wait := l.reserve(l.clientAddr(r), time.Now()); wait > 0 {
+			secs := int(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+			writeError(w, &resterror.Error{
+				Title:  "Too many uploads; try again in " + strconv.Itoa(secs) + " seconds",
+				ID:     "rate_limited",
+				Status: http.StatusTooManyRequests,
+			})
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
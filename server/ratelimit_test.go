@@ -0,0 +1,37 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLimiter(t *testing.T) {
+	h := NewLimiter(1, 2).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	post := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/transactions", nil)
+		req.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	for i := 0; i < 2; i++ {
+		if w := post("192.0.2.1:1234"); w.Code != 200 {
+			t.Fatalf("upload %d: got %d", i+1, w.Code)
+		}
+	}
+	w := post("192.0.2.1:5678")
+	if w.Code != 429 || w.Header().Get("Retry-After") == "" {
+		t.Errorf("third upload: got %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := post("192.0.2.2:1234"); w.Code != 200 {
+		t.Errorf("another client: got %d", w.Code)
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("GET over the limit: got %d", rec.Code)
+	}
+}
@@ -111,5 +111,6 @@ func NewServeMux(key nacl.Key) http.Handler {
 		render(w, r, homepageTpl, "homepage", flashMessage{GetFlashError(w, r, key), GetFlashSuccess(w, r, key)})
 	})
 	r.HandleFunc(regexp.MustCompile(`^/csv$`), []string{"POST"}, csvUpload(key))
+	r.HandleFunc(regexp.MustCompile(`^/transactions$`), []string{"POST"}, parseAPI)
 	return r
 }
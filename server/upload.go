@@ -2,12 +2,12 @@ package server
 
 import (
 	"bytes"
-	"encoding/csv"
 	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clippercsv"
 	"github.com/kevinburke/handlers"
 	"github.com/kevinburke/nacl"
 	"github.com/kevinburke/rest"
@@ -84,7 +84,7 @@ func csvUpload(key nacl.Key) http.HandlerFunc {
 			// should be exactly one key
 			contents := fileDatas[filename]
 			var err error
-			txnData, err = clipper.ParsePDF(bytes.NewReader(contents))
+			txnData, err = clipper.ParsePDFContext(r.Context(), bytes.NewReader(contents))
 			if err != nil {
 				FlashError(w, err.Error(), key)
 				http.Redirect(w, r, "/", http.StatusFound)
@@ -93,8 +93,7 @@ func csvUpload(key nacl.Key) http.HandlerFunc {
 			break
 		}
 		buf := new(bytes.Buffer)
-		csvWriter := csv.NewWriter(buf)
-		if err := csvWriter.WriteAll(txnData.Transactions); err != nil {
+		if err := txnData.WriteCSV(buf, clippercsv.Options{}); err != nil {
 			FlashError(w, err.Error(), key)
 			http.Redirect(w, r, "/", http.StatusFound)
 			return
@@ -0,0 +1,90 @@
+// Package functions runs the statement download as a Google Cloud Function
+// that uploads to Cloud Storage. Deploy it with Sync as the entry point and
+// trigger it from Cloud Scheduler:
+//
+//	gcloud functions deploy clipper-sync --runtime=go124 --trigger-http \
+//		--no-allow-unauthenticated --entry-point=Sync --source=. \
+//		--set-env-vars=CLIPPER_EMAIL=alice@example.com,CLIPPER_BUCKET=my-bucket \
+//		--set-secrets=CLIPPER_PASSWORD=clipper-password:latest
+//
+// The function reads its configuration from the environment, as described in
+// serverless.ConfigFromEnv; use --set-secrets, as above, to keep the password
+// in Secret Manager. The function's service account needs
+// roles/storage.objectCreator on the bucket.
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kevinburke/clipper/serverless"
+	"github.com/kevinburke/handlers"
+	"golang.org/x/oauth2/google"
+)
+
+const storageScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// uploadURL is the Cloud Storage JSON API's simple upload endpoint.
+var uploadURL = "https://storage.googleapis.com/upload/storage/v1/b/"
+
+type bucket struct {
+	client *http.Client
+	name   string
+}
+
+func (b bucket) Put(ctx context.Context, name string, data []byte, contentType string) error {
+	u := uploadURL + url.PathEscape(b.name) + "/o?" + url.Values{
+		"uploadType": {"media"},
+		"name":       {name},
+	}.Encode()
+	req, err := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("functions: Cloud Storage returned %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+// Sync downloads the statements and uploads them to Cloud Storage, and
+// responds with a JSON serverless.Result. It only accepts POST requests, as
+// Cloud Scheduler sends them.
+func Sync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	c := serverless.ConfigFromEnv()
+	if err := c.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	client, err := google.DefaultClient(r.Context(), storageScope)
+	if err != nil {
+		http.Error(w, "functions: finding credentials: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := serverless.Run(r.Context(), c, bucket{client: client, name: c.Bucket}, time.Now())
+	if err != nil {
+		handlers.Logger.Warn("sync failed", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(res)
+}
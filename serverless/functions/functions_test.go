@@ -0,0 +1,39 @@
+package functions
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPut(t *testing.T) {
+	var gotPath, gotName, gotType, gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotName, gotType = r.URL.Path, r.URL.Query().Get("name"), r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+	old := uploadURL
+	uploadURL = ts.URL + "/upload/storage/v1/b/"
+	defer func() { uploadURL = old }()
+
+	b := bucket{client: ts.Client(), name: "my-bucket"}
+	if err := b.Put(context.Background(), "clipper/digests/clipper-2018-01.csv", []byte("a,b\n"), "text/csv"); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/upload/storage/v1/b/my-bucket/o" || gotName != "clipper/digests/clipper-2018-01.csv" || gotType != "text/csv" || gotBody != "a,b\n" {
+		t.Errorf("got %s %s %s %q", gotPath, gotName, gotType, gotBody)
+	}
+}
+
+func TestSyncMethod(t *testing.T) {
+	w := httptest.NewRecorder()
+	Sync(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 405 {
+		t.Errorf("GET: got %d, want 405", w.Code)
+	}
+}
@@ -0,0 +1,92 @@
+// Package lambda runs the statement download as an AWS Lambda function that
+// uploads to S3. Build cmd/clipper-lambda for the provided.al2023 runtime and
+// trigger it with an EventBridge schedule, like cron(0 9 1 * ? *).
+//
+// The function reads its configuration from the environment, as described in
+// serverless.ConfigFromEnv. Set $CLIPPER_SECRET_ID to the name or ARN of a
+// Secrets Manager secret instead of $CLIPPER_PASSWORD to keep the password out
+// of the function's configuration; the secret is either the password or a JSON
+// object with "email" and "password" keys. The function's role needs
+// s3:PutObject on the bucket and secretsmanager:GetSecretValue on the secret.
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/kevinburke/clipper/serverless"
+)
+
+// An Event triggers a download. Scheduled events don't need any fields.
+type Event struct {
+	// DryRun checks the configuration without downloading anything.
+	DryRun bool `json:"dry_run"`
+}
+
+type bucket struct {
+	client *s3.Client
+	name   string
+}
+
+func (b bucket) Put(ctx context.Context, name string, data []byte, contentType string) error {
+	_, err := b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(b.name),
+		Key:         aws.String(name),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+// applySecret fills in c's credentials from a Secrets Manager secret.
+func applySecret(c *serverless.Config, secret string) {
+	var creds struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if strings.HasPrefix(strings.TrimSpace(secret), "{") && json.Unmarshal([]byte(secret), &creds) == nil {
+		if creds.Email != "" {
+			c.Email = creds.Email
+		}
+		c.Password = creds.Password
+		return
+	}
+	c.Password = secret
+}
+
+// Handler downloads the statements and uploads them to S3.
+func Handler(ctx context.Context, ev Event) (serverless.Result, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return serverless.Result{}, fmt.Errorf("lambda: loading AWS config: %v", err)
+	}
+	c := serverless.ConfigFromEnv()
+	if id := os.Getenv("CLIPPER_SECRET_ID"); id != "" {
+		out, err := secretsmanager.NewFromConfig(cfg).GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String(id),
+		})
+		if err != nil {
+			return serverless.Result{}, fmt.Errorf("lambda: reading secret %s: %v", id, err)
+		}
+		applySecret(&c, aws.ToString(out.SecretString))
+	}
+	if ev.DryRun {
+		return serverless.Result{}, c.Validate()
+	}
+	return serverless.Run(ctx, c, bucket{client: s3.NewFromConfig(cfg), name: c.Bucket}, time.Now())
+}
+
+// Start runs Handler as the Lambda function. It doesn't return.
+func Start() {
+	awslambda.Start(Handler)
+}
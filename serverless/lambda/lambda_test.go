@@ -0,0 +1,19 @@
+package lambda
+
+import (
+	"testing"
+
+	"github.com/kevinburke/clipper/serverless"
+)
+
+func TestApplySecret(t *testing.T) {
+	c := serverless.Config{Email: "alice@example.com"}
+	applySecret(&c, "hunter2")
+	if c.Email != "alice@example.com" || c.Password != "hunter2" {
+		t.Errorf("plain secret: got %+v", c)
+	}
+	applySecret(&c, `{"email": "bob@example.com", "password": "swordfish"}`)
+	if c.Email != "bob@example.com" || c.Password != "swordfish" {
+		t.Errorf("JSON secret: got %+v", c)
+	}
+}
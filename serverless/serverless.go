@@ -0,0 +1,165 @@
+// Package serverless runs the statement download as a one-off job, for
+// serverless platforms that run it on a schedule instead of keeping a server
+// up. Each run downloads the latest statements into a temporary directory,
+// uploads them to a bucket along with last month's digest, and exits.
+//
+// The lambda and functions packages adapt Run to AWS Lambda and Google Cloud
+// Functions.
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clippercsv"
+	"github.com/kevinburke/clipper/clipperstats"
+	"github.com/kevinburke/clipper/store"
+	"github.com/kevinburke/clipper/transit"
+)
+
+// Config says whose statements to download and where to put them.
+type Config struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Bucket   string `json:"bucket"`
+	// Prefix is prepended to the name of everything uploaded, like
+	// "clipper/".
+	Prefix string `json:"prefix"`
+}
+
+// ConfigFromEnv reads a Config from $CLIPPER_EMAIL, $CLIPPER_PASSWORD,
+// $CLIPPER_BUCKET and $CLIPPER_PREFIX.
+func ConfigFromEnv() Config {
+	return Config{
+		Email:    os.Getenv("CLIPPER_EMAIL"),
+		Password: os.Getenv("CLIPPER_PASSWORD"),
+		Bucket:   os.Getenv("CLIPPER_BUCKET"),
+		Prefix:   os.Getenv("CLIPPER_PREFIX"),
+	}
+}
+
+// Validate reports whether c has everything Run needs.
+func (c Config) Validate() error {
+	if c.Email == "" || c.Password == "" {
+		return fmt.Errorf("serverless: no Clipper email and password configured")
+	}
+	if c.Bucket == "" {
+		return fmt.Errorf("serverless: no bucket configured")
+	}
+	return nil
+}
+
+// A Bucket stores the artifacts of a run, like S3 or Cloud Storage.
+type Bucket interface {
+	Put(ctx context.Context, name string, data []byte, contentType string) error
+}
+
+// A Result describes what a run uploaded.
+type Result struct {
+	// Uploaded are the names of the objects written to the bucket.
+	Uploaded     []string `json:"uploaded"`
+	Transactions int      `json:"transactions"`
+	// Digest is the month of the uploaded digest, as YYYY-MM.
+	Digest string `json:"digest,omitempty"`
+}
+
+// Run downloads c's statements and uploads them to b under
+// "<prefix>statements/", followed by the digest of the month before now as
+// "<prefix>digests/clipper-YYYY-MM.csv" and ".html". If some of the account's
+// cards fail to download, it uploads the rest and returns what it uploaded
+// along with the error.
+func Run(ctx context.Context, c Config, b Bucket, now time.Time, opts ...clipper.Option) (Result, error) {
+	if err := c.Validate(); err != nil {
+		return Result{}, err
+	}
+	// Lambda and Cloud Functions only let you write to the temp directory.
+	dir, err := os.MkdirTemp("", "clipper-")
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.RemoveAll(dir)
+	client, err := clipper.NewClient(c.Email, c.Password, opts...)
+	if err != nil {
+		return Result{}, fmt.Errorf("serverless: creating client: %v", err)
+	}
+	dlErr := client.DownloadPDFs(ctx, dir, "", "", false)
+	if dlErr != nil && len(clipper.CardErrors(dlErr)) == 0 {
+		return Result{}, fmt.Errorf("serverless: downloading statements: %v", dlErr)
+	}
+	// If only some cards failed, upload the others' statements before
+	// saying so.
+	res, err := upload(ctx, c, b, dir, now)
+	if err != nil {
+		return res, err
+	}
+	if dlErr != nil {
+		return res, fmt.Errorf("serverless: downloading statements: %w", dlErr)
+	}
+	return res, nil
+}
+
+// upload puts the statements in dir, and a digest made from them, in b.
+func upload(ctx context.Context, c Config, b Bucket, dir string, now time.Time) (Result, error) {
+	s, err := store.Open(dir)
+	if err != nil {
+		return Result{}, err
+	}
+	statements, err := s.Statements()
+	if err != nil {
+		return Result{}, err
+	}
+	var res Result
+	for _, path := range statements {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return res, err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return res, err
+		}
+		name := c.Prefix + "statements/" + filepath.ToSlash(rel)
+		if err := b.Put(ctx, name, data, "application/pdf"); err != nil {
+			return res, fmt.Errorf("serverless: uploading %s: %v", name, err)
+		}
+		res.Uploaded = append(res.Uploaded, name)
+	}
+	txns, err := s.Transactions()
+	if err != nil {
+		return res, err
+	}
+	res.Transactions = len(txns)
+	if len(txns) == 0 {
+		return res, nil
+	}
+
+	lastMonth, _ := transit.LastMonth(now)
+	d := clipperstats.MonthlyDigest(txns, lastMonth)
+	var csvData, htmlData bytes.Buffer
+	if err := d.WriteCSV(&csvData, clippercsv.Options{}); err != nil {
+		return res, err
+	}
+	if err := d.WriteHTML(&htmlData); err != nil {
+		return res, err
+	}
+	name := c.Prefix + "digests/clipper-" + d.Start.Format("2006-01")
+	for _, a := range []struct {
+		ext, contentType string
+		data             []byte
+	}{
+		{".csv", "text/csv", csvData.Bytes()},
+		{".html", "text/html; charset=utf-8", htmlData.Bytes()},
+	} {
+		if err := b.Put(ctx, name+a.ext, a.data, a.contentType); err != nil {
+			return res, fmt.Errorf("serverless: uploading %s: %v", name+a.ext, err)
+		}
+		res.Uploaded = append(res.Uploaded, name+a.ext)
+	}
+	res.Digest = d.Start.Format("2006-01")
+	return res, nil
+}
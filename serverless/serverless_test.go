@@ -0,0 +1,54 @@
+package serverless
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memBucket map[string][]byte
+
+func (m memBucket) Put(_ context.Context, name string, data []byte, _ string) error {
+	m[name] = data
+	return nil
+}
+
+func TestUpload(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile("../testdata/transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.pdf"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	b := make(memBucket)
+	c := Config{Email: "alice@example.com", Password: "secret", Bucket: "b", Prefix: "clipper/"}
+	res, err := upload(context.Background(), c, b, dir, time.Date(2018, time.February, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Digest != "2018-01" || res.Transactions == 0 {
+		t.Errorf("got %+v", res)
+	}
+	for _, name := range []string{"clipper/statements/a.pdf", "clipper/digests/clipper-2018-01.csv", "clipper/digests/clipper-2018-01.html"} {
+		if _, ok := b[name]; !ok {
+			t.Errorf("%s wasn't uploaded; got %v", name, res.Uploaded)
+		}
+	}
+	if csv := string(b["clipper/digests/clipper-2018-01.csv"]); !strings.Contains(csv, "2018-01-") {
+		t.Errorf("digest has no January transactions: %s", csv)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Config{Email: "alice@example.com", Password: "secret"}).Validate(); err == nil {
+		t.Error("expected an error without a bucket")
+	}
+	if _, err := Run(context.Background(), Config{Bucket: "b"}, make(memBucket), time.Now()); err == nil {
+		t.Error("expected an error without credentials")
+	}
+}
@@ -0,0 +1,141 @@
+package clipper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/rest"
+)
+
+// A SessionStore keeps a client's session cookies between runs, so that a
+// client can pick up a session an earlier one logged in to instead of
+// logging in again. The keychain package's Keychain is one.
+//
+// Sessions are as good as a password until they expire, so keep them
+// somewhere safe.
+type SessionStore interface {
+	// Get returns the session saved for account, or an error wrapping
+	// fs.ErrNotExist if there isn't one.
+	Get(account string) ([]byte, error)
+	Set(account string, session []byte) error
+	Delete(account string) error
+}
+
+// WithSessionStore makes the client save its session in s after it logs in,
+// and try the saved session before logging in with the password. If the
+// saved session has expired, the client logs in as usual and saves the new
+// one.
+func WithSessionStore(s SessionStore) Option {
+	return func(c *Client) {
+		c.sessions = s
+	}
+}
+
+// A savedSession is what a SessionStore holds for an account.
+type savedSession struct {
+	Saved time.Time `json:"saved"`
+	// UserAgent is the browser the session was made with, which the client
+	// keeps being, so the site doesn't see one session switch browsers.
+	UserAgent string        `json:"user_agent"`
+	Cookies   []savedCookie `json:"cookies"`
+}
+
+type savedCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// passwordFieldRx matches the password input of a login form.
+var passwordFieldRx = regexp.MustCompile(`(?i)<input[^>]+type=["']?password`)
+
+// resumeSession loads the session saved for the client's account and, if
+// the site still takes it, returns the account page. It returns nil if
+// there's no saved session or it has expired; the caller should log in. The
+// caller should hold c.mu.
+func (c *Client) resumeSession(ctx context.Context) *http.Response {
+	if c.sessions == nil {
+		return nil
+	}
+	data, err := c.sessions.Get(c.username)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			rest.Logger.Warn("could not load saved session", "err", err)
+		}
+		return nil
+	}
+	var s savedSession
+	if err := json.Unmarshal(data, &s); err != nil || len(s.Cookies) == 0 {
+		rest.Logger.Warn("ignoring unreadable saved session", "err", err)
+		return nil
+	}
+	cookies := make([]*http.Cookie, len(s.Cookies))
+	for i, sc := range s.Cookies {
+		cookies[i] = &http.Cookie{Name: sc.Name, Value: sc.Value}
+	}
+	c.client.Jar.SetCookies(c.hostURL, cookies)
+	prev := c.browser.Load()
+	if s.UserAgent != "" {
+		c.browser.Store(newBrowser(s.UserAgent))
+	}
+	resp, err := c.dashboard(ctx)
+	if err == nil {
+		var body []byte
+		body, err = readUTF8(resp)
+		resp.Body.Close()
+		if err == nil && c.isLoginPage(resp.Request.URL, body) {
+			err = errors.New("session expired")
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	if err != nil {
+		rest.Logger.Debug("saved session didn't work, logging in", "age", time.Since(s.Saved).Round(time.Minute), "err", err)
+		// Start the login with a clean slate.
+		for _, ck := range cookies {
+			ck.MaxAge = -1
+		}
+		c.client.Jar.SetCookies(c.hostURL, cookies)
+		c.browser.Store(prev)
+		c.sessions.Delete(c.username)
+		return nil
+	}
+	c.event(Event{Action: EventResume})
+	c.saveSession()
+	return resp
+}
+
+// isLoginPage reports whether the page at u asks the visitor to log in, as
+// the account page does once a session has expired.
+func (c *Client) isLoginPage(u *url.URL, body []byte) bool {
+	return !c.isClipperURL(u) || strings.Contains(strings.ToLower(u.Path), "login") || passwordFieldRx.Match(body)
+}
+
+// saveSession saves the client's session cookies, if it has a SessionStore.
+// Saving again after resuming a session keeps cookies the site has renewed.
+func (c *Client) saveSession() {
+	if c.sessions == nil {
+		return
+	}
+	s := savedSession{Saved: time.Now().UTC(), UserAgent: c.UserAgent()}
+	for _, ck := range c.client.Jar.Cookies(c.hostURL) {
+		s.Cookies = append(s.Cookies, savedCookie{Name: ck.Name, Value: ck.Value})
+	}
+	if len(s.Cookies) == 0 {
+		return
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+	if err := c.sessions.Set(c.username, data); err != nil {
+		rest.Logger.Warn("could not save session", "err", err)
+	}
+}
@@ -0,0 +1,180 @@
+package clipper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// memSessions is a SessionStore in memory.
+type memSessions map[string][]byte
+
+func (m memSessions) Get(account string) ([]byte, error) {
+	if s, ok := m[account]; ok {
+		return s, nil
+	}
+	return nil, fs.ErrNotExist
+}
+
+func (m memSessions) Set(account string, session []byte) error {
+	m[account] = session
+	return nil
+}
+
+func (m memSessions) Delete(account string) error {
+	delete(m, account)
+	return nil
+}
+
+// sessionSite is a Clipper site that hands out the session "fresh" to a
+// password login and shows the account page only to live sessions.
+type sessionSite struct {
+	live     map[string]bool
+	logins   int
+	requests []string
+}
+
+func (s *sessionSite) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.requests = append(s.requests, req.Method+" "+req.URL.Path)
+	resp := &http.Response{StatusCode: 200, Header: http.Header{"Content-Type": {"text/html"}}, Request: req}
+	body := `<h1>My Account</h1>`
+	switch req.URL.Path {
+	case "/ClipperWeb/login.html":
+		body = `<form><input name="_csrf" value="tok"><input type="password" name="password"></form>`
+	case "/ClipperWeb/account":
+		s.logins++
+		resp.Header.Set("Set-Cookie", "JSESSIONID=fresh; Path=/; Secure")
+	case "/ClipperWeb/account.html":
+		if ck, err := req.Cookie("JSESSIONID"); err != nil || !s.live[ck.Value] {
+			resp.StatusCode = 302
+			resp.Header.Set("Location", "/ClipperWeb/login.html")
+		}
+	}
+	resp.Body = io.NopCloser(strings.NewReader(body))
+	return resp, nil
+}
+
+func TestSessionStore(t *testing.T) {
+	sessions := memSessions{}
+	site := &sessionSite{live: map[string]bool{"fresh": true}}
+	var events []string
+	newClient := func() *Client {
+		c, err := NewClient("me@example.com", "password", WithTransport(site), WithSessionStore(sessions), WithUserAgentRotation(), WithEvents(func(e Event) {
+			events = append(events, e.Action)
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	// The first run logs in and saves the session.
+	c := newClient()
+	if err := c.ensureLogin(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var s savedSession
+	if err := json.Unmarshal(sessions["me@example.com"], &s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Cookies) != 1 || s.Cookies[0].Value != "fresh" || s.UserAgent != c.UserAgent() {
+		t.Errorf("saved session: %+v", s)
+	}
+
+	// The next one picks it up, as the same browser, without logging in.
+	site.requests = nil
+	c = newClient()
+	body, err := c.dashboardPage(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `<h1>My Account</h1>` || site.logins != 1 || len(site.requests) != 1 {
+		t.Errorf("resumed session: got %q after %q", body, site.requests)
+	}
+	if c.UserAgent() != s.UserAgent {
+		t.Errorf("resumed session as %q, want %q", c.UserAgent(), s.UserAgent)
+	}
+
+	// Once it expires, the client logs in again and saves the new session.
+	site.live = map[string]bool{}
+	c = newClient()
+	if err := c.ensureLogin(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if site.logins != 2 {
+		t.Errorf("logins: got %d, want 2", site.logins)
+	}
+	if got := fmt.Sprint(events); got != "[login resume login]" {
+		t.Errorf("events: got %s", got)
+	}
+	if _, ok := sessions["me@example.com"]; !ok {
+		t.Error("no session saved after logging in again")
+	}
+}
+
+func TestSessionStoreNotUsedOnFailedLogin(t *testing.T) {
+	sessions := memSessions{}
+	c, err := NewClient("me@example.com", "password", WithTransport(lockedTransport{}), WithSessionStore(sessions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ensureLogin(context.Background()); err != ErrAccountLocked {
+		t.Fatalf("ensureLogin: got %v, want ErrAccountLocked", err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("saved a session for a failed login: %q", sessions)
+	}
+}
+
+func TestFileSessionStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	if _, err := NewFileSessionStore(path).Get("me@example.com"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Get with no file: got %v, want fs.ErrNotExist", err)
+	}
+	site := &sessionSite{live: map[string]bool{"fresh": true}}
+	for i := 0; i < 2; i++ {
+		c, err := NewClientWithSession("me@example.com", "password", path, WithTransport(site))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.ensureLogin(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if site.logins != 1 {
+		t.Errorf("logins: got %d, want 1 and then a resumed session", site.logins)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && fi.Mode().Perm() != 0600 {
+		t.Errorf("session file mode: got %v, want only the owner to read it", fi.Mode().Perm())
+	}
+
+	s := NewFileSessionStore(path)
+	if err := s.Set("other@example.com", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("me@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("me@example.com"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Get after Delete: got %v, want fs.ErrNotExist", err)
+	}
+	if got, err := s.Get("other@example.com"); err != nil || string(got) != "x" {
+		t.Errorf("Get: got %q, %v", got, err)
+	}
+	if err := s.Delete("nobody@example.com"); err != nil {
+		t.Errorf("Delete of a missing session: %v", err)
+	}
+}
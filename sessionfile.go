@@ -0,0 +1,117 @@
+package clipper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// A FileSessionStore is a SessionStore that keeps sessions in a file, for
+// systems without a keychain, like a server or a container. The file holds a
+// session for each account, and is only readable by its owner, since a
+// session is as good as a password until it expires.
+//
+// A FileSessionStore is safe to use from several goroutines, and clients.
+type FileSessionStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSessionStore returns a FileSessionStore that keeps sessions in the
+// file at path. The file is created the first time a session is saved.
+func NewFileSessionStore(path string) *FileSessionStore {
+	return &FileSessionStore{path: path}
+}
+
+// NewClientWithSession is like NewClient, but keeps the client's session in
+// the file at path, and picks up the session saved there by an earlier run
+// instead of logging in, while the site still takes it. See
+// FileSessionStore and WithSessionStore.
+func NewClientWithSession(username, password, path string, opts ...Option) (*Client, error) {
+	return NewClient(username, password, append(opts, WithSessionStore(NewFileSessionStore(path)))...)
+}
+
+func (s *FileSessionStore) read() (map[string][]byte, error) {
+	sessions := make(map[string][]byte)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return sessions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, fmt.Errorf("clipper: reading sessions from %s: %v", s.path, err)
+	}
+	return sessions, nil
+}
+
+func (s *FileSessionStore) write(sessions map[string][]byte) error {
+	data, err := json.Marshal(sessions)
+	if err != nil {
+		return err
+	}
+	// CreateTemp makes the file readable only by its owner, and renaming it
+	// into place keeps the old sessions if this fails partway.
+	f, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path)
+}
+
+// Get returns the session saved for account, or an error wrapping
+// fs.ErrNotExist if there isn't one.
+func (s *FileSessionStore) Get(account string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	session, ok := sessions[account]
+	if !ok {
+		return nil, fmt.Errorf("clipper: no session for %s in %s: %w", account, s.path, fs.ErrNotExist)
+	}
+	return session, nil
+}
+
+// Set saves session for account, replacing any session already there.
+func (s *FileSessionStore) Set(account string, session []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions, err := s.read()
+	if err != nil {
+		return err
+	}
+	sessions[account] = session
+	return s.write(sessions)
+}
+
+// Delete removes the session saved for account. Deleting a session that
+// isn't there is not an error.
+func (s *FileSessionStore) Delete(account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := sessions[account]; !ok {
+		return nil
+	}
+	delete(sessions, account)
+	return s.write(sessions)
+}
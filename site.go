@@ -0,0 +1,188 @@
+package clipper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// A SiteAdapter is how a Client talks to a card portal: how it logs in, finds
+// the account's cards and fetches a card's statement. Everything else (date
+// ranges, saving and checking statements, events) is the Client's, so an
+// adapter for another Cubic-run portal, or for an API that replaces the
+// Clipper site's pages, only has to do these three things.
+//
+// The default, ClipperWeb, is clippercard.com. An adapter makes its requests
+// with c.HTTPClient() and c.NewRequest, which keep the client's cookies and
+// browser headers. An adapter may keep state, like a form token, between
+// calls, so give each Client its own.
+type SiteAdapter interface {
+	// Login logs in as c's user, leaving the session in c's cookie jar.
+	Login(ctx context.Context, c *Client) error
+	// Cards returns the account's cards. The client is logged in. If it
+	// can't list them all, it may return the ones it found with the error.
+	Cards(ctx context.Context, c *Client) ([]Card, error)
+	// Statement returns the statement for card covering r, as a PDF. The
+	// client is logged in.
+	Statement(ctx context.Context, c *Client, card Card, r DateRange) (io.ReadCloser, error)
+}
+
+// WithSiteAdapter makes the client use a for the card portal, instead of
+// clippercard.com. Methods that only make sense on clippercard.com, like
+// CardDetails, Notices and Pricing, return an error with other adapters, and
+// only ClipperWeb saves sessions in a SessionStore.
+func WithSiteAdapter(a SiteAdapter) Option {
+	return func(c *Client) {
+		c.site = a
+	}
+}
+
+// WithSiteURL makes ClipperWeb find the Clipper site at rawurl, like
+// "http://127.0.0.1:7080" for a clippermock server, instead of
+// https://www.clippercard.com. NewClient returns an error if rawurl isn't a
+// bare http or https URL.
+func WithSiteURL(rawurl string) Option {
+	return func(c *Client) {
+		c.host = rawurl
+	}
+}
+
+// Credentials returns the username and password the client logs in with, for
+// a SiteAdapter.
+func (c *Client) Credentials() (username string, password *Secret) {
+	return c.username, c.password
+}
+
+// HTTPClient returns the client's HTTP client, with its cookie jar and
+// transport, for a SiteAdapter to make requests with.
+func (c *Client) HTTPClient() *http.Client {
+	return c.client
+}
+
+// NewRequest returns a request for rawurl with the headers the client's
+// browser would send, for a SiteAdapter. referer is the page the request
+// comes from, or "" for one typed into the address bar.
+func (c *Client) NewRequest(ctx context.Context, method, rawurl, referer string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawurl, body)
+	if err != nil {
+		return nil, err
+	}
+	c.setBrowserHeaders(req, referer, false)
+	return req, nil
+}
+
+// Notify tells the function passed to WithEvents, if any, about e. A
+// SiteAdapter calls it with EventLogin each time it logs in.
+func (c *Client) Notify(e Event) {
+	c.event(e)
+}
+
+// ClipperWeb is the SiteAdapter for clippercard.com, and the one a Client uses
+// unless it's given another. Its zero value is ready to use.
+type ClipperWeb struct {
+	// institution is set for the institutional portal, which lists cards
+	// separately; see NewInstitutionalClient.
+	institution bool
+
+	mu sync.Mutex
+	// page is the page the site showed after logging in, the account page,
+	// until the client looks at it.
+	page []byte
+	// token is the CSRF token for the statement form.
+	token string
+}
+
+var errNotClipperWeb = errors.New("clipper: only available on clippercard.com, not with another site adapter")
+
+// clipperWeb returns c's adapter if it's ClipperWeb.
+func (c *Client) clipperWeb() (*ClipperWeb, error) {
+	w, ok := c.site.(*ClipperWeb)
+	if !ok {
+		return nil, errNotClipperWeb
+	}
+	return w, nil
+}
+
+// Login resumes the saved session, if the client has a SessionStore and the
+// site still takes the session, or logs in with the password.
+func (w *ClipperWeb) Login(ctx context.Context, c *Client) error {
+	resp, err := c.login(ctx)
+	if err != nil {
+		return err
+	}
+	page, err := readUTF8(resp)
+	if err != nil {
+		return err
+	}
+	if err := resp.Body.Close(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.page, w.token = page, ""
+	w.mu.Unlock()
+	return nil
+}
+
+// takePage returns the page the site showed after logging in, if nothing has
+// looked at it yet.
+func (w *ClipperWeb) takePage() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	page := w.page
+	w.page = nil
+	return page
+}
+
+// cardsPage is the page that lists the account's cards, which also has the
+// statement form.
+func (w *ClipperWeb) cardsPage(c *Client) string {
+	if w.institution {
+		return c.host + institutionPath
+	}
+	return c.host + "/ClipperWeb/account.html"
+}
+
+// Cards returns the cards on the account page, following its pagination.
+func (w *ClipperWeb) Cards(ctx context.Context, c *Client) ([]Card, error) {
+	if w.institution {
+		return c.institutionCards(ctx)
+	}
+	return c.cards(ctx)
+}
+
+// Statement submits the site's transaction history form for card.
+func (w *ClipperWeb) Statement(ctx context.Context, c *Client, card Card, r DateRange) (io.ReadCloser, error) {
+	w.mu.Lock()
+	token := w.token
+	w.mu.Unlock()
+	if token == "" {
+		var err error
+		if token, err = c.statementToken(ctx, w.cardsPage(c)); err != nil {
+			return nil, err
+		}
+		w.mu.Lock()
+		w.token = token
+		w.mu.Unlock()
+	}
+	return c.statement(ctx, card, statementForm(token, card, r), w.cardsPage(c))
+}
+
+// statementForm returns the transaction history form for card, covering r.
+func statementForm(token string, card Card, r DateRange) url.Values {
+	start, end := r.formDates()
+	data := url.Values{}
+	data.Set("_csrf", token)
+	data.Set("cardNumber", strconv.FormatInt(card.SerialNumber, 10))
+	data.Set("cardNickName", card.Nickname)
+	data.Set("rhStartDate", start)
+	data.Set("startDateValue", start)
+	data.Set("startDate", start)
+	data.Set("rhEndDate", end)
+	data.Set("endDateValue", end)
+	data.Set("endDate", end)
+	return data
+}
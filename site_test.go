@@ -0,0 +1,177 @@
+package clipper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePortal is a SiteAdapter for a portal with two cards. Their statements
+// are in pdfs; a card without one fails.
+type fakePortal struct {
+	mu     sync.Mutex
+	logins int
+	ranges []DateRange
+	pdfs   map[int64][]byte
+	// delay, if set, is how long a statement takes.
+	delay time.Duration
+	// paced, if set, makes each statement send a request with the client
+	// first, so that it waits for the client's Pacer.
+	paced bool
+	// inFlight is how many statements are being fetched now, and
+	// maxInFlight the most there have been at once.
+	inFlight, maxInFlight int
+}
+
+// testStatements returns the statements in testdata for the two cards on a
+// fakePortal.
+func testStatements(t *testing.T) map[int64][]byte {
+	t.Helper()
+	pdfs := make(map[int64][]byte)
+	for serial, name := range map[int64]string{1202728442: "transactions.pdf", 1401491737: "no-transactions.pdf"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pdfs[serial] = data
+	}
+	return pdfs
+}
+
+func (p *fakePortal) Login(ctx context.Context, c *Client) error {
+	p.logins++
+	c.Notify(Event{Action: EventLogin})
+	return nil
+}
+
+func (p *fakePortal) Cards(ctx context.Context, c *Client) ([]Card, error) {
+	return []Card{{SerialNumber: 1202728442, Nickname: "Commute"}, {SerialNumber: 1401491737}}, nil
+}
+
+func (p *fakePortal) Statement(ctx context.Context, c *Client, card Card, r DateRange) (io.ReadCloser, error) {
+	if p.paced {
+		req, err := http.NewRequestWithContext(ctx, "GET", c.host+"/ClipperWeb/account.html", nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+	}
+	p.mu.Lock()
+	p.ranges = append(p.ranges, r)
+	p.inFlight++
+	p.maxInFlight = max(p.maxInFlight, p.inFlight)
+	pdf, ok := p.pdfs[card.SerialNumber]
+	p.mu.Unlock()
+	var err error
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	p.mu.Lock()
+	p.inFlight--
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("statement unavailable")
+	}
+	return io.NopCloser(bytes.NewReader(pdf)), nil
+}
+
+func TestSiteAdapter(t *testing.T) {
+	portal := &fakePortal{pdfs: testStatements(t)}
+	var events []Event
+	c, err := NewClient("me@example.com", "password", WithSiteAdapter(portal), WithTransport(lockedTransport{}), WithEvents(func(e Event) {
+		events = append(events, e)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ParseDateRange("2024-03-01", "2024-03-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := c.DownloadRange(context.Background(), dir, r, false); err != nil {
+		t.Fatal(err)
+	}
+	if portal.logins != 1 || len(portal.ranges) != 2 || portal.ranges[0] != r {
+		t.Errorf("portal saw %d logins and ranges %v", portal.logins, portal.ranges)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "clipper-transactions-1202728442.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := portal.pdfs[1202728442]; !bytes.Equal(got, want) {
+		t.Errorf("saved %d bytes, want %d", len(got), len(want))
+	}
+	if len(events) != 3 || events[0].Action != EventLogin || events[2].Action != EventDownload || events[2].Card != 1401491737 {
+		t.Errorf("events: %+v", events)
+	}
+	if _, err := c.Notices(context.Background()); err != errNotClipperWeb {
+		t.Errorf("Notices: got %v, want errNotClipperWeb", err)
+	}
+}
+
+func TestPartialResults(t *testing.T) {
+	portal := &fakePortal{pdfs: testStatements(t)}
+	delete(portal.pdfs, 1401491737)
+	c, err := NewClient("me@example.com", "password", WithSiteAdapter(portal), WithTransport(lockedTransport{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	err = c.DownloadRange(context.Background(), dir, DateRange{}, false)
+	cerrs := CardErrors(err)
+	if len(cerrs) != 1 || cerrs[0].Card != 1401491737 || !strings.Contains(err.Error(), "card 1401491737: statement unavailable") {
+		t.Fatalf("DownloadRange: got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "clipper-transactions-1202728442.pdf")); err != nil {
+		t.Errorf("the working card's statement wasn't saved: %v", err)
+	}
+
+	txns, err := c.Transactions(context.Background())
+	var cerr *CardError
+	if !errors.As(err, &cerr) || cerr.Card != 1401491737 {
+		t.Fatalf("Transactions: got %v", err)
+	}
+	if len(txns) != 1 || len(txns[Card{SerialNumber: 1202728442, Nickname: "Commute"}].Transactions) != 32 {
+		t.Errorf("Transactions: got %d cards", len(txns))
+	}
+}
+
+func TestWrongCard(t *testing.T) {
+	portal := &fakePortal{pdfs: testStatements(t)}
+	// Clipper sends the first card's statement for the second one.
+	portal.pdfs[1401491737] = portal.pdfs[1202728442]
+	c, err := NewClient("me@example.com", "password", WithSiteAdapter(portal), WithTransport(lockedTransport{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	err = c.DownloadRange(context.Background(), dir, DateRange{}, false)
+	var wrong *WrongCardError
+	if !errors.As(err, &wrong) || wrong.Want != 1401491737 || wrong.Got != 1202728442 {
+		t.Fatalf("DownloadRange: got %v, want a WrongCardError", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "clipper-transactions-1401491737.pdf")); !os.IsNotExist(err) {
+		t.Errorf("saved the other card's statement: %v", err)
+	}
+	if _, err := c.Transactions(context.Background()); !errors.As(err, &wrong) {
+		t.Errorf("Transactions: got %v, want a WrongCardError", err)
+	}
+}
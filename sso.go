@@ -0,0 +1,238 @@
+package clipper
+
+// Clipper is moving account login behind a hosted identity provider. Instead of
+// rendering a form that POSTs to /ClipperWeb/account, the login page redirects
+// to the provider, which shows its own (sometimes multi-step) form and then
+// sends the browser back to Clipper with an authorization code. Clipper's
+// callback exchanges the code for a session cookie.
+//
+// We follow the same path a browser would: fill in whatever credential form the
+// provider shows, submit the auto-posting forms that carry the code back
+// (response_mode=form_post), and copy cookies that the provider sets from
+// JavaScript into the jar.
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// maxSSOSteps bounds the number of forms we will submit before giving up.
+const maxSSOSteps = 10
+
+// WithSSOHosts lets the client log in through an identity provider at hosts,
+// like "example.auth0.com", as well as the Clipper site and the other hosts
+// on its domain, like login.clippercard.com. A login that's sent anywhere
+// else fails before the client fills in a form there, so the password only
+// goes to hosts the client trusts.
+func WithSSOHosts(hosts ...string) Option {
+	return func(c *Client) {
+		c.ssoHosts = append(c.ssoHosts, hosts...)
+	}
+}
+
+// isSSOHost reports whether the client may follow a login to u: the Clipper
+// site, another host on its domain or a host from WithSSOHosts.
+func (c *Client) isSSOHost(u *url.URL) bool {
+	if u.Host == c.hostURL.Host {
+		return true
+	}
+	for _, h := range c.ssoHosts {
+		if strings.EqualFold(u.Host, h) || strings.EqualFold(u.Hostname(), h) {
+			return true
+		}
+	}
+	// A site at an IP address or a name like localhost, as for clippermock,
+	// has no domain to share.
+	if net.ParseIP(c.hostURL.Hostname()) != nil {
+		return false
+	}
+	site, err := publicsuffix.EffectiveTLDPlusOne(c.hostURL.Hostname())
+	if err != nil {
+		return false
+	}
+	name := strings.ToLower(u.Hostname())
+	return name == site || strings.HasSuffix(name, "."+site)
+}
+
+// checkSSOHost returns an error if the client may not follow a login to u,
+// because it's not on a host from isSSOHost or, unless the Clipper site is
+// itself served over plain HTTP, like clippermock, it's not HTTPS.
+func (c *Client) checkSSOHost(u *url.URL) error {
+	if u.Scheme != "https" && u.Scheme != c.hostURL.Scheme {
+		return fmt.Errorf("clipper: login went to %s over %s, not https", u.Host, u.Scheme)
+	}
+	if !c.isSSOHost(u) {
+		return fmt.Errorf("clipper: login went to %s, which isn't Clipper or a known identity provider; see WithSSOHosts", u.Host)
+	}
+	return nil
+}
+
+// isClipperURL reports whether u is a page on the ClipperWeb site, as opposed
+// to the identity provider.
+func (c *Client) isClipperURL(u *url.URL) bool {
+	return u.Host == c.hostURL.Host && strings.HasPrefix(u.Path, "/ClipperWeb/")
+}
+
+// ssoLogin completes a login that was redirected to an identity provider. resp
+// is the provider's first page; its body has already been read into body.
+//
+// On success the returned response is the Clipper page the provider redirected
+// back to, with its body intact. The caller should hold c.mu.
+func (c *Client) ssoLogin(ctx context.Context, resp *http.Response, body []byte) (*http.Response, error) {
+	sentPassword := false
+	for step := 0; step < maxSSOSteps; step++ {
+		page := resp.Request.URL
+		if err := c.checkSSOHost(page); err != nil {
+			return nil, err
+		}
+		// The first page is the provider's login form, which hasn't been
+		// submitted yet.
+		if step > 0 {
+			if err := checkLoginPage(page, body); err != nil {
+				return nil, err
+			}
+		}
+		c.setJSCookies(page, body)
+		form, isCredentials := c.nextSSOForm(body)
+		if form == nil {
+			if c.isClipperURL(page) {
+				resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+				return resp, nil
+			}
+			return nil, fmt.Errorf("clipper: login did not return to Clipper, stopped at %s", page.Redacted())
+		}
+		if isCredentials && form.PasswordField != "" {
+			if sentPassword {
+				return nil, errors.New("clipper: login failed, the identity provider asked for the password again")
+			}
+			sentPassword = true
+		}
+		var err error
+		resp, body, err = c.submitForm(ctx, page, form)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != 200 {
+			return nil, fmt.Errorf("could not login: want 200 response code from %s, got %d", resp.Request.URL.Redacted(), resp.StatusCode)
+		}
+	}
+	return nil, fmt.Errorf("clipper: login did not complete after %d steps", maxSSOSteps)
+}
+
+// callbackFields are inputs that only appear in forms that hand an identity
+// provider's response back to the relying party.
+var callbackFields = []string{"code", "id_token", "access_token", "SAMLResponse"}
+
+// nextSSOForm finds the form to submit next on an identity provider page, and
+// fills in credentials if the form asks for them. isCredentials is true if the
+// form asks for a username or password.
+func (c *Client) nextSSOForm(body []byte) (form *htmlForm, isCredentials bool) {
+	forms := findForms(bytes.NewReader(body))
+	for i := range forms {
+		f := &forms[i]
+		if f.PasswordField == "" && f.UsernameField == "" {
+			continue
+		}
+		// Some providers ask for the email on one page and the password on
+		// the next.
+		if f.UsernameField != "" {
+			f.Values.Set(f.UsernameField, c.username)
+		}
+		if f.PasswordField != "" {
+			f.Values.Set(f.PasswordField, c.password.Reveal())
+		}
+		return f, true
+	}
+	for i := range forms {
+		for _, field := range callbackFields {
+			if _, ok := forms[i].Values[field]; ok {
+				return &forms[i], false
+			}
+		}
+	}
+	return nil, false
+}
+
+// submitForm submits form as a browser would from page, and returns the final
+// response after redirects along with its body.
+func (c *Client) submitForm(ctx context.Context, page *url.URL, form *htmlForm) (*http.Response, []byte, error) {
+	action, err := page.Parse(form.Action)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.checkSSOHost(action); err != nil {
+		return nil, nil, err
+	}
+	var req *http.Request
+	if form.Method == "GET" {
+		action.RawQuery = form.Values.Encode()
+		req, err = http.NewRequest("GET", action.String(), nil)
+	} else {
+		req, err = http.NewRequest("POST", action.String(), strings.NewReader(form.Values.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.WithContext(ctx)
+	c.setBrowserHeaders(req, page.String(), false)
+	// A 307 or 308 redirect sends the form, and any password in it, on to
+	// the next host, so check each one.
+	client := *c.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return c.checkSSOHost(req.URL)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := resp.Body.Close(); err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
+
+var jsCookieRx = regexp.MustCompile(`document\.cookie\s*=\s*["']([^"']+)["']`)
+
+// findJSCookies returns the cookies that inline scripts in body set by
+// assigning to document.cookie.
+func findJSCookies(body []byte) []*http.Cookie {
+	var cookies []*http.Cookie
+	for _, m := range jsCookieRx.FindAllSubmatch(body, -1) {
+		// document.cookie assignments use Set-Cookie syntax.
+		cookie, err := http.ParseSetCookie(string(m[1]))
+		if err != nil {
+			continue
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies
+}
+
+func (c *Client) setJSCookies(page *url.URL, body []byte) {
+	if c.client.Jar == nil {
+		return
+	}
+	if cookies := findJSCookies(body); len(cookies) > 0 {
+		c.client.Jar.SetCookies(page, cookies)
+	}
+}
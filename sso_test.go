@@ -0,0 +1,193 @@
+package clipper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+var providerLoginPage = []byte(`<html><body>
+<script>document.cookie = "idp_session=abc123; path=/; secure";</script>
+<form method="post" action="/u/login?state=xyz">
+<input type="hidden" name="state" value="xyz">
+<input type="email" name="username" value="">
+<input type="password" name="password">
+<button type="submit" name="action" value="default">Continue</button>
+</form>
+</body></html>`)
+
+var providerCallbackPage = []byte(`<html><body onload="document.forms[0].submit()">
+<form method="post" action="https://www.clippercard.com/ClipperWeb/oauth/callback">
+<input type="hidden" name="code" value="auth-code"/>
+<input type="hidden" name="state" value="xyz"/>
+<noscript><input type="submit" value="Continue"/></noscript>
+</form>
+</body></html>`)
+
+func TestNextSSOFormCredentials(t *testing.T) {
+	c := &Client{username: "me@example.com", password: NewSecret("hunter2")}
+	form, isCredentials := c.nextSSOForm(providerLoginPage)
+	if form == nil || !isCredentials {
+		t.Fatalf("expected a credentials form, got %#v", form)
+	}
+	if form.Method != "POST" || form.Action != "/u/login?state=xyz" {
+		t.Errorf("bad form method/action: %q %q", form.Method, form.Action)
+	}
+	if got := form.Values.Get("username"); got != "me@example.com" {
+		t.Errorf("username: got %q", got)
+	}
+	if got := form.Values.Get("password"); got != "hunter2" {
+		t.Errorf("password: got %q", got)
+	}
+	if got := form.Values.Get("state"); got != "xyz" {
+		t.Errorf("state: got %q", got)
+	}
+}
+
+func TestNextSSOFormCallback(t *testing.T) {
+	c := &Client{username: "me@example.com", password: NewSecret("hunter2")}
+	form, isCredentials := c.nextSSOForm(providerCallbackPage)
+	if form == nil || isCredentials {
+		t.Fatalf("expected a callback form, got %#v", form)
+	}
+	if got := form.Values.Get("code"); got != "auth-code" {
+		t.Errorf("code: got %q", got)
+	}
+	if _, ok := form.Values["password"]; ok {
+		t.Errorf("callback form should not include credentials")
+	}
+}
+
+func TestFindJSCookies(t *testing.T) {
+	cookies := findJSCookies(providerLoginPage)
+	if len(cookies) != 1 {
+		t.Fatalf("expected 1 cookie, got %d", len(cookies))
+	}
+	if cookies[0].Name != "idp_session" || cookies[0].Value != "abc123" {
+		t.Errorf("bad cookie: %v", cookies[0])
+	}
+}
+
+func TestSSOHosts(t *testing.T) {
+	var stolen []string
+	evil := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		stolen = append(stolen, r.Method+" "+r.Form.Get("password"))
+	}))
+	defer evil.Close()
+	var posted []string
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.Write(providerLoginPage)
+			return
+		}
+		r.ParseForm()
+		posted = append(posted, r.Form.Get("password"))
+		// Send the form, password and all, on to another host.
+		http.Redirect(w, r, evil.URL+"/collect", http.StatusTemporaryRedirect)
+	}))
+	defer idp.Close()
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, idp.URL+"/authorize", http.StatusFound)
+	}))
+	defer site.Close()
+
+	// An identity provider the client doesn't know never sees the password.
+	c, err := NewClient("me@example.com", "hunter2", WithSiteURL(site.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Cards(context.Background()); err == nil || !strings.Contains(err.Error(), "WithSSOHosts") {
+		t.Errorf("login through an unknown host: got %v", err)
+	}
+	if len(posted) != 0 {
+		t.Errorf("unknown identity provider got the password")
+	}
+
+	// A known one does, but a redirect from it to somewhere else doesn't.
+	u, _ := url.Parse(idp.URL)
+	c, err = NewClient("me@example.com", "hunter2", WithSiteURL(site.URL), WithSSOHosts(u.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Cards(context.Background()); err == nil {
+		t.Error("login redirected to an unknown host: got nil error")
+	}
+	if len(posted) != 1 || posted[0] != "hunter2" {
+		t.Errorf("identity provider got %q, want the password", posted)
+	}
+	if len(stolen) != 0 {
+		t.Errorf("unknown host got %q", stolen)
+	}
+}
+
+func TestSSOHTTP(t *testing.T) {
+	var posted []string
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.Write(providerLoginPage)
+			return
+		}
+		r.ParseForm()
+		posted = append(posted, r.Form.Get("password"))
+	}))
+	defer idp.Close()
+	site := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, idp.URL+"/authorize", http.StatusFound)
+	}))
+	defer site.Close()
+
+	// Even a known identity provider doesn't get the password over plain
+	// HTTP when Clipper is served over HTTPS.
+	u, _ := url.Parse(idp.URL)
+	c, err := NewClient("me@example.com", "hunter2", WithSiteURL(site.URL), WithTransport(site.Client().Transport), WithSSOHosts(u.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Cards(context.Background()); err == nil || !strings.Contains(err.Error(), "not https") {
+		t.Errorf("login redirected to http: got %v", err)
+	}
+	if len(posted) != 0 {
+		t.Errorf("identity provider got the password over http")
+	}
+
+	c, err = NewClient("me@example.com", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rawurl := range []string{"http://login.clippercard.com/u/login", "http://www.clippercard.com/ClipperWeb/login.html"} {
+		u, _ := url.Parse(rawurl)
+		if err := c.checkSSOHost(u); err == nil {
+			t.Errorf("checkSSOHost(%s): got nil error", rawurl)
+		}
+	}
+}
+
+func TestIsSSOHost(t *testing.T) {
+	c, err := NewClient("me@example.com", "hunter2", WithSSOHosts("example.auth0.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		url  string
+		want bool
+	}{
+		{"https://www.clippercard.com/ClipperWeb/login.html", true},
+		{"https://login.clippercard.com/u/login", true},
+		{"https://example.auth0.com/u/login", true},
+		{"https://evil.example.com/u/login", false},
+		{"https://clippercard.com.evil.example.com/", false},
+		{"https://notclippercard.com/", false},
+	} {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.isSSOHost(u); got != tt.want {
+			t.Errorf("isSSOHost(%s): got %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
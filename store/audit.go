@@ -0,0 +1,132 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// AuditFile is the file in the archive directory that holds the audit log,
+// one JSON AuditEvent per line. Events are only ever appended to it.
+const AuditFile = "audit.log"
+
+// Actions in the audit log.
+const (
+	AuditLogin    = "login"
+	AuditDownload = "download"
+	AuditParse    = "parse"
+	AuditExport   = "export"
+	AuditAPI      = "api"
+)
+
+// Outcomes in the audit log.
+const (
+	AuditOK     = "ok"
+	AuditDenied = "denied"
+	AuditError  = "error"
+)
+
+// An AuditEvent records something done with an account's credentials or
+// data.
+type AuditEvent struct {
+	Time time.Time `json:"time"`
+	// Actor is who did it: the name of an API token, or "sync", "server",
+	// "slack" or "cli".
+	Actor  string `json:"actor"`
+	Action string `json:"action"`
+	// Account is the user whose account or data it was, if known.
+	Account string `json:"account,omitempty"`
+	Card    int64  `json:"card,omitempty"`
+	// Detail says more, like the API request or the file written.
+	Detail  string `json:"detail,omitempty"`
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// An AuditQuery selects events from the audit log. Zero fields match every
+// event.
+type AuditQuery struct {
+	// Since and Until bound the events' times; Until is exclusive.
+	Since, Until time.Time
+	Actor        string
+	Action       string
+	Account      string
+	Card         int64
+	Outcome      string
+	// Limit, if positive, returns only the most recent Limit events.
+	Limit int
+}
+
+func (q AuditQuery) match(e AuditEvent) bool {
+	switch {
+	case !q.Since.IsZero() && e.Time.Before(q.Since),
+		!q.Until.IsZero() && !e.Time.Before(q.Until),
+		q.Actor != "" && e.Actor != q.Actor,
+		q.Action != "" && e.Action != q.Action,
+		q.Account != "" && e.Account != q.Account,
+		q.Card != 0 && e.Card != q.Card,
+		q.Outcome != "" && e.Outcome != q.Outcome:
+		return false
+	}
+	return true
+}
+
+// Audit appends e to the audit log. If e.Time is zero, it's set to now.
+func (s *Store) Audit(e AuditEvent) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+	f, err := os.OpenFile(filepath.Join(s.dir, AuditFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// AuditLog returns the events in the audit log that match q, oldest first.
+func (s *Store) AuditLog(q AuditQuery) ([]AuditEvent, error) {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+	events := []AuditEvent{}
+	f, err := os.Open(filepath.Join(s.dir, AuditFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return events, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		var e AuditEvent
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("store: %s line %d: %v", AuditFile, n, err)
+		}
+		if q.match(e) {
+			events = append(events, e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if q.Limit > 0 && len(events) > q.Limit {
+		events = events[len(events)-q.Limit:]
+	}
+	return events, nil
+}
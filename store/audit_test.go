@@ -0,0 +1,52 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAudit(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := s.AuditLog(AuditQuery{})
+	if err != nil || len(events) != 0 {
+		t.Fatalf("AuditLog without an audit file: got %v, %v", events, err)
+	}
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, e := range []AuditEvent{
+		{Actor: "sync", Action: AuditLogin, Account: "alice", Outcome: AuditOK},
+		{Actor: "sync", Action: AuditDownload, Account: "alice", Card: 1202728442, Outcome: AuditOK},
+		{Actor: "sync", Action: AuditLogin, Account: "bob", Outcome: AuditError, Error: "account locked"},
+		{Actor: "phone", Action: AuditAPI, Card: 1202728442, Detail: "GET /transactions", Outcome: AuditOK},
+	} {
+		e.Time = start.Add(time.Duration(i) * time.Hour)
+		if err := s.Audit(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range []struct {
+		q    AuditQuery
+		want int
+	}{
+		{AuditQuery{}, 4},
+		{AuditQuery{Account: "alice"}, 2},
+		{AuditQuery{Card: 1202728442}, 2},
+		{AuditQuery{Action: AuditLogin, Outcome: AuditError}, 1},
+		{AuditQuery{Since: start.Add(time.Hour), Until: start.Add(3 * time.Hour)}, 2},
+		{AuditQuery{Limit: 3}, 3},
+	} {
+		events, err := s.AuditLog(tt.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != tt.want {
+			t.Errorf("AuditLog(%+v): got %d events, want %d", tt.q, len(events), tt.want)
+		}
+	}
+	events, _ = s.AuditLog(AuditQuery{Limit: 1})
+	if len(events) != 1 || events[0].Actor != "phone" {
+		t.Errorf("Limit should keep the most recent events, got %+v", events)
+	}
+}
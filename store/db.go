@@ -0,0 +1,124 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// dbSchema is the schema of a DB. Transactions are keyed by their ID, so
+// adding one that's already there does nothing. time is the transaction's
+// time in RFC 3339 format, Pacific time, for people querying the database by
+// hand; unix orders them.
+const dbSchema = `
+CREATE TABLE IF NOT EXISTS transactions (
+	id TEXT PRIMARY KEY,
+	time TEXT NOT NULL,
+	unix INTEGER NOT NULL,
+	card INTEGER NOT NULL,
+	type TEXT NOT NULL,
+	location TEXT NOT NULL,
+	route TEXT NOT NULL,
+	product TEXT NOT NULL,
+	debit_cents INTEGER NOT NULL,
+	credit_cents INTEGER NOT NULL,
+	balance_cents INTEGER NOT NULL,
+	source TEXT NOT NULL,
+	row INTEGER NOT NULL,
+	added TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS transactions_unix ON transactions (unix);
+`
+
+// A DB is a SQLite database of transactions. Unlike an archive of
+// statements, it keeps every transaction ever added to it, so the history
+// grows with each download even once old statements are deleted, and a
+// transaction that's in several downloads is only stored once.
+//
+// A DB is safe to use from several goroutines, and processes: a write waits
+// for another process's to finish.
+type DB struct {
+	db *sql.DB
+}
+
+// OpenDB opens the database at path, creating it if it doesn't exist.
+func OpenDB(path string) (*DB, error) {
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=10000")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(dbSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("store: opening %s: %v", path, err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Add adds txns to the database, skipping any it already has (see ID), and
+// returns how many were new. It adds all of them or, if it returns an error,
+// none.
+func (d *DB) Add(txns []clipper.Transaction) (int, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`INSERT INTO transactions
+		(id, time, unix, card, type, location, route, product, debit_cents, credit_cents, balance_cents, source, row, added)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	added := time.Now().UTC().Format(time.RFC3339)
+	n := 0
+	for _, t := range txns {
+		res, err := stmt.Exec(ID(t), t.Timestamp.In(clipper.Pacific).Format(time.RFC3339), t.Timestamp.Unix(),
+			t.CardSerial, t.Type, t.Location, t.Route, t.Product,
+			int64(t.DebitCents), int64(t.CreditCents), int64(t.BalanceCents), t.Source, t.Row, added)
+		if err != nil {
+			return 0, fmt.Errorf("store: adding transaction %s: %v", ID(t), err)
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		n += int(rows)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Transactions returns every transaction in the database, oldest first, in
+// the same order Merge would.
+func (d *DB) Transactions() ([]clipper.Transaction, error) {
+	rows, err := d.db.Query(`SELECT unix, card, type, location, route, product, debit_cents, credit_cents, balance_cents, source, row
+		FROM transactions ORDER BY unix, card, rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var txns []clipper.Transaction
+	for rows.Next() {
+		var t clipper.Transaction
+		var unix int64
+		if err := rows.Scan(&unix, &t.CardSerial, &t.Type, &t.Location, &t.Route, &t.Product,
+			&t.DebitCents, &t.CreditCents, &t.BalanceCents, &t.Source, &t.Row); err != nil {
+			return nil, err
+		}
+		t.Timestamp = time.Unix(unix, 0).In(clipper.Pacific)
+		txns = append(txns, t)
+	}
+	return txns, rows.Err()
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDB(t *testing.T) {
+	txns, err := ParseStatement("../testdata/transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "clipper.db")
+	db, err := OpenDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := db.Add(txns[:10])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 10 {
+		t.Errorf("first Add: got %d new transactions, want 10", n)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later download overlaps the first.
+	db, err = OpenDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	n, err = db.Add(txns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(txns)-10 {
+		t.Errorf("second Add: got %d new transactions, want %d", n, len(txns)-10)
+	}
+	got, err := db.Transactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Merge(txns)
+	if len(got) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if ID(got[i]) != ID(want[i]) || !got[i].Timestamp.Equal(want[i].Timestamp) || got[i].Source != want[i].Source || got[i].Row != want[i].Row {
+			t.Errorf("transaction %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if loc := got[0].Timestamp.Location(); loc != want[0].Timestamp.Location() {
+		t.Errorf("got times in %v, want %v", loc, want[0].Timestamp.Location())
+	}
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// JobsFile is the file in the archive directory where the server keeps its
+// job queue (see the queue package), so that job schedules and history
+// survive a restart.
+const JobsFile = "jobs.json"
+
+// LoadJobs reads the job queue saved with SaveJobs into v. If nothing has
+// been saved, it leaves v alone.
+func (s *Store) LoadJobs(v interface{}) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, JobsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("store: reading %s: %v", JobsFile, err)
+	}
+	return nil
+}
+
+// SaveJobs saves v, a job queue, in the archive.
+func (s *Store) SaveJobs(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, JobsFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,23 @@
+package store
+
+import "testing"
+
+func TestSaveJobs(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := []string{"unchanged"}
+	if err := s.LoadJobs(&jobs); err != nil || len(jobs) != 1 {
+		t.Fatalf("LoadJobs without a jobs file: got %v, %v", jobs, err)
+	}
+	if err := s.SaveJobs([]string{"alice", "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LoadJobs(&jobs); err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[1] != "bob" {
+		t.Errorf("LoadJobs: got %v", jobs)
+	}
+}
@@ -0,0 +1,196 @@
+// Package store reads the archive of Clipper transaction history kept on disk.
+//
+// The archive is a directory of PDF statements, as saved by
+// clipper-pdf-downloader. Statements can be named anything and kept in
+// subdirectories. They often overlap (each download covers the last few
+// months), so transactions that appear in more than one statement are only
+// returned once.
+//
+// A DB keeps the transactions from every download in a SQLite database, so
+// the history outlasts the statements it came from.
+package store
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinburke/clipper"
+)
+
+// A Store is an archive of statements in a directory.
+type Store struct {
+	dir string
+	// auditMu keeps appends to the audit log from interleaving.
+	auditMu sync.Mutex
+	// syncMu keeps updates to the sync state from racing.
+	syncMu sync.Mutex
+}
+
+// Open returns the Store for the archive in dir, which must exist.
+func Open(dir string) (*Store, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("store: %s is not a directory", dir)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir returns the directory that holds the archive.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Statements returns the paths of the PDF statements in the archive, sorted by
+// name.
+func (s *Store) Statements() ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".pdf") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// ParseStatement parses the PDF statement at path.
+func ParseStatement(path string) ([]clipper.Transaction, error) {
+	data, err := clipper.ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return statementTransactions(path, data)
+}
+
+// statementTransactions returns the transactions in data, parsed from the
+// statement at path.
+func statementTransactions(path string, data clipper.TransactionData) ([]clipper.Transaction, error) {
+	txns, err := data.ParsedTransactions()
+	if err != nil {
+		return nil, fmt.Errorf("store: parsing %s: %v", path, err)
+	}
+	for i := range txns {
+		txns[i].Source = path
+	}
+	return txns, nil
+}
+
+// key identifies a transaction across statements.
+type key struct {
+	unix     int64
+	card     int64
+	typ      string
+	location string
+	debit    clipper.Money
+	credit   clipper.Money
+	balance  clipper.Money
+}
+
+// wallClock returns the time printed on t's statement as seconds since the
+// Unix epoch, as though it were UTC. Statement times used to be read as UTC,
+// and IDs were computed from them; hashing the printed time instead of the
+// instant keeps IDs, and tags and API clients that refer to them, the same
+// now that they're read as Pacific time.
+func wallClock(t clipper.Transaction) int64 {
+	ts := t.Timestamp
+	return time.Date(ts.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0, time.UTC).Unix()
+}
+
+func keyFor(t clipper.Transaction) key {
+	return key{wallClock(t), t.CardSerial, t.Type, t.Location, t.DebitCents, t.CreditCents, t.BalanceCents}
+}
+
+// ID identifies t across statements and downloads: transactions that Merge
+// treats as duplicates have the same ID. It's t.ID().
+func ID(t clipper.Transaction) string {
+	return t.ID()
+}
+
+// Merge combines transactions from overlapping statements, dropping
+// duplicates, and sorts them oldest first. When the same transaction appears
+// in more than one statement, the first one seen is kept.
+func Merge(lists ...[]clipper.Transaction) []clipper.Transaction {
+	seen := make(map[key]bool)
+	var out []clipper.Transaction
+	for _, list := range lists {
+		for _, t := range list {
+			k := keyFor(t)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			out = append(out, t)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Timestamp.Equal(out[j].Timestamp) {
+			return out[i].Timestamp.Before(out[j].Timestamp)
+		}
+		return out[i].CardSerial < out[j].CardSerial
+	})
+	return out
+}
+
+// Transactions returns every transaction in the archive, oldest first. The
+// statements are parsed in parallel, one per CPU.
+func (s *Store) Transactions() ([]clipper.Transaction, error) {
+	return s.TransactionsContext(context.Background())
+}
+
+// TransactionsContext is like Transactions, but stops parsing and returns
+// ctx.Err() once ctx is done.
+func (s *Store) TransactionsContext(ctx context.Context) ([]clipper.Transaction, error) {
+	paths, err := s.Statements()
+	if err != nil {
+		return nil, err
+	}
+	results, errs := clipper.ParseAll(ctx, paths, 0)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	lists := make([][]clipper.Transaction, 0, len(paths))
+	for i, path := range paths {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		txns, err := statementTransactions(path, results[i])
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, txns)
+	}
+	return Merge(lists...), nil
+}
+
+// Between returns the transactions in txns at or after from and before to.
+// A zero from or to is unbounded.
+func Between(txns []clipper.Transaction, from, to time.Time) []clipper.Transaction {
+	out := make([]clipper.Transaction, 0, len(txns))
+	for _, t := range txns {
+		if !from.IsZero() && t.Timestamp.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !t.Timestamp.Before(to) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper"
+)
+
+func TestTransactions(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile("../testdata/transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same statement twice should not double count.
+	for _, name := range []string{"a.pdf", "b.PDF"} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, err := s.Statements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(paths))
+	}
+	once, err := ParseStatement(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if once[0].Source != paths[0] || once[0].Row != 1 {
+		t.Errorf("bad provenance: %q row %d", once[0].Source, once[0].Row)
+	}
+	txns, err := s.Transactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) == 0 || len(txns) != len(once) {
+		t.Errorf("expected %d transactions, got %d", len(once), len(txns))
+	}
+	for i := 1; i < len(txns); i++ {
+		if txns[i].Timestamp.Before(txns[i-1].Timestamp) {
+			t.Fatalf("transactions not sorted at %d", i)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.TransactionsContext(ctx); err != context.Canceled {
+		t.Errorf("TransactionsContext: got %v, want context.Canceled", err)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2018, 1, d, 0, 0, 0, 0, time.UTC) }
+	txns := []clipper.Transaction{{Timestamp: day(1)}, {Timestamp: day(2)}, {Timestamp: day(3)}}
+	if got := Between(txns, day(2), day(3)); len(got) != 1 || !got[0].Timestamp.Equal(day(2)) {
+		t.Errorf("Between: got %v", got)
+	}
+	if got := Between(txns, time.Time{}, time.Time{}); len(got) != 3 {
+		t.Errorf("unbounded Between: got %d", len(got))
+	}
+}
+
+func TestIDStable(t *testing.T) {
+	// Computed when statement times were read as UTC; reading them as
+	// Pacific time mustn't change IDs that tags and API clients refer to.
+	txn := clipper.Transaction{
+		Timestamp:    time.Date(2018, 1, 5, 8, 15, 0, 0, clipper.Pacific),
+		CardSerial:   1202728442,
+		Type:         "Single-tag fare payment",
+		Location:     "SFM bus",
+		DebitCents:   250,
+		BalanceCents: 1000,
+	}
+	if got, want := ID(txn), "ffaf47c044cd282e50698b93"; got != want {
+		t.Errorf("ID: got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/transit"
+)
+
+// SyncFile is the file in the archive directory that holds the last day
+// downloaded for each card by clipper.Client.Sync, for example:
+//
+//	{"1202728442": "2024-03-31"}
+const SyncFile = "sync.json"
+
+func (s *Store) readSync() (map[string]string, error) {
+	synced := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(s.dir, SyncFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return synced, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &synced); err != nil {
+		return nil, fmt.Errorf("store: reading %s: %v", SyncFile, err)
+	}
+	return synced, nil
+}
+
+// Synced returns the last day downloaded for card by clipper.Client.Sync, as
+// midnight Pacific time, or the zero time if it's never been synced. With
+// SetSynced, it makes a Store a clipper.SyncState.
+func (s *Store) Synced(card int64) (time.Time, error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	synced, err := s.readSync()
+	if err != nil {
+		return time.Time{}, err
+	}
+	day, ok := synced[strconv.FormatInt(card, 10)]
+	if !ok {
+		return time.Time{}, nil
+	}
+	t, err := clipper.ParseDate(day)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("store: %s: card %d: %v", SyncFile, card, err)
+	}
+	return t, nil
+}
+
+// SetSynced records that card's statements have been downloaded through day.
+func (s *Store) SetSynced(card int64, day time.Time) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	synced, err := s.readSync()
+	if err != nil {
+		return err
+	}
+	synced[strconv.FormatInt(card, 10)] = day.In(clipper.Pacific).Format(transit.DateLayout)
+	data, err := json.MarshalIndent(synced, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write the new state next to the old and rename it into place, so a
+	// run killed partway, by cron or otherwise, leaves one or the other.
+	path := filepath.Join(s.dir, SyncFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinburke/clipper"
+)
+
+func TestSyncState(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ clipper.SyncState = s
+	day, err := s.Synced(1202728442)
+	if err != nil || !day.IsZero() {
+		t.Fatalf("Synced before any sync: got %v, %v", day, err)
+	}
+	want := time.Date(2024, 3, 31, 0, 0, 0, 0, clipper.Pacific)
+	if err := s.SetSynced(1202728442, want); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSynced(1401491737, want.AddDate(0, 0, -1)); err != nil {
+		t.Fatal(err)
+	}
+	day, err = s.Synced(1202728442)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.Equal(want) {
+		t.Errorf("Synced: got %v, want %v", day, want)
+	}
+}
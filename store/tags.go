@@ -0,0 +1,83 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/kevinburke/clipper/clipperstats"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// TagsFile is the file in the archive directory that holds the rules and
+// overrides for tagging trips as work, personal or other, for example:
+//
+//	rules:
+//	  - purpose: work
+//	    days: [Mon, Tue, Wed, Thu, Fri]
+//	    locations: [Belmont, 4th and King]
+//	overrides:
+//	  1202728442-20171212T0917: personal
+const TagsFile = "tags.yml"
+
+// Tagger returns the trip tagging rules and overrides saved in the archive.
+// If there aren't any, every trip is tagged clipperstats.PurposeOther.
+func (s *Store) Tagger() (clipperstats.Tagger, error) {
+	var t clipperstats.Tagger
+	data, err := os.ReadFile(filepath.Join(s.dir, TagsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return t, err
+	}
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return t, fmt.Errorf("store: reading %s: %v", TagsFile, err)
+	}
+	for i, r := range t.Rules {
+		if err := r.Validate(); err != nil {
+			return t, fmt.Errorf("store: %s rule %d: %v", TagsFile, i+1, err)
+		}
+	}
+	for id, p := range t.Overrides {
+		if _, err := clipperstats.ParsePurpose(string(p)); err != nil {
+			return t, fmt.Errorf("store: %s override for %s: %v", TagsFile, id, err)
+		}
+	}
+	return t, nil
+}
+
+// SetTag saves an override tagging the trip with the given ID (see
+// clipperstats.Leg.ID) with purpose p, keeping the other rules and
+// overrides. An empty purpose removes the override.
+func (s *Store) SetTag(id string, p clipperstats.Purpose) error {
+	if p != "" {
+		if _, err := clipperstats.ParsePurpose(string(p)); err != nil {
+			return err
+		}
+	}
+	t, err := s.Tagger()
+	if err != nil {
+		return err
+	}
+	if p == "" {
+		delete(t.Overrides, id)
+	} else {
+		if t.Overrides == nil {
+			t.Overrides = make(map[string]clipperstats.Purpose)
+		}
+		t.Overrides[id] = p
+	}
+	data, err := yaml.Marshal(t)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, TagsFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
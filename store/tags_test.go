@@ -0,0 +1,48 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kevinburke/clipper/clipperstats"
+)
+
+func TestSetTag(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagger, err := s.Tagger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tagger.Rules) != 0 || len(tagger.Overrides) != 0 {
+		t.Errorf("expected an empty tagger without a tags file, got %+v", tagger)
+	}
+	rules := "rules:\n  - purpose: work\n    days: [Mon]\n"
+	if err := os.WriteFile(filepath.Join(dir, TagsFile), []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetTag("1-20180101T0800", clipperstats.PurposePersonal); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetTag("1-20180102T0800", "fun"); err == nil {
+		t.Errorf("expected error for unknown purpose")
+	}
+	tagger, err = s.Tagger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tagger.Rules) != 1 || tagger.Overrides["1-20180101T0800"] != clipperstats.PurposePersonal {
+		t.Errorf("rules or override lost: %+v", tagger)
+	}
+	if err := s.SetTag("1-20180101T0800", ""); err != nil {
+		t.Fatal(err)
+	}
+	tagger, _ = s.Tagger()
+	if len(tagger.Overrides) != 0 {
+		t.Errorf("expected override to be removed, got %+v", tagger.Overrides)
+	}
+}
@@ -0,0 +1,130 @@
+package clipper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// A SyncState remembers how far each card's statements have been downloaded,
+// so that Sync only asks for what's new. The store package's Store is one,
+// kept in a file in the archive.
+type SyncState interface {
+	// Synced returns the last day downloaded for card, as midnight Pacific
+	// time, or the zero time if none has been.
+	Synced(card int64) (time.Time, error)
+	// SetSynced records that card's statements have been downloaded through
+	// day.
+	SetSynced(card int64, day time.Time) error
+}
+
+// syncOverlapDays is how many days before the last one synced Sync asks for
+// again, since Clipper can post a ride a day or two after it's taken.
+const syncOverlapDays = 2
+
+// syncRanges returns the ranges to download to bring a card last synced
+// through last up to now: the last year for a card never synced, or else the
+// days since last, with a little overlap, split into ranges a statement can
+// cover.
+func syncRanges(last, now time.Time) []DateRange {
+	y, m, d := now.In(Pacific).Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, Pacific)
+	start := today.AddDate(0, 0, -(MaxDateRangeDays - 1))
+	if !last.IsZero() {
+		start = last.In(Pacific).AddDate(0, 0, -syncOverlapDays)
+	}
+	if start.After(today) {
+		start = today
+	}
+	var ranges []DateRange
+	for !start.After(today) {
+		end := start.AddDate(0, 0, MaxDateRangeDays-1)
+		if end.After(today) {
+			end = today
+		}
+		ranges = append(ranges, DateRange{Start: start, End: end})
+		start = end.AddDate(0, 0, 1)
+	}
+	return ranges
+}
+
+// A SyncedStatement is a statement Sync downloaded or, on a dry run, would
+// have.
+type SyncedStatement struct {
+	Card  Card
+	Range DateRange
+	// Path is the file the statement was saved to, or would be.
+	Path string
+}
+
+// Sync downloads the statements for each card that cover the days since the
+// last Sync, as recorded in state, into outputDir, and records the new last
+// day in state after each download. A card Sync hasn't seen before gets the
+// last year. Sync is meant to be run on a schedule, like once a day from
+// cron: the statements it saves are named for the card and dates they cover,
+// like clipper-transactions-1202728442-2024-03-01-2024-03-31.pdf, so they
+// build up in outputDir instead of replacing each other, and the overlap
+// between them is dropped when they're read with the store package.
+//
+// Sync returns the statements it saved, card by card, oldest first. Like
+// DownloadPDFs, it carries on past a card that fails, and returns a CardError
+// for it, along with the statements the other cards got; that card's next
+// Sync starts from where it got to. With dryRun, it returns the statements it
+// would download, and doesn't download them or change state.
+func (c *Client) Sync(ctx context.Context, outputDir string, state SyncState, dryRun bool) ([]SyncedStatement, error) {
+	return c.sync(ctx, outputDir, state, dryRun, time.Now())
+}
+
+func (c *Client) sync(ctx context.Context, outputDir string, state SyncState, dryRun bool, now time.Time) ([]SyncedStatement, error) {
+	cards, err := c.Cards(ctx)
+	if err != nil && len(cards) == 0 {
+		return nil, err
+	}
+	var mu sync.Mutex
+	saved := make(map[int64][]SyncedStatement, len(cards))
+	cerr := c.eachCard(ctx, cards, func(card Card) error {
+		stmts, err := c.syncCard(ctx, card, outputDir, state, dryRun, now)
+		mu.Lock()
+		saved[card.SerialNumber] = stmts
+		mu.Unlock()
+		return err
+	})
+	var stmts []SyncedStatement
+	for _, card := range cards {
+		stmts = append(stmts, saved[card.SerialNumber]...)
+	}
+	return stmts, errors.Join(err, cerr)
+}
+
+// syncCard downloads the statements card is missing, oldest first, stopping
+// at the first that fails, and returns the ones it saved.
+func (c *Client) syncCard(ctx context.Context, card Card, outputDir string, state SyncState, dryRun bool, now time.Time) ([]SyncedStatement, error) {
+	last, err := state.Synced(card.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+	var stmts []SyncedStatement
+	for _, r := range syncRanges(last, now) {
+		name := fmt.Sprintf("clipper-transactions-%d-%s-%s.pdf", card.SerialNumber, r.Start.Format(transit.DateLayout), r.End.Format(transit.DateLayout))
+		stmt := SyncedStatement{Card: card, Range: r, Path: filepath.Join(outputDir, name)}
+		if dryRun {
+			stmts = append(stmts, stmt)
+			continue
+		}
+		filename, err := c.downloadPDF(ctx, card, r, stmt.Path)
+		c.event(Event{Action: EventDownload, Card: card.SerialNumber, Path: filename, Err: err})
+		if err != nil {
+			return stmts, err
+		}
+		stmts = append(stmts, stmt)
+		if err := state.SetSynced(card.SerialNumber, r.End); err != nil {
+			return stmts, err
+		}
+	}
+	return stmts, nil
+}
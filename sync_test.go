@@ -0,0 +1,117 @@
+package clipper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// memSyncState is a SyncState in memory.
+type memSyncState map[int64]time.Time
+
+func (m memSyncState) Synced(card int64) (time.Time, error) { return m[card], nil }
+
+func (m memSyncState) SetSynced(card int64, day time.Time) error {
+	m[card] = day
+	return nil
+}
+
+func TestSyncRanges(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 0, 0, 0, Pacific)
+	day := func(s string) time.Time {
+		d, err := ParseDate(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	for _, tt := range []struct {
+		last string
+		want []string
+	}{
+		{"", []string{"2023-03-12 to 2024-03-10"}},
+		{"2024-03-01", []string{"2024-02-28 to 2024-03-10"}},
+		{"2024-03-10", []string{"2024-03-08 to 2024-03-10"}},
+		{"2022-01-01", []string{"2021-12-30 to 2022-12-29", "2022-12-30 to 2023-12-29", "2023-12-30 to 2024-03-10"}},
+	} {
+		var last time.Time
+		if tt.last != "" {
+			last = day(tt.last)
+		}
+		ranges := syncRanges(last, now)
+		if len(ranges) != len(tt.want) {
+			t.Errorf("syncRanges(%q): got %v, want %v", tt.last, ranges, tt.want)
+			continue
+		}
+		for i, r := range ranges {
+			if r.String() != tt.want[i] {
+				t.Errorf("syncRanges(%q)[%d]: got %s, want %s", tt.last, i, r, tt.want[i])
+			}
+			if err := r.validate(now); err != nil {
+				t.Errorf("syncRanges(%q)[%d]: %v", tt.last, i, err)
+			}
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	portal := &fakePortal{pdfs: testStatements(t)}
+	delete(portal.pdfs, 1401491737)
+	c, err := NewClient("me@example.com", "password", WithSiteAdapter(portal), WithTransport(lockedTransport{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	state := memSyncState{}
+	now := time.Date(2024, 3, 10, 15, 0, 0, 0, Pacific)
+	stmts, err := c.sync(context.Background(), dir, state, false, now)
+	if cerrs := CardErrors(err); len(cerrs) != 1 || cerrs[0].Card != 1401491737 {
+		t.Fatalf("first sync: got %v, want an error for the card without a statement", err)
+	}
+	today := time.Date(2024, 3, 10, 0, 0, 0, 0, Pacific)
+	if !state[1202728442].Equal(today) {
+		t.Errorf("synced through %v, want %v", state[1202728442], today)
+	}
+	if _, ok := state[1401491737]; ok {
+		t.Errorf("recorded a sync for the card that failed")
+	}
+	first := filepath.Join(dir, "clipper-transactions-1202728442-2023-03-12-2024-03-10.pdf")
+	if len(stmts) != 1 || stmts[0].Path != first || stmts[0].Card.SerialNumber != 1202728442 {
+		t.Errorf("first sync: got statements %+v, want %s", stmts, first)
+	}
+	if _, err := os.Stat(first); err != nil {
+		t.Error(err)
+	}
+
+	// The next day's run only asks for the days since.
+	portal.ranges = nil
+	portal.pdfs = testStatements(t)
+	stmts, err = c.sync(context.Background(), dir, state, false, now.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 2 || stmts[0].Range.String() != "2024-03-08 to 2024-03-11" || stmts[1].Card.SerialNumber != 1401491737 {
+		t.Errorf("second sync: got statements %+v", stmts)
+	}
+	if len(portal.ranges) != 2 || portal.ranges[0].String() != "2024-03-08 to 2024-03-11" || portal.ranges[1].String() != "2023-03-13 to 2024-03-11" {
+		t.Errorf("second sync asked for %v", portal.ranges)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "clipper-transactions-1202728442-2024-03-08-2024-03-11.pdf")); err != nil {
+		t.Error(err)
+	}
+
+	// A dry run doesn't download anything or move the state on.
+	portal.ranges = nil
+	stmts, err = c.sync(context.Background(), dir, state, true, now.AddDate(0, 0, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 2 || stmts[0].Range.String() != "2024-03-09 to 2024-03-15" {
+		t.Errorf("dry run: got statements %+v", stmts)
+	}
+	if len(portal.ranges) != 0 || !state[1202728442].Equal(today.AddDate(0, 0, 1)) {
+		t.Errorf("dry run asked for %v and left the state at %v", portal.ranges, state[1202728442])
+	}
+}
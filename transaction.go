@@ -0,0 +1,66 @@
+package clipper
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// statementTimeLayout is the format of the Date column in a statement. The
+// times are Pacific time; see transit.Pacific.
+const statementTimeLayout = "01/02/2006 03:04 PM"
+
+// ParseTransactions converts records, as returned in
+// TransactionData.Transactions, to Transactions. A header row, if present, is
+// skipped. cardSerial is recorded on each Transaction.
+func ParseTransactions(records [][]string, cardSerial int64) ([]Transaction, error) {
+	txns := make([]Transaction, 0, len(records))
+	for i, record := range records {
+		if i == 0 && len(record) > 0 && record[0] == recordHeader[0] {
+			continue
+		}
+		t, err := parseTransaction(record)
+		if err != nil {
+			return nil, fmt.Errorf("clipper: row %d: %v", i, err)
+		}
+		t.CardSerial = cardSerial
+		t.Row = len(txns) + 1
+		txns = append(txns, t)
+	}
+	return txns, nil
+}
+
+func parseTransaction(record []string) (Transaction, error) {
+	if len(record) != len(recordHeader) {
+		return Transaction{}, fmt.Errorf("want %d columns, got %d", len(recordHeader), len(record))
+	}
+	ts, err := time.ParseInLocation(statementTimeLayout, strings.TrimSpace(record[0]), Pacific)
+	if err != nil {
+		return Transaction{}, err
+	}
+	t := Transaction{
+		Timestamp: ts,
+		Type:      strings.TrimSpace(record[1]),
+		Location:  strings.TrimSpace(record[2]),
+		Route:     strings.TrimSpace(record[3]),
+		Product:   strings.TrimSpace(record[4]),
+	}
+	for _, col := range []struct {
+		val string
+		dst *Money
+	}{
+		{record[5], &t.DebitCents},
+		{record[6], &t.CreditCents},
+		{record[7], &t.BalanceCents},
+	} {
+		if strings.TrimSpace(col.val) == "" {
+			continue
+		}
+		m, err := ParseMoney(col.val)
+		if err != nil {
+			return Transaction{}, err
+		}
+		*col.dst = m
+	}
+	return t, nil
+}
@@ -0,0 +1,106 @@
+package clipper
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseTransactions(t *testing.T) {
+	_, records, err := getCSV(samplePages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns, err := ParseTransactions(records, 1202728442)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 31 {
+		t.Fatalf("expected 31 transactions, got %d", len(txns))
+	}
+	first := txns[0]
+	if want := time.Date(2017, 12, 12, 9, 17, 0, 0, Pacific); !first.Timestamp.Equal(want) || first.Timestamp.Location() != Pacific {
+		t.Errorf("Timestamp: got %v, want %v", first.Timestamp, want)
+	}
+	if first.DebitCents != 205 || first.CreditCents != 0 || first.BalanceCents != 14685 {
+		t.Errorf("bad amounts: %+v", first)
+	}
+	if first.CardSerial != 1202728442 {
+		t.Errorf("CardSerial: got %d", first.CardSerial)
+	}
+	if !first.IsTripStart() || !first.IsFare() || first.FareCents() != 205 {
+		t.Errorf("first transaction should be a 2.05 trip: %+v", first)
+	}
+	rebate := txns[5]
+	if rebate.CreditCents != 675 || rebate.FareCents() != -675 || rebate.IsTripStart() {
+		t.Errorf("bad rebate: %+v", rebate)
+	}
+}
+
+func TestParsedTransactions(t *testing.T) {
+	f, err := os.Open("testdata/transactions.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, err := ParsePDF(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns, err := data.ParsedTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != len(data.Transactions)-1 {
+		t.Fatalf("got %d transactions from %d rows and a header", len(txns), len(data.Transactions)-1)
+	}
+	last := txns[len(txns)-1]
+	if last.Location != "Civic Center (BART)" || last.DebitCents != 200 || last.CardSerial != 1202728442 || last.Row != len(txns) {
+		t.Errorf("last transaction: got %+v", last)
+	}
+
+	data = TransactionData{AccountNumber: -1, Transactions: [][]string{recordHeader}}
+	if txns, err := data.ParsedTransactions(); err != nil || len(txns) != 0 {
+		t.Errorf("statement with no activity: got %v, %v", txns, err)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	_, records, err := getCSV(samplePages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := (TransactionData{AccountNumber: 1202728442, Transactions: records}).WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got []Record
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 31 {
+		t.Fatalf("got %d transactions, want 31", len(got))
+	}
+	first := got[0]
+	if !first.Timestamp.Equal(time.Date(2017, 12, 12, 9, 17, 0, 0, Pacific)) || first.CardSerial != 1202728442 || first.Type != "Single-tag fare payment" || first.DebitCents != 205 || first.BalanceCents != 14685 || first.ID == "" {
+		t.Errorf("first transaction: got %+v", first)
+	}
+
+	buf.Reset()
+	bad := TransactionData{Transactions: [][]string{recordHeader, {"yesterday", "", "", "", "", "", "", ""}}}
+	if err := bad.WriteJSON(&buf); err == nil || buf.Len() != 0 {
+		t.Errorf("unparseable row: got %v and %d bytes, want an error and nothing written", err, buf.Len())
+	}
+}
+
+func TestReload(t *testing.T) {
+	txn, err := parseTransaction([]string{"01/02/2018 08:00 AM", "Autoload", "Autoload", "", "Clipper Cash", "", "50.00", "91.75"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !txn.IsReload() || txn.IsFare() || txn.FareCents() != 0 {
+		t.Errorf("autoload should be a reload: %+v", txn)
+	}
+}
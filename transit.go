@@ -0,0 +1,52 @@
+package clipper
+
+import (
+	"time"
+
+	"github.com/kevinburke/clipper/transit"
+)
+
+// The transaction, money, agency and station types live in package transit, so
+// programs that only analyze transactions don't need this package. They're
+// aliased here for existing callers.
+
+type (
+	Transaction = transit.Transaction
+	Money       = transit.Money
+	Agency      = transit.Agency
+	Station     = transit.Station
+)
+
+const (
+	AgencyUnknown         = transit.AgencyUnknown
+	AgencyBART            = transit.AgencyBART
+	AgencySFMTA           = transit.AgencySFMTA
+	AgencyCaltrain        = transit.AgencyCaltrain
+	AgencyACTransit       = transit.AgencyACTransit
+	AgencySamTrans        = transit.AgencySamTrans
+	AgencyVTA             = transit.AgencyVTA
+	AgencyGoldenGate      = transit.AgencyGoldenGate
+	AgencyGoldenGateFerry = transit.AgencyGoldenGateFerry
+	AgencySFBayFerry      = transit.AgencySFBayFerry
+)
+
+// Pacific is the time zone Clipper runs in, and that every time in this
+// package is in; see transit.Pacific.
+var Pacific = transit.Pacific
+
+// ParseDate parses a date like "2024-03-01" as midnight Pacific time; see
+// transit.ParseDate.
+func ParseDate(s string) (time.Time, error) { return transit.ParseDate(s) }
+
+// ParseMoney parses a dollar amount; see transit.ParseMoney.
+func ParseMoney(s string) (Money, error) { return transit.ParseMoney(s) }
+
+// Stations returns the known stations; see transit.Stations.
+func Stations() []Station { return transit.Stations() }
+
+// RegisterStations adds or replaces stations; see transit.RegisterStations.
+func RegisterStations(sts ...Station) { transit.RegisterStations(sts...) }
+
+// LookupStation finds the station at a statement location; see
+// transit.LookupStation.
+func LookupStation(location string) (Station, bool) { return transit.LookupStation(location) }
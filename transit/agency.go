@@ -0,0 +1,83 @@
+package transit
+
+import "strings"
+
+// An Agency is a transit operator that accepts Clipper.
+type Agency string
+
+const (
+	AgencyUnknown         Agency = ""
+	AgencyBART            Agency = "BART"
+	AgencySFMTA           Agency = "SFMTA"
+	AgencyCaltrain        Agency = "Caltrain"
+	AgencyACTransit       Agency = "AC Transit"
+	AgencySamTrans        Agency = "SamTrans"
+	AgencyVTA             Agency = "VTA"
+	AgencyGoldenGate      Agency = "Golden Gate Transit"
+	AgencyGoldenGateFerry Agency = "Golden Gate Ferry"
+	AgencySFBayFerry      Agency = "SF Bay Ferry"
+)
+
+// agencyMarkers maps text that appears in a statement's Location or Route
+// column to the agency that wrote it. Statements usually put the operator in
+// parentheses after a station name ("Millbrae (BART)"); buses are named after
+// the operator ("SAM bus"). More specific markers come first.
+var agencyMarkers = []struct {
+	marker string
+	agency Agency
+}{
+	{"(bart)", AgencyBART},
+	{"(caltrain)", AgencyCaltrain},
+	{"(muni)", AgencySFMTA},
+	{"(sfmta)", AgencySFMTA},
+	{"golden gate ferry", AgencyGoldenGateFerry},
+	{"larkspur", AgencyGoldenGateFerry},
+	{"sausalito", AgencyGoldenGateFerry},
+	{"sf bay ferry", AgencySFBayFerry},
+	{"ferry", AgencySFBayFerry},
+	{"golden gate", AgencyGoldenGate},
+	{"muni", AgencySFMTA},
+	{"sfmta", AgencySFMTA},
+	{"cable car", AgencySFMTA},
+	{"sam bus", AgencySamTrans},
+	{"samtrans", AgencySamTrans},
+	{"ac transit", AgencyACTransit},
+	{"act bus", AgencyACTransit},
+	{"vta", AgencyVTA},
+	{"bart", AgencyBART},
+	{"caltrain", AgencyCaltrain},
+}
+
+// Agency returns the operator that charged the transaction, based on its
+// location and route, or AgencyUnknown if it can't tell. Reloads have no
+// agency.
+func (t Transaction) Agency() Agency {
+	if !t.IsFare() {
+		return AgencyUnknown
+	}
+	for _, field := range []string{t.Location, t.Route} {
+		if a := AgencyAt(field); a != AgencyUnknown {
+			return a
+		}
+	}
+	return AgencyUnknown
+}
+
+// AgencyAt returns the operator named by a value from the Location or Route
+// column of a statement, like "Millbrae (BART)" or "SAM bus", or
+// AgencyUnknown if it doesn't name one.
+func AgencyAt(field string) Agency {
+	lower := strings.ToLower(strings.TrimSpace(field))
+	if lower == "" {
+		return AgencyUnknown
+	}
+	for _, m := range agencyMarkers {
+		if strings.Contains(lower, m.marker) {
+			return m.agency
+		}
+	}
+	if st, ok := LookupStation(field); ok {
+		return st.Agency
+	}
+	return AgencyUnknown
+}
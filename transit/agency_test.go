@@ -0,0 +1,27 @@
+package transit
+
+import "testing"
+
+func TestAgency(t *testing.T) {
+	tests := []struct {
+		typ, location, route string
+		want                 Agency
+	}{
+		{"Single-tag fare payment", "SAM bus", "LOC", AgencySamTrans},
+		{"Single-tag fare payment", "Powell (Muni)", "NONE", AgencySFMTA},
+		{"Dual-tag exit transaction, fare payment", "Millbrae (BART)", "", AgencyBART},
+		{"Dual-tag entry transaction, maximum fare deducted (purse debit)", "Millbrae (Caltrain)", "", AgencyCaltrain},
+		{"Dual-tag entry transaction, maximum fare deducted (purse debit)", "Belmont", "", AgencyCaltrain},
+		{"Dual-tag entry transaction, no fare deduction", "16th St Mission", "", AgencyBART},
+		{"Dual-tag entry transaction, maximum fare deducted (purse debit)", "Larkspur Ferry Terminal", "", AgencyGoldenGateFerry},
+		{"Single-tag fare payment", "AC Transit bus", "51B", AgencyACTransit},
+		{"Single-tag fare payment", "Somewhere", "", AgencyUnknown},
+		{"Autoload", "Powell (Muni)", "", AgencyUnknown},
+	}
+	for _, tt := range tests {
+		txn := Transaction{Type: tt.typ, Location: tt.location, Route: tt.route}
+		if got := txn.Agency(); got != tt.want {
+			t.Errorf("Agency(%q, %q): got %q, want %q", tt.location, tt.route, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package transit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in US cents. Fares, balances and totals are all Money,
+// so they add up exactly and print the same way everywhere.
+//
+// Money encodes to JSON as a number of cents, as the API has always sent
+// amounts. CSV exports write Decimal. It deliberately isn't an
+// encoding.TextMarshaler: YAML files give amounts in cents, like
+// "adult_cents: 285", and YAML would read those as dollars.
+type Money int64
+
+// Dollars returns m in dollars, for charts and APIs that want a float.
+func (m Money) Dollars() float64 { return float64(m) / 100 }
+
+// Abs returns the absolute value of m.
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// Mul returns m times f, rounded to the nearest cent, half away from zero.
+func (m Money) Mul(f float64) Money { return Money(math.Round(float64(m) * f)) }
+
+// Div returns m divided by n, rounded to the nearest cent, half away from
+// zero. Div by zero returns zero.
+func (m Money) Div(n int) Money {
+	if n == 0 {
+		return 0
+	}
+	return Money(math.Round(float64(m) / float64(n)))
+}
+
+// String formats m as dollars with a sign and a dollar sign, like "$2.50" or
+// "-$0.25".
+func (m Money) String() string {
+	if m < 0 {
+		return "-$" + (-m).Decimal()
+	}
+	return "$" + m.Decimal()
+}
+
+// Decimal formats m as a decimal number of dollars, like "2.50" or "-0.25",
+// for CSV files and spreadsheets.
+func (m Money) Decimal() string {
+	sign := ""
+	u := uint64(m)
+	if m < 0 {
+		sign = "-"
+		u = -u
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, u/100, u%100)
+}
+
+// ParseMoney parses a dollar amount like "$1,234.50", "2.05", "-$0.50" or
+// "$3".
+func ParseMoney(s string) (Money, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	s = strings.TrimPrefix(s, "$")
+	s = strings.Replace(strings.TrimSpace(s), ",", "", -1)
+	if s == "" {
+		return 0, errors.New("transit: empty dollar amount")
+	}
+	dollars, cents := s, "00"
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		dollars, cents = s[:i], s[i+1:]
+		if len(cents) == 1 {
+			cents += "0"
+		}
+	}
+	if dollars == "" {
+		dollars = "0"
+	}
+	d, err := strconv.ParseUint(dollars, 10, 63)
+	if err != nil || len(cents) != 2 || d > math.MaxInt64/100-1 {
+		return 0, fmt.Errorf("transit: invalid dollar amount %q", orig)
+	}
+	c, err := strconv.ParseUint(cents, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("transit: invalid dollar amount %q", orig)
+	}
+	m := Money(d*100 + c)
+	if neg {
+		m = -m
+	}
+	return m, nil
+}
+
+// MarshalJSON implements json.Marshaler, as a number of cents.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(m), 10), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts a number of cents, or
+// a string with a dollar amount in it.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return fmt.Errorf("transit: invalid dollar amount %s", data)
+		}
+		v, err := ParseMoney(s)
+		if err != nil {
+			return err
+		}
+		*m = v
+		return nil
+	}
+	v, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("transit: invalid amount in cents %s", data)
+	}
+	*m = Money(v)
+	return nil
+}
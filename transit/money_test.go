@@ -0,0 +1,113 @@
+package transit
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var moneyTests = []struct {
+	in   string
+	want Money
+}{
+	{"$2.05", 205},
+	{"146.85", 14685},
+	{"$1,234.5", 123450},
+	{"-$0.50", -50},
+	{"$3", 300},
+	{" $ 7.25 ", 725},
+	{".75", 75},
+}
+
+func TestParseMoney(t *testing.T) {
+	for _, tt := range moneyTests {
+		got, err := ParseMoney(tt.in)
+		if err != nil {
+			t.Errorf("ParseMoney(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q): got %d, want %d", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"", "$", "1.234", "12a", "$1.5x", "--1", "99999999999999999999"} {
+		if m, err := ParseMoney(in); err == nil {
+			t.Errorf("ParseMoney(%q): got %d, want an error", in, m)
+		}
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	for _, tt := range []struct {
+		m       Money
+		str     string
+		decimal string
+	}{
+		{0, "$0.00", "0.00"},
+		{5, "$0.05", "0.05"},
+		{250, "$2.50", "2.50"},
+		{-25, "-$0.25", "-0.25"},
+		{123456, "$1234.56", "1234.56"},
+	} {
+		if got := tt.m.String(); got != tt.str {
+			t.Errorf("Money(%d).String(): got %q, want %q", tt.m, got, tt.str)
+		}
+		if got := tt.m.Decimal(); got != tt.decimal {
+			t.Errorf("Money(%d).Decimal(): got %q, want %q", tt.m, got, tt.decimal)
+		}
+		if back, err := ParseMoney(tt.m.String()); err != nil || back != tt.m {
+			t.Errorf("ParseMoney(%q): got %d, %v", tt.m.String(), back, err)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	if got := Money(2000).Mul(0.025); got != 50 {
+		t.Errorf("Mul: got %d, want 50", got)
+	}
+	if got := Money(-250).Mul(0.5); got != -125 {
+		t.Errorf("Mul: got %d, want -125", got)
+	}
+	if got := Money(1000).Div(3); got != 333 {
+		t.Errorf("Div: got %d, want 333", got)
+	}
+	if got := Money(5).Div(2); got != 3 {
+		t.Errorf("Div: got %d, want 3", got)
+	}
+	if got := Money(100).Div(0); got != 0 {
+		t.Errorf("Div by zero: got %d", got)
+	}
+	if got := Money(-40).Abs(); got != 40 {
+		t.Errorf("Abs: got %d", got)
+	}
+	if got := Money(-40).Dollars(); got != -0.4 {
+		t.Errorf("Dollars: got %v", got)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	type row struct {
+		Fare    Money  `json:"fare"`
+		Balance *Money `json:"balance"`
+	}
+	b := Money(-75)
+	data, err := json.Marshal(row{Fare: 250, Balance: &b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"fare":250,"balance":-75}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	var r row
+	if err := json.Unmarshal([]byte(`{"fare":"$2.50","balance":null}`), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Fare != 250 || r.Balance != nil {
+		t.Errorf("got %+v", r)
+	}
+	if err := json.Unmarshal([]byte(`{"fare":2.5}`), &r); err == nil {
+		t.Error("fractional cents: want an error")
+	}
+	if err := json.Unmarshal([]byte(`{"fare":"2.5.0"}`), &r); err == nil {
+		t.Error("bad dollar amount: want an error")
+	}
+}
@@ -0,0 +1,211 @@
+package transit
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A Station is a rail stop that appears in the Location column of a
+// statement.
+type Station struct {
+	Name   string
+	Agency Agency
+	// Zone is the station's Caltrain fare zone, or 0 for other agencies.
+	Zone int
+	// Lat and Lon are the station's approximate coordinates.
+	Lat, Lon float64
+	// StopID is the station's GTFS stop_id, if it was loaded from a feed.
+	StopID string
+}
+
+// stations lists the stations statements use, by the name Clipper prints.
+// Caltrain zones are as of 2024.
+var stations = []Station{
+	{"4th and King", AgencyCaltrain, 1, 37.7764, -122.3943, ""},
+	{"San Francisco", AgencyCaltrain, 1, 37.7764, -122.3943, ""},
+	{"22nd St", AgencyCaltrain, 1, 37.7575, -122.3926, ""},
+	{"Bayshore", AgencyCaltrain, 1, 37.7099, -122.4014, ""},
+	{"South San Francisco", AgencyCaltrain, 1, 37.6576, -122.4056, ""},
+	{"San Bruno", AgencyCaltrain, 2, 37.6301, -122.4111, ""},
+	{"Millbrae", AgencyCaltrain, 2, 37.5999, -122.3866, ""},
+	{"Broadway", AgencyCaltrain, 2, 37.5873, -122.3625, ""},
+	{"Burlingame", AgencyCaltrain, 2, 37.5796, -122.3450, ""},
+	{"San Mateo", AgencyCaltrain, 2, 37.5680, -122.3240, ""},
+	{"Hayward Park", AgencyCaltrain, 2, 37.5526, -122.3090, ""},
+	{"Hillsdale", AgencyCaltrain, 2, 37.5378, -122.2973, ""},
+	{"Belmont", AgencyCaltrain, 3, 37.5206, -122.2760, ""},
+	{"San Carlos", AgencyCaltrain, 3, 37.5075, -122.2601, ""},
+	{"Redwood City", AgencyCaltrain, 3, 37.4854, -122.2319, ""},
+	{"Menlo Park", AgencyCaltrain, 3, 37.4545, -122.1823, ""},
+	{"Palo Alto", AgencyCaltrain, 3, 37.4430, -122.1650, ""},
+	{"Stanford", AgencyCaltrain, 3, 37.4361, -122.1566, ""},
+	{"California Ave", AgencyCaltrain, 3, 37.4291, -122.1420, ""},
+	{"San Antonio", AgencyCaltrain, 3, 37.4072, -122.1072, ""},
+	{"Mountain View", AgencyCaltrain, 3, 37.3944, -122.0762, ""},
+	{"Sunnyvale", AgencyCaltrain, 4, 37.3784, -122.0308, ""},
+	{"Lawrence", AgencyCaltrain, 4, 37.3705, -121.9973, ""},
+	{"Santa Clara", AgencyCaltrain, 4, 37.3532, -121.9365, ""},
+	{"College Park", AgencyCaltrain, 4, 37.3425, -121.9149, ""},
+	{"San Jose Diridon", AgencyCaltrain, 4, 37.3297, -121.9026, ""},
+	{"Tamien", AgencyCaltrain, 4, 37.3113, -121.8830, ""},
+	{"Capitol", AgencyCaltrain, 5, 37.2844, -121.8419, ""},
+	{"Blossom Hill", AgencyCaltrain, 5, 37.2526, -121.7975, ""},
+	{"Morgan Hill", AgencyCaltrain, 6, 37.1295, -121.6505, ""},
+	{"San Martin", AgencyCaltrain, 6, 37.0856, -121.6104, ""},
+	{"Gilroy", AgencyCaltrain, 6, 37.0036, -121.5669, ""},
+
+	{"12th St Oakland", AgencyBART, 0, 37.8033, -122.2717, ""},
+	{"16th St Mission", AgencyBART, 0, 37.7650, -122.4197, ""},
+	{"19th St Oakland", AgencyBART, 0, 37.8081, -122.2686, ""},
+	{"24th St Mission", AgencyBART, 0, 37.7522, -122.4184, ""},
+	{"Antioch", AgencyBART, 0, 37.9955, -121.7805, ""},
+	{"Ashby", AgencyBART, 0, 37.8529, -122.2700, ""},
+	{"Balboa Park", AgencyBART, 0, 37.7217, -122.4475, ""},
+	{"Bay Fair", AgencyBART, 0, 37.6970, -122.1266, ""},
+	{"Berryessa", AgencyBART, 0, 37.3685, -121.8744, ""},
+	{"Castro Valley", AgencyBART, 0, 37.6907, -122.0757, ""},
+	{"Civic Center", AgencyBART, 0, 37.7796, -122.4139, ""},
+	{"Coliseum", AgencyBART, 0, 37.7537, -122.1969, ""},
+	{"Colma", AgencyBART, 0, 37.6847, -122.4661, ""},
+	{"Concord", AgencyBART, 0, 37.9737, -122.0291, ""},
+	{"Daly City", AgencyBART, 0, 37.7063, -122.4690, ""},
+	{"Downtown Berkeley", AgencyBART, 0, 37.8701, -122.2681, ""},
+	{"Dublin/Pleasanton", AgencyBART, 0, 37.7016, -121.8992, ""},
+	{"El Cerrito del Norte", AgencyBART, 0, 37.9254, -122.3172, ""},
+	{"El Cerrito Plaza", AgencyBART, 0, 37.9030, -122.2992, ""},
+	{"Embarcadero", AgencyBART, 0, 37.7929, -122.3971, ""},
+	{"Fremont", AgencyBART, 0, 37.5574, -121.9764, ""},
+	{"Fruitvale", AgencyBART, 0, 37.7748, -122.2241, ""},
+	{"Glen Park", AgencyBART, 0, 37.7331, -122.4338, ""},
+	{"Hayward", AgencyBART, 0, 37.6697, -122.0870, ""},
+	{"Lafayette", AgencyBART, 0, 37.8931, -122.1246, ""},
+	{"Lake Merritt", AgencyBART, 0, 37.7970, -122.2653, ""},
+	{"MacArthur", AgencyBART, 0, 37.8290, -122.2671, ""},
+	{"Millbrae", AgencyBART, 0, 37.6001, -122.3867, ""},
+	{"Milpitas", AgencyBART, 0, 37.4103, -121.8912, ""},
+	{"Montgomery", AgencyBART, 0, 37.7894, -122.4011, ""},
+	{"North Berkeley", AgencyBART, 0, 37.8739, -122.2834, ""},
+	{"North Concord", AgencyBART, 0, 38.0032, -122.0246, ""},
+	{"Oakland Airport", AgencyBART, 0, 37.7132, -122.2122, ""},
+	{"Orinda", AgencyBART, 0, 37.8784, -122.1837, ""},
+	{"Pittsburg Center", AgencyBART, 0, 38.0169, -121.8891, ""},
+	{"Pittsburg/Bay Point", AgencyBART, 0, 38.0189, -121.9451, ""},
+	{"Pleasant Hill", AgencyBART, 0, 37.9284, -122.0560, ""},
+	{"Powell St", AgencyBART, 0, 37.7844, -122.4080, ""},
+	{"Richmond", AgencyBART, 0, 37.9370, -122.3533, ""},
+	{"Rockridge", AgencyBART, 0, 37.8445, -122.2514, ""},
+	{"San Bruno", AgencyBART, 0, 37.6379, -122.4163, ""},
+	{"San Leandro", AgencyBART, 0, 37.7219, -122.1609, ""},
+	{"SFO", AgencyBART, 0, 37.6160, -122.3924, ""},
+	{"South Hayward", AgencyBART, 0, 37.6345, -122.0571, ""},
+	{"South San Francisco", AgencyBART, 0, 37.6642, -122.4440, ""},
+	{"Union City", AgencyBART, 0, 37.5908, -122.0175, ""},
+	{"Walnut Creek", AgencyBART, 0, 37.9055, -122.0675, ""},
+	{"Warm Springs", AgencyBART, 0, 37.5024, -121.9395, ""},
+	{"West Dublin", AgencyBART, 0, 37.6997, -121.9281, ""},
+	{"West Oakland", AgencyBART, 0, 37.8049, -122.2951, ""},
+}
+
+var (
+	stationsMu sync.RWMutex
+	// stationsByName indexes stations by lowercased name.
+	stationsByName = func() map[string][]Station {
+		m := make(map[string][]Station, len(stations))
+		for _, s := range stations {
+			key := strings.ToLower(s.Name)
+			m[key] = append(m[key], s)
+		}
+		return m
+	}()
+)
+
+// Stations returns the stations LookupStation knows about.
+func Stations() []Station {
+	stationsMu.RLock()
+	defer stationsMu.RUnlock()
+	var out []Station
+	for _, sts := range stationsByName {
+		out = append(out, sts...)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Agency != out[j].Agency {
+			return out[i].Agency < out[j].Agency
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// RegisterStations adds stations to the ones LookupStation knows about, for
+// example from a GTFS feed. A station with the same name and agency as one
+// that's already known replaces it.
+func RegisterStations(sts ...Station) {
+	stationsMu.Lock()
+	defer stationsMu.Unlock()
+	for _, st := range sts {
+		key := strings.ToLower(st.Name)
+		// Copy, since LookupStation may be reading the old slice.
+		list := append([]Station(nil), stationsByName[key]...)
+		replaced := false
+		for i, old := range list {
+			if old.Agency == st.Agency {
+				list[i] = st
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			list = append(list, st)
+		}
+		stationsByName[key] = list
+	}
+}
+
+// splitLocation splits a Location such as "Millbrae (Caltrain)" into the
+// station name and the operator in parentheses, if there is one.
+func splitLocation(location string) (name, operator string) {
+	location = strings.TrimSpace(location)
+	if i := strings.LastIndexByte(location, '('); i > 0 && strings.HasSuffix(location, ")") {
+		return strings.TrimSpace(location[:i]), strings.TrimSpace(location[i+1 : len(location)-1])
+	}
+	return location, ""
+}
+
+// LookupStation returns the station named by location, a value from the
+// Location column of a statement. If the name is shared by more than one
+// agency's station, location must say which operator it is, as in "Millbrae
+// (BART)".
+func LookupStation(location string) (Station, bool) {
+	name, operator := splitLocation(location)
+	stationsMu.RLock()
+	candidates := stationsByName[strings.ToLower(name)]
+	stationsMu.RUnlock()
+	if operator != "" {
+		op := strings.ToLower(operator)
+		for _, s := range candidates {
+			if strings.ToLower(string(s.Agency)) == op {
+				return s, true
+			}
+		}
+		return Station{}, false
+	}
+	if len(candidates) != 1 {
+		return Station{}, false
+	}
+	return candidates[0], true
+}
+
+// earthRadiusMiles is the mean radius of the Earth.
+const earthRadiusMiles = 3958.8
+
+// MilesTo returns the straight-line distance from s to o in miles.
+func (s Station) MilesTo(o Station) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := rad(o.Lat - s.Lat)
+	dLon := rad(o.Lon - s.Lon)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(s.Lat))*math.Cos(rad(o.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMiles * math.Asin(math.Sqrt(a))
+}
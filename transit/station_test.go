@@ -0,0 +1,36 @@
+package transit
+
+import "testing"
+
+func TestLookupStation(t *testing.T) {
+	tests := []struct {
+		location string
+		agency   Agency
+		zone     int
+		ok       bool
+	}{
+		{"Millbrae (Caltrain)", AgencyCaltrain, 2, true},
+		{"Millbrae (BART)", AgencyBART, 0, true},
+		{"Millbrae", "", 0, false},
+		{"4th and King (Caltrain)", AgencyCaltrain, 1, true},
+		{"belmont", AgencyCaltrain, 3, true},
+		{"Powell (Muni)", "", 0, false},
+	}
+	for _, tt := range tests {
+		st, ok := LookupStation(tt.location)
+		if ok != tt.ok || st.Agency != tt.agency || st.Zone != tt.zone {
+			t.Errorf("LookupStation(%q): got %+v, %t", tt.location, st, ok)
+		}
+	}
+}
+
+func TestMilesTo(t *testing.T) {
+	sf, _ := LookupStation("4th and King (Caltrain)")
+	sj, _ := LookupStation("San Jose Diridon")
+	if miles := sf.MilesTo(sj); miles < 40 || miles > 45 {
+		t.Errorf("SF to San Jose: got %.1f miles", miles)
+	}
+	if miles := sf.MilesTo(sf); miles != 0 {
+		t.Errorf("expected 0 miles to the same station, got %v", miles)
+	}
+}
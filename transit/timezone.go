@@ -0,0 +1,61 @@
+package transit
+
+import (
+	"time"
+	// Containers and serverless runtimes often have no zoneinfo database;
+	// embed one so Pacific always loads.
+	_ "time/tzdata"
+)
+
+// Pacific is America/Los_Angeles, the time zone Clipper runs in.
+//
+// Times in these packages follow a few rules, so that a server running in
+// UTC draws day and month lines in the same places as a laptop in San
+// Francisco:
+//
+//   - Statements print times without a zone; they're read as Pacific time,
+//     so a Transaction's Timestamp is in Pacific.
+//   - Grouping by day or month uses the zone of the times being grouped,
+//     which for transactions is Pacific.
+//   - Dates given by people, like a --from flag or a from= query parameter,
+//     mean midnight Pacific time at the start of that day; see ParseDate.
+//     "Last month" and "this month" are the Pacific calendar months; see
+//     MonthStart and LastMonth.
+var Pacific *time.Location
+
+func init() {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		panic("transit: loading America/Los_Angeles: " + err.Error())
+	}
+	Pacific = loc
+}
+
+// DateLayout is the layout of dates given to ParseDate.
+const DateLayout = "2006-01-02"
+
+// ParseDate parses a date like "2024-03-01" as midnight Pacific time at the
+// start of that day.
+func ParseDate(s string) (time.Time, error) {
+	return time.ParseInLocation(DateLayout, s, Pacific)
+}
+
+// ParseMonth parses a month like "2024-03" as midnight Pacific time on its
+// first day.
+func ParseMonth(s string) (time.Time, error) {
+	return time.ParseInLocation("2006-01", s, Pacific)
+}
+
+// MonthStart returns midnight Pacific time on the first day of the Pacific
+// calendar month that t falls in.
+func MonthStart(t time.Time) time.Time {
+	t = t.In(Pacific)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, Pacific)
+}
+
+// LastMonth returns midnight Pacific time on the first and on the last day of
+// the Pacific calendar month before the one now falls in.
+func LastMonth(now time.Time) (first, last time.Time) {
+	this := MonthStart(now)
+	return this.AddDate(0, -1, 0), this.AddDate(0, 0, -1)
+}
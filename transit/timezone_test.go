@@ -0,0 +1,50 @@
+package transit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	d, err := ParseDate("2024-03-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC); !d.Equal(want) || d.Location() != Pacific {
+		t.Errorf("got %v, want %v in Pacific", d, want)
+	}
+	m, err := ParseMonth("2024-07")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 7, 1, 7, 0, 0, 0, time.UTC); !m.Equal(want) {
+		t.Errorf("ParseMonth: got %v, want %v", m, want)
+	}
+	if _, err := ParseDate("03/10/2024"); err == nil {
+		t.Error("want an error for a date in the wrong layout")
+	}
+}
+
+func TestLastMonth(t *testing.T) {
+	for _, tt := range []struct {
+		now         time.Time
+		first, last string
+	}{
+		// 3am UTC on April 1 is still March 31 in California.
+		{time.Date(2024, 4, 1, 3, 0, 0, 0, time.UTC), "2024-02-01", "2024-02-29"},
+		{time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC), "2024-03-01", "2024-03-31"},
+		{time.Date(2025, 1, 15, 12, 0, 0, 0, Pacific), "2024-12-01", "2024-12-31"},
+	} {
+		first, last := LastMonth(tt.now)
+		if got := first.Format(DateLayout); got != tt.first || first.Location() != Pacific || first.Hour() != 0 {
+			t.Errorf("LastMonth(%v): first %v, want %s", tt.now, first, tt.first)
+		}
+		if got := last.Format(DateLayout); got != tt.last || last.Hour() != 0 {
+			t.Errorf("LastMonth(%v): last %v, want %s", tt.now, last, tt.last)
+		}
+	}
+	// March 10, 2024 is when clocks went forward.
+	if got := MonthStart(time.Date(2024, 3, 31, 23, 0, 0, 0, Pacific)); !got.Equal(time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("MonthStart: got %v", got)
+	}
+}
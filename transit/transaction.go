@@ -0,0 +1,87 @@
+// Package transit describes Clipper transactions and the agencies and stations
+// they refer to. It has no dependencies outside the standard library, so it
+// can be used without the statement downloader in package clipper.
+package transit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A Transaction is a row from a card's transaction history, with the amounts
+// and date parsed.
+type Transaction struct {
+	// Timestamp is the time of the tag, as printed on the statement, in
+	// Pacific time.
+	Timestamp time.Time
+	// Type is Clipper's description of the transaction, for example
+	// "Single-tag fare payment" or "Dual-tag exit transaction, fare payment".
+	Type     string
+	Location string
+	Route    string
+	Product  string
+
+	DebitCents   Money
+	CreditCents  Money
+	BalanceCents Money
+
+	// CardSerial is the serial number of the card the transaction was made
+	// with, if known.
+	CardSerial int64
+
+	// Source is the statement the transaction was read from, if known, and
+	// Row is its position in that statement, starting at 1.
+	Source string
+	Row    int
+}
+
+// ID identifies t across statements and downloads: the same transaction read
+// from two statements has the same ID, whichever file it came from and
+// whatever time zone its time was read in.
+func (t Transaction) ID() string {
+	// The wall clock time, so IDs made before times were read as Pacific
+	// time still match.
+	ts := t.Timestamp
+	wall := time.Date(ts.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0, time.UTC).Unix()
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%d\x00%s\x00%s\x00%d\x00%d\x00%d", wall, t.CardSerial, t.Type, t.Location, t.DebitCents, t.CreditCents, t.BalanceCents)
+	return hex.EncodeToString(h.Sum(nil)[:12])
+}
+
+// IsFare reports whether the transaction is a tag that pays for (or adjusts
+// the price of) a ride, as opposed to adding value to the card.
+func (t Transaction) IsFare() bool {
+	lower := strings.ToLower(t.Type)
+	return strings.Contains(lower, "fare") || strings.Contains(lower, "tag") || strings.Contains(lower, "transfer")
+}
+
+// IsTripStart reports whether the transaction starts a ride: a single tag on a
+// bus or train, or the entry tag of a dual-tag (tag on, tag off) ride.
+func (t Transaction) IsTripStart() bool {
+	lower := strings.ToLower(t.Type)
+	if strings.Contains(lower, "single-tag") {
+		return true
+	}
+	return strings.Contains(lower, "dual-tag") && strings.Contains(lower, "entry")
+}
+
+// IsReload reports whether the transaction adds value to the card, for example
+// an Autoload or a purchase at a ticket machine.
+func (t Transaction) IsReload() bool {
+	if t.IsFare() || t.CreditCents == 0 {
+		return false
+	}
+	return true
+}
+
+// FareCents returns how much the transaction cost toward rides: debits minus
+// credits for fare transactions, and zero for reloads.
+func (t Transaction) FareCents() Money {
+	if !t.IsFare() {
+		return 0
+	}
+	return t.DebitCents - t.CreditCents
+}
@@ -0,0 +1,209 @@
+package clipper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/rest"
+)
+
+// TransportOptions tune the connections a Client makes to the Clipper site.
+// The zero value of each field keeps the default from http.DefaultTransport.
+type TransportOptions struct {
+	// MaxIdleConnsPerHost is how many idle connections to keep open to each
+	// host, for the next request to reuse. http.Transport keeps 2. Raise it
+	// when several goroutines share a client, as a run over an institution's
+	// cards can.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits the connections open to each host at once,
+	// including ones in use; requests past the limit wait. 0 means no limit.
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long an idle connection stays open.
+	IdleConnTimeout time.Duration
+	// KeepAlive is how often to send TCP keep-alive probes on an open
+	// connection. A negative KeepAlive turns the probes off.
+	KeepAlive time.Duration
+	// DisableKeepAlives makes every request open a new connection.
+	DisableKeepAlives bool
+	// DisableHTTP2 makes the client speak HTTP/1.1 only, for when the site's
+	// firewall mishandles HTTP/2 connections.
+	DisableHTTP2 bool
+	// TLSHandshakeTimeout is how long to wait for a TLS handshake.
+	TLSHandshakeTimeout time.Duration
+	// TLSConfig, if set, configures TLS connections, for example to trust a
+	// proxy's certificate authority or to require TLS 1.3. It's cloned, so
+	// changing it later has no effect.
+	TLSConfig *tls.Config
+	// PinnedCertificates, if set, makes the transport refuse a TLS
+	// connection unless a certificate in the server's chain has one of
+	// these public keys; see WithPinnedCertificates for the format. If a pin
+	// isn't valid, every request fails.
+	PinnedCertificates []string
+}
+
+// NewTransport returns a RoundTripper configured by o. Like the transport a
+// Client uses by default, it prints requests and responses to stderr when
+// $DEBUG_HTTP_TRAFFIC is "true", with Secrets redacted.
+//
+// Use it with WithTransport to wrap the tuned transport, for example to count
+// errors; otherwise use WithTransportOptions.
+func NewTransport(o TransportOptions) http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	keepAlive := 30 * time.Second
+	if o.KeepAlive != 0 {
+		keepAlive = o.KeepAlive
+	}
+	t.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: keepAlive,
+	}).DialContext
+	if o.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+		if t.MaxIdleConns != 0 && t.MaxIdleConns < o.MaxIdleConnsPerHost {
+			t.MaxIdleConns = o.MaxIdleConnsPerHost
+		}
+	}
+	if o.MaxConnsPerHost > 0 {
+		t.MaxConnsPerHost = o.MaxConnsPerHost
+	}
+	if o.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = o.IdleConnTimeout
+	}
+	if o.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = o.TLSHandshakeTimeout
+	}
+	if o.TLSConfig != nil {
+		t.TLSClientConfig = o.TLSConfig.Clone()
+	}
+	if len(o.PinnedCertificates) > 0 {
+		pins, err := parsePins(o.PinnedCertificates)
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = new(tls.Config)
+		}
+		t.TLSClientConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			if err != nil {
+				return err
+			}
+			return checkPins(cs, pins)
+		}
+	}
+	t.DisableKeepAlives = o.DisableKeepAlives
+	if o.DisableHTTP2 {
+		t.ForceAttemptHTTP2 = false
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetHTTP1(true)
+	}
+	return &rest.Transport{
+		RoundTripper: t,
+		Debug:        rest.DefaultTransport.Debug,
+		Output:       redactingWriter{rest.DefaultTransport.Output},
+	}
+}
+
+// defaultTransport is the transport a Client uses unless it's given another:
+// rest.DefaultTransport, but with Secrets redacted from its debug output.
+var defaultTransport = &rest.Transport{
+	RoundTripper: http.DefaultTransport,
+	Debug:        rest.DefaultTransport.Debug,
+	Output:       redactingWriter{rest.DefaultTransport.Output},
+}
+
+// WithTransportOptions makes the client send requests with a transport
+// configured by o. It replaces a transport set by WithTransport, and the
+// other way around, whichever comes last.
+func WithTransportOptions(o TransportOptions) Option {
+	return func(c *Client) {
+		c.client.Transport = NewTransport(o)
+		c.transportOpts = &o
+	}
+}
+
+// WithPinnedCertificates makes the client refuse to connect to a server
+// unless a certificate in the chain it presents has one of pins as its public
+// key, so that it fails rather than send your password through a proxy that
+// intercepts TLS with a certificate your machine trusts. Each pin is the
+// base64 SHA-256 hash of a certificate's SubjectPublicKeyInfo, with or
+// without a "sha256/" prefix, which you can get with:
+//
+//	openssl s_client -connect www.clippercard.com:443 </dev/null |
+//		openssl x509 -pubkey -noout | openssl pkey -pubin -outform der |
+//		openssl dgst -sha256 -binary | base64
+//
+// The pins apply to every host the client visits, including an identity
+// provider the login goes through. Pin the key of a certificate authority
+// in the chain, as well as the site's own, to keep working when the site
+// renews its certificate.
+//
+// NewClient returns an error if a pin isn't valid, or if the client's
+// transport was set by WithTransport; use TransportOptions.PinnedCertificates
+// with NewTransport instead.
+func WithPinnedCertificates(pins ...string) Option {
+	return func(c *Client) {
+		c.pins = append([]string{}, pins...)
+	}
+}
+
+// pinTransport applies the pins from WithPinnedCertificates to c's transport.
+func (c *Client) pinTransport() error {
+	if _, err := parsePins(c.pins); err != nil {
+		return err
+	}
+	var o TransportOptions
+	switch {
+	case c.transportOpts != nil:
+		o = *c.transportOpts
+	case c.client.Transport != defaultTransport:
+		return errors.New("clipper: can't pin certificates on a transport set by WithTransport; set TransportOptions.PinnedCertificates instead")
+	}
+	o.PinnedCertificates = append(append([]string(nil), o.PinnedCertificates...), c.pins...)
+	c.client.Transport = NewTransport(o)
+	return nil
+}
+
+func parsePins(pins []string) ([][]byte, error) {
+	if len(pins) == 0 {
+		return nil, errors.New("clipper: no certificate pins")
+	}
+	hashes := make([][]byte, len(pins))
+	for i, pin := range pins {
+		h, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256/"))
+		if err != nil || len(h) != sha256.Size {
+			return nil, fmt.Errorf("clipper: certificate pin %q isn't a base64 SHA-256 hash", pin)
+		}
+		hashes[i] = h
+	}
+	return hashes, nil
+}
+
+// checkPins returns an error unless a certificate in a chain the server's
+// certificate was verified with has a public key hashing to one of pins.
+// The other certificates the server sent don't count: anyone can send a
+// copy of a pinned certificate.
+func checkPins(cs tls.ConnectionState, pins [][]byte) error {
+	chains := cs.VerifiedChains
+	if len(chains) == 0 && len(cs.PeerCertificates) > 0 {
+		// InsecureSkipVerify is set; only the server's own key is known to
+		// be the server's.
+		chains = [][]*x509.Certificate{cs.PeerCertificates[:1]}
+	}
+	for _, chain := range chains {
+		for _, cert := range chain {
+			h := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			for _, pin := range pins {
+				if bytes.Equal(h[:], pin) {
+					return nil
+				}
+			}
+		}
+	}
+	return fmt.Errorf("clipper: the certificate for %s doesn't match a pinned key", cs.ServerName)
+}
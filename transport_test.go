@@ -0,0 +1,97 @@
+package clipper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/rest"
+)
+
+func TestNewTransport(t *testing.T) {
+	rt := NewTransport(TransportOptions{MaxIdleConnsPerHost: 200, MaxConnsPerHost: 8, IdleConnTimeout: time.Minute})
+	tr := rt.(*rest.Transport).RoundTripper.(*http.Transport)
+	if tr.MaxIdleConnsPerHost != 200 || tr.MaxIdleConns < 200 || tr.MaxConnsPerHost != 8 || tr.IdleConnTimeout != time.Minute {
+		t.Errorf("got MaxIdleConnsPerHost %d, MaxIdleConns %d, MaxConnsPerHost %d, IdleConnTimeout %v",
+			tr.MaxIdleConnsPerHost, tr.MaxIdleConns, tr.MaxConnsPerHost, tr.IdleConnTimeout)
+	}
+	if tr == http.DefaultTransport {
+		t.Error("NewTransport changed http.DefaultTransport")
+	}
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	s.EnableHTTP2 = true
+	s.StartTLS()
+	defer s.Close()
+	tlsConfig := s.Client().Transport.(*http.Transport).TLSClientConfig
+	for _, disable := range []bool{false, true} {
+		c, err := NewClient("email", "password", WithTransportOptions(TransportOptions{TLSConfig: tlsConfig, DisableHTTP2: disable}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, _ := http.NewRequestWithContext(context.Background(), "GET", s.URL, nil)
+		resp, err := c.client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		want := 2
+		if disable {
+			want = 1
+		}
+		if resp.ProtoMajor != want {
+			t.Errorf("DisableHTTP2 %t: got %s", disable, resp.Proto)
+		}
+	}
+}
+
+func TestPinnedCertificates(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+	tlsConfig := s.Client().Transport.(*http.Transport).TLSClientConfig
+	h := sha256.Sum256(s.Certificate().RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(h[:])
+	other := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	for _, tt := range []struct {
+		pins    []string
+		wantErr string
+	}{
+		{[]string{pin}, ""},
+		{[]string{other, "sha256/" + pin}, ""},
+		{[]string{other}, "doesn't match a pinned key"},
+	} {
+		c, err := NewClient("email", "password", WithTransportOptions(TransportOptions{TLSConfig: tlsConfig}), WithPinnedCertificates(tt.pins...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, _ := http.NewRequestWithContext(context.Background(), "GET", s.URL, nil)
+		resp, err := c.client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("pins %q: got error %v, want %q", tt.pins, err, tt.wantErr)
+		}
+	}
+
+	for _, opts := range [][]Option{
+		{WithPinnedCertificates("not a pin")},
+		{WithPinnedCertificates()},
+		{WithTransport(lockedTransport{}), WithPinnedCertificates(pin)},
+	} {
+		if _, err := NewClient("email", "password", opts...); err == nil {
+			t.Errorf("NewClient with %d options: want an error", len(opts))
+		}
+	}
+	// A transport that doesn't come from NewClient can still be pinned.
+	rt := NewTransport(TransportOptions{TLSConfig: tlsConfig, PinnedCertificates: []string{other}})
+	req, _ := http.NewRequest("GET", s.URL, nil)
+	if _, err := rt.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "doesn't match a pinned key") {
+		t.Errorf("NewTransport with the wrong pin: got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package clipper
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Recent desktop browsers. Update these when the browsers' stable versions
+// move on: the Clipper site's firewall challenges browsers that are plainly
+// out of date, and a challenge page looks to the client like a failed login.
+const (
+	chromeMac     = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36"
+	chromeWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36"
+	edgeWindows   = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36 Edg/141.0.0.0"
+	firefoxMac    = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:143.0) Gecko/20100101 Firefox/143.0"
+	firefoxWin    = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:143.0) Gecko/20100101 Firefox/143.0"
+	safariMac     = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Safari/605.1.15"
+)
+
+// DefaultUserAgent is the User-Agent a Client sends unless it's configured
+// with WithUserAgent or WithUserAgentRotation: a recent Chrome on a Mac.
+const DefaultUserAgent = chromeMac
+
+// UserAgents are the User-Agents of recent desktop browsers, which
+// WithUserAgentRotation picks from if it isn't given any.
+var UserAgents = []string{chromeMac, chromeWindows, edgeWindows, firefoxMac, firefoxWin, safariMac}
+
+// WithUserAgent makes the client identify itself as ua, or as
+// DefaultUserAgent if ua is empty. The client sends the Accept and client
+// hint headers that the browser ua names would.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		if ua == "" {
+			ua = DefaultUserAgent
+		}
+		c.userAgents = nil
+		c.browser.Store(newBrowser(ua))
+	}
+}
+
+// WithUserAgentRotation makes the client pick one of uas, or of UserAgents
+// if uas is empty, at random each time it logs in, and send it until it logs
+// in again. It doesn't switch between requests: a session whose browser
+// changes partway through looks more like a bot than any one browser does.
+func WithUserAgentRotation(uas ...string) Option {
+	return func(c *Client) {
+		if len(uas) == 0 {
+			uas = UserAgents
+		}
+		c.userAgents = uas
+		c.browser.Store(newBrowser(uas[rand.Intn(len(uas))]))
+	}
+}
+
+// rotateUserAgent picks a browser for a new session, if the client rotates
+// them. The caller should hold c.mu.
+func (c *Client) rotateUserAgent() {
+	if len(c.userAgents) > 0 {
+		c.browser.Store(newBrowser(c.userAgents[rand.Intn(len(c.userAgents))]))
+	}
+}
+
+// UserAgent returns the User-Agent the client is sending.
+func (c *Client) UserAgent() string {
+	return c.browser.Load().userAgent
+}
+
+// A browser is who a Client says it is: a User-Agent, and the headers that
+// browser sends along with it.
+type browser struct {
+	userAgent string
+	// accept is the Accept header the browser sends when it loads a page.
+	accept string
+	// clientHints are the Sec-CH-UA headers Chromium browsers send. Other
+	// browsers don't send them.
+	clientHints http.Header
+}
+
+const (
+	defaultAccept  = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	chromiumAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7"
+)
+
+// newBrowser returns the browser that sends ua, working out the other
+// headers it sends from what ua says.
+func newBrowser(ua string) *browser {
+	b := &browser{userAgent: ua, accept: defaultAccept}
+	brand, version := "Google Chrome", majorVersion(ua, "Chrome/")
+	if v := majorVersion(ua, "Edg/"); v != "" {
+		brand, version = "Microsoft Edge", v
+	}
+	if version == "" || strings.Contains(ua, "OPR/") {
+		return b
+	}
+	mobile := "?0"
+	if strings.Contains(ua, "Mobile") {
+		mobile = "?1"
+	}
+	b.accept = chromiumAccept
+	b.clientHints = http.Header{
+		"Sec-Ch-Ua":          {fmt.Sprintf(`"%s";v="%s", "Chromium";v="%s", "Not?A_Brand";v="24"`, brand, version, version)},
+		"Sec-Ch-Ua-Mobile":   {mobile},
+		"Sec-Ch-Ua-Platform": {`"` + platform(ua) + `"`},
+	}
+	return b
+}
+
+// majorVersion returns the major version after product, like "141" for
+// "Chrome/" in a Chrome User-Agent, or "" if ua doesn't have one.
+func majorVersion(ua, product string) string {
+	i := strings.Index(ua, product)
+	if i < 0 {
+		return ""
+	}
+	v := ua[i+len(product):]
+	n := 0
+	for n < len(v) && v[n] >= '0' && v[n] <= '9' {
+		n++
+	}
+	return v[:n]
+}
+
+// platform returns the operating system ua runs on, the way Chromium names
+// it in the Sec-CH-UA-Platform header.
+func platform(ua string) string {
+	switch {
+	case strings.Contains(ua, "Android"):
+		return "Android"
+	case strings.Contains(ua, "Windows"):
+		return "Windows"
+	case strings.Contains(ua, "CrOS"):
+		return "Chrome OS"
+	case strings.Contains(ua, "Macintosh"):
+		return "macOS"
+	case strings.Contains(ua, "Linux"), strings.Contains(ua, "X11"):
+		return "Linux"
+	}
+	return "Unknown"
+}
+
+// setBrowserHeaders sets the headers the client's browser sends with req:
+// its User-Agent and the headers that go with it, and the Referer, Origin
+// and Sec-Fetch headers for a request made from the page at referer, or
+// typed into the address bar if referer is "". Set ajax for a request that a
+// page's scripts make, rather than one that loads a page.
+//
+// Clipper picks the page language from Accept-Language when the account
+// doesn't have a preference, and our parsers work best on English pages.
+func (c *Client) setBrowserHeaders(req *http.Request, referer string, ajax bool) {
+	b := c.browser.Load()
+	h := req.Header
+	h.Set("User-Agent", b.userAgent)
+	h.Set("Accept-Language", "en-US,en;q=0.9")
+	for k, v := range b.clientHints {
+		h[k] = v
+	}
+	site := "none"
+	if referer != "" {
+		h.Set("Referer", referer)
+		if from, err := url.Parse(referer); err == nil {
+			site = fetchSite(from, req.URL)
+			if req.Method == "POST" {
+				h.Set("Origin", from.Scheme+"://"+from.Host)
+			}
+		}
+	}
+	h.Set("Sec-Fetch-Site", site)
+	if ajax {
+		h.Set("Accept", "text/html, */*; q=0.01")
+		h.Set("X-Requested-With", "XMLHttpRequest")
+		h.Set("Sec-Fetch-Mode", "cors")
+		h.Set("Sec-Fetch-Dest", "empty")
+		return
+	}
+	h.Set("Accept", b.accept)
+	h.Set("Upgrade-Insecure-Requests", "1")
+	h.Set("Sec-Fetch-Mode", "navigate")
+	h.Set("Sec-Fetch-Dest", "document")
+	h.Set("Sec-Fetch-User", "?1")
+}
+
+// fetchSite returns the Sec-Fetch-Site header for a request to u made from
+// the page at from.
+func fetchSite(from, u *url.URL) string {
+	if from.Scheme == u.Scheme && from.Host == u.Host {
+		return "same-origin"
+	}
+	a, err1 := publicsuffix.EffectiveTLDPlusOne(from.Hostname())
+	b, err2 := publicsuffix.EffectiveTLDPlusOne(u.Hostname())
+	if err1 == nil && err2 == nil && a == b && from.Scheme == u.Scheme {
+		return "same-site"
+	}
+	return "cross-site"
+}
@@ -0,0 +1,131 @@
+package clipper
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+var browserTests = []struct {
+	ua       string
+	accept   string
+	brands   string
+	platform string
+}{
+	{chromeMac, chromiumAccept, `"Google Chrome";v="141", "Chromium";v="141", "Not?A_Brand";v="24"`, `"macOS"`},
+	{chromeWindows, chromiumAccept, `"Google Chrome";v="141", "Chromium";v="141", "Not?A_Brand";v="24"`, `"Windows"`},
+	{edgeWindows, chromiumAccept, `"Microsoft Edge";v="141", "Chromium";v="141", "Not?A_Brand";v="24"`, `"Windows"`},
+	{firefoxMac, defaultAccept, "", ""},
+	{safariMac, defaultAccept, "", ""},
+	{"curl/8.4.0", defaultAccept, "", ""},
+}
+
+func TestNewBrowser(t *testing.T) {
+	for _, tt := range browserTests {
+		b := newBrowser(tt.ua)
+		if b.accept != tt.accept {
+			t.Errorf("newBrowser(%q).accept = %q, want %q", tt.ua, b.accept, tt.accept)
+		}
+		if got := b.clientHints.Get("Sec-CH-UA"); got != tt.brands {
+			t.Errorf("newBrowser(%q) Sec-CH-UA = %q, want %q", tt.ua, got, tt.brands)
+		}
+		if got := b.clientHints.Get("Sec-CH-UA-Platform"); got != tt.platform {
+			t.Errorf("newBrowser(%q) Sec-CH-UA-Platform = %q, want %q", tt.ua, got, tt.platform)
+		}
+	}
+}
+
+func TestSetBrowserHeaders(t *testing.T) {
+	c, err := NewClient("email", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		method, url, referer string
+		ajax                 bool
+		want                 map[string]string
+	}{
+		{"GET", host + "/ClipperWeb/login.html", "", false, map[string]string{
+			"User-Agent":     DefaultUserAgent,
+			"Accept":         chromiumAccept,
+			"Referer":        "",
+			"Sec-Fetch-Site": "none",
+			"Sec-Fetch-Mode": "navigate",
+			"Sec-Fetch-User": "?1",
+		}},
+		{"POST", host + "/ClipperWeb/account", host + "/ClipperWeb/login.html", false, map[string]string{
+			"Origin":         host,
+			"Referer":        host + "/ClipperWeb/login.html",
+			"Sec-Fetch-Site": "same-origin",
+		}},
+		{"GET", host + "/ClipperWeb/cards", host + "/ClipperWeb/account.html", true, map[string]string{
+			"X-Requested-With": "XMLHttpRequest",
+			"Origin":           "",
+			"Sec-Fetch-Mode":   "cors",
+			"Sec-Fetch-Dest":   "empty",
+			"Sec-Fetch-User":   "",
+		}},
+		{"POST", "https://login.clippercard.com/sso", host + "/ClipperWeb/login.html", false, map[string]string{
+			"Sec-Fetch-Site": "same-site",
+		}},
+		{"POST", "https://sso.example.com/login", host + "/ClipperWeb/login.html", false, map[string]string{
+			"Sec-Fetch-Site": "cross-site",
+		}},
+	} {
+		req, err := http.NewRequest(tt.method, tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.setBrowserHeaders(req, tt.referer, tt.ajax)
+		for k, v := range tt.want {
+			if got := req.Header.Get(k); got != v {
+				t.Errorf("%s %s from %q: %s = %q, want %q", tt.method, tt.url, tt.referer, k, got, v)
+			}
+		}
+	}
+}
+
+// uaTransport records the User-Agent of each request and fails it.
+type uaTransport struct{ uas []string }
+
+func (u *uaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u.uas = append(u.uas, req.Header.Get("User-Agent"))
+	return lockedTransport{}.RoundTrip(req)
+}
+
+func TestUserAgentRotation(t *testing.T) {
+	rt := new(uaTransport)
+	pool := []string{chromeWindows, firefoxMac}
+	c, err := NewClient("email", "password", WithTransport(rt), WithUserAgentRotation(pool...), WithRateLimit(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		rt.uas = nil
+		c.ensureLogin(context.Background())
+		if len(rt.uas) == 0 {
+			t.Fatal("login sent no requests")
+		}
+		for _, ua := range rt.uas {
+			if ua != rt.uas[0] {
+				t.Fatalf("User-Agent changed within a login: %q", rt.uas)
+			}
+		}
+		if rt.uas[0] != pool[0] && rt.uas[0] != pool[1] {
+			t.Fatalf("User-Agent %q isn't in the pool", rt.uas[0])
+		}
+		seen[rt.uas[0]] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("20 logins all used %v", seen)
+	}
+
+	c, err = NewClient("email", "password", WithUserAgent("Custom/1.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.UserAgent() != "Custom/1.0" {
+		t.Errorf("UserAgent() = %q, want Custom/1.0", c.UserAgent())
+	}
+}
@@ -0,0 +1,300 @@
+package clipper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	v1 "github.com/kevinburke/clipper"
+)
+
+type (
+	// A Renderer loads pages that need JavaScript; see the version 1
+	// Renderer.
+	Renderer = v1.Renderer
+	// An Event is a login or download a Client did; see the version 1
+	// Event.
+	Event = v1.Event
+	// A SessionStore keeps a client's session between runs; see the
+	// version 1 SessionStore.
+	SessionStore = v1.SessionStore
+	// A SiteAdapter is how a Client talks to a card portal; see the version
+	// 1 SiteAdapter.
+	SiteAdapter = v1.SiteAdapter
+	// A CardError is what went wrong with one card when the others worked;
+	// see the version 1 CardError.
+	CardError = v1.CardError
+	// A WrongCardError is returned for a download that turns out to be
+	// another card's statement; see the version 1 WrongCardError.
+	WrongCardError = v1.WrongCardError
+	// A Pacer spaces out requests to each host; see the version 1 Pacer.
+	Pacer = v1.Pacer
+	// A Quota counts each account's requests and downloads; see the
+	// version 1 Quota.
+	Quota = v1.Quota
+)
+
+// NewPacer returns a Pacer that lets burst requests to a host go at once,
+// and after that one every interval.
+func NewPacer(interval time.Duration, burst int) *Pacer {
+	return v1.NewPacer(interval, burst)
+}
+
+// CardErrors returns the CardErrors in err; see the version 1 CardErrors.
+func CardErrors(err error) []*CardError {
+	return v1.CardErrors(err)
+}
+
+// What a Client did, in an Event.
+const (
+	EventLogin    = v1.EventLogin
+	EventResume   = v1.EventResume
+	EventDownload = v1.EventDownload
+)
+
+// What a Quota counts.
+const (
+	QuotaRequest  = v1.QuotaRequest
+	QuotaDownload = v1.QuotaDownload
+)
+
+// An Option configures a Client.
+type Option func(*settings)
+
+type settings struct {
+	username, password string
+	opts               []v1.Option
+}
+
+// WithLogin makes the client sign in to clippercard.com as username, with
+// password. Every Client needs it.
+func WithLogin(username, password string) Option {
+	return func(s *settings) {
+		s.username, s.password = username, password
+	}
+}
+
+// WithTransport makes the client send requests with rt, for example to count
+// or log them. rt must not be nil.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithTransport(rt))
+	}
+}
+
+// TransportOptions tune the connections a Client makes; see the version 1
+// TransportOptions.
+type TransportOptions = v1.TransportOptions
+
+// WithTransportOptions makes the client send requests with a transport
+// configured by o. It replaces a transport set by WithTransport, and the
+// other way around, whichever comes last.
+func WithTransportOptions(o TransportOptions) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithTransportOptions(o))
+	}
+}
+
+// WithPinnedCertificates makes the client refuse to connect to a server
+// unless a certificate in its chain has one of pins as its public key; see the
+// version 1 WithPinnedCertificates.
+func WithPinnedCertificates(pins ...string) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithPinnedCertificates(pins...))
+	}
+}
+
+// WithSiteAdapter makes the client use a for the card portal, instead of
+// clippercard.com.
+func WithSiteAdapter(a SiteAdapter) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithSiteAdapter(a))
+	}
+}
+
+// WithSiteURL makes the client find the Clipper site at rawurl, like a
+// clippermock server, instead of https://www.clippercard.com.
+func WithSiteURL(rawurl string) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithSiteURL(rawurl))
+	}
+}
+
+// WithSSOHosts lets the client log in through an identity provider at hosts,
+// as well as the Clipper site and its domain; see the version 1 WithSSOHosts.
+func WithSSOHosts(hosts ...string) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithSSOHosts(hosts...))
+	}
+}
+
+// WithPacer makes the client wait for p before each request, pacing it
+// together with the other clients that share p.
+func WithPacer(p *Pacer) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithPacer(p))
+	}
+}
+
+// WithQuota makes the client take from q for each request and download, and
+// fail once the account's quota for the day is used up.
+func WithQuota(q Quota) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithQuota(q))
+	}
+}
+
+// WithMinInterval makes the client wait at least d between requests to each
+// host, after a burst of as many as WithBurst allows.
+func WithMinInterval(d time.Duration) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithMinInterval(d))
+	}
+}
+
+// DefaultRateLimit and DefaultBurst are how a client paces its requests to
+// clippercard.com unless it's told otherwise; see the version 1
+// DefaultRateLimit.
+const (
+	DefaultRateLimit = v1.DefaultRateLimit
+	DefaultBurst     = v1.DefaultBurst
+)
+
+// WithRateLimit makes the client send at most one request to each host every
+// d, instead of pacing by DefaultRateLimit; a d of 0 turns pacing off. See the
+// version 1 WithRateLimit.
+func WithRateLimit(d time.Duration) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithRateLimit(d))
+	}
+}
+
+// WithBurst lets a client paced by WithMinInterval send n requests to a host
+// at once before it starts waiting.
+func WithBurst(n int) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithBurst(n))
+	}
+}
+
+// WithConcurrency makes the client work on up to n cards at once when it
+// downloads or fetches every card's statement; see the version 1
+// WithConcurrency.
+func WithConcurrency(n int) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithConcurrency(n))
+	}
+}
+
+// WithStatementCheck makes the client refuse to save a download that isn't a
+// Clipper statement, as well as one that isn't a whole PDF.
+func WithStatementCheck() Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithStatementCheck())
+	}
+}
+
+// WithSessionStore makes the client save its session in st and pick it up
+// again instead of logging in, while the site still takes it.
+func WithSessionStore(st SessionStore) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithSessionStore(st))
+	}
+}
+
+// A FileSessionStore keeps sessions in a file only its owner can read; see
+// the version 1 FileSessionStore.
+type FileSessionStore = v1.FileSessionStore
+
+// NewFileSessionStore returns a FileSessionStore that keeps sessions in the
+// file at path.
+func NewFileSessionStore(path string) *FileSessionStore {
+	return v1.NewFileSessionStore(path)
+}
+
+// WithSessionFile makes the client keep its session in the file at path,
+// like the version 1 NewClientWithSession.
+func WithSessionFile(path string) Option {
+	return WithSessionStore(NewFileSessionStore(path))
+}
+
+// WithRenderer makes the client fall back to r when a page comes back without
+// the data it expects, because the site filled it in with JavaScript.
+func WithRenderer(r Renderer) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithRenderer(r))
+	}
+}
+
+// WithEvents makes the client call fn each time it logs in or downloads a
+// statement, for example to keep an audit log. fn must not use the client.
+func WithEvents(fn func(Event)) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithEvents(fn))
+	}
+}
+
+// WithUserAgent makes the client identify itself as ua, along with the
+// headers the browser ua names would send. Without it, the client sends
+// v1.DefaultUserAgent.
+func WithUserAgent(ua string) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithUserAgent(ua))
+	}
+}
+
+// WithUserAgentRotation makes the client log in as a browser picked at random
+// from uas, or from v1.UserAgents if uas is empty, and keep it until it logs
+// in again.
+func WithUserAgentRotation(uas ...string) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, v1.WithUserAgentRotation(uas...))
+	}
+}
+
+// A Client gets cards and statements from clippercard.com. It's safe to use
+// from more than one goroutine.
+type Client struct {
+	c *v1.Client
+}
+
+// NewClient returns a Client configured by opts, which must include
+// WithLogin.
+func NewClient(opts ...Option) (*Client, error) {
+	var s settings
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if s.username == "" || s.password == "" {
+		return nil, errors.New("clipper: no username or password; use WithLogin")
+	}
+	c, err := v1.NewClient(s.username, s.password, s.opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{c: c}, nil
+}
+
+// Cards returns the cards on the account. If it can't load the whole card
+// list, it returns the cards it found along with the error.
+func (c *Client) Cards(ctx context.Context) ([]Card, error) {
+	return c.c.Cards(ctx)
+}
+
+// CardDetails returns card with the details only its own page shows, like
+// when it expires and the discount it gets.
+func (c *Client) CardDetails(ctx context.Context, card Card) (Card, error) {
+	return c.c.CardDetails(ctx, card)
+}
+
+// DownloadStatements saves a statement PDF for each card on the account to
+// dir, covering the Pacific calendar days from from to to. If from or to is
+// the zero time, the site picks the range. It returns an error without
+// contacting the site if the range isn't one the site accepts; see
+// v1.DateRange. A card that fails doesn't stop the others; the error has a
+// CardError for each card that did. Read the files with ParseFile or
+// ParseAll.
+func (c *Client) DownloadStatements(ctx context.Context, dir string, from, to time.Time) error {
+	return c.c.DownloadRange(ctx, dir, v1.DateRange{Start: from, End: to}, false)
+}
@@ -0,0 +1,194 @@
+// Package clipper reads Clipper card statements into typed transactions, and
+// downloads them from the Clipper website.
+//
+// This is version 2 of github.com/kevinburke/clipper. Where version 1 returns
+// a statement as rows of strings, version 2 returns Transactions with times
+// and Money amounts, and groups them into Journeys:
+//
+//	st, err := clipper.ParseFile("statement.pdf")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, j := range st.Journeys(0) {
+//		fmt.Println(j.Start().Format(time.Kitchen), j.Origin(), j.Destination(), j.FareCents())
+//	}
+//
+// Version 1 keeps working, and the two share an implementation: Transaction,
+// Money, Journey and Card here are the same types as in version 1 and its
+// transit and clipperstats packages, and FromTransactionData and
+// Statement.TransactionData convert between the two ways of holding a
+// statement. A program can move over one call at a time.
+package clipper
+
+import (
+	"context"
+	"io"
+	"time"
+
+	v1 "github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clippercsv"
+	"github.com/kevinburke/clipper/clipperstats"
+	"github.com/kevinburke/clipper/transit"
+)
+
+type (
+	Transaction = transit.Transaction
+	Money       = transit.Money
+	Agency      = transit.Agency
+	Station     = transit.Station
+	Journey     = clipperstats.Journey
+	Leg         = clipperstats.Leg
+	Card        = v1.Card
+)
+
+// Pacific is the time zone Clipper runs in, and that the times on a
+// Transaction are in; see transit.Pacific.
+var Pacific = transit.Pacific
+
+// DefaultTransferWindow is how long after one ride another can start and
+// still be part of the same journey, if Journeys is given a window of zero.
+const DefaultTransferWindow = clipperstats.DefaultTransferWindow
+
+// A Statement is the transactions on one card's statement.
+type Statement struct {
+	// Card is the serial number of the card, or 0 if the statement doesn't
+	// show it.
+	Card         int64
+	Transactions []Transaction
+}
+
+// ParsePDF reads the statement PDF in r.
+func ParsePDF(r io.ReadSeeker) (Statement, error) {
+	data, err := v1.ParsePDF(r)
+	if err != nil {
+		return Statement{}, err
+	}
+	return FromTransactionData(data)
+}
+
+// ParsePDFContext is like ParsePDF, but gives up with ctx.Err() once ctx is
+// done; see the version 1 ParsePDFContext.
+func ParsePDFContext(ctx context.Context, r io.ReadSeeker) (Statement, error) {
+	data, err := v1.ParsePDFContext(ctx, r)
+	if err != nil {
+		return Statement{}, err
+	}
+	return FromTransactionData(data)
+}
+
+// ParseOptions change how ParsePDFOptions reads a statement; see the version
+// 1 ParseOptions.
+type ParseOptions = v1.ParseOptions
+
+// A ParseError is a page or line of a statement that couldn't be read; see
+// the version 1 ParseError.
+type ParseError = v1.ParseError
+
+// ParseErrors returns the ParseErrors in err; see the version 1 ParseErrors.
+func ParseErrors(err error) []*ParseError {
+	return v1.ParseErrors(err)
+}
+
+// ParsePDFOptions is like ParsePDFContext, with opts. In lenient mode it
+// returns what it could read along with the ParseErrors for what it skipped;
+// see the version 1 ParsePDFOptions.
+func ParsePDFOptions(ctx context.Context, r io.ReadSeeker, opts ParseOptions) (Statement, error) {
+	data, err := v1.ParsePDFOptions(ctx, r, opts)
+	if data.Transactions == nil {
+		return Statement{}, err
+	}
+	st, cerr := FromTransactionData(data)
+	if cerr != nil {
+		return Statement{}, cerr
+	}
+	return st, err
+}
+
+// ParseFile reads the statement PDF at path.
+func ParseFile(path string) (Statement, error) {
+	data, err := v1.ParseFile(path)
+	if err != nil {
+		return Statement{}, err
+	}
+	return FromTransactionData(data)
+}
+
+// ParseAll reads the statement PDFs at files, up to workers at a time, or one
+// per CPU if workers is 0 or less. statements[i] and errs[i] are the result of
+// reading files[i]; see the version 1 ParseAll.
+func ParseAll(ctx context.Context, files []string, workers int) (statements []Statement, errs []error) {
+	data, errs := v1.ParseAll(ctx, files, workers)
+	statements = make([]Statement, len(files))
+	for i := range data {
+		if errs[i] == nil {
+			statements[i], errs[i] = FromTransactionData(data[i])
+		}
+	}
+	return statements, errs
+}
+
+// FromTransactionData converts a statement read by version 1 of this package.
+// It returns an error if a row's date or amounts don't parse.
+func FromTransactionData(data v1.TransactionData) (Statement, error) {
+	card := data.AccountNumber
+	if card < 0 {
+		card = 0
+	}
+	txns, err := data.ParsedTransactions()
+	if err != nil {
+		return Statement{}, err
+	}
+	return Statement{Card: card, Transactions: txns}, nil
+}
+
+// statementTimeLayout is how a statement writes a transaction's time.
+const statementTimeLayout = "01/02/2006 03:04 PM"
+
+// TransactionData returns s the way version 1 of this package holds a
+// statement, as a header row and a row of strings for each transaction, for
+// code that hasn't moved to version 2 yet.
+func (s Statement) TransactionData() v1.TransactionData {
+	card := s.Card
+	if card == 0 {
+		card = -1
+	}
+	records := make([][]string, 0, len(s.Transactions)+1)
+	records = append(records, []string{"Date", "Transaction Type", "Location", "Route", "Product", "Debit", "Credit", "Balance"})
+	amount := func(m Money) string {
+		if m == 0 {
+			return ""
+		}
+		return m.Decimal()
+	}
+	for _, t := range s.Transactions {
+		records = append(records, []string{
+			t.Timestamp.In(Pacific).Format(statementTimeLayout),
+			t.Type,
+			t.Location,
+			t.Route,
+			t.Product,
+			amount(t.DebitCents),
+			amount(t.CreditCents),
+			t.BalanceCents.Decimal(),
+		})
+	}
+	return v1.TransactionData{AccountNumber: card, Transactions: records}
+}
+
+// WriteCSV writes s to w as CSV, with the same columns as a statement.
+func (s Statement) WriteCSV(w io.Writer, opts clippercsv.Options) error {
+	return s.TransactionData().WriteCSV(w, opts)
+}
+
+// WriteJSON writes s to w as indented JSON, with times in RFC 3339 format and
+// amounts in cents; see the version 1 TransactionData.WriteJSON.
+func (s Statement) WriteJSON(w io.Writer) error {
+	return s.TransactionData().WriteJSON(w)
+}
+
+// Journeys groups the transactions on s into journeys, joining rides that
+// start within window of the end of the one before. A window of zero uses
+// DefaultTransferWindow.
+func (s Statement) Journeys(window time.Duration) []Journey {
+	return clipperstats.Journeys(s.Transactions, window)
+}
@@ -0,0 +1,115 @@
+package clipper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+
+	v1 "github.com/kevinburke/clipper"
+	"github.com/kevinburke/clipper/clippercsv"
+)
+
+const statementPDF = "../testdata/transactions.pdf"
+
+func TestParseFile(t *testing.T) {
+	st, err := ParseFile(statementPDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Card != 1202728442 || len(st.Transactions) != 31 {
+		t.Fatalf("got card %d with %d transactions", st.Card, len(st.Transactions))
+	}
+	first := st.Transactions[0]
+	if first.DebitCents != 205 || first.BalanceCents != 14685 || first.Agency() != v1.AgencySamTrans ||
+		first.Timestamp.Location() != Pacific || first.Timestamp.Format("2006-01-02 15:04") != "2017-12-12 09:17" {
+		t.Errorf("first transaction: %+v", first)
+	}
+	journeys := st.Journeys(0)
+	if len(journeys) == 0 || len(journeys) >= len(st.Transactions) {
+		t.Errorf("got %d journeys", len(journeys))
+	}
+
+	// A program moving to version 2 gets the same transactions both ways.
+	data, err := v1.ParseFile(statementPDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := FromTransactionData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(old, st) {
+		t.Error("FromTransactionData and ParseFile differ")
+	}
+	if back := st.TransactionData(); !reflect.DeepEqual(back, data) {
+		t.Errorf("TransactionData:\ngot  %v\nwant %v", back, data)
+	}
+	var got, want bytes.Buffer
+	if err := st.WriteCSV(&got, clippercsv.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.WriteCSV(&want, clippercsv.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("WriteCSV:\ngot  %s\nwant %s", got.String(), want.String())
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	files := []string{statementPDF, "../testdata/no-transactions.pdf", "missing.pdf"}
+	statements, errs := ParseAll(context.Background(), files, 2)
+	if errs[0] != nil || errs[1] != nil || !os.IsNotExist(errs[2]) {
+		t.Fatalf("got errors %v", errs)
+	}
+	if len(statements[0].Transactions) != 31 || len(statements[1].Transactions) != 0 || statements[1].Card != 0 {
+		t.Errorf("got %d and %d transactions, card %d", len(statements[0].Transactions), len(statements[1].Transactions), statements[1].Card)
+	}
+	f, err := os.Open(statementPDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	st, err := ParsePDF(f)
+	if err != nil || !reflect.DeepEqual(st, statements[0]) {
+		t.Errorf("ParsePDF: got %d transactions, %v", len(st.Transactions), err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParsePDFContext(ctx, f); err != context.Canceled {
+		t.Errorf("ParsePDFContext: got %v, want context.Canceled", err)
+	}
+}
+
+type offline struct{ requests int }
+
+func (o *offline) RoundTrip(req *http.Request) (*http.Response, error) {
+	o.requests++
+	return nil, errors.New("offline")
+}
+
+func TestNewClient(t *testing.T) {
+	if _, err := NewClient(); err == nil {
+		t.Error("want an error without WithLogin")
+	}
+	rt := new(offline)
+	var events []Event
+	c, err := NewClient(WithLogin("user@example.com", "password"), WithTransport(rt), WithEvents(func(e Event) { events = append(events, e) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Cards(context.Background()); err == nil {
+		t.Error("Cards: want an error")
+	}
+	if rt.requests == 0 || len(events) != 1 || events[0].Action != EventLogin || events[0].Err == nil {
+		t.Errorf("got %d requests and events %+v, want a failed login", rt.requests, events)
+	}
+}
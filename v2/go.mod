@@ -0,0 +1,24 @@
+module github.com/kevinburke/clipper/v2
+
+go 1.24.4
+
+require github.com/kevinburke/clipper v0.0.0-00010101000000-000000000000
+
+require (
+	github.com/go-stack/stack v1.8.1 // indirect
+	github.com/inconshreveable/log15 v3.0.0-testing.5+incompatible // indirect
+	github.com/kevinburke/rest v0.0.0-20240617045629-3ed0ad3487f0 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.16 // indirect
+	github.com/unidoc/unidoc v2.2.0+incompatible // indirect
+	golang.org/x/image v0.28.0 // indirect
+	golang.org/x/net v0.41.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/term v0.32.0 // indirect
+	golang.org/x/text v0.26.0 // indirect
+	golang.org/x/time v0.12.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
+
+// Version 2 is built on version 1; develop them together.
+replace github.com/kevinburke/clipper => ../
@@ -0,0 +1,161 @@
+package clipper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kevinburke/rest"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// ErrInvalidPDF is wrapped by the error ValidatePDF returns for a file that
+// isn't a whole, readable PDF, and so by the error for a download that comes
+// back as one. Clipper sometimes sends an HTML error page, or a statement cut
+// off partway, labeled as a PDF.
+var ErrInvalidPDF = errors.New("clipper: not a valid PDF")
+
+// pdfTail is how far from the end of a PDF its %%EOF marker can be; the
+// spec allows some trailing junk, like a newline or two.
+const pdfTail = 1024
+
+// ValidatePDF checks that r holds a whole PDF with at least one page: that it
+// starts with a PDF header, ends with an end-of-file marker, and has a
+// cross-reference table and page tree that can be read. It doesn't check
+// that the PDF is a Clipper statement; ParsePDF does that. It returns an
+// error wrapping ErrInvalidPDF if the PDF isn't whole, or the error from r
+// if reading fails.
+func ValidatePDF(r io.ReadSeeker) (err error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	head := make([]byte, min(size, 1024))
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(r, head); err != nil {
+		return err
+	}
+	if !bytes.Contains(head, []byte("%PDF-")) {
+		return fmt.Errorf("%w: no PDF header", ErrInvalidPDF)
+	}
+	tail := make([]byte, min(size, pdfTail))
+	if _, err := r.Seek(size-int64(len(tail)), io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(r, tail); err != nil {
+		return err
+	}
+	if !bytes.Contains(tail, []byte("%%EOF")) {
+		return fmt.Errorf("%w: no end-of-file marker; it may have been cut off after %d bytes", ErrInvalidPDF, size)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	// unidoc panics on some malformed PDFs.
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%w: %v", ErrInvalidPDF, p)
+		}
+	}()
+	reader, err := pdf.NewPdfReader(r)
+	if err != nil {
+		return fmt.Errorf("%w: can't read its cross-reference table: %v", ErrInvalidPDF, err)
+	}
+	pages, err := reader.GetNumPages()
+	if err != nil {
+		return fmt.Errorf("%w: can't read its pages: %v", ErrInvalidPDF, err)
+	}
+	if pages == 0 {
+		return fmt.Errorf("%w: it has no pages", ErrInvalidPDF)
+	}
+	return nil
+}
+
+// WithStatementCheck makes the client refuse to save a download that doesn't
+// start the way a statement does, with the title of its table of
+// transactions, or the line saying there weren't any. Every download is
+// checked with ValidatePDF, and for the card number on its first page,
+// either way; this also catches a valid PDF that isn't a statement.
+func WithStatementCheck() Option {
+	return func(c *Client) {
+		c.checkStatements = true
+	}
+}
+
+// checkStatement returns an error if the PDF in r, downloaded as card's
+// statement, isn't a whole PDF, or is the statement for another card, as
+// Clipper has been known to send. If the client checks statements, it also
+// returns one if the PDF isn't a statement at all. Errors about the PDF
+// itself wrap ErrInvalidPDF; a statement for another card is a
+// *WrongCardError.
+func (c *Client) checkStatement(ctx context.Context, card Card, r io.ReadSeeker) (err error) {
+	if err := ValidatePDF(r); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = nil
+			if c.checkStatements {
+				err = fmt.Errorf("%w: can't read its text: %v", ErrInvalidPDF, p)
+			}
+		}
+	}()
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	// The first page says whose statement it is.
+	pages, _, err := extractPDFPages(ctx, r, 1, false)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		if c.checkStatements {
+			return fmt.Errorf("%w: can't read its text: %v", ErrInvalidPDF, err)
+		}
+		rest.Logger.Debug("couldn't read the statement's card number", "card", card.SerialNumber, "err", err)
+		return nil
+	}
+	if !isStatement(pages) {
+		if c.checkStatements {
+			return fmt.Errorf("%w: it isn't a Clipper statement", ErrInvalidPDF)
+		}
+		return nil
+	}
+	if got := statementCard(pages[0]); got > 0 && got != card.SerialNumber {
+		return &WrongCardError{Want: card.SerialNumber, Got: got}
+	}
+	return nil
+}
+
+// isStatement reports whether pages, the text of a PDF, start the way a
+// statement does: with the title above its table of transactions, or the
+// line saying there weren't any.
+func isStatement(pages []string) bool {
+	if len(pages) == 0 {
+		return false
+	}
+	if strings.HasPrefix(pages[0], noActivity) {
+		return true
+	}
+	title, _, _ := strings.Cut(pages[0], "\n")
+	return canonicalPDFLine(strings.TrimSuffix(title, "\r")) == "TRANSACTION HISTORY FOR"
+}
+
+// statementCard returns the card number on page, the first page of a
+// statement, or -1 if it doesn't have one, as a statement without any rides
+// doesn't.
+func statementCard(page string) int64 {
+	if strings.HasPrefix(page, noActivity) {
+		return -1
+	}
+	lines := strings.SplitN(page, "\n", 3)
+	if len(lines) < 2 {
+		return -1
+	}
+	return readCardNumber(canonicalPDFLine(strings.TrimSuffix(lines[1], "\r")), -1)
+}
@@ -0,0 +1,47 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Run calls job once for each of names, at most concurrency at a time. Each
+// call gets its own context, canceled after timeout, and a panic in one is
+// reported as its error rather than crashing the others. The errors are
+// joined, each prefixed with its tenant's name.
+func Run(ctx context.Context, names []string, concurrency int, timeout time.Duration, job func(ctx context.Context, name string) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = fmt.Errorf("%s: %v", name, ctx.Err())
+				return
+			}
+			defer func() { <-sem }()
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = fmt.Errorf("%s: panic: %v", name, r)
+				}
+			}()
+			jobCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			if err := job(jobCtx, name); err != nil {
+				errs[i] = fmt.Errorf("%s: %v", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
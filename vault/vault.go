@@ -0,0 +1,208 @@
+// Package vault keeps the Clipper credentials of several accounts in one
+// file, so that one server can download statements for a small group of
+// people without any of their passwords being stored in the clear.
+//
+// Each account, or tenant, has its password sealed with its own key, derived
+// from the vault's master key and the tenant's name. The master key is never
+// written to the vault file; keep it somewhere else, like an environment
+// variable or a secrets manager.
+package vault
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/secretbox"
+)
+
+// A Tenant is one Clipper account in the vault.
+type Tenant struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	// SealedPassword is the account's password, sealed with the tenant's
+	// key and base64 encoded.
+	SealedPassword string  `json:"sealed_password"`
+	Cards          []int64 `json:"cards,omitempty"`
+}
+
+type file struct {
+	// Check is a known value sealed with the master key, to tell a wrong
+	// key apart from a corrupt password.
+	Check   string   `json:"check"`
+	Tenants []Tenant `json:"tenants"`
+}
+
+const checkValue = "clipper vault"
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ErrWrongKey is returned by Open when the vault was sealed with a different
+// master key.
+var ErrWrongKey = errors.New("vault: wrong master key")
+
+// A Vault is a file of sealed credentials. It's safe for concurrent use.
+type Vault struct {
+	path   string
+	master nacl.Key
+
+	mu      sync.Mutex
+	tenants map[string]Tenant
+}
+
+// Open reads the vault at path, which is created on the first Put if it
+// doesn't exist.
+func Open(path string, master nacl.Key) (*Vault, error) {
+	v := &Vault{path: path, master: master, tenants: make(map[string]Tenant)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("vault: reading %s: %v", path, err)
+	}
+	check, err := open(f.Check, master)
+	if err != nil || check != checkValue {
+		return nil, ErrWrongKey
+	}
+	for _, t := range f.Tenants {
+		v.tenants[t.Name] = t
+	}
+	return v, nil
+}
+
+// tenantKey derives the key that seals name's password.
+func tenantKey(master nacl.Key, name string) (nacl.Key, error) {
+	b, err := hkdf.Key(sha256.New, master[:], nil, "clipper tenant "+name, nacl.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	key := new([nacl.KeySize]byte)
+	copy(key[:], b)
+	return key, nil
+}
+
+func seal(s string, key nacl.Key) string {
+	return base64.URLEncoding.EncodeToString(secretbox.EasySeal([]byte(s), key))
+}
+
+func open(sealed string, key nacl.Key) (string, error) {
+	data, err := base64.URLEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	b, err := secretbox.EasyOpen(data, key)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Names returns the names of the tenants in the vault, sorted.
+func (v *Vault) Names() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	names := make([]string, 0, len(v.tenants))
+	for name := range v.tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Tenant returns the tenant called name.
+func (v *Vault) Tenant(name string) (Tenant, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	t, ok := v.tenants[name]
+	return t, ok
+}
+
+// Credentials returns the email address and password of the tenant called
+// name.
+func (v *Vault) Credentials(name string) (email, password string, err error) {
+	t, ok := v.Tenant(name)
+	if !ok {
+		return "", "", fmt.Errorf("vault: no tenant named %q", name)
+	}
+	key, err := tenantKey(v.master, name)
+	if err != nil {
+		return "", "", err
+	}
+	password, err = open(t.SealedPassword, key)
+	if err != nil {
+		return "", "", fmt.Errorf("vault: unsealing password for %s: %v", name, err)
+	}
+	return t.Email, password, nil
+}
+
+// Put adds a tenant to the vault, or replaces the one with the same name, and
+// saves the vault. Names are lowercase letters, digits, dashes and
+// underscores, since they're also used as directory names.
+func (v *Vault) Put(name, email, password string, cards []int64) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("vault: invalid tenant name %q; use lowercase letters, digits, - and _", name)
+	}
+	if email == "" || password == "" {
+		return errors.New("vault: a tenant needs an email address and a password")
+	}
+	key, err := tenantKey(v.master, name)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.tenants[name] = Tenant{Name: name, Email: email, SealedPassword: seal(password, key), Cards: cards}
+	return v.save()
+}
+
+// Delete removes the tenant called name and saves the vault.
+func (v *Vault) Delete(name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.tenants[name]; !ok {
+		return fmt.Errorf("vault: no tenant named %q", name)
+	}
+	delete(v.tenants, name)
+	return v.save()
+}
+
+// save writes the vault to a temporary file and renames it into place, so a
+// crash can't leave it half written. v.mu must be held.
+func (v *Vault) save() error {
+	f := file{Check: seal(checkValue, v.master), Tenants: make([]Tenant, 0, len(v.tenants))}
+	for _, t := range v.tenants {
+		f.Tenants = append(f.Tenants, t)
+	}
+	sort.Slice(f.Tenants, func(i, j int) bool { return f.Tenants[i].Name < f.Tenants[j].Name })
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(v.path), ".vault-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), v.path)
+}
@@ -0,0 +1,89 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/nacl"
+)
+
+func TestVault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+	key := nacl.NewKey()
+	v, err := Open(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Put("alice", "alice@example.com", "hunter2", []int64{1202728442}); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Put("bob", "bob@example.com", "hunter2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Put("Bad Name", "x@example.com", "x", nil); err == nil {
+		t.Error("expected an error for an invalid name")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Fatal("vault file contains a password in the clear")
+	}
+	a, _ := v.Tenant("alice")
+	b, _ := v.Tenant("bob")
+	if a.SealedPassword == b.SealedPassword {
+		t.Error("same password sealed the same way for two tenants")
+	}
+
+	v, err = Open(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := v.Names(); len(names) != 2 || names[0] != "alice" {
+		t.Errorf("Names: got %v", names)
+	}
+	email, password, err := v.Credentials("alice")
+	if err != nil || email != "alice@example.com" || password != "hunter2" {
+		t.Errorf("Credentials: got %q %q %v", email, password, err)
+	}
+	if err := v.Delete("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := v.Credentials("bob"); err == nil {
+		t.Error("expected an error for a deleted tenant")
+	}
+	if _, err := Open(path, nacl.NewKey()); err != ErrWrongKey {
+		t.Errorf("Open with the wrong key: got %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var ran []string
+	done := make(chan string, 3)
+	err := Run(context.Background(), []string{"a", "b", "c"}, 2, time.Second, func(ctx context.Context, name string) error {
+		done <- name
+		switch name {
+		case "b":
+			return errors.New("bad password")
+		case "c":
+			panic("boom")
+		}
+		return nil
+	})
+	close(done)
+	for name := range done {
+		ran = append(ran, name)
+	}
+	if len(ran) != 3 {
+		t.Errorf("expected every job to run, got %v", ran)
+	}
+	if err == nil || !strings.Contains(err.Error(), "b: bad password") || !strings.Contains(err.Error(), "c: panic: boom") || strings.Contains(err.Error(), "a:") {
+		t.Errorf("got %v", err)
+	}
+}